@@ -51,6 +51,11 @@ export interface DataLink<T extends DataQuery = any> {
   internal?: InternalDataLink<T>;
 
   origin?: DataLinkConfigOrigin;
+
+  // Transformations extracting the variables of a link that is not internal, internal links have
+  // their transformations in `internal`
+  // @internal and subject to change in future releases
+  transformations?: DataLinkTransformationConfig[];
 }
 
 /**
@@ -62,6 +67,8 @@ export interface DataLink<T extends DataQuery = any> {
 export enum SupportedTransformationType {
   Regex = 'regex',
   Logfmt = 'logfmt',
+  JSONPath = 'jsonpath',
+  Label = 'label',
 }
 
 /** @internal */
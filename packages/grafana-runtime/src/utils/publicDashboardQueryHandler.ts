@@ -4,6 +4,7 @@ import { DataQuery, DataQueryRequest, DataQueryResponse } from '@grafana/data';
 
 import { config } from '../config';
 import { getBackendSrv } from '../services/backendSrv';
+import { getTemplateSrv } from '../services/templateSrv';
 
 import { BackendDataSourceResponse, toDataQueryResponse } from './queryResponse';
 
@@ -30,6 +31,7 @@ export function publicDashboardQueryHandler(request: DataQueryRequest<DataQuery>
       to: toRange.valueOf().toString(),
       timezone: request.timezone,
     },
+    variables: getPublicDashboardVariables(),
   };
 
   return getBackendSrv()
@@ -48,3 +50,20 @@ export function publicDashboardQueryHandler(request: DataQueryRequest<DataQuery>
       })
     );
 }
+
+// Template variables whose values are checked and interpolated by the server
+const supportedVariableTypes = ['custom', 'constant', 'interval', 'query'];
+
+function getPublicDashboardVariables(): Record<string, string | string[]> {
+  const variables: Record<string, string | string[]> = {};
+  for (const variable of getTemplateSrv().getVariables()) {
+    if (!supportedVariableTypes.includes(variable.type) || !('current' in variable) || !variable.current) {
+      continue;
+    }
+    const { value } = variable.current;
+    if (value !== undefined && value !== null) {
+      variables[variable.name] = value;
+    }
+  }
+  return variables;
+}
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
@@ -31,36 +33,14 @@ import (
 // 401: unauthorisedError
 // 500: internalServerError
 func (hs *HTTPServer) GetAnnotations(c *contextmodel.ReqContext) response.Response {
-	query := &annotations.ItemQuery{
-		From:         c.QueryInt64("from"),
-		To:           c.QueryInt64("to"),
-		OrgID:        c.SignedInUser.GetOrgID(),
-		UserID:       c.QueryInt64("userId"),
-		AlertID:      c.QueryInt64("alertId"),
-		DashboardID:  c.QueryInt64("dashboardId"),
-		DashboardUID: c.Query("dashboardUID"),
-		PanelID:      c.QueryInt64("panelId"),
-		Limit:        c.QueryInt64("limit"),
-		Tags:         c.QueryStrings("tags"),
-		Type:         c.Query("type"),
-		MatchAny:     c.QueryBool("matchAny"),
-		SignedInUser: c.SignedInUser,
-	}
-
-	// When dashboard UID present in the request, we ignore dashboard ID
-	if query.DashboardUID != "" {
-		dq := dashboards.GetDashboardQuery{UID: query.DashboardUID, OrgID: c.SignedInUser.GetOrgID()}
-		dqResult, err := hs.DashboardService.GetDashboard(c.Req.Context(), &dq)
-		if err != nil {
-			return response.Error(http.StatusBadRequest, "Invalid dashboard UID in annotation request", err)
-		} else {
-			query.DashboardID = dqResult.ID
-		}
+	query, errResp := hs.annotationsQueryFromRequest(c)
+	if errResp != nil {
+		return errResp
 	}
 
 	items, err := hs.annotationsRepo.Find(c.Req.Context(), query)
 	if err != nil {
-		return response.Error(500, "Failed to get annotations", err)
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get annotations", err)
 	}
 
 	// since there are several annotations per dashboard, we can cache dashboard uid
@@ -87,6 +67,76 @@ func (hs *HTTPServer) GetAnnotations(c *contextmodel.ReqContext) response.Respon
 	return response.JSON(http.StatusOK, items)
 }
 
+// swagger:route GET /annotations/aggregate annotations getAnnotationsAggregate
+//
+// Aggregate Annotations.
+//
+// Counts the annotations matching the filters of the Find Annotations endpoint, per time bucket and per tag or field value.
+//
+// Responses:
+// 200: getAnnotationsAggregateResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) GetAnnotationsAggregate(c *contextmodel.ReqContext) response.Response {
+	itemQuery, errResp := hs.annotationsQueryFromRequest(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	query := &annotations.AggregateQuery{ItemQuery: *itemQuery, GroupBy: c.Query("groupBy")}
+	if interval := c.Query("interval"); interval != "" {
+		if ms, err := strconv.ParseInt(interval, 10, 64); err == nil {
+			query.Interval = ms
+		} else if d, err := gtime.ParseDuration(interval); err == nil {
+			query.Interval = d.Milliseconds()
+		} else {
+			return response.Error(http.StatusBadRequest, "Invalid interval", err)
+		}
+		if query.Interval <= 0 {
+			return response.Error(http.StatusBadRequest, "Invalid interval", nil)
+		}
+	}
+
+	result, err := hs.annotationsRepo.Aggregate(c.Req.Context(), query)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to aggregate annotations", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// annotationsQueryFromRequest returns the annotations query of the filters of a request
+func (hs *HTTPServer) annotationsQueryFromRequest(c *contextmodel.ReqContext) (*annotations.ItemQuery, response.Response) {
+	query := &annotations.ItemQuery{
+		From:         c.QueryInt64("from"),
+		To:           c.QueryInt64("to"),
+		OrgID:        c.SignedInUser.GetOrgID(),
+		UserID:       c.QueryInt64("userId"),
+		AlertID:      c.QueryInt64("alertId"),
+		DashboardID:  c.QueryInt64("dashboardId"),
+		DashboardUID: c.Query("dashboardUID"),
+		PanelID:      c.QueryInt64("panelId"),
+		Limit:        c.QueryInt64("limit"),
+		Tags:         c.QueryStrings("tags"),
+		Type:         c.Query("type"),
+		MatchAny:     c.QueryBool("matchAny"),
+		Fields:       c.QueryStrings("field"),
+		SignedInUser: c.SignedInUser,
+	}
+
+	// When dashboard UID present in the request, we ignore dashboard ID
+	if query.DashboardUID != "" {
+		dq := dashboards.GetDashboardQuery{UID: query.DashboardUID, OrgID: c.SignedInUser.GetOrgID()}
+		dqResult, err := hs.DashboardService.GetDashboard(c.Req.Context(), &dq)
+		if err != nil {
+			return nil, response.Error(http.StatusBadRequest, "Invalid dashboard UID in annotation request", err)
+		} else {
+			query.DashboardID = dqResult.ID
+		}
+	}
+	return query, nil
+}
+
 type AnnotationError struct {
 	message string
 }
@@ -717,6 +767,25 @@ type GetAnnotationsParams struct {
 	// in:query
 	// required:false
 	MatchAny bool `json:"matchAny"`
+	// Filter on the fields of the annotation data, for example `deploy.service=api` or `duration>=30`. You can filter by multiple fields.
+	// in:query
+	// required:false
+	// type: array
+	// collectionFormat: multi
+	Fields []string `json:"field"`
+}
+
+// swagger:parameters getAnnotationsAggregate
+type GetAnnotationsAggregateParams struct {
+	GetAnnotationsParams
+	// Size of the time buckets, in milliseconds or as a duration like `1h`. All the annotations are counted in one bucket when it is not set.
+	// in:query
+	// required:false
+	Interval string `json:"interval"`
+	// Count the annotations per tag with `tag`, or per value of a field with `field:<key>`.
+	// in:query
+	// required:false
+	GroupBy string `json:"groupBy"`
 }
 
 // swagger:parameters getAnnotationTags
@@ -803,6 +872,13 @@ type PostAnnotationResponse struct {
 	} `json:"body"`
 }
 
+// swagger:response getAnnotationsAggregateResponse
+type GetAnnotationsAggregateResponse struct {
+	// The response message
+	// in: body
+	Body annotations.AggregateResult `json:"body"`
+}
+
 // swagger:response getAnnotationTagsResponse
 type GetAnnotationTagsResponse struct {
 	// The response message
@@ -458,12 +458,20 @@ func (hs *HTTPServer) registerRoutes() {
 				dashUidRoute.Get("/versions", authorize(ac.EvalPermission(dashboards.ActionDashboardsWrite)), routing.Wrap(hs.GetDashboardVersions))
 				dashUidRoute.Post("/restore", authorize(ac.EvalPermission(dashboards.ActionDashboardsWrite)), routing.Wrap(hs.RestoreDashboardVersion))
 				dashUidRoute.Get("/versions/:id", authorize(ac.EvalPermission(dashboards.ActionDashboardsWrite)), routing.Wrap(hs.GetDashboardVersion))
+				dashUidRoute.Post("/versions/:id/pin", authorize(ac.EvalPermission(dashboards.ActionDashboardsWrite)), routing.Wrap(hs.PinDashboardVersion))
+				dashUidRoute.Delete("/versions/:id/pin", authorize(ac.EvalPermission(dashboards.ActionDashboardsWrite)), routing.Wrap(hs.UnpinDashboardVersion))
+				dashUidRoute.Post("/versions/:id/labels", authorize(ac.EvalPermission(dashboards.ActionDashboardsWrite)), routing.Wrap(hs.AddDashboardVersionLabel))
+				dashUidRoute.Delete("/versions/:id/labels/:label", authorize(ac.EvalPermission(dashboards.ActionDashboardsWrite)), routing.Wrap(hs.RemoveDashboardVersionLabel))
 				dashUidRoute.Group("/permissions", func(dashboardPermissionRoute routing.RouteRegister) {
 					dashboardPermissionRoute.Get("/", authorize(ac.EvalPermission(dashboards.ActionDashboardsPermissionsRead)), routing.Wrap(hs.GetDashboardPermissionList))
 					dashboardPermissionRoute.Post("/", authorize(ac.EvalPermission(dashboards.ActionDashboardsPermissionsWrite)), routing.Wrap(hs.UpdateDashboardPermissions))
 				})
 			})
 
+			dashboardRoute.Get("/version-retention", reqOrgAdmin, routing.Wrap(hs.GetDashboardVersionRetentionPolicies))
+			dashboardRoute.Put("/version-retention", reqOrgAdmin, routing.Wrap(hs.SaveDashboardVersionRetentionPolicy))
+			dashboardRoute.Delete("/version-retention", reqOrgAdmin, routing.Wrap(hs.DeleteDashboardVersionRetentionPolicy))
+
 			dashboardRoute.Post("/calculate-diff", authorize(ac.EvalPermission(dashboards.ActionDashboardsWrite)), routing.Wrap(hs.CalculateDashboardDiff))
 			dashboardRoute.Post("/validate", authorize(ac.EvalPermission(dashboards.ActionDashboardsWrite)), routing.Wrap(hs.ValidateDashboard))
 
@@ -550,6 +558,7 @@ func (hs *HTTPServer) registerRoutes() {
 			annotationsRoute.Patch("/:annotationId", authorize(ac.EvalPermission(ac.ActionAnnotationsWrite, ac.ScopeAnnotationsID)), routing.Wrap(hs.PatchAnnotation))
 			annotationsRoute.Post("/graphite", authorize(ac.EvalPermission(ac.ActionAnnotationsCreate, ac.ScopeAnnotationsTypeOrganization)), routing.Wrap(hs.PostGraphiteAnnotation))
 			annotationsRoute.Get("/tags", authorize(ac.EvalPermission(ac.ActionAnnotationsRead)), routing.Wrap(hs.GetAnnotationTags))
+			annotationsRoute.Get("/aggregate", authorize(ac.EvalPermission(ac.ActionAnnotationsRead)), routing.Wrap(hs.GetAnnotationsAggregate))
 		})
 
 		apiRoute.Post("/frontend-metrics", routing.Wrap(hs.PostFrontendMetrics))
@@ -569,7 +578,12 @@ func (hs *HTTPServer) registerRoutes() {
 		}, requestmeta.SetSLOGroup(requestmeta.SLOGroupNone))
 
 		// short urls
-		apiRoute.Post("/short-urls", routing.Wrap(hs.createShortURL))
+		apiRoute.Group("/short-urls", func(shortURLRoute routing.RouteRegister) {
+			shortURLRoute.Post("/", routing.Wrap(hs.createShortURL))
+			shortURLRoute.Get("/", routing.Wrap(hs.listShortURLs))
+			shortURLRoute.Get("/search", reqOrgAdmin, routing.Wrap(hs.searchOrgShortURLs))
+			shortURLRoute.Delete("/:uid", routing.Wrap(hs.deleteShortURL))
+		})
 	}, reqSignedIn)
 
 	// admin api
@@ -624,6 +638,9 @@ func (hs *HTTPServer) registerRoutes() {
 
 	// Snapshots
 	r.Post("/api/snapshots/", reqSnapshotPublicModeOrSignedIn, hs.CreateDashboardSnapshot)
+	r.Post("/api/snapshots/capture", reqSignedIn, routing.Wrap(hs.CaptureDashboardSnapshot))
+	r.Post("/api/snapshots/import", reqSignedIn, routing.Wrap(hs.ImportDashboardSnapshot))
+	r.Get("/api/snapshots/:key/export", reqSignedIn, routing.Wrap(hs.ExportDashboardSnapshot))
 	r.Get("/api/snapshot/shared-options/", reqSignedIn, hs.GetSharingOptions)
 	r.Get("/api/snapshots/:key", routing.Wrap(hs.GetDashboardSnapshot))
 	r.Get("/api/snapshots-delete/:deleteKey", reqSnapshotPublicModeOrSignedIn, routing.Wrap(hs.DeleteDashboardSnapshotByDeleteKey))
@@ -8,6 +8,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/dashboardlint"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
 	"github.com/grafana/grafana/pkg/util"
@@ -15,6 +16,11 @@ import (
 
 // ToDashboardErrorResponse returns a different response status according to the dashboard error type
 func ToDashboardErrorResponse(ctx context.Context, pluginStore pluginstore.Store, err error) response.Response {
+	var mergeErr dashboards.DashboardMergeConflictError
+	if ok := errors.As(err, &mergeErr); ok {
+		return response.JSON(http.StatusPreconditionFailed, mergeErr.Body())
+	}
+
 	var dashboardErr dashboards.DashboardErr
 	if ok := errors.As(err, &dashboardErr); ok {
 		if body := dashboardErr.Body(); body != nil {
@@ -35,6 +41,11 @@ func ToDashboardErrorResponse(ctx context.Context, pluginStore pluginstore.Store
 		return response.Error(http.StatusUnprocessableEntity, validationErr.Error(), err)
 	}
 
+	var lintErr *dashboardlint.LintError
+	if ok := errors.As(err, &lintErr); ok {
+		return response.JSON(http.StatusBadRequest, util.DynMap{"status": "lint-failed", "message": lintErr.Error(), "findings": lintErr.Result.Findings})
+	}
+
 	var pluginErr dashboards.UpdatePluginDashboardError
 	if ok := errors.As(err, &pluginErr); ok {
 		message := fmt.Sprintf("The dashboard belongs to plugin %s.", pluginErr.PluginId)
@@ -452,6 +452,162 @@ func (hs *HTTPServer) SearchDashboardSnapshots(c *contextmodel.ReqContext) respo
 	return response.JSON(http.StatusOK, dto)
 }
 
+// swagger:route POST /snapshots/capture snapshots captureDashboardSnapshot
+//
+// Create a snapshot of a stored dashboard. The panel queries are run on the server for the given time range, and the results are stored with the snapshot.
+//
+// Responses:
+// 200: captureDashboardSnapshotResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) CaptureDashboardSnapshot(c *contextmodel.ReqContext) response.Response {
+	if !hs.Cfg.SnapshotEnabled {
+		return response.Error(http.StatusForbidden, "Dashboard Snapshots are disabled", nil)
+	}
+
+	cmd := dashboardsnapshots.CaptureDashboardSnapshotCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.SignedInUser.GetOrgID(), 0, cmd.DashboardUID)
+	if rsp != nil {
+		return rsp
+	}
+	g, err := guardian.NewByDashboard(c.Req.Context(), dash, c.SignedInUser.GetOrgID(), c.SignedInUser)
+	if err != nil {
+		return response.Err(err)
+	}
+	if canView, err := g.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+
+	cmd.OrgID = c.SignedInUser.GetOrgID()
+	cmd.SignedInUser = c.SignedInUser
+	if cmd.Key, err = util.GetRandomString(32); err != nil {
+		return response.Error(http.StatusInternalServerError, "Could not generate random string", err)
+	}
+	if cmd.DeleteKey, err = util.GetRandomString(32); err != nil {
+		return response.Error(http.StatusInternalServerError, "Could not generate random string", err)
+	}
+
+	result, err := hs.dashboardsnapshotsService.CaptureDashboardSnapshot(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to capture snapshot", err)
+	}
+
+	metrics.MApiDashboardSnapshotCreate.Inc()
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"key":         cmd.Key,
+		"deleteKey":   cmd.DeleteKey,
+		"url":         setting.ToAbsUrl("dashboard/snapshot/" + cmd.Key),
+		"deleteUrl":   setting.ToAbsUrl("api/snapshots-delete/" + cmd.DeleteKey),
+		"id":          result.Snapshot.ID,
+		"panelErrors": result.PanelErrors,
+	})
+}
+
+// swagger:route GET /snapshots/{key}/export snapshots exportDashboardSnapshot
+//
+// Export a local snapshot of the current organization as a single file, which can be imported into another Grafana instance.
+//
+// Responses:
+// 200: exportDashboardSnapshotResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) ExportDashboardSnapshot(c *contextmodel.ReqContext) response.Response {
+	if !hs.Cfg.SnapshotEnabled {
+		return response.Error(http.StatusForbidden, "Dashboard Snapshots are disabled", nil)
+	}
+
+	key := web.Params(c.Req)[":key"]
+	snapshot, err := hs.dashboardsnapshotsService.GetDashboardSnapshot(c.Req.Context(), &dashboardsnapshots.GetDashboardSnapshotQuery{Key: key})
+	if err != nil {
+		return response.Err(err)
+	}
+	if snapshot.OrgID != c.SignedInUser.GetOrgID() || snapshot.Expires.Before(time.Now()) {
+		return response.Error(http.StatusNotFound, "Dashboard snapshot not found", nil)
+	}
+	if snapshot.External {
+		return response.Error(http.StatusBadRequest, "External snapshots can't be exported", nil)
+	}
+
+	export := dashboardsnapshots.DashboardSnapshotExport{
+		APIVersion: dashboardsnapshots.DashboardSnapshotExportVersion,
+		Name:       snapshot.Name,
+		Created:    snapshot.Created,
+		Dashboard:  snapshot.Dashboard,
+	}
+	return response.JSONDownload(http.StatusOK, export, fmt.Sprintf("snapshot-%s.json", snapshot.Key))
+}
+
+// swagger:route POST /snapshots/import snapshots importDashboardSnapshot
+//
+// Import a snapshot exported from another Grafana instance. The snapshot gets new keys.
+//
+// Responses:
+// 200: createDashboardSnapshotResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) ImportDashboardSnapshot(c *contextmodel.ReqContext) response.Response {
+	if !hs.Cfg.SnapshotEnabled {
+		return response.Error(http.StatusForbidden, "Dashboard Snapshots are disabled", nil)
+	}
+
+	export := dashboardsnapshots.DashboardSnapshotExport{}
+	if err := web.Bind(c.Req, &export); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if export.APIVersion != dashboardsnapshots.DashboardSnapshotExportVersion {
+		return response.Error(http.StatusBadRequest, fmt.Sprintf("Unsupported snapshot export version %d", export.APIVersion), nil)
+	}
+
+	userID, err := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to import snapshot", err)
+	}
+
+	cmd := dashboardsnapshots.CreateDashboardSnapshotCommand{
+		Dashboard: export.Dashboard,
+		Name:      export.Name,
+		Expires:   c.QueryInt64("expires"),
+		OrgID:     c.SignedInUser.GetOrgID(),
+		UserID:    userID,
+	}
+	if cmd.Name == "" {
+		cmd.Name = "Imported snapshot"
+	}
+	if cmd.Key, err = util.GetRandomString(32); err != nil {
+		return response.Error(http.StatusInternalServerError, "Could not generate random string", err)
+	}
+	if cmd.DeleteKey, err = util.GetRandomString(32); err != nil {
+		return response.Error(http.StatusInternalServerError, "Could not generate random string", err)
+	}
+
+	result, err := hs.dashboardsnapshotsService.CreateDashboardSnapshot(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to import snapshot", err)
+	}
+
+	metrics.MApiDashboardSnapshotCreate.Inc()
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"key":       cmd.Key,
+		"deleteKey": cmd.DeleteKey,
+		"url":       setting.ToAbsUrl("dashboard/snapshot/" + cmd.Key),
+		"deleteUrl": setting.ToAbsUrl("api/snapshots-delete/" + cmd.DeleteKey),
+		"id":        result.ID,
+	})
+}
+
 // swagger:parameters createDashboardSnapshot
 type CreateSnapshotParams struct {
 	// in:body
@@ -459,6 +615,30 @@ type CreateSnapshotParams struct {
 	Body dashboardsnapshots.CreateDashboardSnapshotCommand `json:"body"`
 }
 
+// swagger:parameters captureDashboardSnapshot
+type CaptureSnapshotParams struct {
+	// in:body
+	// required:true
+	Body dashboardsnapshots.CaptureDashboardSnapshotCommand `json:"body"`
+}
+
+// swagger:parameters exportDashboardSnapshot
+type ExportDashboardSnapshotParams struct {
+	// in:path
+	Key string `json:"key"`
+}
+
+// swagger:parameters importDashboardSnapshot
+type ImportSnapshotParams struct {
+	// in:body
+	// required:true
+	Body dashboardsnapshots.DashboardSnapshotExport `json:"body"`
+	// When the imported snapshot should expire in seconds. Default is never to expire.
+	// in:query
+	// required:false
+	Expires int64 `json:"expires"`
+}
+
 // swagger:parameters searchDashboardSnapshots
 type GetSnapshotsParams struct {
 	// Search Query
@@ -503,6 +683,26 @@ type CreateSnapshotResponse struct {
 	} `json:"body"`
 }
 
+// swagger:response captureDashboardSnapshotResponse
+type CaptureSnapshotResponse struct {
+	// in:body
+	Body struct {
+		Key       string `json:"key"`
+		DeleteKey string `json:"deleteKey"`
+		URL       string `json:"url"`
+		DeleteUrl string `json:"deleteUrl"`
+		ID        int64  `json:"id"`
+		// Query errors by panel ID. These panels are stored without data.
+		PanelErrors map[int64]string `json:"panelErrors"`
+	} `json:"body"`
+}
+
+// swagger:response exportDashboardSnapshotResponse
+type ExportSnapshotResponse struct {
+	// in:body
+	Body dashboardsnapshots.DashboardSnapshotExport `json:"body"`
+}
+
 // swagger:response searchDashboardSnapshotsResponse
 type SearchDashboardSnapshotsResponse struct {
 	// in:body
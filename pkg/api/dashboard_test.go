@@ -37,6 +37,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/dashboards/database"
 	"github.com/grafana/grafana/pkg/services/dashboards/service"
+	"github.com/grafana/grafana/pkg/services/dashboardusage/dashboardusagetest"
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 	"github.com/grafana/grafana/pkg/services/dashboardversion/dashvertest"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
@@ -54,6 +55,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/provisioning"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/api"
+	"github.com/grafana/grafana/pkg/services/querylibrary/querylibrarytest"
 	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/star/startest"
 	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
@@ -149,6 +151,7 @@ func TestHTTPServer_GetDashboard_AccessControl(t *testing.T) {
 			hs.Cfg = setting.NewCfg()
 			hs.AccessControl = acimpl.ProvideAccessControl(hs.Cfg)
 			hs.starService = startest.NewStarServiceFake()
+			hs.dashboardUsageService = dashboardusagetest.NewFakeService()
 			hs.dashboardProvisioningService = mockDashboardProvisioningService{}
 
 			guardian.InitAccessControlGuardian(hs.Cfg, hs.AccessControl, hs.DashboardService)
@@ -268,6 +271,7 @@ func TestHTTPServer_DeleteDashboardByUID_AccessControl(t *testing.T) {
 			hs.Cfg = setting.NewCfg()
 			hs.AccessControl = acimpl.ProvideAccessControl(hs.Cfg)
 			hs.starService = startest.NewStarServiceFake()
+			hs.dashboardUsageService = dashboardusagetest.NewFakeService()
 
 			hs.LibraryPanelService = &mockLibraryPanelService{}
 			hs.LibraryElementService = &mockLibraryElementService{}
@@ -321,6 +325,7 @@ func TestHTTPServer_GetDashboardVersions_AccessControl(t *testing.T) {
 			hs.Cfg = setting.NewCfg()
 			hs.AccessControl = acimpl.ProvideAccessControl(hs.Cfg)
 			hs.starService = startest.NewStarServiceFake()
+			hs.dashboardUsageService = dashboardusagetest.NewFakeService()
 
 			hs.dashboardVersionService = &dashvertest.FakeDashboardVersionService{
 				ExpectedListDashboarVersions: []*dashver.DashboardVersionDTO{},
@@ -763,6 +768,7 @@ func TestDashboardAPIEndpoint(t *testing.T) {
 				Features:                     featuremgmt.WithFeatures(),
 				Kinds:                        corekind.NewBase(nil),
 				starService:                  startest.NewStarServiceFake(),
+				dashboardUsageService:        dashboardusagetest.NewFakeService(),
 			}
 			hs.callGetDashboard(sc)
 
@@ -942,6 +948,7 @@ func getDashboardShouldReturn200WithConfig(t *testing.T, sc *scenarioContext, pr
 		Features:                     featuremgmt.WithFeatures(),
 		Kinds:                        corekind.NewBase(nil),
 		starService:                  startest.NewStarServiceFake(),
+		dashboardUsageService:        dashboardusagetest.NewFakeService(),
 	}
 
 	hs.callGetDashboard(sc)
@@ -995,6 +1002,7 @@ func postDashboardScenario(t *testing.T, desc string, url string, routePattern s
 			Features:              featuremgmt.WithFeatures(),
 			Kinds:                 corekind.NewBase(nil),
 			accesscontrolService:  actest.FakeService{},
+			queryLibraryService:   querylibrarytest.NewFakeService(),
 			log:                   log.New("test-logger"),
 		}
 
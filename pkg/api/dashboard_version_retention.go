@@ -0,0 +1,302 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/apierrors"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// getEditableDashboardVersion returns the dashboard and version number of a version route,
+// checking that the user can save the dashboard.
+func (hs *HTTPServer) getEditableDashboardVersion(c *contextmodel.ReqContext) (*dashboards.Dashboard, int, response.Response) {
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.SignedInUser.GetOrgID(), 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return nil, 0, rsp
+	}
+
+	guardian, err := guardian.NewByDashboard(c.Req.Context(), dash, c.SignedInUser.GetOrgID(), c.SignedInUser)
+	if err != nil {
+		return nil, 0, response.Err(err)
+	}
+	if canSave, err := guardian.CanSave(); err != nil || !canSave {
+		return nil, 0, dashboardGuardianResponse(err)
+	}
+
+	version, err := strconv.Atoi(web.Params(c.Req)[":id"])
+	if err != nil {
+		return nil, 0, response.Error(http.StatusBadRequest, "version is invalid", err)
+	}
+	return dash, version, nil
+}
+
+func dashboardVersionErrorResponse(err error) response.Response {
+	switch {
+	case errors.Is(err, dashver.ErrDashboardVersionNotFound), errors.Is(err, dashver.ErrVersionLabelNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, dashver.ErrVersionLabelInvalid):
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, dashver.ErrVersionLabelExists):
+		return response.Error(http.StatusConflict, err.Error(), nil)
+	}
+	return response.Error(http.StatusInternalServerError, "Failed to update dashboard version", err)
+}
+
+// swagger:route POST /dashboards/uid/{uid}/versions/{DashboardVersionID}/pin dashboard_versions pinDashboardVersion
+//
+// Pin a dashboard version so it is never removed by the version cleanup.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) PinDashboardVersion(c *contextmodel.ReqContext) response.Response {
+	return hs.setDashboardVersionPinned(c, true)
+}
+
+// swagger:route DELETE /dashboards/uid/{uid}/versions/{DashboardVersionID}/pin dashboard_versions unpinDashboardVersion
+//
+// Unpin a dashboard version.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) UnpinDashboardVersion(c *contextmodel.ReqContext) response.Response {
+	return hs.setDashboardVersionPinned(c, false)
+}
+
+func (hs *HTTPServer) setDashboardVersionPinned(c *contextmodel.ReqContext, pinned bool) response.Response {
+	dash, version, rsp := hs.getEditableDashboardVersion(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	err := hs.dashboardVersionService.SetPinned(c.Req.Context(), &dashver.SetVersionPinnedCommand{
+		OrgID:       c.SignedInUser.GetOrgID(),
+		DashboardID: dash.ID,
+		Version:     version,
+		Pinned:      pinned,
+	})
+	if err != nil {
+		return dashboardVersionErrorResponse(err)
+	}
+	if pinned {
+		return response.Success("Dashboard version pinned")
+	}
+	return response.Success("Dashboard version unpinned")
+}
+
+// swagger:route POST /dashboards/uid/{uid}/versions/{DashboardVersionID}/labels dashboard_versions addDashboardVersionLabel
+//
+// Add a label to a dashboard version. Labels are unique per dashboard and protect the version from the version cleanup.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 409: conflictError
+// 500: internalServerError
+func (hs *HTTPServer) AddDashboardVersionLabel(c *contextmodel.ReqContext) response.Response {
+	cmd := dtos.AddDashboardVersionLabelCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	dash, version, rsp := hs.getEditableDashboardVersion(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	userID, _ := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
+	err := hs.dashboardVersionService.AddLabel(c.Req.Context(), &dashver.AddVersionLabelCommand{
+		OrgID:       c.SignedInUser.GetOrgID(),
+		DashboardID: dash.ID,
+		Version:     version,
+		Label:       cmd.Label,
+		UserID:      userID,
+	})
+	if err != nil {
+		return dashboardVersionErrorResponse(err)
+	}
+	return response.Success("Dashboard version label added")
+}
+
+// swagger:route DELETE /dashboards/uid/{uid}/versions/{DashboardVersionID}/labels/{label} dashboard_versions removeDashboardVersionLabel
+//
+// Remove a label from a dashboard version.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) RemoveDashboardVersionLabel(c *contextmodel.ReqContext) response.Response {
+	dash, version, rsp := hs.getEditableDashboardVersion(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	err := hs.dashboardVersionService.RemoveLabel(c.Req.Context(), &dashver.RemoveVersionLabelCommand{
+		OrgID:       c.SignedInUser.GetOrgID(),
+		DashboardID: dash.ID,
+		Version:     version,
+		Label:       web.Params(c.Req)[":label"],
+	})
+	if err != nil {
+		return dashboardVersionErrorResponse(err)
+	}
+	return response.Success("Dashboard version label removed")
+}
+
+// swagger:route GET /dashboards/version-retention dashboard_versions getDashboardVersionRetentionPolicies
+//
+// Get the dashboard version retention policies of the org and its folders.
+//
+// Responses:
+// 200: dashboardVersionRetentionPoliciesResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) GetDashboardVersionRetentionPolicies(c *contextmodel.ReqContext) response.Response {
+	policies, err := hs.dashboardVersionService.ListRetentionPolicies(c.Req.Context(), &dashver.ListRetentionPoliciesQuery{OrgID: c.SignedInUser.GetOrgID()})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get retention policies", err)
+	}
+	return response.JSON(http.StatusOK, policies)
+}
+
+// swagger:route PUT /dashboards/version-retention dashboard_versions saveDashboardVersionRetentionPolicy
+//
+// Create or replace the dashboard version retention policy of the org, or of a folder when folderUid is set.
+//
+// Responses:
+// 200: dashboardVersionRetentionPolicyResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) SaveDashboardVersionRetentionPolicy(c *contextmodel.ReqContext) response.Response {
+	cmd := dashver.SaveRetentionPolicyCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.SignedInUser.GetOrgID()
+
+	if cmd.FolderUID != "" {
+		_, err := hs.folderService.Get(c.Req.Context(), &folder.GetFolderQuery{OrgID: cmd.OrgID, UID: &cmd.FolderUID, SignedInUser: c.SignedInUser})
+		if err != nil {
+			return apierrors.ToFolderErrorResponse(err)
+		}
+	}
+
+	policy, err := hs.dashboardVersionService.SaveRetentionPolicy(c.Req.Context(), &cmd)
+	if err != nil {
+		if errors.Is(err, dashver.ErrRetentionPolicyInvalid) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to save retention policy", err)
+	}
+	return response.JSON(http.StatusOK, policy)
+}
+
+// swagger:route DELETE /dashboards/version-retention dashboard_versions deleteDashboardVersionRetentionPolicy
+//
+// Delete the dashboard version retention policy of the org, or of a folder when folderUid is set.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) DeleteDashboardVersionRetentionPolicy(c *contextmodel.ReqContext) response.Response {
+	err := hs.dashboardVersionService.DeleteRetentionPolicy(c.Req.Context(), &dashver.DeleteRetentionPolicyCommand{
+		OrgID:     c.SignedInUser.GetOrgID(),
+		FolderUID: c.Query("folderUid"),
+	})
+	if err != nil {
+		if errors.Is(err, dashver.ErrRetentionPolicyNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to delete retention policy", err)
+	}
+	return response.Success("Retention policy deleted")
+}
+
+// swagger:parameters pinDashboardVersion unpinDashboardVersion
+type PinDashboardVersionParams struct {
+	// in:path
+	DashboardVersionID int64
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+}
+
+// swagger:parameters addDashboardVersionLabel
+type AddDashboardVersionLabelParams struct {
+	// in:body
+	// required:true
+	Body dtos.AddDashboardVersionLabelCommand
+	// in:path
+	DashboardVersionID int64
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+}
+
+// swagger:parameters removeDashboardVersionLabel
+type RemoveDashboardVersionLabelParams struct {
+	// in:path
+	DashboardVersionID int64
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+	// in:path
+	// required:true
+	Label string `json:"label"`
+}
+
+// swagger:parameters saveDashboardVersionRetentionPolicy
+type SaveDashboardVersionRetentionPolicyParams struct {
+	// in:body
+	// required:true
+	Body dashver.SaveRetentionPolicyCommand
+}
+
+// swagger:parameters deleteDashboardVersionRetentionPolicy
+type DeleteDashboardVersionRetentionPolicyParams struct {
+	// in:query
+	// required:false
+	FolderUID string `json:"folderUid"`
+}
+
+// swagger:response dashboardVersionRetentionPoliciesResponse
+type DashboardVersionRetentionPoliciesResponse struct {
+	// in: body
+	Body []*dashver.RetentionPolicy `json:"body"`
+}
+
+// swagger:response dashboardVersionRetentionPolicyResponse
+type DashboardVersionRetentionPolicyResponse struct {
+	// in: body
+	Body *dashver.RetentionPolicy `json:"body"`
+}
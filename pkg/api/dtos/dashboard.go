@@ -71,3 +71,7 @@ type CalculateDiffTarget struct {
 type RestoreDashboardVersionCommand struct {
 	Version int `json:"version" binding:"Required"`
 }
+
+type AddDashboardVersionLabelCommand struct {
+	Label string `json:"label" binding:"Required"`
+}
@@ -78,6 +78,9 @@ type MetricRequest struct {
 	Queries []*simplejson.Json `json:"queries"`
 	// required: false
 	Debug bool `json:"debug"`
+	// DashboardUID is the saved dashboard the queries come from, set by the services running
+	// the queries of a dashboard. The requests of the HTTP API send the X-Dashboard-Uid header.
+	DashboardUID string `json:"-"`
 }
 
 func (mr *MetricRequest) GetUniqueDatasourceTypes() []string {
@@ -1,10 +1,25 @@
 package dtos
 
 type ShortURL struct {
-	UID string `json:"uid"`
-	URL string `json:"url"`
+	UID         string `json:"uid"`
+	URL         string `json:"url"`
+	Path        string `json:"path"`
+	CreatedBy   int64  `json:"createdBy"`
+	CreatedAt   int64  `json:"createdAt"`
+	LastSeenAt  int64  `json:"lastSeenAt"`
+	ExpiresAt   int64  `json:"expiresAt"`
+	AccessCount int64  `json:"accessCount"`
 }
 
 type CreateShortURLCmd struct {
-	Path string `json:"path"`
+	Path      string `json:"path"`
+	UID       string `json:"uid"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+type SearchShortURLsResult struct {
+	ShortURLs  []ShortURL `json:"shortUrls"`
+	TotalCount int64      `json:"totalCount"`
+	Page       int        `json:"page"`
+	PerPage    int        `json:"perPage"`
 }
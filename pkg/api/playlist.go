@@ -1,10 +1,11 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
-	"k8s.io/apimachinery/pkg/api/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
@@ -45,6 +46,15 @@ func (hs *HTTPServer) registerPlaylistAPI(apiRoute routing.RouteRegister) {
 			playlistRoute.Post("/", middleware.ReqEditorRole, routing.Wrap(hs.CreatePlaylist))
 		}
 	})
+
+	apiRoute.Group("/playlist-devices", func(deviceRoute routing.RouteRegister) {
+		deviceRoute.Get("/", routing.Wrap(hs.SearchDisplayDevices))
+		deviceRoute.Get("/:uid", routing.Wrap(hs.GetDisplayDevice))
+		deviceRoute.Get("/:uid/playlist", routing.Wrap(hs.GetDisplayDevicePlaylist))
+		deviceRoute.Delete("/:uid", middleware.ReqEditorRole, routing.Wrap(hs.DeleteDisplayDevice))
+		deviceRoute.Put("/:uid", middleware.ReqEditorRole, routing.Wrap(hs.UpdateDisplayDevice))
+		deviceRoute.Post("/", middleware.ReqEditorRole, routing.Wrap(hs.CreateDisplayDevice))
+	})
 }
 
 func (hs *HTTPServer) validateOrgPlaylist(c *contextmodel.ReqContext) {
@@ -181,6 +191,9 @@ func (hs *HTTPServer) CreatePlaylist(c *contextmodel.ReqContext) response.Respon
 
 	p, err := hs.playlistService.Create(c.Req.Context(), &cmd)
 	if err != nil {
+		if errors.Is(err, playlist.ErrInvalidItemDuration) {
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		}
 		return response.Error(500, "Failed to create playlist", err)
 	}
 
@@ -207,6 +220,9 @@ func (hs *HTTPServer) UpdatePlaylist(c *contextmodel.ReqContext) response.Respon
 
 	_, err := hs.playlistService.Update(c.Req.Context(), &cmd)
 	if err != nil {
+		if errors.Is(err, playlist.ErrInvalidItemDuration) {
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		}
 		return response.Error(500, "Failed to save playlist", err)
 	}
 
@@ -462,7 +478,7 @@ func (pk8s *playlistK8sHandler) getClient(c *contextmodel.ReqContext) (dynamic.R
 
 func (pk8s *playlistK8sHandler) writeError(c *contextmodel.ReqContext, err error) {
 	//nolint:errorlint
-	statusError, ok := err.(*errors.StatusError)
+	statusError, ok := err.(*k8serrors.StatusError)
 	if ok {
 		c.JsonApiErr(int(statusError.Status().Code), statusError.Status().Message, err)
 		return
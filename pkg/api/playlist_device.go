@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/playlist"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /playlist-devices playlists searchDisplayDevices
+//
+// Get display devices.
+//
+// Responses:
+// 200: searchDisplayDevicesResponse
+// 500: internalServerError
+func (hs *HTTPServer) SearchDisplayDevices(c *contextmodel.ReqContext) response.Response {
+	limit := c.QueryInt("limit")
+	if limit == 0 {
+		limit = 1000
+	}
+
+	devices, err := hs.playlistService.SearchDevices(c.Req.Context(), &playlist.GetDisplayDevicesQuery{
+		Name:  c.Query("query"),
+		Limit: limit,
+		OrgId: c.SignedInUser.GetOrgID(),
+	})
+	if err != nil {
+		return response.Error(500, "Search failed", err)
+	}
+
+	return response.JSON(http.StatusOK, devices)
+}
+
+// swagger:route GET /playlist-devices/{uid} playlists getDisplayDevice
+//
+// Get display device.
+//
+// Responses:
+// 200: getDisplayDeviceResponse
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetDisplayDevice(c *contextmodel.ReqContext) response.Response {
+	device, err := hs.playlistService.GetDevice(c.Req.Context(), &playlist.GetDisplayDeviceQuery{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgId: c.SignedInUser.GetOrgID(),
+	})
+	if err != nil {
+		return displayDeviceError(err, "Failed to get display device")
+	}
+
+	return response.JSON(http.StatusOK, device)
+}
+
+// swagger:route GET /playlist-devices/{uid}/playlist playlists getDisplayDevicePlaylist
+//
+// Get the playlist a display device should show now.
+//
+// Responses:
+// 200: getDisplayDevicePlaylistResponse
+// 401: unauthorisedError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetDisplayDevicePlaylist(c *contextmodel.ReqContext) response.Response {
+	result, err := hs.playlistService.GetDevicePlaylist(c.Req.Context(), &playlist.GetDisplayDeviceQuery{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgId: c.SignedInUser.GetOrgID(),
+	})
+	if err != nil {
+		return displayDeviceError(err, "Failed to get display device playlist")
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// swagger:route POST /playlist-devices playlists createDisplayDevice
+//
+// Create display device.
+//
+// Responses:
+// 200: getDisplayDeviceResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) CreateDisplayDevice(c *contextmodel.ReqContext) response.Response {
+	cmd := playlist.CreateDisplayDeviceCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgId = c.SignedInUser.GetOrgID()
+
+	device, err := hs.playlistService.CreateDevice(c.Req.Context(), &cmd)
+	if err != nil {
+		return displayDeviceError(err, "Failed to create display device")
+	}
+
+	return response.JSON(http.StatusOK, device)
+}
+
+// swagger:route PUT /playlist-devices/{uid} playlists updateDisplayDevice
+//
+// Update display device.
+//
+// Responses:
+// 200: getDisplayDeviceResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) UpdateDisplayDevice(c *contextmodel.ReqContext) response.Response {
+	cmd := playlist.UpdateDisplayDeviceCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgId = c.SignedInUser.GetOrgID()
+	cmd.UID = web.Params(c.Req)[":uid"]
+
+	device, err := hs.playlistService.UpdateDevice(c.Req.Context(), &cmd)
+	if err != nil {
+		return displayDeviceError(err, "Failed to save display device")
+	}
+
+	return response.JSON(http.StatusOK, device)
+}
+
+// swagger:route DELETE /playlist-devices/{uid} playlists deleteDisplayDevice
+//
+// Delete display device.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) DeleteDisplayDevice(c *contextmodel.ReqContext) response.Response {
+	cmd := playlist.DeleteDisplayDeviceCommand{UID: web.Params(c.Req)[":uid"], OrgId: c.SignedInUser.GetOrgID()}
+	if err := hs.playlistService.DeleteDevice(c.Req.Context(), &cmd); err != nil {
+		return displayDeviceError(err, "Failed to delete display device")
+	}
+
+	return response.JSON(http.StatusOK, "")
+}
+
+func displayDeviceError(err error, message string) response.Response {
+	switch {
+	case errors.Is(err, playlist.ErrDeviceNotFound):
+		return response.Error(http.StatusNotFound, "Display device not found", err)
+	case errors.Is(err, playlist.ErrInvalidSchedule), errors.Is(err, playlist.ErrPlaylistNotFound):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
+
+// swagger:parameters getDisplayDevice getDisplayDevicePlaylist deleteDisplayDevice
+type DisplayDeviceUIDParams struct {
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+}
+
+// swagger:parameters createDisplayDevice
+type CreateDisplayDeviceParams struct {
+	// in:body
+	// required:true
+	Body playlist.CreateDisplayDeviceCommand
+}
+
+// swagger:parameters updateDisplayDevice
+type UpdateDisplayDeviceParams struct {
+	// in:body
+	// required:true
+	Body playlist.UpdateDisplayDeviceCommand
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+}
+
+// swagger:response searchDisplayDevicesResponse
+type SearchDisplayDevicesResponse struct {
+	// in: body
+	Body []*playlist.DisplayDevice `json:"body"`
+}
+
+// swagger:response getDisplayDeviceResponse
+type GetDisplayDeviceResponse struct {
+	// in: body
+	Body *playlist.DisplayDevice `json:"body"`
+}
+
+// swagger:response getDisplayDevicePlaylistResponse
+type GetDisplayDevicePlaylistResponse struct {
+	// in: body
+	Body *playlist.DisplayDevicePlaylist `json:"body"`
+}
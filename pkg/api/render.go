@@ -79,6 +79,10 @@ func (hs *HTTPServer) RenderToPng(c *contextmodel.ReqContext) {
 		Theme:             models.ThemeDark,
 	}, nil)
 	if err != nil {
+		if errors.Is(err, rendering.ErrInvalidSize) {
+			c.Handle(hs.Cfg, 400, "Render parameters error", err)
+			return
+		}
 		if errors.Is(err, rendering.ErrTimeout) {
 			c.Handle(hs.Cfg, 500, err.Error(), err)
 			return
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
@@ -21,22 +23,91 @@ func (hs *HTTPServer) createShortURL(c *contextmodel.ReqContext) response.Respon
 		return response.Err(shorturls.ErrShortURLBadRequest.Errorf("bad request data: %w", err))
 	}
 	hs.log.Debug("Received request to create short URL", "path", cmd.Path)
-	shortURL, err := hs.ShortURLService.CreateShortURL(c.Req.Context(), c.SignedInUser, cmd.Path)
+	shortURL, err := hs.ShortURLService.CreateShortURL(c.Req.Context(), c.SignedInUser, &shorturls.CreateShortURLCommand{
+		Path:      cmd.Path,
+		UID:       cmd.UID,
+		ExpiresAt: cmd.ExpiresAt,
+	})
 	if err != nil {
 		return response.Err(err)
 	}
 
-	url := fmt.Sprintf("%s/goto/%s?orgId=%d", strings.TrimSuffix(setting.AppUrl, "/"), shortURL.Uid, c.SignedInUser.GetOrgID())
-	c.Logger.Debug("Created short URL", "url", url)
+	dto := shortURLToDTO(shortURL)
+	c.Logger.Debug("Created short URL", "url", dto.URL)
 
-	dto := dtos.ShortURL{
-		UID: shortURL.Uid,
-		URL: url,
+	return response.JSON(http.StatusOK, dto)
+}
+
+// listShortURLs returns the short URLs created by the signed in user.
+func (hs *HTTPServer) listShortURLs(c *contextmodel.ReqContext) response.Response {
+	// API keys are not users, they did not create any short URL
+	if c.SignedInUser.UserID == 0 {
+		return response.JSON(http.StatusOK, dtos.SearchShortURLsResult{ShortURLs: []dtos.ShortURL{}})
+	}
+	userID := c.SignedInUser.UserID
+	return hs.searchShortURLs(c, &userID)
+}
+
+// searchOrgShortURLs returns the short URLs of the organization, optionally filtered by creator.
+func (hs *HTTPServer) searchOrgShortURLs(c *contextmodel.ReqContext) response.Response {
+	var createdBy *int64
+	if c.Query("createdBy") != "" {
+		userID := c.QueryInt64("createdBy")
+		createdBy = &userID
+	}
+	return hs.searchShortURLs(c, createdBy)
+}
+
+func (hs *HTTPServer) searchShortURLs(c *contextmodel.ReqContext, createdBy *int64) response.Response {
+	query := &shorturls.SearchShortURLsQuery{
+		OrgID:     c.SignedInUser.GetOrgID(),
+		CreatedBy: createdBy,
+		Query:     c.Query("query"),
+		Page:      c.QueryInt("page"),
+		Limit:     c.QueryInt("perpage"),
+	}
+	result, err := hs.ShortURLService.SearchShortURLs(c.Req.Context(), query)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	dto := dtos.SearchShortURLsResult{
+		ShortURLs:  make([]dtos.ShortURL, 0, len(result.ShortURLs)),
+		TotalCount: result.TotalCount,
+		Page:       query.Page,
+		PerPage:    query.Limit,
+	}
+	for _, shortURL := range result.ShortURLs {
+		dto.ShortURLs = append(dto.ShortURLs, shortURLToDTO(shortURL))
 	}
 
 	return response.JSON(http.StatusOK, dto)
 }
 
+// deleteShortURL deletes a short URL. Users can delete the short URLs they created, organization
+// admins can delete any short URL of the organization.
+func (hs *HTTPServer) deleteShortURL(c *contextmodel.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+
+	shortURL, err := hs.ShortURLService.GetShortURLByUID(c.Req.Context(), c.SignedInUser, uid)
+	if err != nil {
+		return response.Err(err)
+	}
+	isCreator := c.SignedInUser.UserID != 0 && shortURL.CreatedBy == c.SignedInUser.UserID
+	if !isCreator && !c.SignedInUser.HasRole(org.RoleAdmin) {
+		return response.Err(shorturls.ErrShortURLForbidden.Errorf("user %d cannot delete short URL %s", c.SignedInUser.UserID, uid))
+	}
+
+	if err := hs.ShortURLService.DeleteShortURL(c.Req.Context(), &shorturls.DeleteShortURLByUIDCommand{
+		OrgID: c.SignedInUser.GetOrgID(),
+		UID:   uid,
+	}); err != nil {
+		return response.Err(err)
+	}
+
+	return response.Success("Short URL deleted")
+}
+
 func (hs *HTTPServer) redirectFromShortURL(c *contextmodel.ReqContext) {
 	shortURLUID := web.Params(c.Req)[":uid"]
 
@@ -55,6 +126,11 @@ func (hs *HTTPServer) redirectFromShortURL(c *contextmodel.ReqContext) {
 		return
 	}
 
+	if shortURL.IsExpired(time.Now()) {
+		hs.log.Debug("Not redirecting short URL since expired")
+		return
+	}
+
 	// Failure to update LastSeenAt should still allow to redirect
 	if err := hs.ShortURLService.UpdateLastSeenAt(c.Req.Context(), shortURL); err != nil {
 		hs.log.Error("Failed to update short URL last seen at", "error", err)
@@ -63,3 +139,16 @@ func (hs *HTTPServer) redirectFromShortURL(c *contextmodel.ReqContext) {
 	hs.log.Debug("Redirecting short URL", "path", shortURL.Path)
 	c.Redirect(setting.ToAbsUrl(shortURL.Path), 302)
 }
+
+func shortURLToDTO(shortURL *shorturls.ShortUrl) dtos.ShortURL {
+	return dtos.ShortURL{
+		UID:         shortURL.Uid,
+		URL:         fmt.Sprintf("%s/goto/%s?orgId=%d", strings.TrimSuffix(setting.AppUrl, "/"), shortURL.Uid, shortURL.OrgId),
+		Path:        shortURL.Path,
+		CreatedBy:   shortURL.CreatedBy,
+		CreatedAt:   shortURL.CreatedAt,
+		LastSeenAt:  shortURL.LastSeenAt,
+		ExpiresAt:   shortURL.ExpiresAt,
+		AccessCount: shortURL.AccessCount,
+	}
+}
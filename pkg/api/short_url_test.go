@@ -31,7 +31,7 @@ func TestShortURLAPIEndpoint(t *testing.T) {
 			Path:  cmd.Path,
 		}
 		service := &fakeShortURLService{
-			createShortURLFunc: func(ctx context.Context, user *user.SignedInUser, path string) (*shorturls.ShortUrl, error) {
+			createShortURLFunc: func(ctx context.Context, user *user.SignedInUser, cmd *shorturls.CreateShortURLCommand) (*shorturls.ShortUrl, error) {
 				return createResp, nil
 			},
 		}
@@ -47,6 +47,78 @@ func TestShortURLAPIEndpoint(t *testing.T) {
 				require.Equal(t, fmt.Sprintf("/goto/%s?orgId=%d", createResp.Uid, createResp.OrgId), shortUrl.URL)
 			})
 	})
+
+	t.Run("Given a request for creating a shortUrl with a custom slug and expiry", func(t *testing.T) {
+		cmd := dtos.CreateShortURLCmd{
+			Path:      "d/TxKARsmGz/new-dashboard",
+			UID:       "my-dashboard",
+			ExpiresAt: 1893456000,
+		}
+
+		var received *shorturls.CreateShortURLCommand
+		service := &fakeShortURLService{
+			createShortURLFunc: func(ctx context.Context, user *user.SignedInUser, cmd *shorturls.CreateShortURLCommand) (*shorturls.ShortUrl, error) {
+				received = cmd
+				return &shorturls.ShortUrl{Id: 1, OrgId: testOrgID, Uid: cmd.UID, Path: cmd.Path, ExpiresAt: cmd.ExpiresAt}, nil
+			},
+		}
+
+		createShortURLScenario(t, "When calling POST on", "/api/short-urls", "/api/short-urls", cmd, service,
+			func(sc *scenarioContext) {
+				callCreateShortURL(sc)
+
+				shortUrl := dtos.ShortURL{}
+				err := json.NewDecoder(sc.resp.Body).Decode(&shortUrl)
+				require.NoError(t, err)
+				require.Equal(t, 200, sc.resp.Code)
+				require.Equal(t, &shorturls.CreateShortURLCommand{Path: cmd.Path, UID: cmd.UID, ExpiresAt: cmd.ExpiresAt}, received)
+				require.Equal(t, fmt.Sprintf("/goto/my-dashboard?orgId=%d", testOrgID), shortUrl.URL)
+				require.Equal(t, cmd.ExpiresAt, shortUrl.ExpiresAt)
+			})
+	})
+}
+
+func TestListShortURLs(t *testing.T) {
+	listShortURLs := func(t *testing.T, signedInUser *user.SignedInUser) (*shorturls.SearchShortURLsQuery, dtos.SearchShortURLsResult) {
+		var received *shorturls.SearchShortURLsQuery
+		hs := HTTPServer{
+			Cfg: setting.NewCfg(),
+			ShortURLService: &fakeShortURLService{
+				searchShortURLsFunc: func(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error) {
+					received = query
+					return &shorturls.SearchShortURLsResult{ShortURLs: []*shorturls.ShortUrl{{Uid: "mine", OrgId: testOrgID}}, TotalCount: 1}, nil
+				},
+			},
+			log: log.New("test"),
+		}
+
+		sc := setupScenarioContext(t, "/api/short-urls")
+		sc.defaultHandler = routing.Wrap(func(c *contextmodel.ReqContext) response.Response {
+			sc.context = c
+			sc.context.SignedInUser = signedInUser
+			return hs.listShortURLs(c)
+		})
+		sc.m.Get("/api/short-urls", sc.defaultHandler)
+		sc.fakeReqWithParams("GET", sc.url, map[string]string{}).exec()
+		require.Equal(t, 200, sc.resp.Code)
+
+		result := dtos.SearchShortURLsResult{}
+		require.NoError(t, json.NewDecoder(sc.resp.Body).Decode(&result))
+		return received, result
+	}
+
+	t.Run("Lists the short URLs created by the user", func(t *testing.T) {
+		query, result := listShortURLs(t, &user.SignedInUser{OrgID: testOrgID, UserID: testUserID})
+		require.NotNil(t, query.CreatedBy)
+		require.Equal(t, int64(testUserID), *query.CreatedBy)
+		require.Len(t, result.ShortURLs, 1)
+	})
+
+	t.Run("Lists no short URLs for API keys", func(t *testing.T) {
+		query, result := listShortURLs(t, &user.SignedInUser{OrgID: testOrgID, ApiKeyID: 1})
+		require.Nil(t, query)
+		require.Empty(t, result.ShortURLs)
+	})
 }
 
 func callCreateShortURL(sc *scenarioContext) {
@@ -78,16 +150,17 @@ func createShortURLScenario(t *testing.T, desc string, url string, routePattern
 }
 
 type fakeShortURLService struct {
-	createShortURLFunc func(ctx context.Context, user *user.SignedInUser, path string) (*shorturls.ShortUrl, error)
+	createShortURLFunc  func(ctx context.Context, user *user.SignedInUser, cmd *shorturls.CreateShortURLCommand) (*shorturls.ShortUrl, error)
+	searchShortURLsFunc func(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error)
 }
 
 func (s *fakeShortURLService) GetShortURLByUID(ctx context.Context, user *user.SignedInUser, uid string) (*shorturls.ShortUrl, error) {
 	return nil, nil
 }
 
-func (s *fakeShortURLService) CreateShortURL(ctx context.Context, user *user.SignedInUser, path string) (*shorturls.ShortUrl, error) {
+func (s *fakeShortURLService) CreateShortURL(ctx context.Context, user *user.SignedInUser, cmd *shorturls.CreateShortURLCommand) (*shorturls.ShortUrl, error) {
 	if s.createShortURLFunc != nil {
-		return s.createShortURLFunc(ctx, user, path)
+		return s.createShortURLFunc(ctx, user, cmd)
 	}
 
 	return nil, nil
@@ -97,6 +170,17 @@ func (s *fakeShortURLService) UpdateLastSeenAt(ctx context.Context, shortURL *sh
 	return nil
 }
 
+func (s *fakeShortURLService) SearchShortURLs(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error) {
+	if s.searchShortURLsFunc != nil {
+		return s.searchShortURLsFunc(ctx, query)
+	}
+	return &shorturls.SearchShortURLsResult{}, nil
+}
+
+func (s *fakeShortURLService) DeleteShortURL(ctx context.Context, cmd *shorturls.DeleteShortURLByUIDCommand) error {
+	return nil
+}
+
 func (s *fakeShortURLService) DeleteStaleShortURLs(ctx context.Context, cmd *shorturls.DeleteShortUrlCommand) error {
 	return nil
 }
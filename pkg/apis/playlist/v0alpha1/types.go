@@ -59,7 +59,83 @@ type Item struct {
 	//  dashboards behind the tag will be added to the playlist.
 	//  - dashboard_by_uid: The value is the dashboard UID
 	Value string `json:"value"`
+
+	// Duration overrides the interval of the playlist for this item.
+	// +optional
+	Duration string `json:"duration,omitempty"`
+
+	// TimeFrom and TimeTo override the time range of the dashboards of this item.
+	// +optional
+	TimeFrom string `json:"timeFrom,omitempty"`
+	// +optional
+	TimeTo string `json:"timeTo,omitempty"`
+
+	// Variables override template variable values of the dashboards of this item.
+	// +optional
+	Variables map[string][]string `json:"variables,omitempty"`
 }
 
 // Type of the item.
 type ItemType string
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type DisplayDevice struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object's metadata
+	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DisplayDeviceSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status DisplayDeviceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type DisplayDeviceList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []DisplayDevice `json:"items,omitempty"`
+}
+
+// DisplayDeviceSpec defines model for DisplayDeviceSpec.
+type DisplayDeviceSpec struct {
+	// Name of the device.
+	Title string `json:"title"`
+
+	// The playlist shown when no schedule entry is active.
+	Playlist string `json:"playlist"`
+
+	// Time zone of the schedule. UTC when empty.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+
+	// Entries showing another playlist at some times of the day. The first active entry wins.
+	// +optional
+	Schedule []ScheduleEntry `json:"schedule,omitempty"`
+}
+
+// ScheduleEntry defines model for ScheduleEntry.
+type ScheduleEntry struct {
+	// The playlist shown while the entry is active.
+	Playlist string `json:"playlist"`
+
+	// Days the entry starts on: mon, tue, wed, thu, fri, sat or sun. Every day when empty.
+	// +optional
+	Days []string `json:"days,omitempty"`
+
+	// Start and End are times of the day formatted as 15:04. An entry whose end is before its
+	// start runs past midnight.
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// DisplayDeviceStatus defines model for DisplayDeviceStatus.
+type DisplayDeviceStatus struct {
+	// The last time the device polled for its playlist, in epoch milliseconds.
+	// +optional
+	LastSeenAt int64 `json:"lastSeenAt,omitempty"`
+}
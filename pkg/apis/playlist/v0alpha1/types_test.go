@@ -24,6 +24,8 @@ func TestPlaylistClone(t *testing.T) {
 			Interval: "20s",
 			Items: []Item{
 				{Type: ItemTypeDashboardByTag, Value: "graph-ng"},
+				{Type: ItemTypeDashboardByUid, Value: "noc", Duration: "2m", TimeFrom: "now-24h", TimeTo: "now",
+					Variables: map[string][]string{"env": {"prod"}}},
 			},
 		},
 	}
@@ -35,4 +37,8 @@ func TestPlaylistClone(t *testing.T) {
 	require.NoError(t, err)
 
 	require.JSONEq(t, string(json0), string(json1))
+
+	// the copy does not share the variables of the items
+	copy.(*Playlist).Spec.Items[1].Variables["env"][0] = "dev"
+	require.Equal(t, "prod", src.Spec.Items[1].Variables["env"][0])
 }
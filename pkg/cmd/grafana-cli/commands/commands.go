@@ -225,6 +225,33 @@ grafana-cli user-manager conflicts ingest-file <filepath>
 	},
 }
 
+var dashboardCommands = []*cli.Command{
+	{
+		Name:   "lint",
+		Usage:  "lint <dashboard json file>...",
+		Action: runPluginCommand(dashboardLintCommand),
+	}, {
+		Name:   "diff",
+		Usage:  "diff <base.json> <new.json>, or diff --uid <dashboard uid> <base version> <new version>",
+		Action: runDashboardDiffCommand,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "uid",
+				Usage: "Compare two stored versions of the dashboard with this UID",
+			},
+			&cli.IntFlag{
+				Name:  "org-id",
+				Usage: "The org of the dashboard",
+				Value: 1,
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the changes as JSON",
+			},
+		},
+	},
+}
+
 var Commands = []*cli.Command{
 	{
 		Name:        "plugins",
@@ -236,4 +263,9 @@ var Commands = []*cli.Command{
 		Usage:       "Grafana admin commands",
 		Subcommands: adminCommands,
 	},
+	{
+		Name:        "dashboards",
+		Usage:       "Dashboard tools",
+		Subcommands: dashboardCommands,
+	},
 }
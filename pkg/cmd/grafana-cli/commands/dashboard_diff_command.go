@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
+)
+
+var errDashboardDiffArgs = errors.New("usage: dashboards diff <base.json> <new.json>, or dashboards diff --uid <dashboard uid> <base version> <new version>")
+
+// runDashboardDiffCommand compares two exported dashboard files, or two stored versions
+// when --uid is set. Only the latter needs the database.
+func runDashboardDiffCommand(context *cli.Context) error {
+	if context.String("uid") != "" {
+		return runDbCommand(dashboardVersionDiffCommand)(context)
+	}
+	return runPluginCommand(dashboardFileDiffCommand)(context)
+}
+
+func dashboardFileDiffCommand(c utils.CommandLine) error {
+	args := c.Args().Slice()
+	if len(args) != 2 {
+		return errDashboardDiffArgs
+	}
+
+	dashboards := make([]*simplejson.Json, 0, 2)
+	for _, file := range args {
+		// nolint:gosec
+		// We can ignore the gosec G304 warning since the path comes from the command line
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		dash, err := simplejson.NewJson(b)
+		if err != nil {
+			return fmt.Errorf("%s: invalid dashboard JSON: %w", file, err)
+		}
+		dashboards = append(dashboards, dash)
+	}
+
+	return printDashboardDiff(c, dashboards[0], dashboards[1])
+}
+
+func dashboardVersionDiffCommand(c utils.CommandLine, sqlStore db.DB) error {
+	args := c.Args().Slice()
+	if len(args) != 2 {
+		return errDashboardDiffArgs
+	}
+
+	orgID := int64(c.Int("org-id"))
+	dashboards := make([]*simplejson.Json, 0, 2)
+	for _, arg := range args {
+		version, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", arg, err)
+		}
+		data, err := getDashboardVersionData(sqlStore, orgID, c.String("uid"), version)
+		if err != nil {
+			return err
+		}
+		dashboards = append(dashboards, data)
+	}
+
+	return printDashboardDiff(c, dashboards[0], dashboards[1])
+}
+
+func getDashboardVersionData(sqlStore db.DB, orgID int64, uid string, version int) (*simplejson.Json, error) {
+	var v dashver.DashboardVersion
+	err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		has, err := sess.Where("dashboard.org_id=? AND dashboard.uid=? AND dashboard_version.version=?", orgID, uid, version).
+			Join("INNER", "dashboard", "dashboard.id = dashboard_version.dashboard_id").
+			Get(&v)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return fmt.Errorf("version %d of dashboard %s: %w", version, uid, dashver.ErrDashboardVersionNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.Data, nil
+}
+
+func printDashboardDiff(c utils.CommandLine, base, next *simplejson.Json) error {
+	result, err := dashdiffs.CalculateSemanticDiff(base, next)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		b, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		logger.Info(string(b) + "\n")
+		return nil
+	}
+
+	if len(result.Changes) == 0 {
+		logger.Info("no changes\n")
+		return nil
+	}
+	for _, change := range result.Changes {
+		symbol := color.YellowString("~")
+		switch change.Type {
+		case dashdiffs.SemanticAdded:
+			symbol = color.GreenString("+")
+		case dashdiffs.SemanticRemoved:
+			symbol = color.RedString("-")
+		case dashdiffs.SemanticMoved:
+			symbol = color.CyanString(">")
+		}
+		logger.Infof("%s %s\n", symbol, change.Summary)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboardlint"
+)
+
+var errMissingDashboardFiles = errors.New("missing dashboard files, usage: dashboards lint <file>...")
+
+// dashboardLintCommand lints dashboard JSON files with the built-in rules. Data sources
+// are not checked, since the files are linted without a running Grafana.
+func dashboardLintCommand(c utils.CommandLine) error {
+	files := c.Args().Slice()
+	if len(files) == 0 {
+		return errMissingDashboardFiles
+	}
+
+	linter := dashboardlint.NewLinter(dashboardlint.DefaultRules(), nil)
+	errorCount := 0
+	for _, file := range files {
+		// nolint:gosec
+		// We can ignore the gosec G304 warning since the path comes from the command line
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		dash, err := simplejson.NewJson(b)
+		if err != nil {
+			return fmt.Errorf("%s: invalid dashboard JSON: %w", file, err)
+		}
+
+		result, err := linter.Lint(context.Background(), &dashboardlint.RuleContext{}, dash)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		if len(result.Findings) == 0 {
+			logger.Infof("%s %s\n", file, color.GreenString("ok"))
+			continue
+		}
+		logger.Infof("%s\n", file)
+		for _, f := range result.Findings {
+			severity := color.YellowString(string(f.Severity))
+			if f.Severity == dashboardlint.SeverityError {
+				severity = color.RedString(string(f.Severity))
+			}
+			panel := ""
+			if f.PanelID != nil {
+				panel = fmt.Sprintf(" (panel %d)", *f.PanelID)
+			}
+			logger.Infof("  %s %s: %s%s\n", severity, f.Rule, f.Message, panel)
+		}
+		errorCount += result.Errors
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("found %d lint error(s)", errorCount)
+	}
+	return nil
+}
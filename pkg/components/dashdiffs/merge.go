@@ -0,0 +1,343 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// MergeConflict is an element of the dashboard which was changed differently by both sides.
+type MergeConflict struct {
+	// Path identifies the element, e.g. `title`, `panels[id=2].gridPos` or `templating.list[name=env].query`.
+	Path string `json:"path"`
+	// PanelID is set for conflicts within a panel.
+	PanelID *int64 `json:"panelId,omitempty"`
+	// Variable is set for conflicts within a variable.
+	Variable string `json:"variable,omitempty"`
+	// Ancestor, Current and Incoming are the values in the common ancestor, the stored
+	// dashboard and the dashboard being saved. A missing value means it was removed.
+	Ancestor any `json:"ancestor,omitempty"`
+	Current  any `json:"current,omitempty"`
+	Incoming any `json:"incoming,omitempty"`
+}
+
+// mergeIdentityFields are never merged, the incoming values are kept.
+var mergeIdentityFields = map[string]bool{
+	"id":        true,
+	"uid":       true,
+	"version":   true,
+	"iteration": true,
+}
+
+// MergeDashboards does a three-way merge of two dashboards changed concurrently from a common
+// ancestor. Panels are matched by ID, queries by refId and variables by name, and all other
+// values are merged field by field. Changes made on one side only are applied, and elements
+// changed on both sides are returned as conflicts, in which case the merged dashboard is nil.
+//
+// New panels added on both sides with the same ID are kept, the incoming panel gets a new ID
+// since the current one may already be linked to.
+func MergeDashboards(ancestor, current, incoming *simplejson.Json) (*simplejson.Json, []MergeConflict, error) {
+	base, err := normalizeDashboard(ancestor)
+	if err != nil {
+		return nil, nil, err
+	}
+	ours, err := normalizeDashboard(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	theirs, err := normalizeDashboard(incoming)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := &merger{nextPanelID: maxPanelID(base, ours, theirs) + 1}
+	merged := m.mergeDashboard(base, ours, theirs)
+	if len(m.conflicts) > 0 {
+		return nil, m.conflicts, nil
+	}
+
+	// round trip so the result looks like any other dashboard loaded from JSON
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := simplejson.NewJson(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, nil, nil
+}
+
+type merger struct {
+	conflicts   []MergeConflict
+	nextPanelID int64
+}
+
+// mergeContext describes where in the dashboard a merge happens, for conflict reports.
+type mergeContext struct {
+	path     string
+	panelID  *int64
+	variable string
+}
+
+func (c mergeContext) child(path string) mergeContext {
+	if c.path != "" {
+		path = c.path + "." + path
+	}
+	return mergeContext{path: path, panelID: c.panelID, variable: c.variable}
+}
+
+func (m *merger) conflict(ctx mergeContext, base, ours, theirs any) {
+	m.conflicts = append(m.conflicts, MergeConflict{
+		Path:     ctx.path,
+		PanelID:  ctx.panelID,
+		Variable: ctx.variable,
+		Ancestor: base,
+		Current:  ours,
+		Incoming: theirs,
+	})
+}
+
+func (m *merger) mergeDashboard(base, ours, theirs map[string]any) map[string]any {
+	return m.mergeObject(mergeContext{}, base, ours, theirs, func(ctx mergeContext, key string, b, o, t any) (any, bool) {
+		switch key {
+		case "panels":
+			return m.mergePanels(ctx, b, o, t), true
+		case "templating":
+			bt, _ := b.(map[string]any)
+			ot, _ := o.(map[string]any)
+			tt, ok := t.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			return m.mergeObject(ctx, bt, ot, tt, func(ctx mergeContext, key string, b, o, t any) (any, bool) {
+				if key != "list" {
+					return nil, false
+				}
+				return m.mergeList(ctx, objectList(b), objectList(o), objectList(t), variableKey, m.mergeVariable), true
+			}), true
+		}
+		return nil, false
+	})
+}
+
+func (m *merger) mergePanels(ctx mergeContext, base, ours, theirs any) []any {
+	return m.mergeList(ctx, objectList(base), objectList(ours), objectList(theirs), panelKey, m.mergePanel)
+}
+
+func (m *merger) mergePanel(ctx mergeContext, base, ours, theirs map[string]any) map[string]any {
+	return m.mergeObject(ctx, base, ours, theirs, func(ctx mergeContext, key string, b, o, t any) (any, bool) {
+		switch key {
+		case "panels":
+			return m.mergePanels(ctx, b, o, t), true
+		case "targets":
+			return m.mergeList(ctx, objectList(b), objectList(o), objectList(t), targetKey, func(ctx mergeContext, b, o, t map[string]any) map[string]any {
+				return m.mergeObject(ctx, b, o, t, nil)
+			}), true
+		}
+		return nil, false
+	})
+}
+
+func (m *merger) mergeVariable(ctx mergeContext, base, ours, theirs map[string]any) map[string]any {
+	return m.mergeObject(ctx, base, ours, theirs, nil)
+}
+
+// fieldMerger merges a field with special semantics. It returns false to use the default merge.
+type fieldMerger func(ctx mergeContext, key string, base, ours, theirs any) (any, bool)
+
+// mergeObject merges the fields of an object. Fields are taken from the side which changed them,
+// and changes on both sides are conflicts unless they are identical.
+func (m *merger) mergeObject(ctx mergeContext, base, ours, theirs map[string]any, special fieldMerger) map[string]any {
+	merged := make(map[string]any, len(theirs))
+	keys := make(map[string]bool, len(theirs))
+	for _, obj := range []map[string]any{base, ours, theirs} {
+		for k := range obj {
+			keys[k] = true
+		}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		b, inBase := base[key]
+		o, inOurs := ours[key]
+		t, inTheirs := theirs[key]
+
+		if mergeIdentityFields[key] && ctx.path == "" {
+			if inTheirs {
+				merged[key] = t
+			}
+			continue
+		}
+
+		if special != nil && inOurs && inTheirs {
+			if v, ok := special(ctx.child(key), key, b, o, t); ok {
+				merged[key] = v
+				continue
+			}
+		}
+
+		v, keep, ok := mergeValue(b, inBase, o, inOurs, t, inTheirs)
+		if !ok {
+			m.conflict(ctx.child(key), b, o, t)
+			continue
+		}
+		if keep {
+			merged[key] = v
+		}
+	}
+	return merged
+}
+
+// mergeValue merges a single value. keep is false when the value was removed, and ok is false
+// for conflicting changes.
+func mergeValue(b any, inBase bool, o any, inOurs bool, t any, inTheirs bool) (v any, keep bool, ok bool) {
+	same := func(x any, inX bool, y any, inY bool) bool {
+		return inX == inY && (!inX || reflect.DeepEqual(x, y))
+	}
+	switch {
+	case same(o, inOurs, t, inTheirs):
+		return t, inTheirs, true
+	case same(b, inBase, o, inOurs):
+		// only changed in the incoming dashboard
+		return t, inTheirs, true
+	case same(b, inBase, t, inTheirs):
+		// only changed in the current dashboard
+		return o, inOurs, true
+	}
+	return nil, false, false
+}
+
+type itemKeyFunc func(item map[string]any, index int) string
+
+func panelKey(panel map[string]any, _ int) string {
+	if id, ok := panel["id"].(float64); ok {
+		return fmt.Sprintf("id=%d", int64(id))
+	}
+	return fmt.Sprintf("title=%s", stringField(panel, "title"))
+}
+
+func targetKey(target map[string]any, index int) string {
+	if refID := stringField(target, "refId"); refID != "" {
+		return "refId=" + refID
+	}
+	return fmt.Sprintf("%d", index)
+}
+
+func variableKey(variable map[string]any, _ int) string {
+	return "name=" + stringField(variable, "name")
+}
+
+type itemMerger func(ctx mergeContext, base, ours, theirs map[string]any) map[string]any
+
+// mergeList merges lists of objects identified by key. The result uses the incoming order,
+// followed by the items only added to the current dashboard.
+func (m *merger) mergeList(ctx mergeContext, base, ours, theirs []map[string]any, keyFn itemKeyFunc, mergeItem itemMerger) []any {
+	index := func(items []map[string]any) (map[string]map[string]any, []string) {
+		byKey := make(map[string]map[string]any, len(items))
+		keys := make([]string, 0, len(items))
+		for i, item := range items {
+			k := keyFn(item, i)
+			byKey[k] = item
+			keys = append(keys, k)
+		}
+		return byKey, keys
+	}
+	baseByKey, _ := index(base)
+	oursByKey, oursKeys := index(ours)
+	theirsByKey, theirsKeys := index(theirs)
+
+	merged := make([]any, 0, len(theirs))
+	itemContext := func(key string, item map[string]any) mergeContext {
+		c := mergeContext{path: fmt.Sprintf("%s[%s]", ctx.path, key), panelID: ctx.panelID, variable: ctx.variable}
+		if id, ok := item["id"].(float64); ok && isPanelKey(key) {
+			i := int64(id)
+			c.panelID = &i
+		}
+		if isVariableKey(key) {
+			c.variable = stringField(item, "name")
+		}
+		return c
+	}
+
+	for _, key := range theirsKeys {
+		t := theirsByKey[key]
+		b, inBase := baseByKey[key]
+		o, inOurs := oursByKey[key]
+		c := itemContext(key, t)
+
+		switch {
+		case inBase && inOurs:
+			merged = append(merged, mergeItem(c, b, o, t))
+		case inBase && !inOurs:
+			// removed from the current dashboard, which is fine unless it was changed in the incoming one
+			if !reflect.DeepEqual(b, t) {
+				m.conflict(c, b, nil, t)
+			}
+		case !inBase && !inOurs:
+			merged = append(merged, t)
+		case reflect.DeepEqual(o, t):
+			merged = append(merged, t)
+		case isPanelKey(key):
+			// both sides added a different panel with the same ID
+			merged = append(merged, o, m.withNewPanelID(t))
+		default:
+			merged = append(merged, mergeItem(c, map[string]any{}, o, t))
+		}
+		delete(oursByKey, key)
+	}
+
+	for _, key := range oursKeys {
+		o, ok := oursByKey[key]
+		if !ok {
+			continue
+		}
+		b, inBase := baseByKey[key]
+		if !inBase {
+			merged = append(merged, o)
+			continue
+		}
+		// removed from the incoming dashboard, which is fine unless it was changed in the current one
+		if !reflect.DeepEqual(b, o) {
+			m.conflict(itemContext(key, o), b, o, nil)
+		}
+	}
+	return merged
+}
+
+func isPanelKey(key string) bool {
+	return len(key) > 3 && key[:3] == "id="
+}
+
+func isVariableKey(key string) bool {
+	return len(key) > 5 && key[:5] == "name="
+}
+
+func (m *merger) withNewPanelID(panel map[string]any) map[string]any {
+	copied := make(map[string]any, len(panel))
+	for k, v := range panel {
+		copied[k] = v
+	}
+	copied["id"] = float64(m.nextPanelID)
+	m.nextPanelID++
+	return copied
+}
+
+func maxPanelID(dashboards ...map[string]any) int64 {
+	var maxID int64
+	for _, dash := range dashboards {
+		for _, p := range flattenPanels(dash) {
+			if p.id != nil && *p.id > maxID {
+				maxID = *p.id
+			}
+		}
+	}
+	return maxID
+}
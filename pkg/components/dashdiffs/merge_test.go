@@ -0,0 +1,170 @@
+package dashdiffs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestMergeDashboards(t *testing.T) {
+	const ancestorJSON = `{
+		"id": 1,
+		"version": 3,
+		"title": "Production",
+		"tags": ["prod"],
+		"templating": {"list": [
+			{"name": "env", "type": "custom", "query": "prod,dev"}
+		]},
+		"panels": [
+			{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "cpu"}]},
+			{"id": 2, "title": "Memory", "type": "timeseries"}
+		]
+	}`
+
+	parse := func(t *testing.T, s string) *simplejson.Json {
+		t.Helper()
+		j, err := simplejson.NewJson([]byte(s))
+		require.NoError(t, err)
+		return j
+	}
+	ancestor := parse(t, ancestorJSON)
+
+	t.Run("changes to different elements are merged", func(t *testing.T) {
+		current := parse(t, `{
+			"id": 1,
+			"version": 4,
+			"title": "Production overview",
+			"tags": ["prod"],
+			"templating": {"list": [
+				{"name": "env", "type": "custom", "query": "prod,dev,test"}
+			]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "cpu"}]},
+				{"id": 2, "title": "Memory", "type": "timeseries"},
+				{"id": 3, "title": "Disk", "type": "stat"}
+			]
+		}`)
+		incoming := parse(t, `{
+			"id": 1,
+			"version": 3,
+			"title": "Production",
+			"tags": ["prod", "infra"],
+			"templating": {"list": [
+				{"name": "env", "type": "custom", "query": "prod,dev"},
+				{"name": "host", "type": "query", "query": "hosts()"}
+			]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "sum(cpu)"}, {"refId": "B", "expr": "load"}]},
+				{"id": 3, "title": "Network", "type": "timeseries"}
+			]
+		}`)
+
+		merged, conflicts, err := MergeDashboards(ancestor, current, incoming)
+		require.NoError(t, err)
+		require.Empty(t, conflicts)
+
+		require.Equal(t, "Production overview", merged.Get("title").MustString())
+		require.Equal(t, []string{"prod", "infra"}, merged.Get("tags").MustStringArray())
+		require.Equal(t, 3, merged.Get("version").MustInt())
+
+		vars := merged.Get("templating").Get("list")
+		require.Len(t, vars.MustArray(), 2)
+		require.Equal(t, "prod,dev,test", vars.GetIndex(0).Get("query").MustString())
+		require.Equal(t, "host", vars.GetIndex(1).Get("name").MustString())
+
+		panels := merged.Get("panels")
+		require.Len(t, panels.MustArray(), 3)
+		cpu := panels.GetIndex(0)
+		require.Equal(t, "sum(cpu)", cpu.Get("targets").GetIndex(0).Get("expr").MustString())
+		require.Equal(t, "B", cpu.Get("targets").GetIndex(1).Get("refId").MustString())
+
+		// both sides added a panel with id 3, the incoming one is renumbered
+		require.Equal(t, "Disk", panels.GetIndex(1).Get("title").MustString())
+		require.Equal(t, 3, panels.GetIndex(1).Get("id").MustInt())
+		require.Equal(t, "Network", panels.GetIndex(2).Get("title").MustString())
+		require.Equal(t, 4, panels.GetIndex(2).Get("id").MustInt())
+	})
+
+	t.Run("changes to the same element conflict", func(t *testing.T) {
+		current := parse(t, `{
+			"id": 1, "version": 4, "title": "Production", "tags": ["prod"],
+			"templating": {"list": [{"name": "env", "type": "custom", "query": "prod"}]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "cpu"}]},
+				{"id": 2, "title": "Memory", "type": "stat"}
+			]
+		}`)
+		incoming := parse(t, `{
+			"id": 1, "version": 3, "title": "Production", "tags": ["prod"],
+			"templating": {"list": [{"name": "env", "type": "custom", "query": "dev"}]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "cpu"}]},
+				{"id": 2, "title": "Memory", "type": "gauge"}
+			]
+		}`)
+
+		merged, conflicts, err := MergeDashboards(ancestor, current, incoming)
+		require.NoError(t, err)
+		require.Nil(t, merged)
+		require.Len(t, conflicts, 2)
+
+		require.Equal(t, "panels[id=2].type", conflicts[0].Path)
+		require.Equal(t, int64(2), *conflicts[0].PanelID)
+		require.Equal(t, "timeseries", conflicts[0].Ancestor)
+		require.Equal(t, "stat", conflicts[0].Current)
+		require.Equal(t, "gauge", conflicts[0].Incoming)
+
+		require.Equal(t, "templating.list[name=env].query", conflicts[1].Path)
+		require.Equal(t, "env", conflicts[1].Variable)
+	})
+
+	t.Run("removing an element changed on the other side conflicts", func(t *testing.T) {
+		current := parse(t, `{
+			"id": 1, "version": 4, "title": "Production", "tags": ["prod"],
+			"templating": {"list": [{"name": "env", "type": "custom", "query": "prod,dev"}]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "cpu"}]}
+			]
+		}`)
+		incoming := parse(t, `{
+			"id": 1, "version": 3, "title": "Production", "tags": ["prod"],
+			"templating": {"list": [{"name": "env", "type": "custom", "query": "prod,dev"}]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "cpu"}]},
+				{"id": 2, "title": "Memory usage", "type": "timeseries"}
+			]
+		}`)
+
+		_, conflicts, err := MergeDashboards(ancestor, current, incoming)
+		require.NoError(t, err)
+		require.Len(t, conflicts, 1)
+		require.Equal(t, "panels[id=2]", conflicts[0].Path)
+		require.Nil(t, conflicts[0].Current)
+	})
+
+	t.Run("removing an unchanged element is merged", func(t *testing.T) {
+		current := parse(t, `{
+			"id": 1, "version": 4, "title": "Production", "tags": ["prod"],
+			"templating": {"list": [{"name": "env", "type": "custom", "query": "prod,dev"}]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "cpu"}]}
+			]
+		}`)
+		incoming := parse(t, `{
+			"id": 1, "version": 3, "title": "Production (new)", "tags": ["prod"],
+			"templating": {"list": [{"name": "env", "type": "custom", "query": "prod,dev"}]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "cpu"}]},
+				{"id": 2, "title": "Memory", "type": "timeseries"}
+			]
+		}`)
+
+		merged, conflicts, err := MergeDashboards(ancestor, current, incoming)
+		require.NoError(t, err)
+		require.Empty(t, conflicts)
+		require.Equal(t, "Production (new)", merged.Get("title").MustString())
+		require.Len(t, merged.Get("panels").MustArray(), 1)
+	})
+}
@@ -0,0 +1,433 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// SemanticChangeType is the kind of a semantic change.
+type SemanticChangeType string
+
+const (
+	SemanticAdded    SemanticChangeType = "added"
+	SemanticRemoved  SemanticChangeType = "removed"
+	SemanticModified SemanticChangeType = "modified"
+	SemanticMoved    SemanticChangeType = "moved"
+)
+
+// ChangeTarget is the part of the dashboard model a change applies to.
+type ChangeTarget string
+
+const (
+	TargetDashboard ChangeTarget = "dashboard"
+	TargetPanel     ChangeTarget = "panel"
+	TargetQuery     ChangeTarget = "query"
+	TargetVariable  ChangeTarget = "variable"
+)
+
+// SemanticChange is a single semantic change between two dashboard versions.
+type SemanticChange struct {
+	Type   SemanticChangeType `json:"type"`
+	Target ChangeTarget       `json:"target"`
+	// PanelID and PanelTitle are set for panel and query changes.
+	PanelID    *int64 `json:"panelId,omitempty"`
+	PanelTitle string `json:"panelTitle,omitempty"`
+	// RefID is set for query changes.
+	RefID string `json:"refId,omitempty"`
+	// Variable is set for variable changes.
+	Variable string `json:"variable,omitempty"`
+	// Path is the changed property of the target, e.g. "title", "gridPos" or "datasource".
+	Path    string `json:"path,omitempty"`
+	Old     any    `json:"old,omitempty"`
+	New     any    `json:"new,omitempty"`
+	Summary string `json:"summary"`
+}
+
+type SemanticSummary struct {
+	PanelsAdded      int `json:"panelsAdded"`
+	PanelsRemoved    int `json:"panelsRemoved"`
+	PanelsModified   int `json:"panelsModified"`
+	PanelsMoved      int `json:"panelsMoved"`
+	QueriesChanged   int `json:"queriesChanged"`
+	VariablesChanged int `json:"variablesChanged"`
+	SettingsChanged  int `json:"settingsChanged"`
+}
+
+type SemanticResult struct {
+	Changes []SemanticChange `json:"changes"`
+	Summary SemanticSummary  `json:"summary"`
+}
+
+// dashboardIgnoredFields always differ between versions, or are compared separately.
+var dashboardIgnoredFields = map[string]bool{
+	"id":         true,
+	"version":    true,
+	"iteration":  true,
+	"panels":     true,
+	"rows":       true,
+	"templating": true,
+}
+
+// panelCompareIgnoredFields are compared separately from the other panel fields.
+var panelCompareIgnoredFields = map[string]bool{
+	"id":         true,
+	"gridPos":    true,
+	"targets":    true,
+	"panels":     true,
+	"datasource": true,
+}
+
+// CalculateSemanticDiff compares two dashboards using the dashboard model: panels are
+// matched by ID, queries by refId and variables by name. Unlike CalculateDiff it does
+// not return ErrNilDiff for identical dashboards, the change list is empty instead.
+func CalculateSemanticDiff(baseData, newData *simplejson.Json) (*SemanticResult, error) {
+	base, err := normalizeDashboard(baseData)
+	if err != nil {
+		return nil, err
+	}
+	next, err := normalizeDashboard(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &semanticDiffer{result: &SemanticResult{Changes: make([]SemanticChange, 0)}}
+	d.diffDashboard(base, next)
+	d.diffVariables(variableList(base), variableList(next))
+	d.diffPanels(flattenPanels(base), flattenPanels(next))
+	return d.result, nil
+}
+
+// normalizeDashboard round trips the dashboard through encoding/json, so that values
+// loaded from the database and values sent by clients compare equal.
+func normalizeDashboard(data *simplejson.Json) (map[string]any, error) {
+	if data == nil {
+		return map[string]any{}, nil
+	}
+	b, err := data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	dash := map[string]any{}
+	if err := json.Unmarshal(b, &dash); err != nil {
+		return nil, err
+	}
+	return dash, nil
+}
+
+type semanticDiffer struct {
+	result *SemanticResult
+}
+
+func (d *semanticDiffer) add(c SemanticChange) {
+	d.result.Changes = append(d.result.Changes, c)
+}
+
+func (d *semanticDiffer) diffDashboard(base, next map[string]any) {
+	for _, key := range changedKeys(base, next, dashboardIgnoredFields) {
+		d.result.Summary.SettingsChanged++
+		c := fieldChange(base, next, key)
+		c.Target = TargetDashboard
+		c.Summary = fmt.Sprintf("dashboard %s %s", key, c.Type)
+		d.add(c)
+	}
+}
+
+func (d *semanticDiffer) diffVariables(base, next []map[string]any) {
+	baseByName := make(map[string]map[string]any, len(base))
+	baseIndex := make(map[string]int, len(base))
+	for i, v := range base {
+		name := stringField(v, "name")
+		baseByName[name] = v
+		baseIndex[name] = i
+	}
+	seen := make(map[string]bool, len(next))
+
+	for i, v := range next {
+		name := stringField(v, "name")
+		seen[name] = true
+		old, ok := baseByName[name]
+		if !ok {
+			d.result.Summary.VariablesChanged++
+			d.add(SemanticChange{Type: SemanticAdded, Target: TargetVariable, Variable: name, Summary: fmt.Sprintf("variable %q added", name)})
+			continue
+		}
+
+		changed := false
+		for _, key := range changedKeys(old, v, map[string]bool{"current": true, "options": true}) {
+			c := fieldChange(old, v, key)
+			c.Target = TargetVariable
+			c.Variable = name
+			c.Summary = fmt.Sprintf("variable %q %s %s", name, key, c.Type)
+			d.add(c)
+			changed = true
+		}
+		if baseIndex[name] != i {
+			d.add(SemanticChange{
+				Type: SemanticMoved, Target: TargetVariable, Variable: name, Path: "position",
+				Old: baseIndex[name], New: i,
+				Summary: fmt.Sprintf("variable %q moved from position %d to %d", name, baseIndex[name]+1, i+1),
+			})
+			changed = true
+		}
+		if changed {
+			d.result.Summary.VariablesChanged++
+		}
+	}
+
+	for _, v := range base {
+		name := stringField(v, "name")
+		if !seen[name] {
+			d.result.Summary.VariablesChanged++
+			d.add(SemanticChange{Type: SemanticRemoved, Target: TargetVariable, Variable: name, Summary: fmt.Sprintf("variable %q removed", name)})
+		}
+	}
+}
+
+func (d *semanticDiffer) diffPanels(base, next []panelInfo) {
+	baseByKey := make(map[string]panelInfo, len(base))
+	for _, p := range base {
+		baseByKey[p.key] = p
+	}
+	seen := make(map[string]bool, len(next))
+
+	for _, p := range next {
+		seen[p.key] = true
+		old, ok := baseByKey[p.key]
+		if !ok {
+			d.result.Summary.PanelsAdded++
+			d.add(p.change(SemanticAdded, fmt.Sprintf("%s added", p.describe())))
+			continue
+		}
+		d.diffPanel(old, p)
+	}
+
+	for _, p := range base {
+		if !seen[p.key] {
+			d.result.Summary.PanelsRemoved++
+			d.add(p.change(SemanticRemoved, fmt.Sprintf("%s removed", p.describe())))
+		}
+	}
+}
+
+func (d *semanticDiffer) diffPanel(old, p panelInfo) {
+	moved := false
+	if !reflect.DeepEqual(old.panel["gridPos"], p.panel["gridPos"]) {
+		c := p.change(SemanticMoved, fmt.Sprintf("%s moved", p.describe()))
+		c.Path, c.Old, c.New = "gridPos", old.panel["gridPos"], p.panel["gridPos"]
+		d.add(c)
+		moved = true
+	}
+	if old.row != p.row {
+		c := p.change(SemanticMoved, fmt.Sprintf("%s moved to another row", p.describe()))
+		c.Path, c.Old, c.New = "row", old.row, p.row
+		d.add(c)
+		moved = true
+	}
+	if moved {
+		d.result.Summary.PanelsMoved++
+	}
+
+	modified := false
+	if !reflect.DeepEqual(old.panel["datasource"], p.panel["datasource"]) {
+		c := p.change(SemanticModified, fmt.Sprintf("%s data source changed from %s to %s", p.describe(),
+			describeDataSource(old.panel["datasource"]), describeDataSource(p.panel["datasource"])))
+		c.Path, c.Old, c.New = "datasource", old.panel["datasource"], p.panel["datasource"]
+		d.add(c)
+		modified = true
+	}
+	for _, key := range changedKeys(old.panel, p.panel, panelCompareIgnoredFields) {
+		c := fieldChange(old.panel, p.panel, key)
+		c.Target, c.PanelID, c.PanelTitle = TargetPanel, p.id, p.title()
+		c.Summary = fmt.Sprintf("%s %s %s", p.describe(), key, c.Type)
+		d.add(c)
+		modified = true
+	}
+	if d.diffQueries(old, p) {
+		modified = true
+	}
+	if modified {
+		d.result.Summary.PanelsModified++
+	}
+}
+
+// diffQueries compares panel targets by refId and reports whether any query changed.
+func (d *semanticDiffer) diffQueries(old, p panelInfo) bool {
+	base := objectList(old.panel["targets"])
+	next := objectList(p.panel["targets"])
+	baseByRef := make(map[string]map[string]any, len(base))
+	for _, t := range base {
+		baseByRef[stringField(t, "refId")] = t
+	}
+	seen := make(map[string]bool, len(next))
+
+	changed := false
+	queryChange := func(typ SemanticChangeType, refID string, summary string) SemanticChange {
+		d.result.Summary.QueriesChanged++
+		changed = true
+		return SemanticChange{Type: typ, Target: TargetQuery, PanelID: p.id, PanelTitle: p.title(), RefID: refID, Summary: summary}
+	}
+
+	for _, t := range next {
+		refID := stringField(t, "refId")
+		seen[refID] = true
+		oldTarget, ok := baseByRef[refID]
+		if !ok {
+			d.add(queryChange(SemanticAdded, refID, fmt.Sprintf("query %s of %s added", refID, p.describe())))
+			continue
+		}
+		keys := changedKeys(oldTarget, t, nil)
+		if len(keys) == 0 {
+			continue
+		}
+		c := queryChange(SemanticModified, refID, fmt.Sprintf("query %s of %s changed (%s)", refID, p.describe(), strings.Join(keys, ", ")))
+		if !reflect.DeepEqual(oldTarget["datasource"], t["datasource"]) {
+			c.Path, c.Old, c.New = "datasource", oldTarget["datasource"], t["datasource"]
+			c.Summary = fmt.Sprintf("query %s of %s data source changed from %s to %s", refID, p.describe(),
+				describeDataSource(oldTarget["datasource"]), describeDataSource(t["datasource"]))
+		}
+		d.add(c)
+	}
+
+	for _, t := range base {
+		refID := stringField(t, "refId")
+		if !seen[refID] {
+			d.add(queryChange(SemanticRemoved, refID, fmt.Sprintf("query %s of %s removed", refID, p.describe())))
+		}
+	}
+	return changed
+}
+
+type panelInfo struct {
+	key   string
+	id    *int64
+	row   string
+	panel map[string]any
+}
+
+func (p panelInfo) title() string {
+	return stringField(p.panel, "title")
+}
+
+func (p panelInfo) describe() string {
+	if p.id == nil {
+		return fmt.Sprintf("panel %q", p.title())
+	}
+	return fmt.Sprintf("panel %q (id %d)", p.title(), *p.id)
+}
+
+func (p panelInfo) change(typ SemanticChangeType, summary string) SemanticChange {
+	return SemanticChange{Type: typ, Target: TargetPanel, PanelID: p.id, PanelTitle: p.title(), Summary: summary}
+}
+
+// flattenPanels lists all panels in order, including panels in collapsed rows and old style
+// rows. Panels are keyed by ID, panels without ID by their title.
+func flattenPanels(dash map[string]any) []panelInfo {
+	panels := make([]panelInfo, 0)
+	add := func(panel map[string]any, row string) {
+		info := panelInfo{panel: panel, row: row}
+		if id, ok := panel["id"].(float64); ok {
+			i := int64(id)
+			info.id = &i
+			info.key = fmt.Sprintf("id:%d", i)
+		} else {
+			info.key = "title:" + stringField(panel, "title")
+		}
+		panels = append(panels, info)
+	}
+
+	// panels after an expanded row belong to it until the next row
+	currentRow := ""
+	for _, panel := range objectList(dash["panels"]) {
+		if stringField(panel, "type") == "row" {
+			add(panel, "")
+			currentRow = stringField(panel, "title")
+			for _, child := range objectList(panel["panels"]) {
+				add(child, currentRow)
+			}
+			continue
+		}
+		add(panel, currentRow)
+	}
+	for _, row := range objectList(dash["rows"]) {
+		for _, panel := range objectList(row["panels"]) {
+			add(panel, stringField(row, "title"))
+		}
+	}
+	return panels
+}
+
+func variableList(dash map[string]any) []map[string]any {
+	templating, _ := dash["templating"].(map[string]any)
+	return objectList(templating["list"])
+}
+
+func objectList(v any) []map[string]any {
+	items, _ := v.([]any)
+	objects := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if obj, ok := item.(map[string]any); ok {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+func stringField(obj map[string]any, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
+
+// changedKeys returns the sorted keys whose values differ between the two objects.
+func changedKeys(base, next map[string]any, ignored map[string]bool) []string {
+	keys := make([]string, 0)
+	for k, v := range base {
+		if ignored[k] {
+			continue
+		}
+		if nv, ok := next[k]; !ok || !reflect.DeepEqual(v, nv) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range next {
+		if _, ok := base[k]; !ok && !ignored[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func fieldChange(base, next map[string]any, key string) SemanticChange {
+	oldValue, hadOld := base[key]
+	newValue, hasNew := next[key]
+	c := SemanticChange{Type: SemanticModified, Path: key, Old: oldValue, New: newValue}
+	switch {
+	case !hadOld:
+		c.Type = SemanticAdded
+	case !hasNew:
+		c.Type = SemanticRemoved
+	}
+	return c
+}
+
+func describeDataSource(ref any) string {
+	switch v := ref.(type) {
+	case nil:
+		return "default"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case map[string]any:
+		if uid, ok := v["uid"].(string); ok && uid != "" {
+			return fmt.Sprintf("%q", uid)
+		}
+		if t, ok := v["type"].(string); ok {
+			return fmt.Sprintf("%q", t)
+		}
+	}
+	return fmt.Sprintf("%v", ref)
+}
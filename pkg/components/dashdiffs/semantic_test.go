@@ -0,0 +1,136 @@
+package dashdiffs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestSemanticDiff(t *testing.T) {
+	const (
+		baseJSON = `{
+			"id": 1,
+			"version": 3,
+			"title": "Production",
+			"tags": ["prod"],
+			"templating": {"list": [
+				{"name": "env", "type": "custom", "query": "prod,dev"},
+				{"name": "host", "type": "query", "query": "hosts()", "current": {"value": "a"}},
+				{"name": "old", "type": "constant"}
+			]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+					"datasource": {"uid": "prom"},
+					"targets": [{"refId": "A", "expr": "cpu"}, {"refId": "B", "expr": "load"}]},
+				{"id": 2, "title": "Memory", "type": "timeseries", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}},
+				{"id": 3, "title": "Details", "type": "row", "collapsed": true, "panels": [
+					{"id": 4, "title": "Disk", "type": "stat", "targets": [{"refId": "A", "datasource": {"uid": "prom"}}]}
+				]}
+			]
+		}`
+
+		newJSON = `{
+			"id": 1,
+			"version": 4,
+			"title": "Production overview",
+			"tags": ["prod"],
+			"templating": {"list": [
+				{"name": "host", "type": "query", "query": "hosts()", "current": {"value": "b"}},
+				{"name": "env", "type": "custom", "query": "prod,dev,test"}
+			]},
+			"panels": [
+				{"id": 1, "title": "CPU", "type": "timeseries", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+					"datasource": {"uid": "prom"},
+					"targets": [{"refId": "A", "expr": "sum(cpu)"}, {"refId": "C", "expr": "iowait"}]},
+				{"id": 3, "title": "Details", "type": "row", "collapsed": true, "panels": [
+					{"id": 4, "title": "Disk", "type": "stat", "targets": [{"refId": "A", "datasource": {"uid": "loki"}}]}
+				]},
+				{"id": 5, "title": "Network", "type": "timeseries", "gridPos": {"x": 0, "y": 9, "w": 24, "h": 8}}
+			]
+		}`
+	)
+
+	base, err := simplejson.NewJson([]byte(baseJSON))
+	require.NoError(t, err)
+	next, err := simplejson.NewJson([]byte(newJSON))
+	require.NoError(t, err)
+
+	result, err := CalculateSemanticDiff(base, next)
+	require.NoError(t, err)
+
+	summaries := make([]string, 0, len(result.Changes))
+	for _, c := range result.Changes {
+		summaries = append(summaries, c.Summary)
+	}
+	require.Equal(t, []string{
+		"dashboard title modified",
+		`variable "host" moved from position 2 to 1`,
+		`variable "env" query modified`,
+		`variable "env" moved from position 1 to 2`,
+		`variable "old" removed`,
+		`panel "CPU" (id 1) moved`,
+		`query A of panel "CPU" (id 1) changed (expr)`,
+		`query C of panel "CPU" (id 1) added`,
+		`query B of panel "CPU" (id 1) removed`,
+		`query A of panel "Disk" (id 4) data source changed from "prom" to "loki"`,
+		`panel "Network" (id 5) added`,
+		`panel "Memory" (id 2) removed`,
+	}, summaries)
+
+	require.Equal(t, SemanticSummary{
+		PanelsAdded:      1,
+		PanelsRemoved:    1,
+		PanelsModified:   2,
+		PanelsMoved:      1,
+		QueriesChanged:   4,
+		VariablesChanged: 3,
+		SettingsChanged:  1,
+	}, result.Summary)
+
+	title := result.Changes[0]
+	require.Equal(t, TargetDashboard, title.Target)
+	require.Equal(t, "Production", title.Old)
+	require.Equal(t, "Production overview", title.New)
+
+	moved := result.Changes[5]
+	require.Equal(t, SemanticMoved, moved.Type)
+	require.Equal(t, int64(1), *moved.PanelID)
+	require.Equal(t, "gridPos", moved.Path)
+
+	t.Run("identical dashboards have no changes", func(t *testing.T) {
+		result, err := CalculateSemanticDiff(base, base)
+		require.NoError(t, err)
+		require.Empty(t, result.Changes)
+	})
+
+	t.Run("panel moved into a row", func(t *testing.T) {
+		base, err := simplejson.NewJson([]byte(`{"panels": [
+			{"id": 1, "title": "A", "type": "stat"},
+			{"id": 2, "title": "Row", "type": "row", "panels": []}
+		]}`))
+		require.NoError(t, err)
+		next, err := simplejson.NewJson([]byte(`{"panels": [
+			{"id": 2, "title": "Row", "type": "row", "panels": []},
+			{"id": 1, "title": "A", "type": "stat"}
+		]}`))
+		require.NoError(t, err)
+
+		result, err := CalculateSemanticDiff(base, next)
+		require.NoError(t, err)
+		require.Len(t, result.Changes, 1)
+		require.Equal(t, "row", result.Changes[0].Path)
+		require.Equal(t, "Row", result.Changes[0].New)
+	})
+
+	t.Run("CalculateDiff returns semantic diffs as JSON", func(t *testing.T) {
+		res, err := CalculateDiff(context.Background(), &Options{DiffType: ParseDiffType("semantic")}, base, next)
+		require.NoError(t, err)
+		decoded := SemanticResult{}
+		require.NoError(t, json.Unmarshal(res.Delta, &decoded))
+		require.Len(t, decoded.Changes, len(result.Changes))
+	})
+}
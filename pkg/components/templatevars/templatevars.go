@@ -0,0 +1,268 @@
+// Package templatevars interpolates dashboard template variables in queries run on the server,
+// formatting their values the way the browser does.
+package templatevars
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// AllValue is the value of the All option of a variable.
+const AllValue = "$__all"
+
+// variableRegex matches $var, [[var]], [[var:format]], ${var} and ${var:format}
+var variableRegex = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?::([^\}]+))?\}`)
+
+// SQLDatasourceTypes are the data sources which quote multiple values and escape single values.
+var SQLDatasourceTypes = map[string]bool{
+	"mysql":                         true,
+	"postgres":                      true,
+	"grafana-postgresql-datasource": true,
+	"mssql":                         true,
+}
+
+// data sources that match multiple values with a regular expression
+var regexDatasourceTypes = map[string]bool{
+	"prometheus": true,
+	"loki":       true,
+}
+
+// Variable is a template variable with the values to interpolate.
+type Variable struct {
+	Name string
+	// Values are the selected values, with All expanded to every option.
+	Values []string
+	// Texts are the display texts of the values, used by the text format.
+	Texts []string
+	// Multi is true when the variable can have several values, or includes All.
+	Multi       bool
+	AllSelected bool
+	// AllValue replaces the values when All is selected, if set.
+	AllValue string
+}
+
+// FromDashboard returns the variables of a dashboard with the current values saved with it.
+// Variables without a current value are left out.
+func FromDashboard(dashboard *simplejson.Json) map[string]*Variable {
+	variables := map[string]*Variable{}
+	for _, v := range dashboard.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(v)
+		name := variable.Get("name").MustString()
+		if name == "" {
+			continue
+		}
+		values := stringValues(variable.GetPath("current", "value").Interface())
+		if len(values) == 0 {
+			continue
+		}
+		texts := stringValues(variable.GetPath("current", "text").Interface())
+		if len(texts) != len(values) {
+			texts = values
+		}
+
+		tv := &Variable{
+			Name:     name,
+			Values:   values,
+			Texts:    texts,
+			Multi:    variable.Get("multi").MustBool() || variable.Get("includeAll").MustBool(),
+			AllValue: variable.Get("allValue").MustString(),
+		}
+		if len(values) == 1 && values[0] == AllValue {
+			tv.AllSelected = true
+			tv.Values, tv.Texts = nil, nil
+			for _, option := range variable.Get("options").MustArray() {
+				o := simplejson.NewFromAny(option)
+				value := o.Get("value").MustString()
+				if value == "" || value == AllValue {
+					continue
+				}
+				tv.Values = append(tv.Values, value)
+				tv.Texts = append(tv.Texts, o.Get("text").MustString(value))
+			}
+		}
+		variables[name] = tv
+	}
+	return variables
+}
+
+func stringValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// ReferencedVariables returns the names of the variables used in the strings of a query.
+func ReferencedVariables(value any) []string {
+	var names []string
+	walkQueryStrings(value, func(s string) string {
+		for _, match := range variableRegex.FindAllStringSubmatch(s, -1) {
+			names = append(names, firstNonEmpty(match[1], match[2], match[4]))
+		}
+		return s
+	})
+	return names
+}
+
+// InterpolateQuery replaces the template variables in the strings of a query with their values,
+// formatted for its data source. Unknown variables are left as they are.
+func InterpolateQuery(query *simplejson.Json, variables map[string]*Variable) {
+	if len(variables) == 0 {
+		return
+	}
+
+	datasourceType := query.Get("datasource").Get("type").MustString()
+	for key, value := range query.MustMap() {
+		// the data source and refId identify the query, they are never interpolated
+		if key == "datasource" || key == "refId" {
+			continue
+		}
+		query.Set(key, walkQueryStrings(value, func(s string) string {
+			return InterpolateString(s, variables, datasourceType)
+		}))
+	}
+}
+
+// InterpolateString replaces the template variables in s with their values, formatted for the
+// data source type.
+func InterpolateString(s string, variables map[string]*Variable, datasourceType string) string {
+	return variableRegex.ReplaceAllStringFunc(s, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+		name := firstNonEmpty(groups[1], groups[2], groups[4])
+		format := firstNonEmpty(groups[3], groups[5])
+
+		variable, ok := variables[name]
+		if !ok {
+			return match
+		}
+		return Format(variable, format, datasourceType)
+	})
+}
+
+func walkQueryStrings(value any, fn func(string) string) any {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		for key, item := range v {
+			v[key] = walkQueryStrings(item, fn)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = walkQueryStrings(item, fn)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// Format formats the values of a variable like the browser does: with the format of the
+// variable expression, such as ${var:csv}, or the default format of the data source.
+func Format(variable *Variable, format string, datasourceType string) string {
+	if variable.AllSelected && variable.AllValue != "" {
+		return variable.AllValue
+	}
+
+	values := variable.Values
+	switch format {
+	case "raw":
+		return strings.Join(values, ",")
+	case "csv":
+		return strings.Join(values, ",")
+	case "pipe":
+		return strings.Join(values, "|")
+	case "regex":
+		return regexFormat(values)
+	case "glob":
+		return globFormat(values)
+	case "json":
+		body, _ := json.Marshal(values)
+		return string(body)
+	case "singlequote":
+		return quoteEach(values, "'", `\'`)
+	case "doublequote":
+		return quoteEach(values, `"`, `\"`)
+	case "sqlstring":
+		return quoteEach(values, "'", "''")
+	case "text":
+		texts := variable.Texts
+		if len(texts) != len(values) {
+			texts = values
+		}
+		return strings.Join(texts, " + ")
+	case "queryparam":
+		params := url.Values{}
+		for _, value := range values {
+			params.Add("var-"+variable.Name, value)
+		}
+		return params.Encode()
+	}
+
+	switch {
+	case SQLDatasourceTypes[datasourceType]:
+		if variable.Multi {
+			return quoteEach(values, "'", "''")
+		}
+		return strings.ReplaceAll(strings.Join(values, ","), "'", "''")
+	case regexDatasourceTypes[datasourceType]:
+		if variable.Multi {
+			return regexFormat(values)
+		}
+		return strings.NewReplacer(`\`, `\\`, `'`, `\\'`).Replace(strings.Join(values, ","))
+	default:
+		if len(values) > 1 {
+			return globFormat(values)
+		}
+		return strings.Join(values, ",")
+	}
+}
+
+func regexFormat(values []string) string {
+	escaped := make([]string, 0, len(values))
+	for _, value := range values {
+		escaped = append(escaped, regexp.QuoteMeta(value))
+	}
+	if len(escaped) == 1 {
+		return escaped[0]
+	}
+	return "(" + strings.Join(escaped, "|") + ")"
+}
+
+func globFormat(values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return "{" + strings.Join(values, ",") + "}"
+}
+
+func quoteEach(values []string, quote string, escapedQuote string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, quote+strings.ReplaceAll(value, quote, escapedQuote)+quote)
+	}
+	return strings.Join(quoted, ",")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package templatevars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestFormat(t *testing.T) {
+	variable := &Variable{
+		Name:   "var",
+		Values: []string{"o'ne", "two.*"},
+		Texts:  []string{"One", "Two"},
+		Multi:  true,
+	}
+
+	testCases := []struct {
+		format         string
+		datasourceType string
+		expected       string
+	}{
+		{format: "", datasourceType: "mysql", expected: `'o''ne','two.*'`},
+		{format: "", datasourceType: "prometheus", expected: `(o'ne|two\.\*)`},
+		{format: "", datasourceType: "elasticsearch", expected: `{o'ne,two.*}`},
+		{format: "csv", expected: `o'ne,two.*`},
+		{format: "pipe", expected: `o'ne|two.*`},
+		{format: "json", expected: `["o'ne","two.*"]`},
+		{format: "singlequote", expected: `'o\'ne','two.*'`},
+		{format: "doublequote", expected: `"o'ne","two.*"`},
+		{format: "sqlstring", expected: `'o''ne','two.*'`},
+		{format: "text", expected: `One + Two`},
+		{format: "queryparam", expected: `var-var=o%27ne&var-var=two.%2A`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.format+tc.datasourceType, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Format(variable, tc.format, tc.datasourceType))
+		})
+	}
+
+	t.Run("uses the custom all value", func(t *testing.T) {
+		all := &Variable{Name: "var", Multi: true, AllSelected: true, AllValue: ".*", Values: []string{"a"}}
+		assert.Equal(t, ".*", Format(all, "", "prometheus"))
+	})
+}
+
+func TestFromDashboard(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"templating": {"list": [
+			{"name": "host", "type": "custom", "current": {"text": "Web", "value": "web"}},
+			{"name": "env", "type": "query", "multi": true, "current": {"text": ["Prod", "Dev"], "value": ["prod", "dev"]}},
+			{"name": "region", "type": "query", "includeAll": true, "current": {"text": "All", "value": "$__all"},
+			 "options": [{"text": "All", "value": "$__all"}, {"text": "EU", "value": "eu"}, {"text": "US", "value": "us"}]},
+			{"name": "empty", "type": "textbox"}
+		]}
+	}`))
+	require.NoError(t, err)
+
+	variables := FromDashboard(dashboard)
+	require.Len(t, variables, 3)
+	assert.Equal(t, &Variable{Name: "host", Values: []string{"web"}, Texts: []string{"Web"}}, variables["host"])
+	assert.Equal(t, []string{"prod", "dev"}, variables["env"].Values)
+	assert.True(t, variables["region"].AllSelected)
+	assert.Equal(t, []string{"eu", "us"}, variables["region"].Values)
+	assert.Equal(t, []string{"EU", "US"}, variables["region"].Texts)
+}
+
+func TestInterpolateQuery(t *testing.T) {
+	variables := map[string]*Variable{
+		"host": {Name: "host", Values: []string{"web"}},
+		"env":  {Name: "env", Values: []string{"prod", "dev"}, Multi: true},
+	}
+
+	query := simplejson.NewFromAny(map[string]any{
+		"refId":      "$host",
+		"datasource": map[string]any{"uid": "$host", "type": "prometheus"},
+		"expr":       `up{host="$host", env=~"${env}", other="$other"}`,
+		"nested":     []any{map[string]any{"legend": "[[host]] ${env:csv}"}},
+	})
+	InterpolateQuery(query, variables)
+
+	assert.Equal(t, "$host", query.Get("refId").MustString(), "the refId is not interpolated")
+	assert.Equal(t, "$host", query.GetPath("datasource", "uid").MustString(), "the data source is not interpolated")
+	assert.Equal(t, `up{host="web", env=~"(prod|dev)", other="$other"}`, query.Get("expr").MustString())
+	assert.Equal(t, "web prod,dev", query.Get("nested").GetIndex(0).Get("legend").MustString())
+	assert.ElementsMatch(t, []string{"host", "env", "other"}, ReferencedVariables(map[string]any{"expr": `$host ${env} [[other]]`}))
+}
@@ -65,8 +65,14 @@ type Service struct {
 
 	tracer  tracing.Tracer
 	metrics *metrics
+
+	observers []QueryObserver
 }
 
+// QueryObserver is called after each query to a data source run by the expression service,
+// with the duration of the query.
+type QueryObserver func(ctx context.Context, req *backend.QueryDataRequest, resp *backend.QueryDataResponse, err error, duration time.Duration)
+
 type pluginContextProvider interface {
 	Get(ctx context.Context, pluginID string, user identity.Requester, orgID int64) (backend.PluginContext, error)
 	GetWithDataSource(ctx context.Context, pluginID string, user identity.Requester, ds *datasources.DataSource) (backend.PluginContext, error)
@@ -92,6 +98,22 @@ func (s *Service) isDisabled() bool {
 	return !s.cfg.ExpressionsEnabled
 }
 
+// ObserveQueries registers an observer of the data source queries. Observers must be registered
+// before the service runs queries.
+func (s *Service) ObserveQueries(o QueryObserver) {
+	s.observers = append(s.observers, o)
+}
+
+// queryData queries a data source and notifies the observers.
+func (s *Service) queryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	start := time.Now()
+	resp, err := s.dataService.QueryData(ctx, req)
+	for _, o := range s.observers {
+		o(ctx, req, resp, err, time.Since(start))
+	}
+	return resp, err
+}
+
 // BuildPipeline builds a pipeline from a request.
 func (s *Service) BuildPipeline(req *Request) (DataPipeline, error) {
 	return s.buildPipeline(req)
@@ -0,0 +1,128 @@
+package remotecache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const memoryCacheType = "memory"
+
+// memoryGCInterval is the number of writes between removals of the expired items
+const memoryGCInterval = 1000
+
+// defaultMemoryMaxSize is the total size of the items kept in memory, in bytes, when the options do not set it
+const defaultMemoryMaxSize = 100 * 1024 * 1024
+
+type memoryItem struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// memoryStorage keeps the items in the memory of the Grafana process. It is only shared by
+// a single instance, and the items are lost on restart. When the total size of the items
+// exceeds maxSize, the least recently used items are removed.
+type memoryStorage struct {
+	mu      sync.Mutex
+	items   map[string]*list.Element
+	lru     *list.List // most recently used first
+	size    int
+	maxSize int
+	writes  int
+}
+
+func newMemoryStorage(maxSize int) *memoryStorage {
+	if maxSize <= 0 {
+		maxSize = defaultMemoryMaxSize
+	}
+	return &memoryStorage{items: map[string]*list.Element{}, lru: list.New(), maxSize: maxSize}
+}
+
+func (s *memoryStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[key]
+	if !ok {
+		return nil, ErrCacheItemNotFound
+	}
+	item := e.Value.(*memoryItem)
+	if item.expired(getTime()) {
+		return nil, ErrCacheItemNotFound
+	}
+	s.lru.MoveToFront(e)
+	return item.value, nil
+}
+
+func (s *memoryStorage) Set(ctx context.Context, key string, value []byte, expire time.Duration) error {
+	if expire == 0 {
+		expire = defaultMaxCacheExpiration
+	}
+	now := getTime()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[key]; ok {
+		s.remove(e)
+	}
+	item := &memoryItem{key: key, value: value, expires: now.Add(expire)}
+	if item.size() > s.maxSize {
+		return nil // would evict everything else, and then itself
+	}
+	s.items[key] = s.lru.PushFront(item)
+	s.size += item.size()
+
+	s.writes++
+	if s.writes >= memoryGCInterval {
+		s.writes = 0
+		for _, e := range s.items {
+			if e.Value.(*memoryItem).expired(now) {
+				s.remove(e)
+			}
+		}
+	}
+	for s.size > s.maxSize {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+func (s *memoryStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[key]; ok {
+		s.remove(e)
+	}
+	return nil
+}
+
+func (s *memoryStorage) Count(ctx context.Context, prefix string) (int64, error) {
+	now := getTime()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for key, e := range s.items {
+		if strings.HasPrefix(key, prefix) && !e.Value.(*memoryItem).expired(now) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// remove must be called with the lock held.
+func (s *memoryStorage) remove(e *list.Element) {
+	item := s.lru.Remove(e).(*memoryItem)
+	delete(s.items, item.key)
+	s.size -= item.size()
+}
+
+func (i *memoryItem) expired(now time.Time) bool {
+	return !now.Before(i.expires)
+}
+
+func (i *memoryItem) size() int {
+	return len(i.key) + len(i.value)
+}
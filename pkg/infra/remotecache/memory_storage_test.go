@@ -0,0 +1,91 @@
+package remotecache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	t.Cleanup(func() { getTime = time.Now })
+	now := time.Now()
+	getTime = func() time.Time { return now }
+
+	s := newMemoryStorage(0)
+	ctx := context.Background()
+
+	require.NoError(t, s.Set(ctx, "a:1", []byte("one"), time.Minute))
+	require.NoError(t, s.Set(ctx, "a:2", []byte("two"), time.Hour))
+	require.NoError(t, s.Set(ctx, "b:1", []byte("three"), time.Hour))
+
+	value, err := s.Get(ctx, "a:1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("one"), value)
+
+	count, err := s.Count(ctx, "a:")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	now = now.Add(2 * time.Minute)
+	_, err = s.Get(ctx, "a:1")
+	require.ErrorIs(t, err, ErrCacheItemNotFound)
+	count, err = s.Count(ctx, "a:")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	require.NoError(t, s.Delete(ctx, "a:2"))
+	_, err = s.Get(ctx, "a:2")
+	require.ErrorIs(t, err, ErrCacheItemNotFound)
+}
+
+func TestMemoryStorageGarbageCollection(t *testing.T) {
+	t.Cleanup(func() { getTime = time.Now })
+	now := time.Now()
+	getTime = func() time.Time { return now }
+
+	s := newMemoryStorage(0)
+	ctx := context.Background()
+	require.NoError(t, s.Set(ctx, "expired", []byte("x"), time.Second))
+
+	now = now.Add(time.Minute)
+	for i := 0; i < memoryGCInterval; i++ {
+		require.NoError(t, s.Set(ctx, "key", []byte("x"), time.Hour))
+	}
+	require.NotContains(t, s.items, "expired")
+	require.Contains(t, s.items, "key")
+	require.Equal(t, len("key")+len("x"), s.size)
+}
+
+func TestMemoryStorageEviction(t *testing.T) {
+	s := newMemoryStorage(30)
+	ctx := context.Background()
+
+	require.NoError(t, s.Set(ctx, "a", make([]byte, 9), time.Hour))
+	require.NoError(t, s.Set(ctx, "b", make([]byte, 9), time.Hour))
+	require.NoError(t, s.Set(ctx, "c", make([]byte, 9), time.Hour))
+
+	// reading "a" makes "b" the least recently used item
+	_, err := s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NoError(t, s.Set(ctx, "d", make([]byte, 9), time.Hour))
+	_, err = s.Get(ctx, "b")
+	require.ErrorIs(t, err, ErrCacheItemNotFound)
+	for _, key := range []string{"a", "c", "d"} {
+		_, err := s.Get(ctx, key)
+		require.NoError(t, err, key)
+	}
+	require.Equal(t, 30, s.size)
+
+	// replacing an item does not count it twice
+	require.NoError(t, s.Set(ctx, "a", make([]byte, 4), time.Hour))
+	require.Equal(t, 25, s.size)
+	require.Len(t, s.items, 3)
+
+	// items larger than the cache are not stored
+	require.NoError(t, s.Set(ctx, "large", make([]byte, 30), time.Hour))
+	_, err = s.Get(ctx, "large")
+	require.ErrorIs(t, err, ErrCacheItemNotFound)
+	require.Len(t, s.items, 3)
+}
@@ -119,6 +119,12 @@ func (ds *RemoteCache) Run(ctx context.Context) error {
 	return ctx.Err()
 }
 
+// NewCacheStorage creates a cache storage of the type of the options, with the prefix and
+// encryption of the options, for services that need a cache separate from the remote cache.
+func NewCacheStorage(opts *setting.RemoteCacheOptions, sqlstore db.DB, secretsService secrets.Service) (CacheStorage, error) {
+	return createClient(opts, sqlstore, secretsService)
+}
+
 func createClient(opts *setting.RemoteCacheOptions, sqlstore db.DB, secretsService secrets.Service) (cache CacheStorage, err error) {
 	switch opts.Name {
 	case redisCacheType:
@@ -127,6 +133,8 @@ func createClient(opts *setting.RemoteCacheOptions, sqlstore db.DB, secretsServi
 		cache = newMemcachedStorage(opts)
 	case databaseCacheType:
 		cache = newDatabaseCache(sqlstore)
+	case memoryCacheType:
+		cache = newMemoryStorage(opts.MemoryMaxSize)
 	default:
 		return nil, ErrInvalidCacheType
 	}
@@ -17,12 +17,25 @@ import (
 )
 
 func LegacyUpdateCommandToUnstructured(cmd playlistsvc.UpdatePlaylistCommand) unstructured.Unstructured {
-	items := []map[string]string{}
+	items := []map[string]any{}
 	for _, item := range cmd.Items {
-		items = append(items, map[string]string{
+		v := map[string]any{
 			"type":  item.Type,
 			"value": item.Value,
-		})
+		}
+		if item.Duration != "" {
+			v["duration"] = item.Duration
+		}
+		if item.TimeFrom != "" {
+			v["timeFrom"] = item.TimeFrom
+		}
+		if item.TimeTo != "" {
+			v["timeTo"] = item.TimeTo
+		}
+		if len(item.Variables) > 0 {
+			v["variables"] = item.Variables
+		}
+		items = append(items, v)
 	}
 	obj := unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -72,8 +85,12 @@ func convertToK8sResource(v *playlistsvc.PlaylistDTO, namespacer request.Namespa
 	}
 	for _, item := range v.Items {
 		spec.Items = append(spec.Items, playlist.Item{
-			Type:  playlist.ItemType(item.Type),
-			Value: item.Value,
+			Type:      playlist.ItemType(item.Type),
+			Value:     item.Value,
+			Duration:  item.Duration,
+			TimeFrom:  item.TimeFrom,
+			TimeTo:    item.TimeTo,
+			Variables: item.Variables,
 		})
 	}
 
@@ -111,13 +128,73 @@ func convertToLegacyUpdateCommand(p *playlist.Playlist, orgId int64) (*playlists
 			return nil, fmt.Errorf("unsupported item type: %s", item.Type)
 		}
 		cmd.Items = append(cmd.Items, playlistsvc.PlaylistItem{
-			Type:  string(item.Type),
-			Value: item.Value,
+			Type:      string(item.Type),
+			Value:     item.Value,
+			Duration:  item.Duration,
+			TimeFrom:  item.TimeFrom,
+			TimeTo:    item.TimeTo,
+			Variables: item.Variables,
 		})
 	}
 	return cmd, nil
 }
 
+func convertDeviceToK8sResource(v *playlistsvc.DisplayDevice, namespacer request.NamespaceMapper) *playlist.DisplayDevice {
+	spec := playlist.DisplayDeviceSpec{
+		Title:    v.Name,
+		Playlist: v.PlaylistUID,
+		Timezone: v.Timezone,
+	}
+	for _, entry := range v.Schedule {
+		spec.Schedule = append(spec.Schedule, playlist.ScheduleEntry{
+			Playlist: entry.PlaylistUID,
+			Days:     entry.Days,
+			Start:    entry.Start,
+			End:      entry.End,
+		})
+	}
+
+	meta := kinds.GrafanaResourceMetadata{}
+	meta.SetUpdatedTimestampMillis(v.UpdatedAt)
+	if v.Id > 0 {
+		meta.SetOriginInfo(&kinds.ResourceOriginInfo{
+			Name: "SQL",
+			Key:  fmt.Sprintf("%d", v.Id),
+		})
+	}
+	return &playlist.DisplayDevice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              v.UID,
+			UID:               types.UID(v.UID),
+			ResourceVersion:   fmt.Sprintf("%d", v.UpdatedAt),
+			CreationTimestamp: metav1.NewTime(time.UnixMilli(v.CreatedAt)),
+			Namespace:         namespacer(v.OrgId),
+			Annotations:       meta.Annotations,
+		},
+		Spec:   spec,
+		Status: playlist.DisplayDeviceStatus{LastSeenAt: v.LastSeenAt},
+	}
+}
+
+func convertToLegacyDeviceCommand(d *playlist.DisplayDevice, orgId int64) *playlistsvc.UpdateDisplayDeviceCommand {
+	cmd := &playlistsvc.UpdateDisplayDeviceCommand{
+		UID:         d.Name,
+		OrgId:       orgId,
+		Name:        d.Spec.Title,
+		PlaylistUID: d.Spec.Playlist,
+		Timezone:    d.Spec.Timezone,
+	}
+	for _, entry := range d.Spec.Schedule {
+		cmd.Schedule = append(cmd.Schedule, playlistsvc.ScheduleEntry{
+			PlaylistUID: entry.Playlist,
+			Days:        entry.Days,
+			Start:       entry.Start,
+			End:         entry.End,
+		})
+	}
+	return cmd
+}
+
 // Read legacy ID from metadata annotations
 func getLegacyID(item *unstructured.Unstructured) int64 {
 	meta := kinds.GrafanaResourceMetadata{
@@ -23,6 +23,8 @@ func TestPlaylistConversion(t *testing.T) {
 			{Type: "dashboard_by_uid", Value: "UID0"},
 			{Type: "dashboard_by_tag", Value: "tagA"},
 			{Type: "dashboard_by_id", Value: "123"}, // deprecated
+			{Type: "dashboard_by_uid", Value: "UID1", Duration: "2m", TimeFrom: "now-24h", TimeTo: "now",
+				Variables: map[string][]string{"env": {"prod"}}},
 		},
 	}
 	dst := convertToK8sResource(src, request.GetNamespaceMapper(nil))
@@ -62,8 +64,72 @@ func TestPlaylistConversion(t *testing.T) {
 			{
 			  "type": "dashboard_by_id",
 			  "value": "123"
+			},
+			{
+			  "type": "dashboard_by_uid",
+			  "value": "UID1",
+			  "duration": "2m",
+			  "timeFrom": "now-24h",
+			  "timeTo": "now",
+			  "variables": {"env": ["prod"]}
 			}
 		  ]
 		}
 	  }`, string(out))
 }
+
+func TestDisplayDeviceConversion(t *testing.T) {
+	src := &playlist.DisplayDevice{
+		Id:          12,
+		UID:         "noc",
+		OrgId:       3,
+		Name:        "NOC wall",
+		PlaylistUID: "day",
+		Timezone:    "Europe/Paris",
+		Schedule: []playlist.ScheduleEntry{
+			{PlaylistUID: "night", Days: []string{"mon", "tue"}, Start: "20:00", End: "06:00"},
+		},
+		LastSeenAt: 99999,
+		CreatedAt:  12345,
+		UpdatedAt:  54321,
+	}
+	dst := convertDeviceToK8sResource(src, request.GetNamespaceMapper(nil))
+
+	out, err := json.MarshalIndent(dst, "", "  ")
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"metadata": {
+		  "name": "noc",
+		  "namespace": "org-3",
+		  "uid": "noc",
+		  "resourceVersion": "54321",
+		  "creationTimestamp": "1970-01-01T00:00:12Z",
+		  "annotations": {
+			"grafana.app/originKey": "12",
+			"grafana.app/originName": "SQL",
+			"grafana.app/updatedTimestamp": "1970-01-01T00:00:54Z"
+		  }
+		},
+		"spec": {
+		  "title": "NOC wall",
+		  "playlist": "day",
+		  "timezone": "Europe/Paris",
+		  "schedule": [
+			{"playlist": "night", "days": ["mon", "tue"], "start": "20:00", "end": "06:00"}
+		  ]
+		},
+		"status": {
+		  "lastSeenAt": 99999
+		}
+	  }`, string(out))
+
+	cmd := convertToLegacyDeviceCommand(dst, 3)
+	require.Equal(t, &playlist.UpdateDisplayDeviceCommand{
+		UID:         "noc",
+		OrgId:       3,
+		Name:        "NOC wall",
+		PlaylistUID: "day",
+		Timezone:    "Europe/Paris",
+		Schedule:    src.Schedule,
+	}, cmd)
+}
@@ -0,0 +1,199 @@
+package playlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	playlist "github.com/grafana/grafana/pkg/apis/playlist/v0alpha1"
+	"github.com/grafana/grafana/pkg/services/grafana-apiserver/endpoints/request"
+	playlistsvc "github.com/grafana/grafana/pkg/services/playlist"
+)
+
+var (
+	_ rest.Scoper               = (*legacyDeviceStorage)(nil)
+	_ rest.SingularNameProvider = (*legacyDeviceStorage)(nil)
+	_ rest.Getter               = (*legacyDeviceStorage)(nil)
+	_ rest.Lister               = (*legacyDeviceStorage)(nil)
+	_ rest.Storage              = (*legacyDeviceStorage)(nil)
+	_ rest.Creater              = (*legacyDeviceStorage)(nil)
+	_ rest.Updater              = (*legacyDeviceStorage)(nil)
+	_ rest.GracefulDeleter      = (*legacyDeviceStorage)(nil)
+)
+
+type legacyDeviceStorage struct {
+	service        playlistsvc.Service
+	namespacer     request.NamespaceMapper
+	tableConverter rest.TableConvertor
+
+	DefaultQualifiedResource  schema.GroupResource
+	SingularQualifiedResource schema.GroupResource
+}
+
+func (s *legacyDeviceStorage) New() runtime.Object {
+	return &playlist.DisplayDevice{}
+}
+
+func (s *legacyDeviceStorage) Destroy() {}
+
+func (s *legacyDeviceStorage) NamespaceScoped() bool {
+	return true // namespace == org
+}
+
+func (s *legacyDeviceStorage) GetSingularName() string {
+	return "displaydevice"
+}
+
+func (s *legacyDeviceStorage) NewList() runtime.Object {
+	return &playlist.DisplayDeviceList{}
+}
+
+func (s *legacyDeviceStorage) ConvertToTable(ctx context.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return s.tableConverter.ConvertToTable(ctx, object, tableOptions)
+}
+
+func (s *legacyDeviceStorage) List(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+	info, err := request.NamespaceInfoFrom(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := 100
+	if options.Limit > 0 {
+		limit = int(options.Limit)
+	}
+	res, err := s.service.SearchDevices(ctx, &playlistsvc.GetDisplayDevicesQuery{
+		OrgId: info.OrgID,
+		Limit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := &playlist.DisplayDeviceList{}
+	for _, v := range res {
+		list.Items = append(list.Items, *convertDeviceToK8sResource(v, s.namespacer))
+	}
+	if len(list.Items) == limit {
+		list.Continue = "<more>" // TODO?
+	}
+	return list, nil
+}
+
+func (s *legacyDeviceStorage) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	info, err := request.NamespaceInfoFrom(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := s.service.GetDevice(ctx, &playlistsvc.GetDisplayDeviceQuery{
+		UID:   name,
+		OrgId: info.OrgID,
+	})
+	if err != nil {
+		if errors.Is(err, playlistsvc.ErrDeviceNotFound) {
+			err = k8serrors.NewNotFound(s.SingularQualifiedResource, name)
+		}
+		return nil, err
+	}
+
+	return convertDeviceToK8sResource(device, s.namespacer), nil
+}
+
+func (s *legacyDeviceStorage) Create(ctx context.Context,
+	obj runtime.Object,
+	createValidation rest.ValidateObjectFunc,
+	options *metav1.CreateOptions,
+) (runtime.Object, error) {
+	info, err := request.NamespaceInfoFrom(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	d, ok := obj.(*playlist.DisplayDevice)
+	if !ok {
+		return nil, fmt.Errorf("expected display device?")
+	}
+	cmd := convertToLegacyDeviceCommand(d, info.OrgID)
+	out, err := s.service.CreateDevice(ctx, &playlistsvc.CreateDisplayDeviceCommand{
+		UID:         d.Name,
+		Name:        cmd.Name,
+		PlaylistUID: cmd.PlaylistUID,
+		Timezone:    cmd.Timezone,
+		Schedule:    cmd.Schedule,
+		OrgId:       cmd.OrgId,
+	})
+	if err != nil {
+		return nil, deviceError(err)
+	}
+	return s.Get(ctx, out.UID, nil)
+}
+
+func (s *legacyDeviceStorage) Update(ctx context.Context,
+	name string,
+	objInfo rest.UpdatedObjectInfo,
+	createValidation rest.ValidateObjectFunc,
+	updateValidation rest.ValidateObjectUpdateFunc,
+	forceAllowCreate bool,
+	options *metav1.UpdateOptions,
+) (runtime.Object, bool, error) {
+	info, err := request.NamespaceInfoFrom(ctx, true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	created := false
+	old, err := s.Get(ctx, name, nil)
+	if err != nil {
+		return old, created, err
+	}
+
+	obj, err := objInfo.UpdatedObject(ctx, old)
+	if err != nil {
+		return old, created, err
+	}
+	d, ok := obj.(*playlist.DisplayDevice)
+	if !ok {
+		return nil, created, fmt.Errorf("expected display device after update")
+	}
+
+	_, err = s.service.UpdateDevice(ctx, convertToLegacyDeviceCommand(d, info.OrgID))
+	if err != nil {
+		return nil, false, deviceError(err)
+	}
+
+	r, err := s.Get(ctx, name, nil)
+	return r, created, err
+}
+
+// GracefulDeleter
+func (s *legacyDeviceStorage) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	v, err := s.Get(ctx, name, &metav1.GetOptions{})
+	if err != nil {
+		return v, false, err // includes the not-found error
+	}
+	info, err := request.NamespaceInfoFrom(ctx, true)
+	if err != nil {
+		return nil, false, err
+	}
+	err = s.service.DeleteDevice(ctx, &playlistsvc.DeleteDisplayDeviceCommand{
+		UID:   name,
+		OrgId: info.OrgID,
+	})
+	return v, true, err // true is instant delete
+}
+
+// deviceError returns validation failures as bad requests
+func deviceError(err error) error {
+	if errors.Is(err, playlistsvc.ErrInvalidSchedule) || errors.Is(err, playlistsvc.ErrPlaylistNotFound) {
+		return k8serrors.NewBadRequest(err.Error())
+	}
+	return err
+}
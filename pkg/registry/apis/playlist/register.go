@@ -56,6 +56,8 @@ func (b *PlaylistAPIBuilder) InstallSchema(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(b.gv,
 		&playlist.Playlist{},
 		&playlist.PlaylistList{},
+		&playlist.DisplayDevice{},
+		&playlist.DisplayDeviceList{},
 	)
 
 	// Link this version to the internal representation.
@@ -67,6 +69,8 @@ func (b *PlaylistAPIBuilder) InstallSchema(scheme *runtime.Scheme) error {
 	},
 		&playlist.Playlist{},
 		&playlist.PlaylistList{},
+		&playlist.DisplayDevice{},
+		&playlist.DisplayDeviceList{},
 	)
 
 	// If multiple versions exist, then register conversions from zz_generated.conversion.go
@@ -123,6 +127,35 @@ func (b *PlaylistAPIBuilder) GetAPIGroupInfo(
 		storage["playlists"] = grafanarest.NewDualWriter(legacyStore, store)
 	}
 
+	deviceStore := &legacyDeviceStorage{
+		service:                   b.service,
+		namespacer:                b.namespacer,
+		DefaultQualifiedResource:  b.gv.WithResource("displaydevices").GroupResource(),
+		SingularQualifiedResource: b.gv.WithResource("displaydevice").GroupResource(),
+	}
+	deviceStore.tableConverter = utils.NewTableConverter(
+		deviceStore.DefaultQualifiedResource,
+		[]metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name"},
+			{Name: "Title", Type: "string", Format: "string", Description: "The device name"},
+			{Name: "Playlist", Type: "string", Format: "string", Description: "The default playlist of the device"},
+			{Name: "Created At", Type: "date"},
+		},
+		func(obj any) ([]interface{}, error) {
+			m, ok := obj.(*playlist.DisplayDevice)
+			if !ok {
+				return nil, fmt.Errorf("expected display device")
+			}
+			return []interface{}{
+				m.Name,
+				m.Spec.Title,
+				m.Spec.Playlist,
+				m.CreationTimestamp.UTC().Format(time.RFC3339),
+			}, nil
+		},
+	)
+	storage["displaydevices"] = deviceStore
+
 	apiGroupInfo.VersionedResourcesStorageMap[VersionID] = storage
 	return &apiGroupInfo, nil
 }
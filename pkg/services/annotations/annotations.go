@@ -11,6 +11,8 @@ import (
 var (
 	ErrTimerangeMissing     = errors.New("missing timerange")
 	ErrBaseTagLimitExceeded = errutil.BadRequest("annotations.tag-limit-exceeded", errutil.WithPublicMessage("Tags length exceeds the maximum allowed."))
+	ErrInvalidFieldFilter   = errutil.BadRequest("annotations.invalid-field-filter", errutil.WithPublicMessage("Invalid annotation field filter."))
+	ErrInvalidGroupBy       = errutil.BadRequest("annotations.invalid-group-by", errutil.WithPublicMessage("Annotations can only be grouped by tag or field."))
 )
 
 //go:generate mockery --name Repository --structname FakeAnnotationsRepo --inpackage --filename annotations_repository_mock.go
@@ -21,6 +23,7 @@ type Repository interface {
 	Find(ctx context.Context, query *ItemQuery) ([]*ItemDTO, error)
 	Delete(ctx context.Context, params *DeleteParams) error
 	FindTags(ctx context.Context, query *TagsQuery) (FindTagsResult, error)
+	Aggregate(ctx context.Context, query *AggregateQuery) (AggregateResult, error)
 }
 
 // Cleaner is responsible for cleaning up old annotations
@@ -0,0 +1,111 @@
+package annotationsimpl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+// maxAggregateBuckets is the maximum number of buckets of an aggregate query
+const maxAggregateBuckets = 10000
+
+type aggregateRow struct {
+	Time     int64  `xorm:"time"`
+	GroupKey string `xorm:"group_key"`
+	GroupVal string `xorm:"group_value"`
+	Count    int64  `xorm:"count"`
+	Duration int64  `xorm:"duration"`
+}
+
+// Aggregate counts the annotations per time bucket and group in the database. The buckets of
+// an interval start at the multiples of the interval since the epoch.
+func (r *xormRepositoryImpl) Aggregate(ctx context.Context, query *annotations.AggregateQuery) (annotations.AggregateResult, error) {
+	result := annotations.AggregateResult{Buckets: []annotations.AggregateBucket{}}
+	if query.Interval < 0 {
+		return result, fmt.Errorf("invalid interval %d", query.Interval)
+	}
+
+	dialect := r.db.GetDialect()
+	// the expressions are repeated in the GROUP BY clause, the interval is written in the query so
+	// that the databases see the same expression in both clauses
+	bucket := "0"
+	groupBy := []string{}
+	params := make([]any, 0)
+	if query.Interval > 0 {
+		bucket = fmt.Sprintf("(a.epoch - (a.epoch %% %d))", query.Interval)
+		groupBy = append(groupBy, bucket)
+	}
+
+	var joins, groupKey, groupValue string
+	switch {
+	case query.GroupBy == "":
+		groupKey, groupValue = "''", "''"
+	case query.GroupBy == annotations.GroupByTag:
+		joins = " INNER JOIN annotation_tag gat ON gat.annotation_id = a.id INNER JOIN tag gt ON gt.id = gat.tag_id"
+		groupKey, groupValue = "gt."+dialect.Quote("key"), "gt."+dialect.Quote("value")
+		groupBy = append(groupBy, groupKey, groupValue)
+	case strings.HasPrefix(query.GroupBy, annotations.GroupByFieldPrefix) && len(query.GroupBy) > len(annotations.GroupByFieldPrefix):
+		joins = " INNER JOIN annotation_field gaf ON gaf.annotation_id = a.id AND gaf." + dialect.Quote("key") + " = ?"
+		params = append(params, strings.TrimPrefix(query.GroupBy, annotations.GroupByFieldPrefix))
+		groupKey, groupValue = "''", "gaf."+dialect.Quote("value")
+		groupBy = append(groupBy, groupValue)
+	default:
+		return result, annotations.ErrInvalidGroupBy.Errorf("invalid group by %q", query.GroupBy)
+	}
+
+	var rows []aggregateRow
+	err := r.db.WithDbSession(ctx, func(sess *db.Session) error {
+		where, whereParams, acFilter, err := r.filterSQL(&query.ItemQuery)
+		if err != nil {
+			return err
+		}
+
+		var sql bytes.Buffer
+		sql.WriteString(acFilter.recQueries)
+		sql.WriteString(fmt.Sprintf(`
+			SELECT
+				%[1]s AS time,
+				%[2]s AS group_key,
+				%[3]s AS group_value,
+				COUNT(*) AS count,
+				COALESCE(SUM(a.epoch_end - a.epoch), 0) AS duration
+			FROM annotation a%[4]s
+			`, bucket, groupKey, groupValue, joins))
+		sql.WriteString(where)
+		if len(groupBy) > 0 {
+			sql.WriteString(" GROUP BY " + strings.Join(groupBy, ", "))
+		}
+		sql.WriteString(" ORDER BY time, group_key, group_value" + dialect.Limit(maxAggregateBuckets))
+
+		allParams := append([]any{}, acFilter.recParams...)
+		allParams = append(allParams, params...)
+		allParams = append(allParams, whereParams...)
+		return sess.SQL(sql.String(), allParams...).Find(&rows)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for _, row := range rows {
+		if row.Count == 0 {
+			continue
+		}
+		group := row.GroupVal
+		if row.GroupKey != "" && row.GroupVal != "" {
+			group = row.GroupKey + ":" + row.GroupVal
+		} else if row.GroupKey != "" {
+			group = row.GroupKey
+		}
+		result.Buckets = append(result.Buckets, annotations.AggregateBucket{
+			Time:     row.Time,
+			Group:    group,
+			Count:    row.Count,
+			Duration: row.Duration,
+		})
+	}
+	return result, nil
+}
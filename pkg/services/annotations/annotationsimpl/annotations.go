@@ -2,6 +2,7 @@ package annotationsimpl
 
 import (
 	"context"
+	"sort"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -13,10 +14,12 @@ import (
 
 type RepositoryImpl struct {
 	store store
+	log   log.Logger
 }
 
 func ProvideService(db db.DB, cfg *setting.Cfg, features featuremgmt.FeatureToggles, tagService tag.Service) *RepositoryImpl {
 	return &RepositoryImpl{
+		log: log.New("annotations"),
 		store: &xormRepositoryImpl{
 			cfg:               cfg,
 			features:          features,
@@ -24,6 +27,7 @@ func ProvideService(db db.DB, cfg *setting.Cfg, features featuremgmt.FeatureTogg
 			log:               log.New("annotations"),
 			tagService:        tagService,
 			maximumTagsLength: cfg.AnnotationMaximumTagsLength,
+			archive:           newArchive(cfg.AnnotationArchiveEnabled, cfg.AnnotationArchivePath, log.New("annotations.archive")),
 		},
 	}
 }
@@ -42,8 +46,47 @@ func (r *RepositoryImpl) Update(ctx context.Context, item *annotations.Item) err
 	return r.store.Update(ctx, item)
 }
 
+// Find returns the annotations matching a query. The queries with a time range that match less
+// annotations than their limit also return the matching annotations of the archive.
 func (r *RepositoryImpl) Find(ctx context.Context, query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
-	return r.store.Get(ctx, query)
+	items, err := r.store.Get(ctx, query)
+	if err != nil || query.From <= 0 || query.To <= 0 || int64(len(items)) >= query.Limit {
+		return items, err
+	}
+
+	archived, err := r.store.GetArchived(ctx, query)
+	if err != nil {
+		r.log.Warn("Failed to query the annotations archive", "orgId", query.OrgID, "error", err)
+		return items, nil
+	}
+	if len(archived) == 0 {
+		return items, nil
+	}
+	return mergeArchived(items, archived, query.Limit), nil
+}
+
+// mergeArchived adds the archived annotations to the annotations of the database, in the order
+// of the database query, and keeps the first ones up to the limit.
+func mergeArchived(items []*annotations.ItemDTO, archived []*annotations.ItemDTO, limit int64) []*annotations.ItemDTO {
+	ids := make(map[int64]bool, len(items))
+	for _, item := range items {
+		ids[item.ID] = true
+	}
+	for _, item := range archived {
+		if !ids[item.ID] {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].TimeEnd != items[j].TimeEnd {
+			return items[i].TimeEnd > items[j].TimeEnd
+		}
+		return items[i].Time > items[j].Time
+	})
+	if int64(len(items)) > limit {
+		items = items[:limit]
+	}
+	return items
 }
 
 func (r *RepositoryImpl) Delete(ctx context.Context, params *annotations.DeleteParams) error {
@@ -53,3 +96,7 @@ func (r *RepositoryImpl) Delete(ctx context.Context, params *annotations.DeleteP
 func (r *RepositoryImpl) FindTags(ctx context.Context, query *annotations.TagsQuery) (annotations.FindTagsResult, error) {
 	return r.store.GetTags(ctx, query)
 }
+
+func (r *RepositoryImpl) Aggregate(ctx context.Context, query *annotations.AggregateQuery) (annotations.AggregateResult, error) {
+	return r.store.Aggregate(ctx, query)
+}
@@ -0,0 +1,534 @@
+package annotationsimpl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gocloud.dev/blob"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/tag"
+)
+
+const (
+	archiveFileExtension = ".jsonl.gz"
+	archiveMimeType      = "application/gzip"
+)
+
+// archivedAnnotation is an annotation of the archive. The names of its user and alert are the
+// ones they had when it was archived.
+type archivedAnnotation struct {
+	OrgID               int64 `json:"orgId" xorm:"org_id"`
+	annotations.ItemDTO `xorm:"extends"`
+}
+
+// archive stores the annotations removed by the cleanup in compressed files of JSON lines,
+// in a folder per organization and month of the annotations. An annotation spanning several
+// months is stored in the folder of each of them. The names of the files hold the time range
+// and the IDs of their annotations, so that the queries only read the files of the months of
+// their time range that overlap it:
+//
+//	/<orgId>/<yyyy-mm>/<first time>-<last time end>-<first id>-<last id>.jsonl.gz
+type archive struct {
+	path string
+	log  log.Logger
+
+	once    sync.Once
+	storage filestorage.FileStorage
+	err     error
+}
+
+// newArchive returns the archive of the settings, or nil when it is disabled.
+func newArchive(enabled bool, path string, logger log.Logger) *archive {
+	if !enabled {
+		return nil
+	}
+	return &archive{path: path, log: logger}
+}
+
+// open opens the storage of the archive the first time it is used, so that an invalid path
+// fails the cleanup instead of the startup.
+func (a *archive) open(ctx context.Context) (filestorage.FileStorage, error) {
+	a.once.Do(func() {
+		if err := os.MkdirAll(a.path, 0750); err != nil {
+			a.err = fmt.Errorf("failed to create the annotations archive: %w", err)
+			return
+		}
+		bucket, err := blob.OpenBucket(ctx, "file:///"+a.path)
+		if err != nil {
+			a.err = fmt.Errorf("failed to open the annotations archive: %w", err)
+			return
+		}
+		a.storage = filestorage.NewCdkBlobStorage(a.log, bucket, "", filestorage.NewAllowAllPathFilter())
+	})
+	return a.storage, a.err
+}
+
+// write stores a batch of annotations, in a file per organization and month.
+func (a *archive) write(ctx context.Context, items []*archivedAnnotation) error {
+	storage, err := a.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	type archiveFolder struct {
+		orgID int64
+		month string
+	}
+	byFolder := map[archiveFolder][]*archivedAnnotation{}
+	for _, item := range items {
+		for _, month := range archiveMonths(item.Time, timeEnd(&item.ItemDTO)) {
+			folder := archiveFolder{orgID: item.OrgID, month: month}
+			byFolder[folder] = append(byFolder[folder], item)
+		}
+	}
+	for folder, items := range byFolder {
+		sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		encoder := json.NewEncoder(zw)
+		first, last := items[0].Time, timeEnd(&items[0].ItemDTO)
+		for _, item := range items {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+			first = min(first, item.Time)
+			last = max(last, timeEnd(&item.ItemDTO))
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%d-%d-%d-%d%s", first, last, items[0].ID, items[len(items)-1].ID, archiveFileExtension)
+		err := storage.Upsert(ctx, &filestorage.UpsertFileCommand{
+			Path:     filestorage.Join(strconv.FormatInt(folder.orgID, 10), folder.month, name),
+			MimeType: archiveMimeType,
+			Contents: buf.Bytes(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to archive annotations: %w", err)
+		}
+	}
+	return nil
+}
+
+// find returns the archived annotations matching the filters of a query, without checking the
+// access to the annotations. The query must have a time range.
+func (a *archive) find(ctx context.Context, query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	storage, err := a.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newArchiveFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	months, err := a.months(ctx, storage, query)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*annotations.ItemDTO{}
+	seen := map[int64]bool{}
+	for _, month := range months {
+		files, err := list(ctx, storage, month, &filestorage.ListOptions{Recursive: true, WithFiles: true})
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if !archiveFileOverlaps(file.Name, query.From, query.To) {
+				continue
+			}
+			found, err := a.read(ctx, storage, file.FullPath, filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range found {
+				// an annotation is in the folder of each of its months, and a batch is archived
+				// again when it couldn't be deleted after it was archived
+				if !seen[item.ID] {
+					seen[item.ID] = true
+					items = append(items, item)
+				}
+			}
+		}
+	}
+	return items, nil
+}
+
+// months returns the paths of the month folders of an organization that overlap the time
+// range of a query.
+func (a *archive) months(ctx context.Context, storage filestorage.FileStorage, query *annotations.ItemQuery) ([]string, error) {
+	folders, err := list(ctx, storage, filestorage.Join(strconv.FormatInt(query.OrgID, 10)), &filestorage.ListOptions{WithFolders: true})
+	if err != nil {
+		return nil, err
+	}
+	from, to := archiveMonth(query.From), archiveMonth(query.To)
+	paths := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		// the months are formatted so that they sort like their time
+		if folder.IsFolder() && folder.Name >= from && folder.Name <= to {
+			paths = append(paths, folder.FullPath)
+		}
+	}
+	return paths, nil
+}
+
+// list returns all the pages of a listing of the storage
+func list(ctx context.Context, storage filestorage.FileStorage, path string, options *filestorage.ListOptions) ([]*filestorage.File, error) {
+	var files []*filestorage.File
+	paging := &filestorage.Paging{Limit: 1000}
+	for {
+		resp, err := storage.List(ctx, path, paging, options)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, resp.Files...)
+		if !resp.HasMore || resp.LastPath == "" {
+			return files, nil
+		}
+		paging.After = resp.LastPath
+	}
+}
+
+func (a *archive) read(ctx context.Context, storage filestorage.FileStorage, path string, filter *archiveFilter) ([]*annotations.ItemDTO, error) {
+	file, found, err := storage.Get(ctx, path, &filestorage.GetFileOptions{WithContents: true})
+	if err != nil || !found {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(file.Contents))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archived annotations %s: %w", path, err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	items := []*annotations.ItemDTO{}
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var item archivedAnnotation
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return nil, fmt.Errorf("failed to read archived annotations %s: %w", path, err)
+		}
+		if item.OrgID == filter.query.OrgID && filter.matches(&item.ItemDTO) {
+			items = append(items, &item.ItemDTO)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read archived annotations %s: %w", path, err)
+	}
+	return items, nil
+}
+
+// archiveFileOverlaps returns true when the time range in the name of a file overlaps the range
+func archiveFileOverlaps(name string, from, to int64) bool {
+	parts := strings.Split(strings.TrimSuffix(name, archiveFileExtension), "-")
+	if len(parts) != 4 || !strings.HasSuffix(name, archiveFileExtension) {
+		return false
+	}
+	first, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return false
+	}
+	last, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+	return first <= to && last >= from
+}
+
+// archiveMonth returns the name of the folder of the month of a time
+func archiveMonth(t int64) string {
+	return time.UnixMilli(t).UTC().Format("2006-01")
+}
+
+// archiveMonths returns the names of the folders of the months between two times
+func archiveMonths(from, to int64) []string {
+	last := archiveMonth(to)
+	month := time.UnixMilli(from).UTC()
+	month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	months := []string{}
+	for {
+		name := month.Format("2006-01")
+		months = append(months, name)
+		if name >= last {
+			return months
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+}
+
+func timeEnd(item *annotations.ItemDTO) int64 {
+	return max(item.Time, item.TimeEnd)
+}
+
+// archiveFilter matches the archived annotations with the filters of a query, like the WHERE
+// clause of the annotations in the database.
+type archiveFilter struct {
+	query  *annotations.ItemQuery
+	tags   []*tag.Tag
+	fields []fieldFilter
+}
+
+func newArchiveFilter(query *annotations.ItemQuery) (*archiveFilter, error) {
+	f := &archiveFilter{query: query, tags: tag.ParseTagPairs(query.Tags)}
+	for _, raw := range query.Fields {
+		field, err := parseFieldFilter(raw)
+		if err != nil {
+			return nil, err
+		}
+		f.fields = append(f.fields, field)
+	}
+	return f, nil
+}
+
+func (f *archiveFilter) matches(item *annotations.ItemDTO) bool {
+	q := f.query
+	switch {
+	case item.Time > q.To || timeEnd(item) < q.From:
+		return false
+	case q.AnnotationID != 0 && item.ID != q.AnnotationID:
+		return false
+	case q.AlertID != 0 && item.AlertID != q.AlertID:
+		return false
+	case q.DashboardID != 0 && item.DashboardID != q.DashboardID:
+		return false
+	case q.PanelID != 0 && item.PanelID != q.PanelID:
+		return false
+	case q.UserID != 0 && item.UserID != q.UserID:
+		return false
+	case q.Type == "alert" && item.AlertID <= 0:
+		return false
+	case q.Type == "annotation" && item.AlertID != 0:
+		return false
+	}
+	return f.matchesTags(item) && f.matchesFields(item)
+}
+
+func (f *archiveFilter) matchesTags(item *annotations.ItemDTO) bool {
+	if len(f.tags) == 0 {
+		return true
+	}
+	itemTags := tag.ParseTagPairs(item.Tags)
+	matched := 0
+	for _, want := range f.tags {
+		for _, t := range itemTags {
+			if t.Key == want.Key && (want.Value == "" || t.Value == want.Value) {
+				matched++
+				break
+			}
+		}
+	}
+	if f.query.MatchAny {
+		return matched > 0
+	}
+	return matched == len(f.tags)
+}
+
+func (f *archiveFilter) matchesFields(item *annotations.ItemDTO) bool {
+	if len(f.fields) == 0 {
+		return true
+	}
+	fields := extractFields(&annotations.Item{AlertID: item.AlertID, Data: item.Data})
+	for _, filter := range f.fields {
+		found := false
+		for _, field := range fields {
+			if field.Key == filter.key && filter.matchesValue(field) {
+				found = true
+				break
+			}
+		}
+		// != matches the annotations without a field with the value, like in the database
+		if found == (filter.operator == "!=") {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesValue returns true when a field matches the filter, or has its value for !=
+func (f fieldFilter) matchesValue(field annotationField) bool {
+	switch f.operator {
+	case "":
+		return true
+	case "=", "!=":
+		return field.Value == f.value
+	}
+	if field.ValueNum == nil {
+		return false
+	}
+	n := *field.ValueNum
+	switch f.operator {
+	case ">":
+		return n > f.number
+	case ">=":
+		return n >= f.number
+	case "<":
+		return n < f.number
+	default:
+		return n <= f.number
+	}
+}
+
+// archiveSelectSQL selects the annotations to archive, with the names of their user and alert
+func (r *xormRepositoryImpl) archiveSelectSQL(selectIDs string) string {
+	return `
+		SELECT
+			annotation.id,
+			annotation.org_id,
+			annotation.epoch as time,
+			annotation.epoch_end as time_end,
+			annotation.dashboard_id,
+			annotation.panel_id,
+			annotation.user_id,
+			annotation.new_state,
+			annotation.prev_state,
+			annotation.alert_id,
+			annotation.text,
+			annotation.tags,
+			annotation.data,
+			annotation.created,
+			annotation.updated,
+			usr.email,
+			usr.login,
+			alert.name as alert_name
+		FROM annotation
+		LEFT OUTER JOIN ` + r.db.GetDialect().Quote("user") + ` as usr on usr.id = annotation.user_id
+		LEFT OUTER JOIN alert on alert.id = annotation.alert_id
+		WHERE annotation.id IN (SELECT id FROM (` + selectIDs + `) a)`
+}
+
+// archiveUntilDoneOrCancelled archives and deletes the annotations selected by a query in
+// batches. A batch is only deleted once it is archived.
+func (r *xormRepositoryImpl) archiveUntilDoneOrCancelled(ctx context.Context, selectIDs string) (int64, error) {
+	var totalAffected int64
+	for {
+		select {
+		case <-ctx.Done():
+			return totalAffected, ctx.Err()
+		default:
+			items := make([]*archivedAnnotation, 0)
+			err := r.db.WithDbSession(ctx, func(sess *db.Session) error {
+				return sess.SQL(r.archiveSelectSQL(selectIDs)).Find(&items)
+			})
+			if err != nil {
+				return totalAffected, err
+			}
+			if len(items) == 0 {
+				return totalAffected, nil
+			}
+
+			if err := r.archive.write(ctx, items); err != nil {
+				return totalAffected, err
+			}
+
+			args := make([]any, 0, len(items)+1)
+			args = append(args, "DELETE FROM annotation WHERE id IN (?"+strings.Repeat(",?", len(items)-1)+")")
+			for _, item := range items {
+				args = append(args, item.ID)
+			}
+			err = r.db.WithDbSession(ctx, func(sess *db.Session) error {
+				res, err := sess.Exec(args...)
+				if err != nil {
+					return err
+				}
+				affected, err := res.RowsAffected()
+				totalAffected += affected
+				return err
+			})
+			if err != nil {
+				return totalAffected, err
+			}
+		}
+	}
+}
+
+// GetArchived returns the archived annotations matching a query with a time range, that the
+// signed in user can read.
+func (r *xormRepositoryImpl) GetArchived(ctx context.Context, query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	if r.archive == nil || query.From <= 0 || query.To <= 0 {
+		return []*annotations.ItemDTO{}, nil
+	}
+	items, err := r.archive.find(ctx, query)
+	if err != nil || len(items) == 0 {
+		return items, err
+	}
+
+	readable, err := r.readableDashboards(ctx, query, items)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]*annotations.ItemDTO, 0, len(items))
+	for _, item := range items {
+		if readable[item.DashboardID] {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+// readableDashboards returns the dashboards of the annotations whose annotations the user can
+// read, with the access control filter of the annotations in the database. The dashboard 0 is
+// readable when the user can read the organization annotations.
+func (r *xormRepositoryImpl) readableDashboards(ctx context.Context, query *annotations.ItemQuery, items []*annotations.ItemDTO) (map[int64]bool, error) {
+	filter, err := r.getAccessControlFilter(query.SignedInUser)
+	if err != nil {
+		return nil, err
+	}
+	readable := map[int64]bool{}
+	if filter.where == "" {
+		return readable, nil
+	}
+
+	dashboardIDs := []any{}
+	seen := map[int64]bool{}
+	for _, item := range items {
+		if item.DashboardID != 0 && !seen[item.DashboardID] {
+			seen[item.DashboardID] = true
+			dashboardIDs = append(dashboardIDs, item.DashboardID)
+		}
+	}
+
+	var sql bytes.Buffer
+	params := make([]any, 0)
+	sql.WriteString(filter.recQueries)
+	params = append(params, filter.recParams...)
+	sql.WriteString("SELECT a.dashboard_id FROM (SELECT 0 AS dashboard_id")
+	if len(dashboardIDs) > 0 {
+		sql.WriteString(" UNION SELECT id AS dashboard_id FROM dashboard WHERE org_id = ? AND id IN (?" + strings.Repeat(",?", len(dashboardIDs)-1) + ")")
+		params = append(params, query.OrgID)
+		params = append(params, dashboardIDs...)
+	}
+	sql.WriteString(") a WHERE (" + filter.where + ")")
+	params = append(params, filter.whereParams...)
+
+	rows := make([]struct {
+		DashboardID int64 `xorm:"dashboard_id"`
+	}, 0)
+	err = r.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.SQL(sql.String(), params...).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		readable[row.DashboardID] = true
+	}
+	return readable, nil
+}
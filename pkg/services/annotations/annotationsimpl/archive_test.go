@@ -0,0 +1,231 @@
+package annotationsimpl
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dashboardstore "github.com/grafana/grafana/pkg/services/dashboards/database"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationAnnotationArchive(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sql := db.InitTestDB(t)
+
+	cfg := setting.NewCfg()
+	cfg.AnnotationCleanupJobBatchSize = 2
+	cfg.AnnotationMaximumTagsLength = 500
+	cfg.AnnotationArchiveEnabled = true
+	cfg.AnnotationArchivePath = t.TempDir()
+	cfg.AlertingAnnotationCleanupSetting = settingsFn(time.Hour, 0)
+	cfg.DashboardAnnotationCleanupSettings = settingsFn(time.Hour, 0)
+	cfg.APIAnnotationCleanupSettings = settingsFn(time.Hour, 0)
+	repo := ProvideService(sql, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sql))
+	cleaner := ProvideCleanupService(sql, cfg, featuremgmt.WithFeatures())
+
+	dashboardStore, err := dashboardstore.ProvideDashboardStore(sql, sql.Cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sql), quotatest.New(false, nil))
+	require.NoError(t, err)
+	dashboard1, err := dashboardStore.SaveDashboard(context.Background(), dashboards.SaveDashboardCommand{
+		UserID:    1,
+		OrgID:     1,
+		Dashboard: simplejson.NewFromAny(map[string]any{"title": "Dashboard 1"}),
+	})
+	require.NoError(t, err)
+	dashboard2, err := dashboardStore.SaveDashboard(context.Background(), dashboards.SaveDashboardCommand{
+		UserID:    1,
+		OrgID:     1,
+		Dashboard: simplejson.NewFromAny(map[string]any{"title": "Dashboard 2"}),
+	})
+	require.NoError(t, err)
+
+	// the annotations are created two days ago, so that the cleanup removes them
+	now := time.Now()
+	timeNow = func() time.Time { return now.Add(-48 * time.Hour) }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	add := func(item *annotations.Item) int64 {
+		t.Helper()
+		require.NoError(t, repo.Save(context.Background(), item))
+		return item.ID
+	}
+	deployAPI := add(&annotations.Item{OrgID: 1, UserID: 1, Text: "deploy api", Epoch: 1000, EpochEnd: 1500, Tags: []string{"deploy", "service:api"},
+		Data: simplejson.NewFromAny(map[string]any{"deploy": map[string]any{"service": "api", "duration": 30}})})
+	deployWeb := add(&annotations.Item{OrgID: 1, UserID: 1, Text: "deploy web", Epoch: 2000, EpochEnd: 2000, Tags: []string{"deploy", "service:web"},
+		Data: simplejson.NewFromAny(map[string]any{"deploy": map[string]any{"service": "web", "duration": 90}})})
+	dash1Annotation := add(&annotations.Item{OrgID: 1, UserID: 1, DashboardID: dashboard1.ID, Text: "dashboard 1", Epoch: 3000, EpochEnd: 3000})
+	dash2Annotation := add(&annotations.Item{OrgID: 1, UserID: 1, DashboardID: dashboard2.ID, Text: "dashboard 2", Epoch: 4000, EpochEnd: 4000})
+	otherOrg := add(&annotations.Item{OrgID: 2, UserID: 1, Text: "other org", Epoch: 1000, EpochEnd: 1000})
+
+	timeNow = time.Now
+	recent := add(&annotations.Item{OrgID: 1, UserID: 1, Text: "recent", Epoch: 5000, EpochEnd: 5000, Tags: []string{"deploy"}})
+
+	affected, _, err := cleaner.Run(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), affected)
+	assertAnnotationCount(t, sql, "", 1)
+
+	files := []string{}
+	err = filepath.WalkDir(cfg.AnnotationArchivePath, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, archiveFileExtension) {
+			rel, _ := filepath.Rel(cfg.AnnotationArchivePath, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	require.NoError(t, err)
+	assert.Contains(t, files, fmt.Sprintf("2/1970-01/1000-1000-%d-%d.jsonl.gz", otherOrg, otherOrg))
+
+	reader := &user.SignedInUser{
+		UserID: 1,
+		OrgID:  1,
+		Permissions: map[int64]map[string][]string{1: {
+			accesscontrol.ActionAnnotationsRead: {accesscontrol.ScopeAnnotationsAll},
+			dashboards.ActionDashboardsRead:     {dashboards.ScopeDashboardsAll},
+		}},
+	}
+	find := func(t *testing.T, query annotations.ItemQuery) []int64 {
+		t.Helper()
+		query.OrgID = 1
+		if query.SignedInUser == nil {
+			query.SignedInUser = reader
+		}
+		items, err := repo.Find(context.Background(), &query)
+		require.NoError(t, err)
+		ids := make([]int64, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	t.Run("Should return the archived annotations of a time range", func(t *testing.T) {
+		assert.Equal(t, []int64{recent, dash2Annotation, dash1Annotation, deployWeb, deployAPI}, find(t, annotations.ItemQuery{From: 1, To: 10000}))
+		assert.Equal(t, []int64{deployWeb, deployAPI}, find(t, annotations.ItemQuery{From: 1200, To: 2500}))
+		assert.Equal(t, []int64{recent}, find(t, annotations.ItemQuery{}))
+		assert.Equal(t, []int64{recent, dash2Annotation}, find(t, annotations.ItemQuery{From: 1, To: 10000, Limit: 2}))
+	})
+
+	t.Run("Should keep the data of the archived annotations", func(t *testing.T) {
+		items, err := repo.Find(context.Background(), &annotations.ItemQuery{OrgID: 1, From: 1, To: 1200, SignedInUser: reader})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "deploy api", items[0].Text)
+		assert.Equal(t, int64(1500), items[0].TimeEnd)
+		assert.ElementsMatch(t, []string{"deploy", "service:api"}, items[0].Tags)
+		assert.Equal(t, "api", items[0].Data.GetPath("deploy", "service").MustString())
+	})
+
+	t.Run("Should filter the archived annotations", func(t *testing.T) {
+		assert.Equal(t, []int64{recent, deployWeb, deployAPI}, find(t, annotations.ItemQuery{From: 1, To: 10000, Tags: []string{"deploy"}}))
+		assert.Equal(t, []int64{deployWeb}, find(t, annotations.ItemQuery{From: 1, To: 10000, Tags: []string{"deploy", "service:web"}}))
+		assert.Equal(t, []int64{deployWeb, deployAPI}, find(t, annotations.ItemQuery{From: 1, To: 10000, Tags: []string{"service:web", "service:api"}, MatchAny: true}))
+		assert.Equal(t, []int64{deployWeb}, find(t, annotations.ItemQuery{From: 1, To: 10000, Fields: []string{"deploy.duration>45"}}))
+		assert.Equal(t, []int64{deployAPI}, find(t, annotations.ItemQuery{From: 1, To: 10000, Fields: []string{"deploy.service!=web", "deploy.service"}}))
+		assert.Equal(t, []int64{dash1Annotation}, find(t, annotations.ItemQuery{From: 1, To: 10000, DashboardID: dashboard1.ID}))
+		assert.Equal(t, []int64{dash2Annotation}, find(t, annotations.ItemQuery{From: 1, To: 10000, AnnotationID: dash2Annotation}))
+	})
+
+	t.Run("Should check the access to the archived annotations", func(t *testing.T) {
+		testCases := []struct {
+			description string
+			permissions map[string][]string
+			expected    []int64
+		}{
+			{
+				description: "organization annotations",
+				permissions: map[string][]string{
+					accesscontrol.ActionAnnotationsRead: {accesscontrol.ScopeAnnotationsTypeOrganization},
+					dashboards.ActionDashboardsRead:     {dashboards.ScopeDashboardsAll},
+				},
+				expected: []int64{recent, deployWeb, deployAPI},
+			},
+			{
+				description: "annotations of the dashboards the user can read",
+				permissions: map[string][]string{
+					accesscontrol.ActionAnnotationsRead: {accesscontrol.ScopeAnnotationsTypeDashboard},
+					dashboards.ActionDashboardsRead:     {dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dashboard1.UID)},
+				},
+				expected: []int64{dash1Annotation},
+			},
+		}
+		usr := &user.SignedInUser{UserID: 1, OrgID: 1}
+		role := setupRBACRole(t, sql, usr)
+		for _, tc := range testCases {
+			t.Run(tc.description, func(t *testing.T) {
+				usr.Permissions = map[int64]map[string][]string{1: tc.permissions}
+				setupRBACPermission(t, sql, role, usr)
+				assert.Equal(t, tc.expected, find(t, annotations.ItemQuery{From: 1, To: 10000, SignedInUser: usr}))
+			})
+		}
+	})
+}
+
+func TestArchiveMonths(t *testing.T) {
+	ctx := context.Background()
+	a := newArchive(true, t.TempDir(), log.NewNopLogger())
+	ms := func(month string, day int) int64 {
+		t.Helper()
+		m, err := time.Parse("2006-01", month)
+		require.NoError(t, err)
+		return m.AddDate(0, 0, day-1).UnixMilli()
+	}
+	item := func(id int64, from, to int64) *archivedAnnotation {
+		return &archivedAnnotation{OrgID: 1, ItemDTO: annotations.ItemDTO{ID: id, Time: from, TimeEnd: to}}
+	}
+	require.NoError(t, a.write(ctx, []*archivedAnnotation{
+		item(1, ms("2023-01", 10), ms("2023-01", 10)),
+		item(2, ms("2023-01", 20), ms("2023-03", 5)),
+		item(3, ms("2023-04", 1), ms("2023-04", 1)),
+	}))
+
+	storage, err := a.open(ctx)
+	require.NoError(t, err)
+	months, err := a.months(ctx, storage, &annotations.ItemQuery{OrgID: 1, From: ms("2023-02", 1), To: ms("2023-03", 31)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/1/2023-02", "/1/2023-03"}, months)
+
+	find := func(from, to int64) []int64 {
+		t.Helper()
+		items, err := a.find(ctx, &annotations.ItemQuery{OrgID: 1, From: from, To: to})
+		require.NoError(t, err)
+		ids := []int64{}
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []int64{2}, find(ms("2023-03", 1), ms("2023-03", 10)), "an annotation is found in the months it spans")
+	assert.ElementsMatch(t, []int64{1, 2, 3}, find(ms("2023-01", 1), ms("2023-04", 30)), "an annotation is found once")
+	assert.Empty(t, find(ms("2023-05", 1), ms("2023-06", 1)))
+
+	assert.Equal(t, []string{"2023-11", "2023-12", "2024-01"}, archiveMonths(ms("2023-11", 30), ms("2024-01", 1)))
+}
+
+func TestArchiveFileOverlaps(t *testing.T) {
+	assert.True(t, archiveFileOverlaps("1000-2000-1-4.jsonl.gz", 1500, 3000))
+	assert.True(t, archiveFileOverlaps("1000-2000-1-4.jsonl.gz", 500, 1000))
+	assert.False(t, archiveFileOverlaps("1000-2000-1-4.jsonl.gz", 2001, 3000))
+	assert.False(t, archiveFileOverlaps("1000-2000-1-4.jsonl.gz", 1, 999))
+	assert.False(t, archiveFileOverlaps("1000-2000.jsonl.gz", 1, 3000))
+	assert.False(t, archiveFileOverlaps("1000-2000-1-4.json", 1, 3000))
+}
@@ -21,6 +21,7 @@ func ProvideCleanupService(db db.DB, cfg *setting.Cfg, features featuremgmt.Feat
 			features: features,
 			db:       db,
 			log:      log.New("annotations"),
+			archive:  newArchive(cfg.AnnotationArchiveEnabled, cfg.AnnotationArchivePath, log.New("annotations.archive")),
 		},
 	}
 }
@@ -32,12 +33,13 @@ const (
 )
 
 // Run deletes old annotations created by alert rules, API
-// requests and human made in the UI. It subsequently deletes orphaned rows
-// from the annotation_tag table. Cleanup actions are performed in batches
-// so that no query takes too long to complete.
+// requests and human made in the UI, after archiving them when the
+// archive is enabled. It subsequently deletes orphaned rows
+// from the annotation_tag and annotation_field tables. Cleanup actions are
+// performed in batches so that no query takes too long to complete.
 //
-// Returns the number of annotation and annotation_tag rows deleted. If an
-// error occurs, it returns the number of rows affected so far.
+// Returns the number of annotation rows, and annotation_tag and annotation_field
+// rows deleted. If an error occurs, it returns the number of rows affected so far.
 func (cs *CleanupServiceImpl) Run(ctx context.Context, cfg *setting.Cfg) (int64, int64, error) {
 	var totalCleanedAnnotations int64
 	affected, err := cs.store.CleanAnnotations(ctx, cfg.AlertingAnnotationCleanupSetting, alertAnnotationType)
@@ -57,8 +59,13 @@ func (cs *CleanupServiceImpl) Run(ctx context.Context, cfg *setting.Cfg) (int64,
 	if err != nil {
 		return totalCleanedAnnotations, 0, err
 	}
-	if totalCleanedAnnotations > 0 {
-		affected, err = cs.store.CleanOrphanedAnnotationTags(ctx)
+	if totalCleanedAnnotations == 0 {
+		return 0, 0, nil
 	}
-	return totalCleanedAnnotations, affected, err
+	affectedTags, err := cs.store.CleanOrphanedAnnotationTags(ctx)
+	if err != nil {
+		return totalCleanedAnnotations, affectedTags, err
+	}
+	affectedFields, err := cs.store.CleanOrphanedAnnotationFields(ctx)
+	return totalCleanedAnnotations, affectedTags + affectedFields, err
 }
@@ -0,0 +1,216 @@
+package annotationsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+const (
+	// maxFieldsPerAnnotation is the maximum number of indexed fields of an annotation, the other
+	// fields are kept in the data but can't be filtered
+	maxFieldsPerAnnotation = 64
+	// maxFieldDepth is the maximum depth of the indexed fields
+	maxFieldDepth = 5
+	// maxFieldLength is the maximum length of the keys and the values of the indexed fields
+	maxFieldLength = 190
+)
+
+// annotationField is an indexed field of the data of an annotation. Numbers are also stored in
+// ValueNum so that they can be compared, booleans are stored as true or false.
+type annotationField struct {
+	ID           int64    `xorm:"pk autoincr 'id'"`
+	AnnotationID int64    `xorm:"annotation_id"`
+	OrgID        int64    `xorm:"org_id"`
+	Key          string   `xorm:"key"`
+	Value        string   `xorm:"value"`
+	ValueNum     *float64 `xorm:"value_num"`
+}
+
+func (f annotationField) TableName() string {
+	return "annotation_field"
+}
+
+// extractFields returns the indexed fields of the data of an annotation. Nested objects are
+// flattened to dotted keys, and the items of arrays are indexed under the key of the array.
+// The fields of alert annotations are not indexed.
+func extractFields(item *annotations.Item) []annotationField {
+	if item.Data == nil || item.AlertID != 0 {
+		return nil
+	}
+	fields := []annotationField{}
+	var walk func(prefix string, value any, depth int)
+	walk = func(prefix string, value any, depth int) {
+		if len(fields) >= maxFieldsPerAnnotation || depth > maxFieldDepth {
+			return
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if prefix != "" {
+					walk(prefix+"."+key, v[key], depth+1)
+				} else {
+					walk(key, v[key], depth+1)
+				}
+			}
+		case []any:
+			for _, item := range v {
+				if _, nested := item.(map[string]any); !nested {
+					walk(prefix, item, depth)
+				}
+			}
+		default:
+			if field, ok := newField(prefix, v); ok {
+				fields = append(fields, field)
+			}
+		}
+	}
+	walk("", item.Data.Interface(), 0)
+
+	for i := range fields {
+		fields[i].OrgID = item.OrgID
+	}
+	return fields
+}
+
+func newField(key string, value any) (annotationField, bool) {
+	if key == "" || len(key) > maxFieldLength {
+		return annotationField{}, false
+	}
+	field := annotationField{Key: key}
+	switch v := value.(type) {
+	case string:
+		field.Value = v
+	case bool:
+		field.Value = strconv.FormatBool(v)
+	case json.Number:
+		field.Value = v.String()
+		if n, err := v.Float64(); err == nil {
+			field.ValueNum = &n
+		}
+	case float64:
+		field.Value = strconv.FormatFloat(v, 'f', -1, 64)
+		field.ValueNum = &v
+	case int:
+		n := float64(v)
+		field.Value = strconv.Itoa(v)
+		field.ValueNum = &n
+	case int64:
+		n := float64(v)
+		field.Value = strconv.FormatInt(v, 10)
+		field.ValueNum = &n
+	default:
+		return annotationField{}, false
+	}
+	if len(field.Value) > maxFieldLength {
+		return annotationField{}, false
+	}
+	return field, true
+}
+
+// ensureFields replaces the indexed fields of an annotation with the fields of its data
+func (r *xormRepositoryImpl) ensureFields(ctx context.Context, item *annotations.Item) error {
+	return r.db.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Exec("DELETE FROM annotation_field WHERE annotation_id = ?", item.ID); err != nil {
+			return err
+		}
+		fields := extractFields(item)
+		if len(fields) == 0 {
+			return nil
+		}
+		for i := range fields {
+			fields[i].AnnotationID = item.ID
+		}
+		_, err := sess.InsertMulti(fields)
+		return err
+	})
+}
+
+type fieldFilter struct {
+	key      string
+	operator string
+	value    string
+	number   float64
+}
+
+// fieldOperators are ordered so that the two characters operators are matched first
+var fieldOperators = []string{"!=", ">=", "<=", "=", ">", "<"}
+
+func parseFieldFilter(filter string) (fieldFilter, error) {
+	for _, op := range fieldOperators {
+		key, value, found := strings.Cut(filter, op)
+		if !found {
+			continue
+		}
+		f := fieldFilter{key: strings.TrimSpace(key), operator: op, value: strings.TrimSpace(value)}
+		if f.key == "" {
+			return fieldFilter{}, annotations.ErrInvalidFieldFilter.Errorf("field filter %q has no key", filter)
+		}
+		if op != "=" && op != "!=" {
+			n, err := strconv.ParseFloat(f.value, 64)
+			if err != nil {
+				return fieldFilter{}, annotations.ErrInvalidFieldFilter.Errorf("field filter %q compares to a value which is not a number", filter)
+			}
+			f.number = n
+		}
+		return f, nil
+	}
+	key := strings.TrimSpace(filter)
+	if key == "" {
+		return fieldFilter{}, annotations.ErrInvalidFieldFilter.Errorf("empty field filter")
+	}
+	return fieldFilter{key: key}, nil
+}
+
+// fieldFiltersSQL returns the conditions on the annotation a matching the field filters
+func (r *xormRepositoryImpl) fieldFiltersSQL(filters []string) (string, []any, error) {
+	var sql strings.Builder
+	params := []any{}
+	keyCol := "af." + r.db.GetDialect().Quote("key")
+	valueCol := "af." + r.db.GetDialect().Quote("value")
+	for _, raw := range filters {
+		f, err := parseFieldFilter(raw)
+		if err != nil {
+			return "", nil, err
+		}
+		exists := "EXISTS"
+		condition := ""
+		params = append(params, f.key)
+		switch f.operator {
+		case "":
+		case "=":
+			condition = " AND " + valueCol + " = ?"
+			params = append(params, f.value)
+		case "!=":
+			exists = "NOT EXISTS"
+			condition = " AND " + valueCol + " = ?"
+			params = append(params, f.value)
+		default:
+			condition = fmt.Sprintf(" AND af.value_num %s ?", f.operator)
+			params = append(params, f.number)
+		}
+		sql.WriteString(fmt.Sprintf(" AND %s (SELECT 1 FROM annotation_field af WHERE af.annotation_id = a.id AND %s = ?%s)", exists, keyCol, condition))
+	}
+	return sql.String(), params, nil
+}
+
+// hasData returns true when the data of an annotation has fields
+func hasData(data *simplejson.Json) bool {
+	if data == nil {
+		return false
+	}
+	m, err := data.Map()
+	return err == nil && len(m) > 0
+}
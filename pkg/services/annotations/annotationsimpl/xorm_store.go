@@ -48,6 +48,8 @@ type xormRepositoryImpl struct {
 	log               log.Logger
 	maximumTagsLength int64
 	tagService        tag.Service
+	// archive stores the annotations removed by the cleanup, it is nil when they are deleted
+	archive *archive
 }
 
 func (r *xormRepositoryImpl) Add(ctx context.Context, item *annotations.Item) error {
@@ -66,12 +68,18 @@ func (r *xormRepositoryImpl) Add(ctx context.Context, item *annotations.Item) er
 		if _, err := sess.Table("annotation").Insert(item); err != nil {
 			return err
 		}
-		return r.ensureTags(ctx, item.ID, item.Tags)
+		if err := r.ensureTags(ctx, item.ID, item.Tags); err != nil {
+			return err
+		}
+		if hasData(item.Data) {
+			return r.ensureFields(ctx, item)
+		}
+		return nil
 	})
 }
 
 // AddMany inserts large batches of annotations at once.
-// It does not return IDs associated with created annotations, and it does not support annotations with tags. If you need this functionality, use the single-item Add instead.
+// It does not return IDs associated with created annotations. Annotations with tags or data fields are inserted one by one. If you need the IDs, use the single-item Add instead.
 // This is due to a limitation with some supported databases:
 // We cannot correlate the IDs of batch-inserted records without acquiring a full table lock in MySQL.
 // Annotations have no other uniquifier field, so we also cannot re-query for them after the fact.
@@ -97,7 +105,7 @@ func (r *xormRepositoryImpl) AddMany(ctx context.Context, items []annotations.It
 			return err
 		}
 
-		if len(item.Tags) > 0 {
+		if len(item.Tags) > 0 || len(extractFields(item)) > 0 {
 			hasTags = append(hasTags, *item)
 		} else {
 			hasNoTags = append(hasNoTags, *item)
@@ -119,6 +127,11 @@ func (r *xormRepositoryImpl) AddMany(ctx context.Context, items []annotations.It
 			if err := r.ensureTags(ctx, itemWithID.ID, itemWithID.Tags); err != nil {
 				return err
 			}
+			if hasData(itemWithID.Data) {
+				if err := r.ensureFields(ctx, itemWithID); err != nil {
+					return err
+				}
+			}
 		}
 
 		return nil
@@ -160,6 +173,9 @@ func (r *xormRepositoryImpl) update(ctx context.Context, item *annotations.Item)
 
 		if item.Data != nil {
 			existing.Data = item.Data
+			if err := r.ensureFields(ctx, existing); err != nil {
+				return err
+			}
 		}
 
 		if item.Tags != nil {
@@ -267,84 +283,12 @@ func (r *xormRepositoryImpl) Get(ctx context.Context, query *annotations.ItemQue
 				SELECT a.id from annotation a
 			`)
 
-		sql.WriteString(`WHERE a.org_id = ?`)
-		params = append(params, query.OrgID)
-
-		if query.AnnotationID != 0 {
-			// fmt.Print("annotation query")
-			sql.WriteString(` AND a.id = ?`)
-			params = append(params, query.AnnotationID)
-		}
-
-		if query.AlertID != 0 {
-			sql.WriteString(` AND a.alert_id = ?`)
-			params = append(params, query.AlertID)
-		}
-
-		if query.DashboardID != 0 {
-			sql.WriteString(` AND a.dashboard_id = ?`)
-			params = append(params, query.DashboardID)
-		}
-
-		if query.PanelID != 0 {
-			sql.WriteString(` AND a.panel_id = ?`)
-			params = append(params, query.PanelID)
-		}
-
-		if query.UserID != 0 {
-			sql.WriteString(` AND a.user_id = ?`)
-			params = append(params, query.UserID)
-		}
-
-		if query.From > 0 && query.To > 0 {
-			sql.WriteString(` AND a.epoch <= ? AND a.epoch_end >= ?`)
-			params = append(params, query.To, query.From)
-		}
-
-		if query.Type == "alert" {
-			sql.WriteString(` AND a.alert_id > 0`)
-		} else if query.Type == "annotation" {
-			sql.WriteString(` AND a.alert_id = 0`)
-		}
-
-		if len(query.Tags) > 0 {
-			keyValueFilters := []string{}
-
-			tags := tag.ParseTagPairs(query.Tags)
-			for _, tag := range tags {
-				if tag.Value == "" {
-					keyValueFilters = append(keyValueFilters, "(tag."+r.db.GetDialect().Quote("key")+" = ?)")
-					params = append(params, tag.Key)
-				} else {
-					keyValueFilters = append(keyValueFilters, "(tag."+r.db.GetDialect().Quote("key")+" = ? AND tag."+r.db.GetDialect().Quote("value")+" = ?)")
-					params = append(params, tag.Key, tag.Value)
-				}
-			}
-
-			if len(tags) > 0 {
-				tagsSubQuery := fmt.Sprintf(`
-			SELECT SUM(1) FROM annotation_tag at
-			INNER JOIN tag on tag.id = at.tag_id
-			WHERE at.annotation_id = a.id
-				AND (
-				%s
-				)
-		`, strings.Join(keyValueFilters, " OR "))
-
-				if query.MatchAny {
-					sql.WriteString(fmt.Sprintf(" AND (%s) > 0 ", tagsSubQuery))
-				} else {
-					sql.WriteString(fmt.Sprintf(" AND (%s) = %d ", tagsSubQuery, len(tags)))
-				}
-			}
-		}
-
-		acFilter, err := r.getAccessControlFilter(query.SignedInUser)
+		where, whereParams, acFilter, err := r.filterSQL(query)
 		if err != nil {
 			return err
 		}
-		sql.WriteString(fmt.Sprintf(" AND (%s)", acFilter.where))
-		params = append(params, acFilter.whereParams...)
+		sql.WriteString(where)
+		params = append(params, whereParams...)
 
 		if query.Limit == 0 {
 			query.Limit = 100
@@ -371,6 +315,103 @@ func (r *xormRepositoryImpl) Get(ctx context.Context, query *annotations.ItemQue
 	return items, err
 }
 
+// filterSQL returns the WHERE clause of the annotations a matching a query, and the access
+// control filter whose recursive queries must prefix the SQL query
+func (r *xormRepositoryImpl) filterSQL(query *annotations.ItemQuery) (string, []any, acFilter, error) {
+	var sql bytes.Buffer
+	params := make([]any, 0)
+
+	sql.WriteString(`WHERE a.org_id = ?`)
+	params = append(params, query.OrgID)
+
+	if query.AnnotationID != 0 {
+		// fmt.Print("annotation query")
+		sql.WriteString(` AND a.id = ?`)
+		params = append(params, query.AnnotationID)
+	}
+
+	if query.AlertID != 0 {
+		sql.WriteString(` AND a.alert_id = ?`)
+		params = append(params, query.AlertID)
+	}
+
+	if query.DashboardID != 0 {
+		sql.WriteString(` AND a.dashboard_id = ?`)
+		params = append(params, query.DashboardID)
+	}
+
+	if query.PanelID != 0 {
+		sql.WriteString(` AND a.panel_id = ?`)
+		params = append(params, query.PanelID)
+	}
+
+	if query.UserID != 0 {
+		sql.WriteString(` AND a.user_id = ?`)
+		params = append(params, query.UserID)
+	}
+
+	if query.From > 0 && query.To > 0 {
+		sql.WriteString(` AND a.epoch <= ? AND a.epoch_end >= ?`)
+		params = append(params, query.To, query.From)
+	}
+
+	if query.Type == "alert" {
+		sql.WriteString(` AND a.alert_id > 0`)
+	} else if query.Type == "annotation" {
+		sql.WriteString(` AND a.alert_id = 0`)
+	}
+
+	if len(query.Tags) > 0 {
+		keyValueFilters := []string{}
+
+		tags := tag.ParseTagPairs(query.Tags)
+		for _, tag := range tags {
+			if tag.Value == "" {
+				keyValueFilters = append(keyValueFilters, "(tag."+r.db.GetDialect().Quote("key")+" = ?)")
+				params = append(params, tag.Key)
+			} else {
+				keyValueFilters = append(keyValueFilters, "(tag."+r.db.GetDialect().Quote("key")+" = ? AND tag."+r.db.GetDialect().Quote("value")+" = ?)")
+				params = append(params, tag.Key, tag.Value)
+			}
+		}
+
+		if len(tags) > 0 {
+			tagsSubQuery := fmt.Sprintf(`
+		SELECT SUM(1) FROM annotation_tag at
+		INNER JOIN tag on tag.id = at.tag_id
+		WHERE at.annotation_id = a.id
+			AND (
+			%s
+			)
+	`, strings.Join(keyValueFilters, " OR "))
+
+			if query.MatchAny {
+				sql.WriteString(fmt.Sprintf(" AND (%s) > 0 ", tagsSubQuery))
+			} else {
+				sql.WriteString(fmt.Sprintf(" AND (%s) = %d ", tagsSubQuery, len(tags)))
+			}
+		}
+	}
+
+	if len(query.Fields) > 0 {
+		fieldsSQL, fieldsParams, err := r.fieldFiltersSQL(query.Fields)
+		if err != nil {
+			return "", nil, acFilter{}, err
+		}
+		sql.WriteString(fieldsSQL)
+		params = append(params, fieldsParams...)
+	}
+
+	acFilter, err := r.getAccessControlFilter(query.SignedInUser)
+	if err != nil {
+		return "", nil, acFilter, err
+	}
+	sql.WriteString(fmt.Sprintf(" AND (%s)", acFilter.where))
+	params = append(params, acFilter.whereParams...)
+
+	return sql.String(), params, acFilter, nil
+}
+
 type acFilter struct {
 	where       string
 	whereParams []interface{}
@@ -447,6 +488,10 @@ func (r *xormRepositoryImpl) Delete(ctx context.Context, params *annotations.Del
 				return err
 			}
 
+			if _, err := sess.Exec("DELETE FROM annotation_field WHERE annotation_id = ? AND org_id = ?", params.ID, params.OrgID); err != nil {
+				return err
+			}
+
 			if _, err := sess.Exec(sql, params.ID, params.OrgID); err != nil {
 				return err
 			}
@@ -458,6 +503,11 @@ func (r *xormRepositoryImpl) Delete(ctx context.Context, params *annotations.Del
 				return err
 			}
 
+			annoFieldSQL := "DELETE FROM annotation_field WHERE annotation_id IN (SELECT id FROM annotation WHERE dashboard_id = ? AND panel_id = ? AND org_id = ?)"
+			if _, err := sess.Exec(annoFieldSQL, params.DashboardID, params.PanelID, params.OrgID); err != nil {
+				return err
+			}
+
 			if _, err := sess.Exec(sql, params.DashboardID, params.PanelID, params.OrgID); err != nil {
 				return err
 			}
@@ -551,10 +601,10 @@ func (r *xormRepositoryImpl) CleanAnnotations(ctx context.Context, cfg setting.A
 	var totalAffected int64
 	if cfg.MaxAge > 0 {
 		cutoffDate := time.Now().Add(-cfg.MaxAge).UnixNano() / int64(time.Millisecond)
-		deleteQuery := `DELETE FROM annotation WHERE id IN (SELECT id FROM (SELECT id FROM annotation WHERE %s AND created < %v ORDER BY id DESC %s) a)`
-		sql := fmt.Sprintf(deleteQuery, annotationType, cutoffDate, r.db.GetDialect().Limit(r.cfg.AnnotationCleanupJobBatchSize))
+		selectQuery := `SELECT id FROM annotation WHERE %s AND created < %v ORDER BY id DESC %s`
+		sql := fmt.Sprintf(selectQuery, annotationType, cutoffDate, r.db.GetDialect().Limit(r.cfg.AnnotationCleanupJobBatchSize))
 
-		affected, err := r.executeUntilDoneOrCancelled(ctx, sql)
+		affected, err := r.cleanUntilDoneOrCancelled(ctx, sql)
 		totalAffected += affected
 		if err != nil {
 			return totalAffected, err
@@ -562,9 +612,9 @@ func (r *xormRepositoryImpl) CleanAnnotations(ctx context.Context, cfg setting.A
 	}
 
 	if cfg.MaxCount > 0 {
-		deleteQuery := `DELETE FROM annotation WHERE id IN (SELECT id FROM (SELECT id FROM annotation WHERE %s ORDER BY id DESC %s) a)`
-		sql := fmt.Sprintf(deleteQuery, annotationType, r.db.GetDialect().LimitOffset(r.cfg.AnnotationCleanupJobBatchSize, cfg.MaxCount))
-		affected, err := r.executeUntilDoneOrCancelled(ctx, sql)
+		selectQuery := `SELECT id FROM annotation WHERE %s ORDER BY id DESC %s`
+		sql := fmt.Sprintf(selectQuery, annotationType, r.db.GetDialect().LimitOffset(r.cfg.AnnotationCleanupJobBatchSize, cfg.MaxCount))
+		affected, err := r.cleanUntilDoneOrCancelled(ctx, sql)
 		totalAffected += affected
 		return totalAffected, err
 	}
@@ -572,12 +622,27 @@ func (r *xormRepositoryImpl) CleanAnnotations(ctx context.Context, cfg setting.A
 	return totalAffected, nil
 }
 
+// cleanUntilDoneOrCancelled removes the annotations selected by a query in batches, and archives
+// them when the archive is enabled
+func (r *xormRepositoryImpl) cleanUntilDoneOrCancelled(ctx context.Context, selectQuery string) (int64, error) {
+	if r.archive != nil {
+		return r.archiveUntilDoneOrCancelled(ctx, selectQuery)
+	}
+	return r.executeUntilDoneOrCancelled(ctx, fmt.Sprintf(`DELETE FROM annotation WHERE id IN (SELECT id FROM (%s) a)`, selectQuery))
+}
+
 func (r *xormRepositoryImpl) CleanOrphanedAnnotationTags(ctx context.Context) (int64, error) {
 	deleteQuery := `DELETE FROM annotation_tag WHERE id IN ( SELECT id FROM (SELECT id FROM annotation_tag WHERE NOT EXISTS (SELECT 1 FROM annotation a WHERE annotation_id = a.id) %s) a)`
 	sql := fmt.Sprintf(deleteQuery, r.db.GetDialect().Limit(r.cfg.AnnotationCleanupJobBatchSize))
 	return r.executeUntilDoneOrCancelled(ctx, sql)
 }
 
+func (r *xormRepositoryImpl) CleanOrphanedAnnotationFields(ctx context.Context) (int64, error) {
+	deleteQuery := `DELETE FROM annotation_field WHERE id IN ( SELECT id FROM (SELECT id FROM annotation_field WHERE NOT EXISTS (SELECT 1 FROM annotation a WHERE annotation_id = a.id) %s) a)`
+	sql := fmt.Sprintf(deleteQuery, r.db.GetDialect().Limit(r.cfg.AnnotationCleanupJobBatchSize))
+	return r.executeUntilDoneOrCancelled(ctx, sql)
+}
+
 func (r *xormRepositoryImpl) executeUntilDoneOrCancelled(ctx context.Context, sql string) (int64, error) {
 	var totalAffected int64
 	for {
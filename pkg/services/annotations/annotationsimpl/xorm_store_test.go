@@ -967,3 +967,189 @@ func benchmarkFindTags(b *testing.B, numAnnotations int) {
 		require.Equal(b, int64(1), result.Tags[1].Count)
 	}
 }
+
+func TestIntegrationAnnotationFields(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sql := db.InitTestDB(t)
+	repo := xormRepositoryImpl{db: sql, cfg: setting.NewCfg(), log: log.New("annotation.test"), tagService: tagimpl.ProvideService(sql), maximumTagsLength: 60,
+		features: featuremgmt.WithFeatures(),
+	}
+
+	testUser := &user.SignedInUser{
+		OrgID: 1,
+		Permissions: map[int64]map[string][]string{
+			1: {
+				accesscontrol.ActionAnnotationsRead: []string{accesscontrol.ScopeAnnotationsAll},
+				dashboards.ActionDashboardsRead:     []string{dashboards.ScopeDashboardsAll},
+			},
+		},
+	}
+
+	deploys := []struct {
+		epoch    int64
+		service  string
+		duration int
+		tags     []string
+	}{
+		{epoch: 1000, service: "api", duration: 30, tags: []string{"deploy", "env:prod"}},
+		{epoch: 2000, service: "api", duration: 90, tags: []string{"deploy", "env:dev"}},
+		{epoch: 3600_000 + 1000, service: "web", duration: 45, tags: []string{"deploy", "env:prod"}},
+	}
+	ids := make([]int64, 0, len(deploys))
+	for _, d := range deploys {
+		item := &annotations.Item{
+			OrgID:    1,
+			UserID:   1,
+			Text:     "deploy " + d.service,
+			Epoch:    d.epoch,
+			EpochEnd: d.epoch + 500,
+			Tags:     d.tags,
+			Data: simplejson.NewFromAny(map[string]any{
+				"deploy":   map[string]any{"service": d.service, "regions": []any{"eu", "us"}},
+				"duration": d.duration,
+			}),
+		}
+		require.NoError(t, repo.Add(context.Background(), item))
+		ids = append(ids, item.ID)
+	}
+	require.NoError(t, repo.Add(context.Background(), &annotations.Item{OrgID: 1, UserID: 1, Text: "no data", Epoch: 1500, Tags: []string{"note"}}))
+
+	find := func(t *testing.T, fields ...string) []int64 {
+		t.Helper()
+		items, err := repo.Get(context.Background(), &annotations.ItemQuery{OrgID: 1, Fields: fields, SignedInUser: testUser})
+		require.NoError(t, err)
+		found := make([]int64, 0, len(items))
+		for _, item := range items {
+			found = append(found, item.ID)
+		}
+		return found
+	}
+
+	t.Run("Can filter annotations by fields", func(t *testing.T) {
+		assert.ElementsMatch(t, []int64{ids[0], ids[1]}, find(t, "deploy.service=api"))
+		assert.ElementsMatch(t, []int64{ids[2]}, find(t, "deploy.service!=api", "deploy.regions"))
+		assert.ElementsMatch(t, []int64{ids[1], ids[2]}, find(t, "duration>=45"))
+		assert.ElementsMatch(t, []int64{ids[0]}, find(t, "duration<45", "deploy.regions=eu"))
+		assert.ElementsMatch(t, ids, find(t, "deploy.service"))
+		assert.Empty(t, find(t, "deploy.service=db"))
+	})
+
+	t.Run("Should fail on invalid field filters", func(t *testing.T) {
+		_, err := repo.Get(context.Background(), &annotations.ItemQuery{OrgID: 1, Fields: []string{"duration>long"}, SignedInUser: testUser})
+		require.ErrorIs(t, err, annotations.ErrInvalidFieldFilter)
+	})
+
+	t.Run("Should index the fields again when the data is updated", func(t *testing.T) {
+		err := repo.Update(context.Background(), &annotations.Item{
+			ID:    ids[1],
+			OrgID: 1,
+			Text:  "deploy db",
+			Tags:  deploys[1].tags,
+			Data:  simplejson.NewFromAny(map[string]any{"deploy": map[string]any{"service": "db"}, "duration": 90}),
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{ids[1]}, find(t, "deploy.service=db"))
+		assert.ElementsMatch(t, []int64{ids[0]}, find(t, "deploy.service=api"))
+	})
+
+	t.Run("Can count annotations per tag and interval", func(t *testing.T) {
+		result, err := repo.Aggregate(context.Background(), &annotations.AggregateQuery{
+			ItemQuery: annotations.ItemQuery{OrgID: 1, Tags: []string{"deploy"}, SignedInUser: testUser},
+			Interval:  3600_000,
+			GroupBy:   annotations.GroupByTag,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []annotations.AggregateBucket{
+			{Time: 0, Group: "deploy", Count: 2, Duration: 1000},
+			{Time: 0, Group: "env:dev", Count: 1, Duration: 500},
+			{Time: 0, Group: "env:prod", Count: 1, Duration: 500},
+			{Time: 3600_000, Group: "deploy", Count: 1, Duration: 500},
+			{Time: 3600_000, Group: "env:prod", Count: 1, Duration: 500},
+		}, result.Buckets)
+	})
+
+	t.Run("Can count annotations per field value", func(t *testing.T) {
+		result, err := repo.Aggregate(context.Background(), &annotations.AggregateQuery{
+			ItemQuery: annotations.ItemQuery{OrgID: 1, SignedInUser: testUser},
+			GroupBy:   annotations.GroupByFieldPrefix + "deploy.service",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []annotations.AggregateBucket{
+			{Group: "api", Count: 1, Duration: 500},
+			{Group: "db", Count: 1, Duration: 500},
+			{Group: "web", Count: 1, Duration: 500},
+		}, result.Buckets)
+
+		result, err = repo.Aggregate(context.Background(), &annotations.AggregateQuery{
+			ItemQuery: annotations.ItemQuery{OrgID: 1, SignedInUser: testUser},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []annotations.AggregateBucket{{Count: 4, Duration: 1500}}, result.Buckets)
+
+		_, err = repo.Aggregate(context.Background(), &annotations.AggregateQuery{
+			ItemQuery: annotations.ItemQuery{OrgID: 1, SignedInUser: testUser},
+			GroupBy:   "dashboard",
+		})
+		require.ErrorIs(t, err, annotations.ErrInvalidGroupBy)
+	})
+
+	t.Run("Should delete the fields with the annotation", func(t *testing.T) {
+		require.NoError(t, repo.Delete(context.Background(), &annotations.DeleteParams{ID: ids[0], OrgID: 1}))
+		assert.Empty(t, find(t, "deploy.service=api"))
+
+		var count int64
+		err := sql.WithDbSession(context.Background(), func(sess *db.Session) error {
+			var err error
+			count, err = sess.Table("annotation_field").Where("annotation_id = ?", ids[0]).Count()
+			return err
+		})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
+
+func TestParseFieldFilter(t *testing.T) {
+	f, err := parseFieldFilter("deploy.service = api")
+	require.NoError(t, err)
+	assert.Equal(t, fieldFilter{key: "deploy.service", operator: "=", value: "api"}, f)
+
+	f, err = parseFieldFilter("duration>=1.5")
+	require.NoError(t, err)
+	assert.Equal(t, fieldFilter{key: "duration", operator: ">=", value: "1.5", number: 1.5}, f)
+
+	f, err = parseFieldFilter("version!=2")
+	require.NoError(t, err)
+	assert.Equal(t, fieldFilter{key: "version", operator: "!=", value: "2"}, f)
+
+	f, err = parseFieldFilter("deploy")
+	require.NoError(t, err)
+	assert.Equal(t, fieldFilter{key: "deploy"}, f)
+
+	_, err = parseFieldFilter("=api")
+	require.ErrorIs(t, err, annotations.ErrInvalidFieldFilter)
+	_, err = parseFieldFilter("duration<short")
+	require.ErrorIs(t, err, annotations.ErrInvalidFieldFilter)
+}
+
+func TestExtractFields(t *testing.T) {
+	item := &annotations.Item{OrgID: 2, Data: simplejson.NewFromAny(map[string]any{
+		"deploy":  map[string]any{"service": "api", "canary": true},
+		"tags":    []any{"a", "b", map[string]any{"skipped": 1}},
+		"retries": 3,
+		"empty":   nil,
+	})}
+	fields := extractFields(item)
+	three := 3.0
+	assert.Equal(t, []annotationField{
+		{OrgID: 2, Key: "deploy.canary", Value: "true"},
+		{OrgID: 2, Key: "deploy.service", Value: "api"},
+		{OrgID: 2, Key: "retries", Value: "3", ValueNum: &three},
+		{OrgID: 2, Key: "tags", Value: "a"},
+		{OrgID: 2, Key: "tags", Value: "b"},
+	}, fields)
+
+	item.AlertID = 1
+	assert.Empty(t, extractFields(item))
+}
@@ -83,6 +83,10 @@ func (repo *fakeAnnotationsRepo) FindTags(_ context.Context, query *annotations.
 	return result, nil
 }
 
+func (repo *fakeAnnotationsRepo) Aggregate(_ context.Context, query *annotations.AggregateQuery) (annotations.AggregateResult, error) {
+	return annotations.AggregateResult{Buckets: []annotations.AggregateBucket{}}, nil
+}
+
 func (repo *fakeAnnotationsRepo) Len() int {
 	repo.mtx.Lock()
 	defer repo.mtx.Unlock()
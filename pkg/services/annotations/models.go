@@ -18,11 +18,47 @@ type ItemQuery struct {
 	Tags         []string `json:"tags"`
 	Type         string   `json:"type"`
 	MatchAny     bool     `json:"matchAny"`
+	// Fields filters the annotations on the fields of their data, for example
+	// deploy.service=api, duration>=30 or deploy.canary to only check that the field is set.
+	// The operators are =, !=, >, >=, < and <=, the numeric ones only match numeric fields.
+	Fields       []string `json:"fields"`
 	SignedInUser identity.Requester
 
 	Limit int64 `json:"limit"`
 }
 
+// AggregateQuery counts the annotations matching the filters of ItemQuery, per time bucket and group.
+// The Limit of ItemQuery is ignored.
+type AggregateQuery struct {
+	ItemQuery
+	// Interval is the size of the time buckets in milliseconds. The annotations are counted in the
+	// bucket of their start time. All the annotations are counted in one bucket when it is 0.
+	Interval int64 `json:"interval"`
+	// GroupBy counts the annotations per tag when it is "tag", or per value of a field when it is
+	// "field:<key>". The annotations are not grouped when it is empty.
+	GroupBy string `json:"groupBy"`
+}
+
+const (
+	GroupByTag         = "tag"
+	GroupByFieldPrefix = "field:"
+)
+
+// AggregateBucket is the number of annotations of a time bucket and group
+type AggregateBucket struct {
+	// Time is the start of the bucket in milliseconds, 0 when the query has no interval
+	Time  int64  `json:"time"`
+	Group string `json:"group,omitempty"`
+	Count int64  `json:"count"`
+	// Duration is the total duration of the regions of the bucket in milliseconds
+	Duration int64 `json:"duration"`
+}
+
+// AggregateResult is the result of an aggregate query, with the buckets ordered by time and group
+type AggregateResult struct {
+	Buckets []AggregateBucket `json:"buckets"`
+}
+
 // TagsQuery is the query for a tags search.
 type TagsQuery struct {
 	OrgID int64  `json:"orgId"`
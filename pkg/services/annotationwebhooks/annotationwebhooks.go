@@ -0,0 +1,19 @@
+package annotationwebhooks
+
+import (
+	"context"
+)
+
+// Service stores the annotation webhooks of the organizations and turns the payloads they
+// receive from CI/CD and incident tools into annotations.
+type Service interface {
+	Create(ctx context.Context, cmd *CreateHookCommand) (*HookWithSecret, error)
+	Update(ctx context.Context, cmd *UpdateHookCommand) (*Hook, error)
+	Get(ctx context.Context, query *GetHookQuery) (*Hook, error)
+	List(ctx context.Context, query *ListHooksQuery) ([]*Hook, error)
+	Delete(ctx context.Context, cmd *DeleteHookCommand) error
+	// RotateSecret replaces the secret of a hook with a random one.
+	RotateSecret(ctx context.Context, cmd *RotateSecretCommand) (*HookWithSecret, error)
+	// Ingest authenticates a payload and saves the annotations it maps to.
+	Ingest(ctx context.Context, cmd *IngestCommand) (*IngestResult, error)
+}
@@ -0,0 +1,274 @@
+package annotationwebhooksimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/annotationwebhooks"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// secretLength is the length of the generated secrets
+const secretLength = 40
+
+const (
+	deliveryCreated      = "created"
+	deliveryIgnored      = "ignored"
+	deliveryUnauthorized = "unauthorized"
+	deliveryInvalid      = "invalid"
+	deliveryFailed       = "failed"
+)
+
+// Service stores the annotation webhooks and saves the annotations of the payloads they
+// receive through the annotations repository.
+type Service struct {
+	store            store
+	log              log.Logger
+	secretsService   secrets.Service
+	annotationsRepo  annotations.Repository
+	dashboardService dashboards.DashboardService
+
+	enabled        bool
+	maxPayloadSize int64
+	deliveries     *prometheus.CounterVec
+	now            func() time.Time
+}
+
+var _ annotationwebhooks.Service = (*Service)(nil)
+
+func ProvideService(cfg *setting.Cfg, db db.DB, routeRegister routing.RouteRegister, secretsService secrets.Service,
+	annotationsRepo annotations.Repository, dashboardService dashboards.DashboardService, registerer prometheus.Registerer) *Service {
+	section := cfg.SectionWithEnvOverrides("annotation_webhooks")
+	s := &Service{
+		store:            &sqlStore{db: db},
+		log:              log.New("annotation-webhooks"),
+		secretsService:   secretsService,
+		annotationsRepo:  annotationsRepo,
+		dashboardService: dashboardService,
+		enabled:          section.Key("enabled").MustBool(true),
+		maxPayloadSize:   section.Key("max_payload_mb").MustInt64(1) * 1024 * 1024,
+		now:              time.Now,
+	}
+	s.deliveries = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "annotation_webhook_deliveries_total",
+		Help:      "Number of payloads received by the annotation webhooks, by format and status.",
+	}, []string{"format", "status"})
+
+	if s.enabled {
+		s.registerAPIEndpoints(routeRegister)
+	}
+
+	return s
+}
+
+func (s *Service) Create(ctx context.Context, cmd *annotationwebhooks.CreateHookCommand) (*annotationwebhooks.HookWithSecret, error) {
+	if err := s.validate(ctx, cmd.OrgID, &cmd.HookSettings); err != nil {
+		return nil, err
+	}
+
+	secret := cmd.Secret
+	if secret == "" {
+		var err error
+		if secret, err = util.GetRandomString(secretLength); err != nil {
+			return nil, err
+		}
+	}
+
+	now := s.now()
+	hook := &annotationwebhooks.Hook{
+		UID:     util.GenerateShortUID(),
+		OrgID:   cmd.OrgID,
+		Created: now,
+	}
+	s.apply(hook, &cmd.HookSettings, now)
+	if err := s.setSecret(ctx, hook, secret); err != nil {
+		return nil, err
+	}
+	if err := s.store.Insert(ctx, hook); err != nil {
+		return nil, err
+	}
+	return &annotationwebhooks.HookWithSecret{Hook: hook, Secret: secret}, nil
+}
+
+func (s *Service) Update(ctx context.Context, cmd *annotationwebhooks.UpdateHookCommand) (*annotationwebhooks.Hook, error) {
+	if err := s.validate(ctx, cmd.OrgID, &cmd.HookSettings); err != nil {
+		return nil, err
+	}
+	hook, err := s.store.Get(ctx, &annotationwebhooks.GetHookQuery{UID: cmd.UID, OrgID: cmd.OrgID})
+	if err != nil {
+		return nil, err
+	}
+
+	s.apply(hook, &cmd.HookSettings, s.now())
+	if cmd.Secret != "" {
+		if err := s.setSecret(ctx, hook, cmd.Secret); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.store.Update(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *Service) Get(ctx context.Context, query *annotationwebhooks.GetHookQuery) (*annotationwebhooks.Hook, error) {
+	return s.store.Get(ctx, query)
+}
+
+func (s *Service) List(ctx context.Context, query *annotationwebhooks.ListHooksQuery) ([]*annotationwebhooks.Hook, error) {
+	return s.store.List(ctx, query)
+}
+
+func (s *Service) Delete(ctx context.Context, cmd *annotationwebhooks.DeleteHookCommand) error {
+	return s.store.Delete(ctx, cmd)
+}
+
+func (s *Service) RotateSecret(ctx context.Context, cmd *annotationwebhooks.RotateSecretCommand) (*annotationwebhooks.HookWithSecret, error) {
+	hook, err := s.store.Get(ctx, &annotationwebhooks.GetHookQuery{UID: cmd.UID, OrgID: cmd.OrgID})
+	if err != nil {
+		return nil, err
+	}
+	secret, err := util.GetRandomString(secretLength)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setSecret(ctx, hook, secret); err != nil {
+		return nil, err
+	}
+	hook.Updated = s.now()
+	if err := s.store.Update(ctx, hook); err != nil {
+		return nil, err
+	}
+	return &annotationwebhooks.HookWithSecret{Hook: hook, Secret: secret}, nil
+}
+
+func (s *Service) Ingest(ctx context.Context, cmd *annotationwebhooks.IngestCommand) (*annotationwebhooks.IngestResult, error) {
+	hook, err := s.store.GetByUID(ctx, cmd.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, status, err := s.ingest(ctx, hook, cmd)
+	s.deliveries.WithLabelValues(string(hook.Format), status).Inc()
+	if err != nil {
+		s.log.Warn("Failed to ingest webhook payload", "orgId", hook.OrgID, "uid", hook.UID, "status", status, "error", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// ingest returns the result of a payload, with the status of the delivery for the metrics
+func (s *Service) ingest(ctx context.Context, hook *annotationwebhooks.Hook, cmd *annotationwebhooks.IngestCommand) (*annotationwebhooks.IngestResult, string, error) {
+	secret, err := s.secretsService.Decrypt(ctx, hook.Secret)
+	if err != nil {
+		return nil, deliveryFailed, err
+	}
+	if err := verify(hook.Format, secret, cmd.Header, cmd.Body); err != nil {
+		return nil, deliveryUnauthorized, err
+	}
+	if !hook.Enabled {
+		return nil, deliveryIgnored, annotationwebhooks.ErrHookDisabled.Errorf("webhook %s is disabled", hook.UID)
+	}
+
+	result := &annotationwebhooks.IngestResult{AnnotationIDs: []int64{}}
+	mapping, ok := mappingFor(hook, cmd.Header)
+	if !ok {
+		return result, deliveryIgnored, nil
+	}
+
+	var payload any
+	decoder := json.NewDecoder(bytes.NewReader(cmd.Body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, deliveryInvalid, annotationwebhooks.ErrInvalidPayload.Errorf("payload is not valid JSON: %w", err)
+	}
+
+	mapped, err := applyMapping(hook, mapping, payload, s.now())
+	if err != nil {
+		return nil, deliveryInvalid, err
+	}
+	if len(mapped) == 0 {
+		return result, deliveryIgnored, nil
+	}
+
+	dashboardIDs := map[string]int64{}
+	for i := range mapped {
+		m := &mapped[i]
+		if m.dashboardUID != "" {
+			id, ok := dashboardIDs[m.dashboardUID]
+			if !ok {
+				dashboard, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{OrgID: hook.OrgID, UID: m.dashboardUID})
+				if errors.Is(err, dashboards.ErrDashboardNotFound) {
+					return nil, deliveryInvalid, annotationwebhooks.ErrInvalidPayload.Errorf("dashboard %s not found", m.dashboardUID)
+				}
+				if err != nil {
+					return nil, deliveryFailed, err
+				}
+				id = dashboard.ID
+				dashboardIDs[m.dashboardUID] = id
+			}
+			m.item.DashboardID = id
+		}
+	}
+
+	for i := range mapped {
+		if err := s.annotationsRepo.Save(ctx, &mapped[i].item); err != nil {
+			return nil, deliveryFailed, err
+		}
+		result.AnnotationIDs = append(result.AnnotationIDs, mapped[i].item.ID)
+	}
+	return result, deliveryCreated, nil
+}
+
+// validate checks the settings, the templates of their mapping and their dashboard.
+func (s *Service) validate(ctx context.Context, orgID int64, settings *annotationwebhooks.HookSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if err := validateMapping(settings.Mapping); err != nil {
+		return err
+	}
+	if settings.DashboardUID != "" {
+		_, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{OrgID: orgID, UID: settings.DashboardUID})
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return annotationwebhooks.ErrInvalidHook.Errorf("dashboard %s not found", settings.DashboardUID)
+		}
+		return err
+	}
+	return nil
+}
+
+// apply copies the settings to the hook, except the secret.
+func (s *Service) apply(hook *annotationwebhooks.Hook, settings *annotationwebhooks.HookSettings, now time.Time) {
+	hook.Name = settings.Name
+	hook.Format = settings.Format
+	hook.Mapping = settings.Mapping
+	hook.DashboardUID = settings.DashboardUID
+	hook.PanelID = settings.PanelID
+	hook.Tags = settings.Tags
+	hook.Enabled = settings.Enabled
+	hook.Updated = now
+}
+
+func (s *Service) setSecret(ctx context.Context, hook *annotationwebhooks.Hook, secret string) error {
+	encrypted, err := s.secretsService.Encrypt(ctx, []byte(secret), secrets.WithoutScope())
+	if err != nil {
+		return err
+	}
+	hook.Secret = encrypted
+	return nil
+}
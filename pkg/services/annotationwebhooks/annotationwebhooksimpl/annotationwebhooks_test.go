@@ -0,0 +1,342 @@
+package annotationwebhooksimpl
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/annotationwebhooks"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeRepo records the saved annotations.
+type fakeRepo struct {
+	annotations.Repository
+
+	mu    sync.Mutex
+	items []annotations.Item
+}
+
+func (r *fakeRepo) Save(_ context.Context, item *annotations.Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	item.ID = int64(len(r.items) + 1)
+	r.items = append(r.items, *item)
+	return nil
+}
+
+func setupService(t *testing.T) (*Service, *fakeRepo) {
+	t.Helper()
+
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).Return(
+		func(_ context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+			if query.UID != "prod" || query.OrgID != 1 {
+				return nil, dashboards.ErrDashboardNotFound
+			}
+			return &dashboards.Dashboard{ID: 42, UID: "prod", OrgID: 1}, nil
+		}).Maybe()
+
+	repo := &fakeRepo{}
+	s := ProvideService(setting.NewCfg(), db.InitTestDB(t), routing.NewRouteRegister(), fakes.NewFakeSecretsService(),
+		repo, dashboardService, prometheus.NewRegistry())
+	s.now = func() time.Time { return time.Date(2023, 10, 2, 10, 0, 0, 0, time.UTC) }
+	return s, repo
+}
+
+func signed(secret string, header http.Header, body string) *annotationwebhooks.IngestCommand {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(annotationwebhooks.HeaderGenericSignature, "sha256="+hex.EncodeToString(sum([]byte(secret), []byte(body))))
+	header.Set(annotationwebhooks.HeaderGitHubSignature, header.Get(annotationwebhooks.HeaderGenericSignature))
+	return &annotationwebhooks.IngestCommand{Header: header, Body: []byte(body)}
+}
+
+func eventHeader(key, event string) http.Header {
+	header := http.Header{}
+	header.Set(key, event)
+	return header
+}
+
+func TestIntegrationIngest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Run("generic payloads have the fields of the annotations API by default", func(t *testing.T) {
+		s, repo := setupService(t)
+		hook, err := s.Create(context.Background(), &annotationwebhooks.CreateHookCommand{
+			OrgID:        1,
+			HookSettings: annotationwebhooks.HookSettings{Name: "deploys", Format: annotationwebhooks.FormatGeneric, Tags: []string{"ci"}, Enabled: true},
+		})
+		require.NoError(t, err)
+		require.Len(t, hook.Secret, secretLength)
+
+		cmd := signed(hook.Secret, nil, `{"text": "Deploy api", "time": 1696240000000, "timeEnd": 1696240060000, "tags": ["deploy", "ci"], "dashboardUID": "prod", "panelId": 2}`)
+		cmd.UID = hook.UID
+		result, err := s.Ingest(context.Background(), cmd)
+		require.NoError(t, err)
+		require.Equal(t, []int64{1}, result.AnnotationIDs)
+
+		require.Len(t, repo.items, 1)
+		item := repo.items[0]
+		require.Equal(t, int64(1), item.OrgID)
+		require.Equal(t, "Deploy api", item.Text)
+		require.Equal(t, int64(1696240000000), item.Epoch)
+		require.Equal(t, int64(1696240060000), item.EpochEnd)
+		require.Equal(t, []string{"ci", "deploy"}, item.Tags)
+		require.Equal(t, int64(42), item.DashboardID)
+		require.Equal(t, int64(2), item.PanelID)
+	})
+
+	t.Run("rejects payloads with an invalid signature or to disabled hooks", func(t *testing.T) {
+		s, repo := setupService(t)
+		hook, err := s.Create(context.Background(), &annotationwebhooks.CreateHookCommand{
+			OrgID:        1,
+			HookSettings: annotationwebhooks.HookSettings{Name: "deploys", Format: annotationwebhooks.FormatGeneric, Secret: "s3cret"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "s3cret", hook.Secret)
+
+		cmd := signed("other", nil, `{"text": "Deploy"}`)
+		cmd.UID = hook.UID
+		_, err = s.Ingest(context.Background(), cmd)
+		require.ErrorIs(t, err, annotationwebhooks.ErrInvalidSignature)
+
+		cmd = signed("s3cret", nil, `{"text": "Deploy"}`)
+		cmd.UID = hook.UID
+		_, err = s.Ingest(context.Background(), cmd)
+		require.ErrorIs(t, err, annotationwebhooks.ErrHookDisabled)
+
+		cmd.UID = "unknown"
+		_, err = s.Ingest(context.Background(), cmd)
+		require.ErrorIs(t, err, annotationwebhooks.ErrHookNotFound)
+		require.Empty(t, repo.items)
+	})
+
+	t.Run("maps completed GitHub Actions workflow runs to regions", func(t *testing.T) {
+		s, repo := setupService(t)
+		hook, err := s.Create(context.Background(), &annotationwebhooks.CreateHookCommand{
+			OrgID:        1,
+			HookSettings: annotationwebhooks.HookSettings{Name: "github", Format: annotationwebhooks.FormatGitHub, Enabled: true},
+		})
+		require.NoError(t, err)
+
+		payload := `{
+			"action": "%s",
+			"workflow_run": {"id": 30433642, "name": "Build", "head_branch": "main", "head_sha": "acb5820", "conclusion": "success",
+				"run_started_at": "2023-10-02T09:00:00Z", "updated_at": "2023-10-02T09:05:30Z", "html_url": "https://github.com/org/api/actions/runs/30433642",
+				"actor": {"login": "octocat"}},
+			"repository": {"full_name": "org/api"}
+		}`
+		header := eventHeader(annotationwebhooks.HeaderGitHubEvent, "workflow_run")
+		cmd := signed(hook.Secret, header, `{"action": "in_progress"}`)
+		cmd.UID = hook.UID
+		result, err := s.Ingest(context.Background(), cmd)
+		require.NoError(t, err)
+		require.Empty(t, result.AnnotationIDs)
+
+		cmd = signed(hook.Secret, eventHeader(annotationwebhooks.HeaderGitHubEvent, "ping"), `{"zen": "Keep it simple."}`)
+		cmd.UID = hook.UID
+		result, err = s.Ingest(context.Background(), cmd)
+		require.NoError(t, err)
+		require.Empty(t, result.AnnotationIDs)
+
+		cmd = signed(hook.Secret, header, fmt.Sprintf(payload, "completed"))
+		cmd.UID = hook.UID
+		result, err = s.Ingest(context.Background(), cmd)
+		require.NoError(t, err)
+		require.Len(t, result.AnnotationIDs, 1)
+
+		item := repo.items[0]
+		require.Equal(t, "Build success on org/api@main", item.Text)
+		require.Equal(t, time.Date(2023, 10, 2, 9, 0, 0, 0, time.UTC).UnixMilli(), item.Epoch)
+		require.Equal(t, time.Date(2023, 10, 2, 9, 5, 30, 0, time.UTC).UnixMilli(), item.EpochEnd)
+		require.Equal(t, []string{"github", "repository:org/api", "workflow:Build", "conclusion:success"}, item.Tags)
+		require.Equal(t, "acb5820", item.Data.GetPath("github", "sha").MustString())
+		require.Equal(t, int64(30433642), item.Data.GetPath("github", "runId").MustInt64())
+		require.Equal(t, int64(0), item.DashboardID)
+	})
+
+	t.Run("authenticates GitLab payloads with their token", func(t *testing.T) {
+		s, repo := setupService(t)
+		hook, err := s.Create(context.Background(), &annotationwebhooks.CreateHookCommand{
+			OrgID:        1,
+			HookSettings: annotationwebhooks.HookSettings{Name: "gitlab", Format: annotationwebhooks.FormatGitLab, DashboardUID: "prod", Enabled: true},
+		})
+		require.NoError(t, err)
+
+		body := []byte(`{
+			"object_kind": "pipeline",
+			"object_attributes": {"id": 31, "ref": "main", "sha": "bcbb5ec", "status": "failed",
+				"created_at": "2023-10-02 09:00:00 UTC", "finished_at": "2023-10-02 09:10:00 UTC"},
+			"project": {"path_with_namespace": "group/api"}
+		}`)
+		_, err = s.Ingest(context.Background(), &annotationwebhooks.IngestCommand{
+			UID:    hook.UID,
+			Header: http.Header{annotationwebhooks.HeaderGitLabEvent: {"Pipeline Hook"}, annotationwebhooks.HeaderGitLabToken: {"wrong"}},
+			Body:   body,
+		})
+		require.ErrorIs(t, err, annotationwebhooks.ErrInvalidSignature)
+
+		result, err := s.Ingest(context.Background(), &annotationwebhooks.IngestCommand{
+			UID:    hook.UID,
+			Header: http.Header{annotationwebhooks.HeaderGitLabEvent: {"Pipeline Hook"}, annotationwebhooks.HeaderGitLabToken: {hook.Secret}},
+			Body:   body,
+		})
+		require.NoError(t, err)
+		require.Len(t, result.AnnotationIDs, 1)
+
+		item := repo.items[0]
+		require.Equal(t, "Pipeline failed for group/api@main", item.Text)
+		require.Equal(t, time.Date(2023, 10, 2, 9, 10, 0, 0, time.UTC).UnixMilli(), item.EpochEnd)
+		require.Equal(t, []string{"gitlab", "project:group/api", "status:failed"}, item.Tags)
+		require.Equal(t, int64(42), item.DashboardID)
+	})
+
+	t.Run("maps the items of a payload with a custom mapping", func(t *testing.T) {
+		s, repo := setupService(t)
+		hook, err := s.Create(context.Background(), &annotationwebhooks.CreateHookCommand{
+			OrgID: 1,
+			HookSettings: annotationwebhooks.HookSettings{
+				Name:   "incidents",
+				Format: annotationwebhooks.FormatGeneric,
+				Mapping: annotationwebhooks.Mapping{
+					Items: "{.incidents[*]}",
+					Match: map[string][]string{"{.severity}": {"critical"}},
+					Text:  "{.title} ({.severity})",
+					Time:  "{.started_at}",
+					Tags:  []string{"incident", "service:{.service}", "{.labels[*]}"},
+					Data:  map[string]string{"incident.id": "{.id}", "incident.service": "{.service}"},
+				},
+				Enabled: true,
+			},
+		})
+		require.NoError(t, err)
+
+		cmd := signed(hook.Secret, nil, `{"incidents": [
+			{"id": 481, "title": "API latency", "severity": "critical", "service": "api", "started_at": "2023-10-02T09:12:00Z", "labels": ["p1", "sla"]},
+			{"id": 482, "title": "Slow page", "severity": "minor", "service": "web"}
+		]}`)
+		cmd.UID = hook.UID
+		result, err := s.Ingest(context.Background(), cmd)
+		require.NoError(t, err)
+		require.Len(t, result.AnnotationIDs, 1)
+
+		item := repo.items[0]
+		require.Equal(t, "API latency (critical)", item.Text)
+		require.Equal(t, []string{"incident", "service:api", "p1", "sla"}, item.Tags)
+		require.Equal(t, int64(481), item.Data.GetPath("incident", "id").MustInt64())
+		require.Equal(t, "api", item.Data.GetPath("incident", "service").MustString())
+
+		cmd = signed(hook.Secret, nil, `{"incidents": [{"title": "Outage", "severity": "critical", "started_at": "yesterday"}]}`)
+		cmd.UID = hook.UID
+		_, err = s.Ingest(context.Background(), cmd)
+		require.ErrorIs(t, err, annotationwebhooks.ErrInvalidPayload)
+	})
+
+	t.Run("rejects payloads targeting unknown dashboards", func(t *testing.T) {
+		s, _ := setupService(t)
+		hook, err := s.Create(context.Background(), &annotationwebhooks.CreateHookCommand{
+			OrgID:        1,
+			HookSettings: annotationwebhooks.HookSettings{Name: "deploys", Format: annotationwebhooks.FormatGeneric, Enabled: true},
+		})
+		require.NoError(t, err)
+
+		cmd := signed(hook.Secret, nil, `{"text": "Deploy", "dashboardUID": "staging"}`)
+		cmd.UID = hook.UID
+		_, err = s.Ingest(context.Background(), cmd)
+		require.ErrorIs(t, err, annotationwebhooks.ErrInvalidPayload)
+	})
+}
+
+func TestIntegrationHooks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	s, _ := setupService(t)
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, &annotationwebhooks.CreateHookCommand{
+		OrgID:        1,
+		HookSettings: annotationwebhooks.HookSettings{Name: "bad", Format: annotationwebhooks.FormatGeneric, Mapping: annotationwebhooks.Mapping{Text: "{.title"}},
+	})
+	require.ErrorIs(t, err, annotationwebhooks.ErrInvalidHook)
+	_, err = s.Create(ctx, &annotationwebhooks.CreateHookCommand{
+		OrgID:        1,
+		HookSettings: annotationwebhooks.HookSettings{Name: "bad", Format: "jenkins"},
+	})
+	require.ErrorIs(t, err, annotationwebhooks.ErrInvalidHook)
+	_, err = s.Create(ctx, &annotationwebhooks.CreateHookCommand{
+		OrgID:        1,
+		HookSettings: annotationwebhooks.HookSettings{Name: "bad", Format: annotationwebhooks.FormatGeneric, DashboardUID: "staging"},
+	})
+	require.ErrorIs(t, err, annotationwebhooks.ErrInvalidHook)
+
+	created, err := s.Create(ctx, &annotationwebhooks.CreateHookCommand{
+		OrgID:        1,
+		HookSettings: annotationwebhooks.HookSettings{Name: "deploys", Format: annotationwebhooks.FormatGeneric, Enabled: true},
+	})
+	require.NoError(t, err)
+
+	updated, err := s.Update(ctx, &annotationwebhooks.UpdateHookCommand{
+		UID:          created.UID,
+		OrgID:        1,
+		HookSettings: annotationwebhooks.HookSettings{Name: "releases", Format: annotationwebhooks.FormatGeneric, Tags: []string{"release"}, Enabled: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "releases", updated.Name)
+
+	// the secret is kept when the update has none
+	cmd := signed(created.Secret, nil, `{"text": "Release"}`)
+	cmd.UID = created.UID
+	_, err = s.Ingest(ctx, cmd)
+	require.NoError(t, err)
+
+	rotated, err := s.RotateSecret(ctx, &annotationwebhooks.RotateSecretCommand{UID: created.UID, OrgID: 1})
+	require.NoError(t, err)
+	require.NotEqual(t, created.Secret, rotated.Secret)
+	_, err = s.Ingest(ctx, cmd)
+	require.ErrorIs(t, err, annotationwebhooks.ErrInvalidSignature)
+
+	hooks, err := s.List(ctx, &annotationwebhooks.ListHooksQuery{OrgID: 1})
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	require.Equal(t, []string{"release"}, hooks[0].Tags)
+
+	_, err = s.Get(ctx, &annotationwebhooks.GetHookQuery{UID: created.UID, OrgID: 2})
+	require.ErrorIs(t, err, annotationwebhooks.ErrHookNotFound)
+
+	require.NoError(t, s.Delete(ctx, &annotationwebhooks.DeleteHookCommand{UID: created.UID, OrgID: 1}))
+	require.ErrorIs(t, s.Delete(ctx, &annotationwebhooks.DeleteHookCommand{UID: created.UID, OrgID: 1}), annotationwebhooks.ErrHookNotFound)
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2023, 10, 2, 9, 12, 0, 0, time.UTC).UnixMilli()
+	for _, value := range []string{"2023-10-02T09:12:00Z", "2023-10-02T11:12:00+02:00", "2023-10-02 09:12:00 UTC", "2023-10-02 11:12:00 +0200", "1696237920000"} {
+		ms, err := parseTime(value)
+		require.NoError(t, err, value)
+		require.Equal(t, expected, ms, value)
+	}
+
+	ms, err := parseTime("")
+	require.NoError(t, err)
+	require.Zero(t, ms)
+
+	_, err = parseTime("yesterday")
+	require.ErrorIs(t, err, annotationwebhooks.ErrInvalidPayload)
+}
@@ -0,0 +1,126 @@
+package annotationwebhooksimpl
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/services/annotationwebhooks"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/annotation-webhooks", func(hooks routing.RouteRegister) {
+		hooks.Get("/", routing.Wrap(s.listHandler))
+		hooks.Post("/", routing.Wrap(s.createHandler))
+		hooks.Get("/:uid", routing.Wrap(s.getHandler))
+		hooks.Put("/:uid", routing.Wrap(s.updateHandler))
+		hooks.Delete("/:uid", routing.Wrap(s.deleteHandler))
+		hooks.Post("/:uid/rotate-secret", routing.Wrap(s.rotateSecretHandler))
+	}, middleware.ReqOrgAdmin)
+
+	// The payloads are authenticated by the secret of their hook.
+	routeRegister.Post("/api/annotation-webhooks/:uid/payloads", routing.Wrap(s.ingestHandler))
+}
+
+// GET /api/annotation-webhooks
+func (s *Service) listHandler(c *contextmodel.ReqContext) response.Response {
+	hooks, err := s.List(c.Req.Context(), &annotationwebhooks.ListHooksQuery{OrgID: c.SignedInUser.GetOrgID()})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get webhooks", err)
+	}
+	return response.JSON(http.StatusOK, hooks)
+}
+
+// POST /api/annotation-webhooks
+func (s *Service) createHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := annotationwebhooks.CreateHookCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.SignedInUser.GetOrgID()
+
+	hook, err := s.Create(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to create webhook", err)
+	}
+	return response.JSON(http.StatusOK, hook)
+}
+
+// GET /api/annotation-webhooks/:uid
+func (s *Service) getHandler(c *contextmodel.ReqContext) response.Response {
+	hook, err := s.Get(c.Req.Context(), &annotationwebhooks.GetHookQuery{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgID: c.SignedInUser.GetOrgID(),
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get webhook", err)
+	}
+	return response.JSON(http.StatusOK, hook)
+}
+
+// PUT /api/annotation-webhooks/:uid
+func (s *Service) updateHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := annotationwebhooks.UpdateHookCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.UID = web.Params(c.Req)[":uid"]
+	cmd.OrgID = c.SignedInUser.GetOrgID()
+
+	hook, err := s.Update(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update webhook", err)
+	}
+	return response.JSON(http.StatusOK, hook)
+}
+
+// DELETE /api/annotation-webhooks/:uid
+func (s *Service) deleteHandler(c *contextmodel.ReqContext) response.Response {
+	err := s.Delete(c.Req.Context(), &annotationwebhooks.DeleteHookCommand{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgID: c.SignedInUser.GetOrgID(),
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete webhook", err)
+	}
+	return response.Success("Webhook deleted")
+}
+
+// POST /api/annotation-webhooks/:uid/rotate-secret
+func (s *Service) rotateSecretHandler(c *contextmodel.ReqContext) response.Response {
+	hook, err := s.RotateSecret(c.Req.Context(), &annotationwebhooks.RotateSecretCommand{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgID: c.SignedInUser.GetOrgID(),
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to rotate webhook secret", err)
+	}
+	return response.JSON(http.StatusOK, hook)
+}
+
+// POST /api/annotation-webhooks/:uid/payloads
+func (s *Service) ingestHandler(c *contextmodel.ReqContext) response.Response {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Resp, c.Req.Body, s.maxPayloadSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return response.Error(http.StatusRequestEntityTooLarge, "Payload too large", err)
+		}
+		return response.Error(http.StatusBadRequest, "Failed to read payload", err)
+	}
+
+	result, err := s.Ingest(c.Req.Context(), &annotationwebhooks.IngestCommand{
+		UID:    web.Params(c.Req)[":uid"],
+		Header: c.Req.Header,
+		Body:   body,
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to ingest payload", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,453 @@
+package annotationwebhooksimpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/annotationwebhooks"
+)
+
+// maxAnnotationsPerPayload is the maximum number of annotations created from a payload
+const maxAnnotationsPerPayload = 100
+
+// githubMappings are the default mappings of the GitHub events, by X-GitHub-Event header
+var githubMappings = map[string]annotationwebhooks.Mapping{
+	"workflow_run": {
+		Match:   map[string][]string{"{.action}": {"completed"}},
+		Text:    "{.workflow_run.name} {.workflow_run.conclusion} on {.repository.full_name}@{.workflow_run.head_branch}",
+		Time:    "{.workflow_run.run_started_at}",
+		TimeEnd: "{.workflow_run.updated_at}",
+		Tags:    []string{"github", "repository:{.repository.full_name}", "workflow:{.workflow_run.name}", "conclusion:{.workflow_run.conclusion}"},
+		Data: map[string]string{
+			"github.runId": "{.workflow_run.id}",
+			"github.sha":   "{.workflow_run.head_sha}",
+			"github.actor": "{.workflow_run.actor.login}",
+			"github.url":   "{.workflow_run.html_url}",
+		},
+	},
+	"deployment_status": {
+		Match: map[string][]string{"{.deployment_status.state}": {"success", "failure", "error"}},
+		Text:  "Deployment of {.repository.full_name} to {.deployment.environment}: {.deployment_status.state}",
+		Time:  "{.deployment_status.created_at}",
+		Tags:  []string{"github", "repository:{.repository.full_name}", "environment:{.deployment.environment}", "state:{.deployment_status.state}"},
+		Data: map[string]string{
+			"github.deploymentId": "{.deployment.id}",
+			"github.sha":          "{.deployment.sha}",
+			"github.actor":        "{.deployment_status.creator.login}",
+			"github.url":          "{.deployment_status.target_url}",
+		},
+	},
+}
+
+// gitlabMappings are the default mappings of the GitLab events, by X-Gitlab-Event header
+var gitlabMappings = map[string]annotationwebhooks.Mapping{
+	"Pipeline Hook": {
+		Match:   map[string][]string{"{.object_attributes.status}": {"success", "failed", "canceled"}},
+		Text:    "Pipeline {.object_attributes.status} for {.project.path_with_namespace}@{.object_attributes.ref}",
+		Time:    "{.object_attributes.created_at}",
+		TimeEnd: "{.object_attributes.finished_at}",
+		Tags:    []string{"gitlab", "project:{.project.path_with_namespace}", "status:{.object_attributes.status}"},
+		Data: map[string]string{
+			"gitlab.pipelineId": "{.object_attributes.id}",
+			"gitlab.sha":        "{.object_attributes.sha}",
+			"gitlab.user":       "{.user.username}",
+			"gitlab.url":        "{.object_attributes.url}",
+		},
+	},
+	"Deployment Hook": {
+		Match: map[string][]string{"{.status}": {"success", "failed", "canceled"}},
+		Text:  "Deployment of {.project.path_with_namespace} to {.environment}: {.status}",
+		Time:  "{.status_changed_at}",
+		Tags:  []string{"gitlab", "project:{.project.path_with_namespace}", "environment:{.environment}", "status:{.status}"},
+		Data: map[string]string{
+			"gitlab.deploymentId": "{.deployment_id}",
+			"gitlab.sha":          "{.short_sha}",
+			"gitlab.user":         "{.user.username}",
+			"gitlab.url":          "{.deployable_url}",
+		},
+	},
+}
+
+// genericMapping is the default mapping of the generic payloads, the fields of the annotations API
+var genericMapping = annotationwebhooks.Mapping{
+	Text:         "{.text}",
+	Time:         "{.time}",
+	TimeEnd:      "{.timeEnd}",
+	Tags:         []string{"{.tags[*]}"},
+	DashboardUID: "{.dashboardUID}",
+	PanelID:      "{.panelId}",
+}
+
+// timeLayouts are the layouts of the times of the payloads, the GitLab payloads use the
+// layouts with spaces
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+}
+
+// mappingFor returns the mapping of a payload: the default mapping of its event overridden
+// by the mapping of the hook. It returns false for the events that are ignored.
+func mappingFor(hook *annotationwebhooks.Hook, header http.Header) (annotationwebhooks.Mapping, bool) {
+	var defaults annotationwebhooks.Mapping
+	var known bool
+	switch hook.Format {
+	case annotationwebhooks.FormatGitHub:
+		event := header.Get(annotationwebhooks.HeaderGitHubEvent)
+		if event == "ping" {
+			return annotationwebhooks.Mapping{}, false
+		}
+		defaults, known = githubMappings[event]
+	case annotationwebhooks.FormatGitLab:
+		defaults, known = gitlabMappings[header.Get(annotationwebhooks.HeaderGitLabEvent)]
+	default:
+		defaults, known = genericMapping, true
+	}
+	if !known && hook.Mapping.Text == "" {
+		return annotationwebhooks.Mapping{}, false
+	}
+	return mergeMappings(defaults, hook.Mapping), true
+}
+
+func mergeMappings(defaults, override annotationwebhooks.Mapping) annotationwebhooks.Mapping {
+	m := defaults
+	if override.Items != "" {
+		m.Items = override.Items
+	}
+	if override.Match != nil {
+		m.Match = override.Match
+	}
+	if override.Text != "" {
+		m.Text = override.Text
+	}
+	if override.Time != "" {
+		m.Time = override.Time
+	}
+	if override.TimeEnd != "" {
+		m.TimeEnd = override.TimeEnd
+	}
+	if override.Tags != nil {
+		m.Tags = override.Tags
+	}
+	if override.DashboardUID != "" {
+		m.DashboardUID = override.DashboardUID
+	}
+	if override.PanelID != "" {
+		m.PanelID = override.PanelID
+	}
+	if len(override.Data) > 0 {
+		m.Data = make(map[string]string, len(defaults.Data)+len(override.Data))
+		for key, value := range defaults.Data {
+			m.Data[key] = value
+		}
+		for key, value := range override.Data {
+			m.Data[key] = value
+		}
+	}
+	return m
+}
+
+// validateMapping checks that the templates of a mapping can be parsed
+func validateMapping(m annotationwebhooks.Mapping) error {
+	templates := []string{m.Items, m.Text, m.Time, m.TimeEnd, m.DashboardUID, m.PanelID}
+	for tmpl := range m.Match {
+		templates = append(templates, tmpl)
+	}
+	templates = append(templates, m.Tags...)
+	for _, tmpl := range m.Data {
+		templates = append(templates, tmpl)
+	}
+	for _, tmpl := range templates {
+		if _, err := parseTemplate(tmpl); err != nil {
+			return annotationwebhooks.ErrInvalidHook.Errorf("invalid template %q: %w", tmpl, err)
+		}
+	}
+	return nil
+}
+
+// mappedAnnotation is an annotation of a payload, with the dashboard it targets
+type mappedAnnotation struct {
+	item         annotations.Item
+	dashboardUID string
+}
+
+// applyMapping returns the annotations a payload maps to
+func applyMapping(hook *annotationwebhooks.Hook, mapping annotationwebhooks.Mapping, payload any, now time.Time) ([]mappedAnnotation, error) {
+	items := []any{payload}
+	if mapping.Items != "" {
+		values, err := evaluate(mapping.Items, payload)
+		if err != nil {
+			return nil, err
+		}
+		items = make([]any, 0, len(values))
+		for _, value := range values {
+			if list, ok := value.([]any); ok {
+				items = append(items, list...)
+			} else {
+				items = append(items, value)
+			}
+		}
+	}
+	if len(items) > maxAnnotationsPerPayload {
+		return nil, annotationwebhooks.ErrInvalidPayload.Errorf("payload maps to %d annotations, the maximum is %d", len(items), maxAnnotationsPerPayload)
+	}
+
+	result := make([]mappedAnnotation, 0, len(items))
+	for _, item := range items {
+		ok, err := matches(mapping.Match, item)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		annotation, err := mapItem(hook, mapping, item, now)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, annotation)
+	}
+	return result, nil
+}
+
+func matches(match map[string][]string, item any) (bool, error) {
+	for tmpl, accepted := range match {
+		value, err := render(tmpl, item)
+		if err != nil {
+			return false, err
+		}
+		found := false
+		for _, a := range accepted {
+			if value == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func mapItem(hook *annotationwebhooks.Hook, mapping annotationwebhooks.Mapping, item any, now time.Time) (mappedAnnotation, error) {
+	text, err := render(mapping.Text, item)
+	if err != nil {
+		return mappedAnnotation{}, err
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		return mappedAnnotation{}, annotationwebhooks.ErrInvalidPayload.Errorf("annotation text is empty")
+	}
+
+	epoch, err := timeValue(mapping.Time, item)
+	if err != nil {
+		return mappedAnnotation{}, err
+	}
+	if epoch == 0 {
+		epoch = now.UnixMilli()
+	}
+	epochEnd, err := timeValue(mapping.TimeEnd, item)
+	if err != nil {
+		return mappedAnnotation{}, err
+	}
+
+	tags, err := mapTags(hook.Tags, mapping.Tags, item)
+	if err != nil {
+		return mappedAnnotation{}, err
+	}
+
+	dashboardUID, panelID := hook.DashboardUID, hook.PanelID
+	uid, err := render(mapping.DashboardUID, item)
+	if err != nil {
+		return mappedAnnotation{}, err
+	}
+	if uid = strings.TrimSpace(uid); uid != "" {
+		dashboardUID, panelID = uid, 0
+	}
+	panel, err := render(mapping.PanelID, item)
+	if err != nil {
+		return mappedAnnotation{}, err
+	}
+	if panel = strings.TrimSpace(panel); panel != "" {
+		if panelID, err = strconv.ParseInt(panel, 10, 64); err != nil {
+			return mappedAnnotation{}, annotationwebhooks.ErrInvalidPayload.Errorf("invalid panel id %q", panel)
+		}
+	}
+
+	data, err := mapData(mapping.Data, item)
+	if err != nil {
+		return mappedAnnotation{}, err
+	}
+
+	return mappedAnnotation{
+		item: annotations.Item{
+			OrgID:    hook.OrgID,
+			PanelID:  panelID,
+			Text:     text,
+			Epoch:    epoch,
+			EpochEnd: epochEnd,
+			Tags:     tags,
+			Data:     data,
+		},
+		dashboardUID: dashboardUID,
+	}, nil
+}
+
+func mapTags(hookTags []string, templates []string, item any) ([]string, error) {
+	tags := make([]string, 0, len(hookTags)+len(templates))
+	seen := map[string]bool{}
+	add := func(tag string) {
+		if tag = strings.TrimSpace(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range hookTags {
+		add(tag)
+	}
+	for _, tmpl := range templates {
+		values, err := evaluate(tmpl, item)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			add(valueString(value))
+		}
+	}
+	return tags, nil
+}
+
+func mapData(templates map[string]string, item any) (*simplejson.Json, error) {
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(templates))
+	for key := range templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	data := simplejson.New()
+	for _, key := range keys {
+		values, err := evaluate(templates[key], item)
+		if err != nil {
+			return nil, err
+		}
+		switch len(values) {
+		case 0:
+		case 1:
+			if values[0] != nil && values[0] != "" {
+				data.SetPath(strings.Split(key, "."), values[0])
+			}
+		default:
+			data.SetPath(strings.Split(key, "."), values)
+		}
+	}
+	return data, nil
+}
+
+func timeValue(tmpl string, item any) (int64, error) {
+	values, err := evaluate(tmpl, item)
+	if err != nil || len(values) == 0 {
+		return 0, err
+	}
+	return parseTime(valueString(values[0]))
+}
+
+// parseTime parses an epoch time in milliseconds, or a time with a layout of timeLayouts
+func parseTime(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UnixMilli(), nil
+		}
+	}
+	return 0, annotationwebhooks.ErrInvalidPayload.Errorf("invalid time %q", s)
+}
+
+func parseTemplate(tmpl string) (*jsonpath.JSONPath, error) {
+	j := jsonpath.New("mapping").AllowMissingKeys(true)
+	if err := j.Parse(tmpl); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// render returns the text of a template
+func render(tmpl string, item any) (string, error) {
+	if tmpl == "" {
+		return "", nil
+	}
+	j, err := parseTemplate(tmpl)
+	if err != nil {
+		return "", annotationwebhooks.ErrInvalidHook.Errorf("invalid template %q: %w", tmpl, err)
+	}
+	var buf bytes.Buffer
+	if err := j.Execute(&buf, item); err != nil {
+		return "", annotationwebhooks.ErrInvalidPayload.Errorf("failed to render %q: %w", tmpl, err)
+	}
+	return buf.String(), nil
+}
+
+// evaluate returns the values of a template that is text or a single expression, and the
+// text of the other templates
+func evaluate(tmpl string, item any) ([]any, error) {
+	if tmpl == "" {
+		return nil, nil
+	}
+	j, err := parseTemplate(tmpl)
+	if err != nil {
+		return nil, annotationwebhooks.ErrInvalidHook.Errorf("invalid template %q: %w", tmpl, err)
+	}
+	results, err := j.FindResults(item)
+	if err != nil {
+		return nil, annotationwebhooks.ErrInvalidPayload.Errorf("failed to evaluate %q: %w", tmpl, err)
+	}
+	if len(results) != 1 {
+		text, err := render(tmpl, item)
+		if err != nil {
+			return nil, err
+		}
+		return []any{text}, nil
+	}
+	values := make([]any, 0, len(results[0]))
+	for _, value := range results[0] {
+		values = append(values, value.Interface())
+	}
+	return values, nil
+}
+
+func valueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+}
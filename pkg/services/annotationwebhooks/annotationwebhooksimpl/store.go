@@ -0,0 +1,17 @@
+package annotationwebhooksimpl
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/annotationwebhooks"
+)
+
+type store interface {
+	Insert(ctx context.Context, hook *annotationwebhooks.Hook) error
+	Update(ctx context.Context, hook *annotationwebhooks.Hook) error
+	Get(ctx context.Context, query *annotationwebhooks.GetHookQuery) (*annotationwebhooks.Hook, error)
+	// GetByUID returns a hook of any organization, the UIDs of the hooks are unique.
+	GetByUID(ctx context.Context, uid string) (*annotationwebhooks.Hook, error)
+	List(ctx context.Context, query *annotationwebhooks.ListHooksQuery) ([]*annotationwebhooks.Hook, error)
+	Delete(ctx context.Context, cmd *annotationwebhooks.DeleteHookCommand) error
+}
@@ -0,0 +1,49 @@
+package annotationwebhooksimpl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/annotationwebhooks"
+)
+
+const signaturePrefix = "sha256="
+
+// verify authenticates a payload with the secret of its hook. GitHub and generic payloads are
+// signed with an HMAC-SHA256 of the body, GitLab sends the secret in a header.
+func verify(format annotationwebhooks.Format, secret []byte, header http.Header, body []byte) error {
+	switch format {
+	case annotationwebhooks.FormatGitHub:
+		return verifySignature(secret, header.Get(annotationwebhooks.HeaderGitHubSignature), body)
+	case annotationwebhooks.FormatGitLab:
+		token := header.Get(annotationwebhooks.HeaderGitLabToken)
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), secret) != 1 {
+			return annotationwebhooks.ErrInvalidSignature.Errorf("invalid %s header", annotationwebhooks.HeaderGitLabToken)
+		}
+		return nil
+	default:
+		return verifySignature(secret, header.Get(annotationwebhooks.HeaderGenericSignature), body)
+	}
+}
+
+func verifySignature(secret []byte, signature string, body []byte) error {
+	encoded, found := strings.CutPrefix(signature, signaturePrefix)
+	if !found {
+		return annotationwebhooks.ErrInvalidSignature.Errorf("missing sha256 signature")
+	}
+	got, err := hex.DecodeString(encoded)
+	if err != nil || !hmac.Equal(got, sum(secret, body)) {
+		return annotationwebhooks.ErrInvalidSignature.Errorf("signature does not match the payload")
+	}
+	return nil
+}
+
+func sum(secret []byte, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,90 @@
+package annotationwebhooksimpl
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/annotationwebhooks"
+)
+
+type sqlStore struct {
+	db db.DB
+}
+
+var _ store = (*sqlStore)(nil)
+
+func (s *sqlStore) Insert(ctx context.Context, hook *annotationwebhooks.Hook) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(hook)
+		return err
+	})
+}
+
+func (s *sqlStore) Update(ctx context.Context, hook *annotationwebhooks.Hook) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("org_id = ? AND uid = ?", hook.OrgID, hook.UID).
+			Cols("name", "format", "mapping", "dashboard_uid", "panel_id", "tags", "enabled", "secret", "updated").Update(hook)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return annotationwebhooks.ErrHookNotFound.Errorf("webhook not found")
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) Get(ctx context.Context, query *annotationwebhooks.GetHookQuery) (*annotationwebhooks.Hook, error) {
+	hook := &annotationwebhooks.Hook{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("org_id = ? AND uid = ?", query.OrgID, query.UID).Get(hook)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return annotationwebhooks.ErrHookNotFound.Errorf("webhook not found")
+		}
+		return nil
+	})
+	return hook, err
+}
+
+func (s *sqlStore) GetByUID(ctx context.Context, uid string) (*annotationwebhooks.Hook, error) {
+	hook := &annotationwebhooks.Hook{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("uid = ?", uid).Get(hook)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return annotationwebhooks.ErrHookNotFound.Errorf("webhook not found")
+		}
+		return nil
+	})
+	return hook, err
+}
+
+func (s *sqlStore) List(ctx context.Context, query *annotationwebhooks.ListHooksQuery) ([]*annotationwebhooks.Hook, error) {
+	hooks := make([]*annotationwebhooks.Hook, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id = ?", query.OrgID).Asc("name").Find(&hooks)
+	})
+	return hooks, err
+}
+
+func (s *sqlStore) Delete(ctx context.Context, cmd *annotationwebhooks.DeleteHookCommand) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM annotation_webhook WHERE org_id = ? AND uid = ?", cmd.OrgID, cmd.UID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return annotationwebhooks.ErrHookNotFound.Errorf("webhook not found")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,167 @@
+package annotationwebhooks
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrHookNotFound     = errutil.NotFound("annotationwebhooks.not-found", errutil.WithPublicMessage("Annotation webhook not found"))
+	ErrHookDisabled     = errutil.Forbidden("annotationwebhooks.disabled", errutil.WithPublicMessage("Annotation webhook is disabled"))
+	ErrInvalidHook      = errutil.BadRequest("annotationwebhooks.invalid")
+	ErrInvalidSignature = errutil.Unauthorized("annotationwebhooks.invalid-signature", errutil.WithPublicMessage("Invalid webhook signature"))
+	ErrInvalidPayload   = errutil.BadRequest("annotationwebhooks.invalid-payload")
+)
+
+type Format string
+
+const (
+	// FormatGitHub accepts the workflow_run and deployment_status events of GitHub webhooks,
+	// signed with the X-Hub-Signature-256 header.
+	FormatGitHub Format = "github"
+	// FormatGitLab accepts the pipeline and deployment events of GitLab webhooks,
+	// authenticated with the X-Gitlab-Token header.
+	FormatGitLab Format = "gitlab"
+	// FormatGeneric accepts any JSON payload, signed with the X-Grafana-Signature-256 header.
+	// Without a mapping, the payloads have the fields of the annotations API.
+	FormatGeneric Format = "generic"
+)
+
+const (
+	HeaderGitHubSignature  = "X-Hub-Signature-256"
+	HeaderGitHubEvent      = "X-GitHub-Event"
+	HeaderGitLabToken      = "X-Gitlab-Token"
+	HeaderGitLabEvent      = "X-Gitlab-Event"
+	HeaderGenericSignature = "X-Grafana-Signature-256"
+)
+
+// Hook is an inbound webhook of an organization that turns the payloads it receives into
+// annotations. The secret authenticating the payloads is stored encrypted.
+type Hook struct {
+	ID     int64  `xorm:"pk autoincr 'id'" json:"-"`
+	UID    string `xorm:"uid" json:"uid"`
+	OrgID  int64  `xorm:"org_id" json:"orgId"`
+	Name   string `xorm:"name" json:"name"`
+	Format Format `xorm:"format" json:"format"`
+	// Mapping overrides the default mapping of the format.
+	Mapping Mapping `xorm:"jsonb mapping" json:"mapping"`
+	// DashboardUID and PanelID target the annotations when the mapping does not.
+	DashboardUID string `xorm:"dashboard_uid" json:"dashboardUid"`
+	PanelID      int64  `xorm:"panel_id" json:"panelId"`
+	// Tags are added to all the annotations of the hook.
+	Tags    []string  `xorm:"tags" json:"tags"`
+	Enabled bool      `xorm:"enabled" json:"enabled"`
+	Secret  []byte    `xorm:"secret" json:"-"`
+	Created time.Time `xorm:"created" json:"created"`
+	Updated time.Time `xorm:"updated" json:"updated"`
+}
+
+func (h Hook) TableName() string {
+	return "annotation_webhook"
+}
+
+// Mapping turns a payload into annotations. The fields are JSONPath templates, text mixed
+// with expressions in braces such as `Deploy of {.repository.name}`. A template that is a
+// single expression keeps the type of its value, so numbers stay numbers in the data of the
+// annotations and an expression matching an array gives one tag per item.
+type Mapping struct {
+	// Items is an expression matching the items of the payload that are each mapped to an
+	// annotation. The whole payload is mapped to one annotation when it is not set.
+	Items string `json:"items,omitempty"`
+	// Match ignores the payloads unless each template renders to one of its values.
+	Match map[string][]string `json:"match,omitempty"`
+	Text  string              `json:"text,omitempty"`
+	// Time and TimeEnd are RFC 3339 times, or epoch times in milliseconds. Annotations without
+	// a time are created at the time the payload is received.
+	Time    string   `json:"time,omitempty"`
+	TimeEnd string   `json:"timeEnd,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	// DashboardUID and PanelID override the dashboard and panel of the hook.
+	DashboardUID string `json:"dashboardUid,omitempty"`
+	PanelID      string `json:"panelId,omitempty"`
+	// Data is set in the data of the annotations. Dotted keys create nested objects, so that
+	// `deploy.service` can be used to filter the annotations.
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// HookSettings are the user editable fields of a hook.
+type HookSettings struct {
+	Name         string   `json:"name"`
+	Format       Format   `json:"format"`
+	Mapping      Mapping  `json:"mapping"`
+	DashboardUID string   `json:"dashboardUid"`
+	PanelID      int64    `json:"panelId"`
+	Tags         []string `json:"tags"`
+	Enabled      bool     `json:"enabled"`
+	// Secret replaces the secret of the hook when set. A random secret is generated for new
+	// hooks without one.
+	Secret string `json:"secret,omitempty"`
+}
+
+// Validate checks the settings, the templates of the mapping are checked by the service.
+func (s *HookSettings) Validate() error {
+	if s.Name == "" {
+		return ErrInvalidHook.Errorf("webhook name is required")
+	}
+	switch s.Format {
+	case FormatGitHub, FormatGitLab, FormatGeneric:
+	default:
+		return ErrInvalidHook.Errorf("unsupported webhook format %q", s.Format)
+	}
+	if s.PanelID != 0 && s.DashboardUID == "" {
+		return ErrInvalidHook.Errorf("webhook panel requires a dashboard")
+	}
+	return nil
+}
+
+// HookWithSecret is returned when the secret of a hook is set, it is the only time the
+// secret can be read.
+type HookWithSecret struct {
+	*Hook
+	Secret string `json:"secret"`
+}
+
+type CreateHookCommand struct {
+	HookSettings
+	OrgID int64 `json:"-"`
+}
+
+type UpdateHookCommand struct {
+	HookSettings
+	UID   string `json:"-"`
+	OrgID int64  `json:"-"`
+}
+
+type GetHookQuery struct {
+	UID   string
+	OrgID int64
+}
+
+type ListHooksQuery struct {
+	OrgID int64
+}
+
+type DeleteHookCommand struct {
+	UID   string
+	OrgID int64
+}
+
+type RotateSecretCommand struct {
+	UID   string
+	OrgID int64
+}
+
+// IngestCommand is a payload received by a hook.
+type IngestCommand struct {
+	UID    string
+	Header http.Header
+	Body   []byte
+}
+
+type IngestResult struct {
+	// AnnotationIDs are the annotations created from the payload, empty when the payload
+	// was ignored by the mapping.
+	AnnotationIDs []int64 `json:"ids"`
+}
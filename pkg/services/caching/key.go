@@ -0,0 +1,101 @@
+package caching
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+)
+
+// volatileQueryFields are the fields of a query that change on every request without changing its result
+var volatileQueryFields = []string{"requestId"}
+
+// queryKey returns the cache key of a query request. The key covers the data source, the
+// normalized queries and their time range aligned to the query interval, so that refreshes of
+// a relative time range within the same interval share their results. The user and the teams
+// are added when the data source receives them, through the OAuth token, the user header or the
+// ID token, as the results can then differ between users.
+func (s *OSSCachingService) queryKey(reqCtx *contextmodel.ReqContext, req *backend.QueryDataRequest) (string, error) {
+	ds := req.PluginContext.DataSourceInstanceSettings
+	type keyQuery struct {
+		Query     map[string]any `json:"query"`
+		QueryType string         `json:"queryType"`
+		From      int64          `json:"from"`
+		To        int64          `json:"to"`
+		Interval  int64          `json:"interval"`
+		MaxPoints int64          `json:"maxDataPoints"`
+	}
+	key := struct {
+		OrgID      int64      `json:"orgId"`
+		Datasource string     `json:"datasource"`
+		Updated    int64      `json:"updated"`
+		User       string     `json:"user,omitempty"`
+		Teams      []int64    `json:"teams,omitempty"`
+		Queries    []keyQuery `json:"queries"`
+	}{
+		OrgID:      req.PluginContext.OrgID,
+		Datasource: ds.UID,
+		Updated:    ds.Updated.UnixMilli(),
+	}
+
+	var jsonData map[string]any
+	if len(ds.JSONData) > 0 {
+		if err := json.Unmarshal(ds.JSONData, &jsonData); err != nil {
+			return "", fmt.Errorf("failed to read data source settings: %w", err)
+		}
+	}
+	if reqCtx.SignedInUser != nil {
+		if passThru, _ := jsonData["oauthPassThru"].(bool); passThru || s.sendUserHeader || s.features.IsEnabled(featuremgmt.FlagIdForwarding) {
+			key.User = strconv.FormatInt(reqCtx.SignedInUser.UserID, 10)
+		}
+		if headers, ok := jsonData["teamHttpHeaders"].(map[string]any); ok && len(headers) > 0 {
+			key.Teams = append([]int64{}, reqCtx.SignedInUser.Teams...)
+			sort.Slice(key.Teams, func(i, j int) bool { return key.Teams[i] < key.Teams[j] })
+		}
+	}
+
+	for _, q := range req.Queries {
+		model := map[string]any{}
+		if len(q.JSON) > 0 {
+			if err := json.Unmarshal(q.JSON, &model); err != nil {
+				return "", fmt.Errorf("failed to read query %s: %w", q.RefID, err)
+			}
+		}
+		for _, field := range volatileQueryFields {
+			delete(model, field)
+		}
+		from, to := alignTimeRange(q.TimeRange, q.Interval)
+		key.Queries = append(key.Queries, keyQuery{
+			Query:     model,
+			QueryType: q.QueryType,
+			From:      from,
+			To:        to,
+			Interval:  q.Interval.Milliseconds(),
+			MaxPoints: q.MaxDataPoints,
+		})
+	}
+
+	// maps are marshaled with sorted keys, which makes the key independent of the field order
+	body, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// alignTimeRange truncates a time range to the query interval, in milliseconds
+func alignTimeRange(tr backend.TimeRange, interval time.Duration) (int64, int64) {
+	if interval <= 0 {
+		return tr.From.UnixMilli(), tr.To.UnixMilli()
+	}
+	return tr.From.Truncate(interval).UnixMilli(), tr.To.Truncate(interval).UnixMilli()
+}
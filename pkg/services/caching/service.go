@@ -2,8 +2,21 @@ package caching
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 const (
@@ -35,8 +48,64 @@ type CachedResourceDataResponse struct {
 	UpdateCacheFn CacheResourceResponseFn
 }
 
-func ProvideCachingService() *OSSCachingService {
-	return &OSSCachingService{}
+const (
+	// jsonDataTTLKey is the data source setting overriding the default TTL, in milliseconds
+	jsonDataTTLKey = "queryCachingTTL"
+	// jsonDataEnabledKey is the data source setting disabling the query caching of a data source when false
+	jsonDataEnabledKey = "queryCachingEnabled"
+)
+
+// Settings are the settings of the [caching] section
+type Settings struct {
+	Enabled bool
+	// TTL is the time query results are kept, unless the data source overrides it
+	TTL time.Duration
+	// MaxValueSize is the maximum size of a cached response, in bytes. Larger responses are not cached.
+	MaxValueSize int
+	Storage      setting.RemoteCacheOptions
+}
+
+func readSettings(cfg *setting.Cfg) Settings {
+	section := cfg.SectionWithEnvOverrides("caching")
+	return Settings{
+		Enabled:      section.Key("enabled").MustBool(false),
+		TTL:          section.Key("ttl").MustDuration(5 * time.Minute),
+		MaxValueSize: section.Key("max_value_mb").MustInt(1) * 1024 * 1024,
+		Storage: setting.RemoteCacheOptions{
+			Name:       section.Key("backend").MustString("memory"),
+			ConnStr:    section.Key("connstr").MustString(""),
+			Prefix:     section.Key("prefix").MustString("query-cache:"),
+			Encryption: section.Key("encryption").MustBool(false),
+			// only used by the memory backend
+			MemoryMaxSize: section.Key("memory_max_size_mb").MustInt(100) * 1024 * 1024,
+		},
+	}
+}
+
+func ProvideCachingService(cfg *setting.Cfg, sqlStore db.DB, secretsService secrets.Service, registerer prometheus.Registerer,
+	features featuremgmt.FeatureToggles) (*OSSCachingService, error) {
+	s := &OSSCachingService{
+		log:            log.New("query-caching"),
+		settings:       readSettings(cfg),
+		sendUserHeader: cfg.SendUserHeader,
+		features:       features,
+	}
+	if !s.settings.Enabled {
+		return s, nil
+	}
+
+	storage, err := remotecache.NewCacheStorage(&s.settings.Storage, sqlStore, secretsService)
+	if err != nil {
+		return nil, err
+	}
+	s.storage = storage
+	s.requests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "caching",
+		Name:      "query_requests_total",
+		Help:      "Number of query requests handled by the query cache, by cache status",
+	}, []string{"datasource_type", "status"})
+	return s, nil
 }
 
 type CachingService interface {
@@ -49,16 +118,131 @@ type CachingService interface {
 	HandleResourceRequest(context.Context, *backend.CallResourceRequest) (bool, CachedResourceDataResponse)
 }
 
-// Implementation of interface - does nothing
+// OSSCachingService caches the query responses of the data sources in one of the remote cache
+// storages. The zero value does not cache anything.
 type OSSCachingService struct {
+	log            log.Logger
+	settings       Settings
+	sendUserHeader bool
+	features       featuremgmt.FeatureToggles
+	storage        remotecache.CacheStorage
+	requests       *prometheus.CounterVec
 }
 
 func (s *OSSCachingService) HandleQueryRequest(ctx context.Context, req *backend.QueryDataRequest) (bool, CachedQueryDataResponse) {
-	return false, CachedQueryDataResponse{}
+	if s.storage == nil || req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return false, CachedQueryDataResponse{}
+	}
+	reqCtx := contexthandler.FromContext(ctx)
+	if reqCtx == nil {
+		return false, CachedQueryDataResponse{}
+	}
+
+	ds := req.PluginContext.DataSourceInstanceSettings
+	ttl, enabled := s.datasourceTTL(ds)
+	if !enabled {
+		s.setStatus(ctx, ds.Type, StatusDisabled)
+		return false, CachedQueryDataResponse{}
+	}
+
+	key, err := s.queryKey(reqCtx, req)
+	if err != nil {
+		s.log.FromContext(ctx).Warn("Failed to create query cache key", "datasource", ds.UID, "error", err)
+		s.setStatus(ctx, ds.Type, StatusError)
+		return false, CachedQueryDataResponse{}
+	}
+	update := s.updateFn(key, ttl)
+
+	// The X-Cache-Skip header asks for fresh data; the response still replaces the cached one
+	if reqCtx.SkipQueryCache {
+		s.setStatus(ctx, ds.Type, StatusBypass)
+		return false, CachedQueryDataResponse{UpdateCacheFn: update}
+	}
+
+	value, err := s.storage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			s.setStatus(ctx, ds.Type, StatusMiss)
+			return false, CachedQueryDataResponse{UpdateCacheFn: update}
+		}
+		s.log.FromContext(ctx).Warn("Failed to read from the query cache", "datasource", ds.UID, "error", err)
+		s.setStatus(ctx, ds.Type, StatusError)
+		return false, CachedQueryDataResponse{}
+	}
+
+	resp := &backend.QueryDataResponse{}
+	if err := json.Unmarshal(value, resp); err != nil {
+		s.log.FromContext(ctx).Warn("Failed to decode cached query response", "datasource", ds.UID, "error", err)
+		s.setStatus(ctx, ds.Type, StatusMiss)
+		return false, CachedQueryDataResponse{UpdateCacheFn: update}
+	}
+	s.setStatus(ctx, ds.Type, StatusHit)
+	return true, CachedQueryDataResponse{Response: resp}
 }
 
+// HandleResourceRequest does not cache resource calls, their responses are streamed by the plugins
 func (s *OSSCachingService) HandleResourceRequest(ctx context.Context, req *backend.CallResourceRequest) (bool, CachedResourceDataResponse) {
 	return false, CachedResourceDataResponse{}
 }
 
+func (s *OSSCachingService) updateFn(key string, ttl time.Duration) CacheQueryResponseFn {
+	return func(ctx context.Context, resp *backend.QueryDataResponse) {
+		if resp == nil || hasErrors(resp) {
+			return
+		}
+		value, err := json.Marshal(resp)
+		if err != nil {
+			s.log.FromContext(ctx).Warn("Failed to encode query response for the query cache", "error", err)
+			return
+		}
+		if s.settings.MaxValueSize > 0 && len(value) > s.settings.MaxValueSize {
+			s.log.FromContext(ctx).Debug("Query response is too large to be cached", "size", len(value))
+			return
+		}
+		if err := s.storage.Set(ctx, key, value, ttl); err != nil {
+			s.log.FromContext(ctx).Warn("Failed to write to the query cache", "error", err)
+		}
+	}
+}
+
+// datasourceTTL returns the TTL of the cached responses of a data source, and whether they are cached at all.
+// The responses of data sources that forward the cookies of the user are never cached, they can
+// depend on any of these cookies.
+func (s *OSSCachingService) datasourceTTL(ds *backend.DataSourceInstanceSettings) (time.Duration, bool) {
+	var jsonData map[string]any
+	if len(ds.JSONData) > 0 {
+		if err := json.Unmarshal(ds.JSONData, &jsonData); err != nil {
+			return s.settings.TTL, true
+		}
+	}
+	if cookies, ok := jsonData["keepCookies"].([]any); ok && len(cookies) > 0 {
+		return 0, false
+	}
+	if enabled, ok := jsonData[jsonDataEnabledKey].(bool); ok && !enabled {
+		return 0, false
+	}
+	if ttl, ok := jsonData[jsonDataTTLKey].(float64); ok && ttl > 0 {
+		return time.Duration(ttl) * time.Millisecond, true
+	}
+	return s.settings.TTL, s.settings.TTL > 0
+}
+
+func (s *OSSCachingService) setStatus(ctx context.Context, datasourceType string, status string) {
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Resp != nil {
+		reqCtx.Resp.Header().Set(XCacheHeader, status)
+	}
+	if s.requests != nil {
+		s.requests.WithLabelValues(datasourceType, status).Inc()
+	}
+}
+
+func hasErrors(resp *backend.QueryDataResponse) bool {
+	for _, r := range resp.Responses {
+		if r.Error != nil || r.Status >= 400 {
+			return true
+		}
+	}
+	return false
+}
+
 var _ CachingService = &OSSCachingService{}
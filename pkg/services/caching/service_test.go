@@ -0,0 +1,247 @@
+package caching
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func setupService(t *testing.T, features featuremgmt.FeatureToggles) *OSSCachingService {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	section, err := cfg.Raw.NewSection("caching")
+	require.NoError(t, err)
+	_, err = section.NewKey("enabled", "true")
+	require.NoError(t, err)
+
+	s, err := ProvideCachingService(cfg, nil, nil, prometheus.NewRegistry(), features)
+	require.NoError(t, err)
+	return s
+}
+
+func newRequestContext(t *testing.T, u *user.SignedInUser) (context.Context, *contextmodel.ReqContext) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/ds/query", nil)
+	reqCtx := &contextmodel.ReqContext{
+		Context:      &web.Context{Req: req, Resp: web.NewResponseWriter(http.MethodPost, httptest.NewRecorder())},
+		SignedInUser: u,
+	}
+	return ctxkey.Set(context.Background(), reqCtx), reqCtx
+}
+
+func newQueryRequest(jsonData string, query string, to time.Time) *backend.QueryDataRequest {
+	return &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			OrgID: 1,
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				UID:      "prom",
+				Type:     "prometheus",
+				JSONData: []byte(jsonData),
+			},
+		},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			JSON:      []byte(query),
+			Interval:  time.Minute,
+			TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to},
+		}},
+	}
+}
+
+func TestHandleQueryRequest(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 10, 0, time.UTC)
+	resp := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{data.NewFrame("cpu", data.NewField("value", nil, []float64{1, 2}))}},
+	}}
+
+	t.Run("caches responses and serves them to the same queries", func(t *testing.T) {
+		s := setupService(t, featuremgmt.WithFeatures())
+
+		ctx, reqCtx := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		hit, cr := s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"refId":"A","expr":"cpu","requestId":"1"}`, now))
+		require.False(t, hit)
+		require.Equal(t, StatusMiss, reqCtx.Resp.Header().Get(XCacheHeader))
+		require.NotNil(t, cr.UpdateCacheFn)
+		cr.UpdateCacheFn(ctx, resp)
+
+		// same query with the fields in a different order, a new request ID and a time range in the same interval
+		ctx, reqCtx = newRequestContext(t, &user.SignedInUser{UserID: 2, OrgID: 1})
+		hit, cr = s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu","refId":"A","requestId":"2"}`, now.Add(20*time.Second)))
+		require.True(t, hit)
+		require.Equal(t, StatusHit, reqCtx.Resp.Header().Get(XCacheHeader))
+		require.Equal(t, []float64{1, 2}, []float64{
+			cr.Response.Responses["A"].Frames[0].Fields[0].At(0).(float64),
+			cr.Response.Responses["A"].Frames[0].Fields[0].At(1).(float64),
+		})
+
+		ctx, _ = newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		hit, _ = s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"refId":"A","expr":"cpu"}`, now.Add(time.Minute)))
+		require.False(t, hit, "the next interval is not cached")
+
+		require.Equal(t, 1.0, testutil.ToFloat64(s.requests.WithLabelValues("prometheus", StatusHit)))
+		require.Equal(t, 2.0, testutil.ToFloat64(s.requests.WithLabelValues("prometheus", StatusMiss)))
+	})
+
+	t.Run("scopes responses to the user when the data source forwards the identity", func(t *testing.T) {
+		s := setupService(t, featuremgmt.WithFeatures())
+		jsonData := `{"oauthPassThru":true}`
+
+		ctx, _ := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(jsonData, `{"expr":"cpu"}`, now))
+		cr.UpdateCacheFn(ctx, resp)
+
+		ctx, _ = newRequestContext(t, &user.SignedInUser{UserID: 2, OrgID: 1})
+		hit, _ := s.HandleQueryRequest(ctx, newQueryRequest(jsonData, `{"expr":"cpu"}`, now))
+		require.False(t, hit)
+
+		ctx, _ = newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		hit, _ = s.HandleQueryRequest(ctx, newQueryRequest(jsonData, `{"expr":"cpu"}`, now))
+		require.True(t, hit)
+	})
+
+	t.Run("scopes responses to the user when the ID token is forwarded", func(t *testing.T) {
+		s := setupService(t, featuremgmt.WithFeatures(featuremgmt.FlagIdForwarding))
+
+		ctx, _ := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu"}`, now))
+		cr.UpdateCacheFn(ctx, resp)
+
+		ctx, _ = newRequestContext(t, &user.SignedInUser{UserID: 2, OrgID: 1})
+		hit, _ := s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu"}`, now))
+		require.False(t, hit)
+
+		ctx, _ = newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		hit, _ = s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu"}`, now))
+		require.True(t, hit)
+	})
+
+	t.Run("does not cache data sources that forward cookies", func(t *testing.T) {
+		s := setupService(t, featuremgmt.WithFeatures())
+		jsonData := `{"keepCookies":["session"]}`
+
+		ctx, reqCtx := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		hit, cr := s.HandleQueryRequest(ctx, newQueryRequest(jsonData, `{"expr":"cpu"}`, now))
+		require.False(t, hit)
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Equal(t, StatusDisabled, reqCtx.Resp.Header().Get(XCacheHeader))
+	})
+
+	t.Run("scopes responses to the teams when the data source has team headers", func(t *testing.T) {
+		s := setupService(t, featuremgmt.WithFeatures())
+		jsonData := `{"teamHttpHeaders":{"1":[{"header":"X-Team","value":"a"}]}}`
+
+		ctx, _ := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1, Teams: []int64{1, 2}})
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(jsonData, `{"expr":"cpu"}`, now))
+		cr.UpdateCacheFn(ctx, resp)
+
+		ctx, _ = newRequestContext(t, &user.SignedInUser{UserID: 2, OrgID: 1, Teams: []int64{1}})
+		hit, _ := s.HandleQueryRequest(ctx, newQueryRequest(jsonData, `{"expr":"cpu"}`, now))
+		require.False(t, hit)
+
+		ctx, _ = newRequestContext(t, &user.SignedInUser{UserID: 3, OrgID: 1, Teams: []int64{2, 1}})
+		hit, _ = s.HandleQueryRequest(ctx, newQueryRequest(jsonData, `{"expr":"cpu"}`, now))
+		require.True(t, hit)
+	})
+
+	t.Run("bypasses the cache when asked to and updates it", func(t *testing.T) {
+		s := setupService(t, featuremgmt.WithFeatures())
+
+		ctx, _ := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu"}`, now))
+		cr.UpdateCacheFn(ctx, &backend.QueryDataResponse{Responses: backend.Responses{"A": {}}})
+
+		ctx, reqCtx := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		reqCtx.SkipQueryCache = true
+		hit, cr := s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu"}`, now))
+		require.False(t, hit)
+		require.Equal(t, StatusBypass, reqCtx.Resp.Header().Get(XCacheHeader))
+		cr.UpdateCacheFn(ctx, resp)
+
+		ctx, _ = newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		hit, cr = s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu"}`, now))
+		require.True(t, hit)
+		require.Len(t, cr.Response.Responses["A"].Frames, 1)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		s := setupService(t, featuremgmt.WithFeatures())
+
+		ctx, _ := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu"}`, now))
+		cr.UpdateCacheFn(ctx, &backend.QueryDataResponse{Responses: backend.Responses{"A": backend.ErrDataResponse(backend.StatusBadRequest, "bad query")}})
+
+		hit, _ := s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu"}`, now))
+		require.False(t, hit)
+	})
+
+	t.Run("uses the TTL of the data source", func(t *testing.T) {
+		s := setupService(t, featuremgmt.WithFeatures())
+
+		ctx, reqCtx := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		hit, cr := s.HandleQueryRequest(ctx, newQueryRequest(`{"queryCachingEnabled":false}`, `{"expr":"cpu"}`, now))
+		require.False(t, hit)
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Equal(t, StatusDisabled, reqCtx.Resp.Header().Get(XCacheHeader))
+
+		ttl, enabled := s.datasourceTTL(&backend.DataSourceInstanceSettings{JSONData: []byte(`{"queryCachingTTL":60000}`)})
+		require.True(t, enabled)
+		require.Equal(t, time.Minute, ttl)
+
+		ttl, enabled = s.datasourceTTL(&backend.DataSourceInstanceSettings{JSONData: []byte(`{}`)})
+		require.True(t, enabled)
+		require.Equal(t, 5*time.Minute, ttl)
+	})
+
+	t.Run("does nothing when disabled", func(t *testing.T) {
+		s, err := ProvideCachingService(setting.NewCfg(), nil, nil, prometheus.NewRegistry(), featuremgmt.WithFeatures())
+		require.NoError(t, err)
+
+		ctx, reqCtx := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+		hit, cr := s.HandleQueryRequest(ctx, newQueryRequest(`{}`, `{"expr":"cpu"}`, now))
+		require.False(t, hit)
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Empty(t, reqCtx.Resp.Header().Get(XCacheHeader))
+	})
+}
+
+func TestQueryKey(t *testing.T) {
+	s := &OSSCachingService{log: log.NewNopLogger(), features: featuremgmt.WithFeatures()}
+	now := time.Date(2026, 10, 18, 10, 0, 10, 0, time.UTC)
+	_, reqCtx := newRequestContext(t, &user.SignedInUser{UserID: 1, OrgID: 1})
+
+	key := func(req *backend.QueryDataRequest) string {
+		k, err := s.queryKey(reqCtx, req)
+		require.NoError(t, err)
+		return k
+	}
+
+	base := key(newQueryRequest(`{}`, `{"expr":"cpu","step":"1m"}`, now))
+	require.Equal(t, base, key(newQueryRequest(`{}`, `{"step":"1m","expr":"cpu"}`, now.Add(30*time.Second))))
+	require.NotEqual(t, base, key(newQueryRequest(`{}`, `{"expr":"mem","step":"1m"}`, now)))
+
+	other := newQueryRequest(`{}`, `{"expr":"cpu","step":"1m"}`, now)
+	other.PluginContext.DataSourceInstanceSettings.UID = "other"
+	require.NotEqual(t, base, key(other))
+
+	otherOrg := newQueryRequest(`{}`, `{"expr":"cpu","step":"1m"}`, now)
+	otherOrg.PluginContext.OrgID = 2
+	require.NotEqual(t, base, key(otherOrg))
+}
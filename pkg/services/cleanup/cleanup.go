@@ -270,6 +270,15 @@ func (srv *CleanUpService) deleteStaleQueryHistory(ctx context.Context) {
 		logger.Debug("Enforced row limit for query_history", "rows affected", rowsCount)
 	}
 
+	// Enforce 200k limit for the queries captured on the server, so they don't evict the queries run in Explore
+	capturedQueriesLimit := 200000
+	rowsCount, err = srv.QueryHistoryService.EnforceRowLimitInCapturedQueries(ctx, capturedQueriesLimit)
+	if err != nil {
+		logger.Error("Problem with enforcing row limit for captured queries in query_history", "error", err.Error())
+	} else {
+		logger.Debug("Enforced row limit for captured queries in query_history", "rows affected", rowsCount)
+	}
+
 	// Enforce 150k limit for query_history_star table
 	queryHistoryStarLimit := 150000
 	rowsCount, err = srv.QueryHistoryService.EnforceRowLimitInQueryHistory(ctx, queryHistoryStarLimit, true)
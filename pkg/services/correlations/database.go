@@ -2,6 +2,7 @@ package correlations
 
 import (
 	"context"
+	"fmt"
 
 	"xorm.io/core"
 
@@ -117,6 +118,7 @@ func (s CorrelationsService) updateCorrelation(ctx context.Context, cmd UpdateCo
 			return ErrCorrelationReadOnly
 		}
 
+		clearTarget := false
 		if cmd.Label != nil {
 			correlation.Label = *cmd.Label
 			session.MustCols("label")
@@ -139,12 +141,33 @@ func (s CorrelationsService) updateCorrelation(ctx context.Context, cmd UpdateCo
 			if cmd.Config.Transformations != nil {
 				correlation.Config.Transformations = cmd.Config.Transformations
 			}
+			// the configs without type are query configs, see CorrelationConfig.MarshalJSON
+			if correlation.Config.Type == "" {
+				correlation.Config.Type = ConfigTypeQuery
+			}
+			// the target must match the type when only one of them is updated
+			if err := correlation.Config.Validate(); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidCorrelationConfig, err)
+			}
+			// the target data source is only kept by query correlations, and can't be set by an update
+			if correlation.Config.Type != ConfigTypeQuery && correlation.TargetUID != nil {
+				correlation.TargetUID = nil
+				clearTarget = true
+			}
+			if err := validateTargetUID(correlation.TargetUID, correlation.Config.Type); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidCorrelationConfig, err)
+			}
 		}
 
 		updateCount, err := session.Where("uid = ? AND source_uid = ?", correlation.UID, correlation.SourceUID).Limit(1).Update(correlation)
 		if updateCount == 0 {
 			return ErrCorrelationNotFound
 		}
+		if err != nil || !clearTarget {
+			return err
+		}
+		// nil fields are skipped by the update
+		_, err = session.Exec("UPDATE correlation SET target_uid = NULL WHERE uid = ? AND source_uid = ?", correlation.UID, correlation.SourceUID)
 		return err
 	})
 
@@ -0,0 +1,70 @@
+package correlations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+)
+
+func TestIntegrationUpdateCorrelationTarget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	dsUID := "loki"
+	s := CorrelationsService{
+		SQLStore: db.InitTestDB(t),
+		log:      logger,
+		DataSourceService: &fakeDatasources.FakeDataSourceService{
+			DataSources: []*datasources.DataSource{{UID: dsUID, OrgID: 1}},
+		},
+	}
+	update := func(correlation Correlation, configType CorrelationConfigType, target map[string]any) (Correlation, error) {
+		return s.updateCorrelation(ctx, UpdateCorrelationCommand{
+			UID:       correlation.UID,
+			SourceUID: correlation.SourceUID,
+			OrgId:     1,
+			Config:    &CorrelationConfigUpdateDTO{Type: &configType, Target: &target},
+		})
+	}
+
+	t.Run("query correlations updated to another type don't keep their target data source", func(t *testing.T) {
+		correlation, err := s.createCorrelation(ctx, CreateCorrelationCommand{
+			SourceUID: dsUID,
+			TargetUID: &dsUID,
+			OrgId:     1,
+			Config:    CorrelationConfig{Field: "traceId", Type: ConfigTypeQuery, Target: map[string]any{}},
+		})
+		require.NoError(t, err)
+
+		updated, err := update(correlation, ConfigTypeExternal, map[string]any{"url": "https://example.com/${traceId}"})
+		require.NoError(t, err)
+		require.Nil(t, updated.TargetUID)
+
+		stored := Correlation{UID: correlation.UID, SourceUID: correlation.SourceUID}
+		err = s.SQLStore.WithDbSession(ctx, func(session *db.Session) error {
+			_, err := session.Get(&stored)
+			return err
+		})
+		require.NoError(t, err)
+		require.Nil(t, stored.TargetUID)
+		require.Equal(t, ConfigTypeExternal, stored.Config.Type)
+	})
+
+	t.Run("correlations without target data source can't be updated to query correlations", func(t *testing.T) {
+		correlation, err := s.createCorrelation(ctx, CreateCorrelationCommand{
+			SourceUID: dsUID,
+			OrgId:     1,
+			Config:    CorrelationConfig{Field: "traceId", Type: ConfigTypeExternal, Target: map[string]any{"url": "https://example.com/${traceId}"}},
+		})
+		require.NoError(t, err)
+
+		_, err = update(correlation, ConfigTypeQuery, map[string]any{"expr": "foo"})
+		require.ErrorIs(t, err, ErrInvalidCorrelationConfig)
+	})
+}
@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
+
+	"k8s.io/client-go/util/jsonpath"
 
 	"github.com/grafana/grafana/pkg/services/quota"
 )
@@ -18,6 +22,11 @@ var (
 	ErrInvalidTransformationType     = errors.New("invalid transformation type")
 	ErrTransformationNotNested       = errors.New("transformations must be nested under config")
 	ErrTransformationRegexReqExp     = errors.New("regex transformations require expression")
+	ErrTransformationJSONPathReqExp  = errors.New("jsonpath transformations require expression")
+	ErrTransformationLabelReqExp     = errors.New("label transformations require the name of the label as expression")
+	ErrTransformationInvalidExp      = errors.New("invalid transformation expression")
+	ErrInvalidTarget                 = errors.New("invalid correlation target")
+	ErrInvalidCorrelationConfig      = errors.New("invalid correlation config")
 	ErrCorrelationsQuotaFailed       = errors.New("error getting correlations quota")
 	ErrCorrelationsQuotaReached      = errors.New("correlations quota reached")
 )
@@ -30,7 +39,7 @@ const (
 type CorrelationConfigType string
 
 type Transformation struct {
-	//Enum: regex,logfmt
+	//Enum: regex,logfmt,jsonpath,label
 	Type       string `json:"type"`
 	Expression string `json:"expression,omitempty"`
 	Field      string `json:"field,omitempty"`
@@ -38,11 +47,34 @@ type Transformation struct {
 }
 
 const (
+	// ConfigTypeQuery correlations run a query of the target data source
 	ConfigTypeQuery CorrelationConfigType = "query"
+	// ConfigTypeExternal correlations open the templated URL of their target
+	ConfigTypeExternal CorrelationConfigType = "external"
+	// ConfigTypeDashboard correlations open the dashboard of their target, with the variables
+	// of the dashboard set to the templated values of the target
+	ConfigTypeDashboard CorrelationConfigType = "dashboard"
+)
+
+const (
+	// TransformationRegex extracts the first group of a regular expression
+	TransformationRegex = "regex"
+	// TransformationLogfmt extracts the logfmt fields of a log line
+	TransformationLogfmt = "logfmt"
+	// TransformationJSONPath extracts the value of a JSONPath expression, such as $.user.id, from a JSON field
+	TransformationJSONPath = "jsonpath"
+	// TransformationLabel extracts the value of a label of the field, the expression is the name of the label
+	TransformationLabel = "label"
 )
 
+// labelNameRegex matches the names of Prometheus and Loki labels
+var labelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// templateVariableRegex matches the variables of the templates of the targets, such as ${traceId}
+var templateVariableRegex = regexp.MustCompile(`\$\{[^}]*\}`)
+
 func (t CorrelationConfigType) Validate() error {
-	if t != ConfigTypeQuery {
+	if t != ConfigTypeQuery && t != ConfigTypeExternal && t != ConfigTypeDashboard {
 		return fmt.Errorf("%s: \"%s\"", ErrInvalidConfigType, t)
 	}
 	return nil
@@ -50,10 +82,28 @@ func (t CorrelationConfigType) Validate() error {
 
 func (t Transformations) Validate() error {
 	for _, v := range t {
-		if v.Type != "regex" && v.Type != "logfmt" {
+		switch v.Type {
+		case TransformationRegex:
+			if len(v.Expression) == 0 {
+				return fmt.Errorf("%s: \"%s\"", ErrTransformationRegexReqExp, t)
+			}
+		case TransformationLogfmt:
+		case TransformationJSONPath:
+			if len(v.Expression) == 0 {
+				return fmt.Errorf("%s: \"%s\"", ErrTransformationJSONPathReqExp, t)
+			}
+			if err := jsonpath.New(v.Expression).Parse("{" + v.Expression + "}"); err != nil {
+				return fmt.Errorf("%w: \"%s\": %s", ErrTransformationInvalidExp, v.Expression, err)
+			}
+		case TransformationLabel:
+			if len(v.Expression) == 0 {
+				return fmt.Errorf("%s: \"%s\"", ErrTransformationLabelReqExp, t)
+			}
+			if !labelNameRegex.MatchString(v.Expression) {
+				return fmt.Errorf("%w: \"%s\" is not a valid label name", ErrTransformationInvalidExp, v.Expression)
+			}
+		default:
 			return fmt.Errorf("%s: \"%s\"", ErrInvalidTransformationType, t)
-		} else if v.Type == "regex" && len(v.Expression) == 0 {
-			return fmt.Errorf("%s: \"%s\"", ErrTransformationRegexReqExp, t)
 		}
 	}
 	return nil
@@ -67,10 +117,11 @@ type CorrelationConfig struct {
 	// required:true
 	// example: message
 	Field string `json:"field" binding:"Required"`
-	// Target type
+	// Target type, query, external or dashboard
 	// required:true
 	Type CorrelationConfigType `json:"type" binding:"Required"`
-	// Target data query
+	// Target data query, {"url":"..."} for external correlations, or {"dashboardUID":"...","variables":{"name":"..."}}
+	// for dashboard correlations
 	// required:true
 	// example: {"prop1":"value1","prop2":"value"}
 	Target map[string]any `json:"target" binding:"Required"`
@@ -83,22 +134,85 @@ type CorrelationConfig struct {
 func (c CorrelationConfig) MarshalJSON() ([]byte, error) {
 	target := c.Target
 	transformations := c.Transformations
+	configType := c.Type
 	if target == nil {
 		target = map[string]any{}
 	}
+	if configType == "" {
+		configType = ConfigTypeQuery
+	}
 	return json.Marshal(struct {
 		Type            CorrelationConfigType `json:"type"`
 		Field           string                `json:"field"`
 		Target          map[string]any        `json:"target"`
 		Transformations Transformations       `json:"transformations,omitempty"`
 	}{
-		Type:            ConfigTypeQuery,
+		Type:            configType,
 		Field:           c.Field,
 		Target:          target,
 		Transformations: transformations,
 	})
 }
 
+// Validate checks the type, the target and the transformations of the config. The target of
+// external correlations must have a url, and the target of dashboard correlations a dashboardUID
+// and optionally the variables of the dashboard.
+func (c CorrelationConfig) Validate() error {
+	if err := c.Type.Validate(); err != nil {
+		return err
+	}
+
+	switch c.Type {
+	case ConfigTypeExternal:
+		if err := validateExternalTarget(c.Target); err != nil {
+			return err
+		}
+	case ConfigTypeDashboard:
+		if err := validateDashboardTarget(c.Target); err != nil {
+			return err
+		}
+	}
+
+	return c.Transformations.Validate()
+}
+
+func validateExternalTarget(target map[string]any) error {
+	rawURL, ok := target["url"].(string)
+	if !ok || rawURL == "" {
+		return fmt.Errorf("%w: correlations of type \"%s\" must have a url", ErrInvalidTarget, ConfigTypeExternal)
+	}
+	// the variables are replaced so that the templates of the scheme and host are rejected
+	u, err := url.Parse(templateVariableRegex.ReplaceAllString(rawURL, "var"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: \"%s\" is not an absolute http or https url", ErrInvalidTarget, rawURL)
+	}
+	return nil
+}
+
+func validateDashboardTarget(target map[string]any) error {
+	dashboardUID, ok := target["dashboardUID"].(string)
+	if !ok || dashboardUID == "" {
+		return fmt.Errorf("%w: correlations of type \"%s\" must have a dashboardUID", ErrInvalidTarget, ConfigTypeDashboard)
+	}
+	variables, ok := target["variables"]
+	if !ok || variables == nil {
+		return nil
+	}
+	mapped, ok := variables.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%w: the variables of the dashboard must be an object", ErrInvalidTarget)
+	}
+	for name, value := range mapped {
+		if name == "" {
+			return fmt.Errorf("%w: the variables of the dashboard must have a name", ErrInvalidTarget)
+		}
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%w: the value of the variable \"%s\" must be a string", ErrInvalidTarget, name)
+		}
+	}
+	return nil
+}
+
 // Correlation is the model for correlations definitions
 // swagger:model
 type Correlation struct {
@@ -163,15 +277,19 @@ type CreateCorrelationCommand struct {
 }
 
 func (c CreateCorrelationCommand) Validate() error {
-	if err := c.Config.Type.Validate(); err != nil {
+	if err := c.Config.Validate(); err != nil {
 		return err
 	}
-	if c.TargetUID == nil && c.Config.Type == ConfigTypeQuery {
+	return validateTargetUID(c.TargetUID, c.Config.Type)
+}
+
+// validateTargetUID checks that only query correlations, and all of them, have a target data source.
+func validateTargetUID(targetUID *string, configType CorrelationConfigType) error {
+	if targetUID == nil && configType == ConfigTypeQuery {
 		return fmt.Errorf("correlations of type \"%s\" must have a targetUID", ConfigTypeQuery)
 	}
-
-	if err := c.Config.Transformations.Validate(); err != nil {
-		return err
+	if targetUID != nil && configType != ConfigTypeQuery {
+		return fmt.Errorf("correlations of type \"%s\" can't have a targetUID", configType)
 	}
 	return nil
 }
@@ -219,6 +337,12 @@ func (c CorrelationConfigUpdateDTO) Validate() error {
 		}
 	}
 
+	if c.Transformations != nil {
+		if err := Transformations(c.Transformations).Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -41,6 +41,46 @@ func TestCorrelationModels(t *testing.T) {
 			require.Error(t, cmd.Validate())
 		})
 
+		t.Run("Successfully validates external and dashboard correlations without target UID", func(t *testing.T) {
+			external := &CreateCorrelationCommand{
+				SourceUID: "some-uid",
+				OrgId:     1,
+				Config: CorrelationConfig{
+					Field:  "service",
+					Type:   ConfigTypeExternal,
+					Target: map[string]any{"url": "https://incidents.example.com/services/${service}"},
+				},
+			}
+			require.NoError(t, external.Validate())
+
+			dashboard := &CreateCorrelationCommand{
+				SourceUID: "some-uid",
+				OrgId:     1,
+				Config: CorrelationConfig{
+					Field:  "service",
+					Type:   ConfigTypeDashboard,
+					Target: map[string]any{"dashboardUID": "abc", "variables": map[string]any{"service": "${service}"}},
+				},
+			}
+			require.NoError(t, dashboard.Validate())
+		})
+
+		t.Run("Fails if target UID is set and config type != query", func(t *testing.T) {
+			targetUid := "targetUid"
+			cmd := &CreateCorrelationCommand{
+				SourceUID: "some-uid",
+				OrgId:     1,
+				TargetUID: &targetUid,
+				Config: CorrelationConfig{
+					Field:  "service",
+					Type:   ConfigTypeExternal,
+					Target: map[string]any{"url": "https://example.com"},
+				},
+			}
+
+			require.Error(t, cmd.Validate())
+		})
+
 		t.Run("Fails if config type is unknown", func(t *testing.T) {
 			config := &CorrelationConfig{
 				Field:  "field",
@@ -66,6 +106,8 @@ func TestCorrelationModels(t *testing.T) {
 
 			tests := []test{
 				{input: "query", assertion: require.NoError},
+				{input: "external", assertion: require.NoError},
+				{input: "dashboard", assertion: require.NoError},
 				{input: "link", assertion: require.Error},
 			}
 
@@ -75,6 +117,60 @@ func TestCorrelationModels(t *testing.T) {
 		})
 	})
 
+	t.Run("CorrelationConfig Validate", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			config CorrelationConfig
+			err    error
+		}{
+			{name: "external with templated url", config: CorrelationConfig{Type: ConfigTypeExternal, Target: map[string]any{"url": "http://example.com/${path}?q=${value}"}}},
+			{name: "external without url", config: CorrelationConfig{Type: ConfigTypeExternal, Target: map[string]any{}}, err: ErrInvalidTarget},
+			{name: "external with relative url", config: CorrelationConfig{Type: ConfigTypeExternal, Target: map[string]any{"url": "/d/abc"}}, err: ErrInvalidTarget},
+			{name: "external with templated host", config: CorrelationConfig{Type: ConfigTypeExternal, Target: map[string]any{"url": "${url}"}}, err: ErrInvalidTarget},
+			{name: "external with javascript url", config: CorrelationConfig{Type: ConfigTypeExternal, Target: map[string]any{"url": "javascript:alert(1)"}}, err: ErrInvalidTarget},
+			{name: "dashboard without variables", config: CorrelationConfig{Type: ConfigTypeDashboard, Target: map[string]any{"dashboardUID": "abc"}}},
+			{name: "dashboard without uid", config: CorrelationConfig{Type: ConfigTypeDashboard, Target: map[string]any{"variables": map[string]any{}}}, err: ErrInvalidTarget},
+			{name: "dashboard with invalid variables", config: CorrelationConfig{Type: ConfigTypeDashboard, Target: map[string]any{"dashboardUID": "abc", "variables": []any{"a"}}}, err: ErrInvalidTarget},
+			{name: "dashboard with non string variable", config: CorrelationConfig{Type: ConfigTypeDashboard, Target: map[string]any{"dashboardUID": "abc", "variables": map[string]any{"a": 1}}}, err: ErrInvalidTarget},
+			{name: "query with any target", config: CorrelationConfig{Type: ConfigTypeQuery, Target: map[string]any{"expr": "up"}}},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				err := tc.config.Validate()
+				if tc.err == nil {
+					require.NoError(t, err)
+				} else {
+					require.ErrorIs(t, err, tc.err)
+				}
+			})
+		}
+	})
+
+	t.Run("Transformations Validate", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			transformation Transformation
+			assertion      require.ErrorAssertionFunc
+		}{
+			{name: "logfmt", transformation: Transformation{Type: "logfmt"}, assertion: require.NoError},
+			{name: "regex", transformation: Transformation{Type: "regex", Expression: "id=(\\w+)"}, assertion: require.NoError},
+			{name: "regex without expression", transformation: Transformation{Type: "regex"}, assertion: require.Error},
+			{name: "jsonpath", transformation: Transformation{Type: "jsonpath", Expression: "$.user.id", MapValue: "userId"}, assertion: require.NoError},
+			{name: "jsonpath with filter", transformation: Transformation{Type: "jsonpath", Expression: "$.spans[?(@.kind==\"server\")].id"}, assertion: require.NoError},
+			{name: "jsonpath without expression", transformation: Transformation{Type: "jsonpath"}, assertion: require.Error},
+			{name: "invalid jsonpath", transformation: Transformation{Type: "jsonpath", Expression: "$.user[id"}, assertion: require.Error},
+			{name: "label", transformation: Transformation{Type: "label", Expression: "service_name"}, assertion: require.NoError},
+			{name: "label without name", transformation: Transformation{Type: "label"}, assertion: require.Error},
+			{name: "invalid label name", transformation: Transformation{Type: "label", Expression: "service-name"}, assertion: require.Error},
+			{name: "unknown type", transformation: Transformation{Type: "xpath", Expression: "//id"}, assertion: require.Error},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				tc.assertion(t, Transformations{tc.transformation}.Validate())
+			})
+		}
+	})
+
 	t.Run("CorrelationConfig JSON Marshaling", func(t *testing.T) {
 		t.Run("Applies a default empty object if target is not defined", func(t *testing.T) {
 			config := CorrelationConfig{
@@ -87,5 +183,18 @@ func TestCorrelationModels(t *testing.T) {
 
 			require.Equal(t, `{"type":"query","field":"field","target":{}}`, string(data))
 		})
+
+		t.Run("Keeps the type of the config", func(t *testing.T) {
+			config := CorrelationConfig{
+				Field:  "field",
+				Type:   ConfigTypeDashboard,
+				Target: map[string]any{"dashboardUID": "abc"},
+			}
+
+			data, err := json.Marshal(config)
+			require.NoError(t, err)
+
+			require.Equal(t, `{"type":"dashboard","field":"field","target":{"dashboardUID":"abc"}}`, string(data))
+		})
 	})
 }
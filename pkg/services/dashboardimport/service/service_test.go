@@ -17,6 +17,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/librarypanels"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/plugindashboards"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
+	"github.com/grafana/grafana/pkg/services/querylibrary/querylibrarytest"
 	"github.com/grafana/grafana/pkg/services/user"
 )
 
@@ -68,6 +70,7 @@ func TestImportDashboardService(t *testing.T) {
 			dashboardService:       dashboardService,
 			libraryPanelService:    libraryPanelService,
 			folderService:          folderService,
+			queryLibraryService:    querylibrarytest.NewFakeService(),
 		}
 
 		req := &dashboardimport.ImportDashboardRequest{
@@ -129,6 +132,7 @@ func TestImportDashboardService(t *testing.T) {
 			dashboardService:    dashboardService,
 			libraryPanelService: libraryPanelService,
 			folderService:       folderService,
+			queryLibraryService: querylibrarytest.NewFakeService(),
 		}
 
 		loadResp, err := loadTestDashboard(context.Background(), &plugindashboards.LoadPluginDashboardRequest{
@@ -163,6 +167,36 @@ func TestImportDashboardService(t *testing.T) {
 		panel := importDashboardArg.Dashboard.Data.Get("panels").GetIndex(0)
 		require.Equal(t, "prom", panel.Get("datasource").MustString())
 	})
+
+	t.Run("When importing a dashboard referencing a library query the user cannot read should fail", func(t *testing.T) {
+		importDashboardCalled := false
+		queryLibraryService := querylibrarytest.NewFakeService()
+		queryLibraryService.ExpectedError = querylibrary.ErrAccessDenied.Errorf("user cannot access library query")
+		s := &ImportDashboardService{
+			dashboardService: &dashboardServiceMock{
+				importDashboardFunc: func(ctx context.Context, dto *dashboards.SaveDashboardDTO) (*dashboards.Dashboard, error) {
+					importDashboardCalled = true
+					return nil, nil
+				},
+			},
+			folderService: &foldertest.FakeService{
+				ExpectedFolder: &folder.Folder{ID: 5, UID: "123"},
+			},
+			queryLibraryService: queryLibraryService,
+		}
+
+		req := &dashboardimport.ImportDashboardRequest{
+			Dashboard: simplejson.NewFromAny(map[string]any{
+				"title":  "Library query",
+				"panels": []any{map[string]any{"targets": []any{map[string]any{"refId": "A", "libraryQuery": map[string]any{"uid": "cpu"}}}}},
+			}),
+			User:     &user.SignedInUser{UserID: 2, OrgRole: org.RoleEditor, OrgID: 3},
+			FolderId: 5, // nolint:staticcheck
+		}
+		_, err := s.ImportDashboard(context.Background(), req)
+		require.ErrorIs(t, err, querylibrary.ErrAccessDenied)
+		require.False(t, importDashboardCalled)
+	})
 }
 
 func loadTestDashboard(ctx context.Context, req *plugindashboards.LoadPluginDashboardRequest) (*plugindashboards.LoadPluginDashboardResponse, error) {
@@ -0,0 +1,15 @@
+package dashboardlint
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Service lints dashboards with the configured rules.
+type Service interface {
+	// Lint runs the enabled rules over the dashboard JSON.
+	Lint(ctx context.Context, orgID int64, dashboard *simplejson.Json) (*Result, error)
+	// Rules lists all known rules and whether they are enabled.
+	Rules() []RuleInfo
+}
@@ -0,0 +1,50 @@
+package dashboardlintimpl
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type lintRequest struct {
+	Dashboard *simplejson.Json `json:"dashboard"`
+}
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+	// Linting checks the data sources used by the dashboard, so it is limited to users who can save dashboards.
+	canSave := ac.EvalAny(ac.EvalPermission(dashboards.ActionDashboardsCreate), ac.EvalPermission(dashboards.ActionDashboardsWrite))
+	routeRegister.Group("/api/dashboards/lint", func(lint routing.RouteRegister) {
+		lint.Post("/", middleware.ReqSignedIn, authorize(canSave), routing.Wrap(s.lintHandler))
+		lint.Get("/rules", middleware.ReqSignedIn, routing.Wrap(s.rulesHandler))
+	})
+}
+
+// POST /api/dashboards/lint
+func (s *Service) lintHandler(c *contextmodel.ReqContext) response.Response {
+	req := lintRequest{}
+	if err := web.Bind(c.Req, &req); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if req.Dashboard == nil {
+		return response.Error(http.StatusBadRequest, "dashboard is required", nil)
+	}
+
+	result, err := s.Lint(c.Req.Context(), c.SignedInUser.OrgID, req.Dashboard)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to lint dashboard", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// GET /api/dashboards/lint/rules
+func (s *Service) rulesHandler(_ *contextmodel.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, s.Rules())
+}
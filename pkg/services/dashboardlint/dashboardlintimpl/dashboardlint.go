@@ -0,0 +1,179 @@
+package dashboardlintimpl
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboardlint"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dashboardservice "github.com/grafana/grafana/pkg/services/dashboards/service"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Service runs the lint rules when dashboards are saved, imported or provisioned, and
+// exposes them through the API. Saves are only rejected for the configured orgs and folders,
+// otherwise the findings are logged.
+type Service struct {
+	cfg               *setting.Cfg
+	log               log.Logger
+	linter            *dashboardlint.Linter
+	dataSourceService datasources.DataSourceService
+	folderStore       folder.FolderStore
+	accessControl     accesscontrol.AccessControl
+
+	enabled       bool
+	rejectAllOrgs bool
+	rejectOrgs    map[int64]bool
+	rejectFolders map[string]bool
+}
+
+var _ dashboardlint.Service = (*Service)(nil)
+var _ dashboards.SaveValidator = (*Service)(nil)
+
+func ProvideService(cfg *setting.Cfg, dashboardService *dashboardservice.DashboardServiceImpl, dataSourceService datasources.DataSourceService,
+	folderStore folder.FolderStore, routeRegister routing.RouteRegister, accessControl accesscontrol.AccessControl) *Service {
+	section := cfg.SectionWithEnvOverrides("dashboard_lint")
+	s := &Service{
+		cfg:               cfg,
+		log:               log.New("dashboard-lint"),
+		dataSourceService: dataSourceService,
+		folderStore:       folderStore,
+		accessControl:     accessControl,
+		enabled:           section.Key("enabled").MustBool(false),
+		rejectOrgs:        map[int64]bool{},
+		rejectFolders:     map[string]bool{},
+	}
+
+	severities := map[string]dashboardlint.Severity{}
+	for _, name := range splitList(section.Key("error_rules").String()) {
+		severities[name] = dashboardlint.SeverityError
+	}
+	for _, name := range splitList(section.Key("warning_rules").String()) {
+		severities[name] = dashboardlint.SeverityWarning
+	}
+
+	disabled := map[string]bool{}
+	for _, name := range splitList(section.Key("disabled_rules").String()) {
+		disabled[name] = true
+	}
+	rules := make([]dashboardlint.Rule, 0)
+	for _, rule := range dashboardlint.DefaultRules() {
+		if !disabled[rule.Name()] {
+			rules = append(rules, rule)
+		}
+	}
+	s.linter = dashboardlint.NewLinter(rules, severities)
+
+	for _, org := range splitList(section.Key("reject_orgs").String()) {
+		if org == "*" {
+			s.rejectAllOrgs = true
+			continue
+		}
+		id, err := strconv.ParseInt(org, 10, 64)
+		if err != nil {
+			s.log.Warn("Ignoring invalid org ID in reject_orgs", "value", org)
+			continue
+		}
+		s.rejectOrgs[id] = true
+	}
+	for _, uid := range splitList(section.Key("reject_folders").String()) {
+		s.rejectFolders[uid] = true
+	}
+
+	if s.enabled {
+		dashboardService.RegisterSaveValidator(s)
+		s.registerAPIEndpoints(routeRegister)
+	}
+
+	return s
+}
+
+func (s *Service) Lint(ctx context.Context, orgID int64, dashboard *simplejson.Json) (*dashboardlint.Result, error) {
+	return s.linter.Lint(ctx, &dashboardlint.RuleContext{
+		OrgID:            orgID,
+		DataSourceExists: s.dataSourceExists,
+	}, dashboard)
+}
+
+func (s *Service) Rules() []dashboardlint.RuleInfo {
+	return s.linter.RuleInfos(dashboardlint.DefaultRules())
+}
+
+// ValidateSave lints the dashboard before it is saved and rejects it with a
+// *dashboardlint.LintError when it has errors and rejection is enabled for its org or folder.
+func (s *Service) ValidateSave(ctx context.Context, dash *dashboards.Dashboard) error {
+	if dash.IsFolder || dash.Data == nil {
+		return nil
+	}
+
+	result, err := s.Lint(ctx, dash.OrgID, dash.Data)
+	if err != nil {
+		// a broken rule must never block saving dashboards
+		s.log.Error("Failed to lint dashboard", "uid", dash.UID, "error", err)
+		return nil
+	}
+	if len(result.Findings) == 0 {
+		return nil
+	}
+
+	if result.HasErrors() && s.shouldReject(ctx, dash) {
+		return &dashboardlint.LintError{Result: result}
+	}
+
+	s.log.Info("Dashboard has lint findings", "orgId", dash.OrgID, "uid", dash.UID, "errors", result.Errors, "warnings", result.Warnings)
+	return nil
+}
+
+func (s *Service) shouldReject(ctx context.Context, dash *dashboards.Dashboard) bool {
+	if s.rejectAllOrgs || s.rejectOrgs[dash.OrgID] {
+		return true
+	}
+	if len(s.rejectFolders) == 0 {
+		return false
+	}
+
+	folderUID := dash.FolderUID
+	if folderUID == "" && dash.FolderID > 0 {
+		f, err := s.folderStore.GetFolderByID(ctx, dash.OrgID, dash.FolderID)
+		if err != nil {
+			s.log.Warn("Failed to get dashboard folder", "folderId", dash.FolderID, "error", err)
+			return false
+		}
+		folderUID = f.UID
+	}
+	return s.rejectFolders[folderUID]
+}
+
+func (s *Service) dataSourceExists(ctx context.Context, orgID int64, uidOrName string) (bool, error) {
+	for _, query := range []*datasources.GetDataSourceQuery{
+		{OrgID: orgID, UID: uidOrName},
+		{OrgID: orgID, Name: uidOrName},
+	} {
+		_, err := s.dataSourceService.GetDataSource(ctx, query)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
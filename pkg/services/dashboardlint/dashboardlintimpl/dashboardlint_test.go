@@ -0,0 +1,123 @@
+package dashboardlintimpl
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/dashboardlint"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dashboardservice "github.com/grafana/grafana/pkg/services/dashboards/service"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/folder/foldertest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func setupService(t *testing.T, settings map[string]string) *Service {
+	t.Helper()
+	return setupServiceWithRoutes(t, settings, routing.NewRouteRegister())
+}
+
+func setupServiceWithRoutes(t *testing.T, settings map[string]string, routeRegister routing.RouteRegister) *Service {
+	t.Helper()
+	cfg := setting.NewCfg()
+	section, err := cfg.Raw.NewSection("dashboard_lint")
+	require.NoError(t, err)
+	for k, v := range settings {
+		_, err := section.NewKey(k, v)
+		require.NoError(t, err)
+	}
+
+	dsService := &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{{OrgID: 1, UID: "prom", Name: "Prometheus"}}}
+	return ProvideService(cfg, &dashboardservice.DashboardServiceImpl{}, dsService, foldertest.NewFakeFolderStore(t), routeRegister, acimpl.ProvideAccessControl(cfg))
+}
+
+func TestValidateSave(t *testing.T) {
+	ctx := context.Background()
+	broken := func(orgID int64, folderUID string) *dashboards.Dashboard {
+		return &dashboards.Dashboard{
+			OrgID:     orgID,
+			FolderUID: folderUID,
+			Data: simplejson.NewFromAny(map[string]any{
+				"panels": []any{
+					map[string]any{"id": 1, "type": "timeseries", "datasource": map[string]any{"uid": "gone"}},
+				},
+			}),
+		}
+	}
+
+	t.Run("only logs findings by default", func(t *testing.T) {
+		s := setupService(t, nil)
+		require.NoError(t, s.ValidateSave(ctx, broken(1, "")))
+	})
+
+	t.Run("rejects configured orgs", func(t *testing.T) {
+		s := setupService(t, map[string]string{"reject_orgs": "2, 3"})
+		require.NoError(t, s.ValidateSave(ctx, broken(1, "")))
+
+		err := s.ValidateSave(ctx, broken(2, ""))
+		var lintErr *dashboardlint.LintError
+		require.ErrorAs(t, err, &lintErr)
+		require.Equal(t, "missing-datasource", lintErr.Result.Findings[0].Rule)
+	})
+
+	t.Run("rejects configured folders", func(t *testing.T) {
+		s := setupService(t, map[string]string{"reject_folders": "strict"})
+		require.NoError(t, s.ValidateSave(ctx, broken(1, "other")))
+		require.Error(t, s.ValidateSave(ctx, broken(1, "strict")))
+	})
+
+	t.Run("severity overrides and disabled rules", func(t *testing.T) {
+		s := setupService(t, map[string]string{"reject_orgs": "*", "warning_rules": "missing-datasource"})
+		require.NoError(t, s.ValidateSave(ctx, broken(1, "")))
+
+		s = setupService(t, map[string]string{"reject_orgs": "*", "disabled_rules": "missing-datasource"})
+		require.NoError(t, s.ValidateSave(ctx, broken(1, "")))
+		for _, rule := range s.Rules() {
+			require.Equal(t, rule.Name != "missing-datasource", rule.Enabled)
+		}
+	})
+
+	t.Run("data sources can be referenced by name", func(t *testing.T) {
+		s := setupService(t, nil)
+		result, err := s.Lint(ctx, 1, simplejson.NewFromAny(map[string]any{
+			"panels": []any{map[string]any{"id": 1, "type": "timeseries", "datasource": "Prometheus"}},
+		}))
+		require.NoError(t, err)
+		require.Empty(t, result.Findings)
+	})
+}
+
+func TestLintAPI(t *testing.T) {
+	routeRegister := routing.NewRouteRegister()
+	setupServiceWithRoutes(t, map[string]string{"enabled": "true"}, routeRegister)
+	server := webtest.NewServer(t, routeRegister)
+
+	lint := func(t *testing.T, permissions map[string][]string) int {
+		t.Helper()
+		req := server.NewPostRequest("/api/dashboards/lint", strings.NewReader(`{"dashboard":{"panels":[]}}`))
+		webtest.RequestWithSignedInUser(req, &user.SignedInUser{UserID: 1, OrgID: 1, Permissions: map[int64]map[string][]string{1: permissions}})
+		resp, err := server.SendJSON(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+
+	t.Run("users who can save dashboards can lint", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, lint(t, map[string][]string{dashboards.ActionDashboardsWrite: {dashboards.ScopeDashboardsAll}}))
+		require.Equal(t, http.StatusOK, lint(t, map[string][]string{dashboards.ActionDashboardsCreate: {dashboards.ScopeFoldersAll}}))
+	})
+
+	t.Run("viewers can not lint", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, lint(t, map[string][]string{dashboards.ActionDashboardsRead: {dashboards.ScopeDashboardsAll}}))
+	})
+}
@@ -0,0 +1,72 @@
+package dashboardlint
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Linter runs a set of rules over dashboards.
+type Linter struct {
+	rules []Rule
+	// severity overrides by rule name
+	severities map[string]Severity
+}
+
+func NewLinter(rules []Rule, severities map[string]Severity) *Linter {
+	if severities == nil {
+		severities = map[string]Severity{}
+	}
+	return &Linter{rules: rules, severities: severities}
+}
+
+func (l *Linter) Lint(ctx context.Context, rc *RuleContext, dashboard *simplejson.Json) (*Result, error) {
+	result := &Result{Findings: make([]Finding, 0)}
+	for _, rule := range l.rules {
+		findings, err := rule.Check(ctx, rc, dashboard)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s failed: %w", rule.Name(), err)
+		}
+
+		severity := l.severity(rule)
+		for _, f := range findings {
+			f.Rule = rule.Name()
+			f.Severity = severity
+			result.add(f)
+		}
+	}
+
+	// errors first, keep the order of the rules otherwise
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return result.Findings[i].Severity == SeverityError && result.Findings[j].Severity != SeverityError
+	})
+	return result, nil
+}
+
+func (l *Linter) severity(rule Rule) Severity {
+	if s, ok := l.severities[rule.Name()]; ok {
+		return s
+	}
+	return rule.DefaultSeverity()
+}
+
+// RuleInfos describes the given rules, marking those in enabled as enabled.
+func (l *Linter) RuleInfos(all []Rule) []RuleInfo {
+	enabled := make(map[string]bool, len(l.rules))
+	for _, r := range l.rules {
+		enabled[r.Name()] = true
+	}
+
+	infos := make([]RuleInfo, 0, len(all))
+	for _, r := range all {
+		infos = append(infos, RuleInfo{
+			Name:        r.Name(),
+			Description: r.Description(),
+			Severity:    l.severity(r),
+			Enabled:     enabled[r.Name()],
+		})
+	}
+	return infos
+}
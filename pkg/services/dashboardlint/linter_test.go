@@ -0,0 +1,114 @@
+package dashboardlint
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func lintJSON(t *testing.T, linter *Linter, rc *RuleContext, dashboard string) *Result {
+	t.Helper()
+	dash, err := simplejson.NewJson([]byte(dashboard))
+	require.NoError(t, err)
+	result, err := linter.Lint(context.Background(), rc, dash)
+	require.NoError(t, err)
+	return result
+}
+
+func rulesOf(result *Result) []string {
+	rules := make([]string, 0, len(result.Findings))
+	for _, f := range result.Findings {
+		rules = append(rules, f.Rule)
+	}
+	return rules
+}
+
+func TestLinter(t *testing.T) {
+	existing := func(_ context.Context, _ int64, uidOrName string) (bool, error) {
+		return uidOrName == "prom" || uidOrName == "Prometheus", nil
+	}
+	rc := &RuleContext{OrgID: 1, DataSourceExists: existing}
+	linter := NewLinter(DefaultRules(), nil)
+
+	t.Run("clean dashboard", func(t *testing.T) {
+		result := lintJSON(t, linter, rc, `{
+			"panels": [
+				{"id": 1, "type": "timeseries", "datasource": {"uid": "prom"}, "targets": [{"refId": "A"}]},
+				{"id": 2, "type": "row", "panels": [{"id": 3, "type": "stat", "datasource": "Prometheus"}]},
+				{"id": 4, "type": "table", "datasource": {"uid": "${ds}"}},
+				{"id": 5, "type": "table", "datasource": {"uid": "-- Grafana --"}}
+			]
+		}`)
+		require.Empty(t, result.Findings)
+		require.False(t, result.HasErrors())
+	})
+
+	t.Run("duplicate panel IDs in collapsed rows", func(t *testing.T) {
+		result := lintJSON(t, linter, rc, `{
+			"panels": [
+				{"id": 1, "type": "timeseries"},
+				{"id": 2, "type": "row", "panels": [{"id": 1, "type": "stat"}]}
+			]
+		}`)
+		require.Equal(t, []string{"duplicate-panel-id"}, rulesOf(result))
+		require.Equal(t, int64(1), *result.Findings[0].PanelID)
+		require.True(t, result.HasErrors())
+	})
+
+	t.Run("missing data sources", func(t *testing.T) {
+		result := lintJSON(t, linter, rc, `{
+			"panels": [
+				{"id": 1, "type": "timeseries", "datasource": {"uid": "gone"}, "targets": [{"datasource": {"uid": "gone"}}, {"datasource": {"uid": "prom"}}]}
+			],
+			"templating": {"list": [{"type": "query", "datasource": "Removed"}]}
+		}`)
+		require.Equal(t, []string{"missing-datasource", "missing-datasource", "missing-datasource"}, rulesOf(result))
+		require.Nil(t, result.Findings[2].PanelID)
+	})
+
+	t.Run("missing data sources are not checked offline", func(t *testing.T) {
+		result := lintJSON(t, linter, &RuleContext{}, `{"panels": [{"id": 1, "type": "timeseries", "datasource": {"uid": "gone"}}]}`)
+		require.Empty(t, result.Findings)
+	})
+
+	t.Run("deprecated panel types in old rows", func(t *testing.T) {
+		result := lintJSON(t, linter, rc, `{"rows": [{"panels": [{"id": 1, "type": "graph"}, {"id": 2, "type": "singlestat"}]}]}`)
+		require.Equal(t, []string{"deprecated-panel-type", "deprecated-panel-type"}, rulesOf(result))
+		require.Equal(t, 0, result.Errors)
+		require.Equal(t, 2, result.Warnings)
+	})
+
+	t.Run("unbounded queries", func(t *testing.T) {
+		result := lintJSON(t, linter, rc, `{"panels": [{"id": 1, "type": "table", "targets": [
+			{"refId": "A", "rawSql": "SELECT * FROM logs"},
+			{"refId": "B", "rawSql": "SELECT * FROM logs WHERE $__timeFilter(time)"},
+			{"refId": "C", "rawSql": "SELECT * FROM logs LIMIT 100"},
+			{"refId": "D", "rawSql": "SELECT * FROM logs", "hide": true}
+		]}]}`)
+		require.Equal(t, []string{"unbounded-query"}, rulesOf(result))
+		require.Contains(t, result.Findings[0].Message, "query A")
+	})
+
+	t.Run("severity overrides and ordering", func(t *testing.T) {
+		linter := NewLinter(DefaultRules(), map[string]Severity{
+			"duplicate-panel-id":    SeverityWarning,
+			"deprecated-panel-type": SeverityError,
+		})
+		result := lintJSON(t, linter, rc, `{"panels": [{"id": 1, "type": "timeseries"}, {"id": 1, "type": "graph"}]}`)
+		require.Equal(t, []string{"deprecated-panel-type", "duplicate-panel-id"}, rulesOf(result))
+		require.Equal(t, 1, result.Errors)
+		require.Equal(t, 1, result.Warnings)
+	})
+
+	t.Run("rule infos", func(t *testing.T) {
+		linter := NewLinter([]Rule{&duplicatePanelIDRule{}}, nil)
+		infos := linter.RuleInfos(DefaultRules())
+		require.Len(t, infos, len(DefaultRules()))
+		for _, info := range infos {
+			require.Equal(t, info.Name == "duplicate-panel-id", info.Enabled)
+		}
+	})
+}
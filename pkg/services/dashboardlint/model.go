@@ -0,0 +1,80 @@
+package dashboardlint
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Finding is a single problem reported by a rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// PanelID is set when the finding is about a single panel.
+	PanelID *int64 `json:"panelId,omitempty"`
+}
+
+type Result struct {
+	Findings []Finding `json:"findings"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+}
+
+func (r *Result) HasErrors() bool {
+	return r.Errors > 0
+}
+
+func (r *Result) add(f Finding) {
+	r.Findings = append(r.Findings, f)
+	switch f.Severity {
+	case SeverityError:
+		r.Errors++
+	case SeverityWarning:
+		r.Warnings++
+	}
+}
+
+type RuleInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Severity    Severity `json:"severity"`
+	Enabled     bool     `json:"enabled"`
+}
+
+// DataSourceLookup reports whether a data source exists, by UID or by name for dashboards
+// still referencing data sources by name.
+type DataSourceLookup func(ctx context.Context, orgID int64, uidOrName string) (bool, error)
+
+// RuleContext is passed to every rule.
+type RuleContext struct {
+	OrgID int64
+	// DataSourceExists is nil when data sources can not be looked up, e.g. when linting offline.
+	DataSourceExists DataSourceLookup
+}
+
+// Rule checks one aspect of a dashboard. Rules report findings without a severity, the linter
+// applies the rule severity, which can be overridden in the configuration.
+type Rule interface {
+	Name() string
+	Description() string
+	DefaultSeverity() Severity
+	Check(ctx context.Context, rc *RuleContext, dashboard *simplejson.Json) ([]Finding, error)
+}
+
+// LintError rejects a dashboard save because of lint errors.
+type LintError struct {
+	Result *Result
+}
+
+func (e *LintError) Error() string {
+	return fmt.Sprintf("dashboard has %d lint error(s)", e.Result.Errors)
+}
@@ -0,0 +1,218 @@
+package dashboardlint
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// DefaultRules returns all built-in rules.
+func DefaultRules() []Rule {
+	return []Rule{
+		&duplicatePanelIDRule{},
+		&missingDataSourceRule{},
+		&deprecatedPanelTypeRule{},
+		&unboundedQueryRule{},
+	}
+}
+
+type duplicatePanelIDRule struct{}
+
+func (r *duplicatePanelIDRule) Name() string { return "duplicate-panel-id" }
+
+func (r *duplicatePanelIDRule) Description() string {
+	return "Panel IDs must be unique within a dashboard, links and library panels rely on them"
+}
+
+func (r *duplicatePanelIDRule) DefaultSeverity() Severity { return SeverityError }
+
+func (r *duplicatePanelIDRule) Check(_ context.Context, _ *RuleContext, dashboard *simplejson.Json) ([]Finding, error) {
+	findings := make([]Finding, 0)
+	seen := make(map[int64]bool)
+	forEachPanel(dashboard, func(panel *simplejson.Json) {
+		id, err := panel.Get("id").Int64()
+		if err != nil {
+			return
+		}
+		if seen[id] {
+			findings = append(findings, Finding{
+				PanelID: &id,
+				Message: fmt.Sprintf("panel ID %d is used by more than one panel", id),
+			})
+		}
+		seen[id] = true
+	})
+	return findings, nil
+}
+
+type missingDataSourceRule struct{}
+
+func (r *missingDataSourceRule) Name() string { return "missing-datasource" }
+
+func (r *missingDataSourceRule) Description() string {
+	return "Panels and queries must reference data sources which exist"
+}
+
+func (r *missingDataSourceRule) DefaultSeverity() Severity { return SeverityError }
+
+// builtInDataSources are never stored as data sources
+var builtInDataSources = map[string]bool{
+	"grafana":         true,
+	"-- Grafana --":   true,
+	"-- Mixed --":     true,
+	"-- Dashboard --": true,
+	"__expr__":        true,
+	"default":         true,
+}
+
+func (r *missingDataSourceRule) Check(ctx context.Context, rc *RuleContext, dashboard *simplejson.Json) ([]Finding, error) {
+	findings := make([]Finding, 0)
+	if rc == nil || rc.DataSourceExists == nil {
+		return findings, nil
+	}
+
+	checked := make(map[string]bool)
+	var lookupErr error
+	check := func(panel *simplejson.Json, ref *simplejson.Json) {
+		uid := dataSourceRef(ref)
+		if uid == "" || builtInDataSources[uid] || strings.Contains(uid, "$") || lookupErr != nil {
+			return
+		}
+		exists, ok := checked[uid]
+		if !ok {
+			var err error
+			if exists, err = rc.DataSourceExists(ctx, rc.OrgID, uid); err != nil {
+				lookupErr = err
+				return
+			}
+			checked[uid] = exists
+		}
+		if !exists {
+			findings = append(findings, Finding{
+				PanelID: panelID(panel),
+				Message: fmt.Sprintf("data source %q does not exist", uid),
+			})
+		}
+	}
+
+	forEachPanel(dashboard, func(panel *simplejson.Json) {
+		check(panel, panel.Get("datasource"))
+		for _, target := range panel.Get("targets").MustArray() {
+			check(panel, simplejson.NewFromAny(target).Get("datasource"))
+		}
+	})
+	for _, variable := range dashboard.GetPath("templating", "list").MustArray() {
+		v := simplejson.NewFromAny(variable)
+		if v.Get("type").MustString() == "query" {
+			check(nil, v.Get("datasource"))
+		}
+	}
+	return findings, lookupErr
+}
+
+// dataSourceRef returns the UID of a data source reference, or the name for old dashboards.
+func dataSourceRef(ref *simplejson.Json) string {
+	if name, err := ref.String(); err == nil {
+		return name
+	}
+	return ref.Get("uid").MustString()
+}
+
+type deprecatedPanelTypeRule struct{}
+
+func (r *deprecatedPanelTypeRule) Name() string { return "deprecated-panel-type" }
+
+func (r *deprecatedPanelTypeRule) Description() string {
+	return "Panels should not use deprecated panel types"
+}
+
+func (r *deprecatedPanelTypeRule) DefaultSeverity() Severity { return SeverityWarning }
+
+// deprecatedPanelTypes maps deprecated panel types to their replacement
+var deprecatedPanelTypes = map[string]string{
+	"graph":                    "timeseries",
+	"singlestat":               "stat",
+	"table-old":                "table",
+	"grafana-singlestat-panel": "stat",
+	"grafana-piechart-panel":   "piechart",
+	"grafana-worldmap-panel":   "geomap",
+}
+
+func (r *deprecatedPanelTypeRule) Check(_ context.Context, _ *RuleContext, dashboard *simplejson.Json) ([]Finding, error) {
+	findings := make([]Finding, 0)
+	forEachPanel(dashboard, func(panel *simplejson.Json) {
+		panelType := panel.Get("type").MustString()
+		if replacement, ok := deprecatedPanelTypes[panelType]; ok {
+			findings = append(findings, Finding{
+				PanelID: panelID(panel),
+				Message: fmt.Sprintf("panel type %q is deprecated, use %q instead", panelType, replacement),
+			})
+		}
+	})
+	return findings, nil
+}
+
+type unboundedQueryRule struct{}
+
+func (r *unboundedQueryRule) Name() string { return "unbounded-query" }
+
+func (r *unboundedQueryRule) Description() string {
+	return "Raw SQL queries should be limited to the dashboard time range or a maximum number of rows"
+}
+
+func (r *unboundedQueryRule) DefaultSeverity() Severity { return SeverityWarning }
+
+var (
+	sqlTimeMacro = regexp.MustCompile(`\$__(timeFilter|unixEpochFilter|unixEpochNanoFilter|timeFrom|timeTo|unixEpochFrom|unixEpochTo|unixEpochNanoFrom|unixEpochNanoTo)\b`)
+	sqlLimit     = regexp.MustCompile(`(?i)\b(limit|top)\s+\d+`)
+)
+
+func (r *unboundedQueryRule) Check(_ context.Context, _ *RuleContext, dashboard *simplejson.Json) ([]Finding, error) {
+	findings := make([]Finding, 0)
+	forEachPanel(dashboard, func(panel *simplejson.Json) {
+		for _, t := range panel.Get("targets").MustArray() {
+			target := simplejson.NewFromAny(t)
+			sql := target.Get("rawSql").MustString()
+			if sql == "" || target.Get("hide").MustBool() {
+				continue
+			}
+			if !sqlTimeMacro.MatchString(sql) && !sqlLimit.MatchString(sql) {
+				findings = append(findings, Finding{
+					PanelID: panelID(panel),
+					Message: fmt.Sprintf("query %s is not limited to the time range and has no row limit", target.Get("refId").MustString("A")),
+				})
+			}
+		}
+	})
+	return findings, nil
+}
+
+// forEachPanel calls fn for all panels, including panels in collapsed rows and old style rows.
+func forEachPanel(dashboard *simplejson.Json, fn func(panel *simplejson.Json)) {
+	var walk func(panels []any)
+	walk = func(panels []any) {
+		for _, p := range panels {
+			panel := simplejson.NewFromAny(p)
+			fn(panel)
+			walk(panel.Get("panels").MustArray())
+		}
+	}
+	walk(dashboard.Get("panels").MustArray())
+	for _, row := range dashboard.Get("rows").MustArray() {
+		walk(simplejson.NewFromAny(row).Get("panels").MustArray())
+	}
+}
+
+func panelID(panel *simplejson.Json) *int64 {
+	if panel == nil {
+		return nil
+	}
+	id, err := panel.Get("id").Int64()
+	if err != nil {
+		return nil
+	}
+	return &id
+}
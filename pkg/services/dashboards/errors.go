@@ -2,7 +2,9 @@ package dashboards
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -163,3 +165,29 @@ type UpdatePluginDashboardError struct {
 func (d UpdatePluginDashboardError) Error() string {
 	return "Dashboard belongs to plugin"
 }
+
+// DashboardMergeConflictError is returned when a dashboard has been changed by someone else and
+// the changes could not be merged, because both changed the same elements. It wraps
+// ErrDashboardVersionMismatch.
+type DashboardMergeConflictError struct {
+	// CurrentVersion is the version of the stored dashboard.
+	CurrentVersion int
+	Conflicts      []dashdiffs.MergeConflict
+}
+
+func (e DashboardMergeConflictError) Error() string {
+	return fmt.Sprintf("%s, %d change(s) conflict", ErrDashboardVersionMismatch.Reason, len(e.Conflicts))
+}
+
+func (e DashboardMergeConflictError) Unwrap() error {
+	return ErrDashboardVersionMismatch
+}
+
+// Body returns the version mismatch response body with the conflicts.
+func (e DashboardMergeConflictError) Body() util.DynMap {
+	body := ErrDashboardVersionMismatch.Body()
+	body["message"] = e.Error()
+	body["version"] = e.CurrentVersion
+	body["conflicts"] = e.Conflicts
+	return body
+}
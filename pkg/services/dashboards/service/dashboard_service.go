@@ -2,11 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/alerting"
@@ -48,6 +50,9 @@ type DashboardServiceImpl struct {
 	folderPermissions    accesscontrol.FolderPermissionsService
 	dashboardPermissions accesscontrol.DashboardPermissionsService
 	ac                   accesscontrol.AccessControl
+	saveValidators       []dashboards.SaveValidator
+	// mergeConcurrentEdits enables merging dashboards changed by someone else since they were loaded
+	mergeConcurrentEdits bool
 }
 
 // This is the uber service that implements a three smaller services
@@ -68,6 +73,7 @@ func ProvideDashboardServiceImpl(
 		ac:                   ac,
 		folderStore:          folderStore,
 		folderService:        folderSvc,
+		mergeConcurrentEdits: cfg.SectionWithEnvOverrides("dashboards").Key("merge_concurrent_edits").MustBool(false),
 	}
 
 	ac.RegisterScopeAttributeResolver(dashboards.NewDashboardIDScopeResolver(folderStore, dashSvc, folderSvc))
@@ -92,6 +98,54 @@ func (dr *DashboardServiceImpl) GetProvisionedDashboardDataByDashboardUID(ctx co
 	return dr.dashboardStore.GetProvisionedDataByDashboardUID(ctx, orgID, dashboardUID)
 }
 
+// mergeConcurrentChanges merges the changes saved by someone else since the dashboard was loaded
+// into the dashboard being saved, using the loaded version as common ancestor. A
+// dashboards.DashboardMergeConflictError is returned when both changed the same elements.
+// Nothing happens when the ancestor is not available, and the save fails with a version mismatch.
+func (dr *DashboardServiceImpl) mergeConcurrentChanges(ctx context.Context, dash *dashboards.Dashboard) error {
+	if (dash.ID == 0 && dash.UID == "") || dash.Version == 0 {
+		return nil
+	}
+
+	existing, err := dr.dashboardStore.GetDashboard(ctx, &dashboards.GetDashboardQuery{ID: dash.ID, UID: dash.UID, OrgID: dash.OrgID})
+	if err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.IsFolder || existing.Version <= dash.Version {
+		return nil
+	}
+
+	ancestor, err := dr.dashboardStore.GetDashboardVersionData(ctx, dash.OrgID, existing.ID, dash.Version)
+	if err != nil || ancestor == nil {
+		return err
+	}
+
+	merged, conflicts, err := dashdiffs.MergeDashboards(ancestor, existing.Data, dash.Data)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return dashboards.DashboardMergeConflictError{CurrentVersion: existing.Version, Conflicts: conflicts}
+	}
+
+	dr.log.Info("Merged concurrent dashboard changes", "orgId", dash.OrgID, "uid", existing.UID, "version", dash.Version, "currentVersion", existing.Version)
+	dash.Data = merged
+	dash.Title = strings.TrimSpace(merged.Get("title").MustString())
+	dash.UpdateSlug()
+	dash.SetID(existing.ID)
+	dash.SetUID(existing.UID)
+	dash.SetVersion(existing.Version)
+	return nil
+}
+
+// RegisterSaveValidator adds a validator which is run before dashboards are saved.
+func (dr *DashboardServiceImpl) RegisterSaveValidator(v dashboards.SaveValidator) {
+	dr.saveValidators = append(dr.saveValidators, v)
+}
+
 //nolint:gocyclo
 func (dr *DashboardServiceImpl) BuildSaveDashboardCommand(ctx context.Context, dto *dashboards.SaveDashboardDTO, shouldValidateAlerts bool,
 	validateProvisionedDashboard bool) (*dashboards.SaveDashboardCommand, error) {
@@ -120,10 +174,22 @@ func (dr *DashboardServiceImpl) BuildSaveDashboardCommand(ctx context.Context, d
 		return nil, dashboards.ErrDashboardUidTooLong
 	}
 
+	if dr.mergeConcurrentEdits && !dto.Overwrite && !dash.IsFolder {
+		if err := dr.mergeConcurrentChanges(ctx, dash); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := validateDashboardRefreshInterval(dash); err != nil {
 		return nil, err
 	}
 
+	for _, v := range dr.saveValidators {
+		if err := v.ValidateSave(ctx, dash); err != nil {
+			return nil, err
+		}
+	}
+
 	if shouldValidateAlerts {
 		dashAlertInfo := alerting.DashAlertInfo{Dash: dash, User: dto.User, OrgID: dash.OrgID}
 		if err := dr.dashAlertExtractor.ValidateAlerts(ctx, dashAlertInfo); err != nil {
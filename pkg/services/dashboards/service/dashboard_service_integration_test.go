@@ -543,6 +543,53 @@ func TestIntegrationIntegratedDashboardService(t *testing.T) {
 						assert.Equal(t, dashboards.ErrDashboardVersionMismatch, err)
 					})
 
+				permissionScenario(t, "When updating a dashboard changed by someone else since it was loaded", canSave,
+					func(t *testing.T, sc *permissionScenarioContext) {
+						loaded := sc.savedDashInFolder
+						saveChange := func(fields map[string]any) dashboards.SaveDashboardCommand {
+							data := map[string]any{
+								"uid":     loaded.UID,
+								"title":   loaded.Title,
+								"version": loaded.Version,
+							}
+							for k, v := range fields {
+								data[k] = v
+							}
+							return dashboards.SaveDashboardCommand{
+								OrgID:     1,
+								Dashboard: simplejson.NewFromAny(data),
+								FolderID:  sc.savedFolder.ID,
+								FolderUID: sc.savedFolder.UID,
+								Overwrite: shouldOverwrite,
+							}
+						}
+
+						first := callSaveWithResult(t, saveChange(map[string]any{"tags": []any{"first"}}), sc.sqlStore)
+						require.Equal(t, loaded.Version+1, first.Version)
+
+						// merging is disabled by default
+						err := callSaveWithError(t, saveChange(map[string]any{"description": "second"}), sc.sqlStore)
+						require.Equal(t, dashboards.ErrDashboardVersionMismatch, err)
+
+						// a different field is merged
+						t.Setenv("GF_DASHBOARDS_MERGE_CONCURRENT_EDITS", "true")
+						res := callSaveWithResult(t, saveChange(map[string]any{"description": "second"}), sc.sqlStore)
+						require.Equal(t, loaded.Version+2, res.Version)
+						saved, err := sc.dashboardStore.GetDashboard(context.Background(), &dashboards.GetDashboardQuery{UID: loaded.UID, OrgID: 1})
+						require.NoError(t, err)
+						require.Equal(t, []string{"first"}, saved.Data.Get("tags").MustStringArray())
+						require.Equal(t, "second", saved.Data.Get("description").MustString())
+
+						// the same field is a conflict
+						err = callSaveWithError(t, saveChange(map[string]any{"tags": []any{"third"}}), sc.sqlStore)
+						require.ErrorIs(t, err, dashboards.ErrDashboardVersionMismatch)
+						var conflictErr dashboards.DashboardMergeConflictError
+						require.ErrorAs(t, err, &conflictErr)
+						require.Equal(t, loaded.Version+2, conflictErr.CurrentVersion)
+						require.Len(t, conflictErr.Conflicts, 1)
+						require.Equal(t, "tags", conflictErr.Conflicts[0].Path)
+					})
+
 				permissionScenario(t, "When updating an existing dashboard by uid with current version", canSave,
 					func(t *testing.T, sc *permissionScenarioContext) {
 						cmd := dashboards.SaveDashboardCommand{
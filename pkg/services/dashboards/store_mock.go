@@ -9,6 +9,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	quota "github.com/grafana/grafana/pkg/services/quota"
+
+	simplejson "github.com/grafana/grafana/pkg/components/simplejson"
 )
 
 // FakeDashboardStore is an autogenerated mock type for the Store type
@@ -252,6 +254,32 @@ func (_m *FakeDashboardStore) GetDashboardUIDByID(ctx context.Context, query *Ge
 	return r0, r1
 }
 
+// GetDashboardVersionData provides a mock function with given fields: ctx, orgID, dashboardID, version
+func (_m *FakeDashboardStore) GetDashboardVersionData(ctx context.Context, orgID int64, dashboardID int64, version int) (*simplejson.Json, error) {
+	ret := _m.Called(ctx, orgID, dashboardID, version)
+
+	var r0 *simplejson.Json
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) (*simplejson.Json, error)); ok {
+		return rf(ctx, orgID, dashboardID, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) *simplejson.Json); ok {
+		r0 = rf(ctx, orgID, dashboardID, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*simplejson.Json)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int) error); ok {
+		r1 = rf(ctx, orgID, dashboardID, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetDashboards provides a mock function with given fields: ctx, query
 func (_m *FakeDashboardStore) GetDashboards(ctx context.Context, query *GetDashboardsQuery) ([]*Dashboard, error) {
 	ret := _m.Called(ctx, query)
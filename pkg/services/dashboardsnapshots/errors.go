@@ -4,4 +4,7 @@ import (
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
-var ErrBaseNotFound = errutil.NotFound("dashboardsnapshots.not-found", errutil.WithPublicMessage("Snapshot not found"))
+var (
+	ErrBaseNotFound     = errutil.NotFound("dashboardsnapshots.not-found", errutil.WithPublicMessage("Snapshot not found"))
+	ErrInvalidTimeRange = errutil.BadRequest("dashboardsnapshots.invalid-time-range", errutil.WithPublicMessage("Invalid snapshot time range"))
+)
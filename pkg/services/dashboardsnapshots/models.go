@@ -79,6 +79,52 @@ type CreateDashboardSnapshotCommand struct {
 	DashboardEncrypted []byte `json:"-"`
 }
 
+// CaptureDashboardSnapshotCommand creates a snapshot of a stored dashboard, running the
+// panel queries on the server for the time range.
+// swagger:model
+type CaptureDashboardSnapshotCommand struct {
+	// UID of the dashboard to capture.
+	// required:true
+	DashboardUID string `json:"dashboardUid" binding:"Required"`
+	// Snapshot name, defaults to the dashboard title.
+	// required:false
+	Name string `json:"name"`
+	// Start of the time range, absolute or relative such as `now-6h`. Defaults to the dashboard time range.
+	// required:false
+	From string `json:"from"`
+	// End of the time range. Defaults to the dashboard time range.
+	// required:false
+	To string `json:"to"`
+	// When the snapshot should expire in seconds. Default is never to expire.
+	// required:false
+	// default:0
+	Expires int64 `json:"expires"`
+
+	Key          string             `json:"-"`
+	DeleteKey    string             `json:"-"`
+	OrgID        int64              `json:"-"`
+	SignedInUser identity.Requester `json:"-"`
+}
+
+// CaptureDashboardSnapshotResult is the captured snapshot and the panels which failed to query.
+type CaptureDashboardSnapshotResult struct {
+	Snapshot *DashboardSnapshot
+	// PanelErrors are the query errors by panel ID.
+	PanelErrors map[int64]string
+}
+
+// DashboardSnapshotExport is the file format used to move a local snapshot between
+// Grafana instances. The dashboard includes the captured data.
+type DashboardSnapshotExport struct {
+	// APIVersion of the format, currently 1.
+	APIVersion int              `json:"apiVersion"`
+	Name       string           `json:"name"`
+	Created    time.Time        `json:"created"`
+	Dashboard  *simplejson.Json `json:"dashboard" binding:"Required"`
+}
+
+const DashboardSnapshotExportVersion = 1
+
 type DeleteDashboardSnapshotCommand struct {
 	DeleteKey string `json:"-"`
 }
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/components/templatevars"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+)
+
+const (
+	defaultCaptureFrom          = "now-6h"
+	defaultCaptureTo            = "now"
+	defaultCaptureMaxDataPoints = 1000
+)
+
+// CaptureDashboardSnapshot runs the queries of every panel of a stored dashboard as the signed
+// in user, and stores the dashboard with the resulting frames as a local snapshot. Relative
+// time ranges are resolved when capturing, so the snapshot shows the data as it was then.
+//
+// Panels whose queries fail are stored without data and reported in the result, so a single
+// broken panel doesn't prevent the snapshot.
+func (s *ServiceImpl) CaptureDashboardSnapshot(ctx context.Context, cmd *dashboardsnapshots.CaptureDashboardSnapshotCommand) (*dashboardsnapshots.CaptureDashboardSnapshotResult, error) {
+	dash, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: cmd.DashboardUID, OrgID: cmd.OrgID})
+	if err != nil {
+		return nil, err
+	}
+
+	// work on a copy, the dashboard may be cached
+	encoded, err := dash.Data.Encode()
+	if err != nil {
+		return nil, err
+	}
+	model, err := simplejson.NewJson(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	from, to := cmd.From, cmd.To
+	if from == "" {
+		from = model.GetPath("time", "from").MustString(defaultCaptureFrom)
+	}
+	if to == "" {
+		to = model.GetPath("time", "to").MustString(defaultCaptureTo)
+	}
+	timeRange := legacydata.NewDataTimeRange(from, to)
+	fromTime, err := timeRange.ParseFrom(legacydata.WithNow(now))
+	if err != nil {
+		return nil, dashboardsnapshots.ErrInvalidTimeRange.Errorf("invalid from: %w", err)
+	}
+	toTime, err := timeRange.ParseTo(legacydata.WithNow(now), legacydata.WithRoundUp())
+	if err != nil {
+		return nil, dashboardsnapshots.ErrInvalidTimeRange.Errorf("invalid to: %w", err)
+	}
+	if !fromTime.Before(toTime) {
+		return nil, dashboardsnapshots.ErrInvalidTimeRange.Errorf("from must be before to")
+	}
+
+	c := &capture{
+		s:         s,
+		cmd:       cmd,
+		from:      fromTime,
+		to:        toTime,
+		variables: templatevars.FromDashboard(model),
+		errors:    make(map[int64]string),
+	}
+	panels := model.Get("panels").MustArray()
+	c.capturePanels(ctx, panels)
+	model.Set("panels", panels)
+
+	model.Set("time", map[string]any{"from": fromTime.UTC().Format(time.RFC3339), "to": toTime.UTC().Format(time.RFC3339)})
+	model.Del("refresh")
+	freezeVariables(model)
+	// annotation queries are not captured
+	model.SetPath([]string{"annotations", "list"}, []any{})
+	model.Set("snapshot", map[string]any{
+		"timestamp":   now.UTC().Format(time.RFC3339),
+		"originalUrl": fmt.Sprintf("/d/%s", dash.UID),
+	})
+
+	name := cmd.Name
+	if name == "" {
+		name = dash.Title
+	}
+	userID, err := identity.UserIdentifier(cmd.SignedInUser.GetNamespacedID())
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.CreateDashboardSnapshot(ctx, &dashboardsnapshots.CreateDashboardSnapshotCommand{
+		Dashboard: model,
+		Name:      name,
+		Expires:   cmd.Expires,
+		Key:       cmd.Key,
+		DeleteKey: cmd.DeleteKey,
+		OrgID:     cmd.OrgID,
+		UserID:    userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dashboardsnapshots.CaptureDashboardSnapshotResult{Snapshot: snapshot, PanelErrors: c.errors}, nil
+}
+
+func (s *ServiceImpl) queryPanel(ctx context.Context, cmd *dashboardsnapshots.CaptureDashboardSnapshotCommand, req dtos.MetricRequest) (data.Frames, error) {
+	res, err := s.queryService.QueryData(ctx, cmd.SignedInUser, false, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var frames data.Frames
+	for _, q := range req.Queries {
+		refID := q.Get("refId").MustString()
+		r, ok := res.Responses[refID]
+		if !ok {
+			continue
+		}
+		if r.Error != nil {
+			return nil, fmt.Errorf("query %s: %w", refID, r.Error)
+		}
+		for _, f := range r.Frames {
+			if f.RefID == "" {
+				f.RefID = refID
+			}
+			if f.Meta != nil {
+				// the query can contain secrets which don't belong in a snapshot
+				f.Meta.ExecutedQueryString = ""
+			}
+		}
+		frames = append(frames, r.Frames...)
+	}
+	return frames, nil
+}
+
+type capture struct {
+	s    *ServiceImpl
+	cmd  *dashboardsnapshots.CaptureDashboardSnapshotCommand
+	from time.Time
+	to   time.Time
+	// variables are the template variables with their current values, interpolated in the queries
+	variables map[string]*templatevars.Variable
+	errors    map[int64]string
+}
+
+func (c *capture) capturePanels(ctx context.Context, panels []any) {
+	for _, p := range panels {
+		panel, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		if nested, ok := panel["panels"].([]any); ok {
+			c.capturePanels(ctx, nested)
+		}
+		c.capturePanel(ctx, panel)
+	}
+}
+
+// capturePanel replaces the queries of the panel with their results, the way snapshots
+// taken in the browser store them.
+func (c *capture) capturePanel(ctx context.Context, panel map[string]any) {
+	targets, _ := panel["targets"].([]any)
+	if len(targets) == 0 {
+		return
+	}
+
+	p := simplejson.NewFromAny(panel)
+	maxDataPoints := p.Get("maxDataPoints").MustInt64(defaultCaptureMaxDataPoints)
+	intervalMs := c.to.Sub(c.from).Milliseconds() / maxDataPoints
+	if intervalMs < 1 {
+		intervalMs = 1
+	}
+
+	hasExpression := false
+	for _, t := range targets {
+		if expr.NodeTypeFromDatasourceUID(simplejson.NewFromAny(t).GetPath("datasource", "uid").MustString()) == expr.TypeCMDNode {
+			hasExpression = true
+		}
+	}
+
+	queries := make([]*simplejson.Json, 0, len(targets))
+	for _, t := range targets {
+		q := simplejson.NewFromAny(t)
+		// hidden queries are only needed when expressions depend on them
+		if !hasExpression && q.Get("hide").MustBool() {
+			continue
+		}
+		if _, ok := q.CheckGet("datasource"); !ok {
+			if ds, ok := panel["datasource"]; ok && ds != nil {
+				q.Set("datasource", ds)
+			}
+		}
+		if uid := q.GetPath("datasource", "uid").MustString(); uid != "" {
+			// data source variables
+			q.SetPath([]string{"datasource", "uid"}, templatevars.InterpolateString(uid, c.variables, ""))
+		}
+		templatevars.InterpolateQuery(q, c.variables)
+		q.Set("intervalMs", intervalMs)
+		q.Set("maxDataPoints", maxDataPoints)
+		queries = append(queries, q)
+	}
+
+	snapshotData := []any{}
+	if len(queries) > 0 {
+		frames, err := c.s.queryPanel(ctx, c.cmd, dtos.MetricRequest{
+			From:    fmt.Sprintf("%d", c.from.UnixMilli()),
+			To:      fmt.Sprintf("%d", c.to.UnixMilli()),
+			Queries: queries,
+		})
+		if err != nil {
+			id := p.Get("id").MustInt64()
+			c.errors[id] = err.Error()
+			c.s.log.Warn("Failed to capture panel data for snapshot", "dashboardUID", c.cmd.DashboardUID, "panelId", id, "error", err)
+		}
+		for _, f := range frames {
+			snapshotData = append(snapshotData, frameToSnapshotData(f))
+		}
+	}
+
+	panel["snapshotData"] = snapshotData
+	panel["targets"] = []any{}
+	panel["links"] = []any{}
+	panel["datasource"] = nil
+}
+
+// freezeVariables keeps the current value of each variable as its only option, since the
+// variable queries can't run in a snapshot.
+func freezeVariables(model *simplejson.Json) {
+	for _, v := range model.GetPath("templating", "list").MustArray() {
+		variable, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if current, ok := variable["current"]; ok && current != nil {
+			variable["options"] = []any{current}
+		}
+		if variable["type"] == "query" {
+			variable["refresh"] = 0
+			variable["query"] = ""
+			delete(variable, "datasource")
+		}
+	}
+}
+
+// frameToSnapshotData converts a frame to the DataFrameDTO layout panels read their
+// snapshot data from.
+func frameToSnapshotData(frame *data.Frame) map[string]any {
+	fields := make([]any, 0, len(frame.Fields))
+	for _, f := range frame.Fields {
+		values := make([]any, f.Len())
+		for i := range values {
+			v, ok := f.ConcreteAt(i)
+			if !ok {
+				continue
+			}
+			switch tv := v.(type) {
+			case time.Time:
+				values[i] = tv.UnixMilli()
+			case json.RawMessage:
+				values[i] = tv
+			default:
+				values[i] = v
+			}
+		}
+
+		field := map[string]any{
+			"name":   f.Name,
+			"type":   snapshotFieldType(f.Type()),
+			"values": values,
+		}
+		if f.Config != nil {
+			field["config"] = f.Config
+		} else {
+			field["config"] = map[string]any{}
+		}
+		if len(f.Labels) > 0 {
+			field["labels"] = f.Labels
+		}
+		fields = append(fields, field)
+	}
+
+	dto := map[string]any{
+		"name":   frame.Name,
+		"refId":  frame.RefID,
+		"fields": fields,
+	}
+	if frame.Meta != nil {
+		dto["meta"] = frame.Meta
+	}
+	return dto
+}
+
+func snapshotFieldType(t data.FieldType) string {
+	switch {
+	case t.Time():
+		return "time"
+	case t.Numeric():
+		return "number"
+	case t.JSON():
+		return "other"
+	}
+	switch t.NonNullableType() {
+	case data.FieldTypeString:
+		return "string"
+	case data.FieldTypeBool:
+		return "boolean"
+	case data.FieldTypeEnum:
+		return "enum"
+	}
+	return "other"
+}
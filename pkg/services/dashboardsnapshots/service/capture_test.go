@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
+	dashsnapdb "github.com/grafana/grafana/pkg/services/dashboardsnapshots/database"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestCaptureDashboardSnapshot(t *testing.T) {
+	sqlStore := db.InitTestDB(t)
+	dsStore := dashsnapdb.ProvideStore(sqlStore, setting.NewCfg())
+	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	queryService := &query.FakeQueryService{}
+	s := ProvideService(dsStore, secretsService, dashboardService, queryService)
+
+	dashData, err := simplejson.NewJson([]byte(`{
+		"uid": "prod",
+		"title": "Production",
+		"time": {"from": "now-1h", "to": "now"},
+		"refresh": "10s",
+		"templating": {"list": [
+			{"name": "host", "type": "query", "query": "hosts()", "datasource": {"uid": "prom"},
+				"current": {"text": "a", "value": "a"}, "options": []}
+		]},
+		"annotations": {"list": [{"name": "deploys", "datasource": {"uid": "loki"}}]},
+		"panels": [
+			{"id": 1, "type": "timeseries", "datasource": {"uid": "prom", "type": "prometheus"},
+				"targets": [{"refId": "A", "expr": "cpu{host=\"$host\"}"}, {"refId": "B", "expr": "hidden", "hide": true}]},
+			{"id": 2, "type": "row", "collapsed": true, "panels": [
+				{"id": 3, "type": "stat", "targets": [{"refId": "A", "datasource": {"uid": "broken"}}]}
+			]},
+			{"id": 4, "type": "text", "options": {"content": "notes"}}
+		]
+	}`))
+	require.NoError(t, err)
+	dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).
+		Return(&dashboards.Dashboard{UID: "prod", OrgID: 1, Title: "Production", Data: dashData}, nil)
+
+	ts := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	var requests []dtos.MetricRequest
+	queryService.On("QueryData", mock.Anything, mock.Anything, false, mock.Anything).Return(
+		func(_ context.Context, _ identity.Requester, _ bool, req dtos.MetricRequest) *backend.QueryDataResponse {
+			requests = append(requests, req)
+			if req.Queries[0].GetPath("datasource", "uid").MustString() == "broken" {
+				return nil
+			}
+			frame := data.NewFrame("cpu",
+				data.NewField("time", nil, []time.Time{ts}),
+				data.NewField("value", data.Labels{"host": "a"}, []*float64{nil}),
+			)
+			frame.Meta = &data.FrameMeta{ExecutedQueryString: "secret"}
+			return &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{frame}}}}
+		},
+		func(_ context.Context, _ identity.Requester, _ bool, req dtos.MetricRequest) error {
+			if req.Queries[0].GetPath("datasource", "uid").MustString() == "broken" {
+				return errors.New("data source unavailable")
+			}
+			return nil
+		},
+	)
+
+	cmd := &dashboardsnapshots.CaptureDashboardSnapshotCommand{
+		DashboardUID: "prod",
+		From:         "2026-10-18T09:00:00Z",
+		To:           "2026-10-18T11:00:00Z",
+		Key:          "capture-key",
+		DeleteKey:    "capture-delete-key",
+		OrgID:        1,
+		SignedInUser: &user.SignedInUser{UserID: 2, OrgID: 1},
+	}
+	result, err := s.CaptureDashboardSnapshot(context.Background(), cmd)
+	require.NoError(t, err)
+	require.Equal(t, map[int64]string{3: "data source unavailable"}, result.PanelErrors)
+	require.Equal(t, "Production", result.Snapshot.Name)
+	require.Equal(t, int64(2), result.Snapshot.UserID)
+
+	require.Len(t, requests, 2)
+	require.Len(t, requests[0].Queries, 1, "hidden queries are skipped")
+	require.Equal(t, "prom", requests[0].Queries[0].GetPath("datasource", "uid").MustString(), "queries default to the panel data source")
+	require.Equal(t, strconv.FormatInt(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC).UnixMilli(), 10), requests[0].From)
+	require.Equal(t, int64(7200), requests[0].Queries[0].Get("intervalMs").MustInt64())
+	require.Equal(t, `cpu{host="a"}`, requests[0].Queries[0].Get("expr").MustString(), "variables are interpolated with their current value")
+
+	stored, err := s.GetDashboardSnapshot(context.Background(), &dashboardsnapshots.GetDashboardSnapshotQuery{Key: "capture-key"})
+	require.NoError(t, err)
+	snap := stored.Dashboard
+
+	require.Equal(t, "2026-10-18T09:00:00Z", snap.GetPath("time", "from").MustString())
+	require.Equal(t, "/d/prod", snap.GetPath("snapshot", "originalUrl").MustString())
+	_, hasRefresh := snap.CheckGet("refresh")
+	require.False(t, hasRefresh)
+	require.Empty(t, snap.GetPath("annotations", "list").MustArray())
+
+	variable := snap.GetPath("templating", "list").GetIndex(0)
+	require.Equal(t, "", variable.Get("query").MustString())
+	require.Len(t, variable.Get("options").MustArray(), 1)
+
+	panel := snap.Get("panels").GetIndex(0)
+	require.Empty(t, panel.Get("targets").MustArray())
+	frame := panel.Get("snapshotData").GetIndex(0)
+	require.Equal(t, "A", frame.Get("refId").MustString())
+	require.Equal(t, "", frame.GetPath("meta", "executedQueryString").MustString())
+	timeField := frame.Get("fields").GetIndex(0)
+	require.Equal(t, "time", timeField.Get("type").MustString())
+	require.Equal(t, ts.UnixMilli(), timeField.Get("values").GetIndex(0).MustInt64())
+	valueField := frame.Get("fields").GetIndex(1)
+	require.Equal(t, "number", valueField.Get("type").MustString())
+	require.Nil(t, valueField.Get("values").GetIndex(0).Interface())
+	require.Equal(t, "a", valueField.GetPath("labels", "host").MustString())
+
+	failed := snap.Get("panels").GetIndex(1).Get("panels").GetIndex(0)
+	require.Empty(t, failed.Get("snapshotData").MustArray())
+	require.Empty(t, failed.Get("targets").MustArray())
+
+	text := snap.Get("panels").GetIndex(2)
+	_, hasData := text.CheckGet("snapshotData")
+	require.False(t, hasData, "panels without queries are kept as they are")
+
+	t.Run("invalid time range", func(t *testing.T) {
+		_, err := s.CaptureDashboardSnapshot(context.Background(), &dashboardsnapshots.CaptureDashboardSnapshotCommand{
+			DashboardUID: "prod",
+			From:         "now",
+			To:           "now-1h",
+			OrgID:        1,
+			SignedInUser: &user.SignedInUser{UserID: 2, OrgID: 1},
+		})
+		require.ErrorIs(t, err, dashboardsnapshots.ErrInvalidTimeRange)
+	})
+}
@@ -0,0 +1,21 @@
+package dashboardusage
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+)
+
+// Service records how dashboards are used and answers aggregated usage queries.
+type Service interface {
+	// RecordView records that a dashboard was opened.
+	RecordView(ctx context.Context, orgID int64, dashboardUID string)
+	// RecordQueries records the queries executed on behalf of a dashboard. The queries are
+	// only recorded when the dashboard exists and the user can read it.
+	RecordQueries(ctx context.Context, user identity.Requester, dashboardUID string, stats QueryStats)
+	// Search returns the usage of dashboards in an organization.
+	Search(ctx context.Context, query *SearchDashboardUsageQuery) ([]*DashboardUsageDTO, error)
+	// GetByDashboardUIDs returns the usage of the given dashboards, or of every dashboard in the
+	// organization when no UID is given. The result is keyed by dashboard UID.
+	GetByDashboardUIDs(ctx context.Context, orgID int64, uids ...string) (map[string]*DashboardUsage, error)
+}
@@ -0,0 +1,76 @@
+package dashboardusageimpl
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+)
+
+const (
+	defaultUsageLimit = 10
+	maxUsageLimit     = 100
+	defaultStaleDays  = 90
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/dashboards/usage", func(usage routing.RouteRegister) {
+		usage.Get("/most-viewed", middleware.ReqSignedIn, routing.Wrap(s.mostViewedHandler))
+		usage.Get("/stale", middleware.ReqSignedIn, routing.Wrap(s.staleHandler))
+		usage.Get("/most-expensive", middleware.ReqSignedIn, routing.Wrap(s.mostExpensiveHandler))
+	})
+}
+
+// GET /api/dashboards/usage/most-viewed
+func (s *Service) mostViewedHandler(c *contextmodel.ReqContext) response.Response {
+	return s.search(c, &dashboardusage.SearchDashboardUsageQuery{
+		OrgID: c.SignedInUser.OrgID,
+		Sort:  dashboardusage.SortByViews,
+	})
+}
+
+// GET /api/dashboards/usage/stale
+func (s *Service) staleHandler(c *contextmodel.ReqContext) response.Response {
+	days := c.QueryInt("days")
+	if days <= 0 {
+		days = defaultStaleDays
+	}
+	since := s.now().AddDate(0, 0, -days)
+	return s.search(c, &dashboardusage.SearchDashboardUsageQuery{
+		OrgID:          c.SignedInUser.OrgID,
+		Sort:           dashboardusage.SortByLastViewed,
+		NotViewedSince: &since,
+	})
+}
+
+// GET /api/dashboards/usage/most-expensive
+func (s *Service) mostExpensiveHandler(c *contextmodel.ReqContext) response.Response {
+	return s.search(c, &dashboardusage.SearchDashboardUsageQuery{
+		OrgID: c.SignedInUser.OrgID,
+		Sort:  dashboardusage.SortByQueryDuration,
+	})
+}
+
+// search returns up to `limit` dashboards the user is allowed to read.
+func (s *Service) search(c *contextmodel.ReqContext, query *dashboardusage.SearchDashboardUsageQuery) response.Response {
+	limit := c.QueryInt("limit")
+	if limit <= 0 {
+		limit = defaultUsageLimit
+	}
+	if limit > maxUsageLimit {
+		limit = maxUsageLimit
+	}
+
+	query.SignedInUser = c.SignedInUser
+	query.Limit = limit
+	query.Page = 1
+	result, err := s.Search(c.Req.Context(), query)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get dashboard usage", err)
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
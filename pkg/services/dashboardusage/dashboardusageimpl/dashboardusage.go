@@ -0,0 +1,264 @@
+package dashboardusageimpl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/searchV2"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// maxPendingDashboards is the number of dashboards whose usage is collected in memory between
+// two flushes. The usage of other dashboards is dropped until the next flush.
+const maxPendingDashboards = 10000
+
+type usageKey struct {
+	orgID        int64
+	dashboardUID string
+}
+
+type readableKey struct {
+	user         string
+	orgID        int64
+	dashboardUID string
+}
+
+// Service collects dashboard usage in memory and periodically adds it to the
+// aggregated dashboard_usage table, so recording usage never waits on the database.
+type Service struct {
+	store         store
+	cfg           *setting.Cfg
+	log           log.Logger
+	accessControl accesscontrol.AccessControl
+
+	enabled       bool
+	flushInterval time.Duration
+	now           func() time.Time
+
+	mu      sync.Mutex
+	pending map[usageKey]*usageDelta
+	// readable caches, until the next flush, whether the dashboards the queries are
+	// recorded for exist and can be read by the user.
+	readable map[readableKey]bool
+}
+
+var _ dashboardusage.Service = (*Service)(nil)
+
+func ProvideService(cfg *setting.Cfg, db db.DB, routeRegister routing.RouteRegister, searchService searchV2.SearchService,
+	features featuremgmt.FeatureToggles, accessControl accesscontrol.AccessControl) *Service {
+	section := cfg.SectionWithEnvOverrides("dashboard_usage")
+	s := &Service{
+		store:         &sqlStore{db: db, features: features},
+		cfg:           cfg,
+		log:           log.New("dashboard-usage"),
+		accessControl: accessControl,
+		enabled:       section.Key("enabled").MustBool(false),
+		flushInterval: section.Key("flush_interval").MustDuration(time.Minute),
+		now:           time.Now,
+		pending:       map[usageKey]*usageDelta{},
+		readable:      map[readableKey]bool{},
+	}
+
+	if s.enabled {
+		s.registerAPIEndpoints(routeRegister)
+		searchService.RegisterDashboardIndexExtender(&usageIndexExtender{service: s, log: s.log})
+	}
+
+	return s
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.enabled
+}
+
+// Run periodically flushes the usage collected in memory.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.flush(ctx); err != nil {
+				s.log.Error("Failed to store dashboard usage", "error", err)
+			}
+		case <-ctx.Done():
+			// Try to store what has been collected before shutting down.
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := s.flush(flushCtx); err != nil {
+				s.log.Error("Failed to store dashboard usage", "error", err)
+			}
+			cancel()
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) RecordView(_ context.Context, orgID int64, dashboardUID string) {
+	if !s.enabled || dashboardUID == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.getPending(orgID, dashboardUID)
+	if d == nil {
+		return
+	}
+	d.views++
+	d.lastViewed = s.now()
+}
+
+func (s *Service) RecordQueries(ctx context.Context, user identity.Requester, dashboardUID string, stats dashboardusage.QueryStats) {
+	if !s.enabled || dashboardUID == "" || user == nil {
+		return
+	}
+	// The dashboard is sent by the client, so it is checked before its usage is updated
+	if !s.canRead(ctx, user, dashboardUID) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.getPending(user.GetOrgID(), dashboardUID)
+	if d == nil {
+		return
+	}
+	d.queries += stats.Queries
+	d.queryErrors += stats.Errors
+	d.queryDurationMs += stats.Duration.Milliseconds()
+	d.lastQueried = s.now()
+}
+
+// canRead returns whether the dashboard exists and the user can read it.
+func (s *Service) canRead(ctx context.Context, user identity.Requester, dashboardUID string) bool {
+	namespace, id := user.GetNamespacedID()
+	key := readableKey{user: namespace + ":" + id, orgID: user.GetOrgID(), dashboardUID: dashboardUID}
+	s.mu.Lock()
+	readable, ok := s.readable[key]
+	s.mu.Unlock()
+	if ok {
+		return readable
+	}
+
+	readable, err := s.accessControl.Evaluate(ctx, user, accesscontrol.EvalPermission(dashboards.ActionDashboardsRead,
+		dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dashboardUID)))
+	if err == nil && readable {
+		readable, err = s.store.DashboardExists(ctx, user.GetOrgID(), dashboardUID)
+	}
+	if err != nil {
+		s.log.Warn("Failed to check the dashboard of the queries", "dashboardUid", dashboardUID, "error", err)
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.readable) < maxPendingDashboards {
+		s.readable[key] = readable
+	}
+	return readable
+}
+
+// getPending must be called with the lock held. It returns nil when the usage of too many
+// dashboards is pending.
+func (s *Service) getPending(orgID int64, dashboardUID string) *usageDelta {
+	key := usageKey{orgID: orgID, dashboardUID: dashboardUID}
+	d, ok := s.pending[key]
+	if !ok {
+		if len(s.pending) >= maxPendingDashboards {
+			return nil
+		}
+		d = &usageDelta{orgID: orgID, dashboardUID: dashboardUID}
+		s.pending[key] = d
+	}
+	return d
+}
+
+func (s *Service) flush(ctx context.Context) error {
+	s.mu.Lock()
+	s.readable = map[readableKey]bool{}
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	pending := s.pending
+	s.pending = map[usageKey]*usageDelta{}
+	s.mu.Unlock()
+
+	deltas := make([]*usageDelta, 0, len(pending))
+	for _, d := range pending {
+		deltas = append(deltas, d)
+	}
+
+	if err := s.store.Add(ctx, deltas); err != nil {
+		// Keep the usage around for the next attempt.
+		s.mu.Lock()
+		for _, d := range deltas {
+			p := s.getPending(d.orgID, d.dashboardUID)
+			if p == nil {
+				break
+			}
+			p.views += d.views
+			p.queries += d.queries
+			p.queryErrors += d.queryErrors
+			p.queryDurationMs += d.queryDurationMs
+			if d.lastViewed.After(p.lastViewed) {
+				p.lastViewed = d.lastViewed
+			}
+			if d.lastQueried.After(p.lastQueried) {
+				p.lastQueried = d.lastQueried
+			}
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (s *Service) Search(ctx context.Context, query *dashboardusage.SearchDashboardUsageQuery) ([]*dashboardusage.DashboardUsageDTO, error) {
+	hits, err := s.store.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dashboardusage.DashboardUsageDTO, 0, len(hits))
+	for _, h := range hits {
+		dto := &dashboardusage.DashboardUsageDTO{
+			DashboardUID:    h.DashboardUID,
+			Title:           h.Title,
+			URL:             dashboards.GetDashboardURL(h.DashboardUID, h.Slug),
+			Views:           h.Views,
+			Queries:         h.Queries,
+			QueryErrors:     h.QueryErrors,
+			QueryDurationMs: h.QueryDurationMs,
+			LastViewed:      h.LastViewed,
+			LastQueried:     h.LastQueried,
+		}
+		if h.Queries > 0 {
+			dto.AvgQueryDurationMs = float64(h.QueryDurationMs) / float64(h.Queries)
+		}
+		result = append(result, dto)
+	}
+	return result, nil
+}
+
+func (s *Service) GetByDashboardUIDs(ctx context.Context, orgID int64, uids ...string) (map[string]*dashboardusage.DashboardUsage, error) {
+	usage, err := s.store.GetByDashboardUIDs(ctx, orgID, uids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*dashboardusage.DashboardUsage, len(usage))
+	for _, u := range usage {
+		result[u.DashboardUID] = u
+	}
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package dashboardusageimpl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationDashboardUsage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	testDB := db.InitTestDB(t)
+	for _, uid := range []string{"a", "b", "c"} {
+		err := testDB.WithDbSession(ctx, func(sess *db.Session) error {
+			dash := dashboards.NewDashboard("Dashboard " + uid)
+			dash.UID = uid
+			dash.OrgID = 1
+			dash.Slug = "dashboard-" + uid
+			_, err := sess.Insert(dash)
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := &Service{
+		store:         &sqlStore{db: testDB, features: featuremgmt.WithFeatures()},
+		log:           log.NewNopLogger(),
+		accessControl: acimpl.ProvideAccessControl(setting.NewCfg()),
+		enabled:       true,
+		now:           func() time.Time { return now },
+		pending:       map[usageKey]*usageDelta{},
+		readable:      map[readableKey]bool{},
+	}
+	viewer := &user.SignedInUser{UserID: 1, OrgID: 1, Permissions: map[int64]map[string][]string{
+		1: {dashboards.ActionDashboardsRead: {dashboards.ScopeDashboardsAll}},
+	}}
+
+	s.RecordView(ctx, 1, "a")
+	s.RecordView(ctx, 1, "a")
+	s.RecordView(ctx, 1, "b")
+	s.RecordQueries(ctx, viewer, "b", dashboardusage.QueryStats{Queries: 4, Errors: 1, Duration: 2 * time.Second})
+	require.NoError(t, s.flush(ctx))
+
+	// A second flush adds to the stored usage.
+	now = now.Add(time.Hour)
+	s.RecordView(ctx, 1, "a")
+	s.RecordQueries(ctx, viewer, "a", dashboardusage.QueryStats{Queries: 2, Duration: 100 * time.Millisecond})
+	require.NoError(t, s.flush(ctx))
+	require.Empty(t, s.pending)
+
+	uids := func(result []*dashboardusage.DashboardUsageDTO) []string {
+		uids := make([]string, 0, len(result))
+		for _, r := range result {
+			uids = append(uids, r.DashboardUID)
+		}
+		return uids
+	}
+
+	t.Run("stored usage", func(t *testing.T) {
+		usage, err := s.GetByDashboardUIDs(ctx, 1, "a", "b")
+		require.NoError(t, err)
+		require.Equal(t, int64(3), usage["a"].Views)
+		require.Equal(t, int64(2), usage["a"].Queries)
+		require.Equal(t, int64(1), usage["b"].Views)
+		require.Equal(t, int64(1), usage["b"].QueryErrors)
+		require.Equal(t, float64(500), usage["b"].AvgQueryDurationMs())
+	})
+
+	t.Run("most viewed", func(t *testing.T) {
+		result, err := s.Search(ctx, &dashboardusage.SearchDashboardUsageQuery{OrgID: 1, Sort: dashboardusage.SortByViews})
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, uids(result))
+		require.Equal(t, "/d/a/dashboard-a", result[0].URL)
+	})
+
+	t.Run("most expensive", func(t *testing.T) {
+		result, err := s.Search(ctx, &dashboardusage.SearchDashboardUsageQuery{OrgID: 1, Sort: dashboardusage.SortByQueryDuration})
+		require.NoError(t, err)
+		require.Equal(t, []string{"b", "a"}, uids(result))
+		require.Equal(t, float64(50), result[1].AvgQueryDurationMs)
+	})
+
+	t.Run("not viewed since", func(t *testing.T) {
+		since := now.Add(-30 * time.Minute)
+		result, err := s.Search(ctx, &dashboardusage.SearchDashboardUsageQuery{OrgID: 1, Sort: dashboardusage.SortByLastViewed, NotViewedSince: &since})
+		require.NoError(t, err)
+		require.Equal(t, []string{"c", "b"}, uids(result))
+	})
+
+	t.Run("only dashboards the user can read", func(t *testing.T) {
+		reader := &user.SignedInUser{OrgID: 1, AuthenticatedBy: login.ExtendedJWTModule, Permissions: map[int64]map[string][]string{
+			1: {dashboards.ActionDashboardsRead: {dashboards.ScopeDashboardsProvider.GetResourceScopeUID("b")}},
+		}}
+		result, err := s.Search(ctx, &dashboardusage.SearchDashboardUsageQuery{OrgID: 1, Sort: dashboardusage.SortByViews, SignedInUser: reader})
+		require.NoError(t, err)
+		require.Equal(t, []string{"b"}, uids(result))
+
+		result, err = s.Search(ctx, &dashboardusage.SearchDashboardUsageQuery{OrgID: 1, Sort: dashboardusage.SortByViews, SignedInUser: &user.SignedInUser{OrgID: 1}})
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("only records the queries of existing dashboards the user can read", func(t *testing.T) {
+		reader := &user.SignedInUser{UserID: 2, OrgID: 1, Permissions: map[int64]map[string][]string{
+			1: {dashboards.ActionDashboardsRead: {dashboards.ScopeDashboardsProvider.GetResourceScopeUID("b")}},
+		}}
+		stats := dashboardusage.QueryStats{Queries: 1}
+		s.RecordQueries(ctx, reader, "b", stats)
+		s.RecordQueries(ctx, reader, "c", stats)
+		s.RecordQueries(ctx, viewer, "missing", stats)
+		require.Len(t, s.pending, 1)
+		require.Equal(t, int64(1), s.pending[usageKey{orgID: 1, dashboardUID: "b"}].queries)
+		require.Len(t, s.readable, 3, "the checks are cached until the next flush")
+
+		require.NoError(t, s.flush(ctx))
+		require.Empty(t, s.readable)
+		usage, err := s.GetByDashboardUIDs(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, usage, 2, "no usage is stored for unknown dashboards")
+	})
+
+	t.Run("limits the number of dashboards collected in memory", func(t *testing.T) {
+		for i := 0; i < maxPendingDashboards+10; i++ {
+			s.RecordView(ctx, 2, fmt.Sprintf("dashboard-%d", i))
+		}
+		require.Len(t, s.pending, maxPendingDashboards)
+		s.pending = map[usageKey]*usageDelta{}
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		_, err := s.Search(ctx, &dashboardusage.SearchDashboardUsageQuery{OrgID: 1, Sort: "title"})
+		require.ErrorIs(t, err, dashboardusage.ErrInvalidSort)
+	})
+}
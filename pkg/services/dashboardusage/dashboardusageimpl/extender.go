@@ -0,0 +1,96 @@
+package dashboardusageimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+	"github.com/grafana/grafana/pkg/services/searchV2"
+)
+
+// Search index fields with the dashboard usage. The numeric fields are sortable, e.g. `sort=-views_total`.
+const (
+	documentFieldViewsTotal      = "views_total"
+	documentFieldQueriesTotal    = "queries_total"
+	documentFieldQueryDurationMs = "query_duration_ms"
+	documentFieldLastViewed      = "last_viewed"
+)
+
+// usageIndexExtender adds the dashboard usage to the search index.
+type usageIndexExtender struct {
+	service *Service
+	log     log.Logger
+}
+
+var _ searchV2.DashboardIndexExtender = (*usageIndexExtender)(nil)
+
+func (e *usageIndexExtender) GetDocumentExtender() searchV2.DocumentExtender {
+	return e
+}
+
+func (e *usageIndexExtender) GetQueryExtender(_ searchV2.DashboardQuery) searchV2.QueryExtender {
+	return e
+}
+
+func (e *usageIndexExtender) GetDashboardExtender(orgID int64, uids ...string) searchV2.ExtendDashboardFunc {
+	usage, err := e.service.GetByDashboardUIDs(context.Background(), orgID, uids...)
+	if err != nil {
+		e.log.Warn("Failed to load dashboard usage for the search index", "orgId", orgID, "error", err)
+		usage = map[string]*dashboardusage.DashboardUsage{}
+	}
+
+	return func(uid string, doc *bluge.Document) error {
+		u, ok := usage[uid]
+		if !ok {
+			u = &dashboardusage.DashboardUsage{}
+		}
+		doc.AddField(bluge.NewNumericField(documentFieldViewsTotal, float64(u.Views)).StoreValue().Sortable())
+		doc.AddField(bluge.NewNumericField(documentFieldQueriesTotal, float64(u.Queries)).StoreValue().Sortable())
+		doc.AddField(bluge.NewNumericField(documentFieldQueryDurationMs, float64(u.QueryDurationMs)).StoreValue().Sortable())
+		if u.LastViewed != nil {
+			doc.AddField(bluge.NewDateTimeField(documentFieldLastViewed, *u.LastViewed).StoreValue().Sortable())
+		}
+		return nil
+	}
+}
+
+func (e *usageIndexExtender) GetFramer(frame *data.Frame) searchV2.FramerFunc {
+	fViews := data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, 0)
+	fViews.Name = documentFieldViewsTotal
+	fQueries := data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, 0)
+	fQueries.Name = documentFieldQueriesTotal
+	fDuration := data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, 0)
+	fDuration.Name = documentFieldQueryDurationMs
+	fLastViewed := data.NewFieldFromFieldType(data.FieldTypeNullableTime, 0)
+	fLastViewed.Name = documentFieldLastViewed
+
+	frame.Fields = append(frame.Fields, fViews, fQueries, fDuration, fLastViewed)
+
+	// Values are appended while the row is being read; documents without usage fields,
+	// e.g. panels, are padded with nulls once the row is complete.
+	appendNumber := func(f *data.Field, value []byte) {
+		if num, err := bluge.DecodeNumericFloat64(value); err == nil {
+			f.Append(&num)
+		}
+	}
+
+	return func(field string, value []byte) {
+		switch field {
+		case documentFieldViewsTotal:
+			appendNumber(fViews, value)
+		case documentFieldQueriesTotal:
+			appendNumber(fQueries, value)
+		case documentFieldQueryDurationMs:
+			appendNumber(fDuration, value)
+		case documentFieldLastViewed:
+			if t, err := bluge.DecodeDateTime(value); err == nil {
+				t = t.In(time.UTC)
+				fLastViewed.Append(&t)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package dashboardusageimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+)
+
+type store interface {
+	Add(ctx context.Context, deltas []*usageDelta) error
+	Search(ctx context.Context, query *dashboardusage.SearchDashboardUsageQuery) ([]*dashboardUsageHit, error)
+	GetByDashboardUIDs(ctx context.Context, orgID int64, uids []string) ([]*dashboardusage.DashboardUsage, error)
+	DashboardExists(ctx context.Context, orgID int64, uid string) (bool, error)
+}
+
+// usageDelta is the usage of a dashboard collected in memory since the last flush.
+type usageDelta struct {
+	orgID           int64
+	dashboardUID    string
+	views           int64
+	queries         int64
+	queryErrors     int64
+	queryDurationMs int64
+	lastViewed      time.Time
+	lastQueried     time.Time
+}
+
+type dashboardUsageHit struct {
+	DashboardUID    string     `xorm:"dashboard_uid"`
+	Title           string     `xorm:"title"`
+	Slug            string     `xorm:"slug"`
+	Views           int64      `xorm:"views"`
+	Queries         int64      `xorm:"queries"`
+	QueryErrors     int64      `xorm:"query_errors"`
+	QueryDurationMs int64      `xorm:"query_duration_ms"`
+	LastViewed      *time.Time `xorm:"last_viewed"`
+	LastQueried     *time.Time `xorm:"last_queried"`
+}
@@ -0,0 +1,174 @@
+package dashboardusageimpl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+)
+
+type sqlStore struct {
+	db       db.DB
+	features featuremgmt.FeatureToggles
+}
+
+func (s *sqlStore) Add(ctx context.Context, deltas []*usageDelta) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		now := time.Now()
+		for _, d := range deltas {
+			sets := []string{
+				"views = views + ?",
+				"queries = queries + ?",
+				"query_errors = query_errors + ?",
+				"query_duration_ms = query_duration_ms + ?",
+				"updated = ?",
+			}
+			args := []any{d.views, d.queries, d.queryErrors, d.queryDurationMs, now}
+			if !d.lastViewed.IsZero() {
+				sets = append(sets, "last_viewed = ?")
+				args = append(args, d.lastViewed)
+			}
+			if !d.lastQueried.IsZero() {
+				sets = append(sets, "last_queried = ?")
+				args = append(args, d.lastQueried)
+			}
+			args = append(args, d.orgID, d.dashboardUID)
+
+			exists, err := sess.Table("dashboard_usage").Where("org_id = ? AND dashboard_uid = ?", d.orgID, d.dashboardUID).Exist()
+			if err != nil {
+				return err
+			}
+			if exists {
+				rawSQL := "UPDATE dashboard_usage SET " + strings.Join(sets, ", ") + " WHERE org_id = ? AND dashboard_uid = ?"
+				if _, err := sess.Exec(append([]any{rawSQL}, args...)...); err != nil {
+					return err
+				}
+				continue
+			}
+
+			usage := &dashboardusage.DashboardUsage{
+				OrgID:           d.orgID,
+				DashboardUID:    d.dashboardUID,
+				Views:           d.views,
+				Queries:         d.queries,
+				QueryErrors:     d.queryErrors,
+				QueryDurationMs: d.queryDurationMs,
+				Updated:         now,
+			}
+			if !d.lastViewed.IsZero() {
+				usage.LastViewed = &d.lastViewed
+			}
+			if !d.lastQueried.IsZero() {
+				usage.LastQueried = &d.lastQueried
+			}
+			if _, err := sess.Table("dashboard_usage").Insert(usage); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) Search(ctx context.Context, query *dashboardusage.SearchDashboardUsageQuery) ([]*dashboardUsageHit, error) {
+	hits := make([]*dashboardUsageHit, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var sql bytes.Buffer
+		params := []any{query.OrgID, false}
+
+		sql.WriteString(`SELECT
+			d.uid AS dashboard_uid,
+			d.title,
+			d.slug,
+			COALESCE(u.views, 0) AS views,
+			COALESCE(u.queries, 0) AS queries,
+			COALESCE(u.query_errors, 0) AS query_errors,
+			COALESCE(u.query_duration_ms, 0) AS query_duration_ms,
+			u.last_viewed,
+			u.last_queried
+		FROM dashboard AS d
+		LEFT OUTER JOIN dashboard_usage AS u ON u.org_id = d.org_id AND u.dashboard_uid = d.uid
+		WHERE d.org_id = ? AND d.is_folder = ?`)
+
+		if query.SignedInUser != nil {
+			recursiveQueriesAreSupported, err := s.db.RecursiveQueriesAreSupported()
+			if err != nil {
+				return err
+			}
+			filterRBAC := permissions.NewAccessControlDashboardPermissionFilter(query.SignedInUser, dashboards.PERMISSION_VIEW, searchstore.TypeDashboard, s.features, recursiveQueriesAreSupported)
+			filter, filterParams := filterRBAC.Where()
+			from := "dashboard"
+			if leftJoin := filterRBAC.LeftJoin(); leftJoin != "" {
+				from = "dashboard LEFT OUTER JOIN " + leftJoin
+			}
+			sql.WriteString(fmt.Sprintf(" AND d.id IN (SELECT dashboard.id FROM %s WHERE %s)", from, filter))
+			params = append(params, filterParams...)
+			if recQueries, recParams := filterRBAC.With(); recQueries != "" {
+				var withSQL bytes.Buffer
+				withSQL.WriteString(recQueries)
+				withSQL.WriteString(sql.String())
+				sql = withSQL
+				params = append(recParams, params...)
+			}
+		}
+
+		if query.NotViewedSince != nil {
+			sql.WriteString(" AND (u.last_viewed IS NULL OR u.last_viewed < ?)")
+			params = append(params, *query.NotViewedSince)
+		}
+
+		switch query.Sort {
+		case dashboardusage.SortByViews:
+			sql.WriteString(" AND u.views > 0 ORDER BY u.views DESC, d.title ASC")
+		case dashboardusage.SortByQueryDuration:
+			sql.WriteString(" AND u.query_duration_ms > 0 ORDER BY u.query_duration_ms DESC, d.title ASC")
+		case dashboardusage.SortByLastViewed:
+			// Dashboards never viewed come first
+			sql.WriteString(" ORDER BY CASE WHEN u.last_viewed IS NULL THEN 0 ELSE 1 END, u.last_viewed ASC, d.title ASC")
+		default:
+			return dashboardusage.ErrInvalidSort
+		}
+
+		limit := query.Limit
+		if limit <= 0 {
+			limit = 10
+		}
+		page := query.Page
+		if page <= 0 {
+			page = 1
+		}
+		sql.WriteString(s.db.GetDialect().LimitOffset(int64(limit), int64((page-1)*limit)))
+
+		return sess.SQL(sql.String(), params...).Find(&hits)
+	})
+	return hits, err
+}
+
+func (s *sqlStore) GetByDashboardUIDs(ctx context.Context, orgID int64, uids []string) ([]*dashboardusage.DashboardUsage, error) {
+	usage := make([]*dashboardusage.DashboardUsage, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		sess.Table("dashboard_usage").Where("org_id = ?", orgID)
+		if len(uids) > 0 {
+			sess.In("dashboard_uid", uids)
+		}
+		return sess.Find(&usage)
+	})
+	return usage, err
+}
+
+func (s *sqlStore) DashboardExists(ctx context.Context, orgID int64, uid string) (bool, error) {
+	var exists bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		exists, err = sess.Table("dashboard").Where("org_id = ? AND uid = ? AND is_folder = ?", orgID, uid, false).Exist()
+		return err
+	})
+	return exists, err
+}
@@ -0,0 +1,44 @@
+package dashboardusagetest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
+)
+
+type FakeService struct {
+	ExpectedUsage     []*dashboardusage.DashboardUsageDTO
+	ExpectedUsageByID map[string]*dashboardusage.DashboardUsage
+	ExpectedError     error
+
+	Views   map[string]int64
+	Queries map[string]dashboardusage.QueryStats
+}
+
+func NewFakeService() *FakeService {
+	return &FakeService{
+		Views:   map[string]int64{},
+		Queries: map[string]dashboardusage.QueryStats{},
+	}
+}
+
+func (f *FakeService) RecordView(_ context.Context, _ int64, dashboardUID string) {
+	f.Views[dashboardUID]++
+}
+
+func (f *FakeService) RecordQueries(_ context.Context, _ identity.Requester, dashboardUID string, stats dashboardusage.QueryStats) {
+	s := f.Queries[dashboardUID]
+	s.Queries += stats.Queries
+	s.Errors += stats.Errors
+	s.Duration += stats.Duration
+	f.Queries[dashboardUID] = s
+}
+
+func (f *FakeService) Search(_ context.Context, _ *dashboardusage.SearchDashboardUsageQuery) ([]*dashboardusage.DashboardUsageDTO, error) {
+	return f.ExpectedUsage, f.ExpectedError
+}
+
+func (f *FakeService) GetByDashboardUIDs(_ context.Context, _ int64, _ ...string) (map[string]*dashboardusage.DashboardUsage, error) {
+	return f.ExpectedUsageByID, f.ExpectedError
+}
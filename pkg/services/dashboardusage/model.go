@@ -0,0 +1,73 @@
+package dashboardusage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+)
+
+var ErrInvalidSort = errors.New("invalid dashboard usage sort")
+
+// Sort options for SearchDashboardUsageQuery
+const (
+	SortByViews         = "views"
+	SortByQueryDuration = "query_duration"
+	SortByLastViewed    = "last_viewed"
+)
+
+// DashboardUsage is the usage aggregated over the lifetime of a dashboard.
+type DashboardUsage struct {
+	ID              int64      `xorm:"pk autoincr 'id'" json:"-"`
+	OrgID           int64      `xorm:"org_id" json:"orgId"`
+	DashboardUID    string     `xorm:"dashboard_uid" json:"dashboardUid"`
+	Views           int64      `xorm:"views" json:"views"`
+	Queries         int64      `xorm:"queries" json:"queries"`
+	QueryErrors     int64      `xorm:"query_errors" json:"queryErrors"`
+	QueryDurationMs int64      `xorm:"query_duration_ms" json:"queryDurationMs"`
+	LastViewed      *time.Time `xorm:"last_viewed" json:"lastViewed,omitempty"`
+	LastQueried     *time.Time `xorm:"last_queried" json:"lastQueried,omitempty"`
+	Updated         time.Time  `xorm:"updated" json:"-"`
+}
+
+// AvgQueryDurationMs returns the average duration of the queries executed for the dashboard.
+func (u *DashboardUsage) AvgQueryDurationMs() float64 {
+	if u.Queries == 0 {
+		return 0
+	}
+	return float64(u.QueryDurationMs) / float64(u.Queries)
+}
+
+// QueryStats describes the queries of a single query request.
+type QueryStats struct {
+	Queries  int64
+	Errors   int64
+	Duration time.Duration
+}
+
+type SearchDashboardUsageQuery struct {
+	OrgID int64
+	// SignedInUser limits the result to the dashboards the user can read.
+	SignedInUser identity.Requester
+	// Sort is one of SortByViews, SortByQueryDuration or SortByLastViewed.
+	Sort string
+	// NotViewedSince limits the result to dashboards which have not been viewed since the
+	// given time, including dashboards which have never been viewed.
+	NotViewedSince *time.Time
+	Limit          int
+	Page           int
+}
+
+// DashboardUsageDTO is the dashboard usage returned by the HTTP API.
+type DashboardUsageDTO struct {
+	DashboardUID       string     `json:"dashboardUid"`
+	Title              string     `json:"title"`
+	URL                string     `json:"url"`
+	Views              int64      `json:"views"`
+	Queries            int64      `json:"queries"`
+	QueryErrors        int64      `json:"queryErrors"`
+	QueryDurationMs    int64      `json:"queryDurationMs"`
+	AvgQueryDurationMs float64    `json:"avgQueryDurationMs"`
+	LastViewed         *time.Time `json:"lastViewed,omitempty"`
+	LastQueried        *time.Time `json:"lastQueried,omitempty"`
+}
@@ -8,4 +8,10 @@ type Service interface {
 	Get(context.Context, *GetDashboardVersionQuery) (*DashboardVersionDTO, error)
 	DeleteExpired(context.Context, *DeleteExpiredVersionsCommand) error
 	List(context.Context, *ListDashboardVersionsQuery) ([]*DashboardVersionDTO, error)
+	SetPinned(context.Context, *SetVersionPinnedCommand) error
+	AddLabel(context.Context, *AddVersionLabelCommand) error
+	RemoveLabel(context.Context, *RemoveVersionLabelCommand) error
+	ListRetentionPolicies(context.Context, *ListRetentionPoliciesQuery) ([]*RetentionPolicy, error)
+	SaveRetentionPolicy(context.Context, *SaveRetentionPolicyCommand) (*RetentionPolicy, error)
+	DeleteRetentionPolicy(context.Context, *DeleteRetentionPolicyCommand) error
 }
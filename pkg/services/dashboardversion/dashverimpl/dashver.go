@@ -3,6 +3,7 @@ package dashverimpl
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -59,7 +60,18 @@ func (s *Service) Get(ctx context.Context, query *dashver.GetDashboardVersionQue
 		return nil, err
 	}
 	version.Data.Set("id", version.DashboardID)
-	return version.ToDTO(query.DashboardUID), nil
+	dto := version.ToDTO(query.DashboardUID)
+
+	labels, err := s.store.GetLabels(ctx, version.DashboardID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range labels {
+		if l.Version == dto.Version {
+			dto.Labels = append(dto.Labels, l.Label)
+		}
+	}
+	return dto, nil
 }
 
 func (s *Service) DeleteExpired(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand) error {
@@ -68,6 +80,14 @@ func (s *Service) DeleteExpired(ctx context.Context, cmd *dashver.DeleteExpiredV
 		versionsToKeep = 1
 	}
 
+	policies, err := s.store.ListRetentionPolicies(ctx, &dashver.ListRetentionPoliciesQuery{})
+	if err != nil {
+		return err
+	}
+	if len(policies) > 0 {
+		return s.deleteExpiredByPolicy(ctx, cmd, policies, retentionRules{versionsToKeep: versionsToKeep})
+	}
+
 	for batch := 0; batch < maxVersionDeletionBatches; batch++ {
 		versionIdsToDelete, batchErr := s.store.GetBatch(ctx, cmd, maxVersionsToDeletePerBatch, versionsToKeep)
 		if batchErr != nil {
@@ -120,13 +140,65 @@ func (s *Service) List(ctx context.Context, query *dashver.ListDashboardVersions
 	if err != nil {
 		return nil, err
 	}
+	labels, err := s.store.GetLabels(ctx, query.DashboardID)
+	if err != nil {
+		return nil, err
+	}
+	labelsByVersion := make(map[int][]string, len(labels))
+	for _, l := range labels {
+		labelsByVersion[l.Version] = append(labelsByVersion[l.Version], l.Label)
+	}
+
 	dtos := make([]*dashver.DashboardVersionDTO, len(dvs))
 	for i, v := range dvs {
 		dtos[i] = v.ToDTO(query.DashboardUID)
+		dtos[i].Labels = labelsByVersion[v.Version]
 	}
 	return dtos, nil
 }
 
+func (s *Service) SetPinned(ctx context.Context, cmd *dashver.SetVersionPinnedCommand) error {
+	return s.store.SetPinned(ctx, cmd)
+}
+
+func (s *Service) AddLabel(ctx context.Context, cmd *dashver.AddVersionLabelCommand) error {
+	if err := dashver.ValidateVersionLabel(cmd.Label); err != nil {
+		return err
+	}
+	return s.store.AddLabel(ctx, cmd)
+}
+
+func (s *Service) RemoveLabel(ctx context.Context, cmd *dashver.RemoveVersionLabelCommand) error {
+	return s.store.RemoveLabel(ctx, cmd)
+}
+
+func (s *Service) ListRetentionPolicies(ctx context.Context, query *dashver.ListRetentionPoliciesQuery) ([]*dashver.RetentionPolicy, error) {
+	return s.store.ListRetentionPolicies(ctx, query)
+}
+
+// SaveRetentionPolicy creates or replaces the policy of the org or folder.
+func (s *Service) SaveRetentionPolicy(ctx context.Context, cmd *dashver.SaveRetentionPolicyCommand) (*dashver.RetentionPolicy, error) {
+	policy := &dashver.RetentionPolicy{
+		OrgID:          cmd.OrgID,
+		FolderUID:      cmd.FolderUID,
+		VersionsToKeep: cmd.VersionsToKeep,
+		KeepDays:       cmd.KeepDays,
+		DailyDays:      cmd.DailyDays,
+		Updated:        time.Now(),
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveRetentionPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *Service) DeleteRetentionPolicy(ctx context.Context, cmd *dashver.DeleteRetentionPolicyCommand) error {
+	return s.store.DeleteRetentionPolicy(ctx, cmd)
+}
+
 // getDashUIDMaybeEmpty is a helper function which takes a dashboardID and
 // returns the UID. If the dashboard is not found, it will return an empty
 // string.
@@ -149,7 +149,13 @@ type FakeDashboardVersionStore struct {
 	ExptectedDeletedVersions int64
 	ExpectedVersions         []any
 	ExpectedListVersions     []*dashver.DashboardVersion
+	ExpectedLabels           []*dashver.VersionLabel
+	ExpectedPolicies         []*dashver.RetentionPolicy
+	ExpectedDashboards       []*versionedDashboard
+	ExpectedCandidates       map[int64][]*retentionCandidate
 	ExpectedError            error
+
+	DeletedVersions []any
 }
 
 func newDashboardVersionStoreFake() *FakeDashboardVersionStore {
@@ -165,9 +171,46 @@ func (f *FakeDashboardVersionStore) GetBatch(ctx context.Context, cmd *dashver.D
 }
 
 func (f *FakeDashboardVersionStore) DeleteBatch(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand, versionIdsToDelete []any) (int64, error) {
+	f.DeletedVersions = append(f.DeletedVersions, versionIdsToDelete...)
 	return f.ExptectedDeletedVersions, f.ExpectedError
 }
 
 func (f *FakeDashboardVersionStore) List(ctx context.Context, query *dashver.ListDashboardVersionsQuery) ([]*dashver.DashboardVersion, error) {
 	return f.ExpectedListVersions, f.ExpectedError
 }
+
+func (f *FakeDashboardVersionStore) SetPinned(ctx context.Context, cmd *dashver.SetVersionPinnedCommand) error {
+	return f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) AddLabel(ctx context.Context, cmd *dashver.AddVersionLabelCommand) error {
+	return f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) RemoveLabel(ctx context.Context, cmd *dashver.RemoveVersionLabelCommand) error {
+	return f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) GetLabels(ctx context.Context, dashboardID int64) ([]*dashver.VersionLabel, error) {
+	return f.ExpectedLabels, f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) ListRetentionPolicies(ctx context.Context, query *dashver.ListRetentionPoliciesQuery) ([]*dashver.RetentionPolicy, error) {
+	return f.ExpectedPolicies, f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) SaveRetentionPolicy(ctx context.Context, policy *dashver.RetentionPolicy) error {
+	return f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) DeleteRetentionPolicy(ctx context.Context, cmd *dashver.DeleteRetentionPolicyCommand) error {
+	return f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) ListVersionedDashboards(ctx context.Context) ([]*versionedDashboard, error) {
+	return f.ExpectedDashboards, f.ExpectedError
+}
+
+func (f *FakeDashboardVersionStore) GetRetentionCandidates(ctx context.Context, dashboardID int64) ([]*retentionCandidate, error) {
+	return f.ExpectedCandidates[dashboardID], f.ExpectedError
+}
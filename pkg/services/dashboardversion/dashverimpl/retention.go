@@ -0,0 +1,138 @@
+package dashverimpl
+
+import (
+	"context"
+	"time"
+
+	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
+)
+
+// retentionRules decide which versions of a dashboard are kept, see dashver.RetentionPolicy.
+type retentionRules struct {
+	versionsToKeep int
+	keepDays       int
+	dailyDays      int
+}
+
+func rulesFromPolicy(p *dashver.RetentionPolicy) retentionRules {
+	return retentionRules{versionsToKeep: p.VersionsToKeep, keepDays: p.KeepDays, dailyDays: p.DailyDays}
+}
+
+// countOnly is true when the rules only keep a number of versions, in which case dashboards
+// with fewer versions don't need to be looked at.
+func (r retentionRules) countOnly() bool {
+	return r.keepDays == 0 && r.dailyDays == 0
+}
+
+// expired returns the IDs of the versions the rules don't keep. The current version of the
+// dashboard is always kept. The versions must be ordered newest first.
+func (r retentionRules) expired(versions []*retentionCandidate, currentVersion int, now time.Time) []any {
+	keepAfter := now.AddDate(0, 0, -r.keepDays)
+	dailyAfter := now.AddDate(0, 0, -r.dailyDays)
+	days := make(map[string]bool)
+
+	var ids []any
+	unprotected := 0
+	for _, v := range versions {
+		if v.Pinned || v.Labelled {
+			continue
+		}
+
+		keep := v.Version == currentVersion || unprotected < r.versionsToKeep
+		unprotected++
+		if r.keepDays > 0 && v.Created.After(keepAfter) {
+			keep = true
+		}
+		if r.dailyDays > 0 && v.Created.After(dailyAfter) {
+			day := v.Created.UTC().Format(time.DateOnly)
+			if !days[day] {
+				// the newest version of the day
+				days[day] = true
+				keep = true
+			}
+		}
+
+		if !keep {
+			ids = append(ids, v.ID)
+		}
+	}
+	return ids
+}
+
+type retentionScope struct {
+	orgID     int64
+	folderUID string
+}
+
+// deleteExpiredByPolicy applies the retention policies dashboard by dashboard, falling back
+// to the default rules for dashboards without a policy.
+func (s *Service) deleteExpiredByPolicy(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand, policies []*dashver.RetentionPolicy, defaults retentionRules) error {
+	rulesByScope := make(map[retentionScope]retentionRules, len(policies))
+	for _, p := range policies {
+		rulesByScope[retentionScope{orgID: p.OrgID, folderUID: p.FolderUID}] = rulesFromPolicy(p)
+	}
+	rulesFor := func(dash *versionedDashboard) retentionRules {
+		if dash.FolderUID != "" {
+			if rules, ok := rulesByScope[retentionScope{orgID: dash.OrgID, folderUID: dash.FolderUID}]; ok {
+				return rules
+			}
+		}
+		if rules, ok := rulesByScope[retentionScope{orgID: dash.OrgID}]; ok {
+			return rules
+		}
+		return defaults
+	}
+
+	dashes, err := s.store.ListVersionedDashboards(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending := make([]any, 0, maxVersionsToDeletePerBatch)
+	batches := 0
+	flush := func() error {
+		deleted, err := s.store.DeleteBatch(ctx, cmd, pending)
+		if err != nil {
+			return err
+		}
+		cmd.DeletedRows += deleted
+		pending = pending[:0]
+		batches++
+		return nil
+	}
+
+	now := time.Now()
+	for _, dash := range dashes {
+		rules := rulesFor(dash)
+		if rules.countOnly() && dash.Versions <= rules.versionsToKeep {
+			continue
+		}
+
+		candidates, err := s.store.GetRetentionCandidates(ctx, dash.DashboardID)
+		if err != nil {
+			return err
+		}
+		currentVersion := dash.CurrentVersion
+		if currentVersion == 0 && len(candidates) > 0 {
+			// the dashboard was deleted, keep its last version
+			currentVersion = candidates[0].Version
+		}
+		for _, id := range rules.expired(candidates, currentVersion, now) {
+			pending = append(pending, id)
+			if len(pending) < maxVersionsToDeletePerBatch {
+				continue
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+			if batches >= maxVersionDeletionBatches {
+				return nil
+			}
+		}
+	}
+
+	if len(pending) > 0 {
+		return flush()
+	}
+	return nil
+}
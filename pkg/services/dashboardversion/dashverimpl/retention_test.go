@@ -0,0 +1,115 @@
+package dashverimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
+)
+
+func TestRetentionRules(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	// versions 10 to 1, one every 12 hours going back from now
+	versions := func() []*retentionCandidate {
+		res := make([]*retentionCandidate, 0, 10)
+		for v := 10; v > 0; v-- {
+			res = append(res, &retentionCandidate{
+				ID:      int64(v),
+				Version: v,
+				Created: now.Add(-time.Duration(10-v) * 12 * time.Hour),
+			})
+		}
+		return res
+	}
+
+	t.Run("keeps the most recent versions", func(t *testing.T) {
+		rules := retentionRules{versionsToKeep: 3}
+		require.Equal(t, []any{int64(7), int64(6), int64(5), int64(4), int64(3), int64(2), int64(1)}, rules.expired(versions(), 10, now))
+	})
+
+	t.Run("pinned and labelled versions are kept and not counted", func(t *testing.T) {
+		vs := versions()
+		vs[1].Pinned = true   // version 9
+		vs[8].Labelled = true // version 2
+		rules := retentionRules{versionsToKeep: 3}
+		require.Equal(t, []any{int64(6), int64(5), int64(4), int64(3), int64(1)}, rules.expired(vs, 10, now))
+	})
+
+	t.Run("keeps versions newer than the number of days", func(t *testing.T) {
+		rules := retentionRules{keepDays: 2}
+		// versions created in the last 48 hours, exclusive
+		require.Equal(t, []any{int64(6), int64(5), int64(4), int64(3), int64(2), int64(1)}, rules.expired(versions(), 10, now))
+	})
+
+	t.Run("keeps the last version of each day", func(t *testing.T) {
+		rules := retentionRules{versionsToKeep: 1, dailyDays: 3}
+		// two versions a day, 10 and 9 on the 18th, 8 and 7 on the 17th and 6 and 5 on the 16th
+		require.Equal(t, []any{int64(9), int64(7), int64(5), int64(4), int64(3), int64(2), int64(1)}, rules.expired(versions(), 10, now))
+	})
+
+	t.Run("always keeps the current version", func(t *testing.T) {
+		rules := retentionRules{keepDays: 1}
+		old := []*retentionCandidate{{ID: 2, Version: 2, Created: now.AddDate(0, 0, -10)}, {ID: 1, Version: 1, Created: now.AddDate(0, 0, -20)}}
+		require.Equal(t, []any{int64(1)}, rules.expired(old, 2, now))
+	})
+
+	t.Run("keeps the current version when newer versions are pinned", func(t *testing.T) {
+		rules := retentionRules{keepDays: 1}
+		old := []*retentionCandidate{
+			{ID: 3, Version: 3, Created: now.AddDate(0, 0, -5), Pinned: true},
+			{ID: 2, Version: 2, Created: now.AddDate(0, 0, -10)},
+			{ID: 1, Version: 1, Created: now.AddDate(0, 0, -20)},
+		}
+		require.Equal(t, []any{int64(1)}, rules.expired(old, 2, now))
+		require.Equal(t, []any{int64(2), int64(1)}, rules.expired(old, 3, now))
+	})
+}
+
+func TestDeleteExpiredByPolicy(t *testing.T) {
+	store := newDashboardVersionStoreFake()
+	svc := Service{store: store, dashSvc: dashboards.NewFakeDashboardService(t), log: log.NewNopLogger()}
+
+	candidates := func(dashboardID int64, n int) []*retentionCandidate {
+		res := make([]*retentionCandidate, 0, n)
+		for v := n; v > 0; v-- {
+			res = append(res, &retentionCandidate{ID: dashboardID*100 + int64(v), Version: v, Created: time.Now()})
+		}
+		return res
+	}
+
+	store.ExpectedPolicies = []*dashver.RetentionPolicy{
+		{OrgID: 1, VersionsToKeep: 4},
+		{OrgID: 1, FolderUID: "prod", VersionsToKeep: 2},
+	}
+	store.ExpectedDashboards = []*versionedDashboard{
+		{DashboardID: 1, OrgID: 1, FolderUID: "prod", CurrentVersion: 5, Versions: 5},
+		{DashboardID: 2, OrgID: 1, FolderUID: "dev", CurrentVersion: 5, Versions: 5},
+		{DashboardID: 3, OrgID: 2, CurrentVersion: 5, Versions: 5},
+		{DashboardID: 4, OrgID: 2, Versions: 7},
+	}
+	store.ExpectedCandidates = map[int64][]*retentionCandidate{
+		1: candidates(1, 5),
+		2: candidates(2, 5),
+		3: candidates(3, 5),
+		4: candidates(4, 7),
+	}
+	store.ExptectedDeletedVersions = 6
+
+	cmd := &dashver.DeleteExpiredVersionsCommand{}
+	err := svc.deleteExpiredByPolicy(context.Background(), cmd, store.ExpectedPolicies, retentionRules{versionsToKeep: 5})
+	require.NoError(t, err)
+	require.Equal(t, []any{
+		// folder policy
+		int64(103), int64(102), int64(101),
+		// org policy
+		int64(201),
+		// default
+		int64(402), int64(401),
+	}, store.DeletedVersions)
+	require.Equal(t, int64(6), cmd.DeletedRows)
+}
@@ -2,6 +2,7 @@ package dashverimpl
 
 import (
 	"context"
+	"time"
 
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 )
@@ -11,4 +12,31 @@ type store interface {
 	GetBatch(context.Context, *dashver.DeleteExpiredVersionsCommand, int, int) ([]any, error)
 	DeleteBatch(context.Context, *dashver.DeleteExpiredVersionsCommand, []any) (int64, error)
 	List(context.Context, *dashver.ListDashboardVersionsQuery) ([]*dashver.DashboardVersion, error)
+	SetPinned(context.Context, *dashver.SetVersionPinnedCommand) error
+	AddLabel(context.Context, *dashver.AddVersionLabelCommand) error
+	RemoveLabel(context.Context, *dashver.RemoveVersionLabelCommand) error
+	GetLabels(ctx context.Context, dashboardID int64) ([]*dashver.VersionLabel, error)
+	ListRetentionPolicies(context.Context, *dashver.ListRetentionPoliciesQuery) ([]*dashver.RetentionPolicy, error)
+	SaveRetentionPolicy(context.Context, *dashver.RetentionPolicy) error
+	DeleteRetentionPolicy(context.Context, *dashver.DeleteRetentionPolicyCommand) error
+	ListVersionedDashboards(context.Context) ([]*versionedDashboard, error)
+	GetRetentionCandidates(ctx context.Context, dashboardID int64) ([]*retentionCandidate, error)
+}
+
+// versionedDashboard is a dashboard with more than one version.
+type versionedDashboard struct {
+	DashboardID    int64  `xorm:"dashboard_id"`
+	OrgID          int64  `xorm:"org_id"`
+	FolderUID      string `xorm:"folder_uid"`
+	CurrentVersion int    `xorm:"current_version"`
+	Versions       int    `xorm:"versions"`
+}
+
+// retentionCandidate is a dashboard version as seen by the retention policies.
+type retentionCandidate struct {
+	ID       int64     `xorm:"id"`
+	Version  int       `xorm:"version"`
+	Created  time.Time `xorm:"created"`
+	Pinned   bool      `xorm:"pinned"`
+	Labelled bool      `xorm:"-"`
 }
@@ -111,6 +111,95 @@ func testIntegrationGetDashboardVersion(t *testing.T, fn getStore) {
 		require.Nil(t, err)
 		assert.Equal(t, 2, len(res))
 	})
+
+	t.Run("Pinned and labelled versions are not cleaned up", func(t *testing.T) {
+		dash := insertTestDashboard(t, ss, "test dash pinned", 1, 0, "", false, "pinned")
+		for i := 0; i < 5; i++ {
+			updateTestDashboard(t, ss, dash, map[string]any{"tags": strconv.Itoa(i)})
+		}
+		// versions 1 to 6
+
+		err := dashVerStore.SetPinned(context.Background(), &dashver.SetVersionPinnedCommand{OrgID: 1, DashboardID: dash.ID, Version: 1, Pinned: true})
+		require.NoError(t, err)
+		err = dashVerStore.AddLabel(context.Background(), &dashver.AddVersionLabelCommand{OrgID: 1, DashboardID: dash.ID, Version: 3, Label: "release-2026-10"})
+		require.NoError(t, err)
+
+		err = dashVerStore.AddLabel(context.Background(), &dashver.AddVersionLabelCommand{OrgID: 1, DashboardID: dash.ID, Version: 2, Label: "release-2026-10"})
+		require.ErrorIs(t, err, dashver.ErrVersionLabelExists)
+		err = dashVerStore.SetPinned(context.Background(), &dashver.SetVersionPinnedCommand{OrgID: 2, DashboardID: dash.ID, Version: 1, Pinned: true})
+		require.ErrorIs(t, err, dashver.ErrDashboardVersionNotFound)
+
+		labels, err := dashVerStore.GetLabels(context.Background(), dash.ID)
+		require.NoError(t, err)
+		require.Len(t, labels, 1)
+		assert.Equal(t, 3, labels[0].Version)
+
+		candidates, err := dashVerStore.GetRetentionCandidates(context.Background(), dash.ID)
+		require.NoError(t, err)
+		require.Len(t, candidates, 6)
+		assert.True(t, candidates[5].Pinned)
+		assert.True(t, candidates[3].Labelled)
+
+		// run the cleanup until there is nothing left to delete
+		for {
+			ids, err := dashVerStore.GetBatch(context.Background(), &dashver.DeleteExpiredVersionsCommand{}, 100, 2)
+			require.NoError(t, err)
+			if len(ids) == 0 {
+				break
+			}
+			_, err = dashVerStore.DeleteBatch(context.Background(), &dashver.DeleteExpiredVersionsCommand{}, ids)
+			require.NoError(t, err)
+		}
+
+		res, err := dashVerStore.List(context.Background(), &dashver.ListDashboardVersionsQuery{DashboardID: dash.ID, OrgID: 1, Limit: 1000})
+		require.NoError(t, err)
+		versions := make([]int, 0, len(res))
+		for _, v := range res {
+			versions = append(versions, v.Version)
+		}
+		assert.Equal(t, []int{6, 5, 3, 1}, versions)
+		assert.True(t, res[3].Pinned)
+
+		err = dashVerStore.RemoveLabel(context.Background(), &dashver.RemoveVersionLabelCommand{OrgID: 1, DashboardID: dash.ID, Version: 3, Label: "release-2026-10"})
+		require.NoError(t, err)
+		err = dashVerStore.RemoveLabel(context.Background(), &dashver.RemoveVersionLabelCommand{OrgID: 1, DashboardID: dash.ID, Version: 3, Label: "release-2026-10"})
+		require.ErrorIs(t, err, dashver.ErrVersionLabelNotFound)
+	})
+
+	t.Run("Save, list and delete retention policies", func(t *testing.T) {
+		policy := &dashver.RetentionPolicy{OrgID: 1, FolderUID: "prod", VersionsToKeep: 10, Updated: time.Now()}
+		require.NoError(t, dashVerStore.SaveRetentionPolicy(context.Background(), policy))
+		require.NoError(t, dashVerStore.SaveRetentionPolicy(context.Background(), &dashver.RetentionPolicy{OrgID: 2, KeepDays: 7, Updated: time.Now()}))
+
+		// saving the same scope again replaces the policy
+		updated := &dashver.RetentionPolicy{OrgID: 1, FolderUID: "prod", DailyDays: 30, Updated: time.Now()}
+		require.NoError(t, dashVerStore.SaveRetentionPolicy(context.Background(), updated))
+		assert.Equal(t, policy.ID, updated.ID)
+
+		policies, err := dashVerStore.ListRetentionPolicies(context.Background(), &dashver.ListRetentionPoliciesQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Len(t, policies, 1)
+		assert.Equal(t, 0, policies[0].VersionsToKeep)
+		assert.Equal(t, 30, policies[0].DailyDays)
+
+		policies, err = dashVerStore.ListRetentionPolicies(context.Background(), &dashver.ListRetentionPoliciesQuery{})
+		require.NoError(t, err)
+		require.Len(t, policies, 2)
+
+		require.NoError(t, dashVerStore.DeleteRetentionPolicy(context.Background(), &dashver.DeleteRetentionPolicyCommand{OrgID: 1, FolderUID: "prod"}))
+		err = dashVerStore.DeleteRetentionPolicy(context.Background(), &dashver.DeleteRetentionPolicyCommand{OrgID: 1, FolderUID: "prod"})
+		require.ErrorIs(t, err, dashver.ErrRetentionPolicyNotFound)
+	})
+
+	t.Run("List dashboards with several versions", func(t *testing.T) {
+		dashes, err := dashVerStore.ListVersionedDashboards(context.Background())
+		require.NoError(t, err)
+		require.NotEmpty(t, dashes)
+		for _, d := range dashes {
+			assert.Greater(t, d.Versions, 1)
+			assert.Greater(t, d.CurrentVersion, 0)
+		}
+	})
 }
 
 func getDashboard(t *testing.T, sqlStore db.DB, dashboard *dashboards.Dashboard) error {
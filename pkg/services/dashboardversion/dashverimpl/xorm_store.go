@@ -3,6 +3,7 @@ package dashverimpl
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
@@ -39,14 +40,24 @@ func (ss *sqlStore) Get(ctx context.Context, query *dashver.GetDashboardVersionQ
 func (ss *sqlStore) GetBatch(ctx context.Context, cmd *dashver.DeleteExpiredVersionsCommand, perBatch int, versionsToKeep int) ([]any, error) {
 	var versionIds []any
 	err := ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		// pinned and labelled versions are neither deleted nor counted. Protected versions older
+		// than the cutoff make the query keep a few more versions than needed, which are
+		// removed by the next cleanup.
+		unprotected := `pinned = ` + ss.dialect.BooleanStr(false) + ` AND NOT EXISTS (
+				SELECT 1 FROM dashboard_version_label
+				WHERE dashboard_version_label.dashboard_id = dashboard_version.dashboard_id
+				AND dashboard_version_label.version = dashboard_version.version
+			)`
 		versionIdsToDeleteQuery := `SELECT id
 			FROM dashboard_version, (
 				SELECT dashboard_id, count(version) as count, min(version) as min
 				FROM dashboard_version
+				WHERE ` + unprotected + `
 				GROUP BY dashboard_id
 			) AS vtd
 			WHERE dashboard_version.dashboard_id=vtd.dashboard_id
 			AND version < vtd.min + vtd.count - ?
+			AND ` + unprotected + `
 			LIMIT ?`
 
 		err := sess.SQL(versionIdsToDeleteQuery, versionsToKeep, perBatch).Find(&versionIds)
@@ -83,7 +94,8 @@ func (ss *sqlStore) List(ctx context.Context, query *dashver.ListDashboardVersio
 				dashboard_version.created,
 				dashboard_version.created_by,
 				dashboard_version.message,
-				dashboard_version.data`).
+				dashboard_version.data,
+				dashboard_version.pinned`).
 			Join("LEFT", "dashboard", `dashboard.id = dashboard_version.dashboard_id`).
 			Where("dashboard_version.dashboard_id=? AND dashboard.org_id=?", query.DashboardID, query.OrgID).
 			OrderBy("dashboard_version.version DESC").
@@ -103,3 +115,176 @@ func (ss *sqlStore) List(ctx context.Context, query *dashver.ListDashboardVersio
 	}
 	return dashboardVersion, nil
 }
+
+// versionExists checks that the version exists and belongs to a dashboard in the org.
+func versionExists(sess *db.Session, orgID, dashboardID int64, version int) (bool, error) {
+	count, err := sess.Table("dashboard_version").
+		Join("INNER", "dashboard", "dashboard.id = dashboard_version.dashboard_id").
+		Where("dashboard_version.dashboard_id=? AND dashboard_version.version=? AND dashboard.org_id=?", dashboardID, version, orgID).
+		Count()
+	return count > 0, err
+}
+
+func (ss *sqlStore) SetPinned(ctx context.Context, cmd *dashver.SetVersionPinnedCommand) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		exists, err := versionExists(sess, cmd.OrgID, cmd.DashboardID, cmd.Version)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return dashver.ErrDashboardVersionNotFound
+		}
+		_, err = sess.Exec("UPDATE dashboard_version SET pinned = ? WHERE dashboard_id = ? AND version = ?", cmd.Pinned, cmd.DashboardID, cmd.Version)
+		return err
+	})
+}
+
+func (ss *sqlStore) AddLabel(ctx context.Context, cmd *dashver.AddVersionLabelCommand) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		exists, err := versionExists(sess, cmd.OrgID, cmd.DashboardID, cmd.Version)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return dashver.ErrDashboardVersionNotFound
+		}
+
+		var existing dashver.VersionLabel
+		has, err := sess.Where("dashboard_id=? AND label=?", cmd.DashboardID, cmd.Label).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if has {
+			if existing.Version == cmd.Version {
+				return nil
+			}
+			return dashver.ErrVersionLabelExists
+		}
+
+		_, err = sess.Insert(&dashver.VersionLabel{
+			DashboardID: cmd.DashboardID,
+			Version:     cmd.Version,
+			Label:       cmd.Label,
+			Created:     time.Now(),
+			CreatedBy:   cmd.UserID,
+		})
+		return err
+	})
+}
+
+func (ss *sqlStore) RemoveLabel(ctx context.Context, cmd *dashver.RemoveVersionLabelCommand) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		exists, err := versionExists(sess, cmd.OrgID, cmd.DashboardID, cmd.Version)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return dashver.ErrDashboardVersionNotFound
+		}
+
+		deleted, err := sess.Where("dashboard_id=? AND version=? AND label=?", cmd.DashboardID, cmd.Version, cmd.Label).
+			Delete(&dashver.VersionLabel{})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return dashver.ErrVersionLabelNotFound
+		}
+		return nil
+	})
+}
+
+func (ss *sqlStore) GetLabels(ctx context.Context, dashboardID int64) ([]*dashver.VersionLabel, error) {
+	labels := make([]*dashver.VersionLabel, 0)
+	err := ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("dashboard_id=?", dashboardID).OrderBy("label").Find(&labels)
+	})
+	return labels, err
+}
+
+func (ss *sqlStore) ListRetentionPolicies(ctx context.Context, query *dashver.ListRetentionPoliciesQuery) ([]*dashver.RetentionPolicy, error) {
+	policies := make([]*dashver.RetentionPolicy, 0)
+	err := ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		if query.OrgID > 0 {
+			sess.Where("org_id=?", query.OrgID)
+		}
+		return sess.OrderBy("org_id, folder_uid").Find(&policies)
+	})
+	return policies, err
+}
+
+func (ss *sqlStore) SaveRetentionPolicy(ctx context.Context, policy *dashver.RetentionPolicy) error {
+	return ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		var existing dashver.RetentionPolicy
+		has, err := sess.Where("org_id=? AND folder_uid=?", policy.OrgID, policy.FolderUID).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !has {
+			_, err = sess.Insert(policy)
+			return err
+		}
+		policy.ID = existing.ID
+		_, err = sess.ID(existing.ID).AllCols().Update(policy)
+		return err
+	})
+}
+
+func (ss *sqlStore) DeleteRetentionPolicy(ctx context.Context, cmd *dashver.DeleteRetentionPolicyCommand) error {
+	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		deleted, err := sess.Where("org_id=? AND folder_uid=?", cmd.OrgID, cmd.FolderUID).Delete(&dashver.RetentionPolicy{})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return dashver.ErrRetentionPolicyNotFound
+		}
+		return nil
+	})
+}
+
+func (ss *sqlStore) ListVersionedDashboards(ctx context.Context) ([]*versionedDashboard, error) {
+	dashes := make([]*versionedDashboard, 0)
+	err := ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		// versions of deleted dashboards have no org and fall back to the default retention
+		return sess.SQL(`SELECT dashboard_version.dashboard_id,
+				COALESCE(dashboard.org_id, 0) AS org_id,
+				COALESCE(dashboard.folder_uid, '') AS folder_uid,
+				COALESCE(dashboard.version, 0) AS current_version,
+				COUNT(*) AS versions
+			FROM dashboard_version
+			LEFT JOIN dashboard ON dashboard.id = dashboard_version.dashboard_id
+			GROUP BY dashboard_version.dashboard_id, dashboard.org_id, dashboard.folder_uid, dashboard.version
+			HAVING COUNT(*) > 1`).Find(&dashes)
+	})
+	return dashes, err
+}
+
+func (ss *sqlStore) GetRetentionCandidates(ctx context.Context, dashboardID int64) ([]*retentionCandidate, error) {
+	candidates := make([]*retentionCandidate, 0)
+	err := ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+		err := sess.Table("dashboard_version").
+			Cols("id", "version", "created", "pinned").
+			Where("dashboard_id=?", dashboardID).
+			OrderBy("version DESC").
+			Find(&candidates)
+		if err != nil {
+			return err
+		}
+
+		var labelled []int
+		err = sess.Table("dashboard_version_label").Cols("version").Where("dashboard_id=?", dashboardID).Find(&labelled)
+		if err != nil {
+			return err
+		}
+		isLabelled := make(map[int]bool, len(labelled))
+		for _, v := range labelled {
+			isLabelled[v] = true
+		}
+		for _, c := range candidates {
+			c.Labelled = isLabelled[c.Version]
+		}
+		return nil
+	})
+	return candidates, err
+}
@@ -2,6 +2,7 @@ package dashver
 
 import (
 	"errors"
+	"regexp"
 	"time"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -10,8 +11,23 @@ import (
 var (
 	ErrDashboardVersionNotFound = errors.New("dashboard version not found")
 	ErrNoVersionsForDashboardID = errors.New("no dashboard versions found for the given DashboardId")
+	ErrVersionLabelInvalid      = errors.New("label must be 1-64 letters, digits, dots, dashes or underscores")
+	ErrVersionLabelExists       = errors.New("label is already used by another version of the dashboard")
+	ErrVersionLabelNotFound     = errors.New("dashboard version label not found")
+	ErrRetentionPolicyInvalid   = errors.New("retention policy must keep a positive number of versions or days, and no negative values")
+	ErrRetentionPolicyNotFound  = errors.New("dashboard version retention policy not found")
 )
 
+var versionLabelPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+// ValidateVersionLabel checks that a label is safe to use in URLs.
+func ValidateVersionLabel(label string) error {
+	if !versionLabelPattern.MatchString(label) {
+		return ErrVersionLabelInvalid
+	}
+	return nil
+}
+
 // DashboardVersion represents a dashboard version in the database. Ideally this
 // will be moved into dashverimpl and unexported, but there are a few test
 // fixtures that insert DashboardVersions directly into a database which must be
@@ -28,6 +44,9 @@ type DashboardVersion struct {
 
 	Message string           `json:"message" db:"message"`
 	Data    *simplejson.Json `json:"data" db:"data"`
+
+	// Pinned versions are never removed by the version cleanup.
+	Pinned bool `json:"pinned" db:"pinned"`
 }
 
 // ToDTO converts a DashboardVersion to a DashboardVersionDTO.
@@ -43,6 +62,7 @@ func (v *DashboardVersion) ToDTO(dashUid string) *DashboardVersionDTO {
 		CreatedBy:     v.CreatedBy,
 		Message:       v.Message,
 		Data:          v.Data,
+		Pinned:        v.Pinned,
 	}
 }
 
@@ -77,6 +97,8 @@ type DashboardVersionDTO struct {
 	CreatedBy     int64            `json:"createdBy"`
 	Message       string           `json:"message"`
 	Data          *simplejson.Json `json:"data" db:"data"`
+	Pinned        bool             `json:"pinned"`
+	Labels        []string         `json:"labels,omitempty"`
 }
 
 // DashboardVersionMeta extends the DashboardVersionDTO with the names
@@ -93,4 +115,96 @@ type DashboardVersionMeta struct {
 	Message       string           `json:"message"`
 	Data          *simplejson.Json `json:"data"`
 	CreatedBy     string           `json:"createdBy"`
+	Pinned        bool             `json:"pinned"`
+	Labels        []string         `json:"labels,omitempty"`
+}
+
+// VersionLabel names a dashboard version, e.g. `release-2026-10`. Labels are
+// unique per dashboard and, like pins, protect the version from cleanup.
+type VersionLabel struct {
+	ID          int64     `json:"id" xorm:"pk autoincr 'id'"`
+	DashboardID int64     `json:"dashboardId" xorm:"dashboard_id"`
+	Version     int       `json:"version"`
+	Label       string    `json:"label"`
+	Created     time.Time `json:"created"`
+	CreatedBy   int64     `json:"createdBy"`
+}
+
+func (VersionLabel) TableName() string {
+	return "dashboard_version_label"
+}
+
+type SetVersionPinnedCommand struct {
+	OrgID       int64
+	DashboardID int64
+	Version     int
+	Pinned      bool
+}
+
+type AddVersionLabelCommand struct {
+	OrgID       int64
+	DashboardID int64
+	Version     int
+	Label       string
+	UserID      int64
+}
+
+type RemoveVersionLabelCommand struct {
+	OrgID       int64
+	DashboardID int64
+	Version     int
+	Label       string
+}
+
+// RetentionPolicy decides which dashboard versions the cleanup keeps for the
+// dashboards of an org, or of a single folder when FolderUID is set. Folder
+// policies apply to the dashboards directly in the folder and take precedence
+// over the org policy, which takes precedence over `versions_to_keep`.
+//
+// A version is kept when any of the rules keeps it. The latest version, and
+// pinned or labelled versions, are always kept.
+type RetentionPolicy struct {
+	ID        int64  `json:"id" xorm:"pk autoincr 'id'"`
+	OrgID     int64  `json:"orgId" xorm:"org_id"`
+	FolderUID string `json:"folderUid" xorm:"folder_uid"`
+	// VersionsToKeep keeps the most recent versions, not counting pinned and labelled ones.
+	VersionsToKeep int `json:"versionsToKeep"`
+	// KeepDays keeps all versions created in the last days.
+	KeepDays int `json:"keepDays"`
+	// DailyDays keeps the last version of each day (UTC) for this many days.
+	DailyDays int       `json:"dailyDays"`
+	Updated   time.Time `json:"updated"`
+}
+
+func (RetentionPolicy) TableName() string {
+	return "dashboard_version_retention"
+}
+
+// Validate checks that the policy keeps something.
+func (p *RetentionPolicy) Validate() error {
+	if p.VersionsToKeep < 0 || p.KeepDays < 0 || p.DailyDays < 0 {
+		return ErrRetentionPolicyInvalid
+	}
+	if p.VersionsToKeep == 0 && p.KeepDays == 0 && p.DailyDays == 0 {
+		return ErrRetentionPolicyInvalid
+	}
+	return nil
+}
+
+type ListRetentionPoliciesQuery struct {
+	// OrgID limits the policies to an org, all policies are returned when 0.
+	OrgID int64
+}
+
+type SaveRetentionPolicyCommand struct {
+	OrgID          int64  `json:"-"`
+	FolderUID      string `json:"folderUid"`
+	VersionsToKeep int    `json:"versionsToKeep"`
+	KeepDays       int    `json:"keepDays"`
+	DailyDays      int    `json:"dailyDays"`
+}
+
+type DeleteRetentionPolicyCommand struct {
+	OrgID     int64
+	FolderUID string
 }
@@ -0,0 +1,11 @@
+package datasourcehealth
+
+import (
+	"context"
+)
+
+// Service periodically checks the health of the data sources and keeps the results.
+type Service interface {
+	// GetHistory returns the health checks of a data source, the most recent first.
+	GetHistory(ctx context.Context, query *GetHistoryQuery) (*HealthHistory, error)
+}
@@ -0,0 +1,54 @@
+package datasourcehealthimpl
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasourcehealth"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+	uidScope := datasources.ScopeProvider.GetResourceScopeUID(ac.Parameter(":uid"))
+	routeRegister.Get("/api/datasources/uid/:uid/health/history", middleware.ReqSignedIn,
+		authorize(ac.EvalPermission(datasources.ActionRead, uidScope)), routing.Wrap(s.historyHandler))
+}
+
+// GET /api/datasources/uid/:uid/health/history
+func (s *Service) historyHandler(c *contextmodel.ReqContext) response.Response {
+	fromParam, toParam := c.Query("from"), c.Query("to")
+	if fromParam == "" {
+		fromParam = "now-24h"
+	}
+	if toParam == "" {
+		toParam = "now"
+	}
+	timeRange := legacydata.NewDataTimeRange(fromParam, toParam)
+	from, err := timeRange.ParseFrom()
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Invalid from", err)
+	}
+	to, err := timeRange.ParseTo()
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Invalid to", err)
+	}
+
+	history, err := s.GetHistory(c.Req.Context(), &datasourcehealth.GetHistoryQuery{
+		OrgID:         c.SignedInUser.GetOrgID(),
+		DatasourceUID: web.Params(c.Req)[":uid"],
+		From:          from.UTC(),
+		To:            to.UTC(),
+		Limit:         c.QueryInt("limit"),
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get data source health history", err)
+	}
+	return response.JSON(http.StatusOK, history)
+}
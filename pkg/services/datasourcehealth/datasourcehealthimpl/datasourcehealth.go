@@ -0,0 +1,399 @@
+package datasourcehealthimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/datasourcehealth"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+	defaultRetention    = 7 * 24 * time.Hour
+)
+
+// Service runs the health checks of all the data sources every interval. The status of each data
+// source is kept in memory to detect when a data source becomes unhealthy or recovers.
+//
+// Every instance runs the health checks to keep its metrics up to date, but only the instance
+// holding the server lock for the interval stores the results and creates the annotations.
+type Service struct {
+	store         store
+	serverLock    *serverlock.ServerLockService
+	log           log.Logger
+	accessControl accesscontrol.AccessControl
+	dataSources   datasources.DataSourceService
+	healthChecker backend.CheckHealthHandler
+	pCtxProvider  pluginContextProvider
+	annotations   annotations.Repository
+
+	enabled           bool
+	interval          time.Duration
+	timeout           time.Duration
+	retention         time.Duration
+	concurrency       int
+	createAnnotations bool
+	now               func() time.Time
+
+	up       *prometheus.GaugeVec
+	latency  *prometheus.GaugeVec
+	checks   *prometheus.CounterVec
+	mu       sync.Mutex
+	statuses map[statusKey]string
+	// recorded is whether this instance recorded the previous health checks, the statuses
+	// in memory are outdated otherwise.
+	recorded bool
+}
+
+type pluginContextProvider interface {
+	GetWithDataSource(ctx context.Context, pluginID string, user identity.Requester, ds *datasources.DataSource) (backend.PluginContext, error)
+}
+
+type statusKey struct {
+	orgID int64
+	uid   string
+}
+
+var _ datasourcehealth.Service = (*Service)(nil)
+
+func ProvideService(cfg *setting.Cfg, db db.DB, serverLock *serverlock.ServerLockService, routeRegister routing.RouteRegister, accessControl accesscontrol.AccessControl,
+	dataSources datasources.DataSourceService, pluginClient plugins.Client, pCtxProvider *plugincontext.Provider,
+	annotationsRepo annotations.Repository, registerer prometheus.Registerer) *Service {
+	section := cfg.SectionWithEnvOverrides("datasource_health")
+	s := &Service{
+		store:             &sqlStore{db: db},
+		serverLock:        serverLock,
+		log:               log.New("datasource-health"),
+		accessControl:     accessControl,
+		dataSources:       dataSources,
+		healthChecker:     pluginClient,
+		pCtxProvider:      pCtxProvider,
+		annotations:       annotationsRepo,
+		enabled:           section.Key("enabled").MustBool(false),
+		interval:          section.Key("check_interval").MustDuration(5 * time.Minute),
+		timeout:           section.Key("check_timeout").MustDuration(30 * time.Second),
+		retention:         defaultRetention,
+		concurrency:       section.Key("max_concurrent_checks").MustInt(10),
+		createAnnotations: section.Key("annotations").MustBool(false),
+		now:               time.Now,
+		statuses:          map[statusKey]string{},
+	}
+	if retention, err := gtime.ParseDuration(section.Key("retention").MustString("7d")); err != nil {
+		s.log.Warn("Invalid health check retention, using the default", "retention", section.Key("retention").String(), "default", defaultRetention)
+	} else {
+		s.retention = retention
+	}
+	if s.concurrency < 1 {
+		s.concurrency = 1
+	}
+
+	labels := []string{"org_id", "datasource_uid", "datasource_type"}
+	factory := promauto.With(registerer)
+	s.up = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "datasource_health_up",
+		Help:      "1 when the latest health check of the data source passed, 0 otherwise.",
+	}, labels)
+	s.latency = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "datasource_health_check_duration_seconds",
+		Help:      "Duration of the latest health check of the data source.",
+	}, labels)
+	s.checks = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "datasource_health_checks_total",
+		Help:      "Number of data source health checks, by data source type and status.",
+	}, []string{"datasource_type", "status"})
+
+	if s.enabled {
+		s.registerAPIEndpoints(routeRegister)
+	}
+
+	return s
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.enabled
+}
+
+// Run checks the health of the data sources when Grafana starts and then every check interval.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.runChecks(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) GetHistory(ctx context.Context, query *datasourcehealth.GetHistoryQuery) (*datasourcehealth.HealthHistory, error) {
+	if query.From.After(query.To) {
+		return nil, datasourcehealth.ErrInvalidQuery.Errorf("from must be before to")
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultHistoryLimit
+	}
+	if query.Limit > maxHistoryLimit {
+		query.Limit = maxHistoryLimit
+	}
+	if _, err := s.dataSources.GetDataSource(ctx, &datasources.GetDataSourceQuery{OrgID: query.OrgID, UID: query.DatasourceUID}); err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return nil, datasourcehealth.ErrDataSourceNotFound.Errorf("data source %s not found", query.DatasourceUID)
+		}
+		return nil, err
+	}
+
+	checks, err := s.store.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.store.GetStats(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &datasourcehealth.HealthHistory{
+		DatasourceUID: query.DatasourceUID,
+		AvgLatencyMs:  stats.AvgLatencyMs,
+		Checks:        checks,
+	}
+	if stats.Checks > 0 {
+		history.Uptime = float64(stats.Healthy) / float64(stats.Checks)
+	}
+	latest, err := s.store.GetLatest(ctx, query.OrgID, query.DatasourceUID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		history.Status = latest.Status
+	}
+	return history, nil
+}
+
+// runChecks checks the health of every data source, and records the results when this instance
+// gets the server lock. The lock is held for a bit less than the interval, so that the instance
+// holding it keeps it even when its checks start a little early.
+func (s *Service) runChecks(ctx context.Context) {
+	recorded := false
+	err := s.serverLock.LockAndExecute(ctx, "check data source health", s.interval-s.interval/10, func(ctx context.Context) {
+		recorded = true
+		s.checkAll(ctx, true)
+	})
+	if err != nil {
+		s.log.Error("Failed to lock the data source health checks", "error", err)
+	}
+	if !recorded {
+		s.checkAll(ctx, false)
+	}
+}
+
+// checkAll checks the health of every data source, and removes the metrics of the data sources
+// that don't exist anymore. When record is set, the results are stored, annotated and the old
+// health checks are deleted.
+func (s *Service) checkAll(ctx context.Context, record bool) {
+	dss, err := s.dataSources.GetAllDataSources(ctx, &datasources.GetAllDataSourcesQuery{})
+	if err != nil {
+		s.log.Error("Failed to get data sources", "error", err)
+		return
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.concurrency)
+	for _, ds := range dss {
+		ds := ds
+		g.Go(func() error {
+			s.checkDataSource(gctx, ds, record)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	s.removeDeleted(dss)
+	s.recorded = record
+
+	if record && s.retention > 0 {
+		deleted, err := s.store.DeleteOlderThan(ctx, s.now().Add(-s.retention).UTC())
+		if err != nil {
+			s.log.Error("Failed to delete old health checks", "error", err)
+		} else if deleted > 0 {
+			s.log.Debug("Deleted old health checks", "count", deleted)
+		}
+	}
+}
+
+// checkDataSource checks the health of a data source and, when record is set, stores the result.
+// Data sources whose plugin doesn't support health checks are skipped.
+func (s *Service) checkDataSource(ctx context.Context, ds *datasources.DataSource, record bool) {
+	logger := s.log.New("orgId", ds.OrgID, "datasourceUid", ds.UID, "datasourceType", ds.Type)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := s.now()
+	check := &datasourcehealth.HealthCheck{
+		OrgID:         ds.OrgID,
+		DatasourceUID: ds.UID,
+		Created:       start.UTC(),
+	}
+
+	pCtx, err := s.pCtxProvider.GetWithDataSource(ctx, ds.Type, serviceUser(ds.OrgID), ds)
+	if errors.Is(err, plugins.ErrPluginNotRegistered) {
+		return
+	}
+	if err == nil {
+		var resp *backend.CheckHealthResult
+		resp, err = s.healthChecker.CheckHealth(ctx, &backend.CheckHealthRequest{
+			PluginContext: pCtx,
+			Headers:       map[string]string{},
+		})
+		if errors.Is(err, plugins.ErrMethodNotImplemented) {
+			return
+		}
+		if err == nil {
+			check.Status = resp.Status.String()
+			check.Message = resp.Message
+		}
+	}
+	if err != nil {
+		check.Status = datasourcehealth.StatusError
+		check.Message = err.Error()
+	}
+	latency := s.now().Sub(start)
+	check.LatencyMs = latency.Milliseconds()
+
+	labels := []string{strconv.FormatInt(ds.OrgID, 10), ds.UID, ds.Type}
+	up := 0.0
+	if check.Healthy() {
+		up = 1
+	}
+	s.up.WithLabelValues(labels...).Set(up)
+	s.latency.WithLabelValues(labels...).Set(latency.Seconds())
+	s.checks.WithLabelValues(ds.Type, check.Status).Inc()
+
+	if !record {
+		s.setStatus(ds, check.Status)
+		return
+	}
+	previous, err := s.previousStatus(ctx, ds)
+	if err != nil {
+		logger.Warn("Failed to get the previous health check", "error", err)
+	}
+	if err := s.store.Insert(ctx, check); err != nil {
+		logger.Error("Failed to store health check", "error", err)
+	}
+	s.setStatus(ds, check.Status)
+
+	if previous == "" || (previous == datasourcehealth.StatusOK) == check.Healthy() {
+		return
+	}
+	if check.Healthy() {
+		logger.Info("Data source recovered")
+	} else {
+		logger.Warn("Data source is unhealthy", "status", check.Status, "message", check.Message)
+	}
+	if s.createAnnotations {
+		s.annotate(ctx, ds, check, logger)
+	}
+}
+
+// previousStatus returns the status of the previous health check of a data source, from
+// memory or, after a restart or when another instance recorded it, from the database.
+func (s *Service) previousStatus(ctx context.Context, ds *datasources.DataSource) (string, error) {
+	s.mu.Lock()
+	status, ok := s.statuses[statusKey{orgID: ds.OrgID, uid: ds.UID}]
+	s.mu.Unlock()
+	if ok && s.recorded {
+		return status, nil
+	}
+
+	latest, err := s.store.GetLatest(ctx, ds.OrgID, ds.UID)
+	if err != nil || latest == nil {
+		return "", err
+	}
+	return latest.Status, nil
+}
+
+func (s *Service) setStatus(ds *datasources.DataSource, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[statusKey{orgID: ds.OrgID, uid: ds.UID}] = status
+}
+
+// removeDeleted forgets the data sources that aren't in dss anymore.
+func (s *Service) removeDeleted(dss []*datasources.DataSource) {
+	exists := make(map[statusKey]bool, len(dss))
+	for _, ds := range dss {
+		exists[statusKey{orgID: ds.OrgID, uid: ds.UID}] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.statuses {
+		if exists[key] {
+			continue
+		}
+		delete(s.statuses, key)
+		labels := prometheus.Labels{"org_id": strconv.FormatInt(key.orgID, 10), "datasource_uid": key.uid}
+		s.up.DeletePartialMatch(labels)
+		s.latency.DeletePartialMatch(labels)
+	}
+}
+
+// annotate creates an organization annotation for a change of the health of a data source.
+func (s *Service) annotate(ctx context.Context, ds *datasources.DataSource, check *datasourcehealth.HealthCheck, logger log.Logger) {
+	text := fmt.Sprintf("Data source %s recovered", ds.Name)
+	if !check.Healthy() {
+		text = fmt.Sprintf("Data source %s is unhealthy", ds.Name)
+		if check.Message != "" {
+			text += ": " + check.Message
+		}
+	}
+	epoch := check.Created.UnixMilli()
+	item := &annotations.Item{
+		OrgID:    ds.OrgID,
+		Epoch:    epoch,
+		EpochEnd: epoch,
+		Text:     text,
+		Tags:     []string{datasourcehealth.AnnotationTag, "datasource:" + ds.UID, "status:" + check.Status},
+	}
+	if err := s.annotations.Save(ctx, item); err != nil {
+		logger.Error("Failed to create health annotation", "error", err)
+	}
+}
+
+// serviceUser is the identity of the health checks in the plugin context.
+func serviceUser(orgID int64) *user.SignedInUser {
+	return &user.SignedInUser{
+		OrgID:   orgID,
+		OrgRole: org.RoleAdmin,
+		Login:   "datasource-health",
+	}
+}
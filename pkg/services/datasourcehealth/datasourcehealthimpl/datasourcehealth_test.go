@@ -0,0 +1,245 @@
+package datasourcehealthimpl
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/datasourcehealth"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeRepo records the saved annotations.
+type fakeRepo struct {
+	annotations.Repository
+
+	mu    sync.Mutex
+	items []annotations.Item
+}
+
+func (r *fakeRepo) Save(_ context.Context, item *annotations.Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items = append(r.items, *item)
+	return nil
+}
+
+type fakePluginContextProvider struct{}
+
+func (fakePluginContextProvider) GetWithDataSource(_ context.Context, pluginID string, user identity.Requester, ds *datasources.DataSource) (backend.PluginContext, error) {
+	if pluginID == "unknown" {
+		return backend.PluginContext{}, plugins.ErrPluginNotRegistered
+	}
+	return backend.PluginContext{
+		OrgID:                      user.GetOrgID(),
+		PluginID:                   pluginID,
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: ds.UID},
+	}, nil
+}
+
+// fakeHealthChecker returns the results set by data source UID.
+type fakeHealthChecker struct {
+	mu      sync.Mutex
+	results map[string]*backend.CheckHealthResult
+	errs    map[string]error
+}
+
+func (f *fakeHealthChecker) CheckHealth(_ context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	uid := req.PluginContext.DataSourceInstanceSettings.UID
+	if err, ok := f.errs[uid]; ok {
+		return nil, err
+	}
+	return f.results[uid], nil
+}
+
+func (f *fakeHealthChecker) set(uid string, status backend.HealthStatus, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[uid] = &backend.CheckHealthResult{Status: status, Message: message}
+}
+
+func setupService(t *testing.T, dss []*datasources.DataSource) (*Service, *fakeHealthChecker, *fakeRepo, *fakeDatasources.FakeDataSourceService) {
+	t.Helper()
+	return setupServiceWithDB(t, db.InitTestDB(t), dss)
+}
+
+func setupServiceWithDB(t *testing.T, testDB db.DB, dss []*datasources.DataSource) (*Service, *fakeHealthChecker, *fakeRepo, *fakeDatasources.FakeDataSourceService) {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	section, err := cfg.Raw.NewSection("datasource_health")
+	require.NoError(t, err)
+	_, err = section.NewKey("annotations", "true")
+	require.NoError(t, err)
+
+	dataSources := &fakeDatasources.FakeDataSourceService{DataSources: dss}
+	repo := &fakeRepo{}
+	serverLock := serverlock.ProvideService(testDB, tracing.InitializeTracerForTest())
+	s := ProvideService(cfg, testDB, serverLock, routing.NewRouteRegister(), nil, dataSources, nil, nil, repo, prometheus.NewRegistry())
+	checker := &fakeHealthChecker{results: map[string]*backend.CheckHealthResult{}, errs: map[string]error{}}
+	s.healthChecker = checker
+	s.pCtxProvider = fakePluginContextProvider{}
+	return s, checker, repo, dataSources
+}
+
+func TestIntegrationHealthChecks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	dss := []*datasources.DataSource{
+		{OrgID: 1, UID: "prom", Name: "Prometheus", Type: "prometheus"},
+		{OrgID: 1, UID: "loki", Name: "Loki", Type: "loki"},
+		{OrgID: 1, UID: "csv", Name: "CSV", Type: "csv"},
+		{OrgID: 1, UID: "gone", Name: "Unknown", Type: "unknown"},
+	}
+
+	t.Run("stores the health checks and creates annotations when the health changes", func(t *testing.T) {
+		s, checker, repo, _ := setupService(t, dss)
+		now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+		s.now = func() time.Time { return now }
+		checker.set("prom", backend.HealthStatusOk, "Data source is working")
+		checker.set("loki", backend.HealthStatusOk, "Data source is working")
+		checker.errs["csv"] = plugins.ErrMethodNotImplemented
+
+		s.checkAll(context.Background(), true)
+		require.Empty(t, repo.items, "the first health checks don't create annotations")
+		require.Equal(t, 1.0, testutil.ToFloat64(s.up.WithLabelValues("1", "prom", "prometheus")))
+		require.Equal(t, 2, testutil.CollectAndCount(s.up), "data sources that can't be checked have no metrics")
+
+		now = now.Add(5 * time.Minute)
+		checker.set("prom", backend.HealthStatusError, "connection refused")
+		s.checkAll(context.Background(), true)
+		require.Equal(t, 0.0, testutil.ToFloat64(s.up.WithLabelValues("1", "prom", "prometheus")))
+		require.Len(t, repo.items, 1)
+		require.Equal(t, "Data source Prometheus is unhealthy: connection refused", repo.items[0].Text)
+		require.Equal(t, []string{datasourcehealth.AnnotationTag, "datasource:prom", "status:ERROR"}, repo.items[0].Tags)
+		require.Equal(t, now.UnixMilli(), repo.items[0].Epoch)
+
+		now = now.Add(5 * time.Minute)
+		s.checkAll(context.Background(), true)
+		require.Len(t, repo.items, 1, "no annotation while the data source stays unhealthy")
+
+		now = now.Add(5 * time.Minute)
+		checker.set("prom", backend.HealthStatusOk, "Data source is working")
+		s.checkAll(context.Background(), true)
+		require.Len(t, repo.items, 2)
+		require.Equal(t, "Data source Prometheus recovered", repo.items[1].Text)
+
+		history, err := s.GetHistory(context.Background(), &datasourcehealth.GetHistoryQuery{
+			OrgID:         1,
+			DatasourceUID: "prom",
+			From:          now.Add(-time.Hour),
+			To:            now,
+			Limit:         3,
+		})
+		require.NoError(t, err)
+		require.Equal(t, datasourcehealth.StatusOK, history.Status)
+		require.Len(t, history.Checks, 3)
+		require.Equal(t, datasourcehealth.StatusOK, history.Checks[0].Status)
+		require.Equal(t, "connection refused", history.Checks[1].Message)
+		require.Equal(t, 0.5, history.Uptime, "the uptime counts all the checks of the time range")
+
+		history, err = s.GetHistory(context.Background(), &datasourcehealth.GetHistoryQuery{
+			OrgID: 1, DatasourceUID: "csv", From: now.Add(-time.Hour), To: now,
+		})
+		require.NoError(t, err)
+		require.Empty(t, history.Checks)
+		require.Empty(t, history.Status)
+	})
+
+	t.Run("plugin errors make the data source unhealthy", func(t *testing.T) {
+		s, checker, _, _ := setupService(t, dss[:1])
+		checker.errs["prom"] = errors.New("plugin unavailable")
+
+		s.checkAll(context.Background(), true)
+		latest, err := s.store.GetLatest(context.Background(), 1, "prom")
+		require.NoError(t, err)
+		require.Equal(t, datasourcehealth.StatusError, latest.Status)
+		require.Equal(t, "plugin unavailable", latest.Message)
+	})
+
+	t.Run("detects changes of health across restarts", func(t *testing.T) {
+		s, checker, repo, _ := setupService(t, dss[:1])
+		checker.set("prom", backend.HealthStatusOk, "")
+		s.checkAll(context.Background(), true)
+
+		s.statuses = map[statusKey]string{}
+		checker.set("prom", backend.HealthStatusError, "")
+		s.checkAll(context.Background(), true)
+		require.Len(t, repo.items, 1)
+		require.Equal(t, "Data source Prometheus is unhealthy", repo.items[0].Text)
+	})
+
+	t.Run("removes the metrics of deleted data sources and old health checks", func(t *testing.T) {
+		s, checker, _, dataSources := setupService(t, dss[:2])
+		now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+		s.now = func() time.Time { return now }
+		checker.set("prom", backend.HealthStatusOk, "")
+		checker.set("loki", backend.HealthStatusOk, "")
+		s.checkAll(context.Background(), true)
+		require.Equal(t, 2, testutil.CollectAndCount(s.up))
+
+		now = now.Add(8 * 24 * time.Hour)
+		dataSources.DataSources = dss[:1]
+		s.checkAll(context.Background(), true)
+		require.Equal(t, 1, testutil.CollectAndCount(s.up))
+
+		history, err := s.GetHistory(context.Background(), &datasourcehealth.GetHistoryQuery{
+			OrgID: 1, DatasourceUID: "prom", From: now.Add(-30 * 24 * time.Hour), To: now,
+		})
+		require.NoError(t, err)
+		require.Len(t, history.Checks, 1, "health checks older than the retention are deleted")
+	})
+
+	t.Run("only the instance holding the lock records the health checks", func(t *testing.T) {
+		testDB := db.InitTestDB(t)
+		s, checker, repo, _ := setupServiceWithDB(t, testDB, dss[:1])
+		other, otherChecker, otherRepo, _ := setupServiceWithDB(t, testDB, dss[:1])
+		checker.set("prom", backend.HealthStatusOk, "")
+		otherChecker.set("prom", backend.HealthStatusOk, "")
+
+		s.runChecks(context.Background())
+		other.runChecks(context.Background())
+		require.Equal(t, 1.0, testutil.ToFloat64(other.up.WithLabelValues("1", "prom", "prometheus")), "every instance updates its metrics")
+
+		otherChecker.set("prom", backend.HealthStatusError, "connection refused")
+		other.runChecks(context.Background())
+		require.Equal(t, 0.0, testutil.ToFloat64(other.up.WithLabelValues("1", "prom", "prometheus")))
+		require.Empty(t, otherRepo.items)
+		require.Empty(t, repo.items)
+
+		history, err := s.GetHistory(context.Background(), &datasourcehealth.GetHistoryQuery{
+			OrgID: 1, DatasourceUID: "prom", From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		require.Len(t, history.Checks, 1)
+		require.Equal(t, datasourcehealth.StatusOK, history.Status)
+	})
+
+	t.Run("history of an unknown data source is not found", func(t *testing.T) {
+		s, _, _, _ := setupService(t, dss)
+		_, err := s.GetHistory(context.Background(), &datasourcehealth.GetHistoryQuery{
+			OrgID: 1, DatasourceUID: "missing", From: time.Now().Add(-time.Hour), To: time.Now(),
+		})
+		require.ErrorIs(t, err, datasourcehealth.ErrDataSourceNotFound)
+	})
+}
@@ -0,0 +1,26 @@
+package datasourcehealthimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/datasourcehealth"
+)
+
+type store interface {
+	Insert(ctx context.Context, check *datasourcehealth.HealthCheck) error
+	// GetLatest returns the latest health check of a data source, or nil when it hasn't been checked.
+	GetLatest(ctx context.Context, orgID int64, datasourceUID string) (*datasourcehealth.HealthCheck, error)
+	List(ctx context.Context, query *datasourcehealth.GetHistoryQuery) ([]*datasourcehealth.HealthCheck, error)
+	// GetStats returns the statistics of the health checks in the time range of the query,
+	// regardless of its limit.
+	GetStats(ctx context.Context, query *datasourcehealth.GetHistoryQuery) (*healthStats, error)
+	// DeleteOlderThan deletes the health checks created before t and returns how many were deleted.
+	DeleteOlderThan(ctx context.Context, t time.Time) (int64, error)
+}
+
+type healthStats struct {
+	Checks       int64   `xorm:"checks"`
+	Healthy      int64   `xorm:"healthy"`
+	AvgLatencyMs float64 `xorm:"avg_latency_ms"`
+}
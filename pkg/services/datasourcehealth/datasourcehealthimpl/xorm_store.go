@@ -0,0 +1,73 @@
+package datasourcehealthimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/datasourcehealth"
+)
+
+type sqlStore struct {
+	db db.DB
+}
+
+var _ store = (*sqlStore)(nil)
+
+func (s *sqlStore) Insert(ctx context.Context, check *datasourcehealth.HealthCheck) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(check)
+		return err
+	})
+}
+
+func (s *sqlStore) GetLatest(ctx context.Context, orgID int64, datasourceUID string) (*datasourcehealth.HealthCheck, error) {
+	var check *datasourcehealth.HealthCheck
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		c := &datasourcehealth.HealthCheck{}
+		has, err := sess.Where("org_id = ? AND datasource_uid = ?", orgID, datasourceUID).Desc("created", "id").Get(c)
+		if err != nil {
+			return err
+		}
+		if has {
+			check = c
+		}
+		return nil
+	})
+	return check, err
+}
+
+func (s *sqlStore) List(ctx context.Context, query *datasourcehealth.GetHistoryQuery) ([]*datasourcehealth.HealthCheck, error) {
+	checks := make([]*datasourcehealth.HealthCheck, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id = ? AND datasource_uid = ? AND created >= ? AND created <= ?",
+			query.OrgID, query.DatasourceUID, query.From, query.To).
+			Desc("created", "id").Limit(query.Limit).Find(&checks)
+	})
+	return checks, err
+}
+
+func (s *sqlStore) GetStats(ctx context.Context, query *datasourcehealth.GetHistoryQuery) (*healthStats, error) {
+	stats := &healthStats{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.SQL(`SELECT
+				COUNT(*) AS checks,
+				COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS healthy,
+				COALESCE(AVG(latency_ms), 0) AS avg_latency_ms
+			FROM datasource_health_check
+			WHERE org_id = ? AND datasource_uid = ? AND created >= ? AND created <= ?`,
+			datasourcehealth.StatusOK, query.OrgID, query.DatasourceUID, query.From, query.To).Get(stats)
+		return err
+	})
+	return stats, err
+}
+
+func (s *sqlStore) DeleteOlderThan(ctx context.Context, t time.Time) (int64, error) {
+	var deleted int64
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		deleted, err = sess.Where("created < ?", t).Delete(&datasourcehealth.HealthCheck{})
+		return err
+	})
+	return deleted, err
+}
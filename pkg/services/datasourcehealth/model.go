@@ -0,0 +1,63 @@
+package datasourcehealth
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrDataSourceNotFound = errutil.NotFound("datasourcehealth.datasource-not-found", errutil.WithPublicMessage("Data source not found"))
+	ErrInvalidQuery       = errutil.BadRequest("datasourcehealth.invalid-query")
+)
+
+// Statuses of a health check, as reported by the data source plugin.
+const (
+	StatusOK      = "OK"
+	StatusError   = "ERROR"
+	StatusUnknown = "UNKNOWN"
+)
+
+// AnnotationTag is set on the annotations created when the health of a data source changes.
+const AnnotationTag = "datasource-health"
+
+// HealthCheck is the result of a health check of a data source. The created column is quoted so
+// xorm stores the time of the check instead of the time of the insert.
+type HealthCheck struct {
+	ID            int64     `xorm:"pk autoincr 'id'" json:"-"`
+	OrgID         int64     `xorm:"org_id" json:"-"`
+	DatasourceUID string    `xorm:"datasource_uid" json:"-"`
+	Status        string    `xorm:"status" json:"status"`
+	Message       string    `xorm:"message" json:"message,omitempty"`
+	LatencyMs     int64     `xorm:"latency_ms" json:"latencyMs"`
+	Created       time.Time `xorm:"'created'" json:"created"`
+}
+
+func (c HealthCheck) TableName() string {
+	return "datasource_health_check"
+}
+
+// Healthy reports whether the data source passed the health check.
+func (c *HealthCheck) Healthy() bool {
+	return c.Status == StatusOK
+}
+
+type GetHistoryQuery struct {
+	OrgID         int64
+	DatasourceUID string
+	From          time.Time
+	To            time.Time
+	// Limit is the maximum number of health checks returned, the most recent first.
+	Limit int
+}
+
+// HealthHistory is the health history of a data source over the time range of a GetHistoryQuery.
+type HealthHistory struct {
+	DatasourceUID string `json:"datasourceUid"`
+	// Status is the status of the latest health check, empty when the data source hasn't been checked.
+	Status string `json:"status"`
+	// Uptime is the fraction of the health checks that passed.
+	Uptime       float64        `json:"uptime"`
+	AvgLatencyMs float64        `json:"avgLatencyMs"`
+	Checks       []*HealthCheck `json:"checks"`
+}
@@ -8,6 +8,9 @@ import (
 var (
 	ErrPlaylistNotFound        = errors.New("Playlist not found")
 	ErrCommandValidationFailed = errors.New("command missing required fields")
+	ErrDeviceNotFound          = errors.New("Display device not found")
+	ErrInvalidItemDuration     = errors.New("invalid playlist item duration")
+	ErrInvalidSchedule         = errors.New("invalid display device schedule")
 )
 
 // Playlist model
@@ -68,19 +71,74 @@ type PlaylistItemDTO struct {
 	//  dashboards behind the tag will be added to the playlist.
 	//  - dashboard_by_uid: The value is the dashboard UID
 	Value string `json:"value"`
+
+	// Duration overrides the interval of the playlist for this item.
+	Duration string `json:"duration,omitempty"`
+
+	// TimeFrom and TimeTo override the time range of the dashboards of this item.
+	TimeFrom string `json:"timeFrom,omitempty"`
+	TimeTo   string `json:"timeTo,omitempty"`
+
+	// Variables override template variable values of the dashboards of this item.
+	Variables map[string][]string `json:"variables,omitempty"`
 }
 
 type PlaylistItem struct {
-	Id         int64  `db:"id"`
-	PlaylistId int64  `db:"playlist_id"`
-	Type       string `json:"type" db:"type"`
-	Value      string `json:"value" db:"value"`
-	Order      int    `json:"order" db:"order"`
-	Title      string `json:"title" db:"title"`
+	Id         int64               `db:"id"`
+	PlaylistId int64               `db:"playlist_id"`
+	Type       string              `json:"type" db:"type"`
+	Value      string              `json:"value" db:"value"`
+	Order      int                 `json:"order" db:"order"`
+	Title      string              `json:"title" db:"title"`
+	Duration   string              `json:"duration" db:"duration"`
+	TimeFrom   string              `json:"timeFrom" db:"time_from"`
+	TimeTo     string              `json:"timeTo" db:"time_to"`
+	Variables  map[string][]string `json:"variables" db:"variables"`
 }
 
 type Playlists []*Playlist
 
+// DisplayDevice is a named screen, such as a NOC display, that polls the server for the playlist
+// it should show. The schedule picks a different playlist at some times of the day, and the
+// default playlist is shown the rest of the time.
+type DisplayDevice struct {
+	Id          int64           `json:"-" db:"id"`
+	UID         string          `json:"uid" xorm:"uid" db:"uid"`
+	OrgId       int64           `json:"-" db:"org_id"`
+	Name        string          `json:"name" db:"name"`
+	PlaylistUID string          `json:"playlistUid" xorm:"playlist_uid" db:"playlist_uid"`
+	Timezone    string          `json:"timezone" db:"timezone"`
+	Schedule    []ScheduleEntry `json:"schedule" db:"schedule"`
+	// LastSeenAt is the last time the device polled for its playlist, in epoch milliseconds
+	LastSeenAt int64 `json:"lastSeenAt" db:"last_seen_at"`
+	CreatedAt  int64 `json:"-" db:"created_at"`
+	UpdatedAt  int64 `json:"-" db:"updated_at"`
+}
+
+func (d DisplayDevice) TableName() string {
+	return "playlist_display_device"
+}
+
+// ScheduleEntry shows a playlist between two times of the day, in the time zone of the device.
+// An entry whose end is before its start runs past midnight.
+type ScheduleEntry struct {
+	PlaylistUID string `json:"playlistUid"`
+	// Days the entry starts on: mon, tue, wed, thu, fri, sat or sun. Every day when empty.
+	Days []string `json:"days,omitempty"`
+	// Start and End are times of the day formatted as 15:04
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// DisplayDevicePlaylist is the playlist a display device should show now
+type DisplayDevicePlaylist struct {
+	DeviceUID string       `json:"deviceUid"`
+	Playlist  *PlaylistDTO `json:"playlist"`
+	// NextChange is when the schedule switches to another playlist, in epoch milliseconds. The
+	// device should poll again at that time. 0 when the playlist never changes.
+	NextChange int64 `json:"nextChange"`
+}
+
 //
 // COMMANDS
 //
@@ -107,6 +165,30 @@ type DeletePlaylistCommand struct {
 	OrgId int64
 }
 
+type CreateDisplayDeviceCommand struct {
+	Name        string          `json:"name" binding:"Required"`
+	PlaylistUID string          `json:"playlistUid" binding:"Required"`
+	Timezone    string          `json:"timezone"`
+	Schedule    []ScheduleEntry `json:"schedule"`
+	OrgId       int64           `json:"-"`
+	// Used to create devices from kubectl with a known uid/name
+	UID string `json:"-"`
+}
+
+type UpdateDisplayDeviceCommand struct {
+	OrgId       int64           `json:"-"`
+	UID         string          `json:"-"`
+	Name        string          `json:"name" binding:"Required"`
+	PlaylistUID string          `json:"playlistUid" binding:"Required"`
+	Timezone    string          `json:"timezone"`
+	Schedule    []ScheduleEntry `json:"schedule"`
+}
+
+type DeleteDisplayDeviceCommand struct {
+	UID   string
+	OrgId int64
+}
+
 //
 // QUERIES
 //
@@ -127,3 +209,14 @@ type GetPlaylistItemsByUidQuery struct {
 	PlaylistUID string
 	OrgId       int64
 }
+
+type GetDisplayDeviceQuery struct {
+	UID   string
+	OrgId int64
+}
+
+type GetDisplayDevicesQuery struct {
+	Name  string
+	Limit int
+	OrgId int64
+}
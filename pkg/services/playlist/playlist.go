@@ -11,4 +11,13 @@ type Service interface {
 	Get(context.Context, *GetPlaylistByUidQuery) (*PlaylistDTO, error)
 	Search(context.Context, *GetPlaylistsQuery) (Playlists, error)
 	Delete(ctx context.Context, cmd *DeletePlaylistCommand) error
+
+	CreateDevice(context.Context, *CreateDisplayDeviceCommand) (*DisplayDevice, error)
+	UpdateDevice(context.Context, *UpdateDisplayDeviceCommand) (*DisplayDevice, error)
+	GetDevice(context.Context, *GetDisplayDeviceQuery) (*DisplayDevice, error)
+	SearchDevices(context.Context, *GetDisplayDevicesQuery) ([]*DisplayDevice, error)
+	DeleteDevice(context.Context, *DeleteDisplayDeviceCommand) error
+	// GetDevicePlaylist returns the playlist the device should show now, and records that the
+	// device polled
+	GetDevicePlaylist(context.Context, *GetDisplayDeviceQuery) (*DisplayDevicePlaylist, error)
 }
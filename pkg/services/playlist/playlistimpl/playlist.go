@@ -2,6 +2,7 @@ package playlistimpl
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/tracing"
@@ -11,6 +12,7 @@ import (
 type Service struct {
 	store  store
 	tracer tracing.Tracer
+	now    func() time.Time
 }
 
 var _ playlist.Service = &Service{}
@@ -18,6 +20,7 @@ var _ playlist.Service = &Service{}
 func ProvideService(db db.DB, tracer tracing.Tracer) playlist.Service {
 	return &Service{
 		tracer: tracer,
+		now:    time.Now,
 		store: &sqlStore{
 			db: db,
 		},
@@ -27,12 +30,18 @@ func ProvideService(db db.DB, tracer tracing.Tracer) playlist.Service {
 func (s *Service) Create(ctx context.Context, cmd *playlist.CreatePlaylistCommand) (*playlist.Playlist, error) {
 	ctx, span := s.tracer.Start(ctx, "playlists.Create")
 	defer span.End()
+	if err := playlist.ValidateItems(cmd.Items); err != nil {
+		return nil, err
+	}
 	return s.store.Insert(ctx, cmd)
 }
 
 func (s *Service) Update(ctx context.Context, cmd *playlist.UpdatePlaylistCommand) (*playlist.PlaylistDTO, error) {
 	ctx, span := s.tracer.Start(ctx, "playlists.Update")
 	defer span.End()
+	if err := playlist.ValidateItems(cmd.Items); err != nil {
+		return nil, err
+	}
 	return s.store.Update(ctx, cmd)
 }
 
@@ -60,6 +69,10 @@ func (s *Service) Get(ctx context.Context, q *playlist.GetPlaylistByUidQuery) (*
 	for i := 0; i < len(rawItems); i++ {
 		items[i].Type = rawItems[i].Type
 		items[i].Value = rawItems[i].Value
+		items[i].Duration = rawItems[i].Duration
+		items[i].TimeFrom = rawItems[i].TimeFrom
+		items[i].TimeTo = rawItems[i].TimeTo
+		items[i].Variables = rawItems[i].Variables
 
 		// Add the unused title to the result
 		title := rawItems[i].Title
@@ -90,3 +103,82 @@ func (s *Service) Delete(ctx context.Context, cmd *playlist.DeletePlaylistComman
 	defer span.End()
 	return s.store.Delete(ctx, cmd)
 }
+
+func (s *Service) CreateDevice(ctx context.Context, cmd *playlist.CreateDisplayDeviceCommand) (*playlist.DisplayDevice, error) {
+	ctx, span := s.tracer.Start(ctx, "playlists.CreateDevice")
+	defer span.End()
+	if err := s.validateDevice(ctx, cmd.OrgId, cmd.PlaylistUID, cmd.Timezone, cmd.Schedule); err != nil {
+		return nil, err
+	}
+	return s.store.InsertDevice(ctx, cmd)
+}
+
+func (s *Service) UpdateDevice(ctx context.Context, cmd *playlist.UpdateDisplayDeviceCommand) (*playlist.DisplayDevice, error) {
+	ctx, span := s.tracer.Start(ctx, "playlists.UpdateDevice")
+	defer span.End()
+	if err := s.validateDevice(ctx, cmd.OrgId, cmd.PlaylistUID, cmd.Timezone, cmd.Schedule); err != nil {
+		return nil, err
+	}
+	return s.store.UpdateDevice(ctx, cmd)
+}
+
+func (s *Service) GetDevice(ctx context.Context, q *playlist.GetDisplayDeviceQuery) (*playlist.DisplayDevice, error) {
+	ctx, span := s.tracer.Start(ctx, "playlists.GetDevice")
+	defer span.End()
+	return s.store.GetDevice(ctx, q)
+}
+
+func (s *Service) SearchDevices(ctx context.Context, q *playlist.GetDisplayDevicesQuery) ([]*playlist.DisplayDevice, error) {
+	ctx, span := s.tracer.Start(ctx, "playlists.SearchDevices")
+	defer span.End()
+	return s.store.ListDevices(ctx, q)
+}
+
+func (s *Service) DeleteDevice(ctx context.Context, cmd *playlist.DeleteDisplayDeviceCommand) error {
+	ctx, span := s.tracer.Start(ctx, "playlists.DeleteDevice")
+	defer span.End()
+	return s.store.DeleteDevice(ctx, cmd)
+}
+
+func (s *Service) GetDevicePlaylist(ctx context.Context, q *playlist.GetDisplayDeviceQuery) (*playlist.DisplayDevicePlaylist, error) {
+	ctx, span := s.tracer.Start(ctx, "playlists.GetDevicePlaylist")
+	defer span.End()
+
+	device, err := s.store.GetDevice(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	dto, err := s.Get(ctx, &playlist.GetPlaylistByUidQuery{UID: device.ActivePlaylistUID(now), OrgId: q.OrgId})
+	if err != nil {
+		return nil, err
+	}
+
+	// Failure to record the poll should still return the playlist
+	_ = s.store.UpdateDeviceLastSeenAt(ctx, device.Id, now.UnixMilli())
+
+	result := &playlist.DisplayDevicePlaylist{DeviceUID: device.UID, Playlist: dto}
+	if next := device.NextChange(now); !next.IsZero() {
+		result.NextChange = next.UnixMilli()
+	}
+	return result, nil
+}
+
+// validateDevice checks the schedule of a device and that the playlists it shows exist
+func (s *Service) validateDevice(ctx context.Context, orgID int64, playlistUID string, timezone string, schedule []playlist.ScheduleEntry) error {
+	if err := playlist.ValidateSchedule(timezone, schedule); err != nil {
+		return err
+	}
+
+	uids := []string{playlistUID}
+	for _, entry := range schedule {
+		uids = append(uids, entry.PlaylistUID)
+	}
+	for _, uid := range uids {
+		if _, err := s.store.Get(ctx, &playlist.GetPlaylistByUidQuery{UID: uid, OrgId: orgID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
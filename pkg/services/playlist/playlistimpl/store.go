@@ -13,4 +13,11 @@ type store interface {
 	GetItems(context.Context, *playlist.GetPlaylistItemsByUidQuery) ([]playlist.PlaylistItem, error)
 	List(context.Context, *playlist.GetPlaylistsQuery) (playlist.Playlists, error)
 	Update(context.Context, *playlist.UpdatePlaylistCommand) (*playlist.PlaylistDTO, error)
+
+	InsertDevice(context.Context, *playlist.CreateDisplayDeviceCommand) (*playlist.DisplayDevice, error)
+	UpdateDevice(context.Context, *playlist.UpdateDisplayDeviceCommand) (*playlist.DisplayDevice, error)
+	GetDevice(context.Context, *playlist.GetDisplayDeviceQuery) (*playlist.DisplayDevice, error)
+	ListDevices(context.Context, *playlist.GetDisplayDevicesQuery) ([]*playlist.DisplayDevice, error)
+	DeleteDevice(context.Context, *playlist.DeleteDisplayDeviceCommand) error
+	UpdateDeviceLastSeenAt(ctx context.Context, id int64, lastSeenAt int64) error
 }
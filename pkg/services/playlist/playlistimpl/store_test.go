@@ -139,6 +139,71 @@ func testIntegrationPlaylistDataAccess(t *testing.T, fn getStore) {
 		})
 	})
 
+	t.Run("Can store per-item overrides", func(t *testing.T) {
+		items := []playlist.PlaylistItem{
+			{Value: "noc", Type: "dashboard_by_uid", Duration: "2m", TimeFrom: "now-24h", TimeTo: "now",
+				Variables: map[string][]string{"env": {"prod", "staging"}}},
+		}
+		cmd := playlist.CreatePlaylistCommand{Name: "NOC", Interval: "10m", OrgId: 1, Items: items}
+		p, err := playlistStore.Insert(context.Background(), &cmd)
+		require.NoError(t, err)
+
+		storedItems, err := playlistStore.GetItems(context.Background(), &playlist.GetPlaylistItemsByUidQuery{PlaylistUID: p.UID, OrgId: 1})
+		require.NoError(t, err)
+		require.Len(t, storedItems, 1)
+		require.Equal(t, "2m", storedItems[0].Duration)
+		require.Equal(t, "now-24h", storedItems[0].TimeFrom)
+		require.Equal(t, "now", storedItems[0].TimeTo)
+		require.Equal(t, map[string][]string{"env": {"prod", "staging"}}, storedItems[0].Variables)
+	})
+
+	t.Run("Can create display device", func(t *testing.T) {
+		schedule := []playlist.ScheduleEntry{{PlaylistUID: "night", Start: "20:00", End: "06:00"}}
+		cmd := playlist.CreateDisplayDeviceCommand{Name: "NOC wall", PlaylistUID: "day", Timezone: "Europe/Paris", Schedule: schedule, OrgId: 1}
+		d, err := playlistStore.InsertDevice(context.Background(), &cmd)
+		require.NoError(t, err)
+		require.NotEmpty(t, d.UID)
+
+		t.Run("Can get display device", func(t *testing.T) {
+			stored, err := playlistStore.GetDevice(context.Background(), &playlist.GetDisplayDeviceQuery{UID: d.UID, OrgId: 1})
+			require.NoError(t, err)
+			require.Equal(t, "NOC wall", stored.Name)
+			require.Equal(t, schedule, stored.Schedule)
+
+			_, err = playlistStore.GetDevice(context.Background(), &playlist.GetDisplayDeviceQuery{UID: d.UID, OrgId: 2})
+			require.ErrorIs(t, err, playlist.ErrDeviceNotFound)
+		})
+
+		t.Run("Can update display device", func(t *testing.T) {
+			update := playlist.UpdateDisplayDeviceCommand{UID: d.UID, OrgId: 1, Name: "Lobby", PlaylistUID: "lobby", Timezone: "UTC"}
+			_, err := playlistStore.UpdateDevice(context.Background(), &update)
+			require.NoError(t, err)
+
+			require.NoError(t, playlistStore.UpdateDeviceLastSeenAt(context.Background(), d.Id, 1234))
+
+			stored, err := playlistStore.GetDevice(context.Background(), &playlist.GetDisplayDeviceQuery{UID: d.UID, OrgId: 1})
+			require.NoError(t, err)
+			require.Equal(t, "Lobby", stored.Name)
+			require.Equal(t, "lobby", stored.PlaylistUID)
+			require.Empty(t, stored.Schedule)
+			require.Equal(t, int64(1234), stored.LastSeenAt)
+		})
+
+		t.Run("Can search display devices", func(t *testing.T) {
+			res, err := playlistStore.ListDevices(context.Background(), &playlist.GetDisplayDevicesQuery{Name: "Lob", Limit: 10, OrgId: 1})
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+		})
+
+		t.Run("Can remove display device", func(t *testing.T) {
+			err := playlistStore.DeleteDevice(context.Background(), &playlist.DeleteDisplayDeviceCommand{UID: d.UID, OrgId: 1})
+			require.NoError(t, err)
+
+			_, err = playlistStore.GetDevice(context.Background(), &playlist.GetDisplayDeviceQuery{UID: d.UID, OrgId: 1})
+			require.ErrorIs(t, err, playlist.ErrDeviceNotFound)
+		})
+	})
+
 	t.Run("Delete playlist that doesn't exist, should not return error", func(t *testing.T) {
 		deleteQuery := playlist.DeletePlaylistCommand{UID: "654312", OrgId: 1}
 		err := playlistStore.Delete(context.Background(), &deleteQuery)
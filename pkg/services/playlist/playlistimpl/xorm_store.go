@@ -51,6 +51,10 @@ func (s *sqlStore) Insert(ctx context.Context, cmd *playlist.CreatePlaylistComma
 				Value:      item.Value,
 				Order:      order + 1,
 				Title:      item.Title,
+				Duration:   item.Duration,
+				TimeFrom:   item.TimeFrom,
+				TimeTo:     item.TimeTo,
+				Variables:  item.Variables,
 			})
 		}
 
@@ -107,6 +111,10 @@ func (s *sqlStore) Update(ctx context.Context, cmd *playlist.UpdatePlaylistComma
 				Value:      item.Value,
 				Order:      index + 1,
 				Title:      item.Title,
+				Duration:   item.Duration,
+				TimeFrom:   item.TimeFrom,
+				TimeTo:     item.TimeTo,
+				Variables:  item.Variables,
 			})
 		}
 
@@ -199,3 +207,112 @@ func (s *sqlStore) GetItems(ctx context.Context, query *playlist.GetPlaylistItem
 	})
 	return playlistItems, err
 }
+
+func (s *sqlStore) InsertDevice(ctx context.Context, cmd *playlist.CreateDisplayDeviceCommand) (*playlist.DisplayDevice, error) {
+	if cmd.UID == "" {
+		cmd.UID = util.GenerateShortUID()
+	} else {
+		err := util.ValidateUID(cmd.UID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ts := time.Now().UnixMilli()
+	d := playlist.DisplayDevice{
+		UID:         cmd.UID,
+		OrgId:       cmd.OrgId,
+		Name:        cmd.Name,
+		PlaylistUID: cmd.PlaylistUID,
+		Timezone:    cmd.Timezone,
+		Schedule:    cmd.Schedule,
+		CreatedAt:   ts,
+		UpdatedAt:   ts,
+	}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(&d)
+		return err
+	})
+	return &d, err
+}
+
+func (s *sqlStore) UpdateDevice(ctx context.Context, cmd *playlist.UpdateDisplayDeviceCommand) (*playlist.DisplayDevice, error) {
+	d := playlist.DisplayDevice{}
+	err := s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		d = playlist.DisplayDevice{UID: cmd.UID, OrgId: cmd.OrgId}
+		exists, err := sess.Get(&d)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return playlist.ErrDeviceNotFound
+		}
+
+		d.Name = cmd.Name
+		d.PlaylistUID = cmd.PlaylistUID
+		d.Timezone = cmd.Timezone
+		d.Schedule = cmd.Schedule
+		d.UpdatedAt = time.Now().UnixMilli()
+
+		_, err = sess.Where("id=?", d.Id).Cols("name", "playlist_uid", "timezone", "schedule", "updated_at").Update(&d)
+		return err
+	})
+	return &d, err
+}
+
+func (s *sqlStore) GetDevice(ctx context.Context, query *playlist.GetDisplayDeviceQuery) (*playlist.DisplayDevice, error) {
+	if query.UID == "" || query.OrgId == 0 {
+		return nil, playlist.ErrCommandValidationFailed
+	}
+
+	d := playlist.DisplayDevice{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		d = playlist.DisplayDevice{UID: query.UID, OrgId: query.OrgId}
+		exists, err := sess.Get(&d)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return playlist.ErrDeviceNotFound
+		}
+		return nil
+	})
+	return &d, err
+}
+
+func (s *sqlStore) ListDevices(ctx context.Context, query *playlist.GetDisplayDevicesQuery) ([]*playlist.DisplayDevice, error) {
+	devices := make([]*playlist.DisplayDevice, 0)
+	if query.OrgId == 0 {
+		return devices, playlist.ErrCommandValidationFailed
+	}
+
+	err := s.db.WithDbSession(ctx, func(dbSess *db.Session) error {
+		sess := dbSess.Limit(query.Limit)
+
+		if query.Name != "" {
+			sess.Where("name LIKE ?", "%"+query.Name+"%")
+		}
+
+		sess.Where("org_id = ?", query.OrgId)
+		return sess.Asc("name").Find(&devices)
+	})
+	return devices, err
+}
+
+func (s *sqlStore) DeleteDevice(ctx context.Context, cmd *playlist.DeleteDisplayDeviceCommand) error {
+	if cmd.UID == "" || cmd.OrgId == 0 {
+		return playlist.ErrCommandValidationFailed
+	}
+
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM playlist_display_device WHERE uid = ? and org_id = ?", cmd.UID, cmd.OrgId)
+		return err
+	})
+}
+
+func (s *sqlStore) UpdateDeviceLastSeenAt(ctx context.Context, id int64, lastSeenAt int64) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("UPDATE playlist_display_device SET last_seen_at = ? WHERE id = ?", lastSeenAt, id)
+		return err
+	})
+}
@@ -0,0 +1,155 @@
+package playlist
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+var scheduleDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ValidateItems checks the per-item overrides of playlist items
+func ValidateItems(items []PlaylistItem) error {
+	for _, item := range items {
+		if item.Duration == "" {
+			continue
+		}
+		if duration, err := gtime.ParseDuration(item.Duration); err != nil || duration <= 0 {
+			return fmt.Errorf("%w: %q", ErrInvalidItemDuration, item.Duration)
+		}
+	}
+	return nil
+}
+
+// ValidateSchedule checks the time zone and schedule entries of a display device
+func ValidateSchedule(timezone string, schedule []ScheduleEntry) error {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("%w: unknown time zone %q", ErrInvalidSchedule, timezone)
+	}
+	for _, entry := range schedule {
+		if entry.PlaylistUID == "" {
+			return fmt.Errorf("%w: missing playlist", ErrInvalidSchedule)
+		}
+		if _, err := parseTimeOfDay(entry.Start); err != nil {
+			return fmt.Errorf("%w: invalid start %q", ErrInvalidSchedule, entry.Start)
+		}
+		if _, err := parseTimeOfDay(entry.End); err != nil {
+			return fmt.Errorf("%w: invalid end %q", ErrInvalidSchedule, entry.End)
+		}
+		for _, day := range entry.Days {
+			if _, ok := scheduleDays[day]; !ok {
+				return fmt.Errorf("%w: invalid day %q", ErrInvalidSchedule, day)
+			}
+		}
+	}
+	return nil
+}
+
+// ActivePlaylistUID returns the playlist the device shows at the given time: the playlist of the
+// first schedule entry that is active, or the default playlist of the device
+func (d DisplayDevice) ActivePlaylistUID(now time.Time) string {
+	t := now.In(d.location())
+	for _, entry := range d.Schedule {
+		if entry.activeAt(t) {
+			return entry.PlaylistUID
+		}
+	}
+	return d.PlaylistUID
+}
+
+// NextChange returns the next time the device switches to another playlist, or the zero time if
+// it shows the same playlist for the next week
+func (d DisplayDevice) NextChange(now time.Time) time.Time {
+	loc := d.location()
+	local := now.In(loc)
+	current := d.ActivePlaylistUID(now)
+
+	var candidates []time.Time
+	for offset := 0; offset <= 7; offset++ {
+		for _, entry := range d.Schedule {
+			for _, timeOfDay := range []string{entry.Start, entry.End} {
+				minutes, err := parseTimeOfDay(timeOfDay)
+				if err != nil {
+					continue
+				}
+				candidate := time.Date(local.Year(), local.Month(), local.Day()+offset, minutes/60, minutes%60, 0, 0, loc)
+				if candidate.After(now) {
+					candidates = append(candidates, candidate)
+				}
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	for _, candidate := range candidates {
+		if d.ActivePlaylistUID(candidate) != current {
+			return candidate
+		}
+	}
+	return time.Time{}
+}
+
+func (d DisplayDevice) location() *time.Location {
+	loc, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// activeAt returns true if the entry is active at the given time, in the time zone of the device
+func (e ScheduleEntry) activeAt(t time.Time) bool {
+	start, err := parseTimeOfDay(e.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseTimeOfDay(e.End)
+	if err != nil {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+
+	if start < end {
+		return minute >= start && minute < end && e.onDay(t.Weekday())
+	}
+
+	// the entry runs past midnight, the part after midnight belongs to the day before
+	if minute >= start {
+		return e.onDay(t.Weekday())
+	}
+	if minute < end {
+		return e.onDay((t.Weekday() + 6) % 7)
+	}
+	return false
+}
+
+func (e ScheduleEntry) onDay(day time.Weekday) bool {
+	if len(e.Days) == 0 {
+		return true
+	}
+	for _, d := range e.Days {
+		if scheduleDays[d] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimeOfDay returns the minutes since midnight of a time formatted as 15:04
+func parseTimeOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,96 @@
+package playlist
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayDeviceSchedule(t *testing.T) {
+	device := DisplayDevice{
+		PlaylistUID: "day",
+		Timezone:    "Europe/Paris",
+		Schedule: []ScheduleEntry{
+			{PlaylistUID: "weekend", Days: []string{"sat", "sun"}, Start: "00:00", End: "00:00"},
+			{PlaylistUID: "night", Start: "20:00", End: "06:00"},
+		},
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		now      time.Time
+		expected string
+		next     time.Time
+	}{
+		{
+			desc:     "default playlist during the day",
+			now:      time.Date(2023, time.October, 4, 12, 0, 0, 0, paris), // Wednesday
+			expected: "day",
+			next:     time.Date(2023, time.October, 4, 20, 0, 0, 0, paris),
+		},
+		{
+			desc:     "night playlist in the evening",
+			now:      time.Date(2023, time.October, 4, 22, 30, 0, 0, paris),
+			expected: "night",
+			next:     time.Date(2023, time.October, 5, 6, 0, 0, 0, paris),
+		},
+		{
+			desc:     "night playlist after midnight",
+			now:      time.Date(2023, time.October, 5, 5, 59, 0, 0, paris),
+			expected: "night",
+			next:     time.Date(2023, time.October, 5, 6, 0, 0, 0, paris),
+		},
+		{
+			desc:     "the first active entry wins",
+			now:      time.Date(2023, time.October, 7, 22, 0, 0, 0, paris), // Saturday
+			expected: "weekend",
+			next:     time.Date(2023, time.October, 9, 0, 0, 0, 0, paris),
+		},
+		{
+			desc:     "uses the time zone of the device",
+			now:      time.Date(2023, time.October, 4, 19, 0, 0, 0, time.UTC),
+			expected: "night",
+			next:     time.Date(2023, time.October, 5, 6, 0, 0, 0, paris),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, device.ActivePlaylistUID(tc.now))
+			require.True(t, tc.next.Equal(device.NextChange(tc.now)), "next change %s", device.NextChange(tc.now))
+		})
+	}
+
+	t.Run("never changes without a schedule", func(t *testing.T) {
+		device := DisplayDevice{PlaylistUID: "day"}
+		require.Equal(t, "day", device.ActivePlaylistUID(time.Now()))
+		require.True(t, device.NextChange(time.Now()).IsZero())
+	})
+}
+
+func TestValidateSchedule(t *testing.T) {
+	require.NoError(t, ValidateSchedule("", []ScheduleEntry{{PlaylistUID: "a", Days: []string{"mon"}, Start: "08:00", End: "17:30"}}))
+
+	invalid := map[string][]ScheduleEntry{
+		"missing playlist": {{Start: "08:00", End: "17:00"}},
+		"invalid start":    {{PlaylistUID: "a", Start: "8am", End: "17:00"}},
+		"invalid end":      {{PlaylistUID: "a", Start: "08:00", End: "24:00"}},
+		"invalid day":      {{PlaylistUID: "a", Days: []string{"monday"}, Start: "08:00", End: "17:00"}},
+	}
+	for desc, schedule := range invalid {
+		t.Run(desc, func(t *testing.T) {
+			require.True(t, errors.Is(ValidateSchedule("UTC", schedule), ErrInvalidSchedule))
+		})
+	}
+
+	require.True(t, errors.Is(ValidateSchedule("Mars/Olympus", nil), ErrInvalidSchedule))
+}
+
+func TestValidateItems(t *testing.T) {
+	require.NoError(t, ValidateItems([]PlaylistItem{{Duration: ""}, {Duration: "90s"}, {Duration: "1h"}}))
+	require.ErrorIs(t, ValidateItems([]PlaylistItem{{Duration: "soon"}}), ErrInvalidItemDuration)
+	require.ErrorIs(t, ValidateItems([]PlaylistItem{{Duration: "0s"}}), ErrInvalidItemDuration)
+}
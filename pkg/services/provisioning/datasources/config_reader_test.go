@@ -31,6 +31,8 @@ var (
 
 	oneDatasourceWithTwoCorrelations   = "testdata/one-datasource-two-correlations"
 	correlationsDifferentOrganizations = "testdata/correlations-different-organizations"
+	correlationTypes                   = "testdata/correlation-types"
+	invalidCorrelationConfig           = "testdata/invalid-correlation-config"
 )
 
 func TestDatasourceAsConfig(t *testing.T) {
@@ -256,6 +258,40 @@ func TestDatasourceAsConfig(t *testing.T) {
 			require.Equal(t, true, correlationsStore.deletedBySourceUID[0].OnlyProvisioned)
 		})
 
+		t.Run("Creates correlations of all types", func(t *testing.T) {
+			store := &spyStore{}
+			orgFake := &orgtest.FakeOrgService{}
+			correlationsStore := &mockCorrelationsStore{}
+			dc := newDatasourceProvisioner(logger, store, correlationsStore, orgFake)
+			err := dc.applyChanges(context.Background(), correlationTypes)
+			require.NoError(t, err)
+
+			require.Len(t, correlationsStore.created, 3)
+			query, external, dashboard := correlationsStore.created[0], correlationsStore.created[1], correlationsStore.created[2]
+
+			require.Equal(t, correlations.ConfigTypeQuery, query.Config.Type)
+			require.Equal(t, correlations.Transformations{{Type: correlations.TransformationJSONPath, Field: "body", Expression: "$.trace.id", MapValue: "traceId"}}, query.Config.Transformations)
+
+			require.Equal(t, correlations.ConfigTypeExternal, external.Config.Type)
+			require.Nil(t, external.TargetUID)
+			require.Equal(t, "https://incidents.example.com/services/${service}?env=${env}", external.Config.Target["url"])
+			require.Equal(t, correlations.Transformations{{Type: correlations.TransformationLabel, Expression: "env"}}, external.Config.Transformations)
+
+			require.Equal(t, correlations.ConfigTypeDashboard, dashboard.Config.Type)
+			require.Equal(t, "service-overview", dashboard.Config.Target["dashboardUID"])
+			require.Equal(t, map[string]any{"service": "${service}", "env": "${env}"}, dashboard.Config.Target["variables"])
+		})
+
+		t.Run("Fails on invalid correlation configs", func(t *testing.T) {
+			store := &spyStore{}
+			orgFake := &orgtest.FakeOrgService{}
+			correlationsStore := &mockCorrelationsStore{}
+			dc := newDatasourceProvisioner(logger, store, correlationsStore, orgFake)
+			err := dc.applyChanges(context.Background(), invalidCorrelationConfig)
+			require.ErrorIs(t, err, correlations.ErrInvalidTarget)
+			require.Empty(t, correlationsStore.created)
+		})
+
 		t.Run("Updating existing datasource deletes existing correlations and creates two", func(t *testing.T) {
 			store := &spyStore{items: []*datasources.DataSource{{Name: "Graphite", OrgID: 1, ID: 1}}}
 			orgFake := &orgtest.FakeOrgService{}
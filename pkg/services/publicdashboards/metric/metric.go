@@ -35,15 +35,26 @@ func ProvideService(
 }
 
 func (s *Service) registerMetrics(prom prometheus.Registerer) error {
-	err := prom.Register(s.Metrics.PublicDashboardsAmount)
-	var alreadyRegisterErr prometheus.AlreadyRegisteredError
-	if errors.As(err, &alreadyRegisterErr) {
-		if alreadyRegisterErr.ExistingCollector == alreadyRegisterErr.NewCollector {
-			err = nil
+	for _, collector := range []prometheus.Collector{s.Metrics.PublicDashboardsAmount, s.Metrics.RejectedRequests} {
+		err := prom.Register(collector)
+		var alreadyRegisterErr prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegisterErr) {
+			if alreadyRegisterErr.ExistingCollector == alreadyRegisterErr.NewCollector {
+				err = nil
+			}
+		}
+		if err != nil {
+			return err
 		}
 	}
 
-	return err
+	return nil
+}
+
+// RecordRejectedRequest counts a request rejected because the public dashboard expired, the client
+// address is not allowed or the query rate limit was exceeded
+func (s *Service) RecordRejectedRequest(reason string) {
+	s.Metrics.RejectedRequests.WithLabelValues(reason).Inc()
 }
 
 func (s *Service) Run(ctx context.Context) error {
@@ -8,8 +8,16 @@ const (
 	namespace = "grafana"
 )
 
+// Reasons a request to a public dashboard was rejected
+const (
+	RejectReasonExpired      = "expired"
+	RejectReasonIPNotAllowed = "ip_not_allowed"
+	RejectReasonRateLimited  = "rate_limited"
+)
+
 type Metrics struct {
 	PublicDashboardsAmount *prometheus.GaugeVec
+	RejectedRequests       *prometheus.CounterVec
 }
 
 func newMetrics() *Metrics {
@@ -19,5 +27,10 @@ func newMetrics() *Metrics {
 			Name:      "public_dashboards_amount",
 			Help:      "Total amount of public dashboards",
 		}, []string{"is_enabled", "share_type"}),
+		RejectedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "public_dashboards_rejected_requests_total",
+			Help:      "Total amount of public dashboard requests rejected by access controls",
+		}, []string{"reason"}),
 	}
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"net"
 	"time"
 
 	"github.com/grafana/grafana/pkg/kinds/dashboard"
@@ -53,6 +54,10 @@ type PublicDashboard struct {
 	AnnotationsEnabled   bool          `json:"annotationsEnabled" xorm:"annotations_enabled"`
 	Share                ShareType     `json:"share" xorm:"share"`
 	Recipients           []EmailDTO    `json:"recipients,omitempty" xorm:"-"`
+	//access control fields
+	ExpiresAt      int64    `json:"expiresAt" xorm:"expires_at"` // epoch milliseconds, 0 if it never expires
+	AllowedIPs     []string `json:"allowedIps" xorm:"allowed_ips"`
+	QueryRateLimit int64    `json:"queryRateLimit" xorm:"query_rate_limit"` // queries per minute, 0 if unlimited
 }
 
 type PublicDashboardDTO struct {
@@ -62,6 +67,9 @@ type PublicDashboardDTO struct {
 	IsEnabled            *bool     `json:"isEnabled"`
 	AnnotationsEnabled   *bool     `json:"annotationsEnabled"`
 	Share                ShareType `json:"share"`
+	ExpiresAt            *int64    `json:"expiresAt"`
+	AllowedIPs           []string  `json:"allowedIps"`
+	QueryRateLimit       *int64    `json:"queryRateLimit"`
 }
 
 type EmailDTO struct {
@@ -95,6 +103,37 @@ func (pd PublicDashboard) TableName() string {
 	return "dashboard_public"
 }
 
+// IsExpired returns true if the public dashboard has an expiry time and it has passed
+func (pd PublicDashboard) IsExpired(now time.Time) bool {
+	return pd.ExpiresAt > 0 && now.UnixMilli() >= pd.ExpiresAt
+}
+
+// AllowsIP returns true if the public dashboard can be viewed from the address. Every address is
+// allowed when the allow-list is empty. Entries are either single addresses or CIDR ranges.
+func (pd PublicDashboard) AllowsIP(addr string) bool {
+	if len(pd.AllowedIPs) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, allowed := range pd.AllowedIPs {
+		if _, network, err := net.ParseCIDR(allowed); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 type PublicDashboardListQuery struct {
 	OrgID  int64
 	Query  string
@@ -152,6 +191,26 @@ type PublicDashboardQueryDTO struct {
 	MaxDataPoints   int64
 	QueryCachingTTL int64
 	TimeRange       TimeRangeDTO
+	Variables       map[string]VariableValues
+}
+
+// VariableValues are the values of a template variable picked by a viewer. A single value can
+// also be sent as a string.
+type VariableValues []string
+
+func (v *VariableValues) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*v = VariableValues{value}
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*v = values
+	return nil
 }
 
 type AnnotationsQueryDTO struct {
@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -9,3 +10,34 @@ import (
 func TestPublicDashboardTableName(t *testing.T) {
 	assert.Equal(t, "dashboard_public", PublicDashboard{}.TableName())
 }
+
+func TestPublicDashboardIsExpired(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, PublicDashboard{}.IsExpired(now))
+	assert.False(t, PublicDashboard{ExpiresAt: now.Add(time.Minute).UnixMilli()}.IsExpired(now))
+	assert.True(t, PublicDashboard{ExpiresAt: now.UnixMilli()}.IsExpired(now))
+	assert.True(t, PublicDashboard{ExpiresAt: now.Add(-time.Minute).UnixMilli()}.IsExpired(now))
+}
+
+func TestPublicDashboardAllowsIP(t *testing.T) {
+	pubdash := PublicDashboard{AllowedIPs: []string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32"}}
+
+	testCases := []struct {
+		addr    string
+		allowed bool
+	}{
+		{addr: "10.1.2.3", allowed: true},
+		{addr: "192.168.1.10", allowed: true},
+		{addr: "2001:db8::1", allowed: true},
+		{addr: "192.168.1.11", allowed: false},
+		{addr: "11.0.0.1", allowed: false},
+		{addr: "", allowed: false},
+		{addr: "not-an-ip", allowed: false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.allowed, pubdash.AllowsIP(tc.addr), tc.addr)
+	}
+
+	assert.True(t, PublicDashboard{}.AllowsIP(""), "every address is allowed without an allow-list")
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// checkAccess rejects requests to a public dashboard that expired or that come from an address
+// outside of its allow-list
+func (pd *PublicDashboardServiceImpl) checkAccess(ctx context.Context, pubdash *PublicDashboard) error {
+	if pubdash.IsExpired(time.Now()) {
+		pd.metricService.RecordRejectedRequest(metric.RejectReasonExpired)
+		return ErrPublicDashboardExpired.Errorf("checkAccess: public dashboard expired accessToken: %s", pubdash.AccessToken)
+	}
+
+	if len(pubdash.AllowedIPs) > 0 {
+		addr := pd.clientAddr(ctx)
+		if !pubdash.AllowsIP(addr) {
+			pd.metricService.RecordRejectedRequest(metric.RejectReasonIPNotAllowed)
+			return ErrPublicDashboardIPNotAllowed.Errorf("checkAccess: address %q is not allowed accessToken: %s", addr, pubdash.AccessToken)
+		}
+	}
+
+	return nil
+}
+
+// clientAddr returns the address of the viewer, or an empty string outside of an HTTP request.
+// The X-Forwarded-For and X-Real-IP headers are only used when the request comes from one of the
+// trusted proxies, since anyone else can set them to any address.
+func (pd *PublicDashboardServiceImpl) clientAddr(ctx context.Context) string {
+	reqCtx := contexthandler.FromContext(ctx)
+	if reqCtx == nil || reqCtx.Context == nil || reqCtx.Req == nil {
+		return ""
+	}
+
+	addr := reqCtx.Req.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if !pd.isTrustedProxy(addr) {
+		return addr
+	}
+
+	// X-Forwarded-For lists the addresses the request was forwarded for, each proxy appending the
+	// address it received the request from. The client is the last address that is not a trusted proxy.
+	var forwarded []string
+	for _, header := range reqCtx.Req.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr = strings.TrimSpace(forwarded[i])
+		if !pd.isTrustedProxy(addr) {
+			return addr
+		}
+	}
+	if len(forwarded) > 0 {
+		return addr
+	}
+
+	if realIP := strings.TrimSpace(reqCtx.Req.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+	return addr
+}
+
+func (pd *PublicDashboardServiceImpl) isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range pd.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses the addresses and CIDR ranges of the trusted_proxies setting.
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range util.SplitString(value) {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// queryRateLimiter limits the queries of each public dashboard to its QueryRateLimit per minute.
+// Bursts of up to a minute of queries are allowed, since a dashboard queries all its panels at once.
+type queryRateLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func (l *queryRateLimiter) allow(pubdash *PublicDashboard) bool {
+	if pubdash.QueryRateLimit <= 0 {
+		return true
+	}
+
+	limit := rate.Limit(float64(pubdash.QueryRateLimit) / 60)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limiters == nil {
+		l.limiters = make(map[string]*rate.Limiter)
+	}
+	limiter, ok := l.limiters[pubdash.Uid]
+	if !ok || limiter.Limit() != limit {
+		limiter = rate.NewLimiter(limit, int(pubdash.QueryRateLimit))
+		l.limiters[pubdash.Uid] = limiter
+	}
+
+	return limiter.Allow()
+}
+
+// queryCacheKey identifies the response of a panel or template variable query. The time range is resolved to epoch
+// milliseconds for every request, so it is rounded down to the cache TTL in the key, otherwise
+// viewers of a relative time range would never share a response.
+func queryCacheKey(accessToken string, scope string, metricReq dtos.MetricRequest, ttl time.Duration) (string, error) {
+	body, err := json.Marshal(metricReq.Queries)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(accessToken))
+	hash.Write([]byte(scope))
+	hash.Write([]byte(roundTimestamp(metricReq.From, ttl)))
+	hash.Write([]byte(roundTimestamp(metricReq.To, ttl)))
+	hash.Write(body)
+	return "publicdashboards-query-" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// roundTimestamp rounds an epoch milliseconds timestamp down to a multiple of the duration
+func roundTimestamp(timestamp string, d time.Duration) string {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || d.Milliseconds() <= 0 {
+		return timestamp
+	}
+	return strconv.FormatInt(ms-ms%d.Milliseconds(), 10)
+}
+
+func (pd *PublicDashboardServiceImpl) getCachedQueryResponse(key string) (*backend.QueryDataResponse, bool) {
+	if pd.queryCache == nil {
+		return nil, false
+	}
+	cached, ok := pd.queryCache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	res, ok := cached.(*backend.QueryDataResponse)
+	return res, ok
+}
+
+func (pd *PublicDashboardServiceImpl) setCachedQueryResponse(key string, res *backend.QueryDataResponse) {
+	if pd.queryCache == nil {
+		return
+	}
+	pd.queryCache.Set(key, res, pd.queryCacheTTL)
+}
+
+// getCachedVariableOptions returns a copy of the cached options of a query variable, since the
+// options are changed when resolving the variable.
+func (pd *PublicDashboardServiceImpl) getCachedVariableOptions(key string) ([]variableOption, bool) {
+	if pd.variableCache == nil {
+		return nil, false
+	}
+	cached, ok := pd.variableCache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	options, ok := cached.([]variableOption)
+	return slices.Clone(options), ok
+}
+
+func (pd *PublicDashboardServiceImpl) setCachedVariableOptions(key string, options []variableOption) {
+	if pd.variableCache == nil {
+		return
+	}
+	pd.variableCache.Set(key, slices.Clone(options), pd.variableCacheTTL)
+}
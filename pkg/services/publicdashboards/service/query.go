@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -119,6 +120,14 @@ func (pd *PublicDashboardServiceImpl) GetMetricRequest(ctx context.Context, dash
 		return dtos.MetricRequest{}, err
 	}
 
+	variables, err := pd.resolveVariables(ctx, dashboard, publicDashboard, metricReqDTO, queryDto.Variables)
+	if err != nil {
+		return dtos.MetricRequest{}, err
+	}
+	for _, query := range metricReqDTO.Queries {
+		interpolateQuery(query, variables)
+	}
+
 	return metricReqDTO, nil
 }
 
@@ -138,6 +147,20 @@ func (pd *PublicDashboardServiceImpl) GetQueryDataResponse(ctx context.Context,
 		return nil, models.ErrPanelQueriesNotFound.Errorf("GetQueryDataResponse: failed to extract queries from panel")
 	}
 
+	cacheKey, err := queryCacheKey(accessToken, "panel-"+strconv.FormatInt(panelId, 10), metricReq, pd.queryCacheTTL)
+	if err != nil {
+		return nil, models.ErrInternalServerError.Errorf("GetQueryDataResponse: failed to build query cache key: %w", err)
+	}
+	if res, ok := pd.getCachedQueryResponse(cacheKey); ok {
+		return res, nil
+	}
+
+	// only the queries that reach the data sources count towards the rate limit
+	if !pd.queryLimiter.allow(publicDashboard) {
+		pd.metricService.RecordRejectedRequest(metric.RejectReasonRateLimited)
+		return nil, models.ErrPublicDashboardRateLimited.Errorf("GetQueryDataResponse: query rate limit exceeded accessToken: %s", accessToken)
+	}
+
 	anonymousUser := buildAnonymousUser(ctx, dashboard)
 	res, err := pd.QueryDataService.QueryData(ctx, anonymousUser, skipDSCache, metricReq)
 
@@ -150,9 +173,22 @@ func (pd *PublicDashboardServiceImpl) GetQueryDataResponse(ctx context.Context,
 
 	sanitizeMetadataFromQueryData(res)
 
+	if !hasQueryErrors(res) {
+		pd.setCachedQueryResponse(cacheKey, res)
+	}
+
 	return res, nil
 }
 
+func hasQueryErrors(res *backend.QueryDataResponse) bool {
+	for _, dataResponse := range res.Responses {
+		if dataResponse.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // buildMetricRequest merges public dashboard parameters with dashboard and returns a metrics request to be sent to query backend
 func (pd *PublicDashboardServiceImpl) buildMetricRequest(dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, panelId int64, reqDTO models.PublicDashboardQueryDTO) (dtos.MetricRequest, error) {
 	// group queries by panel
@@ -173,9 +209,10 @@ func (pd *PublicDashboardServiceImpl) buildMetricRequest(dashboard *dashboards.D
 	}
 
 	return dtos.MetricRequest{
-		From:    ts.From,
-		To:      ts.To,
-		Queries: queries,
+		From:         ts.From,
+		To:           ts.To,
+		Queries:      queries,
+		DashboardUID: dashboard.UID,
 	}, nil
 }
 
@@ -241,6 +278,18 @@ func getUniqueDashboardDatasourceUids(dashboard *simplejson.Json) []string {
 		}
 	}
 
+	// query variables are run with the anonymous user too
+	for _, variable := range getTemplateVariables(dashboard) {
+		if variable.varType != "query" {
+			continue
+		}
+		uid := getDataSourceUidFromJson(variable.json)
+		if _, ok := exists[uid]; !ok && uid != "" {
+			datasourceUids = append(datasourceUids, uid)
+			exists[uid] = true
+		}
+	}
+
 	return datasourceUids
 }
 
@@ -10,8 +10,10 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	dashboard2 "github.com/grafana/grafana/pkg/kinds/dashboard"
 	"github.com/grafana/grafana/pkg/services/annotations"
@@ -22,6 +24,7 @@ import (
 	. "github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/database"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/internal"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/quota/quotatest"
@@ -32,6 +35,8 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/legacydata"
 	"github.com/grafana/grafana/pkg/util"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -738,6 +743,85 @@ func TestGetQueryDataResponse(t *testing.T) {
 		resp, _ := service.GetQueryDataResponse(context.Background(), true, publicDashboardQueryDTO, 1, pubdashDto.AccessToken)
 		require.NotNil(t, resp)
 	})
+
+	customPanels := []any{
+		map[string]any{
+			"id":         1,
+			"datasource": map[string]any{"uid": "ds1"},
+			"targets": []any{map[string]any{
+				"datasource": map[string]any{"uid": "ds1"},
+				"refId":      "A",
+			}},
+		}}
+
+	t.Run("Returns cached responses for identical queries", func(t *testing.T) {
+		dashboard := insertTestDashboard(t, dashboardStore, "testDashCachedQuery", 1, 0, "", true, []map[string]any{}, customPanels)
+		isEnabled := true
+		pubdash, err := service.Create(context.Background(), SignedInUser, &SavePublicDashboardDTO{
+			DashboardUid:    dashboard.UID,
+			UserId:          7,
+			OrgID:           dashboard.OrgID,
+			PublicDashboard: &PublicDashboardDTO{IsEnabled: &isEnabled},
+		})
+		require.NoError(t, err)
+
+		queryService := &query.FakeQueryService{}
+		queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&backend.QueryDataResponse{}, nil)
+		cachingService := &PublicDashboardServiceImpl{
+			log:                log.New("test.logger"),
+			store:              publicdashboardStore,
+			intervalCalculator: intervalv2.NewCalculator(),
+			QueryDataService:   queryService,
+			serviceWrapper:     serviceWrapper,
+			queryCache:         localcache.New(time.Minute, time.Minute),
+			queryCacheTTL:      time.Minute,
+		}
+
+		first, err := cachingService.GetQueryDataResponse(context.Background(), false, publicDashboardQueryDTO, 1, pubdash.AccessToken)
+		require.NoError(t, err)
+		second, err := cachingService.GetQueryDataResponse(context.Background(), false, publicDashboardQueryDTO, 1, pubdash.AccessToken)
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+
+		otherQuery := publicDashboardQueryDTO
+		otherQuery.IntervalMs = int64(time.Hour / time.Millisecond)
+		_, err = cachingService.GetQueryDataResponse(context.Background(), false, otherQuery, 1, pubdash.AccessToken)
+		require.NoError(t, err)
+
+		queryService.AssertNumberOfCalls(t, "QueryData", 2)
+	})
+
+	t.Run("Returns ErrPublicDashboardRateLimited when the query rate limit is exceeded", func(t *testing.T) {
+		dashboard := insertTestDashboard(t, dashboardStore, "testDashRateLimited", 1, 0, "", true, []map[string]any{}, customPanels)
+		isEnabled := true
+		queryRateLimit := int64(2)
+		pubdash, err := service.Create(context.Background(), SignedInUser, &SavePublicDashboardDTO{
+			DashboardUid:    dashboard.UID,
+			UserId:          7,
+			OrgID:           dashboard.OrgID,
+			PublicDashboard: &PublicDashboardDTO{IsEnabled: &isEnabled, QueryRateLimit: &queryRateLimit},
+		})
+		require.NoError(t, err)
+
+		metricService, err := metric.ProvideService(publicdashboardStore, prometheus.NewRegistry())
+		require.NoError(t, err)
+		limitedService := &PublicDashboardServiceImpl{
+			log:                log.New("test.logger"),
+			store:              publicdashboardStore,
+			intervalCalculator: intervalv2.NewCalculator(),
+			QueryDataService:   fakeQueryService,
+			serviceWrapper:     serviceWrapper,
+			metricService:      metricService,
+		}
+
+		for i := 0; i < 2; i++ {
+			_, err := limitedService.GetQueryDataResponse(context.Background(), false, publicDashboardQueryDTO, 1, pubdash.AccessToken)
+			require.NoError(t, err)
+		}
+		_, err = limitedService.GetQueryDataResponse(context.Background(), false, publicDashboardQueryDTO, 1, pubdash.AccessToken)
+		require.ErrorIs(t, err, ErrPublicDashboardRateLimited)
+		assert.Equal(t, float64(1), testutil.ToFloat64(metricService.Metrics.RejectedRequests.WithLabelValues(metric.RejectReasonRateLimited)))
+	})
 }
 
 func TestFindAnnotations(t *testing.T) {
@@ -1268,6 +1352,7 @@ func TestBuildMetricRequest(t *testing.T) {
 
 		require.Equal(t, from, reqDTO.From)
 		require.Equal(t, to, reqDTO.To)
+		require.Equal(t, publicDashboard.UID, reqDTO.DashboardUID)
 
 		for i := range reqDTO.Queries {
 			require.Equal(t, publicDashboardQueryDTO.IntervalMs, reqDTO.Queries[i].Get("intervalMs").MustInt64())
@@ -1831,3 +1916,16 @@ func buildJsonDataWithTimeRange(from, to, timezone string) *simplejson.Json {
 		"timezone": timezone,
 	})
 }
+
+func TestQueryCacheKey(t *testing.T) {
+	queries := []*simplejson.Json{simplejson.NewFromAny(map[string]any{"refId": "A"})}
+
+	key := func(from, to string) string {
+		k, err := queryCacheKey("abc123", "panel-1", dtos.MetricRequest{From: from, To: to, Queries: queries}, 10*time.Second)
+		require.NoError(t, err)
+		return k
+	}
+
+	assert.Equal(t, key("1696146001000", "1696149601000"), key("1696146009999", "1696149609999"))
+	assert.NotEqual(t, key("1696146001000", "1696149601000"), key("1696146011000", "1696149611000"))
+}
@@ -4,16 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
 	"github.com/grafana/grafana/pkg/services/query"
@@ -35,6 +38,13 @@ type PublicDashboardServiceImpl struct {
 	AnnotationsRepo    annotations.Repository
 	ac                 accesscontrol.AccessControl
 	serviceWrapper     publicdashboards.ServiceWrapper
+	metricService      *metric.Service
+	queryLimiter       queryRateLimiter
+	queryCache         *localcache.CacheService
+	queryCacheTTL      time.Duration
+	variableCache      *localcache.CacheService
+	variableCacheTTL   time.Duration
+	trustedProxies     []*net.IPNet
 }
 
 var LogPrefix = "publicdashboards.service"
@@ -52,8 +62,9 @@ func ProvideService(
 	anno annotations.Repository,
 	ac accesscontrol.AccessControl,
 	serviceWrapper publicdashboards.ServiceWrapper,
+	metricService *metric.Service,
 ) *PublicDashboardServiceImpl {
-	return &PublicDashboardServiceImpl{
+	pd := &PublicDashboardServiceImpl{
 		log:                log.New(LogPrefix),
 		cfg:                cfg,
 		store:              store,
@@ -62,7 +73,31 @@ func ProvideService(
 		AnnotationsRepo:    anno,
 		ac:                 ac,
 		serviceWrapper:     serviceWrapper,
+		metricService:      metricService,
 	}
+
+	// identical panel queries are answered from memory for a short time, so that many viewers of
+	// the same public dashboard do not all hit the data sources
+	section := cfg.SectionWithEnvOverrides("public_dashboards")
+	pd.queryCacheTTL = section.Key("query_cache_ttl").MustDuration(10 * time.Second)
+	if pd.queryCacheTTL > 0 {
+		pd.queryCache = localcache.New(pd.queryCacheTTL, 2*pd.queryCacheTTL)
+	}
+	// the options of query variables are resolved for every panel query, they are kept separately
+	// from the panel responses so that they are not queried again for each panel
+	pd.variableCacheTTL = section.Key("variable_options_cache_ttl").MustDuration(time.Minute)
+	if pd.variableCacheTTL > 0 {
+		pd.variableCache = localcache.New(pd.variableCacheTTL, 2*pd.variableCacheTTL)
+	}
+
+	trustedProxies, err := parseTrustedProxies(section.Key("trusted_proxies").String())
+	if err != nil {
+		// without trusted proxies the allow-lists are checked against the connection address
+		pd.log.Error("Ignoring the trusted proxies of public dashboards", "error", err)
+	}
+	pd.trustedProxies = trustedProxies
+
+	return pd
 }
 
 func (pd *PublicDashboardServiceImpl) GetPublicDashboardForView(ctx context.Context, accessToken string) (*dtos.DashboardFullWithMeta, error) {
@@ -88,6 +123,7 @@ func (pd *PublicDashboardServiceImpl) GetPublicDashboardForView(ctx context.Cont
 	}
 	dash.Data.Get("timepicker").Set("hidden", !pubdash.TimeSelectionEnabled)
 
+	pd.sanitizeVariables(ctx, dash, pubdash)
 	sanitizeData(dash.Data)
 
 	return &dtos.DashboardFullWithMeta{Meta: meta, Dashboard: dash.Data}, nil
@@ -134,7 +170,8 @@ func (pd *PublicDashboardServiceImpl) FindByAccessToken(ctx context.Context, acc
 	return pubdash, nil
 }
 
-// FindEnabledPublicDashboardAndDashboardByAccessToken Gets public dashboard and a dashboard by access token if public dashboard is enabled
+// FindEnabledPublicDashboardAndDashboardByAccessToken Gets public dashboard and a dashboard by access token if public dashboard is enabled,
+// has not expired and can be viewed from the address of the request
 func (pd *PublicDashboardServiceImpl) FindEnabledPublicDashboardAndDashboardByAccessToken(ctx context.Context, accessToken string) (*PublicDashboard, *dashboards.Dashboard, error) {
 	pubdash, dash, err := pd.FindPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
 	if err != nil {
@@ -145,6 +182,10 @@ func (pd *PublicDashboardServiceImpl) FindEnabledPublicDashboardAndDashboardByAc
 		return nil, nil, ErrPublicDashboardNotEnabled.Errorf("FindEnabledPublicDashboardAndDashboardByAccessToken: Public dashboard is not enabled accessToken: %s", accessToken)
 	}
 
+	if err := pd.checkAccess(ctx, pubdash); err != nil {
+		return nil, nil, err
+	}
+
 	return pubdash, dash, err
 }
 
@@ -476,6 +517,9 @@ func (pd *PublicDashboardServiceImpl) newCreatePublicDashboard(ctx context.Conte
 		share = PublicShareType
 	}
 
+	expiresAt := returnValueOrDefault(dto.PublicDashboard.ExpiresAt, 0)
+	queryRateLimit := returnValueOrDefault(dto.PublicDashboard.QueryRateLimit, 0)
+
 	now := time.Now()
 
 	return &PublicDashboard{
@@ -487,6 +531,9 @@ func (pd *PublicDashboardServiceImpl) newCreatePublicDashboard(ctx context.Conte
 		TimeSelectionEnabled: timeSelectionEnabled,
 		TimeSettings:         &TimeSettings{},
 		Share:                share,
+		ExpiresAt:            expiresAt,
+		AllowedIPs:           dto.PublicDashboard.AllowedIPs,
+		QueryRateLimit:       queryRateLimit,
 		CreatedBy:            dto.UserId,
 		CreatedAt:            now,
 		UpdatedBy:            dto.UserId,
@@ -506,6 +553,15 @@ func newUpdatePublicDashboard(dto *SavePublicDashboardDTO, pd *PublicDashboard)
 		share = pd.Share
 	}
 
+	expiresAt := returnValueOrDefault(pubdashDTO.ExpiresAt, pd.ExpiresAt)
+	queryRateLimit := returnValueOrDefault(pubdashDTO.QueryRateLimit, pd.QueryRateLimit)
+
+	// an empty list removes the allow-list, a missing one keeps it
+	allowedIPs := pubdashDTO.AllowedIPs
+	if allowedIPs == nil {
+		allowedIPs = pd.AllowedIPs
+	}
+
 	return &PublicDashboard{
 		Uid:                  pd.Uid,
 		IsEnabled:            isEnabled,
@@ -513,12 +569,15 @@ func newUpdatePublicDashboard(dto *SavePublicDashboardDTO, pd *PublicDashboard)
 		TimeSelectionEnabled: timeSelectionEnabled,
 		TimeSettings:         pd.TimeSettings,
 		Share:                share,
+		ExpiresAt:            expiresAt,
+		AllowedIPs:           allowedIPs,
+		QueryRateLimit:       queryRateLimit,
 		UpdatedBy:            dto.UserId,
 		UpdatedAt:            time.Now(),
 	}
 }
 
-func returnValueOrDefault(value *bool, defaultValue bool) bool {
+func returnValueOrDefault[T any](value *T, defaultValue T) T {
 	if value != nil {
 		return *value
 	}
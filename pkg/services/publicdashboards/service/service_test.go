@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -19,11 +23,14 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	dashboardsDB "github.com/grafana/grafana/pkg/services/dashboards/database"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/database"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
 	"github.com/grafana/grafana/pkg/services/quota/quotatest"
@@ -33,6 +40,7 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 var timeSettings = &TimeSettings{From: "now-12h", To: "now"}
@@ -586,6 +594,116 @@ func TestGetEnabledPublicDashboard(t *testing.T) {
 	}
 }
 
+func TestGetEnabledPublicDashboardAccessControls(t *testing.T) {
+	proxiedRequestFrom := func(addr string, headers map[string]string) context.Context {
+		req := httptest.NewRequest(http.MethodGet, "/api/public/dashboards/abc123", nil)
+		req.RemoteAddr = addr + ":4321"
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return ctxkey.Set(context.Background(), &contextmodel.ReqContext{Context: &web.Context{Req: req}})
+	}
+	requestFrom := func(addr string) context.Context {
+		return proxiedRequestFrom(addr, nil)
+	}
+	trustedProxies, err := parseTrustedProxies("172.16.0.0/12, 127.0.0.1")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		Name    string
+		Ctx     context.Context
+		Pubdash *PublicDashboard
+		ErrResp error
+		Reason  string
+	}{
+		{
+			Name:    "returns a dashboard that has not expired",
+			Ctx:     context.Background(),
+			Pubdash: &PublicDashboard{IsEnabled: true, ExpiresAt: time.Now().Add(time.Hour).UnixMilli()},
+		},
+		{
+			Name:    "returns ErrPublicDashboardExpired when the public dashboard expired",
+			Ctx:     context.Background(),
+			Pubdash: &PublicDashboard{IsEnabled: true, ExpiresAt: time.Now().Add(-time.Hour).UnixMilli()},
+			ErrResp: ErrPublicDashboardExpired,
+			Reason:  metric.RejectReasonExpired,
+		},
+		{
+			Name:    "returns a dashboard when the address is allowed",
+			Ctx:     requestFrom("10.1.2.3"),
+			Pubdash: &PublicDashboard{IsEnabled: true, AllowedIPs: []string{"10.0.0.0/8"}},
+		},
+		{
+			Name:    "returns ErrPublicDashboardIPNotAllowed when the address is not allowed",
+			Ctx:     requestFrom("192.168.1.1"),
+			Pubdash: &PublicDashboard{IsEnabled: true, AllowedIPs: []string{"10.0.0.0/8"}},
+			ErrResp: ErrPublicDashboardIPNotAllowed,
+			Reason:  metric.RejectReasonIPNotAllowed,
+		},
+		{
+			Name:    "returns ErrPublicDashboardIPNotAllowed when a client that is not a proxy spoofs X-Forwarded-For",
+			Ctx:     proxiedRequestFrom("192.168.1.1", map[string]string{"X-Forwarded-For": "10.1.2.3", "X-Real-IP": "10.1.2.3"}),
+			Pubdash: &PublicDashboard{IsEnabled: true, AllowedIPs: []string{"10.0.0.0/8"}},
+			ErrResp: ErrPublicDashboardIPNotAllowed,
+			Reason:  metric.RejectReasonIPNotAllowed,
+		},
+		{
+			Name:    "returns a dashboard when a trusted proxy forwards an allowed address",
+			Ctx:     proxiedRequestFrom("172.16.0.1", map[string]string{"X-Forwarded-For": "192.168.1.1, 10.1.2.3, 127.0.0.1"}),
+			Pubdash: &PublicDashboard{IsEnabled: true, AllowedIPs: []string{"10.0.0.0/8"}},
+		},
+		{
+			Name:    "returns ErrPublicDashboardIPNotAllowed when the client prepends an allowed address to X-Forwarded-For",
+			Ctx:     proxiedRequestFrom("172.16.0.1", map[string]string{"X-Forwarded-For": "10.1.2.3, 192.168.1.1"}),
+			Pubdash: &PublicDashboard{IsEnabled: true, AllowedIPs: []string{"10.0.0.0/8"}},
+			ErrResp: ErrPublicDashboardIPNotAllowed,
+			Reason:  metric.RejectReasonIPNotAllowed,
+		},
+		{
+			Name:    "returns a dashboard when a trusted proxy sets X-Real-IP to an allowed address",
+			Ctx:     proxiedRequestFrom("127.0.0.1", map[string]string{"X-Real-IP": "10.1.2.3"}),
+			Pubdash: &PublicDashboard{IsEnabled: true, AllowedIPs: []string{"10.0.0.0/8"}},
+		},
+		{
+			Name:    "returns ErrPublicDashboardIPNotAllowed outside of a request",
+			Ctx:     context.Background(),
+			Pubdash: &PublicDashboard{IsEnabled: true, AllowedIPs: []string{"10.0.0.0/8"}},
+			ErrResp: ErrPublicDashboardIPNotAllowed,
+			Reason:  metric.RejectReasonIPNotAllowed,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			fakeStore := FakePublicDashboardStore{}
+			metricService, err := metric.ProvideService(&fakeStore, prometheus.NewRegistry())
+			require.NoError(t, err)
+			service := &PublicDashboardServiceImpl{
+				log:            log.New("test.logger"),
+				store:          &fakeStore,
+				metricService:  metricService,
+				trustedProxies: trustedProxies,
+			}
+
+			fakeStore.On("FindByAccessToken", mock.Anything, mock.Anything).Return(test.Pubdash, nil)
+			fakeStore.On("FindDashboard", mock.Anything, mock.Anything, mock.Anything).Return(&dashboards.Dashboard{UID: "mydashboard", Data: dashboardData}, nil)
+
+			pubdash, dash, err := service.FindEnabledPublicDashboardAndDashboardByAccessToken(test.Ctx, "abc123")
+			if test.ErrResp != nil {
+				require.ErrorIs(t, err, test.ErrResp)
+				assert.Nil(t, pubdash)
+				assert.Nil(t, dash)
+				assert.Equal(t, float64(1), testutil.ToFloat64(metricService.Metrics.RejectedRequests.WithLabelValues(test.Reason)))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.Pubdash, pubdash)
+			assert.Equal(t, "mydashboard", dash.UID)
+		})
+	}
+}
+
 // We're using sqlite here because testing all of the behaviors with mocks in
 // the correct order is convoluted.
 func TestCreatePublicDashboard(t *testing.T) {
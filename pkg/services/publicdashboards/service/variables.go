@@ -0,0 +1,629 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/components/templatevars"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+)
+
+const (
+	allVariableValue        = templatevars.AllValue
+	autoIntervalValuePrefix = "$__auto_interval_"
+	variableQueryRefID      = "variable"
+)
+
+// Template variables whose options can be computed on the server. Viewers can only pick values
+// from these options, other variables are left as they are in the queries.
+var supportedVariableTypes = map[string]bool{
+	"custom":   true,
+	"constant": true,
+	"interval": true,
+	"query":    true,
+}
+
+// custom variable options are separated by commas, which can be escaped with a backslash
+var customOptionRegex = regexp.MustCompile(`(?:\\,|[^,])+`)
+
+type variableOption struct {
+	Text     string `json:"text"`
+	Value    string `json:"value"`
+	Selected bool   `json:"selected"`
+}
+
+// templateVariable is a template variable of a public dashboard with its options computed on the
+// server and the values picked by the viewer, or its default values.
+type templateVariable struct {
+	json         *simplejson.Json
+	name         string
+	varType      string
+	multi        bool
+	includeAll   bool
+	allValue     string
+	options      []variableOption
+	selected     []string
+	autoInterval string
+}
+
+// values returns the selected values, with All expanded to every option
+func (v *templateVariable) values() []string {
+	if !v.isAllSelected() {
+		values := make([]string, 0, len(v.selected))
+		for _, value := range v.selected {
+			if strings.HasPrefix(value, autoIntervalValuePrefix) {
+				value = v.autoInterval
+			}
+			values = append(values, value)
+		}
+		return values
+	}
+
+	values := make([]string, 0, len(v.options))
+	for _, option := range v.options {
+		if option.Value != allVariableValue {
+			values = append(values, option.Value)
+		}
+	}
+	return values
+}
+
+func (v *templateVariable) isAllSelected() bool {
+	return len(v.selected) == 1 && v.selected[0] == allVariableValue
+}
+
+func (v *templateVariable) text(value string) string {
+	for _, option := range v.options {
+		if option.Value == value {
+			return option.Text
+		}
+	}
+	return value
+}
+
+func (v *templateVariable) hasOption(value string) bool {
+	for _, option := range v.options {
+		if option.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// selectValues validates the values picked by the viewer against the options, or picks the
+// default values saved with the dashboard
+func (v *templateVariable) selectValues(requested models.VariableValues) error {
+	if len(requested) > 0 {
+		if len(requested) > 1 && !v.multi {
+			return models.ErrInvalidVariableValue.Errorf("selectValues: template variable %s does not allow multiple values", v.name)
+		}
+		for _, value := range requested {
+			if !v.hasOption(value) {
+				return models.ErrInvalidVariableValue.Errorf("selectValues: %q is not an option of template variable %s", value, v.name)
+			}
+		}
+		if len(requested) > 1 {
+			for _, value := range requested {
+				if value == allVariableValue {
+					return models.ErrInvalidVariableValue.Errorf("selectValues: All cannot be combined with other values of template variable %s", v.name)
+				}
+			}
+		}
+		v.selected = requested
+		return nil
+	}
+
+	var current []string
+	switch value := v.json.GetPath("current", "value").Interface().(type) {
+	case string:
+		current = []string{value}
+	case []any:
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				current = append(current, s)
+			}
+		}
+	}
+
+	v.selected = nil
+	for _, value := range current {
+		if v.hasOption(value) {
+			v.selected = append(v.selected, value)
+		}
+	}
+	if len(v.selected) == 0 && len(v.options) > 0 {
+		v.selected = []string{v.options[0].Value}
+	}
+	if !v.multi && len(v.selected) > 1 {
+		v.selected = v.selected[:1]
+	}
+	return nil
+}
+
+// getTemplateVariables returns the supported template variables of the dashboard, in the order
+// they are defined
+func getTemplateVariables(dashboard *simplejson.Json) []*templateVariable {
+	var variables []*templateVariable
+	for _, variableObj := range dashboard.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(variableObj)
+		varType := variable.Get("type").MustString()
+		name := variable.Get("name").MustString()
+		if !supportedVariableTypes[varType] || name == "" {
+			continue
+		}
+		variables = append(variables, &templateVariable{
+			json:       variable,
+			name:       name,
+			varType:    varType,
+			multi:      variable.Get("multi").MustBool(),
+			includeAll: variable.Get("includeAll").MustBool(),
+			allValue:   variable.Get("allValue").MustString(),
+		})
+	}
+	return variables
+}
+
+// resolveVariables computes the options of the template variables used by the queries, in the
+// order they are defined so that query variables can use the variables before them, and selects
+// the values picked by the viewer. The time range of the metric request is used for query and
+// auto interval variables.
+func (pd *PublicDashboardServiceImpl) resolveVariables(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, metricReq dtos.MetricRequest, requested map[string]models.VariableValues) (map[string]*templateVariable, error) {
+	variables := getTemplateVariables(dashboard.Data)
+	byName := make(map[string]*templateVariable, len(variables))
+	for _, variable := range variables {
+		byName[variable.name] = variable
+	}
+
+	for name := range requested {
+		if _, ok := byName[name]; !ok {
+			return nil, models.ErrInvalidVariableValue.Errorf("resolveVariables: template variable %s cannot be set", name)
+		}
+	}
+
+	// only resolve the variables used by the queries, and the variables these depend on
+	used := map[string]bool{}
+	var pending []string
+	use := func(value any) {
+		for _, name := range templatevars.ReferencedVariables(value) {
+			if _, ok := byName[name]; ok && !used[name] {
+				used[name] = true
+				pending = append(pending, name)
+			}
+		}
+	}
+	for _, query := range metricReq.Queries {
+		use(query.Interface())
+	}
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if byName[name].varType == "query" {
+			use(byName[name].json.Get("query").Interface())
+		}
+	}
+
+	resolved := make(map[string]*templateVariable, len(used))
+	var anonymousUser *user.SignedInUser
+	for _, variable := range variables {
+		if !used[variable.name] {
+			continue
+		}
+
+		var err error
+		switch variable.varType {
+		case "custom":
+			variable.options = customVariableOptions(variable.json.Get("query").MustString())
+		case "constant":
+			value := variable.json.Get("query").MustString()
+			variable.options = []variableOption{{Text: value, Value: value}}
+		case "interval":
+			variable.options, variable.autoInterval = intervalVariableOptions(variable, metricReq)
+		case "query":
+			if anonymousUser == nil {
+				anonymousUser = buildAnonymousUser(ctx, dashboard)
+			}
+			variable.options, err = pd.queryVariableOptions(ctx, anonymousUser, publicDashboard, variable, resolved, metricReq)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if variable.includeAll && variable.varType != "constant" && variable.varType != "interval" {
+			variable.options = append([]variableOption{{Text: "All", Value: allVariableValue}}, variable.options...)
+		}
+
+		if err := variable.selectValues(requested[variable.name]); err != nil {
+			return nil, err
+		}
+		resolved[variable.name] = variable
+	}
+
+	return resolved, nil
+}
+
+func customVariableOptions(query string) []variableOption {
+	var options []variableOption
+	for _, match := range customOptionRegex.FindAllString(query, -1) {
+		text := strings.TrimSpace(strings.ReplaceAll(match, `\,`, ","))
+		if text == "" {
+			continue
+		}
+		value := text
+		// options can be written as "text : value"
+		if parts := strings.SplitN(text, " : ", 2); len(parts) == 2 {
+			text, value = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		}
+		options = append(options, variableOption{Text: text, Value: value})
+	}
+	return options
+}
+
+// intervalVariableOptions returns the intervals of the variable and the value of its auto option,
+// calculated from the time range like in the browser
+func intervalVariableOptions(variable *templateVariable, metricReq dtos.MetricRequest) ([]variableOption, string) {
+	var options []variableOption
+	for _, interval := range strings.Split(variable.json.Get("query").MustString(), ",") {
+		interval = strings.TrimSpace(interval)
+		if interval != "" {
+			options = append(options, variableOption{Text: interval, Value: interval})
+		}
+	}
+
+	if !variable.json.Get("auto").MustBool() {
+		return options, ""
+	}
+
+	autoCount := variable.json.Get("auto_count").MustInt64(30)
+	autoMin, err := intervalv2.ParseIntervalStringToTimeDuration(variable.json.Get("auto_min").MustString("10s"))
+	if err != nil {
+		autoMin = 10 * time.Second
+	}
+	timeRange := legacydata.NewDataTimeRange(metricReq.From, metricReq.To)
+	auto := intervalv2.NewCalculator().Calculate(backend.TimeRange{
+		From: timeRange.GetFromAsTimeUTC(),
+		To:   timeRange.GetToAsTimeUTC(),
+	}, autoMin, autoCount)
+
+	options = append([]variableOption{{Text: "auto", Value: autoIntervalValuePrefix + variable.name}}, options...)
+	return options, auto.Text
+}
+
+// queryVariableOptions runs the query of a query variable against its data source, like a panel
+// query, and returns the values of the first field of the result. Frames with __text and __value
+// fields are read as text and value pairs.
+func (pd *PublicDashboardServiceImpl) queryVariableOptions(ctx context.Context, anonymousUser *user.SignedInUser, publicDashboard *models.PublicDashboard, variable *templateVariable, resolved map[string]*templateVariable, metricReq dtos.MetricRequest) ([]variableOption, error) {
+	// variables that are never refreshed use the options saved with the dashboard
+	if variable.json.Get("refresh").MustInt() == 0 {
+		return savedVariableOptions(variable.json), nil
+	}
+
+	datasource := variable.json.Get("datasource")
+	if uid, err := datasource.String(); err == nil {
+		datasource = simplejson.NewFromAny(map[string]any{"uid": uid})
+	}
+	datasourceType := datasource.Get("type").MustString()
+
+	target := map[string]any{}
+	switch query := variable.json.Get("query").Interface().(type) {
+	case map[string]any:
+		for k, v := range query {
+			target[k] = v
+		}
+	case string:
+		if templatevars.SQLDatasourceTypes[datasourceType] {
+			target["rawSql"] = query
+			target["format"] = "table"
+		} else {
+			target["query"] = query
+		}
+	default:
+		return nil, nil
+	}
+	target["refId"] = variableQueryRefID
+	target["datasource"] = datasource.Interface()
+
+	query := simplejson.NewFromAny(target)
+	interpolateQuery(query, resolved)
+
+	variableReq := dtos.MetricRequest{
+		From:    metricReq.From,
+		To:      metricReq.To,
+		Queries: []*simplejson.Json{query},
+	}
+	cacheKey, err := queryCacheKey(publicDashboard.AccessToken, "variable-"+variable.name, variableReq, pd.variableCacheTTL)
+	if err != nil {
+		return nil, models.ErrInternalServerError.Errorf("queryVariableOptions: failed to build query cache key: %w", err)
+	}
+	if options, ok := pd.getCachedVariableOptions(cacheKey); ok {
+		return options, nil
+	}
+
+	// variable queries reach the data sources like panel queries, so they count towards the rate limit
+	if !pd.queryLimiter.allow(publicDashboard) {
+		pd.metricService.RecordRejectedRequest(metric.RejectReasonRateLimited)
+		return nil, models.ErrPublicDashboardRateLimited.Errorf("queryVariableOptions: query rate limit exceeded accessToken: %s", publicDashboard.AccessToken)
+	}
+
+	res, err := pd.QueryDataService.QueryData(ctx, anonymousUser, false, variableReq)
+	if err != nil {
+		return nil, models.ErrInternalServerError.Errorf("queryVariableOptions: failed to query template variable %s: %w", variable.name, err)
+	}
+	dataResponse := res.Responses[variableQueryRefID]
+	if dataResponse.Error != nil {
+		return nil, models.ErrInternalServerError.Errorf("queryVariableOptions: failed to query template variable %s: %w", variable.name, dataResponse.Error)
+	}
+
+	options, err := filterVariableOptions(frameOptions(dataResponse.Frames), variable.json.Get("regex").MustString())
+	if err != nil {
+		return nil, models.ErrInternalServerError.Errorf("queryVariableOptions: invalid regex of template variable %s: %w", variable.name, err)
+	}
+	sortVariableOptions(options, variable.json.Get("sort").MustInt())
+	pd.setCachedVariableOptions(cacheKey, options)
+	return options, nil
+}
+
+func savedVariableOptions(variable *simplejson.Json) []variableOption {
+	var options []variableOption
+	for _, optionObj := range variable.Get("options").MustArray() {
+		option := simplejson.NewFromAny(optionObj)
+		value, err := option.Get("value").String()
+		if err != nil || value == allVariableValue {
+			continue
+		}
+		options = append(options, variableOption{Text: option.Get("text").MustString(value), Value: value})
+	}
+	return options
+}
+
+func frameOptions(frames data.Frames) []variableOption {
+	var options []variableOption
+	seen := map[string]bool{}
+	add := func(text, value string) {
+		if !seen[value] {
+			seen[value] = true
+			options = append(options, variableOption{Text: text, Value: value})
+		}
+	}
+
+	for _, frame := range frames {
+		if len(frame.Fields) == 0 {
+			continue
+		}
+
+		textField, valueField := -1, -1
+		for i, field := range frame.Fields {
+			switch field.Name {
+			case "__text":
+				textField = i
+			case "__value":
+				valueField = i
+			}
+		}
+		if textField == -1 && valueField == -1 {
+			textField, valueField = 0, 0
+		} else if textField == -1 {
+			textField = valueField
+		} else if valueField == -1 {
+			valueField = textField
+		}
+
+		for row := 0; row < frame.Rows(); row++ {
+			text, ok := fieldValueString(frame.Fields[textField], row)
+			if !ok {
+				continue
+			}
+			value, ok := fieldValueString(frame.Fields[valueField], row)
+			if !ok {
+				continue
+			}
+			add(text, value)
+		}
+	}
+	return options
+}
+
+func fieldValueString(field *data.Field, row int) (string, bool) {
+	value, ok := field.ConcreteAt(row)
+	if !ok {
+		return "", false
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case time.Time:
+		return strconv.FormatInt(v.UnixMilli(), 10), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// filterVariableOptions applies the regex of a query variable, written like a JavaScript regex
+// such as /^prod-(.*)$/i. The first capture group, or the groups named text and value, replace
+// the option.
+func filterVariableOptions(options []variableOption, regex string) ([]variableOption, error) {
+	if regex == "" {
+		return options, nil
+	}
+
+	pattern := regex
+	if strings.HasPrefix(regex, "/") {
+		if end := strings.LastIndex(regex, "/"); end > 0 {
+			pattern = regex[1:end]
+			if strings.Contains(regex[end+1:], "i") {
+				pattern = "(?i)" + pattern
+			}
+		}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]variableOption, 0, len(options))
+	seen := map[string]bool{}
+	for _, option := range options {
+		match := re.FindStringSubmatch(option.Value)
+		if match == nil {
+			continue
+		}
+
+		text, value := option.Text, option.Value
+		if textIndex, valueIndex := re.SubexpIndex("text"), re.SubexpIndex("value"); textIndex > 0 || valueIndex > 0 {
+			if valueIndex > 0 {
+				value = match[valueIndex]
+			}
+			if textIndex > 0 {
+				text = match[textIndex]
+			} else {
+				text = value
+			}
+		} else if len(match) > 1 {
+			text, value = match[1], match[1]
+		}
+
+		if !seen[value] {
+			seen[value] = true
+			filtered = append(filtered, variableOption{Text: text, Value: value})
+		}
+	}
+	return filtered, nil
+}
+
+// sortVariableOptions sorts the options like the sort setting of a query variable: 1 and 2 sort
+// alphabetically, 3 and 4 numerically and 5 and 6 alphabetically ignoring case, ascending and
+// descending
+func sortVariableOptions(options []variableOption, sortOrder int) {
+	if sortOrder <= 0 {
+		return
+	}
+
+	number := func(text string) float64 {
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+
+	var less func(a, b variableOption) bool
+	switch sortOrder {
+	case 3, 4:
+		less = func(a, b variableOption) bool { return number(a.Text) < number(b.Text) }
+	case 5, 6:
+		less = func(a, b variableOption) bool { return strings.ToLower(a.Text) < strings.ToLower(b.Text) }
+	default:
+		less = func(a, b variableOption) bool { return a.Text < b.Text }
+	}
+
+	descending := sortOrder%2 == 0
+	sort.SliceStable(options, func(i, j int) bool {
+		if descending {
+			return less(options[j], options[i])
+		}
+		return less(options[i], options[j])
+	})
+}
+
+// interpolation returns the variable with the selected values, to interpolate in the queries
+func (v *templateVariable) interpolation() *templatevars.Variable {
+	values := v.values()
+	texts := make([]string, 0, len(values))
+	for _, value := range values {
+		texts = append(texts, v.text(value))
+	}
+	return &templatevars.Variable{
+		Name:        v.name,
+		Values:      values,
+		Texts:       texts,
+		Multi:       v.multi || v.includeAll,
+		AllSelected: v.isAllSelected(),
+		AllValue:    v.allValue,
+	}
+}
+
+// interpolateQuery replaces the template variables in the strings of a query with their values.
+// Only values computed on the server end up in the query, formatted for its data source.
+func interpolateQuery(query *simplejson.Json, variables map[string]*templateVariable) {
+	if len(variables) == 0 {
+		return
+	}
+	values := make(map[string]*templatevars.Variable, len(variables))
+	for name, variable := range variables {
+		values[name] = variable.interpolation()
+	}
+	templatevars.InterpolateQuery(query, values)
+}
+
+// sanitizeVariables replaces the options of the supported template variables with the options
+// computed on the server, and removes the queries of query variables, so that the browser only
+// offers values the queries accept and never sees the variable queries
+func (pd *PublicDashboardServiceImpl) sanitizeVariables(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard) {
+	variables := getTemplateVariables(dashboard.Data)
+	if len(variables) == 0 {
+		return
+	}
+
+	// every supported variable is resolved for the default time range of the dashboard
+	ts := buildTimeSettings(dashboard, models.PublicDashboardQueryDTO{}, publicDashboard)
+	metricReq := dtos.MetricRequest{From: ts.From, To: ts.To}
+	for _, variable := range variables {
+		metricReq.Queries = append(metricReq.Queries, simplejson.NewFromAny(map[string]any{"variable": "$" + variable.name}))
+	}
+
+	resolved, err := pd.resolveVariables(ctx, dashboard, publicDashboard, metricReq, nil)
+	if err != nil {
+		pd.log.Warn("Failed to resolve template variables of public dashboard", "publicDashboardUid", publicDashboard.Uid, "error", err)
+	}
+
+	for _, variable := range variables {
+		if variable.varType == "query" {
+			variable.json.Del("query")
+			variable.json.Del("definition")
+			// the options are sent with the dashboard, the browser cannot run the query
+			variable.json.Set("refresh", 0)
+		}
+
+		options := []variableOption{}
+		current := map[string]any{"text": "", "value": ""}
+		if v, ok := resolved[variable.name]; ok {
+			selected := map[string]bool{}
+			for _, value := range v.selected {
+				selected[value] = true
+			}
+			for _, option := range v.options {
+				option.Selected = selected[option.Value]
+				options = append(options, option)
+			}
+
+			texts := make([]string, 0, len(v.selected))
+			for _, value := range v.selected {
+				texts = append(texts, v.text(value))
+			}
+			if v.multi {
+				current = map[string]any{"text": texts, "value": v.selected}
+			} else if len(v.selected) > 0 {
+				current = map[string]any{"text": texts[0], "value": v.selected[0]}
+			}
+		}
+		variable.json.Set("options", options)
+		variable.json.Set("current", current)
+	}
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/components/templatevars"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
+)
+
+const variablesDashboardJSON = `{
+	"time": {"from": "2022-09-01T00:00:00.000Z", "to": "2022-09-01T12:00:00.000Z"},
+	"templating": {"list": [
+		{"name": "env", "type": "custom", "multi": true, "includeAll": true, "query": "prod,staging : stg,dev\\,test", "current": {"value": ["prod"]}},
+		{"name": "limit", "type": "constant", "query": "10"},
+		{"name": "step", "type": "interval", "query": "1m,1h", "auto": true, "auto_count": 12, "current": {"value": "1h"}},
+		{"name": "host", "type": "query", "refresh": 1, "regex": "/^(web-.*)$/", "sort": 2,
+			"datasource": {"type": "prometheus", "uid": "prom"}, "query": "label_values(up{env=~\"$env\"}, host)"},
+		{"name": "search", "type": "textbox", "query": "anything"}
+	]},
+	"panels": [
+		{"id": 1, "targets": [
+			{"refId": "A", "datasource": {"type": "mysql", "uid": "sql"}, "rawSql": "SELECT * FROM t WHERE env IN ($env) LIMIT $limit"},
+			{"refId": "B", "datasource": {"type": "prometheus", "uid": "prom"}, "expr": "rate(up{host=~\"${host}\", env=~\"$env\"}[$step])"}
+		]},
+		{"id": 2, "targets": [
+			{"refId": "A", "datasource": {"type": "loki", "uid": "loki"}, "expr": "{env=\"${env:raw}\"} |= \"$search\""}
+		]}
+	]
+}`
+
+func newVariablesTestDashboard(t *testing.T) *dashboards.Dashboard {
+	t.Helper()
+	dashboardData, err := simplejson.NewJson([]byte(variablesDashboardJSON))
+	require.NoError(t, err)
+	return &dashboards.Dashboard{UID: "dash", OrgID: 1, Data: dashboardData}
+}
+
+func newVariablesTestService(t *testing.T) (*PublicDashboardServiceImpl, *query.FakeQueryService) {
+	t.Helper()
+	hosts := data.NewFrame("", data.NewField("host", nil, []string{"web-1", "db-1", "web-2", "web-1"}))
+	queryService := &query.FakeQueryService{}
+	queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&backend.QueryDataResponse{
+		Responses: backend.Responses{variableQueryRefID: {Frames: data.Frames{hosts}}},
+	}, nil)
+
+	return &PublicDashboardServiceImpl{
+		log:                log.New("test.logger"),
+		intervalCalculator: intervalv2.NewCalculator(),
+		QueryDataService:   queryService,
+	}, queryService
+}
+
+func TestGetMetricRequestWithVariables(t *testing.T) {
+	publicDashboard := &PublicDashboard{Uid: "pubdash", AccessToken: "abc123", IsEnabled: true}
+	queryDto := PublicDashboardQueryDTO{IntervalMs: 1000, MaxDataPoints: 100}
+
+	t.Run("interpolates the default values of the variables", func(t *testing.T) {
+		service, queryService := newVariablesTestService(t)
+
+		metricReq, err := service.GetMetricRequest(context.Background(), newVariablesTestDashboard(t), publicDashboard, 1, queryDto)
+		require.NoError(t, err)
+
+		assert.Equal(t, "SELECT * FROM t WHERE env IN ('prod') LIMIT 10", metricReq.Queries[0].Get("rawSql").MustString())
+		assert.Equal(t, `rate(up{host=~"web-2", env=~"prod"}[1h])`, metricReq.Queries[1].Get("expr").MustString())
+
+		// the variable query is interpolated with the values of the variables before it
+		queryService.AssertNumberOfCalls(t, "QueryData", 1)
+		variableReq := queryService.Calls[0].Arguments.Get(3).(dtos.MetricRequest)
+		assert.Equal(t, `label_values(up{env=~"prod"}, host)`, variableReq.Queries[0].Get("query").MustString())
+	})
+
+	t.Run("interpolates the values picked by the viewer", func(t *testing.T) {
+		service, _ := newVariablesTestService(t)
+		dto := queryDto
+		dto.Variables = map[string]VariableValues{
+			"env":  {"prod", "stg"},
+			"host": {"web-1"},
+			"step": {"$__auto_interval_step"},
+		}
+
+		metricReq, err := service.GetMetricRequest(context.Background(), newVariablesTestDashboard(t), publicDashboard, 1, dto)
+		require.NoError(t, err)
+
+		assert.Equal(t, "SELECT * FROM t WHERE env IN ('prod','stg') LIMIT 10", metricReq.Queries[0].Get("rawSql").MustString())
+		assert.Equal(t, `rate(up{host=~"web-1", env=~"(prod|stg)"}[1h])`, metricReq.Queries[1].Get("expr").MustString())
+	})
+
+	t.Run("expands All to every option", func(t *testing.T) {
+		service, _ := newVariablesTestService(t)
+		dto := queryDto
+		dto.Variables = map[string]VariableValues{"env": {allVariableValue}}
+
+		metricReq, err := service.GetMetricRequest(context.Background(), newVariablesTestDashboard(t), publicDashboard, 1, dto)
+		require.NoError(t, err)
+
+		assert.Equal(t, "SELECT * FROM t WHERE env IN ('prod','stg','dev,test') LIMIT 10", metricReq.Queries[0].Get("rawSql").MustString())
+	})
+
+	t.Run("leaves unsupported variables as they are", func(t *testing.T) {
+		service, _ := newVariablesTestService(t)
+
+		metricReq, err := service.GetMetricRequest(context.Background(), newVariablesTestDashboard(t), publicDashboard, 2, queryDto)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{env="prod"} |= "$search"`, metricReq.Queries[0].Get("expr").MustString())
+	})
+
+	t.Run("resolves the options of query variables once for all panels", func(t *testing.T) {
+		service, queryService := newVariablesTestService(t)
+		service.variableCacheTTL = time.Minute
+		service.variableCache = localcache.New(time.Minute, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			metricReq, err := service.GetMetricRequest(context.Background(), newVariablesTestDashboard(t), publicDashboard, 1, queryDto)
+			require.NoError(t, err)
+			assert.Equal(t, `rate(up{host=~"web-2", env=~"prod"}[1h])`, metricReq.Queries[1].Get("expr").MustString())
+		}
+		queryService.AssertNumberOfCalls(t, "QueryData", 1)
+	})
+
+	t.Run("counts variable queries towards the query rate limit", func(t *testing.T) {
+		service, queryService := newVariablesTestService(t)
+		metricService, err := metric.ProvideService(&publicdashboards.FakePublicDashboardStore{}, prometheus.NewRegistry())
+		require.NoError(t, err)
+		service.metricService = metricService
+		limited := &PublicDashboard{Uid: "limited", AccessToken: "def456", IsEnabled: true, QueryRateLimit: 1}
+
+		_, err = service.GetMetricRequest(context.Background(), newVariablesTestDashboard(t), limited, 1, queryDto)
+		require.NoError(t, err)
+		_, err = service.GetMetricRequest(context.Background(), newVariablesTestDashboard(t), limited, 1, queryDto)
+		require.ErrorIs(t, err, ErrPublicDashboardRateLimited)
+		queryService.AssertNumberOfCalls(t, "QueryData", 1)
+		assert.Equal(t, float64(1), testutil.ToFloat64(metricService.Metrics.RejectedRequests.WithLabelValues(metric.RejectReasonRateLimited)))
+	})
+
+	invalidValues := map[string]map[string]VariableValues{
+		"a value that is not an option":           {"env": {"prod') OR 1=1 --"}},
+		"a query result that was filtered out":    {"host": {"db-1"}},
+		"several values of a single variable":     {"host": {"web-1", "web-2"}},
+		"a different constant":                    {"limit": {"100000"}},
+		"a variable that cannot be set":           {"search": {"anything"}},
+		"a variable that is not in the dashboard": {"missing": {"value"}},
+		"All combined with other values":          {"env": {allVariableValue, "prod"}},
+	}
+	for name, variables := range invalidValues {
+		t.Run("rejects "+name, func(t *testing.T) {
+			service, _ := newVariablesTestService(t)
+			dto := queryDto
+			dto.Variables = variables
+
+			_, err := service.GetMetricRequest(context.Background(), newVariablesTestDashboard(t), publicDashboard, 1, dto)
+			require.ErrorIs(t, err, ErrInvalidVariableValue)
+		})
+	}
+}
+
+func TestSanitizeVariables(t *testing.T) {
+	service, _ := newVariablesTestService(t)
+	dashboard := newVariablesTestDashboard(t)
+
+	service.sanitizeVariables(context.Background(), dashboard, &PublicDashboard{Uid: "pubdash", AccessToken: "abc123"})
+
+	list := dashboard.Data.GetPath("templating", "list")
+	host := list.GetIndex(3)
+	_, hasQuery := host.CheckGet("query")
+	assert.False(t, hasQuery)
+	assert.Equal(t, 0, host.Get("refresh").MustInt())
+	assert.Equal(t, []variableOption{
+		{Text: "web-2", Value: "web-2", Selected: true},
+		{Text: "web-1", Value: "web-1"},
+	}, host.Get("options").Interface())
+
+	env := list.GetIndex(0)
+	assert.Equal(t, map[string]any{"text": []string{"prod"}, "value": []string{"prod"}}, env.Get("current").Interface())
+	assert.Len(t, env.Get("options").Interface(), 4)
+
+	// unsupported variables are sent as they are
+	assert.Equal(t, "anything", list.GetIndex(4).Get("query").MustString())
+}
+
+func TestCustomVariableOptions(t *testing.T) {
+	assert.Equal(t, []variableOption{
+		{Text: "a", Value: "a"},
+		{Text: "Bee", Value: "b"},
+		{Text: "c,d", Value: "c,d"},
+	}, customVariableOptions(`a, Bee : b ,c\,d,`))
+}
+
+func TestVariableInterpolation(t *testing.T) {
+	variable := &templateVariable{
+		name:     "var",
+		multi:    true,
+		options:  []variableOption{{Text: "One", Value: "o'ne"}, {Text: "Two", Value: "two.*"}},
+		selected: []string{"o'ne", "two.*"},
+	}
+	assert.Equal(t, &templatevars.Variable{
+		Name:   "var",
+		Values: []string{"o'ne", "two.*"},
+		Texts:  []string{"One", "Two"},
+		Multi:  true,
+	}, variable.interpolation())
+
+	t.Run("expands All to every option", func(t *testing.T) {
+		all := &templateVariable{
+			name:       "var",
+			includeAll: true,
+			allValue:   ".*",
+			options:    []variableOption{{Text: "All", Value: allVariableValue}, {Text: "One", Value: "one"}},
+			selected:   []string{allVariableValue},
+		}
+		assert.Equal(t, &templatevars.Variable{
+			Name:        "var",
+			Values:      []string{"one"},
+			Texts:       []string{"One"},
+			Multi:       true,
+			AllSelected: true,
+			AllValue:    ".*",
+		}, all.interpolation())
+	})
+}
@@ -1,18 +1,37 @@
 package validation
 
 import (
+	"net"
+	"regexp"
+
 	"github.com/google/uuid"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/tsdb/legacydata"
 	"github.com/grafana/grafana/pkg/util"
 )
 
+var variableNamePattern = regexp.MustCompile(`^\w+$`)
+
 func ValidatePublicDashboard(dto *SavePublicDashboardDTO) error {
 	// if it is empty we override it in the service with public for retro compatibility
 	if dto.PublicDashboard.Share != "" && !IsValidShareType(dto.PublicDashboard.Share) {
 		return ErrInvalidShareType.Errorf("ValidateSavePublicDashboard: invalid share type")
 	}
 
+	if dto.PublicDashboard.ExpiresAt != nil && *dto.PublicDashboard.ExpiresAt < 0 {
+		return ErrInvalidExpiresAt.Errorf("ValidateSavePublicDashboard: expiresAt should not be negative")
+	}
+
+	for _, allowed := range dto.PublicDashboard.AllowedIPs {
+		if !IsValidIPOrCIDR(allowed) {
+			return ErrInvalidAllowedIPs.Errorf("ValidateSavePublicDashboard: invalid IP address or CIDR range %q", allowed)
+		}
+	}
+
+	if dto.PublicDashboard.QueryRateLimit != nil && *dto.PublicDashboard.QueryRateLimit < 0 {
+		return ErrInvalidQueryRateLimit.Errorf("ValidateSavePublicDashboard: queryRateLimit should not be negative")
+	}
+
 	return nil
 }
 
@@ -25,6 +44,15 @@ func ValidateQueryPublicDashboardRequest(req PublicDashboardQueryDTO, pd *Public
 		return ErrInvalidMaxDataPoints.Errorf("ValidateQueryPublicDashboardRequest: maxDataPoints should be greater than 0")
 	}
 
+	for name, values := range req.Variables {
+		if !variableNamePattern.MatchString(name) {
+			return ErrInvalidVariableValue.Errorf("ValidateQueryPublicDashboardRequest: invalid template variable name %q", name)
+		}
+		if len(values) == 0 {
+			return ErrInvalidVariableValue.Errorf("ValidateQueryPublicDashboardRequest: no value for template variable %q", name)
+		}
+	}
+
 	if pd.TimeSelectionEnabled {
 		timeRange := legacydata.NewDataTimeRange(req.TimeRange.From, req.TimeRange.To)
 
@@ -53,6 +81,15 @@ func IsValidShortUID(uid string) bool {
 	return uid != "" && util.IsValidShortUID(uid)
 }
 
+// IsValidIPOrCIDR checks that the value is an IP address or a CIDR range
+func IsValidIPOrCIDR(value string) bool {
+	if net.ParseIP(value) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
+
 func IsValidShareType(shareType ShareType) bool {
 	for _, t := range ValidShareTypes {
 		if t == shareType {
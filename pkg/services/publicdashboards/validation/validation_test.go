@@ -22,6 +22,42 @@ func TestValidatePublicDashboard(t *testing.T) {
 		err := ValidatePublicDashboard(dto)
 		require.Error(t, err)
 	})
+
+	t.Run("Returns no error when access controls are valid", func(t *testing.T) {
+		expiresAt := int64(1700000000000)
+		queryRateLimit := int64(120)
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{
+			ExpiresAt:      &expiresAt,
+			AllowedIPs:     []string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32"},
+			QueryRateLimit: &queryRateLimit,
+		}}
+
+		err := ValidatePublicDashboard(dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns error when allowed IPs contain an invalid address", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{AllowedIPs: []string{"10.0.0.0/33"}}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, ErrInvalidAllowedIPs)
+	})
+
+	t.Run("Returns error when query rate limit is negative", func(t *testing.T) {
+		queryRateLimit := int64(-1)
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{QueryRateLimit: &queryRateLimit}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, ErrInvalidQueryRateLimit)
+	})
+
+	t.Run("Returns error when expiry is negative", func(t *testing.T) {
+		expiresAt := int64(-1)
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{ExpiresAt: &expiresAt}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, ErrInvalidExpiresAt)
+	})
 }
 
 func TestValidateQueryPublicDashboardRequest(t *testing.T) {
@@ -129,6 +165,26 @@ func TestValidateQueryPublicDashboardRequest(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Returns validation error when a template variable name is invalid",
+			args: args{
+				req: PublicDashboardQueryDTO{
+					Variables: map[string]VariableValues{"env}": {"prod"}},
+				},
+				pd: &PublicDashboard{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Returns validation error when a template variable has no value",
+			args: args{
+				req: PublicDashboardQueryDTO{
+					Variables: map[string]VariableValues{"env": {}},
+				},
+				pd: &PublicDashboard{},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,175 @@
+package query
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+)
+
+// coalescedQuery is the key of the in-flight requests to a data source. Requests with the same
+// key are sent once and share the response.
+type coalescedQuery struct {
+	OrgID      int64     `json:"orgId"`
+	Datasource string    `json:"datasource"`
+	Updated    int64     `json:"updated"`
+	Role       string    `json:"role"`
+	Identity   string    `json:"identity,omitempty"`
+	Teams      []int64   `json:"teams,omitempty"`
+	Queries    []keyPart `json:"queries"`
+}
+
+type keyPart struct {
+	Query     map[string]any `json:"query"`
+	QueryType string         `json:"queryType"`
+	From      int64          `json:"from"`
+	To        int64          `json:"to"`
+	Interval  int64          `json:"interval"`
+	MaxPoints int64          `json:"maxDataPoints"`
+}
+
+// coalesceKey returns the key of a data source request, and false when the request can't be
+// shared with the requests of other users. The identity of the user is part of the key when the
+// data source receives it, through the OAuth token, the user header or the ID token, and the
+// teams are when the data source has team headers. Requests forwarding cookies are never shared.
+func (s *ServiceImpl) coalesceKey(user identity.Requester, req *backend.QueryDataRequest) (string, bool) {
+	ds := req.PluginContext.DataSourceInstanceSettings
+	if ds == nil {
+		return "", false
+	}
+	var jsonData struct {
+		OAuthPassThru   bool           `json:"oauthPassThru"`
+		KeepCookies     []string       `json:"keepCookies"`
+		TeamHTTPHeaders map[string]any `json:"teamHttpHeaders"`
+	}
+	if len(ds.JSONData) > 0 {
+		if err := json.Unmarshal(ds.JSONData, &jsonData); err != nil {
+			return "", false
+		}
+	}
+	if len(jsonData.KeepCookies) > 0 {
+		return "", false
+	}
+
+	key := coalescedQuery{
+		OrgID:      req.PluginContext.OrgID,
+		Datasource: ds.UID,
+		Updated:    ds.Updated.UnixMilli(),
+	}
+	if req.PluginContext.User != nil {
+		key.Role = req.PluginContext.User.Role
+	}
+	if jsonData.OAuthPassThru || s.cfg.SendUserHeader || s.features.IsEnabled(featuremgmt.FlagIdForwarding) {
+		if user == nil {
+			return "", false
+		}
+		namespace, id := user.GetNamespacedID()
+		key.Identity = fmt.Sprintf("%s:%s", namespace, id)
+	}
+	if len(jsonData.TeamHTTPHeaders) > 0 {
+		if user == nil {
+			return "", false
+		}
+		key.Teams = append([]int64{}, user.GetTeams()...)
+		sort.Slice(key.Teams, func(i, j int) bool { return key.Teams[i] < key.Teams[j] })
+	}
+
+	for _, q := range req.Queries {
+		model := map[string]any{}
+		if len(q.JSON) > 0 {
+			if err := json.Unmarshal(q.JSON, &model); err != nil {
+				return "", false
+			}
+		}
+		delete(model, "requestId")
+
+		// only the requests of the exact same time range are shared, the response of a shifted
+		// range would miss or add points at its edges
+		key.Queries = append(key.Queries, keyPart{
+			Query:     model,
+			QueryType: q.QueryType,
+			From:      q.TimeRange.From.UnixMilli(),
+			To:        q.TimeRange.To.UnixMilli(),
+			Interval:  q.Interval.Milliseconds(),
+			MaxPoints: q.MaxDataPoints,
+		})
+	}
+
+	body, err := json.Marshal(key)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// queryDataCoalesced sends a request to the data source, or waits for the response of the same
+// request if it is already in flight. When the response is shared, each caller gets its own copy
+// of the frames, since callers such as public dashboards change their metadata.
+func (s *ServiceImpl) queryDataCoalesced(ctx context.Context, user identity.Requester, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if !s.coalesceQueries {
+		return s.pluginClient.QueryData(ctx, req)
+	}
+	key, ok := s.coalesceKey(user, req)
+	if !ok {
+		return s.pluginClient.QueryData(ctx, req)
+	}
+
+	ch := s.inflight.DoChan(key, func() (any, error) {
+		return s.pluginClient.QueryData(ctx, req)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		// the request was canceled by the caller which sent it, not by this one
+		if result.Shared && isCanceled(result.Err) && ctx.Err() == nil {
+			return s.pluginClient.QueryData(ctx, req)
+		}
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		resp, _ := result.Val.(*backend.QueryDataResponse)
+		if resp == nil || !result.Shared {
+			return resp, nil
+		}
+		copied, err := copyQueryDataResponse(resp)
+		if err != nil {
+			s.log.FromContext(ctx).Warn("Failed to copy shared query response, sending the query again", "error", err)
+			return s.pluginClient.QueryData(ctx, req)
+		}
+		return copied, nil
+	}
+}
+
+// copyQueryDataResponse returns a deep copy of the response. The frames are copied through their
+// arrow encoding, which keeps the field configs and the metadata.
+func copyQueryDataResponse(resp *backend.QueryDataResponse) (*backend.QueryDataResponse, error) {
+	responses := make(backend.Responses, len(resp.Responses))
+	for refID, r := range resp.Responses {
+		if r.Frames != nil {
+			encoded, err := r.Frames.MarshalArrow()
+			if err != nil {
+				return nil, err
+			}
+			if r.Frames, err = data.UnmarshalArrowFrames(encoded); err != nil {
+				return nil, err
+			}
+		}
+		responses[refID] = r
+	}
+	return &backend.QueryDataResponse{Responses: responses}, nil
+}
+
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,181 @@
+package query
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type blockingPluginClient struct {
+	plugins.Client
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (c *blockingPluginClient) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	c.calls.Add(1)
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	frame := data.NewFrame("up", data.NewField("value", nil, []float64{1}))
+	frame.Meta = &data.FrameMeta{ExecutedQueryString: "up"}
+	return &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{frame}}}}, nil
+}
+
+func newCoalesceRequest(jsonData string, query string, to time.Time) *backend.QueryDataRequest {
+	return &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			OrgID: 1,
+			User:  &backend.User{Role: "Viewer"},
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				UID:      "prom",
+				Type:     "prometheus",
+				JSONData: []byte(jsonData),
+			},
+		},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			JSON:      []byte(query),
+			Interval:  time.Minute,
+			TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to},
+		}},
+	}
+}
+
+func TestQueryDataCoalesced(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 10, 0, time.UTC)
+
+	setupCoalescing := func() (*ServiceImpl, *blockingPluginClient) {
+		client := &blockingPluginClient{release: make(chan struct{})}
+		return &ServiceImpl{
+			cfg:             setting.NewCfg(),
+			features:        featuremgmt.WithFeatures(),
+			pluginClient:    client,
+			log:             log.NewNopLogger(),
+			coalesceQueries: true,
+		}, client
+	}
+
+	// runConcurrently sends the requests at the same time and waits for their responses
+	runConcurrently := func(t *testing.T, s *ServiceImpl, client *blockingPluginClient, calls int, users []*user.SignedInUser, reqs []*backend.QueryDataRequest) []*backend.QueryDataResponse {
+		t.Helper()
+		responses := make([]*backend.QueryDataResponse, len(reqs))
+		var wg sync.WaitGroup
+		for i := range reqs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				resp, err := s.queryDataCoalesced(context.Background(), users[i], reqs[i])
+				require.NoError(t, err)
+				responses[i] = resp
+			}(i)
+		}
+		require.Eventually(t, func() bool { return client.calls.Load() == int32(calls) }, time.Second, time.Millisecond)
+		// let the requests which are not sent join the in-flight ones
+		time.Sleep(20 * time.Millisecond)
+		close(client.release)
+		wg.Wait()
+		return responses
+	}
+
+	t.Run("identical concurrent queries share one request", func(t *testing.T) {
+		s, client := setupCoalescing()
+		users := []*user.SignedInUser{{UserID: 1, OrgID: 1}, {UserID: 2, OrgID: 1}, {UserID: 3, OrgID: 1}}
+		reqs := []*backend.QueryDataRequest{
+			newCoalesceRequest(`{}`, `{"refId":"A","expr":"up","requestId":"1"}`, now),
+			newCoalesceRequest(`{}`, `{"expr":"up","refId":"A","requestId":"2"}`, now),
+			newCoalesceRequest(`{}`, `{"refId":"A","expr":"up"}`, now),
+		}
+
+		responses := runConcurrently(t, s, client, 1, users, reqs)
+		require.Equal(t, int32(1), client.calls.Load())
+		for _, resp := range responses {
+			require.Contains(t, resp.Responses, "A")
+		}
+		responses[0].Responses["B"] = backend.DataResponse{}
+		require.NotContains(t, responses[1].Responses, "B", "each caller gets its own responses")
+		responses[0].Responses["A"].Frames[0].Meta.ExecutedQueryString = ""
+		responses[0].Responses["A"].Frames[0].Fields[0].Set(0, 2.0)
+		require.Equal(t, "up", responses[1].Responses["A"].Frames[0].Meta.ExecutedQueryString, "each caller gets its own frames")
+		require.Equal(t, 1.0, responses[2].Responses["A"].Frames[0].Fields[0].At(0))
+	})
+
+	t.Run("different queries are not shared", func(t *testing.T) {
+		s, client := setupCoalescing()
+		users := []*user.SignedInUser{{UserID: 1, OrgID: 1}, {UserID: 1, OrgID: 1}, {UserID: 1, OrgID: 1}}
+		reqs := []*backend.QueryDataRequest{
+			newCoalesceRequest(`{}`, `{"expr":"up"}`, now),
+			newCoalesceRequest(`{}`, `{"expr":"down"}`, now),
+			// the same query over a slightly later time range
+			newCoalesceRequest(`{}`, `{"expr":"up"}`, now.Add(5*time.Second)),
+		}
+
+		runConcurrently(t, s, client, 3, users, reqs)
+		require.Equal(t, int32(3), client.calls.Load())
+	})
+
+	t.Run("queries forwarding the identity are only shared by the same user", func(t *testing.T) {
+		s, client := setupCoalescing()
+		users := []*user.SignedInUser{{UserID: 1, OrgID: 1}, {UserID: 2, OrgID: 1}, {UserID: 1, OrgID: 1}}
+		reqs := []*backend.QueryDataRequest{
+			newCoalesceRequest(`{"oauthPassThru":true}`, `{"expr":"up"}`, now),
+			newCoalesceRequest(`{"oauthPassThru":true}`, `{"expr":"up"}`, now),
+			newCoalesceRequest(`{"oauthPassThru":true}`, `{"expr":"up"}`, now),
+		}
+
+		runConcurrently(t, s, client, 2, users, reqs)
+		require.Equal(t, int32(2), client.calls.Load())
+	})
+
+	t.Run("queries forwarding cookies are never shared", func(t *testing.T) {
+		s, client := setupCoalescing()
+		users := []*user.SignedInUser{{UserID: 1, OrgID: 1}, {UserID: 1, OrgID: 1}}
+		reqs := []*backend.QueryDataRequest{
+			newCoalesceRequest(`{"keepCookies":["session"]}`, `{"expr":"up"}`, now),
+			newCoalesceRequest(`{"keepCookies":["session"]}`, `{"expr":"up"}`, now),
+		}
+
+		runConcurrently(t, s, client, 2, users, reqs)
+		require.Equal(t, int32(2), client.calls.Load())
+	})
+
+	t.Run("a canceled caller does not cancel the others", func(t *testing.T) {
+		s, client := setupCoalescing()
+		req := newCoalesceRequest(`{}`, `{"expr":"up"}`, now)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		first := make(chan error)
+		go func() {
+			_, err := s.queryDataCoalesced(ctx, &user.SignedInUser{UserID: 1, OrgID: 1}, req)
+			first <- err
+		}()
+		require.Eventually(t, func() bool { return client.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+		second := make(chan error)
+		go func() {
+			_, err := s.queryDataCoalesced(context.Background(), &user.SignedInUser{UserID: 2, OrgID: 1}, req)
+			second <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-first, context.Canceled)
+
+		require.Eventually(t, func() bool { return client.calls.Load() == 2 }, time.Second, time.Millisecond)
+		close(client.release)
+		require.NoError(t, <-second)
+	})
+}
@@ -10,4 +10,7 @@ var (
 	ErrMissingDataSourceInfo = errutil.BadRequest("query.missingDataSourceInfo").MustTemplate("query missing datasource info: {{ .Public.RefId }}", errutil.WithPublic("Query {{ .Public.RefId }} is missing datasource information"))
 	ErrQueryParamMismatch    = errutil.BadRequest("query.headerMismatch", errutil.WithPublicMessage("The request headers point to a different plugin than is defined in the request body")).Errorf("plugin header/body mismatch")
 	ErrDuplicateRefId        = errutil.BadRequest("query.duplicateRefId", errutil.WithPublicMessage("Multiple queries using the same RefId is not allowed ")).Errorf("multiple queries using the same RefId is not allowed")
+
+	ErrQueryLimitExceeded       = errutil.BadRequest("query.limitExceeded").MustTemplate("query limit {{ .Public.Limit }} exceeded: {{ .Public.Detail }}", errutil.WithPublic("Query limit exceeded: {{ .Public.Detail }}"))
+	ErrTooManyConcurrentQueries = errutil.TooManyRequests("query.tooManyConcurrentQueries").MustTemplate("more than {{ .Public.Max }} concurrent queries", errutil.WithPublic("Too many concurrent queries, the maximum is {{ .Public.Max }}"))
 )
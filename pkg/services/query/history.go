@@ -0,0 +1,214 @@
+package query
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/queryhistory"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// HeaderRuleUID is set by the alert rule evaluation on the queries of a rule.
+const HeaderRuleUID = "X-Rule-Uid"
+
+// historyQueueSize is the number of captured queries waiting to be written to query history.
+// Queries captured while the queue is full are dropped.
+const historyQueueSize = 1000
+
+// historyCapture records the queries run on the server in query history, configured by the
+// capture keys of the [query_history] section. A sample of the queries is captured, failing
+// and slow queries are always captured.
+type historyCapture struct {
+	log           log.Logger
+	service       queryhistory.Service
+	enabled       bool
+	sampleRate    float64
+	slowThreshold time.Duration
+	random        func() float64
+	queue         chan queryhistory.RecordQueryInQueryHistoryCommand
+	dropped       prometheus.Counter
+}
+
+func newHistoryCapture(cfg *setting.Cfg, service queryhistory.Service, logger log.Logger, registerer prometheus.Registerer) *historyCapture {
+	section := cfg.SectionWithEnvOverrides("query_history")
+	return &historyCapture{
+		log:           logger,
+		service:       service,
+		enabled:       cfg.QueryHistoryEnabled && service != nil && section.Key("capture_enabled").MustBool(false),
+		sampleRate:    section.Key("capture_sample_rate").MustFloat64(0.1),
+		slowThreshold: section.Key("capture_slow_query_threshold").MustDuration(5 * time.Second),
+		random:        rand.Float64,
+		queue:         make(chan queryhistory.RecordQueryInQueryHistoryCommand, historyQueueSize),
+		dropped: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "query",
+			Name:      "history_capture_dropped_total",
+			Help:      "Number of captured queries dropped because the query history queue was full",
+		}),
+	}
+}
+
+// sampled reports whether a query is captured.
+func (h *historyCapture) sampled(failed bool, duration time.Duration) bool {
+	if failed || (h.slowThreshold > 0 && duration >= h.slowThreshold) {
+		return true
+	}
+	return h.random() < h.sampleRate
+}
+
+// add queues a captured query, without waiting for the queue to have room.
+func (h *historyCapture) add(cmd queryhistory.RecordQueryInQueryHistoryCommand) {
+	select {
+	case h.queue <- cmd:
+	default:
+		h.dropped.Inc()
+	}
+}
+
+// run writes the captured queries to query history until the context is done.
+func (h *historyCapture) run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case cmd := <-h.queue:
+			if err := h.service.RecordQueryInQueryHistory(ctx, cmd); err != nil {
+				h.log.Warn("Failed to record query in query history", "datasourceUid", cmd.DatasourceUID, "error", err)
+			}
+		}
+	}
+}
+
+// captureQueries captures the queries of a request, one query history entry per data source.
+func (h *historyCapture) captureQueries(ctx context.Context, user identity.Requester, parsedReq *parsedRequest, resp *backend.QueryDataResponse, err error, duration time.Duration) {
+	if !h.enabled || user == nil || parsedReq == nil {
+		return
+	}
+	failed := err != nil || (resp != nil && hasErrors(resp.Responses))
+	if !h.sampled(failed, duration) {
+		return
+	}
+
+	userID, _ := identity.UserIdentifier(user.GetNamespacedID())
+	origin, dashboardUID, panelID := queryhistory.OriginAPI, "", int64(0)
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Req != nil {
+		if dashboardUID = reqCtx.Req.Header.Get(HeaderDashboardUID); dashboardUID != "" {
+			origin = queryhistory.OriginDashboard
+			panelID, _ = strconv.ParseInt(reqCtx.Req.Header.Get(HeaderPanelID), 10, 64)
+		}
+	}
+
+	for dsUID, queries := range parsedReq.parsedQueries {
+		if expr.IsDataSource(dsUID) {
+			continue
+		}
+		refIDs := make([]string, 0, len(queries))
+		for _, q := range queries {
+			refIDs = append(refIDs, q.query.RefID)
+		}
+		cmd := queryhistory.RecordQueryInQueryHistoryCommand{
+			OrgID:         user.GetOrgID(),
+			UserID:        userID,
+			DatasourceUID: dsUID,
+			Queries:       historyQueries(rawQueries(queries)),
+			Origin:        origin,
+			DurationMs:    duration.Milliseconds(),
+			DashboardUID:  dashboardUID,
+			PanelID:       panelID,
+		}
+		setResult(&cmd, refIDs, resp, err)
+		h.add(cmd)
+	}
+}
+
+// captureAlertQueries is a query observer of the expression service that captures the queries of
+// alert rules.
+func (h *historyCapture) captureAlertQueries(_ context.Context, req *backend.QueryDataRequest, resp *backend.QueryDataResponse, err error, duration time.Duration) {
+	ruleUID := req.Headers[HeaderRuleUID]
+	if ruleUID == "" {
+		return
+	}
+	failed := err != nil || (resp != nil && hasErrors(resp.Responses))
+	if !h.sampled(failed, duration) {
+		return
+	}
+
+	dsUID := ""
+	if req.PluginContext.DataSourceInstanceSettings != nil {
+		dsUID = req.PluginContext.DataSourceInstanceSettings.UID
+	}
+	refIDs := make([]string, 0, len(req.Queries))
+	queries := make([]*simplejson.Json, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		refIDs = append(refIDs, q.RefID)
+		query, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			query = simplejson.New()
+		}
+		query.Set("refId", q.RefID)
+		queries = append(queries, query)
+	}
+	cmd := queryhistory.RecordQueryInQueryHistoryCommand{
+		OrgID:         req.PluginContext.OrgID,
+		DatasourceUID: dsUID,
+		Queries:       historyQueries(queries),
+		Origin:        queryhistory.OriginAlerting,
+		DurationMs:    duration.Milliseconds(),
+		AlertRuleUID:  ruleUID,
+	}
+	setResult(&cmd, refIDs, resp, err)
+	h.add(cmd)
+}
+
+// setResult sets the status, the error and the number of rows of the queries with refIDs.
+func setResult(cmd *queryhistory.RecordQueryInQueryHistoryCommand, refIDs []string, resp *backend.QueryDataResponse, err error) {
+	cmd.Status = queryhistory.StatusSuccess
+	if err != nil {
+		cmd.Status, cmd.Error = queryhistory.StatusError, err.Error()
+		return
+	}
+	if resp == nil {
+		return
+	}
+	for _, refID := range refIDs {
+		r, ok := resp.Responses[refID]
+		if !ok {
+			continue
+		}
+		if r.Error != nil && cmd.Error == "" {
+			cmd.Status, cmd.Error = queryhistory.StatusError, r.Error.Error()
+		}
+		for _, frame := range r.Frames {
+			cmd.RowCount += int64(frame.Rows())
+		}
+	}
+}
+
+// historyQueries returns the queries as stored in query history, a JSON list.
+func historyQueries(queries []*simplejson.Json) *simplejson.Json {
+	list := make([]any, 0, len(queries))
+	for _, q := range queries {
+		list = append(list, q.Interface())
+	}
+	return simplejson.NewFromAny(list)
+}
+
+func hasErrors(responses backend.Responses) bool {
+	for _, r := range responses {
+		if r.Error != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/queryhistory"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type fakeQueryHistoryService struct {
+	queryhistory.Service
+	recorded []queryhistory.RecordQueryInQueryHistoryCommand
+	err      error
+}
+
+func (f *fakeQueryHistoryService) RecordQueryInQueryHistory(_ context.Context, cmd queryhistory.RecordQueryInQueryHistoryCommand) error {
+	f.recorded = append(f.recorded, cmd)
+	return f.err
+}
+
+func newTestHistoryCapture(t *testing.T, service queryhistory.Service, keys map[string]string) *historyCapture {
+	t.Helper()
+	cfg := newLimitsCfg(t, map[string]map[string]string{"query_history": keys})
+	cfg.QueryHistoryEnabled = true
+	return newHistoryCapture(cfg, service, log.NewNopLogger(), prometheus.NewRegistry())
+}
+
+func queuedQueries(h *historyCapture) []queryhistory.RecordQueryInQueryHistoryCommand {
+	var cmds []queryhistory.RecordQueryInQueryHistoryCommand
+	for {
+		select {
+		case cmd := <-h.queue:
+			cmds = append(cmds, cmd)
+		default:
+			return cmds
+		}
+	}
+}
+
+func TestHistoryCapture(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		h := newTestHistoryCapture(t, &fakeQueryHistoryService{}, map[string]string{})
+		require.False(t, h.enabled)
+		require.Equal(t, 0.1, h.sampleRate)
+		require.Equal(t, 5*time.Second, h.slowThreshold)
+	})
+
+	t.Run("captures failing and slow queries and a sample of the others", func(t *testing.T) {
+		h := newTestHistoryCapture(t, &fakeQueryHistoryService{}, map[string]string{"capture_enabled": "true", "capture_sample_rate": "0.5"})
+		require.True(t, h.enabled)
+
+		h.random = func() float64 { return 0.7 }
+		require.False(t, h.sampled(false, time.Second))
+		require.True(t, h.sampled(true, time.Second))
+		require.True(t, h.sampled(false, 6*time.Second))
+
+		h.random = func() float64 { return 0.3 }
+		require.True(t, h.sampled(false, time.Second))
+	})
+
+	t.Run("captures the queries of a dashboard panel", func(t *testing.T) {
+		tc := setup(t)
+		h := newTestHistoryCapture(t, &fakeQueryHistoryService{}, map[string]string{"capture_enabled": "true", "capture_sample_rate": "0"})
+		tc.queryService.history = h
+
+		httpreq, err := http.NewRequest(http.MethodPost, "http://localhost/", nil)
+		require.NoError(t, err)
+		httpreq.Header.Set(HeaderDashboardUID, "dash1")
+		httpreq.Header.Set(HeaderPanelID, "4")
+		reqCtx := &contextmodel.ReqContext{Context: &web.Context{Req: httpreq}}
+		ctx := ctxkey.Set(context.Background(), reqCtx)
+
+		_, err = tc.queryService.QueryData(ctx, tc.signedInUser, true, metricRequestWithQueries(t, `{
+			"refId": "A",
+			"datasource": {"uid": "ds1", "type": "mysql"}
+		}`))
+		require.NoError(t, err)
+		require.Empty(t, queuedQueries(h), "successful queries that aren't sampled are not captured")
+
+		_, err = tc.queryService.QueryData(ctx, tc.signedInUser, true, metricRequestWithQueries(t, `{
+			"refId": "A",
+			"queryType": "FAIL",
+			"datasource": {"uid": "ds1", "type": "mysql"}
+		}`))
+		require.Error(t, err)
+
+		cmds := queuedQueries(h)
+		require.Len(t, cmds, 1)
+		require.Equal(t, int64(1), cmds[0].OrgID)
+		require.Equal(t, "ds1", cmds[0].DatasourceUID)
+		require.Equal(t, queryhistory.OriginDashboard, cmds[0].Origin)
+		require.Equal(t, "dash1", cmds[0].DashboardUID)
+		require.Equal(t, int64(4), cmds[0].PanelID)
+		require.Equal(t, queryhistory.StatusError, cmds[0].Status)
+		require.Contains(t, cmds[0].Error, "plugin client failed")
+		require.Equal(t, "FAIL", cmds[0].Queries.GetIndex(0).Get("queryType").MustString())
+	})
+
+	t.Run("captures the queries of alert rules", func(t *testing.T) {
+		h := newTestHistoryCapture(t, &fakeQueryHistoryService{}, map[string]string{"capture_enabled": "true", "capture_sample_rate": "1"})
+		req := &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				OrgID:                      2,
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds1"},
+			},
+			Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(`{"expr":"up"}`)}},
+		}
+		resp := &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": {Frames: data.Frames{data.NewFrame("", data.NewField("value", nil, []float64{1, 2, 3}))}},
+		}}
+
+		h.captureAlertQueries(context.Background(), req, resp, nil, time.Second)
+		require.Empty(t, queuedQueries(h), "queries without a rule are not captured")
+
+		req.Headers = map[string]string{HeaderRuleUID: "rule1"}
+		h.captureAlertQueries(context.Background(), req, resp, nil, time.Second)
+		cmds := queuedQueries(h)
+		require.Len(t, cmds, 1)
+		require.Equal(t, int64(2), cmds[0].OrgID)
+		require.Equal(t, queryhistory.OriginAlerting, cmds[0].Origin)
+		require.Equal(t, "rule1", cmds[0].AlertRuleUID)
+		require.Equal(t, queryhistory.StatusSuccess, cmds[0].Status)
+		require.Equal(t, int64(3), cmds[0].RowCount)
+		require.Equal(t, "A", cmds[0].Queries.GetIndex(0).Get("refId").MustString())
+	})
+
+	t.Run("writes the captured queries to query history", func(t *testing.T) {
+		service := &fakeQueryHistoryService{err: errors.New("failed")}
+		h := newTestHistoryCapture(t, service, map[string]string{"capture_enabled": "true"})
+		h.add(queryhistory.RecordQueryInQueryHistoryCommand{DatasourceUID: "ds1"})
+		h.add(queryhistory.RecordQueryInQueryHistoryCommand{DatasourceUID: "ds2"})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- h.run(ctx) }()
+		require.Eventually(t, func() bool { return len(h.queue) == 0 }, time.Second, 10*time.Millisecond)
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+		require.Len(t, service.recorded, 2)
+	})
+
+	t.Run("drops the captured queries when the queue is full", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		cfg := newLimitsCfg(t, map[string]map[string]string{"query_history": {"capture_enabled": "true"}})
+		cfg.QueryHistoryEnabled = true
+		h := newHistoryCapture(cfg, &fakeQueryHistoryService{}, log.NewNopLogger(), registry)
+		for i := 0; i <= historyQueueSize; i++ {
+			h.add(queryhistory.RecordQueryInQueryHistoryCommand{DatasourceUID: "ds1"})
+		}
+
+		require.Len(t, h.queue, historyQueueSize)
+		require.Equal(t, float64(1), testutil.ToFloat64(h.dropped))
+		require.Equal(t, 1, testutil.CollectAndCount(registry, "grafana_query_history_capture_dropped_total"))
+	})
+}
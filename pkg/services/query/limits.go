@@ -0,0 +1,353 @@
+package query
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+const (
+	limitsSection = "query.limits"
+
+	limitTimeRange         = "max_time_range"
+	limitInterval          = "min_interval"
+	limitQueries           = "max_queries_per_request"
+	limitConcurrentQueries = "max_concurrent_queries_per_user"
+	limitResponseSize      = "max_response_size_mb"
+)
+
+// queryLimits are the limits of the queries to a data source. Zero values are not limited.
+type queryLimits struct {
+	maxTimeRange         time.Duration
+	minInterval          time.Duration
+	maxQueries           int
+	maxConcurrentQueries int
+	maxResponseSize      int64
+}
+
+func (l queryLimits) enabled() bool {
+	return l != queryLimits{}
+}
+
+// limiter applies the limits of the [query.limits] section. The limits are overridden, from the
+// least to the most specific, by the [query.limits.role.<role>], [query.limits.datasource.<type>]
+// and [query.limits.org.<org id>] sections.
+type limiter struct {
+	log         log.Logger
+	defaults    queryLimits
+	roles       map[string]queryLimits
+	datasources map[string]queryLimits
+	orgs        map[int64]queryLimits
+
+	rejected *prometheus.CounterVec
+
+	mu       sync.Mutex
+	inflight map[string]int
+}
+
+func newLimiter(cfg *setting.Cfg, logger log.Logger, registerer prometheus.Registerer) *limiter {
+	l := &limiter{
+		log:         logger,
+		roles:       map[string]queryLimits{},
+		datasources: map[string]queryLimits{},
+		orgs:        map[int64]queryLimits{},
+		inflight:    map[string]int{},
+	}
+	l.rejected = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Subsystem: "query",
+		Name:      "limit_rejections_total",
+		Help:      "Number of data source queries rejected by the query limits",
+	}, []string{"datasource_type", "limit"})
+	l.defaults = readLimits(cfg, limitsSection, logger)
+
+	for _, section := range cfg.Raw.Sections() {
+		scope, name, ok := strings.Cut(strings.TrimPrefix(section.Name(), limitsSection+"."), ".")
+		if !strings.HasPrefix(section.Name(), limitsSection+".") || !ok || name == "" {
+			continue
+		}
+		switch scope {
+		case "role":
+			l.roles[name] = readLimits(cfg, section.Name(), logger)
+		case "datasource":
+			l.datasources[name] = readLimits(cfg, section.Name(), logger)
+		case "org":
+			orgID, err := strconv.ParseInt(name, 10, 64)
+			if err != nil {
+				logger.Warn("Invalid organization in query limits section", "section", section.Name())
+				continue
+			}
+			l.orgs[orgID] = readLimits(cfg, section.Name(), logger)
+		default:
+			logger.Warn("Unknown query limits section", "section", section.Name())
+		}
+	}
+	return l
+}
+
+// readLimits reads the limits of a section. Only the keys of the section itself are read, the
+// override sections don't inherit the keys of [query.limits] through ini child sections.
+func readLimits(cfg *setting.Cfg, name string, logger log.Logger) queryLimits {
+	section := cfg.Raw.Section(name)
+	value := func(key string) string {
+		if env := os.Getenv(setting.EnvKey(name, key)); env != "" {
+			return env
+		}
+		if slices.Contains(section.KeyStrings(), key) {
+			return strings.TrimSpace(section.Key(key).String())
+		}
+		return ""
+	}
+	duration := func(key string) time.Duration {
+		v := value(key)
+		if v == "" {
+			return 0
+		}
+		d, err := gtime.ParseDuration(v)
+		if err != nil {
+			logger.Warn("Invalid query limit", "section", name, "key", key, "value", v, "error", err)
+		}
+		return d
+	}
+	number := func(key string) int64 {
+		v := value(key)
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			logger.Warn("Invalid query limit", "section", name, "key", key, "value", v, "error", err)
+		}
+		return n
+	}
+
+	return queryLimits{
+		maxTimeRange:         duration(limitTimeRange),
+		minInterval:          duration(limitInterval),
+		maxQueries:           int(number(limitQueries)),
+		maxConcurrentQueries: int(number(limitConcurrentQueries)),
+		maxResponseSize:      number(limitResponseSize) * 1024 * 1024,
+	}
+}
+
+// merge returns the limits overridden by the non-zero limits of other
+func (l queryLimits) merge(other queryLimits) queryLimits {
+	if other.maxTimeRange != 0 {
+		l.maxTimeRange = other.maxTimeRange
+	}
+	if other.minInterval != 0 {
+		l.minInterval = other.minInterval
+	}
+	if other.maxQueries != 0 {
+		l.maxQueries = other.maxQueries
+	}
+	if other.maxConcurrentQueries != 0 {
+		l.maxConcurrentQueries = other.maxConcurrentQueries
+	}
+	if other.maxResponseSize != 0 {
+		l.maxResponseSize = other.maxResponseSize
+	}
+	return l
+}
+
+// limitsFor returns the limits of the queries of a user to a data source type
+func (l *limiter) limitsFor(user identity.Requester, datasourceType string) queryLimits {
+	limits := l.defaults
+	if user != nil {
+		limits = limits.merge(l.roles[string(user.GetOrgRole())])
+	}
+	limits = limits.merge(l.datasources[datasourceType])
+	if user != nil {
+		limits = limits.merge(l.orgs[user.GetOrgID()])
+	}
+	return limits
+}
+
+// admit checks the queries of a request against the limits. It returns the error responses of the
+// rejected data sources, which are removed from the request, and a function releasing the
+// concurrent queries of the admitted ones.
+func (l *limiter) admit(user identity.Requester, parsedReq *parsedRequest) (backend.Responses, func()) {
+	rejected := backend.Responses{}
+	total := 0
+	for uid := range parsedReq.parsedQueries {
+		if isDatasourceQueries(parsedReq.parsedQueries[uid]) {
+			total += len(parsedReq.parsedQueries[uid])
+		}
+	}
+
+	userKey := limiterUserKey(user)
+	acquired := 0
+	for uid, queries := range parsedReq.parsedQueries {
+		if !isDatasourceQueries(queries) {
+			continue
+		}
+		dsType := queries[0].datasource.Type
+		limits := l.limitsFor(user, dsType)
+		if !limits.enabled() {
+			continue
+		}
+
+		limit, err := checkQueries(limits, queries, total)
+		if err == nil && limits.maxConcurrentQueries > 0 {
+			limit, err = limitConcurrentQueries, l.acquire(userKey, len(queries), limits.maxConcurrentQueries)
+			if err == nil {
+				acquired += len(queries)
+			}
+		}
+		if err != nil {
+			l.rejected.WithLabelValues(dsType, limit).Inc()
+			l.log.Debug("Queries rejected by the query limits", "datasource", uid, "error", err)
+			for refID, resp := range buildErrorResponses(err, rawQueries(queries)).responses {
+				rejected[refID] = resp
+			}
+			delete(parsedReq.parsedQueries, uid)
+		}
+	}
+
+	return rejected, func() {
+		if acquired > 0 {
+			l.release(userKey, acquired)
+		}
+	}
+}
+
+// checkQueries returns the limit exceeded by queries, if any
+func checkQueries(limits queryLimits, queries []parsedQuery, total int) (string, error) {
+	if limits.maxQueries > 0 && total > limits.maxQueries {
+		return limitQueries, limitError(limitQueries, fmt.Sprintf("the request has %d queries, the maximum is %d", total, limits.maxQueries))
+	}
+	for _, q := range queries {
+		if tr := q.query.TimeRange.To.Sub(q.query.TimeRange.From); limits.maxTimeRange > 0 && tr > limits.maxTimeRange {
+			return limitTimeRange, limitError(limitTimeRange, fmt.Sprintf("the time range of query %s is %s, the maximum is %s", q.query.RefID, tr, limits.maxTimeRange))
+		}
+		// the interval defaults to 1s when the client doesn't send one, which is not checked
+		if limits.minInterval > 0 && hasInterval(q) && q.query.Interval < limits.minInterval {
+			return limitInterval, limitError(limitInterval, fmt.Sprintf("the interval of query %s is %s, the minimum is %s", q.query.RefID, q.query.Interval, limits.minInterval))
+		}
+	}
+	return "", nil
+}
+
+func hasInterval(q parsedQuery) bool {
+	if q.rawQuery == nil {
+		return false
+	}
+	_, ok := q.rawQuery.CheckGet("intervalMs")
+	return ok
+}
+
+func (l *limiter) acquire(userKey string, n int, max int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight[userKey]+n > max {
+		return ErrTooManyConcurrentQueries.Build(errutil.TemplateData{
+			Public: map[string]any{"Limit": limitConcurrentQueries, "Max": max},
+		})
+	}
+	l.inflight[userKey] += n
+	return nil
+}
+
+func (l *limiter) release(userKey string, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight[userKey] -= n
+	if l.inflight[userKey] <= 0 {
+		delete(l.inflight, userKey)
+	}
+}
+
+// limitResponses replaces the responses of the data sources over the maximum response size with errors
+func (l *limiter) limitResponses(user identity.Requester, parsedReq *parsedRequest, resp *backend.QueryDataResponse) {
+	if resp == nil {
+		return
+	}
+	for _, queries := range parsedReq.parsedQueries {
+		if !isDatasourceQueries(queries) {
+			continue
+		}
+		dsType := queries[0].datasource.Type
+		limits := l.limitsFor(user, dsType)
+		if limits.maxResponseSize <= 0 {
+			continue
+		}
+
+		var size int64
+		for _, q := range queries {
+			size += framesSize(resp.Responses[q.query.RefID].Frames)
+		}
+		if size <= limits.maxResponseSize {
+			continue
+		}
+		l.rejected.WithLabelValues(dsType, limitResponseSize).Inc()
+		err := limitError(limitResponseSize, fmt.Sprintf("the response is about %d MB, the maximum is %d MB", size/1024/1024, limits.maxResponseSize/1024/1024))
+		for refID, r := range buildErrorResponses(err, rawQueries(queries)).responses {
+			resp.Responses[refID] = r
+		}
+	}
+}
+
+// framesSize estimates the size of the values of frames, in bytes
+func framesSize(frames data.Frames) int64 {
+	var size int64
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			switch field.Type() {
+			case data.FieldTypeString, data.FieldTypeNullableString:
+				for i := 0; i < field.Len(); i++ {
+					if s, ok := field.ConcreteAt(i); ok {
+						size += int64(len(s.(string)))
+					}
+				}
+			default:
+				size += int64(field.Len()) * 8
+			}
+		}
+	}
+	return size
+}
+
+func limitError(limit string, detail string) error {
+	return ErrQueryLimitExceeded.Build(errutil.TemplateData{
+		Public: map[string]any{"Limit": limit, "Detail": detail},
+	})
+}
+
+func limiterUserKey(user identity.Requester) string {
+	if user == nil {
+		return ""
+	}
+	namespace, id := user.GetNamespacedID()
+	return fmt.Sprintf("%d:%s:%s", user.GetOrgID(), namespace, id)
+}
+
+// isDatasourceQueries returns false for expressions, which are not limited
+func isDatasourceQueries(queries []parsedQuery) bool {
+	return len(queries) > 0 && queries[0].datasource != nil &&
+		expr.NodeTypeFromDatasourceUID(queries[0].datasource.UID) == expr.TypeDatasourceNode
+}
+
+func rawQueries(queries []parsedQuery) []*simplejson.Json {
+	raw := make([]*simplejson.Json, 0, len(queries))
+	for _, q := range queries {
+		raw = append(raw, q.rawQuery)
+	}
+	return raw
+}
@@ -0,0 +1,194 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models/roletype"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func newLimitsCfg(t *testing.T, sections map[string]map[string]string) *setting.Cfg {
+	t.Helper()
+	cfg := setting.NewCfg()
+	for name, keys := range sections {
+		section, err := cfg.Raw.NewSection(name)
+		require.NoError(t, err)
+		for key, value := range keys {
+			_, err := section.NewKey(key, value)
+			require.NoError(t, err)
+		}
+	}
+	return cfg
+}
+
+func TestLimitsFor(t *testing.T) {
+	cfg := newLimitsCfg(t, map[string]map[string]string{
+		"query.limits":                       {"max_time_range": "30d", "max_queries_per_request": "20"},
+		"query.limits.role.Viewer":           {"max_time_range": "7d"},
+		"query.limits.datasource.prometheus": {"min_interval": "15s", "max_queries_per_request": "10"},
+		"query.limits.org.2":                 {"max_time_range": "90d", "max_concurrent_queries_per_user": "5"},
+		"query.limits.org.x":                 {"max_time_range": "1h"},
+	})
+	l := newLimiter(cfg, log.NewNopLogger(), prometheus.NewRegistry())
+
+	viewer := &user.SignedInUser{OrgID: 1, OrgRole: roletype.RoleViewer}
+	editor := &user.SignedInUser{OrgID: 1, OrgRole: roletype.RoleEditor}
+	otherOrg := &user.SignedInUser{OrgID: 2, OrgRole: roletype.RoleViewer}
+
+	require.Equal(t, queryLimits{maxTimeRange: 30 * 24 * time.Hour, maxQueries: 20}, l.limitsFor(editor, "mysql"))
+	require.Equal(t, queryLimits{maxTimeRange: 7 * 24 * time.Hour, maxQueries: 20}, l.limitsFor(viewer, "mysql"))
+	require.Equal(t, queryLimits{maxTimeRange: 7 * 24 * time.Hour, minInterval: 15 * time.Second, maxQueries: 10}, l.limitsFor(viewer, "prometheus"))
+	require.Equal(t, queryLimits{maxTimeRange: 90 * 24 * time.Hour, minInterval: 15 * time.Second, maxQueries: 10, maxConcurrentQueries: 5}, l.limitsFor(otherOrg, "prometheus"))
+	require.Len(t, l.orgs, 1)
+}
+
+func TestQueryLimits(t *testing.T) {
+	t.Run("rejects the queries of the data sources over the limits", func(t *testing.T) {
+		tc := setup(t)
+		tc.queryService.limiter = newLimiter(newLimitsCfg(t, map[string]map[string]string{
+			"query.limits.datasource.mysql": {"max_time_range": "1d"},
+		}), log.NewNopLogger(), prometheus.NewRegistry())
+
+		reqDTO := dtos.MetricRequest{
+			From: "2022-01-01",
+			To:   "2022-01-05",
+			Queries: []*simplejson.Json{
+				simplejson.NewFromAny(map[string]any{"refId": "A", "datasource": map[string]any{"uid": "ds1"}}),
+				simplejson.NewFromAny(map[string]any{"refId": "B", "datasource": map[string]any{"uid": "sEx6ZvSVk"}}),
+			},
+		}
+		resp, err := tc.queryService.QueryData(context.Background(), tc.signedInUser, true, reqDTO)
+		require.NoError(t, err)
+		require.ErrorIs(t, resp.Responses["A"].Error, ErrQueryLimitExceeded)
+		require.Contains(t, resp.Responses["A"].Error.Error(), "max_time_range")
+		require.NoError(t, resp.Responses["B"].Error)
+		require.Len(t, tc.pluginContext.req.Queries, 1)
+		require.Equal(t, "B", tc.pluginContext.req.Queries[0].RefID)
+	})
+
+	t.Run("rejects all the queries of an expression", func(t *testing.T) {
+		tc := setup(t)
+		tc.queryService.limiter = newLimiter(newLimitsCfg(t, map[string]map[string]string{
+			"query.limits": {"min_interval": "1m"},
+		}), log.NewNopLogger(), prometheus.NewRegistry())
+
+		reqDTO := dtos.MetricRequest{
+			From: "2022-01-01",
+			To:   "2022-01-02",
+			Queries: []*simplejson.Json{
+				simplejson.NewFromAny(map[string]any{"refId": "A", "intervalMs": 1000, "datasource": map[string]any{"uid": "ds1"}}),
+				simplejson.NewFromAny(map[string]any{"refId": "B", "type": "math", "expression": "$A * 2", "datasource": map[string]any{"uid": "__expr__", "type": "__expr__"}}),
+			},
+		}
+		resp, err := tc.queryService.QueryData(context.Background(), tc.signedInUser, true, reqDTO)
+		require.NoError(t, err)
+		require.ErrorIs(t, resp.Responses["A"].Error, ErrQueryLimitExceeded)
+		require.ErrorIs(t, resp.Responses["B"].Error, ErrQueryLimitExceeded)
+		require.Nil(t, tc.pluginContext.req)
+	})
+
+	t.Run("only checks the interval of queries sending one", func(t *testing.T) {
+		tc := setup(t)
+		tc.queryService.limiter = newLimiter(newLimitsCfg(t, map[string]map[string]string{
+			"query.limits": {"min_interval": "1m"},
+		}), log.NewNopLogger(), prometheus.NewRegistry())
+
+		reqDTO := dtos.MetricRequest{
+			From: "2022-01-01",
+			To:   "2022-01-02",
+			Queries: []*simplejson.Json{
+				simplejson.NewFromAny(map[string]any{"refId": "A", "datasource": map[string]any{"uid": "ds1"}}),
+			},
+		}
+		resp, err := tc.queryService.QueryData(context.Background(), tc.signedInUser, true, reqDTO)
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Len(t, tc.pluginContext.req.Queries, 1)
+	})
+
+	t.Run("counts the rejected queries in the registry", func(t *testing.T) {
+		tc := setup(t)
+		registry := prometheus.NewRegistry()
+		tc.queryService.limiter = newLimiter(newLimitsCfg(t, map[string]map[string]string{
+			"query.limits": {"max_time_range": "1d"},
+		}), log.NewNopLogger(), registry)
+
+		reqDTO := dtos.MetricRequest{
+			From: "2022-01-01",
+			To:   "2022-01-05",
+			Queries: []*simplejson.Json{
+				simplejson.NewFromAny(map[string]any{"refId": "A", "datasource": map[string]any{"uid": "ds1"}}),
+			},
+		}
+		_, err := tc.queryService.QueryData(context.Background(), tc.signedInUser, true, reqDTO)
+		require.NoError(t, err)
+		require.Equal(t, 1, testutil.CollectAndCount(registry, "grafana_query_limit_rejections_total"))
+	})
+}
+
+func TestLimiterConcurrentQueries(t *testing.T) {
+	l := newLimiter(newLimitsCfg(t, map[string]map[string]string{
+		"query.limits": {"max_concurrent_queries_per_user": "2"},
+	}), log.NewNopLogger(), prometheus.NewRegistry())
+	u := &user.SignedInUser{UserID: 1, OrgID: 1}
+
+	request := func() *parsedRequest {
+		ds := &datasources.DataSource{UID: "ds1", Type: "mysql"}
+		return &parsedRequest{parsedQueries: map[string][]parsedQuery{"ds1": {
+			{datasource: ds, query: backend.DataQuery{RefID: "A"}, rawQuery: simplejson.NewFromAny(map[string]any{"refId": "A"})},
+		}}}
+	}
+
+	rejected, release1 := l.admit(u, request())
+	require.Empty(t, rejected)
+	rejected, release2 := l.admit(u, request())
+	require.Empty(t, rejected)
+
+	rejected, release3 := l.admit(u, request())
+	require.ErrorIs(t, rejected["A"].Error, ErrTooManyConcurrentQueries)
+	release3()
+
+	rejected, _ = l.admit(&user.SignedInUser{UserID: 2, OrgID: 1}, request())
+	require.Empty(t, rejected, "the limit is per user")
+
+	release1()
+	rejected, release4 := l.admit(u, request())
+	require.Empty(t, rejected)
+	release2()
+	release4()
+	require.NotContains(t, l.inflight, limiterUserKey(u))
+}
+
+func TestLimitResponses(t *testing.T) {
+	l := newLimiter(newLimitsCfg(t, map[string]map[string]string{
+		"query.limits": {"max_response_size_mb": "1"},
+	}), log.NewNopLogger(), prometheus.NewRegistry())
+
+	ds := &datasources.DataSource{UID: "ds1", Type: "mysql"}
+	parsedReq := &parsedRequest{parsedQueries: map[string][]parsedQuery{"ds1": {
+		{datasource: ds, query: backend.DataQuery{RefID: "A"}, rawQuery: simplejson.NewFromAny(map[string]any{"refId": "A"})},
+	}}}
+
+	small := data.NewFrame("small", data.NewField("value", nil, make([]float64, 1000)))
+	resp := &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{small}}}}
+	l.limitResponses(nil, parsedReq, resp)
+	require.NoError(t, resp.Responses["A"].Error)
+
+	large := data.NewFrame("large", data.NewField("value", nil, make([]float64, 200000)))
+	resp = &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{large}}}}
+	l.limitResponses(nil, parsedReq, resp)
+	require.ErrorIs(t, resp.Responses["A"].Error, ErrQueryLimitExceeded)
+}
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -18,8 +20,12 @@ import (
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/auth/identity"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/services/dashboardusage"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
+	"github.com/grafana/grafana/pkg/services/queryhistory"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
 	"github.com/grafana/grafana/pkg/services/validations"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/grafanads"
@@ -43,7 +49,13 @@ func ProvideService(
 	pluginRequestValidator validations.PluginRequestValidator,
 	pluginClient plugins.Client,
 	pCtxProvider *plugincontext.Provider,
+	dashboardUsageService dashboardusage.Service,
+	queryLibrary querylibrary.Service,
+	queryHistoryService queryhistory.Service,
+	features featuremgmt.FeatureToggles,
+	registerer prometheus.Registerer,
 ) *ServiceImpl {
+	section := cfg.SectionWithEnvOverrides("query")
 	g := &ServiceImpl{
 		cfg:                    cfg,
 		dataSourceCache:        dataSourceCache,
@@ -51,8 +63,17 @@ func ProvideService(
 		pluginRequestValidator: pluginRequestValidator,
 		pluginClient:           pluginClient,
 		pCtxProvider:           pCtxProvider,
+		dashboardUsageService:  dashboardUsageService,
+		queryLibrary:           queryLibrary,
+		features:               features,
 		log:                    log.New("query_data"),
-		concurrentQueryLimit:   cfg.SectionWithEnvOverrides("query").Key("concurrent_query_limit").MustInt(runtime.NumCPU()),
+		concurrentQueryLimit:   section.Key("concurrent_query_limit").MustInt(runtime.NumCPU()),
+		coalesceQueries:        section.Key("coalesce_queries").MustBool(false),
+	}
+	g.limiter = newLimiter(cfg, g.log, registerer)
+	g.history = newHistoryCapture(cfg, queryHistoryService, g.log, registerer)
+	if g.history.enabled && expressionService != nil {
+		expressionService.ObserveQueries(g.history.captureAlertQueries)
 	}
 	g.log.Info("Query Service initialization")
 	return g
@@ -74,24 +95,90 @@ type ServiceImpl struct {
 	pluginRequestValidator validations.PluginRequestValidator
 	pluginClient           plugins.Client
 	pCtxProvider           *plugincontext.Provider
+	dashboardUsageService  dashboardusage.Service
+	queryLibrary           querylibrary.Service
+	features               featuremgmt.FeatureToggles
 	log                    log.Logger
 	concurrentQueryLimit   int
+	coalesceQueries        bool
+	inflight               singleflight.Group
+	limiter                *limiter
+	history                *historyCapture
 }
 
-// Run ServiceImpl.
+// Run ServiceImpl. It writes the captured queries to query history.
 func (s *ServiceImpl) Run(ctx context.Context) error {
-	<-ctx.Done()
-	return ctx.Err()
+	return s.history.run(ctx)
+}
+
+// IsDisabled returns true when no queries are captured in query history.
+func (s *ServiceImpl) IsDisabled() bool {
+	return !s.history.enabled
 }
 
 // QueryData processes queries and returns query responses. It handles queries to single or mixed datasources, as well as expressions.
 func (s *ServiceImpl) QueryData(ctx context.Context, user identity.Requester, skipDSCache bool, reqDTO dtos.MetricRequest) (*backend.QueryDataResponse, error) {
+	start := time.Now()
+	resp, err := s.queryDataLimited(ctx, user, skipDSCache, reqDTO)
+	s.recordDashboardUsage(ctx, user, reqDTO, resp, err, time.Since(start))
+	return resp, err
+}
+
+// queryDataLimited runs the queries admitted by the query limits. The queries of the rejected
+// data sources get error responses.
+func (s *ServiceImpl) queryDataLimited(ctx context.Context, user identity.Requester, skipDSCache bool, reqDTO dtos.MetricRequest) (resp *backend.QueryDataResponse, err error) {
+	parsedReq, err := s.parseMetricRequest(ctx, user, skipDSCache, reqDTO)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	defer func() {
+		s.history.captureQueries(ctx, user, parsedReq, resp, err, time.Since(start))
+	}()
+
+	rejected, release := s.limiter.admit(user, parsedReq)
+	defer release()
+
+	if len(rejected) > 0 && (parsedReq.hasExpression || len(parsedReq.parsedQueries) == 0) {
+		// expressions can't run without all their queries
+		var err error
+		for _, r := range rejected {
+			err = r.Error
+			break
+		}
+		resp = backend.NewQueryDataResponse()
+		for _, queries := range parsedReq.parsedQueries {
+			for refID, r := range buildErrorResponses(err, rawQueries(queries)).responses {
+				resp.Responses[refID] = r
+			}
+		}
+		for refID, r := range rejected {
+			resp.Responses[refID] = r
+		}
+		return resp, nil
+	}
+
+	resp, err = s.executeRequest(ctx, user, skipDSCache, reqDTO, parsedReq)
+	if err != nil {
+		return nil, err
+	}
+	s.limiter.limitResponses(user, parsedReq, resp)
+	for refID, r := range rejected {
+		resp.Responses[refID] = r
+	}
+	return resp, nil
+}
+
+func (s *ServiceImpl) queryData(ctx context.Context, user identity.Requester, skipDSCache bool, reqDTO dtos.MetricRequest) (*backend.QueryDataResponse, error) {
 	// Parse the request into parsed queries grouped by datasource uid
 	parsedReq, err := s.parseMetricRequest(ctx, user, skipDSCache, reqDTO)
 	if err != nil {
 		return nil, err
 	}
+	return s.executeRequest(ctx, user, skipDSCache, reqDTO, parsedReq)
+}
 
+func (s *ServiceImpl) executeRequest(ctx context.Context, user identity.Requester, skipDSCache bool, reqDTO dtos.MetricRequest, parsedReq *parsedRequest) (*backend.QueryDataResponse, error) {
 	// If there are expressions, handle them and return
 	if parsedReq.hasExpression {
 		return s.handleExpressions(ctx, user, parsedReq)
@@ -104,6 +191,44 @@ func (s *ServiceImpl) QueryData(ctx context.Context, user identity.Requester, sk
 	return s.executeConcurrentQueries(ctx, user, skipDSCache, reqDTO, parsedReq.parsedQueries)
 }
 
+// recordDashboardUsage adds the queries to the usage of the dashboard sending them, if any.
+func (s *ServiceImpl) recordDashboardUsage(ctx context.Context, user identity.Requester, reqDTO dtos.MetricRequest, resp *backend.QueryDataResponse, err error, duration time.Duration) {
+	reqCtx := contexthandler.FromContext(ctx)
+	if reqCtx == nil || reqCtx.Req == nil || user == nil {
+		return
+	}
+	dashboardUID := reqCtx.Req.Header.Get(HeaderDashboardUID)
+	if dashboardUID == "" {
+		return
+	}
+
+	stats := dashboardusage.QueryStats{
+		Queries:  int64(len(reqDTO.Queries)),
+		Duration: duration,
+	}
+	if err != nil {
+		stats.Errors = stats.Queries
+	} else if resp != nil {
+		for _, r := range resp.Responses {
+			if r.Error != nil {
+				stats.Errors++
+			}
+		}
+	}
+	s.dashboardUsageService.RecordQueries(ctx, user, dashboardUID, stats)
+}
+
+// requestDashboardUID returns the saved dashboard the queries of the request come from, if any.
+func requestDashboardUID(ctx context.Context, reqDTO dtos.MetricRequest) string {
+	if reqDTO.DashboardUID != "" {
+		return reqDTO.DashboardUID
+	}
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Req != nil {
+		return reqCtx.Req.Header.Get(HeaderDashboardUID)
+	}
+	return ""
+}
+
 // splitResponse contains the results of a concurrent data source query - the response and any headers
 type splitResponse struct {
 	responses backend.Responses
@@ -145,7 +270,7 @@ func (s *ServiceImpl) executeConcurrentQueries(ctx context.Context, user identit
 			defer recoveryFn(subDTO.Queries)
 
 			ctxCopy := contexthandler.CopyWithReqContext(ctx)
-			subResp, err := s.QueryData(ctxCopy, user, skipDSCache, subDTO)
+			subResp, err := s.queryData(ctxCopy, user, skipDSCache, subDTO)
 			if err == nil {
 				reqCtx, header := contexthandler.FromContext(ctxCopy), http.Header{}
 				if reqCtx != nil {
@@ -267,7 +392,7 @@ func (s *ServiceImpl) handleQuerySingleDatasource(ctx context.Context, user iden
 		req.Queries = append(req.Queries, q.query)
 	}
 
-	return s.pluginClient.QueryData(ctx, req)
+	return s.queryDataCoalesced(ctx, user, req)
 }
 
 // parseRequest parses a request into parsed queries grouped by datasource uid
@@ -283,9 +408,15 @@ func (s *ServiceImpl) parseMetricRequest(ctx context.Context, user identity.Requ
 		dsTypes:       make(map[string]bool),
 	}
 
+	// Panel queries can reference a library query, which replaces them before anything else
+	queries, err := s.queryLibrary.ResolveQueries(ctx, user, requestDashboardUID(ctx, reqDTO), reqDTO.Queries)
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse the queries and store them by datasource
 	datasourcesByUid := map[string]*datasources.DataSource{}
-	for _, query := range reqDTO.Queries {
+	for _, query := range queries {
 		ds, err := s.getDataSourceFromQuery(ctx, user, skipDSCache, query, datasourcesByUid)
 		if err != nil {
 			return nil, err
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -30,12 +31,15 @@ import (
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboardusage/dashboardusagetest"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
 	pluginSettings "github.com/grafana/grafana/pkg/services/pluginsintegration/pluginsettings/service"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
+	"github.com/grafana/grafana/pkg/services/querylibrary/querylibrarytest"
 	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
 	secretsmng "github.com/grafana/grafana/pkg/services/secrets/manager"
@@ -263,6 +267,74 @@ func TestParseMetricRequest(t *testing.T) {
 		_, err := tc.queryService.parseMetricRequest(context.Background(), tc.signedInUser, true, mr)
 		require.Error(t, err)
 	})
+
+	t.Run("Test a query referencing a library query", func(t *testing.T) {
+		tc := setup(t)
+		tc.queryLibrary.Models["cpu"] = simplejson.NewFromAny(map[string]any{
+			"refId":      "X",
+			"datasource": map[string]any{"uid": "sEx6ZvSVk", "type": "testdata"},
+			"scenarioId": "random_walk",
+		})
+		mr := metricRequestWithQueries(t, `{
+			"refId": "A",
+			"maxDataPoints": 50,
+			"libraryQuery": {
+				"uid": "cpu"
+			}
+		}`, `{
+			"refId": "B",
+			"datasource": {
+				"uid": "gIEkMvIVz",
+				"type": "postgres"
+			}
+		}`)
+		parsedReq, err := tc.queryService.parseMetricRequest(context.Background(), tc.signedInUser, true, mr)
+		require.NoError(t, err)
+		require.Contains(t, parsedReq.parsedQueries, "sEx6ZvSVk")
+		require.Len(t, parsedReq.parsedQueries["sEx6ZvSVk"], 1)
+		q := parsedReq.parsedQueries["sEx6ZvSVk"][0]
+		assert.Equal(t, "A", q.query.RefID)
+		assert.Equal(t, int64(50), q.query.MaxDataPoints)
+		assert.Equal(t, "random_walk", q.rawQuery.Get("scenarioId").MustString())
+		assert.Contains(t, parsedReq.parsedQueries, "gIEkMvIVz")
+
+		mr = metricRequestWithQueries(t, `{
+			"refId": "A",
+			"libraryQuery": {
+				"uid": "missing"
+			}
+		}`)
+		_, err = tc.queryService.parseMetricRequest(context.Background(), tc.signedInUser, true, mr)
+		require.ErrorIs(t, err, querylibrary.ErrLibraryQueryNotFound)
+	})
+
+	t.Run("Test the dashboard of queries referencing a library query", func(t *testing.T) {
+		tc := setup(t)
+		tc.queryLibrary.Models["cpu"] = simplejson.NewFromAny(map[string]any{
+			"datasource": map[string]any{"uid": "sEx6ZvSVk", "type": "testdata"},
+		})
+		query := `{
+			"refId": "A",
+			"libraryQuery": {
+				"uid": "cpu"
+			}
+		}`
+
+		httpreq, err := http.NewRequest(http.MethodPost, "http://localhost/", nil)
+		require.NoError(t, err)
+		httpreq.Header.Set(HeaderDashboardUID, "dash1")
+		ctx := ctxkey.Set(context.Background(), &contextmodel.ReqContext{Context: &web.Context{Req: httpreq}})
+		_, err = tc.queryService.parseMetricRequest(ctx, tc.signedInUser, true, metricRequestWithQueries(t, query))
+		require.NoError(t, err)
+		assert.Equal(t, "dash1", tc.queryLibrary.DashboardUID)
+
+		// set by the public dashboards
+		mr := metricRequestWithQueries(t, query)
+		mr.DashboardUID = "dash2"
+		_, err = tc.queryService.parseMetricRequest(context.Background(), tc.signedInUser, true, mr)
+		require.NoError(t, err)
+		assert.Equal(t, "dash2", tc.queryLibrary.DashboardUID)
+	})
 }
 
 func TestQueryDataMultipleSources(t *testing.T) {
@@ -481,9 +553,11 @@ func setup(t *testing.T) *testContext {
 	)
 	exprService := expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, pc, pCtxProvider,
 		&featuremgmt.FeatureManager{}, nil, tracing.InitializeTracerForTest())
-	queryService := ProvideService(setting.NewCfg(), dc, exprService, rv, pc, pCtxProvider) // provider belonging to this package
+	ql := querylibrarytest.NewFakeService()
+	queryService := ProvideService(setting.NewCfg(), dc, exprService, rv, pc, pCtxProvider, dashboardusagetest.NewFakeService(), ql, nil, featuremgmt.WithFeatures(), prometheus.NewRegistry()) // provider belonging to this package
 	return &testContext{
 		pluginContext:          pc,
+		queryLibrary:           ql,
 		secretStore:            ss,
 		pluginRequestValidator: rv,
 		queryService:           queryService,
@@ -495,6 +569,7 @@ type testContext struct {
 	pluginContext          *fakePluginClient
 	secretStore            secretskvs.SecretsKVStore
 	pluginRequestValidator *fakePluginRequestValidator
+	queryLibrary           *querylibrarytest.FakeService
 	queryService           *ServiceImpl // implementation belonging to this package
 	signedInUser           *user.SignedInUser
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/middleware"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/tsdb/legacydata"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
@@ -56,12 +57,25 @@ func (s *QueryHistoryService) createHandler(c *contextmodel.ReqContext) response
 // Query history search supports pagination. Use the `limit` parameter to control the maximum number of queries returned; the default limit is 100.
 // You can also use the `page` query parameter to fetch queries from any page other than the first one.
 //
+// Use the `allUsers` parameter to search the queries of all the users of the organization, this requires the Admin role.
+//
 // Responses:
 // 200: getQueryHistorySearchResponse
 // 401: unauthorisedError
+// 403: forbiddenError
 // 500: internalServerError
 func (s *QueryHistoryService) searchHandler(c *contextmodel.ReqContext) response.Response {
 	timeRange := legacydata.NewDataTimeRange(c.Query("from"), c.Query("to"))
+	allUsers := c.QueryBoolWithDefault("allUsers", false)
+	if allUsers && !c.SignedInUser.HasRole(org.RoleAdmin) {
+		return response.Error(http.StatusForbidden, "Only organization admins can search the queries of all users", nil)
+	}
+
+	// Explore only shows its own queries, unless the captured ones are asked for
+	origins := c.QueryStrings("origin")
+	if len(origins) == 0 {
+		origins = []string{OriginExplore}
+	}
 
 	query := SearchInQueryHistoryQuery{
 		DatasourceUIDs: c.QueryStrings("datasourceUid"),
@@ -72,6 +86,10 @@ func (s *QueryHistoryService) searchHandler(c *contextmodel.ReqContext) response
 		Limit:          c.QueryInt("limit"),
 		From:           timeRange.GetFromAsSecondsEpoch(),
 		To:             timeRange.GetToAsSecondsEpoch(),
+		Origins:        origins,
+		Status:         c.Query("status"),
+		MinDurationMs:  c.QueryInt64("minDurationMs"),
+		AllUsers:       allUsers,
 	}
 
 	result, err := s.SearchInQueryHistory(c.Req.Context(), c.SignedInUser, query)
@@ -214,7 +232,7 @@ type SearchQueriesParams struct {
 	// in:query
 	// required: false
 	// default: time-desc
-	// Enum: time-desc,time-asc
+	// Enum: time-desc,time-asc,duration-desc
 	Sort string `json:"sort"`
 	// Use this parameter to access hits beyond limit. Numbering starts at 1. limit param acts as page size.
 	// in:query
@@ -232,6 +250,25 @@ type SearchQueriesParams struct {
 	// in:query
 	// required: false
 	To int64 `json:"to"`
+	// List of origins of the queries to search for: explore, dashboard, alerting or api. Defaults to explore.
+	// in:query
+	// required: false
+	// type: array
+	// collectionFormat: multi
+	Origin []string `json:"origin"`
+	// Status of the queries to search for, only set on the queries captured on the server
+	// in:query
+	// required: false
+	// Enum: success,error
+	Status string `json:"status"`
+	// Minimum duration in milliseconds of the queries to search for
+	// in:query
+	// required: false
+	MinDurationMs int64 `json:"minDurationMs"`
+	// Flag indicating if the queries of all the users of the organization should be returned. Requires the Admin role.
+	// in:query
+	// required: false
+	AllUsers bool `json:"allUsers"`
 }
 
 // swagger:parameters createQuery
@@ -19,6 +19,7 @@ func (s QueryHistoryService) createQuery(ctx context.Context, user *user.SignedI
 		CreatedBy:     user.UserID,
 		CreatedAt:     s.now().Unix(),
 		Comment:       "",
+		Origin:        OriginExplore,
 	}
 
 	err := s.store.WithDbSession(ctx, func(session *db.Session) error {
@@ -37,11 +38,37 @@ func (s QueryHistoryService) createQuery(ctx context.Context, user *user.SignedI
 		Comment:       queryHistory.Comment,
 		Queries:       queryHistory.Queries,
 		Starred:       false,
+		Origin:        queryHistory.Origin,
 	}
 
 	return dto, nil
 }
 
+// recordQuery adds a query captured on the server into query history
+func (s QueryHistoryService) recordQuery(ctx context.Context, cmd RecordQueryInQueryHistoryCommand) error {
+	queryHistory := QueryHistory{
+		OrgID:         cmd.OrgID,
+		UID:           util.GenerateShortUID(),
+		Queries:       cmd.Queries,
+		DatasourceUID: cmd.DatasourceUID,
+		CreatedBy:     cmd.UserID,
+		CreatedAt:     s.now().Unix(),
+		Origin:        cmd.Origin,
+		Status:        cmd.Status,
+		Error:         cmd.Error,
+		DurationMs:    cmd.DurationMs,
+		RowCount:      cmd.RowCount,
+		DashboardUID:  cmd.DashboardUID,
+		PanelID:       cmd.PanelID,
+		AlertRuleUID:  cmd.AlertRuleUID,
+	}
+
+	return s.store.WithDbSession(ctx, func(session *db.Session) error {
+		_, err := session.Insert(&queryHistory)
+		return err
+	})
+}
+
 // searchQueries searches for queries in query history based on provided parameters
 func (s QueryHistoryService) searchQueries(ctx context.Context, user *user.SignedInUser, query SearchInQueryHistoryQuery) (QueryHistorySearchResult, error) {
 	var dtos []QueryHistoryDTO
@@ -72,6 +99,14 @@ func (s QueryHistoryService) searchQueries(ctx context.Context, user *user.Signe
 			query_history.created_at AS created_at,
 			query_history.comment,
 			query_history.queries,
+			query_history.origin,
+			query_history.status,
+			query_history.error,
+			query_history.duration_ms,
+			query_history.row_count,
+			query_history.dashboard_uid,
+			query_history.panel_id,
+			query_history.alert_rule_uid,
 		`)
 		writeStarredSQL(query, s.store, &dtosBuilder)
 		writeFiltersSQL(query, user, s.store, &dtosBuilder)
@@ -308,28 +343,24 @@ func (s QueryHistoryService) deleteStaleQueries(ctx context.Context, olderThan i
 	return int(rowsCount), nil
 }
 
-// enforceQueryHistoryRowLimit is run in scheduled cleanup and it removes queries and stars that exceeded limit
+// enforceQueryHistoryRowLimit is run in scheduled cleanup and it removes queries and stars that exceeded limit.
+// Only the queries run in Explore count towards the limit of queries, the captured ones have their own limit.
 func (s QueryHistoryService) enforceQueryHistoryRowLimit(ctx context.Context, limit int, starredQueries bool) (int, error) {
+	if !starredQueries {
+		return s.enforceRowLimit(ctx, limit, "query_history.origin = ?")
+	}
+
 	var deletedRowsCount int64
 
 	err := s.store.WithTransactionalDbSession(ctx, func(session *db.Session) error {
-		var rowsCount int64
-		var err error
-		if starredQueries {
-			rowsCount, err = session.Table("query_history_star").Count(QueryHistoryStar{})
-		} else {
-			rowsCount, err = session.Table("query_history").Count(QueryHistory{})
-		}
-
+		rowsCount, err := session.Table("query_history_star").Count(QueryHistoryStar{})
 		if err != nil {
 			return err
 		}
 
 		countRowsToDelete := rowsCount - int64(limit)
 		if countRowsToDelete > 0 {
-			var sql string
-			if starredQueries {
-				sql = `DELETE FROM query_history_star 
+			sql := `DELETE FROM query_history_star 
 					WHERE id IN (
 						SELECT id FROM (
 							SELECT id FROM query_history_star
@@ -337,8 +368,50 @@ func (s QueryHistoryService) enforceQueryHistoryRowLimit(ctx context.Context, li
 							LIMIT ?
 						) AS q
 					)`
-			} else {
-				sql = `DELETE 
+
+			sqlLimit := countRowsToDelete
+			if sqlLimit > 10000 {
+				sqlLimit = 10000
+			}
+
+			res, err := session.Exec(sql, strconv.FormatInt(sqlLimit, 10))
+			if err != nil {
+				return err
+			}
+
+			deletedRowsCount, err = res.RowsAffected()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deletedRowsCount), nil
+}
+
+// enforceCapturedQueriesRowLimit is run in scheduled cleanup and it removes the captured queries that exceeded limit
+func (s QueryHistoryService) enforceCapturedQueriesRowLimit(ctx context.Context, limit int) (int, error) {
+	return s.enforceRowLimit(ctx, limit, "query_history.origin <> ?")
+}
+
+// enforceRowLimit removes the oldest queries that are not starred among the queries matching the origin
+// condition, until there are no more than limit of them.
+func (s QueryHistoryService) enforceRowLimit(ctx context.Context, limit int, originCondition string) (int, error) {
+	var deletedRowsCount int64
+
+	err := s.store.WithTransactionalDbSession(ctx, func(session *db.Session) error {
+		rowsCount, err := session.Table("query_history").Where(originCondition, OriginExplore).Count(QueryHistory{})
+		if err != nil {
+			return err
+		}
+
+		countRowsToDelete := rowsCount - int64(limit)
+		if countRowsToDelete > 0 {
+			sql := `DELETE 
 					FROM query_history 
 					WHERE uid IN (
 						SELECT uid FROM (
@@ -346,18 +419,18 @@ func (s QueryHistoryService) enforceQueryHistoryRowLimit(ctx context.Context, li
 							LEFT JOIN query_history_star
 							ON query_history_star.query_uid = query_history.uid
 							WHERE query_history_star.query_uid IS NULL
+							AND ` + originCondition + `
 							ORDER BY query_history.id ASC
 							LIMIT ?
 						) AS q
 					)`
-			}
 
 			sqlLimit := countRowsToDelete
 			if sqlLimit > 10000 {
 				sqlLimit = 10000
 			}
 
-			res, err := session.Exec(sql, strconv.FormatInt(sqlLimit, 10))
+			res, err := session.Exec(sql, OriginExplore, strconv.FormatInt(sqlLimit, 10))
 			if err != nil {
 				return err
 			}
@@ -12,6 +12,20 @@ var (
 	ErrQueryAlreadyStarred  = errors.New("query was already starred")
 )
 
+// The origin of a query tells where it was run from. Queries from Explore are posted by the
+// frontend, the others are captured on the server when query capture is enabled.
+const (
+	OriginExplore   = "explore"
+	OriginDashboard = "dashboard"
+	OriginAlerting  = "alerting"
+	OriginAPI       = "api"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
 // QueryHistory is the model for query history definitions
 type QueryHistory struct {
 	ID            int64  `xorm:"pk autoincr 'id'"`
@@ -22,6 +36,15 @@ type QueryHistory struct {
 	CreatedAt     int64
 	Comment       string
 	Queries       *simplejson.Json
+	Origin        string
+	// The fields below are only set on the queries captured on the server
+	Status       string
+	Error        string
+	DurationMs   int64
+	RowCount     int64
+	DashboardUID string `xorm:"dashboard_uid"`
+	PanelID      int64  `xorm:"panel_id"`
+	AlertRuleUID string `xorm:"alert_rule_uid"`
 }
 
 // QueryHistory is the model for query history star definitions
@@ -40,6 +63,11 @@ type SearchInQueryHistoryQuery struct {
 	Limit          int      `json:"limit"`
 	From           int64    `json:"from"`
 	To             int64    `json:"to"`
+	Origins        []string `json:"origins"`
+	Status         string   `json:"status"`
+	MinDurationMs  int64    `json:"minDurationMs"`
+	// AllUsers searches the queries of every user of the organization, instead of the user's own queries.
+	AllUsers bool `json:"allUsers"`
 }
 
 type QueryHistoryDTO struct {
@@ -50,6 +78,14 @@ type QueryHistoryDTO struct {
 	Comment       string           `json:"comment"`
 	Queries       *simplejson.Json `json:"queries"`
 	Starred       bool             `json:"starred"`
+	Origin        string           `json:"origin,omitempty"`
+	Status        string           `json:"status,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	DurationMs    int64            `json:"durationMs,omitempty"`
+	RowCount      int64            `json:"rowCount,omitempty"`
+	DashboardUID  string           `json:"dashboardUid,omitempty" xorm:"dashboard_uid"`
+	PanelID       int64            `json:"panelId,omitempty" xorm:"panel_id"`
+	AlertRuleUID  string           `json:"alertRuleUid,omitempty" xorm:"alert_rule_uid"`
 }
 
 // QueryHistoryResponse is a response struct for QueryHistoryDTO
@@ -91,3 +127,19 @@ type PatchQueryCommentInQueryHistoryCommand struct {
 	// Updated comment
 	Comment string `json:"comment"`
 }
+
+// RecordQueryInQueryHistoryCommand is the command for adding a query captured on the server to query history
+type RecordQueryInQueryHistoryCommand struct {
+	OrgID         int64
+	UserID        int64
+	DatasourceUID string
+	Queries       *simplejson.Json
+	Origin        string
+	Status        string
+	Error         string
+	DurationMs    int64
+	RowCount      int64
+	DashboardUID  string
+	PanelID       int64
+	AlertRuleUID  string
+}
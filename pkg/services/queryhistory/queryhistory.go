@@ -30,6 +30,7 @@ func ProvideService(cfg *setting.Cfg, sqlStore db.DB, routeRegister routing.Rout
 
 type Service interface {
 	CreateQueryInQueryHistory(ctx context.Context, user *user.SignedInUser, cmd CreateQueryInQueryHistoryCommand) (QueryHistoryDTO, error)
+	RecordQueryInQueryHistory(ctx context.Context, cmd RecordQueryInQueryHistoryCommand) error
 	SearchInQueryHistory(ctx context.Context, user *user.SignedInUser, query SearchInQueryHistoryQuery) (QueryHistorySearchResult, error)
 	DeleteQueryFromQueryHistory(ctx context.Context, user *user.SignedInUser, UID string) (int64, error)
 	PatchQueryCommentInQueryHistory(ctx context.Context, user *user.SignedInUser, UID string, cmd PatchQueryCommentInQueryHistoryCommand) (QueryHistoryDTO, error)
@@ -37,6 +38,7 @@ type Service interface {
 	UnstarQueryInQueryHistory(ctx context.Context, user *user.SignedInUser, UID string) (QueryHistoryDTO, error)
 	DeleteStaleQueriesInQueryHistory(ctx context.Context, olderThan int64) (int, error)
 	EnforceRowLimitInQueryHistory(ctx context.Context, limit int, starredQueries bool) (int, error)
+	EnforceRowLimitInCapturedQueries(ctx context.Context, limit int) (int, error)
 }
 
 type QueryHistoryService struct {
@@ -51,6 +53,10 @@ func (s QueryHistoryService) CreateQueryInQueryHistory(ctx context.Context, user
 	return s.createQuery(ctx, user, cmd)
 }
 
+func (s QueryHistoryService) RecordQueryInQueryHistory(ctx context.Context, cmd RecordQueryInQueryHistoryCommand) error {
+	return s.recordQuery(ctx, cmd)
+}
+
 func (s QueryHistoryService) SearchInQueryHistory(ctx context.Context, user *user.SignedInUser, query SearchInQueryHistoryQuery) (QueryHistorySearchResult, error) {
 	return s.searchQueries(ctx, user, query)
 }
@@ -78,3 +84,7 @@ func (s QueryHistoryService) DeleteStaleQueriesInQueryHistory(ctx context.Contex
 func (s QueryHistoryService) EnforceRowLimitInQueryHistory(ctx context.Context, limit int, starredQueries bool) (int, error) {
 	return s.enforceQueryHistoryRowLimit(ctx, limit, starredQueries)
 }
+
+func (s QueryHistoryService) EnforceRowLimitInCapturedQueries(ctx context.Context, limit int) (int, error) {
+	return s.enforceCapturedQueriesRowLimit(ctx, limit)
+}
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
 func TestIntegrationEnforceRowLimitInQueryHistory(t *testing.T) {
@@ -28,6 +30,36 @@ func TestIntegrationEnforceRowLimitInQueryHistory(t *testing.T) {
 			require.Equal(t, 1, rowsDeleted)
 		})
 
+	testScenarioWithQueryInQueryHistory(t, "Enforce separate limits for queries run in Explore and captured queries",
+		func(t *testing.T, sc scenarioContext) {
+			for _, origin := range []string{OriginDashboard, OriginAlerting} {
+				err := sc.service.RecordQueryInQueryHistory(context.Background(), RecordQueryInQueryHistoryCommand{
+					OrgID:         testOrgID,
+					UserID:        testUserID,
+					DatasourceUID: testDsUID1,
+					Queries:       simplejson.New(),
+					Origin:        origin,
+				})
+				require.NoError(t, err)
+			}
+
+			rowsDeleted, err := sc.service.EnforceRowLimitInQueryHistory(context.Background(), 1, false)
+			require.NoError(t, err)
+			require.Equal(t, 0, rowsDeleted, "captured queries don't count towards the limit of queries run in Explore")
+
+			rowsDeleted, err = sc.service.EnforceRowLimitInCapturedQueries(context.Background(), 1)
+			require.NoError(t, err)
+			require.Equal(t, 1, rowsDeleted)
+
+			rowsDeleted, err = sc.service.EnforceRowLimitInQueryHistory(context.Background(), 0, false)
+			require.NoError(t, err)
+			require.Equal(t, 1, rowsDeleted)
+
+			rowsDeleted, err = sc.service.EnforceRowLimitInCapturedQueries(context.Background(), 1)
+			require.NoError(t, err)
+			require.Equal(t, 0, rowsDeleted)
+		})
+
 	// In this scenario we have 2 starred queries and 1 not starred query
 	testScenarioWithMultipleQueriesInQueryHistory(t, "Enforce limit for stars in query_history_star",
 		func(t *testing.T, sc scenarioContext) {
@@ -0,0 +1,110 @@
+package queryhistory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+func TestIntegrationRecordQueryInQueryHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	testScenario(t, "When a query is recorded, it should be returned with its details",
+		func(t *testing.T, sc scenarioContext) {
+			err := sc.service.RecordQueryInQueryHistory(context.Background(), RecordQueryInQueryHistoryCommand{
+				OrgID:         testOrgID,
+				UserID:        testUserID,
+				DatasourceUID: testDsUID1,
+				Queries:       simplejson.NewFromAny([]any{map[string]any{"expr": "test"}}),
+				Origin:        OriginDashboard,
+				Status:        StatusError,
+				Error:         "query timed out",
+				DurationMs:    5200,
+				DashboardUID:  "dash1",
+				PanelID:       4,
+			})
+			require.NoError(t, err)
+
+			sc.reqContext.Req.Form.Add("origin", OriginDashboard)
+			resp := sc.service.searchHandler(sc.reqContext)
+			var response QueryHistorySearchResponse
+			err = json.Unmarshal(resp.Body(), &response)
+			require.NoError(t, err)
+			require.Equal(t, 200, resp.Status())
+			require.Equal(t, 1, response.Result.TotalCount)
+			query := response.Result.QueryHistory[0]
+			require.Equal(t, OriginDashboard, query.Origin)
+			require.Equal(t, StatusError, query.Status)
+			require.Equal(t, "query timed out", query.Error)
+			require.Equal(t, int64(5200), query.DurationMs)
+			require.Equal(t, "dash1", query.DashboardUID)
+			require.Equal(t, int64(4), query.PanelID)
+		})
+
+	testScenarioWithQueryInQueryHistory(t, "When queries are searched by origin, status and duration, it should return the matching queries",
+		func(t *testing.T, sc scenarioContext) {
+			require.Equal(t, OriginExplore, sc.initialResult.Result.Origin)
+
+			for _, cmd := range []RecordQueryInQueryHistoryCommand{
+				{Origin: OriginDashboard, Status: StatusSuccess, DurationMs: 100},
+				{Origin: OriginDashboard, Status: StatusError, DurationMs: 6000},
+				{Origin: OriginAlerting, Status: StatusSuccess, DurationMs: 7000, AlertRuleUID: "rule1"},
+			} {
+				cmd.OrgID = testOrgID
+				cmd.UserID = testUserID
+				cmd.DatasourceUID = testDsUID1
+				cmd.Queries = simplejson.New()
+				require.NoError(t, sc.service.RecordQueryInQueryHistory(context.Background(), cmd))
+			}
+
+			search := func(params map[string][]string) QueryHistorySearchResult {
+				sc.reqContext.Req.Form = params
+				resp := sc.service.searchHandler(sc.reqContext)
+				require.Equal(t, 200, resp.Status())
+				var response QueryHistorySearchResponse
+				require.NoError(t, json.Unmarshal(resp.Body(), &response))
+				return response.Result
+			}
+
+			captured := []string{OriginDashboard, OriginAlerting}
+			require.Equal(t, 1, search(map[string][]string{}).TotalCount, "only the queries run in Explore are searched by default")
+			require.Equal(t, 1, search(map[string][]string{"origin": {OriginExplore}}).TotalCount)
+			require.Equal(t, 3, search(map[string][]string{"origin": captured}).TotalCount)
+			require.Equal(t, 1, search(map[string][]string{"origin": captured, "status": {StatusError}}).TotalCount)
+
+			slow := search(map[string][]string{"origin": captured, "minDurationMs": {"5000"}, "sort": {"duration-desc"}})
+			require.Equal(t, 2, slow.TotalCount)
+			require.Equal(t, "rule1", slow.QueryHistory[0].AlertRuleUID)
+			require.Equal(t, int64(6000), slow.QueryHistory[1].DurationMs)
+		})
+
+	testScenario(t, "When queries of all users are searched, it should require the Admin role",
+		func(t *testing.T, sc scenarioContext) {
+			err := sc.service.RecordQueryInQueryHistory(context.Background(), RecordQueryInQueryHistoryCommand{
+				OrgID:         testOrgID,
+				DatasourceUID: testDsUID1,
+				Queries:       simplejson.New(),
+				Origin:        OriginAlerting,
+				Status:        StatusSuccess,
+			})
+			require.NoError(t, err)
+
+			sc.reqContext.Req.Form.Add("origin", OriginAlerting)
+			sc.reqContext.Req.Form.Add("allUsers", "true")
+			resp := sc.service.searchHandler(sc.reqContext)
+			require.Equal(t, 403, resp.Status())
+
+			sc.reqContext.SignedInUser.OrgRole = org.RoleAdmin
+			resp = sc.service.searchHandler(sc.reqContext)
+			require.Equal(t, 200, resp.Status())
+			var response QueryHistorySearchResponse
+			require.NoError(t, json.Unmarshal(resp.Body(), &response))
+			require.Equal(t, 1, response.Result.TotalCount)
+		})
+}
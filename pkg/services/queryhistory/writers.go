@@ -23,9 +23,14 @@ func writeStarredSQL(query SearchInQueryHistoryQuery, sqlStore db.DB, builder *d
 }
 
 func writeFiltersSQL(query SearchInQueryHistoryQuery, user *user.SignedInUser, sqlStore db.DB, builder *db.SQLBuilder) {
-	params := []any{user.OrgID, user.UserID, query.From, query.To, "%" + query.SearchString + "%", "%" + query.SearchString + "%"}
+	params := []any{user.OrgID, query.From, query.To, "%" + query.SearchString + "%", "%" + query.SearchString + "%"}
 	var sql bytes.Buffer
-	sql.WriteString(" WHERE query_history.org_id = ? AND query_history.created_by = ? AND query_history.created_at >= ? AND query_history.created_at <= ? AND (query_history.queries " + sqlStore.GetDialect().LikeStr() + " ? OR query_history.comment " + sqlStore.GetDialect().LikeStr() + " ?) ")
+	sql.WriteString(" WHERE query_history.org_id = ? AND query_history.created_at >= ? AND query_history.created_at <= ? AND (query_history.queries " + sqlStore.GetDialect().LikeStr() + " ? OR query_history.comment " + sqlStore.GetDialect().LikeStr() + " ?) ")
+
+	if !query.AllUsers {
+		params = append(params, user.UserID)
+		sql.WriteString(" AND query_history.created_by = ? ")
+	}
 
 	if len(query.DatasourceUIDs) > 0 {
 		for _, uid := range query.DatasourceUIDs {
@@ -33,12 +38,31 @@ func writeFiltersSQL(query SearchInQueryHistoryQuery, user *user.SignedInUser, s
 		}
 		sql.WriteString(" AND query_history.datasource_uid IN (? " + strings.Repeat(",?", len(query.DatasourceUIDs)-1) + ") ")
 	}
+
+	if len(query.Origins) > 0 {
+		for _, origin := range query.Origins {
+			params = append(params, origin)
+		}
+		sql.WriteString(" AND query_history.origin IN (? " + strings.Repeat(",?", len(query.Origins)-1) + ") ")
+	}
+
+	if query.Status != "" {
+		params = append(params, query.Status)
+		sql.WriteString(" AND query_history.status = ? ")
+	}
+
+	if query.MinDurationMs > 0 {
+		params = append(params, query.MinDurationMs)
+		sql.WriteString(" AND query_history.duration_ms >= ? ")
+	}
 	builder.Write(sql.String(), params...)
 }
 
 func writeSortSQL(query SearchInQueryHistoryQuery, sqlStore db.DB, builder *db.SQLBuilder) {
 	if query.Sort == "time-asc" {
 		builder.Write(" ORDER BY created_at ASC ")
+	} else if query.Sort == "duration-desc" {
+		builder.Write(" ORDER BY duration_ms DESC, created_at DESC ")
 	} else {
 		builder.Write(" ORDER BY created_at DESC ")
 	}
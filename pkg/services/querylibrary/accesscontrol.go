@@ -0,0 +1,20 @@
+package querylibrary
+
+import (
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const (
+	ScopeQueriesRoot   = "library.queries"
+	ScopeQueriesPrefix = "library.queries:uid:"
+
+	ActionQueriesRead   = "library.queries:read"
+	ActionQueriesCreate = "library.queries:create"
+	ActionQueriesWrite  = "library.queries:write"
+	ActionQueriesDelete = "library.queries:delete"
+)
+
+var (
+	ScopeQueriesProvider = ac.NewScopeProvider(ScopeQueriesRoot)
+	ScopeQueriesAll      = ScopeQueriesProvider.GetResourceAllScope()
+)
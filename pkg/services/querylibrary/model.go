@@ -0,0 +1,230 @@
+package querylibrary
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrLibraryQueryNotFound = errutil.NotFound("querylibrary.not-found", errutil.WithPublicMessage("Library query not found"))
+	ErrVersionNotFound      = errutil.NotFound("querylibrary.version-not-found", errutil.WithPublicMessage("Library query version not found"))
+	ErrInvalidLibraryQuery  = errutil.BadRequest("querylibrary.invalid")
+	ErrInvalidReference     = errutil.BadRequest("querylibrary.invalid-reference")
+	ErrAccessDenied         = errutil.Forbidden("querylibrary.forbidden", errutil.WithPublicMessage("Access denied to the library query"))
+	ErrQueryHistoryNotFound = errutil.BadRequest("querylibrary.query-history-not-found", errutil.WithPublicMessage("Query history entry not found"))
+	ErrDisabled             = errutil.BadRequest("querylibrary.disabled", errutil.WithPublicMessage("The query library is disabled"))
+
+	// ErrVersionMismatch is returned when a library query is updated from an outdated version.
+	ErrVersionMismatch = errors.New("the library query has been changed by someone else")
+)
+
+// Visibility decides who, besides the users with the library query permissions, sees a library query.
+type Visibility string
+
+const (
+	// VisibilityOrg shares the query with the whole organization.
+	VisibilityOrg Visibility = "org"
+	// VisibilityTeam shares the query with the members of a single team.
+	VisibilityTeam Visibility = "team"
+)
+
+// ReferenceKey is the key of a panel query that references a library query.
+const ReferenceKey = "libraryQuery"
+
+// panelQueryFields are the fields of a panel query that are kept when a library query
+// replaces it, since they depend on the panel and not on the query.
+var panelQueryFields = []string{"refId", "hide", "maxDataPoints", "intervalMs"}
+
+// LibraryQuery is the latest version of a query published to the library.
+type LibraryQuery struct {
+	ID             int64            `xorm:"pk autoincr 'id'" json:"-"`
+	UID            string           `xorm:"uid" json:"uid"`
+	OrgID          int64            `xorm:"org_id" json:"orgId"`
+	Title          string           `xorm:"title" json:"title"`
+	Description    string           `xorm:"description" json:"description"`
+	Tags           []string         `xorm:"tags" json:"tags"`
+	DatasourceUID  string           `xorm:"datasource_uid" json:"datasourceUid"`
+	DatasourceType string           `xorm:"datasource_type" json:"datasourceType"`
+	Model          *simplejson.Json `xorm:"model" json:"model"`
+	Visibility     Visibility       `xorm:"visibility" json:"visibility"`
+	// TeamID is the team the query is shared with when the visibility is team.
+	TeamID    int64     `xorm:"team_id" json:"teamId"`
+	Version   int64     `json:"version"`
+	CreatedBy int64     `xorm:"created_by" json:"createdBy"`
+	UpdatedBy int64     `xorm:"updated_by" json:"updatedBy"`
+	Created   time.Time `xorm:"created" json:"created"`
+	Updated   time.Time `xorm:"updated" json:"updated"`
+}
+
+func (q LibraryQuery) TableName() string {
+	return "query_library"
+}
+
+// LibraryQueryVersion is a saved version of a library query.
+type LibraryQueryVersion struct {
+	ID             int64            `xorm:"pk autoincr 'id'" json:"-"`
+	QueryID        int64            `xorm:"query_id" json:"-"`
+	Version        int64            `json:"version"`
+	Title          string           `xorm:"title" json:"title"`
+	Description    string           `xorm:"description" json:"description"`
+	Tags           []string         `xorm:"tags" json:"tags"`
+	DatasourceUID  string           `xorm:"datasource_uid" json:"datasourceUid"`
+	DatasourceType string           `xorm:"datasource_type" json:"datasourceType"`
+	Model          *simplejson.Json `xorm:"model" json:"model"`
+	Message        string           `xorm:"message" json:"message"`
+	CreatedBy      int64            `xorm:"created_by" json:"createdBy"`
+	Created        time.Time        `xorm:"created" json:"created"`
+}
+
+func (v LibraryQueryVersion) TableName() string {
+	return "query_library_version"
+}
+
+// LibraryQuerySettings are the user editable fields of a library query.
+type LibraryQuerySettings struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	// DatasourceUID is the data source the query runs against.
+	DatasourceUID string `json:"datasourceUid"`
+	// Model is the JSON model of the query, as it would appear in a panel.
+	Model      *simplejson.Json `json:"model"`
+	Visibility Visibility       `json:"visibility"`
+	TeamID     int64            `json:"teamId"`
+}
+
+// Validate checks the settings, cleans up the tags and defaults the visibility to org.
+func (s *LibraryQuerySettings) Validate() error {
+	s.Title = strings.TrimSpace(s.Title)
+	if s.Title == "" {
+		return ErrInvalidLibraryQuery.Errorf("library query title is required")
+	}
+	if len(s.Title) > 190 {
+		return ErrInvalidLibraryQuery.Errorf("library query title is too long")
+	}
+	if s.DatasourceUID == "" {
+		return ErrInvalidLibraryQuery.Errorf("library query data source is required")
+	}
+	if s.Model == nil {
+		return ErrInvalidLibraryQuery.Errorf("library query model is required")
+	}
+	if _, err := s.Model.Map(); err != nil {
+		return ErrInvalidLibraryQuery.Errorf("library query model must be an object")
+	}
+	if _, ok := s.Model.CheckGet(ReferenceKey); ok {
+		return ErrInvalidLibraryQuery.Errorf("library query cannot reference another library query")
+	}
+
+	switch s.Visibility {
+	case "", VisibilityOrg:
+		s.Visibility = VisibilityOrg
+		s.TeamID = 0
+	case VisibilityTeam:
+		if s.TeamID <= 0 {
+			return ErrInvalidLibraryQuery.Errorf("team is required for a team library query")
+		}
+	default:
+		return ErrInvalidLibraryQuery.Errorf("unsupported visibility %q", s.Visibility)
+	}
+
+	tags := make([]string, 0, len(s.Tags))
+	seen := map[string]bool{}
+	for _, tag := range s.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > 50 {
+			return ErrInvalidLibraryQuery.Errorf("library query tag %q is too long", tag)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	s.Tags = tags
+	return nil
+}
+
+type CreateLibraryQueryCommand struct {
+	LibraryQuerySettings
+	// QueryHistoryUID publishes a query of the user's query history. The data source
+	// and the model are then taken from the query history entry.
+	QueryHistoryUID string `json:"queryHistoryUid"`
+	// RefID selects the query of the query history entry, the first one is used when empty.
+	RefID string `json:"refId"`
+}
+
+type UpdateLibraryQueryCommand struct {
+	LibraryQuerySettings
+	UID string `json:"-"`
+	// Version is the version the update is based on. The update fails when the library
+	// query has been saved since, unless the version is 0.
+	Version int64 `json:"version"`
+	// Message describes the change and is kept with the new version.
+	Message string `json:"message"`
+}
+
+const (
+	SortTitleAsc    = "title-asc"
+	SortUpdatedDesc = "updated-desc"
+)
+
+type SearchLibraryQueriesQuery struct {
+	// Query matches the title and the description of the library queries.
+	Query           string   `json:"query"`
+	DatasourceUIDs  []string `json:"datasourceUids"`
+	DatasourceTypes []string `json:"datasourceTypes"`
+	// Tags returns the library queries that have all the tags.
+	Tags  []string `json:"tags"`
+	Sort  string   `json:"sort"`
+	Page  int      `json:"page"`
+	Limit int      `json:"limit"`
+}
+
+type SearchLibraryQueriesResult struct {
+	TotalCount     int64           `json:"totalCount"`
+	LibraryQueries []*LibraryQuery `json:"libraryQueries"`
+	Page           int             `json:"page"`
+	PerPage        int             `json:"perPage"`
+}
+
+// Reference is the reference of a panel query to a library query.
+type Reference struct {
+	UID string `json:"uid"`
+	// Version pins a version of the library query, the latest version is used when 0.
+	Version int64 `json:"version,omitempty"`
+}
+
+// GetReference returns the library query referenced by a panel query, if any.
+func GetReference(query *simplejson.Json) (*Reference, bool) {
+	ref, ok := query.CheckGet(ReferenceKey)
+	if !ok {
+		return nil, false
+	}
+	return &Reference{
+		UID:     ref.Get("uid").MustString(),
+		Version: ref.Get("version").MustInt64(0),
+	}, true
+}
+
+// ResolveReference returns the query that runs in place of a panel query referencing the
+// library query model. The panel specific fields of the panel query are kept.
+func ResolveReference(query *simplejson.Json, model *simplejson.Json) (*simplejson.Json, error) {
+	data, err := model.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := simplejson.NewJson(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range panelQueryFields {
+		if value, ok := query.CheckGet(field); ok {
+			resolved.Set(field, value.Interface())
+		}
+	}
+	return resolved, nil
+}
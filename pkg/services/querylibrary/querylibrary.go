@@ -0,0 +1,34 @@
+package querylibrary
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+)
+
+// Service stores the queries published to the library of an organization. Every
+// method checks that the user can access the library query it works on.
+type Service interface {
+	Create(ctx context.Context, user identity.Requester, cmd *CreateLibraryQueryCommand) (*LibraryQuery, error)
+	Update(ctx context.Context, user identity.Requester, cmd *UpdateLibraryQueryCommand) (*LibraryQuery, error)
+	Get(ctx context.Context, user identity.Requester, uid string) (*LibraryQuery, error)
+	Delete(ctx context.Context, user identity.Requester, uid string) error
+	Search(ctx context.Context, user identity.Requester, query *SearchLibraryQueriesQuery) (*SearchLibraryQueriesResult, error)
+	// GetVersions returns the saved versions of a library query, newest first.
+	GetVersions(ctx context.Context, user identity.Requester, uid string) ([]*LibraryQueryVersion, error)
+	GetVersion(ctx context.Context, user identity.Requester, uid string, version int64) (*LibraryQueryVersion, error)
+	// ResolveQueries replaces the panel queries that reference a library query with
+	// the stored query. Queries without a reference are returned unchanged.
+	//
+	// The references of the saved dashboard with the given UID are resolved without checking
+	// the library query permissions when the user can read the dashboard, so that the viewers
+	// of a dashboard, including the viewers of public dashboards, can run its queries. They
+	// are instead checked by CheckDashboardReferences when the dashboard is saved. Any other
+	// reference needs the permission to read the library query. Running the resolved queries
+	// still needs the data source permissions.
+	ResolveQueries(ctx context.Context, user identity.Requester, dashboardUID string, queries []*simplejson.Json) ([]*simplejson.Json, error)
+	// CheckDashboardReferences checks that the user can read the library queries
+	// referenced by the panel queries of a dashboard.
+	CheckDashboardReferences(ctx context.Context, user identity.Requester, dashboard *simplejson.Json) error
+}
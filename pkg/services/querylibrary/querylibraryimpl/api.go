@@ -0,0 +1,115 @@
+package querylibraryimpl
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/library-queries", func(queries routing.RouteRegister) {
+		queries.Get("/", routing.Wrap(s.searchHandler))
+		queries.Post("/", routing.Wrap(s.createHandler))
+		queries.Get("/:uid", routing.Wrap(s.getHandler))
+		queries.Put("/:uid", routing.Wrap(s.updateHandler))
+		queries.Delete("/:uid", routing.Wrap(s.deleteHandler))
+		queries.Get("/:uid/versions", routing.Wrap(s.getVersionsHandler))
+		queries.Get("/:uid/versions/:version", routing.Wrap(s.getVersionHandler))
+	}, middleware.ReqSignedIn)
+}
+
+// GET /api/library-queries
+func (s *Service) searchHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.Search(c.Req.Context(), c.SignedInUser, &querylibrary.SearchLibraryQueriesQuery{
+		Query:           c.Query("query"),
+		DatasourceUIDs:  c.QueryStrings("datasourceUid"),
+		DatasourceTypes: c.QueryStrings("datasourceType"),
+		Tags:            c.QueryStrings("tag"),
+		Sort:            c.Query("sort"),
+		Page:            c.QueryInt("page"),
+		Limit:           c.QueryInt("limit"),
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to search library queries", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// POST /api/library-queries
+func (s *Service) createHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := querylibrary.CreateLibraryQueryCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	query, err := s.Create(c.Req.Context(), c.SignedInUser, &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to create library query", err)
+	}
+	return response.JSON(http.StatusOK, query)
+}
+
+// GET /api/library-queries/:uid
+func (s *Service) getHandler(c *contextmodel.ReqContext) response.Response {
+	query, err := s.Get(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get library query", err)
+	}
+	return response.JSON(http.StatusOK, query)
+}
+
+// PUT /api/library-queries/:uid
+func (s *Service) updateHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := querylibrary.UpdateLibraryQueryCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.UID = web.Params(c.Req)[":uid"]
+
+	query, err := s.Update(c.Req.Context(), c.SignedInUser, &cmd)
+	if err != nil {
+		if errors.Is(err, querylibrary.ErrVersionMismatch) {
+			return response.Error(http.StatusPreconditionFailed, "The library query has been changed by someone else", err)
+		}
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update library query", err)
+	}
+	return response.JSON(http.StatusOK, query)
+}
+
+// DELETE /api/library-queries/:uid
+func (s *Service) deleteHandler(c *contextmodel.ReqContext) response.Response {
+	if err := s.Delete(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"]); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete library query", err)
+	}
+	return response.Success("Library query deleted")
+}
+
+// GET /api/library-queries/:uid/versions
+func (s *Service) getVersionsHandler(c *contextmodel.ReqContext) response.Response {
+	versions, err := s.GetVersions(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"])
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get library query versions", err)
+	}
+	return response.JSON(http.StatusOK, versions)
+}
+
+// GET /api/library-queries/:uid/versions/:version
+func (s *Service) getVersionHandler(c *contextmodel.ReqContext) response.Response {
+	version, err := strconv.ParseInt(web.Params(c.Req)[":version"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "version is invalid", err)
+	}
+
+	v, err := s.GetVersion(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":uid"], version)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get library query version", err)
+	}
+	return response.JSON(http.StatusOK, v)
+}
@@ -0,0 +1,454 @@
+package querylibraryimpl
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+// Service stores the library queries of the organizations. Access to a library query
+// needs both the library query permissions and, for team queries, membership of the team.
+type Service struct {
+	store         store
+	cfg           *setting.Cfg
+	log           log.Logger
+	accessControl accesscontrol.AccessControl
+	dataSources   datasources.DataSourceService
+	dashboards    dashboards.DashboardService
+
+	enabled bool
+	now     func() time.Time
+}
+
+var _ querylibrary.Service = (*Service)(nil)
+
+func ProvideService(cfg *setting.Cfg, db db.DB, routeRegister routing.RouteRegister, accessControl accesscontrol.AccessControl,
+	accessControlService accesscontrol.Service, dataSources datasources.DataSourceService, dashboardService dashboards.DashboardService) (*Service, error) {
+	section := cfg.SectionWithEnvOverrides("query_library")
+	s := &Service{
+		store:         &sqlStore{db: db},
+		cfg:           cfg,
+		log:           log.New("query-library"),
+		accessControl: accessControl,
+		dataSources:   dataSources,
+		dashboards:    dashboardService,
+		enabled:       section.Key("enabled").MustBool(true),
+		now:           time.Now,
+	}
+
+	if !s.enabled {
+		return s, nil
+	}
+
+	if err := declareFixedRoles(accessControlService); err != nil {
+		return nil, err
+	}
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+func (s *Service) Create(ctx context.Context, user identity.Requester, cmd *querylibrary.CreateLibraryQueryCommand) (*querylibrary.LibraryQuery, error) {
+	ok, err := s.accessControl.Evaluate(ctx, user, accesscontrol.EvalPermission(querylibrary.ActionQueriesCreate))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, querylibrary.ErrAccessDenied.Errorf("user cannot create library queries")
+	}
+
+	userID, err := identity.UserIdentifier(user.GetNamespacedID())
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.QueryHistoryUID != "" {
+		if err := s.fromQueryHistory(ctx, user.GetOrgID(), userID, cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	ds, err := s.validate(ctx, user, &cmd.LibraryQuerySettings)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	query := &querylibrary.LibraryQuery{
+		UID:       util.GenerateShortUID(),
+		OrgID:     user.GetOrgID(),
+		Version:   1,
+		CreatedBy: userID,
+		Created:   now,
+	}
+	applySettings(query, &cmd.LibraryQuerySettings, ds, userID, now)
+
+	if err := s.store.Insert(ctx, query, newVersion(query, "")); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+func (s *Service) Update(ctx context.Context, user identity.Requester, cmd *querylibrary.UpdateLibraryQueryCommand) (*querylibrary.LibraryQuery, error) {
+	query, err := s.get(ctx, user, cmd.UID, querylibrary.ActionQueriesWrite)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Version != 0 && cmd.Version != query.Version {
+		return nil, querylibrary.ErrVersionMismatch
+	}
+
+	userID, err := identity.UserIdentifier(user.GetNamespacedID())
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := s.validate(ctx, user, &cmd.LibraryQuerySettings)
+	if err != nil {
+		return nil, err
+	}
+
+	prevVersion := query.Version
+	query.Version++
+	applySettings(query, &cmd.LibraryQuerySettings, ds, userID, s.now())
+
+	if err := s.store.Update(ctx, query, prevVersion, newVersion(query, cmd.Message)); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+func (s *Service) Get(ctx context.Context, user identity.Requester, uid string) (*querylibrary.LibraryQuery, error) {
+	return s.get(ctx, user, uid, querylibrary.ActionQueriesRead)
+}
+
+func (s *Service) Delete(ctx context.Context, user identity.Requester, uid string) error {
+	if _, err := s.get(ctx, user, uid, querylibrary.ActionQueriesDelete); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, user.GetOrgID(), uid)
+}
+
+func (s *Service) Search(ctx context.Context, user identity.Requester, query *querylibrary.SearchLibraryQueriesQuery) (*querylibrary.SearchLibraryQueriesResult, error) {
+	access, err := accesscontrol.Filter(user, "query_library.uid", querylibrary.ScopeQueriesPrefix, querylibrary.ActionQueriesRead)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := identity.UserIdentifier(user.GetNamespacedID())
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+
+	queries, total, err := s.store.Search(ctx, &searchFilter{
+		orgID:    user.GetOrgID(),
+		access:   access,
+		allTeams: user.GetOrgRole() == org.RoleAdmin,
+		userID:   userID,
+		teamIDs:  user.GetTeams(),
+	}, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &querylibrary.SearchLibraryQueriesResult{
+		TotalCount:     total,
+		LibraryQueries: queries,
+		Page:           query.Page,
+		PerPage:        query.Limit,
+	}, nil
+}
+
+func (s *Service) GetVersions(ctx context.Context, user identity.Requester, uid string) ([]*querylibrary.LibraryQueryVersion, error) {
+	query, err := s.get(ctx, user, uid, querylibrary.ActionQueriesRead)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetVersions(ctx, query.ID)
+}
+
+func (s *Service) GetVersion(ctx context.Context, user identity.Requester, uid string, version int64) (*querylibrary.LibraryQueryVersion, error) {
+	query, err := s.get(ctx, user, uid, querylibrary.ActionQueriesRead)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetVersion(ctx, query.ID, version)
+}
+
+// ResolveQueries looks the references up in the organization of the user. The library query
+// permissions are not checked for the references of the saved dashboard, they are checked when
+// the dashboard is saved.
+func (s *Service) ResolveQueries(ctx context.Context, user identity.Requester, dashboardUID string, queries []*simplejson.Json) ([]*simplejson.Json, error) {
+	var resolved []*simplejson.Json
+	var dashboardRefs map[querylibrary.Reference]bool
+	models := map[querylibrary.Reference]*simplejson.Json{}
+	for i, query := range queries {
+		ref, ok := querylibrary.GetReference(query)
+		if !ok {
+			continue
+		}
+		if err := s.checkReference(query, ref); err != nil {
+			return nil, err
+		}
+
+		model, ok := models[*ref]
+		if !ok {
+			if dashboardRefs == nil {
+				refs, err := s.dashboardReferences(ctx, user, dashboardUID)
+				if err != nil {
+					return nil, err
+				}
+				dashboardRefs = refs
+			}
+
+			var libraryQuery *querylibrary.LibraryQuery
+			var err error
+			if dashboardRefs[*ref] {
+				libraryQuery, err = s.store.Get(ctx, user.GetOrgID(), ref.UID)
+			} else {
+				libraryQuery, err = s.get(ctx, user, ref.UID, querylibrary.ActionQueriesRead)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if model, err = s.getModel(ctx, libraryQuery, ref); err != nil {
+				return nil, err
+			}
+			models[*ref] = model
+		}
+
+		// The queries of the request are left untouched
+		if resolved == nil {
+			resolved = slices.Clone(queries)
+		}
+		r, err := querylibrary.ResolveReference(query, model)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = r
+	}
+
+	if resolved == nil {
+		return queries, nil
+	}
+	return resolved, nil
+}
+
+func (s *Service) CheckDashboardReferences(ctx context.Context, user identity.Requester, dashboard *simplejson.Json) error {
+	checked := map[querylibrary.Reference]bool{}
+	for _, query := range dashboardQueries(dashboard.Get("panels")) {
+		ref, ok := querylibrary.GetReference(query)
+		if !ok || checked[*ref] {
+			continue
+		}
+		if err := s.checkReference(query, ref); err != nil {
+			return err
+		}
+
+		libraryQuery, err := s.get(ctx, user, ref.UID, querylibrary.ActionQueriesRead)
+		if err != nil {
+			return err
+		}
+		if _, err := s.getModel(ctx, libraryQuery, ref); err != nil {
+			return err
+		}
+		checked[*ref] = true
+	}
+	return nil
+}
+
+// dashboardReferences returns the library queries referenced by the saved dashboard, none
+// when there is no dashboard or the user cannot read it.
+func (s *Service) dashboardReferences(ctx context.Context, user identity.Requester, uid string) (map[querylibrary.Reference]bool, error) {
+	refs := map[querylibrary.Reference]bool{}
+	if uid == "" {
+		return refs, nil
+	}
+
+	ok, err := s.accessControl.Evaluate(ctx, user, accesscontrol.EvalPermission(dashboards.ActionDashboardsRead, dashboards.ScopeDashboardsProvider.GetResourceScopeUID(uid)))
+	if err != nil || !ok {
+		return refs, err
+	}
+	dashboard, err := s.dashboards.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: uid, OrgID: user.GetOrgID()})
+	if errors.Is(err, dashboards.ErrDashboardNotFound) {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range dashboardQueries(dashboard.Data.Get("panels")) {
+		if ref, ok := querylibrary.GetReference(query); ok {
+			refs[*ref] = true
+		}
+	}
+	return refs, nil
+}
+
+// dashboardQueries returns the queries of the panels, including the panels of collapsed rows.
+func dashboardQueries(panels *simplejson.Json) []*simplejson.Json {
+	var queries []*simplejson.Json
+	for i := range panels.MustArray() {
+		panel := panels.GetIndex(i)
+		for j := range panel.Get("targets").MustArray() {
+			queries = append(queries, panel.Get("targets").GetIndex(j))
+		}
+		queries = append(queries, dashboardQueries(panel.Get("panels"))...)
+	}
+	return queries
+}
+
+func (s *Service) checkReference(query *simplejson.Json, ref *querylibrary.Reference) error {
+	if !s.enabled {
+		return querylibrary.ErrDisabled.Errorf("query %s references a library query", query.Get("refId").MustString())
+	}
+	if ref.UID == "" {
+		return querylibrary.ErrInvalidReference.Errorf("query %s references a library query without uid", query.Get("refId").MustString())
+	}
+	return nil
+}
+
+// getModel returns the model of the referenced version of a library query.
+func (s *Service) getModel(ctx context.Context, query *querylibrary.LibraryQuery, ref *querylibrary.Reference) (*simplejson.Json, error) {
+	if ref.Version == 0 || ref.Version == query.Version {
+		return query.Model, nil
+	}
+	version, err := s.store.GetVersion(ctx, query.ID, ref.Version)
+	if err != nil {
+		return nil, err
+	}
+	return version.Model, nil
+}
+
+// get returns a library query if the user is allowed to perform the action on it.
+func (s *Service) get(ctx context.Context, user identity.Requester, uid string, action string) (*querylibrary.LibraryQuery, error) {
+	query, err := s.store.Get(ctx, user.GetOrgID(), uid)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.accessControl.Evaluate(ctx, user, accesscontrol.EvalPermission(action, querylibrary.ScopeQueriesProvider.GetResourceScopeUID(uid)))
+	if err != nil {
+		return nil, err
+	}
+	if !ok || !canSee(user, query) {
+		return nil, querylibrary.ErrAccessDenied.Errorf("user cannot access library query %s", uid)
+	}
+	return query, nil
+}
+
+// canSee returns whether the visibility of a library query includes the user. Team
+// queries are visible to the members of the team, their creator and organization admins.
+func canSee(user identity.Requester, query *querylibrary.LibraryQuery) bool {
+	if query.Visibility != querylibrary.VisibilityTeam || user.GetOrgRole() == org.RoleAdmin {
+		return true
+	}
+	if userID, _ := identity.UserIdentifier(user.GetNamespacedID()); userID != 0 && userID == query.CreatedBy {
+		return true
+	}
+	return slices.Contains(user.GetTeams(), query.TeamID)
+}
+
+// validate checks the settings and returns the data source of the query.
+func (s *Service) validate(ctx context.Context, user identity.Requester, settings *querylibrary.LibraryQuerySettings) (*datasources.DataSource, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	if settings.Visibility == querylibrary.VisibilityTeam && user.GetOrgRole() != org.RoleAdmin &&
+		!slices.Contains(user.GetTeams(), settings.TeamID) {
+		return nil, querylibrary.ErrAccessDenied.Errorf("user is not a member of team %d", settings.TeamID)
+	}
+
+	ds, err := s.dataSources.GetDataSource(ctx, &datasources.GetDataSourceQuery{UID: settings.DatasourceUID, OrgID: user.GetOrgID()})
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return nil, querylibrary.ErrInvalidLibraryQuery.Errorf("data source %s not found", settings.DatasourceUID)
+		}
+		return nil, err
+	}
+	return ds, nil
+}
+
+// fromQueryHistory fills in the data source and the model from a query history entry of the user.
+func (s *Service) fromQueryHistory(ctx context.Context, orgID, userID int64, cmd *querylibrary.CreateLibraryQueryCommand) error {
+	entry, err := s.store.GetQueryHistory(ctx, orgID, userID, cmd.QueryHistoryUID)
+	if err != nil {
+		return err
+	}
+
+	var model *simplejson.Json
+	for i := range entry.Queries.MustArray() {
+		q := entry.Queries.GetIndex(i)
+		if cmd.RefID == "" || q.Get("refId").MustString() == cmd.RefID {
+			model = q
+			break
+		}
+	}
+	if model == nil {
+		return querylibrary.ErrInvalidLibraryQuery.Errorf("query %q not found in query history entry %s", cmd.RefID, cmd.QueryHistoryUID)
+	}
+
+	cmd.Model = model
+	cmd.DatasourceUID = model.Get("datasource").Get("uid").MustString(entry.DatasourceUID)
+	return nil
+}
+
+func applySettings(query *querylibrary.LibraryQuery, settings *querylibrary.LibraryQuerySettings, ds *datasources.DataSource, userID int64, now time.Time) {
+	// The stored model always points at the data source, so it runs on its own.
+	settings.Model.Set("datasource", map[string]any{"uid": ds.UID, "type": ds.Type})
+
+	query.Title = settings.Title
+	query.Description = settings.Description
+	query.Tags = settings.Tags
+	query.DatasourceUID = ds.UID
+	query.DatasourceType = ds.Type
+	query.Model = settings.Model
+	query.Visibility = settings.Visibility
+	query.TeamID = settings.TeamID
+	query.UpdatedBy = userID
+	query.Updated = now
+}
+
+func newVersion(query *querylibrary.LibraryQuery, message string) *querylibrary.LibraryQueryVersion {
+	return &querylibrary.LibraryQueryVersion{
+		QueryID:        query.ID,
+		Version:        query.Version,
+		Title:          query.Title,
+		Description:    query.Description,
+		Tags:           query.Tags,
+		DatasourceUID:  query.DatasourceUID,
+		DatasourceType: query.DatasourceType,
+		Model:          query.Model,
+		Message:        message,
+		CreatedBy:      query.UpdatedBy,
+		Created:        query.Updated,
+	}
+}
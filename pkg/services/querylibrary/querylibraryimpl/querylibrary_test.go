@@ -0,0 +1,330 @@
+package querylibraryimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/queryhistory"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationQueryLibrary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	testDB := db.InitTestDB(t)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := &Service{
+		store:         &sqlStore{db: testDB},
+		cfg:           setting.NewCfg(),
+		log:           log.NewNopLogger(),
+		accessControl: acimpl.ProvideAccessControl(setting.NewCfg()),
+		dataSources: &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+			{UID: "prom", Type: "prometheus", OrgID: 1},
+			{UID: "loki", Type: "loki", OrgID: 1},
+		}},
+		enabled: true,
+		now:     func() time.Time { return now },
+	}
+
+	writerPermissions := map[string][]string{
+		querylibrary.ActionQueriesRead:   {querylibrary.ScopeQueriesAll},
+		querylibrary.ActionQueriesCreate: {},
+		querylibrary.ActionQueriesWrite:  {querylibrary.ScopeQueriesAll},
+		querylibrary.ActionQueriesDelete: {querylibrary.ScopeQueriesAll},
+	}
+	readerPermissions := map[string][]string{
+		querylibrary.ActionQueriesRead: {querylibrary.ScopeQueriesAll},
+	}
+	newUser := func(id int64, permissions map[string][]string, teams ...int64) *user.SignedInUser {
+		return &user.SignedInUser{UserID: id, OrgID: 1, OrgRole: org.RoleEditor, Teams: teams,
+			Permissions: map[int64]map[string][]string{1: permissions}}
+	}
+	writer := newUser(1, writerPermissions, 10)
+	teamReader := newUser(2, readerPermissions, 10)
+	otherReader := newUser(3, readerPermissions, 20)
+
+	model := func(expr string) *simplejson.Json {
+		return simplejson.NewFromAny(map[string]any{"refId": "A", "expr": expr})
+	}
+	create := func(t *testing.T, settings querylibrary.LibraryQuerySettings) *querylibrary.LibraryQuery {
+		t.Helper()
+		query, err := s.Create(ctx, writer, &querylibrary.CreateLibraryQueryCommand{LibraryQuerySettings: settings})
+		require.NoError(t, err)
+		return query
+	}
+
+	cpu := create(t, querylibrary.LibraryQuerySettings{Title: "CPU usage", Description: "CPU of all nodes", Tags: []string{"nodes", "cpu", "cpu"},
+		DatasourceUID: "prom", Model: model("rate(cpu[5m])")})
+	errorsQuery := create(t, querylibrary.LibraryQuerySettings{Title: "Errors", Tags: []string{"logs"},
+		DatasourceUID: "loki", Model: model(`{app="api"} |= "error"`), Visibility: querylibrary.VisibilityTeam, TeamID: 10})
+
+	// A saved dashboard referencing the team library query
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(func(_ context.Context, q *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+		if q.UID != "errors-dashboard" || q.OrgID != 1 {
+			return nil, dashboards.ErrDashboardNotFound
+		}
+		return &dashboards.Dashboard{UID: q.UID, OrgID: 1, Data: simplejson.NewFromAny(map[string]any{
+			"panels": []any{map[string]any{"targets": []any{
+				map[string]any{"refId": "A", "libraryQuery": map[string]any{"uid": errorsQuery.UID}},
+			}}},
+		})}, nil
+	}).Maybe()
+	s.dashboards = dashboardService
+
+	t.Run("Should create a library query", func(t *testing.T) {
+		assert.Equal(t, querylibrary.VisibilityOrg, cpu.Visibility)
+		assert.Equal(t, "prometheus", cpu.DatasourceType)
+		assert.Equal(t, []string{"nodes", "cpu"}, cpu.Tags)
+		assert.Equal(t, int64(1), cpu.Version)
+		assert.Equal(t, "prom", cpu.Model.GetPath("datasource", "uid").MustString())
+
+		query, err := s.Get(ctx, teamReader, cpu.UID)
+		require.NoError(t, err)
+		assert.Equal(t, "CPU usage", query.Title)
+		assert.Equal(t, "rate(cpu[5m])", query.Model.Get("expr").MustString())
+	})
+
+	t.Run("Should validate a library query", func(t *testing.T) {
+		_, err := s.Create(ctx, writer, &querylibrary.CreateLibraryQueryCommand{LibraryQuerySettings: querylibrary.LibraryQuerySettings{
+			Title: "Missing data source", DatasourceUID: "missing", Model: model("up")}})
+		require.ErrorIs(t, err, querylibrary.ErrInvalidLibraryQuery)
+
+		_, err = s.Create(ctx, writer, &querylibrary.CreateLibraryQueryCommand{LibraryQuerySettings: querylibrary.LibraryQuerySettings{
+			Title: "Other team", DatasourceUID: "prom", Model: model("up"), Visibility: querylibrary.VisibilityTeam, TeamID: 20}})
+		require.ErrorIs(t, err, querylibrary.ErrAccessDenied)
+
+		_, err = s.Create(ctx, teamReader, &querylibrary.CreateLibraryQueryCommand{LibraryQuerySettings: querylibrary.LibraryQuerySettings{
+			Title: "Reader", DatasourceUID: "prom", Model: model("up")}})
+		require.ErrorIs(t, err, querylibrary.ErrAccessDenied)
+	})
+
+	t.Run("Should restrict team library queries to the team", func(t *testing.T) {
+		_, err := s.Get(ctx, teamReader, errorsQuery.UID)
+		require.NoError(t, err)
+		_, err = s.Get(ctx, otherReader, errorsQuery.UID)
+		require.ErrorIs(t, err, querylibrary.ErrAccessDenied)
+
+		admin := newUser(4, readerPermissions)
+		admin.OrgRole = org.RoleAdmin
+		_, err = s.Get(ctx, admin, errorsQuery.UID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Should search the library queries", func(t *testing.T) {
+		search := func(t *testing.T, usr *user.SignedInUser, query querylibrary.SearchLibraryQueriesQuery) []string {
+			t.Helper()
+			result, err := s.Search(ctx, usr, &query)
+			require.NoError(t, err)
+			titles := make([]string, 0, len(result.LibraryQueries))
+			for _, q := range result.LibraryQueries {
+				titles = append(titles, q.Title)
+			}
+			assert.Equal(t, int64(len(titles)), result.TotalCount)
+			return titles
+		}
+
+		assert.Equal(t, []string{"CPU usage", "Errors"}, search(t, teamReader, querylibrary.SearchLibraryQueriesQuery{}))
+		assert.Equal(t, []string{"CPU usage"}, search(t, otherReader, querylibrary.SearchLibraryQueriesQuery{}))
+		assert.Equal(t, []string{"Errors"}, search(t, teamReader, querylibrary.SearchLibraryQueriesQuery{DatasourceTypes: []string{"loki"}}))
+		assert.Equal(t, []string{"CPU usage"}, search(t, teamReader, querylibrary.SearchLibraryQueriesQuery{Query: "nodes"}))
+		assert.Equal(t, []string{"CPU usage"}, search(t, teamReader, querylibrary.SearchLibraryQueriesQuery{Tags: []string{"cpu", "nodes"}}))
+		assert.Empty(t, search(t, teamReader, querylibrary.SearchLibraryQueriesQuery{Tags: []string{"cpu", "logs"}}))
+
+		scoped := newUser(5, map[string][]string{
+			querylibrary.ActionQueriesRead: {querylibrary.ScopeQueriesProvider.GetResourceScopeUID(errorsQuery.UID)},
+		}, 10)
+		assert.Equal(t, []string{"Errors"}, search(t, scoped, querylibrary.SearchLibraryQueriesQuery{}))
+		assert.Empty(t, search(t, newUser(6, map[string][]string{}, 10), querylibrary.SearchLibraryQueriesQuery{}))
+	})
+
+	t.Run("Should keep the versions of a library query", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		updated, err := s.Update(ctx, writer, &querylibrary.UpdateLibraryQueryCommand{
+			UID:     cpu.UID,
+			Version: 1,
+			Message: "Use a longer range",
+			LibraryQuerySettings: querylibrary.LibraryQuerySettings{Title: "CPU usage", Tags: []string{"cpu"},
+				DatasourceUID: "prom", Model: model("rate(cpu[10m])")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), updated.Version)
+
+		_, err = s.Update(ctx, writer, &querylibrary.UpdateLibraryQueryCommand{
+			UID:                  cpu.UID,
+			Version:              1,
+			LibraryQuerySettings: querylibrary.LibraryQuerySettings{Title: "Outdated", DatasourceUID: "prom", Model: model("up")},
+		})
+		require.ErrorIs(t, err, querylibrary.ErrVersionMismatch)
+
+		versions, err := s.GetVersions(ctx, teamReader, cpu.UID)
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		assert.Equal(t, int64(2), versions[0].Version)
+		assert.Equal(t, "Use a longer range", versions[0].Message)
+		assert.Equal(t, "rate(cpu[5m])", versions[1].Model.Get("expr").MustString())
+
+		result, err := s.Search(ctx, teamReader, &querylibrary.SearchLibraryQueriesQuery{Tags: []string{"nodes"}})
+		require.NoError(t, err)
+		assert.Empty(t, result.LibraryQueries)
+	})
+
+	t.Run("Should resolve the library queries referenced by panel queries", func(t *testing.T) {
+		queries := []*simplejson.Json{
+			simplejson.NewFromAny(map[string]any{"refId": "A", "intervalMs": 30000, "libraryQuery": map[string]any{"uid": cpu.UID}}),
+			simplejson.NewFromAny(map[string]any{"refId": "B", "libraryQuery": map[string]any{"uid": cpu.UID, "version": 1}}),
+			simplejson.NewFromAny(map[string]any{"refId": "C", "datasource": map[string]any{"uid": "loki"}, "expr": "{app=\"web\"}"}),
+		}
+		resolved, err := s.ResolveQueries(ctx, teamReader, "", queries)
+		require.NoError(t, err)
+		require.Len(t, resolved, 3)
+
+		assert.Equal(t, "A", resolved[0].Get("refId").MustString())
+		assert.Equal(t, int64(30000), resolved[0].Get("intervalMs").MustInt64())
+		assert.Equal(t, "rate(cpu[10m])", resolved[0].Get("expr").MustString())
+		assert.Equal(t, "prom", resolved[0].GetPath("datasource", "uid").MustString())
+		_, ok := resolved[0].CheckGet(querylibrary.ReferenceKey)
+		assert.False(t, ok)
+
+		assert.Equal(t, "B", resolved[1].Get("refId").MustString())
+		assert.Equal(t, "rate(cpu[5m])", resolved[1].Get("expr").MustString())
+
+		assert.Same(t, queries[2], resolved[2])
+		_, ok = queries[0].CheckGet(querylibrary.ReferenceKey)
+		assert.True(t, ok, "the request queries are not modified")
+
+		_, err = s.ResolveQueries(ctx, teamReader, "", []*simplejson.Json{
+			simplejson.NewFromAny(map[string]any{"refId": "A", "libraryQuery": map[string]any{"uid": cpu.UID, "version": 5}}),
+		})
+		require.ErrorIs(t, err, querylibrary.ErrVersionNotFound)
+
+		_, err = s.ResolveQueries(ctx, otherReader, "", []*simplejson.Json{
+			simplejson.NewFromAny(map[string]any{"refId": "A", "libraryQuery": map[string]any{"uid": errorsQuery.UID}}),
+		})
+		require.ErrorIs(t, err, querylibrary.ErrAccessDenied, "team library queries are only resolved for the team")
+	})
+
+	t.Run("Should resolve the library queries for the viewers of a dashboard", func(t *testing.T) {
+		queries := []*simplejson.Json{
+			simplejson.NewFromAny(map[string]any{"refId": "A", "libraryQuery": map[string]any{"uid": errorsQuery.UID}}),
+		}
+		dashboardReader := map[string][]string{dashboards.ActionDashboardsRead: {dashboards.ScopeDashboardsProvider.GetResourceScopeUID("errors-dashboard")}}
+
+		// A viewer of the dashboard who is not a member of the team of the library query
+		viewer := newUser(4, dashboardReader, 20)
+		resolved, err := s.ResolveQueries(ctx, viewer, "errors-dashboard", queries)
+		require.NoError(t, err)
+		assert.Equal(t, `{app="api"} |= "error"`, resolved[0].Get("expr").MustString())
+
+		// The anonymous user of a public dashboard
+		anonymous := &user.SignedInUser{OrgID: 1, OrgRole: org.RoleViewer, IsAnonymous: true,
+			Permissions: map[int64]map[string][]string{1: {dashboards.ActionDashboardsRead: {dashboards.ScopeDashboardsAll}}}}
+		resolved, err = s.ResolveQueries(ctx, anonymous, "errors-dashboard", queries)
+		require.NoError(t, err)
+		assert.Equal(t, `{app="api"} |= "error"`, resolved[0].Get("expr").MustString())
+
+		// Outside of the dashboard, the library query permissions are checked
+		_, err = s.ResolveQueries(ctx, viewer, "", queries)
+		require.ErrorIs(t, err, querylibrary.ErrAccessDenied)
+		_, err = s.ResolveQueries(ctx, viewer, "other-dashboard", queries)
+		require.ErrorIs(t, err, querylibrary.ErrAccessDenied)
+		_, err = s.ResolveQueries(ctx, viewer, "errors-dashboard", []*simplejson.Json{
+			simplejson.NewFromAny(map[string]any{"refId": "A", "libraryQuery": map[string]any{"uid": cpu.UID}}),
+		})
+		require.ErrorIs(t, err, querylibrary.ErrAccessDenied, "the library query is not referenced by the dashboard")
+		_, err = s.ResolveQueries(ctx, newUser(5, map[string][]string{}, 20), "errors-dashboard", queries)
+		require.ErrorIs(t, err, querylibrary.ErrAccessDenied, "the user cannot read the dashboard")
+
+		_, err = s.ResolveQueries(ctx, &user.SignedInUser{OrgID: 2, OrgRole: org.RoleViewer}, "errors-dashboard", queries)
+		require.ErrorIs(t, err, querylibrary.ErrLibraryQueryNotFound)
+	})
+
+	t.Run("Should check the library queries referenced by a dashboard", func(t *testing.T) {
+		dashboard := simplejson.NewFromAny(map[string]any{
+			"panels": []any{
+				map[string]any{"targets": []any{map[string]any{"refId": "A", "expr": "up"}}},
+				map[string]any{"type": "row", "panels": []any{
+					map[string]any{"targets": []any{
+						map[string]any{"refId": "A", "libraryQuery": map[string]any{"uid": errorsQuery.UID}},
+					}},
+				}},
+			},
+		})
+
+		require.NoError(t, s.CheckDashboardReferences(ctx, teamReader, dashboard))
+		require.ErrorIs(t, s.CheckDashboardReferences(ctx, otherReader, dashboard), querylibrary.ErrAccessDenied)
+		require.ErrorIs(t, s.CheckDashboardReferences(ctx, newUser(8, map[string][]string{}, 10), dashboard), querylibrary.ErrAccessDenied)
+		require.NoError(t, s.CheckDashboardReferences(ctx, otherReader, simplejson.NewFromAny(map[string]any{
+			"panels": []any{map[string]any{"targets": []any{map[string]any{"refId": "A", "expr": "up"}}}},
+		})))
+
+		withVersion := simplejson.NewFromAny(map[string]any{
+			"panels": []any{map[string]any{"targets": []any{
+				map[string]any{"refId": "A", "libraryQuery": map[string]any{"uid": errorsQuery.UID, "version": 5}},
+			}}},
+		})
+		require.ErrorIs(t, s.CheckDashboardReferences(ctx, teamReader, withVersion), querylibrary.ErrVersionNotFound)
+	})
+
+	t.Run("Should publish a query from the query history", func(t *testing.T) {
+		err := testDB.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Insert(&queryhistory.QueryHistory{
+				UID:           "history",
+				OrgID:         1,
+				DatasourceUID: "prom",
+				CreatedBy:     writer.UserID,
+				CreatedAt:     now.Unix(),
+				Queries: simplejson.NewFromAny([]any{
+					map[string]any{"refId": "A", "expr": "up"},
+					map[string]any{"refId": "B", "datasource": map[string]any{"uid": "loki"}, "expr": "{app=\"api\"}"},
+				}),
+			})
+			return err
+		})
+		require.NoError(t, err)
+
+		query, err := s.Create(ctx, writer, &querylibrary.CreateLibraryQueryCommand{
+			LibraryQuerySettings: querylibrary.LibraryQuerySettings{Title: "API logs"},
+			QueryHistoryUID:      "history",
+			RefID:                "B",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "loki", query.DatasourceUID)
+		assert.Equal(t, `{app="api"}`, query.Model.Get("expr").MustString())
+
+		_, err = s.Create(ctx, newUser(7, writerPermissions), &querylibrary.CreateLibraryQueryCommand{
+			LibraryQuerySettings: querylibrary.LibraryQuerySettings{Title: "Someone else's history"},
+			QueryHistoryUID:      "history",
+		})
+		require.ErrorIs(t, err, querylibrary.ErrQueryHistoryNotFound)
+	})
+
+	t.Run("Should delete a library query", func(t *testing.T) {
+		require.ErrorIs(t, s.Delete(ctx, teamReader, cpu.UID), querylibrary.ErrAccessDenied)
+		require.NoError(t, s.Delete(ctx, writer, cpu.UID))
+
+		_, err := s.Get(ctx, writer, cpu.UID)
+		require.ErrorIs(t, err, querylibrary.ErrLibraryQueryNotFound)
+		versions, err := s.store.GetVersions(ctx, cpu.ID)
+		require.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+}
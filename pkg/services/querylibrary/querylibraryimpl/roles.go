@@ -0,0 +1,45 @@
+package querylibraryimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
+)
+
+var (
+	queryReaderRole = accesscontrol.RoleDTO{
+		Name:        "fixed:library.queries:reader",
+		DisplayName: "Library query reader",
+		Description: "Read and search the library queries and use them in panels",
+		Group:       "Query library",
+		Permissions: []accesscontrol.Permission{
+			{Action: querylibrary.ActionQueriesRead, Scope: querylibrary.ScopeQueriesAll},
+		},
+	}
+
+	queryWriterRole = accesscontrol.RoleDTO{
+		Name:        "fixed:library.queries:writer",
+		DisplayName: "Library query writer",
+		Description: "Publish, update and delete library queries",
+		Group:       "Query library",
+		Permissions: []accesscontrol.Permission{
+			{Action: querylibrary.ActionQueriesRead, Scope: querylibrary.ScopeQueriesAll},
+			{Action: querylibrary.ActionQueriesCreate},
+			{Action: querylibrary.ActionQueriesWrite, Scope: querylibrary.ScopeQueriesAll},
+			{Action: querylibrary.ActionQueriesDelete, Scope: querylibrary.ScopeQueriesAll},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	queryReader := accesscontrol.RoleRegistration{
+		Role:   queryReaderRole,
+		Grants: []string{string(org.RoleViewer)},
+	}
+	queryWriter := accesscontrol.RoleRegistration{
+		Role:   queryWriterRole,
+		Grants: []string{string(org.RoleEditor)},
+	}
+
+	return ac.DeclareFixedRoles(queryReader, queryWriter)
+}
@@ -0,0 +1,46 @@
+package querylibraryimpl
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/queryhistory"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
+)
+
+type store interface {
+	// Insert stores a new library query together with its first version.
+	Insert(ctx context.Context, query *querylibrary.LibraryQuery, version *querylibrary.LibraryQueryVersion) error
+	// Update stores a new version of a library query. It fails with ErrVersionMismatch
+	// when the stored query is no longer at prevVersion.
+	Update(ctx context.Context, query *querylibrary.LibraryQuery, prevVersion int64, version *querylibrary.LibraryQueryVersion) error
+	Get(ctx context.Context, orgID int64, uid string) (*querylibrary.LibraryQuery, error)
+	Delete(ctx context.Context, orgID int64, uid string) error
+	Search(ctx context.Context, filter *searchFilter, query *querylibrary.SearchLibraryQueriesQuery) ([]*querylibrary.LibraryQuery, int64, error)
+	GetVersions(ctx context.Context, queryID int64) ([]*querylibrary.LibraryQueryVersion, error)
+	GetVersion(ctx context.Context, queryID int64, version int64) (*querylibrary.LibraryQueryVersion, error)
+	// GetQueryHistory returns an entry of the query history of a user.
+	GetQueryHistory(ctx context.Context, orgID, userID int64, uid string) (*queryhistory.QueryHistory, error)
+}
+
+// searchFilter restricts a search to the library queries a user can see.
+type searchFilter struct {
+	orgID int64
+	// access is the filter for the library queries the user has permissions to read.
+	access accesscontrol.SQLFilter
+	// allTeams skips the team visibility check, for organization admins.
+	allTeams bool
+	userID   int64
+	teamIDs  []int64
+}
+
+// queryLibraryTag is a tag of a library query, stored separately so library queries can be searched by tag.
+type queryLibraryTag struct {
+	ID      int64  `xorm:"pk autoincr 'id'"`
+	QueryID int64  `xorm:"query_id"`
+	Tag     string `xorm:"tag"`
+}
+
+func (t queryLibraryTag) TableName() string {
+	return "query_library_tag"
+}
@@ -0,0 +1,215 @@
+package querylibraryimpl
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/queryhistory"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
+)
+
+type sqlStore struct {
+	db db.DB
+}
+
+var _ store = (*sqlStore)(nil)
+
+func (s *sqlStore) Insert(ctx context.Context, query *querylibrary.LibraryQuery, version *querylibrary.LibraryQueryVersion) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Insert(query); err != nil {
+			return err
+		}
+		if err := insertTags(sess, query); err != nil {
+			return err
+		}
+		version.QueryID = query.ID
+		_, err := sess.Insert(version)
+		return err
+	})
+}
+
+func (s *sqlStore) Update(ctx context.Context, query *querylibrary.LibraryQuery, prevVersion int64, version *querylibrary.LibraryQueryVersion) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("id = ? AND version = ?", query.ID, prevVersion).
+			Cols("title", "description", "tags", "datasource_uid", "datasource_type", "model", "visibility", "team_id",
+				"version", "updated_by", "updated").Update(query)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return querylibrary.ErrVersionMismatch
+		}
+		if _, err := sess.Exec("DELETE FROM query_library_tag WHERE query_id = ?", query.ID); err != nil {
+			return err
+		}
+		if err := insertTags(sess, query); err != nil {
+			return err
+		}
+		version.QueryID = query.ID
+		_, err = sess.Insert(version)
+		return err
+	})
+}
+
+func insertTags(sess *db.Session, query *querylibrary.LibraryQuery) error {
+	if len(query.Tags) == 0 {
+		return nil
+	}
+	tags := make([]*queryLibraryTag, 0, len(query.Tags))
+	for _, tag := range query.Tags {
+		tags = append(tags, &queryLibraryTag{QueryID: query.ID, Tag: tag})
+	}
+	_, err := sess.InsertMulti(&tags)
+	return err
+}
+
+func (s *sqlStore) Get(ctx context.Context, orgID int64, uid string) (*querylibrary.LibraryQuery, error) {
+	query := &querylibrary.LibraryQuery{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(query)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return querylibrary.ErrLibraryQueryNotFound.Errorf("library query not found")
+		}
+		return nil
+	})
+	return query, err
+}
+
+func (s *sqlStore) Delete(ctx context.Context, orgID int64, uid string) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		query := &querylibrary.LibraryQuery{}
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(query)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return querylibrary.ErrLibraryQueryNotFound.Errorf("library query not found")
+		}
+		for _, sql := range []string{
+			"DELETE FROM query_library_tag WHERE query_id = ?",
+			"DELETE FROM query_library_version WHERE query_id = ?",
+			"DELETE FROM query_library WHERE id = ?",
+		} {
+			if _, err := sess.Exec(sql, query.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) Search(ctx context.Context, filter *searchFilter, query *querylibrary.SearchLibraryQueriesQuery) ([]*querylibrary.LibraryQuery, int64, error) {
+	where, args := s.searchSQL(filter, query)
+
+	var total int64
+	queries := make([]*querylibrary.LibraryQuery, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		total, err = sess.Where(where, args...).Count(&querylibrary.LibraryQuery{})
+		if err != nil {
+			return err
+		}
+
+		sess.Where(where, args...)
+		if query.Sort == querylibrary.SortUpdatedDesc {
+			sess.Desc("updated")
+		} else {
+			sess.Asc("title")
+		}
+		return sess.Asc("id").Limit(query.Limit, query.Limit*(query.Page-1)).Find(&queries)
+	})
+	return queries, total, err
+}
+
+func (s *sqlStore) searchSQL(filter *searchFilter, query *querylibrary.SearchLibraryQueriesQuery) (string, []any) {
+	var sql strings.Builder
+	args := []any{filter.orgID}
+	sql.WriteString("query_library.org_id = ? AND")
+	sql.WriteString(filter.access.Where)
+	args = append(args, filter.access.Args...)
+
+	if !filter.allTeams {
+		sql.WriteString(" AND (query_library.visibility = ?")
+		args = append(args, querylibrary.VisibilityOrg)
+		if filter.userID != 0 {
+			sql.WriteString(" OR query_library.created_by = ?")
+			args = append(args, filter.userID)
+		}
+		if len(filter.teamIDs) > 0 {
+			sql.WriteString(" OR query_library.team_id IN (?" + strings.Repeat(",?", len(filter.teamIDs)-1) + ")")
+			for _, id := range filter.teamIDs {
+				args = append(args, id)
+			}
+		}
+		sql.WriteString(")")
+	}
+
+	if query.Query != "" {
+		like := s.db.GetDialect().LikeStr()
+		sql.WriteString(" AND (query_library.title " + like + " ? OR query_library.description " + like + " ?)")
+		args = append(args, "%"+query.Query+"%", "%"+query.Query+"%")
+	}
+	if len(query.DatasourceUIDs) > 0 {
+		sql.WriteString(" AND query_library.datasource_uid IN (?" + strings.Repeat(",?", len(query.DatasourceUIDs)-1) + ")")
+		for _, uid := range query.DatasourceUIDs {
+			args = append(args, uid)
+		}
+	}
+	if len(query.DatasourceTypes) > 0 {
+		sql.WriteString(" AND query_library.datasource_type IN (?" + strings.Repeat(",?", len(query.DatasourceTypes)-1) + ")")
+		for _, t := range query.DatasourceTypes {
+			args = append(args, t)
+		}
+	}
+	if len(query.Tags) > 0 {
+		sql.WriteString(" AND query_library.id IN (SELECT query_id FROM query_library_tag WHERE tag IN (?" +
+			strings.Repeat(",?", len(query.Tags)-1) + ") GROUP BY query_id HAVING COUNT(DISTINCT tag) = ?)")
+		for _, tag := range query.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(query.Tags))
+	}
+	return sql.String(), args
+}
+
+func (s *sqlStore) GetVersions(ctx context.Context, queryID int64) ([]*querylibrary.LibraryQueryVersion, error) {
+	versions := make([]*querylibrary.LibraryQueryVersion, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("query_id = ?", queryID).Desc("version").Find(&versions)
+	})
+	return versions, err
+}
+
+func (s *sqlStore) GetVersion(ctx context.Context, queryID int64, version int64) (*querylibrary.LibraryQueryVersion, error) {
+	v := &querylibrary.LibraryQueryVersion{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("query_id = ? AND version = ?", queryID, version).Get(v)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return querylibrary.ErrVersionNotFound.Errorf("version %d not found", version)
+		}
+		return nil
+	})
+	return v, err
+}
+
+func (s *sqlStore) GetQueryHistory(ctx context.Context, orgID, userID int64, uid string) (*queryhistory.QueryHistory, error) {
+	entry := &queryhistory.QueryHistory{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Table("query_history").Where("org_id = ? AND created_by = ? AND uid = ?", orgID, userID, uid).Get(entry)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return querylibrary.ErrQueryHistoryNotFound.Errorf("query history entry %s not found", uid)
+		}
+		return nil
+	})
+	return entry, err
+}
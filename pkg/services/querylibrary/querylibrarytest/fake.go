@@ -0,0 +1,85 @@
+package querylibrarytest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/querylibrary"
+)
+
+type FakeService struct {
+	ExpectedQuery    *querylibrary.LibraryQuery
+	ExpectedResult   *querylibrary.SearchLibraryQueriesResult
+	ExpectedVersions []*querylibrary.LibraryQueryVersion
+	ExpectedError    error
+
+	// Models are the library query models used to resolve the references, by UID.
+	Models map[string]*simplejson.Json
+	// DashboardUID is the dashboard of the last resolved queries.
+	DashboardUID string
+}
+
+func NewFakeService() *FakeService {
+	return &FakeService{Models: map[string]*simplejson.Json{}}
+}
+
+func (f *FakeService) Create(_ context.Context, _ identity.Requester, _ *querylibrary.CreateLibraryQueryCommand) (*querylibrary.LibraryQuery, error) {
+	return f.ExpectedQuery, f.ExpectedError
+}
+
+func (f *FakeService) Update(_ context.Context, _ identity.Requester, _ *querylibrary.UpdateLibraryQueryCommand) (*querylibrary.LibraryQuery, error) {
+	return f.ExpectedQuery, f.ExpectedError
+}
+
+func (f *FakeService) Get(_ context.Context, _ identity.Requester, _ string) (*querylibrary.LibraryQuery, error) {
+	return f.ExpectedQuery, f.ExpectedError
+}
+
+func (f *FakeService) Delete(_ context.Context, _ identity.Requester, _ string) error {
+	return f.ExpectedError
+}
+
+func (f *FakeService) Search(_ context.Context, _ identity.Requester, _ *querylibrary.SearchLibraryQueriesQuery) (*querylibrary.SearchLibraryQueriesResult, error) {
+	return f.ExpectedResult, f.ExpectedError
+}
+
+func (f *FakeService) GetVersions(_ context.Context, _ identity.Requester, _ string) ([]*querylibrary.LibraryQueryVersion, error) {
+	return f.ExpectedVersions, f.ExpectedError
+}
+
+func (f *FakeService) GetVersion(_ context.Context, _ identity.Requester, _ string, _ int64) (*querylibrary.LibraryQueryVersion, error) {
+	if len(f.ExpectedVersions) == 0 {
+		return nil, f.ExpectedError
+	}
+	return f.ExpectedVersions[0], f.ExpectedError
+}
+
+func (f *FakeService) ResolveQueries(_ context.Context, _ identity.Requester, dashboardUID string, queries []*simplejson.Json) ([]*simplejson.Json, error) {
+	f.DashboardUID = dashboardUID
+	if f.ExpectedError != nil {
+		return nil, f.ExpectedError
+	}
+	resolved := make([]*simplejson.Json, 0, len(queries))
+	for _, query := range queries {
+		ref, ok := querylibrary.GetReference(query)
+		if !ok {
+			resolved = append(resolved, query)
+			continue
+		}
+		model, ok := f.Models[ref.UID]
+		if !ok {
+			return nil, querylibrary.ErrLibraryQueryNotFound.Errorf("library query %s not found", ref.UID)
+		}
+		r, err := querylibrary.ResolveReference(query, model)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, r)
+	}
+	return resolved, nil
+}
+
+func (f *FakeService) CheckDashboardReferences(_ context.Context, _ identity.Requester, _ *simplejson.Json) error {
+	return f.ExpectedError
+}
@@ -0,0 +1,363 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type point struct {
+	x, y float64
+}
+
+type textAlign int
+
+const (
+	alignLeft textAlign = iota
+	alignCenter
+	alignRight
+)
+
+// canvas is the surface panels are drawn on. Coordinates are in CSS pixels, the canvas scales
+// them by the device scale factor. Angles are in radians, clockwise from the positive x axis.
+type canvas interface {
+	fillRect(x, y, w, h float64, c color.NRGBA)
+	polyline(points []point, width float64, c color.NRGBA)
+	polygon(points []point, c color.NRGBA)
+	arc(cx, cy, r, start, end, width float64, c color.NRGBA)
+	// text draws a single line of text vertically centered on y
+	text(x, y float64, s string, size float64, c color.NRGBA, align textAlign)
+	textWidth(s string, size float64) float64
+	encode(w io.Writer) error
+}
+
+// truncateText shortens a text with an ellipsis so it fits in the width
+func truncateText(c canvas, s string, size, width float64) string {
+	if c.textWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if t := string(runes) + "..."; c.textWidth(t, size) <= width {
+			return t
+		}
+	}
+	return ""
+}
+
+type pngCanvas struct {
+	img   *image.RGBA
+	mask  *mask
+	scale float64
+}
+
+func newPNGCanvas(width, height int, scale float64) *pngCanvas {
+	w, h := int(math.Round(float64(width)*scale)), int(math.Round(float64(height)*scale))
+	bounds := image.Rect(0, 0, w, h)
+	return &pngCanvas{img: image.NewRGBA(bounds), mask: &mask{alpha: image.NewAlpha(bounds)}, scale: scale}
+}
+
+// mask collects the pixels covered by a shape so that each pixel is blended once, even when
+// parts of the shape overlap. The canvas reuses one mask for all shapes, painting it clears
+// the pixels set since the last paint.
+type mask struct {
+	alpha *image.Alpha
+	dirty image.Rectangle
+}
+
+func (m *mask) set(x, y int) {
+	if !(image.Point{X: x, Y: y}).In(m.alpha.Rect) {
+		return
+	}
+	m.alpha.Pix[m.alpha.PixOffset(x, y)] = 255
+	if m.dirty.Empty() {
+		m.dirty = image.Rect(x, y, x+1, y+1)
+		return
+	}
+	m.dirty.Min.X, m.dirty.Max.X = min(m.dirty.Min.X, x), max(m.dirty.Max.X, x+1)
+	m.dirty.Min.Y, m.dirty.Max.Y = min(m.dirty.Min.Y, y), max(m.dirty.Max.Y, y+1)
+}
+
+func (c *pngCanvas) paint(m *mask, col color.NRGBA) {
+	for y := m.dirty.Min.Y; y < m.dirty.Max.Y; y++ {
+		for x := m.dirty.Min.X; x < m.dirty.Max.X; x++ {
+			i := m.alpha.PixOffset(x, y)
+			if m.alpha.Pix[i] != 0 {
+				c.blend(x, y, col)
+				m.alpha.Pix[i] = 0
+			}
+		}
+	}
+	m.dirty = image.Rectangle{}
+}
+
+func (c *pngCanvas) blend(x, y int, col color.NRGBA) {
+	if col.A == 255 {
+		c.img.SetRGBA(x, y, color.RGBA{R: col.R, G: col.G, B: col.B, A: 255})
+		return
+	}
+	dst := c.img.RGBAAt(x, y)
+	a := float64(col.A) / 255
+	mix := func(s, d uint8) uint8 {
+		return uint8(math.Round(float64(s)*a + float64(d)*(1-a)))
+	}
+	c.img.SetRGBA(x, y, color.RGBA{
+		R: mix(col.R, dst.R),
+		G: mix(col.G, dst.G),
+		B: mix(col.B, dst.B),
+		A: uint8(math.Min(255, float64(col.A)+float64(dst.A)*(1-a))),
+	})
+}
+
+func (c *pngCanvas) fillRect(x, y, w, h float64, col color.NRGBA) {
+	x0, y0 := int(math.Round(x*c.scale)), int(math.Round(y*c.scale))
+	x1, y1 := int(math.Round((x+w)*c.scale)), int(math.Round((y+h)*c.scale))
+	r := image.Rect(x0, y0, x1, y1).Intersect(c.img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			c.blend(px, py, col)
+		}
+	}
+}
+
+func (c *pngCanvas) polyline(points []point, width float64, col color.NRGBA) {
+	m := c.mask
+	radius := math.Max(width*c.scale/2, 0.5)
+	for i := 1; i < len(points); i++ {
+		c.segment(m, c.scaled(points[i-1]), c.scaled(points[i]), radius)
+	}
+	if len(points) == 1 {
+		c.segment(m, c.scaled(points[0]), c.scaled(points[0]), radius)
+	}
+	c.paint(m, col)
+}
+
+func (c *pngCanvas) scaled(p point) point {
+	return point{x: p.x * c.scale, y: p.y * c.scale}
+}
+
+// clipX returns the pixel columns from a to b, limited to the image. A shape entirely outside of
+// the image gives an empty range.
+func (c *pngCanvas) clipX(a, b float64) (int, int) {
+	bounds := c.img.Bounds()
+	return clip(a, b, bounds.Min.X, bounds.Max.X-1)
+}
+
+func (c *pngCanvas) clipY(a, b float64) (int, int) {
+	bounds := c.img.Bounds()
+	return clip(a, b, bounds.Min.Y, bounds.Max.Y-1)
+}
+
+func clip(a, b float64, lo, hi int) (int, int) {
+	return int(math.Max(math.Floor(a), float64(lo))), int(math.Min(math.Ceil(b), float64(hi)))
+}
+
+// segment marks the pixels whose center is within the radius of the segment from a to b
+func (c *pngCanvas) segment(m *mask, a, b point, radius float64) {
+	minX, maxX := c.clipX(math.Min(a.x, b.x)-radius, math.Max(a.x, b.x)+radius)
+	minY, maxY := c.clipY(math.Min(a.y, b.y)-radius, math.Max(a.y, b.y)+radius)
+	dx, dy := b.x-a.x, b.y-a.y
+	length := dx*dx + dy*dy
+	for py := minY; py <= maxY; py++ {
+		for px := minX; px <= maxX; px++ {
+			cx, cy := float64(px)+0.5, float64(py)+0.5
+			t := 0.0
+			if length > 0 {
+				t = math.Max(0, math.Min(1, ((cx-a.x)*dx+(cy-a.y)*dy)/length))
+			}
+			ex, ey := cx-(a.x+t*dx), cy-(a.y+t*dy)
+			if ex*ex+ey*ey <= radius*radius {
+				m.set(px, py)
+			}
+		}
+	}
+}
+
+func (c *pngCanvas) polygon(points []point, col color.NRGBA) {
+	if len(points) < 3 {
+		return
+	}
+	scaled := make([]point, len(points))
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for i, p := range points {
+		scaled[i] = c.scaled(p)
+		minY, maxY = math.Min(minY, scaled[i].y), math.Max(maxY, scaled[i].y)
+	}
+
+	m := c.mask
+	top, bottom := c.clipY(minY, maxY)
+	for py := top; py <= bottom; py++ {
+		cy := float64(py) + 0.5
+		var xs []float64
+		for i := range scaled {
+			a, b := scaled[i], scaled[(i+1)%len(scaled)]
+			if (a.y <= cy && b.y > cy) || (b.y <= cy && a.y > cy) {
+				xs = append(xs, a.x+(cy-a.y)/(b.y-a.y)*(b.x-a.x))
+			}
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			left, right := c.clipX(xs[i], xs[i+1])
+			for px := max(left, int(math.Round(xs[i]))); px < min(right+1, int(math.Round(xs[i+1]))); px++ {
+				m.set(px, py)
+			}
+		}
+	}
+	c.paint(m, col)
+}
+
+func (c *pngCanvas) arc(cx, cy, r, start, end, width float64, col color.NRGBA) {
+	center := c.scaled(point{x: cx, y: cy})
+	radius, half := r*c.scale, math.Max(width*c.scale/2, 0.5)
+	sweep := end - start
+
+	m := c.mask
+	outer := radius + half
+	minX, maxX := c.clipX(center.x-outer, center.x+outer)
+	minY, maxY := c.clipY(center.y-outer, center.y+outer)
+	for py := minY; py <= maxY; py++ {
+		for px := minX; px <= maxX; px++ {
+			dx, dy := float64(px)+0.5-center.x, float64(py)+0.5-center.y
+			if math.Abs(math.Hypot(dx, dy)-radius) > half {
+				continue
+			}
+			angle := math.Mod(math.Atan2(dy, dx)-start, 2*math.Pi)
+			if angle < 0 {
+				angle += 2 * math.Pi
+			}
+			if angle <= sweep {
+				m.set(px, py)
+			}
+		}
+	}
+	c.paint(m, col)
+}
+
+func (c *pngCanvas) text(x, y float64, s string, size float64, col color.NRGBA, align textAlign) {
+	scale := glyphScale(size * c.scale)
+	switch align {
+	case alignCenter:
+		x -= c.textWidth(s, size) / 2
+	case alignRight:
+		x -= c.textWidth(s, size)
+	}
+	left := int(math.Round(x * c.scale))
+	top := int(math.Round(y*c.scale)) - glyphHeight*scale/2
+
+	m := c.mask
+	for _, r := range s {
+		g := glyph(r)
+		for row, line := range g {
+			for column, bit := range line {
+				if bit != '#' {
+					continue
+				}
+				for sy := 0; sy < scale; sy++ {
+					for sx := 0; sx < scale; sx++ {
+						m.set(left+column*scale+sx, top+row*scale+sy)
+					}
+				}
+			}
+		}
+		left += glyphAdvance * scale
+	}
+	c.paint(m, col)
+}
+
+func (c *pngCanvas) textWidth(s string, size float64) float64 {
+	n := utf8.RuneCountInString(s)
+	if n == 0 {
+		return 0
+	}
+	return float64((n*glyphAdvance-1)*glyphScale(size*c.scale)) / c.scale
+}
+
+func (c *pngCanvas) encode(w io.Writer) error {
+	return png.Encode(w, c.img)
+}
+
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+func newSVGCanvas(width, height int, scale float64) *svgCanvas {
+	c := &svgCanvas{}
+	fmt.Fprintf(&c.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		int(math.Round(float64(width)*scale)), int(math.Round(float64(height)*scale)), width, height)
+	return c
+}
+
+// svgPaint returns the fill or stroke attributes of a color
+func svgPaint(attr string, c color.NRGBA) string {
+	s := fmt.Sprintf(`%s="#%02x%02x%02x"`, attr, c.R, c.G, c.B)
+	if c.A != 255 {
+		s += fmt.Sprintf(` %s-opacity="%.3g"`, attr, float64(c.A)/255)
+	}
+	return s
+}
+
+func svgPoints(points []point) string {
+	parts := make([]string, 0, len(points))
+	for _, p := range points {
+		parts = append(parts, fmt.Sprintf("%.2f,%.2f", p.x, p.y))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *svgCanvas) fillRect(x, y, w, h float64, col color.NRGBA) {
+	fmt.Fprintf(&c.buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" %s/>`, x, y, w, h, svgPaint("fill", col))
+}
+
+func (c *svgCanvas) polyline(points []point, width float64, col color.NRGBA) {
+	fmt.Fprintf(&c.buf, `<polyline points="%s" fill="none" %s stroke-width="%.2f" stroke-linejoin="round" stroke-linecap="round"/>`,
+		svgPoints(points), svgPaint("stroke", col), width)
+}
+
+func (c *svgCanvas) polygon(points []point, col color.NRGBA) {
+	fmt.Fprintf(&c.buf, `<polygon points="%s" %s/>`, svgPoints(points), svgPaint("fill", col))
+}
+
+func (c *svgCanvas) arc(cx, cy, r, start, end, width float64, col color.NRGBA) {
+	x0, y0 := cx+r*math.Cos(start), cy+r*math.Sin(start)
+	x1, y1 := cx+r*math.Cos(end), cy+r*math.Sin(end)
+	large := 0
+	if end-start > math.Pi {
+		large = 1
+	}
+	fmt.Fprintf(&c.buf, `<path d="M %.2f %.2f A %.2f %.2f 0 %d 1 %.2f %.2f" fill="none" %s stroke-width="%.2f"/>`,
+		x0, y0, r, r, large, x1, y1, svgPaint("stroke", col), width)
+}
+
+func (c *svgCanvas) text(x, y float64, s string, size float64, col color.NRGBA, align textAlign) {
+	anchor := "start"
+	switch align {
+	case alignCenter:
+		anchor = "middle"
+	case alignRight:
+		anchor = "end"
+	}
+	fmt.Fprintf(&c.buf, `<text x="%.2f" y="%.2f" font-family="Inter, Helvetica, Arial, sans-serif" font-size="%.1f" dominant-baseline="central" text-anchor="%s" %s>%s</text>`,
+		x, y, size, anchor, svgPaint("fill", col), html.EscapeString(s))
+}
+
+// textWidth estimates the width of the text from the average width of sans-serif characters
+func (c *svgCanvas) textWidth(s string, size float64) float64 {
+	return float64(utf8.RuneCountInString(s)) * size * 0.6
+}
+
+func (c *svgCanvas) encode(w io.Writer) error {
+	if _, err := w.Write(c.buf.Bytes()); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "</svg>")
+	return err
+}
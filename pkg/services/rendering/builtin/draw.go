@@ -0,0 +1,547 @@
+package builtin
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	titleHeight   = 28
+	panelPadding  = 8
+	axisFontSize  = 10
+	labelFontSize = 12
+	legendHeight  = 16
+)
+
+type rect struct {
+	x, y, w, h float64
+}
+
+func (r rect) inset(top, right, bottom, left float64) rect {
+	return rect{x: r.x + left, y: r.y + top, w: math.Max(r.w-left-right, 0), h: math.Max(r.h-top-bottom, 0)}
+}
+
+// drawContext holds what every panel of an image is drawn with
+type drawContext struct {
+	canvas   canvas
+	theme    theme
+	from     time.Time
+	to       time.Time
+	location *time.Location
+}
+
+func (d *drawContext) color(name string, fallback color.NRGBA) color.NRGBA {
+	if c, ok := parseColor(name, d.theme); ok {
+		return c
+	}
+	return fallback
+}
+
+// drawPanel draws the panel frame, title and visualization. Panel types without a built-in
+// visualization are drawn as a graph when the data has time series, and as a table otherwise.
+func (d *drawContext) drawPanel(r rect, p *panelModel, frames data.Frames, message string) {
+	c := d.canvas
+	c.fillRect(r.x, r.y, r.w, r.h, d.theme.background)
+	d.drawBorder(r)
+
+	content := r.inset(panelPadding, panelPadding, panelPadding, panelPadding)
+	if p.title != "" {
+		c.text(r.x+panelPadding, r.y+titleHeight/2, truncateText(c, p.title, 14, content.w), 14, d.theme.text, alignLeft)
+		content = r.inset(titleHeight, panelPadding, panelPadding, panelPadding)
+	}
+
+	all := getSeries(frames)
+	if len(all) == 0 && !hasRows(frames) {
+		if message == "" {
+			message = "No data"
+		}
+		c.text(content.x+content.w/2, content.y+content.h/2, truncateText(c, message, labelFontSize, content.w), labelFontSize, d.theme.weakText, alignCenter)
+		return
+	}
+
+	switch p.panelType {
+	case "timeseries", "graph", "trend":
+		d.drawTimeSeries(content, p, all)
+	case "stat", "singlestat":
+		d.drawStat(content, p, all)
+	case "gauge", "bargauge":
+		d.drawGauge(content, p, all)
+	case "table", "table-old":
+		d.drawTable(content, p, frames)
+	default:
+		if hasTimeSeries(all) {
+			d.drawTimeSeries(content, p, all)
+		} else {
+			d.drawTable(content, p, frames)
+		}
+	}
+}
+
+func (d *drawContext) drawBorder(r rect) {
+	c := d.canvas
+	c.fillRect(r.x, r.y, r.w, 1, d.theme.border)
+	c.fillRect(r.x, r.y+r.h-1, r.w, 1, d.theme.border)
+	c.fillRect(r.x, r.y, 1, r.h, d.theme.border)
+	c.fillRect(r.x+r.w-1, r.y, 1, r.h, d.theme.border)
+}
+
+func hasRows(frames data.Frames) bool {
+	for _, f := range frames {
+		if f != nil {
+			if rows, _ := f.RowLen(); rows > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasTimeSeries(all []series) bool {
+	for _, s := range all {
+		if s.times != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *drawContext) seriesColor(p *panelModel, i int) color.NRGBA {
+	if p.fixedColor != "" {
+		return d.color(p.fixedColor, seriesColors[i%len(seriesColors)])
+	}
+	return seriesColors[i%len(seriesColors)]
+}
+
+func (d *drawContext) valueColor(p *panelModel, v float64) color.NRGBA {
+	if p.fixedColor != "" {
+		return d.color(p.fixedColor, d.theme.text)
+	}
+	return d.color(p.thresholdColor(v), d.theme.text)
+}
+
+func (d *drawContext) drawTimeSeries(r rect, p *panelModel, all []series) {
+	c := d.canvas
+
+	var lines []series
+	for _, s := range all {
+		if s.times != nil {
+			lines = append(lines, s)
+		}
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, s := range lines {
+		low, high = math.Min(low, minOf(s.values)), math.Max(high, maxOf(s.values))
+	}
+	if math.IsInf(low, 0) || math.IsInf(high, 0) {
+		low, high = 0, 1
+	}
+	if p.min != nil {
+		low = *p.min
+	}
+	if p.max != nil {
+		high = *p.max
+	}
+	if low == high {
+		low, high = low-1, high+1
+	}
+
+	// the legend lists the series in rows, up to a third of the panel
+	legendRows := layoutLegend(c, lines, r.w)
+	maxRows := int(r.h / 3 / legendHeight)
+	if len(legendRows) > maxRows {
+		legendRows = legendRows[:maxRows]
+	}
+	plotBottom := r.y + r.h - legendHeight - float64(len(legendRows))*legendHeight
+
+	ticks, low, high := niceTicks(low, high, int((plotBottom-r.y)/40)+1, p.min == nil, p.max == nil)
+	labels := make([]string, len(ticks))
+	labelWidth := 0.0
+	for i, tick := range ticks {
+		labels[i] = formatValue(tick, p.unit, p.decimals)
+		labelWidth = math.Max(labelWidth, c.textWidth(labels[i], axisFontSize))
+	}
+
+	plot := rect{x: r.x + labelWidth + 8, y: r.y + axisFontSize/2, w: r.w - labelWidth - 8, h: plotBottom - r.y - axisFontSize/2}
+	if plot.w <= 0 || plot.h <= 0 {
+		return
+	}
+	yOf := func(v float64) float64 {
+		y := plot.y + plot.h - (v-low)/(high-low)*plot.h
+		return math.Max(plot.y, math.Min(plot.y+plot.h, y))
+	}
+	span := d.to.Sub(d.from)
+	xOf := func(t time.Time) float64 {
+		return plot.x + float64(t.Sub(d.from))/float64(span)*plot.w
+	}
+
+	for i, tick := range ticks {
+		y := yOf(tick)
+		c.fillRect(plot.x, y, plot.w, 1, d.theme.grid)
+		c.text(plot.x-8, y, labels[i], axisFontSize, d.theme.weakText, alignRight)
+	}
+	for _, tick := range timeTicks(d.from, d.to, d.location, int(plot.w/100)) {
+		x := xOf(tick.time)
+		c.fillRect(x, plot.y, 1, plot.h, d.theme.grid)
+		c.text(x, plot.y+plot.h+legendHeight/2+2, tick.label, axisFontSize, d.theme.weakText, alignCenter)
+	}
+
+	for i, s := range lines {
+		col := d.seriesColor(p, i)
+		var segment []point
+		flush := func() {
+			if len(segment) > 0 {
+				c.polyline(segment, 1, col)
+			}
+			segment = nil
+		}
+		for j, v := range s.values {
+			if math.IsNaN(v) || s.times[j].Before(d.from) || s.times[j].After(d.to) {
+				flush()
+				continue
+			}
+			segment = append(segment, point{x: xOf(s.times[j]), y: yOf(v)})
+		}
+		flush()
+	}
+
+	y := plotBottom + legendHeight + legendHeight/2
+	for _, row := range legendRows {
+		x := r.x
+		for _, item := range row {
+			c.fillRect(x, y-1, 14, 3, d.seriesColor(p, item.index))
+			c.text(x+18, y, item.name, axisFontSize, d.theme.text, alignLeft)
+			x += item.width
+		}
+		y += legendHeight
+	}
+}
+
+type legendItem struct {
+	index int
+	name  string
+	width float64
+}
+
+// layoutLegend splits the legend items in rows that fit in the width
+func layoutLegend(c canvas, lines []series, width float64) [][]legendItem {
+	var rows [][]legendItem
+	var row []legendItem
+	used := 0.0
+	for i, s := range lines {
+		name := truncateText(c, s.name, axisFontSize, width-40)
+		w := 18 + c.textWidth(name, axisFontSize) + 16
+		if len(row) > 0 && used+w > width {
+			rows = append(rows, row)
+			row, used = nil, 0
+		}
+		row = append(row, legendItem{index: i, name: name, width: w})
+		used += w
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// niceTicks returns evenly spaced round values between low and high. The bounds that are not
+// fixed are extended to the nearest tick.
+func niceTicks(low, high float64, count int, extendLow, extendHigh bool) ([]float64, float64, float64) {
+	if count < 2 {
+		count = 2
+	}
+	step := niceNumber((high - low) / float64(count-1))
+	if extendLow {
+		low = math.Floor(low/step) * step
+	}
+	if extendHigh {
+		high = math.Ceil(high/step) * step
+	}
+
+	var ticks []float64
+	for v := math.Ceil(low/step) * step; v <= high+step/1e6; v += step {
+		// avoid labels like 0.30000000000000004
+		ticks = append(ticks, math.Round(v/step)*step)
+	}
+	return ticks, low, high
+}
+
+func niceNumber(v float64) float64 {
+	exp := math.Floor(math.Log10(v))
+	fraction := v / math.Pow(10, exp)
+	var nice float64
+	switch {
+	case fraction < 1.5:
+		nice = 1
+	case fraction < 3:
+		nice = 2
+	case fraction < 7:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exp)
+}
+
+type timeTick struct {
+	time  time.Time
+	label string
+}
+
+var timeSteps = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour, 2 * 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour, 365 * 24 * time.Hour,
+}
+
+// timeTicks returns up to count round times between from and to, aligned in the location
+func timeTicks(from, to time.Time, loc *time.Location, count int) []timeTick {
+	if count < 1 {
+		count = 1
+	}
+	span := to.Sub(from)
+	step := timeSteps[len(timeSteps)-1]
+	for _, s := range timeSteps {
+		if span/s <= time.Duration(count) {
+			step = s
+			break
+		}
+	}
+
+	layout := "01/02"
+	switch {
+	case step < time.Minute:
+		layout = "15:04:05"
+	case span <= 24*time.Hour:
+		layout = "15:04"
+	case step < 24*time.Hour:
+		layout = "01/02 15:04"
+	}
+
+	_, offset := from.In(loc).Zone()
+	stepSeconds := int64(step / time.Second)
+	first := (from.Unix() + int64(offset) + stepSeconds - 1) / stepSeconds * stepSeconds
+	var ticks []timeTick
+	for t := time.Unix(first-int64(offset), 0); !t.After(to); t = t.Add(step) {
+		ticks = append(ticks, timeTick{time: t, label: t.In(loc).Format(layout)})
+	}
+	return ticks
+}
+
+// valueCells splits the area in a grid with a cell per value
+func valueCells(r rect, n int) []rect {
+	cols := int(math.Ceil(math.Sqrt(float64(n) * r.w / math.Max(r.h, 1) / 2)))
+	cols = int(math.Max(1, math.Min(float64(n), float64(cols))))
+	rows := (n + cols - 1) / cols
+	w, h := r.w/float64(cols), r.h/float64(rows)
+
+	cells := make([]rect, n)
+	for i := range cells {
+		cells[i] = rect{x: r.x + float64(i%cols)*w, y: r.y + float64(i/cols)*h, w: w, h: h}
+	}
+	return cells
+}
+
+// valueFontSize returns the largest font size, up to the limit, that fits the text in the width
+func valueFontSize(c canvas, s string, width, limit float64) float64 {
+	size := limit
+	if w := c.textWidth(s, size); w > width {
+		size = size * width / w
+	}
+	return math.Max(size, 8)
+}
+
+func (d *drawContext) drawStat(r rect, p *panelModel, all []series) {
+	c := d.canvas
+	for i, cell := range valueCells(r, len(all)) {
+		s := all[i]
+		v := reduce(s.values, p.reducer)
+		col := d.valueColor(p, v)
+		text := formatValue(v, p.unit, p.decimals)
+		inner := cell.inset(2, 2, 2, 2)
+
+		textColor := col
+		if p.colorMode == "background" {
+			c.fillRect(inner.x, inner.y, inner.w, inner.h, col)
+			textColor = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+		} else if p.colorMode == "none" {
+			textColor = d.theme.text
+		}
+
+		if p.graphMode == "area" && s.times != nil && len(s.values) > 1 {
+			d.drawSparkline(inner.inset(inner.h*0.6, 0, 0, 0), s, withAlpha(textColor, 255))
+		}
+
+		valueY := inner.y + inner.h/2
+		if len(all) > 1 {
+			c.text(inner.x+inner.w/2, inner.y+labelFontSize, truncateText(c, s.name, labelFontSize, inner.w), labelFontSize, d.theme.text, alignCenter)
+			valueY += labelFontSize / 2
+		}
+		size := valueFontSize(c, text, inner.w*0.9, math.Min(inner.h*0.4, 80))
+		c.text(inner.x+inner.w/2, valueY, text, size, textColor, alignCenter)
+	}
+}
+
+func (d *drawContext) drawSparkline(r rect, s series, col color.NRGBA) {
+	if r.h <= 0 || r.w <= 0 {
+		return
+	}
+	low, high := minOf(s.values), maxOf(s.values)
+	if math.IsInf(low, 0) {
+		return
+	}
+	if low == high {
+		low, high = low-1, high+1
+	}
+	span := d.to.Sub(d.from)
+	var line []point
+	for j, v := range s.values {
+		if math.IsNaN(v) || s.times[j].Before(d.from) || s.times[j].After(d.to) {
+			continue
+		}
+		line = append(line, point{
+			x: r.x + float64(s.times[j].Sub(d.from))/float64(span)*r.w,
+			y: r.y + r.h - (v-low)/(high-low)*r.h,
+		})
+	}
+	if len(line) < 2 {
+		return
+	}
+	area := append([]point{{x: line[0].x, y: r.y + r.h}}, line...)
+	area = append(area, point{x: line[len(line)-1].x, y: r.y + r.h})
+	d.canvas.polygon(area, withAlpha(col, 40))
+	d.canvas.polyline(line, 1, withAlpha(col, 160))
+}
+
+const (
+	gaugeStart = 5 * math.Pi / 6
+	gaugeSweep = 4 * math.Pi / 3
+)
+
+func (d *drawContext) drawGauge(r rect, p *panelModel, all []series) {
+	c := d.canvas
+	low, high := 0.0, 100.0
+	if p.min != nil {
+		low = *p.min
+	}
+	if p.max != nil {
+		high = *p.max
+	}
+	if high <= low {
+		high = low + 1
+	}
+	angleOf := func(v float64) float64 {
+		return gaugeStart + math.Max(0, math.Min(1, (v-low)/(high-low)))*gaugeSweep
+	}
+
+	for i, cell := range valueCells(r, len(all)) {
+		s := all[i]
+		v := reduce(s.values, p.reducer)
+		inner := cell.inset(4, 4, 4, 4)
+		if len(all) > 1 {
+			c.text(inner.x+inner.w/2, inner.y+inner.h-labelFontSize/2, truncateText(c, s.name, labelFontSize, inner.w), labelFontSize, d.theme.text, alignCenter)
+			inner = inner.inset(0, 0, labelFontSize+4, 0)
+		}
+
+		radius := math.Min(inner.w/2, inner.h/1.5) * 0.9
+		if radius <= 4 {
+			continue
+		}
+		width := radius * 0.2
+		cx, cy := inner.x+inner.w/2, inner.y+inner.h/2+radius*0.25
+		valueRadius := radius - width*0.75
+
+		// the thresholds are shown as a band around the gauge
+		for j, t := range p.thresholds {
+			start, end := low, high
+			if !math.IsInf(t.value, -1) {
+				start = math.Max(low, t.value)
+			}
+			if j+1 < len(p.thresholds) {
+				end = math.Min(high, p.thresholds[j+1].value)
+			}
+			if end > start {
+				c.arc(cx, cy, radius-width*0.1, angleOf(start), angleOf(end), width*0.2, d.color(t.color, d.theme.grid))
+			}
+		}
+		c.arc(cx, cy, valueRadius, gaugeStart, gaugeStart+gaugeSweep, width, d.theme.grid)
+		if !math.IsNaN(v) {
+			c.arc(cx, cy, valueRadius, gaugeStart, angleOf(v), width, d.valueColor(p, v))
+		}
+
+		text := formatValue(v, p.unit, p.decimals)
+		size := valueFontSize(c, text, (valueRadius-width)*1.6, radius*0.4)
+		c.text(cx, cy, text, size, d.valueColor(p, v), alignCenter)
+	}
+}
+
+func (d *drawContext) drawTable(r rect, p *panelModel, frames data.Frames) {
+	c := d.canvas
+	var frame *data.Frame
+	for _, f := range frames {
+		if f != nil && len(f.Fields) > 0 {
+			frame = f
+			break
+		}
+	}
+	if frame == nil {
+		return
+	}
+
+	const rowHeight = 24
+	columnWidth := r.w / float64(len(frame.Fields))
+	for i, field := range frame.Fields {
+		name := field.Name
+		if field.Config != nil && field.Config.DisplayNameFromDS != "" {
+			name = field.Config.DisplayNameFromDS
+		}
+		x := r.x + float64(i)*columnWidth
+		c.text(x+4, r.y+rowHeight/2, truncateText(c, name, labelFontSize, columnWidth-8), labelFontSize, d.theme.weakText, alignLeft)
+	}
+	c.fillRect(r.x, r.y+rowHeight, r.w, 1, d.theme.border)
+
+	rows, _ := frame.RowLen()
+	for row := 0; row < rows; row++ {
+		y := r.y + float64(row+1)*rowHeight
+		if y+rowHeight > r.y+r.h {
+			break
+		}
+		for i, field := range frame.Fields {
+			x := r.x + float64(i)*columnWidth
+			text := d.formatCell(p, field, row)
+			c.text(x+4, y+rowHeight/2, truncateText(c, text, labelFontSize, columnWidth-8), labelFontSize, d.theme.text, alignLeft)
+		}
+		c.fillRect(r.x, y+rowHeight, r.w, 1, d.theme.grid)
+	}
+}
+
+func (d *drawContext) formatCell(p *panelModel, field *data.Field, row int) string {
+	v, ok := field.ConcreteAt(row)
+	if !ok {
+		return ""
+	}
+	switch {
+	case field.Type().Time():
+		return v.(time.Time).In(d.location).Format("2006-01-02 15:04:05")
+	case field.Type().Numeric():
+		f, err := field.FloatAt(row)
+		if err != nil {
+			return ""
+		}
+		return formatValue(f, p.unit, p.decimals)
+	}
+	switch value := v.(type) {
+	case string:
+		return value
+	case bool:
+		if value {
+			return "true"
+		}
+		return "false"
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package builtin
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// formatValue formats a value in a unit of the panel field config. It supports the common
+// units and treats any other unit as a suffix.
+func formatValue(v float64, unit string, decimals *int) string {
+	if math.IsNaN(v) {
+		return "NaN"
+	}
+	if math.IsInf(v, 0) {
+		if v > 0 {
+			return "+Inf"
+		}
+		return "-Inf"
+	}
+
+	switch unit {
+	case "", "short", "none":
+		return scaled(v, 1000, []string{"", " K", " Mil", " Bil", " Tri"}, decimals)
+	case "percent":
+		return toFixed(v, decimals) + "%"
+	case "percentunit":
+		return toFixed(v*100, decimals) + "%"
+	case "bytes":
+		return scaled(v, 1024, []string{" B", " KiB", " MiB", " GiB", " TiB", " PiB"}, decimals)
+	case "decbytes":
+		return scaled(v, 1000, []string{" B", " kB", " MB", " GB", " TB", " PB"}, decimals)
+	case "bps":
+		return scaled(v, 1000, []string{" bps", " kbps", " Mbps", " Gbps", " Tbps"}, decimals)
+	case "Bps":
+		return scaled(v, 1000, []string{" B/s", " kB/s", " MB/s", " GB/s", " TB/s"}, decimals)
+	case "ms":
+		return duration(v, decimals, []durationUnit{{1, " ms"}, {1000, " s"}, {60000, " min"}, {3600000, " hour"}, {86400000, " day"}})
+	case "s":
+		return duration(v, decimals, []durationUnit{{1, " s"}, {60, " min"}, {3600, " hour"}, {86400, " day"}})
+	case "reqps":
+		return scaled(v, 1000, []string{" req/s", " K req/s", " Mil req/s"}, decimals)
+	case "ops":
+		return scaled(v, 1000, []string{" ops/s", " K ops/s", " Mil ops/s"}, decimals)
+	case "celsius":
+		return toFixed(v, decimals) + "°C"
+	case "fahrenheit":
+		return toFixed(v, decimals) + "°F"
+	}
+
+	if suffix, ok := strings.CutPrefix(unit, "suffix:"); ok {
+		return toFixed(v, decimals) + suffix
+	}
+	if prefix, ok := strings.CutPrefix(unit, "prefix:"); ok {
+		return prefix + toFixed(v, decimals)
+	}
+	return toFixed(v, decimals) + " " + unit
+}
+
+func scaled(v float64, factor float64, suffixes []string, decimals *int) string {
+	i := 0
+	for math.Abs(v) >= factor && i < len(suffixes)-1 {
+		v /= factor
+		i++
+	}
+	return toFixed(v, decimals) + suffixes[i]
+}
+
+type durationUnit struct {
+	size float64
+	name string
+}
+
+func duration(v float64, decimals *int, units []durationUnit) string {
+	unit := units[0]
+	for _, u := range units[1:] {
+		if math.Abs(v) >= u.size {
+			unit = u
+		}
+	}
+	return toFixed(v/unit.size, decimals) + unit.name
+}
+
+// toFixed formats a value with the given number of decimals, or with a number of decimals that
+// depends on the size of the value when they are not set
+func toFixed(v float64, decimals *int) string {
+	if decimals != nil {
+		return strconv.FormatFloat(v, 'f', *decimals, 64)
+	}
+
+	abs, d := math.Abs(v), 0
+	switch {
+	case abs == 0 || abs >= 100:
+		d = 0
+	case abs >= 10:
+		d = 1
+	case abs >= 1:
+		d = 2
+	default:
+		d = 3
+	}
+	s := strconv.FormatFloat(v, 'f', d, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
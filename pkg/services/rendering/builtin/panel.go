@@ -0,0 +1,189 @@
+package builtin
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// renderTarget is what a render path points at: a single panel, or a whole dashboard when the
+// panel id is not set
+type renderTarget struct {
+	dashboardUID string
+	panelID      int64
+	from         string
+	to           string
+	timezone     string
+	variables    url.Values
+}
+
+// parseRenderPath parses the paths rendered by the image renderer, such as
+// d-solo/<uid>/<slug>?orgId=1&panelId=2&from=now-6h&to=now and d/<uid>/<slug>
+func parseRenderPath(path string) (*renderTarget, error) {
+	u, err := url.Parse(strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid render path: %w", err)
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) > 0 && segments[0] == "render" {
+		segments = segments[1:]
+	}
+	if len(segments) < 2 || (segments[0] != "d-solo" && segments[0] != "d") {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPath, u.Path)
+	}
+
+	q := u.Query()
+	target := &renderTarget{
+		dashboardUID: segments[1],
+		from:         q.Get("from"),
+		to:           q.Get("to"),
+		timezone:     q.Get("tz"),
+		variables:    url.Values{},
+	}
+	for _, param := range []string{"panelId", "viewPanel"} {
+		if v := q.Get(param); v != "" {
+			if target.panelID, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid %s: %q", param, v)
+			}
+			break
+		}
+	}
+	for key, values := range q {
+		if name, ok := strings.CutPrefix(key, "var-"); ok {
+			target.variables[name] = values
+		}
+	}
+	return target, nil
+}
+
+type threshold struct {
+	value float64 // -Inf for the base threshold
+	color string
+}
+
+type gridPos struct {
+	x, y, w, h int
+}
+
+// panelModel holds what the built-in renderer draws of a panel
+type panelModel struct {
+	id         int64
+	panelType  string
+	title      string
+	gridPos    gridPos
+	datasource *simplejson.Json
+	targets    []*simplejson.Json
+	interval   string
+	// maxDataPoints is 0 when the panel doesn't set it
+	maxDataPoints int64
+
+	unit       string
+	decimals   *int
+	min        *float64
+	max        *float64
+	fixedColor string
+	thresholds []threshold
+
+	reducer   string
+	graphMode string
+	colorMode string
+}
+
+func newPanelModel(p *simplejson.Json) *panelModel {
+	defaults := p.GetPath("fieldConfig", "defaults")
+	options := p.Get("options")
+
+	m := &panelModel{
+		id:            p.Get("id").MustInt64(),
+		panelType:     p.Get("type").MustString(),
+		title:         p.Get("title").MustString(),
+		datasource:    p.Get("datasource"),
+		interval:      p.Get("interval").MustString(),
+		maxDataPoints: p.Get("maxDataPoints").MustInt64(),
+		unit:          defaults.Get("unit").MustString(),
+		reducer:       "lastNotNull",
+		graphMode:     options.Get("graphMode").MustString("area"),
+		colorMode:     options.Get("colorMode").MustString("value"),
+		gridPos: gridPos{
+			x: p.GetPath("gridPos", "x").MustInt(),
+			y: p.GetPath("gridPos", "y").MustInt(),
+			w: p.GetPath("gridPos", "w").MustInt(12),
+			h: p.GetPath("gridPos", "h").MustInt(8),
+		},
+	}
+
+	for i := range p.Get("targets").MustArray() {
+		m.targets = append(m.targets, p.Get("targets").GetIndex(i))
+	}
+
+	if d, err := defaults.Get("decimals").Int(); err == nil {
+		m.decimals = &d
+	}
+	if v, err := defaults.Get("min").Float64(); err == nil {
+		m.min = &v
+	}
+	if v, err := defaults.Get("max").Float64(); err == nil {
+		m.max = &v
+	}
+	if defaults.GetPath("color", "mode").MustString() == "fixed" {
+		m.fixedColor = defaults.GetPath("color", "fixedColor").MustString()
+	}
+	if calcs := options.GetPath("reduceOptions", "calcs").MustStringArray(); len(calcs) > 0 {
+		m.reducer = calcs[0]
+	}
+
+	steps := defaults.GetPath("thresholds", "steps")
+	for i := range steps.MustArray() {
+		step := steps.GetIndex(i)
+		value, err := step.Get("value").Float64()
+		if err != nil {
+			value = math.Inf(-1)
+		}
+		m.thresholds = append(m.thresholds, threshold{value: value, color: step.Get("color").MustString()})
+	}
+	if len(m.thresholds) == 0 {
+		m.thresholds = []threshold{{value: math.Inf(-1), color: "green"}, {value: 80, color: "red"}}
+	}
+	return m
+}
+
+// thresholdColor returns the color of the highest threshold below the value
+func (m *panelModel) thresholdColor(v float64) string {
+	color := m.thresholds[0].color
+	for _, t := range m.thresholds {
+		if v >= t.value {
+			color = t.color
+		}
+	}
+	return color
+}
+
+// getPanels returns the panels of a dashboard, including the panels of collapsed rows
+func getPanels(dashboard *simplejson.Json) []*simplejson.Json {
+	var panels []*simplejson.Json
+	for i := range dashboard.Get("panels").MustArray() {
+		panel := dashboard.Get("panels").GetIndex(i)
+		if panel.Get("type").MustString() == "row" {
+			for j := range panel.Get("panels").MustArray() {
+				panels = append(panels, panel.Get("panels").GetIndex(j))
+			}
+			continue
+		}
+		panels = append(panels, panel)
+	}
+	return panels
+}
+
+func findPanel(dashboard *simplejson.Json, id int64) (*simplejson.Json, error) {
+	for _, panel := range getPanels(dashboard) {
+		if panel.Get("id").MustInt64() == id {
+			return panel, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", ErrPanelNotFound, id)
+}
@@ -0,0 +1,371 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+)
+
+var (
+	ErrUnsupportedPath = errors.New("unsupported render path")
+	ErrPanelNotFound   = errors.New("panel not found")
+	ErrAccessDenied    = errors.New("access denied to dashboard")
+)
+
+const (
+	defaultFrom          = "now-6h"
+	defaultTo            = "now"
+	defaultMaxDataPoints = 1000
+
+	// dashboard grid, in pixels, used to lay out the panels when rendering a whole dashboard
+	gridColumns    = 24
+	gridCellHeight = 30
+	gridMargin     = 8
+)
+
+var _ rendering.BuiltinRenderer = (*Renderer)(nil)
+
+// Renderer draws panels in Go from the results of their queries. It's registered with the
+// rendering service, which uses it when no image renderer is available.
+type Renderer struct {
+	log              log.Logger
+	dashboardService dashboards.DashboardService
+	queryService     query.Service
+	userService      user.Service
+	accessControl    accesscontrol.AccessControl
+	acService        accesscontrol.Service
+	maxWidth         int
+	maxHeight        int
+	maxScale         float64
+	now              func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, renderingService *rendering.RenderingService, dashboardService dashboards.DashboardService,
+	queryService query.Service, userService user.Service, accessControl accesscontrol.AccessControl, acService accesscontrol.Service,
+) *Renderer {
+	r := &Renderer{
+		log:              log.New("rendering.builtin"),
+		dashboardService: dashboardService,
+		queryService:     queryService,
+		userService:      userService,
+		accessControl:    accessControl,
+		acService:        acService,
+		maxWidth:         cfg.RendererBuiltinMaxWidth,
+		maxHeight:        cfg.RendererBuiltinMaxHeight,
+		maxScale:         cfg.RendererBuiltinMaxScale,
+		now:              time.Now,
+	}
+	// the rendering service can't depend on the renderer, the queries depend on it
+	if cfg.RendererBuiltinEnabled {
+		renderingService.RegisterBuiltinRenderer(r)
+	}
+	return r
+}
+
+// Render draws the panel, or the whole dashboard, of a render path as the user of the options.
+func (r *Renderer) Render(ctx context.Context, opts rendering.Opts, w io.Writer) error {
+	if opts.DeviceScaleFactor <= 0 {
+		opts.DeviceScaleFactor = 1
+	}
+	if err := r.checkSize(opts); err != nil {
+		return err
+	}
+
+	target, err := parseRenderPath(opts.Path)
+	if err != nil {
+		return err
+	}
+
+	usr, err := r.signedInUser(ctx, opts.AuthOpts)
+	if err != nil {
+		return err
+	}
+	dash, err := r.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: target.dashboardUID, OrgID: opts.OrgID})
+	if err != nil {
+		return err
+	}
+	evaluator := accesscontrol.EvalPermission(dashboards.ActionDashboardsRead, dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dash.UID))
+	if canRead, err := r.accessControl.Evaluate(ctx, usr, evaluator); err != nil {
+		return err
+	} else if !canRead {
+		return fmt.Errorf("%w: %s", ErrAccessDenied, dash.UID)
+	}
+
+	timezone := target.timezone
+	if timezone == "" {
+		timezone = opts.Timezone
+	}
+	if timezone == "" {
+		timezone = dash.Data.Get("timezone").MustString()
+	}
+	loc := parseLocation(timezone)
+
+	from, to := target.from, target.to
+	if from == "" {
+		from = dash.Data.GetPath("time", "from").MustString(defaultFrom)
+	}
+	if to == "" {
+		to = dash.Data.GetPath("time", "to").MustString(defaultTo)
+	}
+	tr := legacydata.NewDataTimeRange(from, to)
+	tr.Now = r.now()
+	fromTime, err := tr.ParseFrom(legacydata.WithLocation(loc))
+	if err != nil {
+		return fmt.Errorf("invalid from: %w", err)
+	}
+	toTime, err := tr.ParseTo(legacydata.WithLocation(loc))
+	if err != nil {
+		return fmt.Errorf("invalid to: %w", err)
+	}
+	if !fromTime.Before(toTime) {
+		return fmt.Errorf("invalid time range: from must be before to")
+	}
+
+	var c canvas
+	if opts.Encoding == string(rendering.RenderSVG) {
+		c = newSVGCanvas(opts.Width, opts.Height, opts.DeviceScaleFactor)
+	} else {
+		c = newPNGCanvas(opts.Width, opts.Height, opts.DeviceScaleFactor)
+	}
+	d := &drawContext{canvas: c, theme: getTheme(opts.Theme), from: fromTime, to: toTime, location: loc}
+	c.fillRect(0, 0, float64(opts.Width), float64(opts.Height), d.theme.background)
+
+	variables := getVariables(dash.Data, target.variables)
+	if target.panelID != 0 {
+		panel, err := findPanel(dash.Data, target.panelID)
+		if err != nil {
+			return err
+		}
+		r.drawPanel(ctx, d, usr, dash.UID, rect{w: float64(opts.Width), h: float64(opts.Height)}, newPanelModel(panel), variables)
+	} else {
+		r.drawDashboard(ctx, d, usr, dash.UID, float64(opts.Width), float64(opts.Height), dash.Data, variables)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.encode(w)
+}
+
+// checkSize rejects images larger than the configured maximums, the whole image is held in memory
+// while it's drawn.
+func (r *Renderer) checkSize(opts rendering.Opts) error {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return fmt.Errorf("%w: width and height must be positive", rendering.ErrInvalidSize)
+	}
+	if opts.Width > r.maxWidth || opts.Height > r.maxHeight {
+		return fmt.Errorf("%w: %dx%d is larger than the maximum of %dx%d", rendering.ErrInvalidSize, opts.Width, opts.Height, r.maxWidth, r.maxHeight)
+	}
+	if opts.DeviceScaleFactor > r.maxScale {
+		return fmt.Errorf("%w: scale %g is larger than the maximum of %g", rendering.ErrInvalidSize, opts.DeviceScaleFactor, r.maxScale)
+	}
+	return nil
+}
+
+// drawDashboard lays out the panels like the dashboard grid, scaled to the width of the image.
+// Panels below the height of the image are not drawn.
+func (r *Renderer) drawDashboard(ctx context.Context, d *drawContext, usr *user.SignedInUser, dashboardUID string, width, height float64, model *simplejson.Json, variables map[string]*variable) {
+	columnWidth := (width - gridMargin) / gridColumns
+	for _, panel := range getPanels(model) {
+		p := newPanelModel(panel)
+		if p.panelType == "row" {
+			continue
+		}
+		bounds := rect{
+			x: gridMargin + float64(p.gridPos.x)*columnWidth,
+			y: gridMargin + float64(p.gridPos.y)*(gridCellHeight+gridMargin),
+			w: float64(p.gridPos.w)*columnWidth - gridMargin,
+			h: float64(p.gridPos.h)*(gridCellHeight+gridMargin) - gridMargin,
+		}
+		if bounds.y >= height || bounds.w <= 0 || bounds.h <= 0 {
+			continue
+		}
+		r.drawPanel(ctx, d, usr, dashboardUID, bounds, p, variables)
+	}
+}
+
+// drawPanel runs the queries of a panel and draws it. Query errors are drawn in the panel,
+// so that a broken panel doesn't fail the dashboard.
+func (r *Renderer) drawPanel(ctx context.Context, d *drawContext, usr *user.SignedInUser, dashboardUID string, bounds rect, p *panelModel, variables map[string]*variable) {
+	frames, err := r.queryPanel(ctx, d, usr, p, variables)
+	message := ""
+	if err != nil {
+		r.log.Warn("Failed to query panel data", "dashboardUID", dashboardUID, "panelId", p.id, "error", err)
+		message = err.Error()
+	}
+	d.drawPanel(bounds, p, frames, message)
+}
+
+func (r *Renderer) queryPanel(ctx context.Context, d *drawContext, usr *user.SignedInUser, p *panelModel, variables map[string]*variable) (data.Frames, error) {
+	if len(p.targets) == 0 {
+		return nil, nil
+	}
+
+	maxDataPoints := p.maxDataPoints
+	if maxDataPoints <= 0 {
+		maxDataPoints = defaultMaxDataPoints
+	}
+	intervalMs := d.to.Sub(d.from).Milliseconds() / maxDataPoints
+	if minInterval, err := parseInterval(interpolateString(p.interval, variables, "")); err == nil && minInterval.Milliseconds() > intervalMs {
+		intervalMs = minInterval.Milliseconds()
+	}
+	if intervalMs < 1 {
+		intervalMs = 1
+	}
+
+	hasExpression := false
+	for _, t := range p.targets {
+		if expr.NodeTypeFromDatasourceUID(t.GetPath("datasource", "uid").MustString()) == expr.TypeCMDNode {
+			hasExpression = true
+		}
+	}
+
+	queries := make([]*simplejson.Json, 0, len(p.targets))
+	for _, t := range p.targets {
+		// hidden queries are only needed when expressions depend on them
+		if !hasExpression && t.Get("hide").MustBool() {
+			continue
+		}
+		// work on a copy, the dashboard may be cached
+		q := simplejson.NewFromAny(copyJSON(t.Interface()))
+		if _, ok := q.CheckGet("datasource"); !ok && p.datasource.Interface() != nil {
+			q.Set("datasource", p.datasource.Interface())
+		}
+		interpolateQuery(q, variables)
+		q.Set("intervalMs", intervalMs)
+		q.Set("maxDataPoints", maxDataPoints)
+		queries = append(queries, q)
+	}
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	req := dtos.MetricRequest{
+		From:    strconv.FormatInt(d.from.UnixMilli(), 10),
+		To:      strconv.FormatInt(d.to.UnixMilli(), 10),
+		Queries: queries,
+	}
+	res, err := r.queryService.QueryData(ctx, usr, false, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var frames data.Frames
+	for _, q := range queries {
+		if q.Get("hide").MustBool() {
+			continue
+		}
+		refID := q.Get("refId").MustString()
+		resp, ok := res.Responses[refID]
+		if !ok {
+			continue
+		}
+		if resp.Error != nil {
+			return nil, fmt.Errorf("query %s: %w", refID, resp.Error)
+		}
+		frames = append(frames, resp.Frames...)
+	}
+	return frames, nil
+}
+
+// signedInUser returns the user the data is queried as. Render requests without a user,
+// such as the ones of alerting, are made with the org role of the options.
+func (r *Renderer) signedInUser(ctx context.Context, opts rendering.AuthOpts) (*user.SignedInUser, error) {
+	var usr *user.SignedInUser
+	if opts.UserID > 0 {
+		var err error
+		usr, err = r.userService.GetSignedInUserWithCacheCtx(ctx, &user.GetSignedInUserQuery{UserID: opts.UserID, OrgID: opts.OrgID})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		usr = &user.SignedInUser{
+			OrgID:   opts.OrgID,
+			OrgRole: opts.OrgRole,
+			Login:   "grafana_renderer",
+		}
+	}
+
+	if usr.Permissions == nil {
+		usr.Permissions = make(map[int64]map[string][]string)
+	}
+	if _, ok := usr.Permissions[usr.OrgID]; !ok {
+		permissions, err := r.acService.GetUserPermissions(ctx, usr, accesscontrol.Options{ReloadCache: false})
+		if err != nil {
+			return nil, err
+		}
+		usr.Permissions[usr.OrgID] = accesscontrol.GroupScopesByAction(permissions)
+	}
+	return usr, nil
+}
+
+// parseLocation returns the location of an IANA time zone name or of an offset like UTC+02:00,
+// and UTC for any other time zone, such as the browser time zone.
+func parseLocation(timezone string) *time.Location {
+	if timezone == "" || timezone == "browser" || timezone == "utc" {
+		return time.UTC
+	}
+	if offset, ok := strings.CutPrefix(timezone, "UTC"); ok && len(offset) > 1 {
+		sign := 1
+		if offset[0] == '-' {
+			sign = -1
+		}
+		parts := strings.SplitN(offset[1:], ":", 2)
+		hours, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return time.UTC
+		}
+		minutes := 0
+		if len(parts) == 2 {
+			if minutes, err = strconv.Atoi(parts[1]); err != nil {
+				return time.UTC
+			}
+		}
+		return time.FixedZone(timezone, sign*(hours*3600+minutes*60))
+	}
+	if loc, err := time.LoadLocation(timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// parseInterval parses the min interval of a panel, such as 1m or >30s
+func parseInterval(s string) (time.Duration, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), ">")
+	if s == "" {
+		return 0, errors.New("empty interval")
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	// units the standard library doesn't have
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(math.Round(v * float64(unit))), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid interval %q", s)
+}
@@ -0,0 +1,271 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+)
+
+const testDashboard = `{
+	"uid": "prod",
+	"title": "Production",
+	"time": {"from": "now-1h", "to": "now"},
+	"templating": {"list": [
+		{"name": "host", "type": "query", "multi": true, "current": {"text": "a", "value": ["a"]}, "options": []}
+	]},
+	"panels": [
+		{"id": 1, "type": "timeseries", "title": "CPU", "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+			"datasource": {"uid": "prom", "type": "prometheus"},
+			"targets": [{"refId": "A", "expr": "cpu{host=~\"$host\"}"}, {"refId": "B", "expr": "hidden", "hide": true}]},
+		{"id": 2, "type": "stat", "title": "Load", "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+			"datasource": {"uid": "prom", "type": "prometheus"},
+			"fieldConfig": {"defaults": {"unit": "percent", "thresholds": {"steps": [{"value": null, "color": "green"}, {"value": 50, "color": "red"}]}}},
+			"targets": [{"refId": "A", "expr": "load"}]}
+	]
+}`
+
+func setupRenderer(t *testing.T, canRead bool) (*Renderer, *query.FakeQueryService, *[]dtos.MetricRequest) {
+	t.Helper()
+
+	dashData, err := simplejson.NewJson([]byte(testDashboard))
+	require.NoError(t, err)
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).
+		Return(&dashboards.Dashboard{UID: "prod", OrgID: 1, Title: "Production", Data: dashData}, nil)
+
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	var requests []dtos.MetricRequest
+	queryService := &query.FakeQueryService{}
+	queryService.On("QueryData", mock.Anything, mock.Anything, false, mock.Anything).Return(
+		func(_ context.Context, _ identity.Requester, _ bool, req dtos.MetricRequest) *backend.QueryDataResponse {
+			requests = append(requests, req)
+			times := make([]time.Time, 60)
+			values := make([]float64, 60)
+			for i := range times {
+				times[i] = now.Add(time.Duration(i-60) * time.Minute)
+				values[i] = float64(i)
+			}
+			frame := data.NewFrame("cpu",
+				data.NewField("time", nil, times),
+				data.NewField("value", data.Labels{"host": "a"}, values),
+			)
+			return &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{frame}}}}
+		}, nil)
+
+	return &Renderer{
+		log:              log.NewNopLogger(),
+		dashboardService: dashboardService,
+		queryService:     queryService,
+		userService:      &usertest.FakeUserService{ExpectedSignedInUser: &user.SignedInUser{UserID: 7, OrgID: 1, OrgRole: org.RoleViewer}},
+		accessControl:    &actest.FakeAccessControl{ExpectedEvaluate: canRead},
+		acService:        &actest.FakeService{},
+		maxWidth:         2000,
+		maxHeight:        1000,
+		maxScale:         2,
+		now:              func() time.Time { return now },
+	}, queryService, &requests
+}
+
+func TestRender(t *testing.T) {
+	t.Run("renders a panel to PNG with the queries of the panel", func(t *testing.T) {
+		r, _, requests := setupRenderer(t, true)
+
+		var buf bytes.Buffer
+		err := r.Render(context.Background(), rendering.Opts{
+			AuthOpts:          rendering.AuthOpts{OrgID: 1, UserID: 7},
+			Width:             400,
+			Height:            200,
+			DeviceScaleFactor: 2,
+			Path:              "d-solo/prod/production?orgId=1&panelId=1&var-host=b&var-host=c",
+			Theme:             models.ThemeLight,
+		}, &buf)
+		require.NoError(t, err)
+
+		img, err := png.Decode(&buf)
+		require.NoError(t, err)
+		require.Equal(t, 800, img.Bounds().Dx())
+		require.Equal(t, 400, img.Bounds().Dy())
+		r0, g0, b0, _ := img.At(400, 5).RGBA()
+		require.Equal(t, [3]uint32{0xffff, 0xffff, 0xffff}, [3]uint32{r0, g0, b0}, "light theme background")
+
+		require.Len(t, *requests, 1)
+		req := (*requests)[0]
+		require.Len(t, req.Queries, 1, "hidden queries are not run")
+		require.Equal(t, `cpu{host=~"(b|c)"}`, req.Queries[0].Get("expr").MustString())
+		require.Equal(t, "prom", req.Queries[0].GetPath("datasource", "uid").MustString())
+		require.Equal(t, "1792314000000", req.From)
+		require.Equal(t, "1792317600000", req.To)
+	})
+
+	t.Run("renders a dashboard to SVG", func(t *testing.T) {
+		r, _, requests := setupRenderer(t, true)
+
+		var buf bytes.Buffer
+		err := r.Render(context.Background(), rendering.Opts{
+			AuthOpts:          rendering.AuthOpts{OrgID: 1, OrgRole: org.RoleAdmin},
+			Width:             1000,
+			Height:            500,
+			DeviceScaleFactor: 1,
+			Encoding:          "svg",
+			Path:              "d/prod/production?orgId=1&from=now-2h&to=now",
+		}, &buf)
+		require.NoError(t, err)
+
+		svg := buf.String()
+		require.True(t, strings.HasPrefix(svg, "<svg"))
+		require.True(t, strings.HasSuffix(svg, "</svg>"))
+		require.Contains(t, svg, ">CPU</text>")
+		require.Contains(t, svg, ">Load</text>")
+		require.Contains(t, svg, ">59%</text>")
+		require.Len(t, *requests, 2)
+		require.Equal(t, `cpu{host=~"a"}`, (*requests)[0].Queries[0].Get("expr").MustString())
+	})
+
+	t.Run("fails without access to the dashboard", func(t *testing.T) {
+		r, queryService, _ := setupRenderer(t, false)
+
+		err := r.Render(context.Background(), rendering.Opts{
+			AuthOpts: rendering.AuthOpts{OrgID: 1, UserID: 7},
+			Width:    400,
+			Height:   200,
+			Path:     "d-solo/prod/production?orgId=1&panelId=1",
+		}, &bytes.Buffer{})
+		require.ErrorIs(t, err, ErrAccessDenied)
+		queryService.AssertNotCalled(t, "QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects images larger than the limits", func(t *testing.T) {
+		r, queryService, _ := setupRenderer(t, true)
+
+		for _, opts := range []rendering.Opts{
+			{Width: 2001, Height: 200},
+			{Width: 400, Height: 1001},
+			{Width: 400, Height: 200, DeviceScaleFactor: 3},
+			{Width: 0, Height: 200},
+			{Width: 400, Height: -1},
+		} {
+			opts.Path = "d-solo/prod/production?orgId=1&panelId=1"
+			err := r.Render(context.Background(), opts, &bytes.Buffer{})
+			require.ErrorIs(t, err, rendering.ErrInvalidSize)
+		}
+		queryService.AssertNotCalled(t, "QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+		var buf bytes.Buffer
+		err := r.Render(context.Background(), rendering.Opts{
+			AuthOpts:          rendering.AuthOpts{OrgID: 1, UserID: 7},
+			Width:             2000,
+			Height:            1000,
+			DeviceScaleFactor: 2,
+			Path:              "d-solo/prod/production?orgId=1&panelId=1",
+		}, &buf)
+		require.NoError(t, err)
+		img, err := png.Decode(&buf)
+		require.NoError(t, err)
+		require.Equal(t, image.Rect(0, 0, 4000, 2000), img.Bounds())
+	})
+
+	t.Run("fails for unknown panels and paths", func(t *testing.T) {
+		r, _, _ := setupRenderer(t, true)
+
+		err := r.Render(context.Background(), rendering.Opts{Width: 400, Height: 200, Path: "d-solo/prod/production?panelId=9"}, &bytes.Buffer{})
+		require.ErrorIs(t, err, ErrPanelNotFound)
+
+		err = r.Render(context.Background(), rendering.Opts{Width: 400, Height: 200, Path: "explore?left=1"}, &bytes.Buffer{})
+		require.ErrorIs(t, err, ErrUnsupportedPath)
+	})
+}
+
+func TestParseRenderPath(t *testing.T) {
+	target, err := parseRenderPath("/render/d-solo/prod/production?orgId=1&panelId=4&from=now-6h&to=now&tz=Europe%2FStockholm&var-host=a&var-host=b")
+	require.NoError(t, err)
+	require.Equal(t, "prod", target.dashboardUID)
+	require.Equal(t, int64(4), target.panelID)
+	require.Equal(t, "now-6h", target.from)
+	require.Equal(t, "now", target.to)
+	require.Equal(t, "Europe/Stockholm", target.timezone)
+	require.Equal(t, []string{"a", "b"}, target.variables["host"])
+
+	target, err = parseRenderPath("d/prod/production?viewPanel=2")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), target.panelID)
+
+	_, err = parseRenderPath("d-solo/prod?panelId=x")
+	require.Error(t, err)
+}
+
+func TestFormatValue(t *testing.T) {
+	two := 2
+	tests := []struct {
+		value    float64
+		unit     string
+		decimals *int
+		expected string
+	}{
+		{value: 1234, unit: "", expected: "1.23 K"},
+		{value: 0.5, unit: "percentunit", expected: "50%"},
+		{value: 42.123, unit: "percent", decimals: &two, expected: "42.12%"},
+		{value: 2048, unit: "bytes", expected: "2 KiB"},
+		{value: 90000, unit: "ms", expected: "1.5 min"},
+		{value: 3, unit: "suffix: req", expected: "3 req"},
+		{value: 3, unit: "widgets", expected: "3 widgets"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, formatValue(tt.value, tt.unit, tt.decimals), "%v %s", tt.value, tt.unit)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	values := []float64{3, 1, math.NaN(), 2}
+	require.Equal(t, 2.0, reduce(values, "lastNotNull"))
+	require.Equal(t, 1.0, reduce(values, "min"))
+	require.Equal(t, 3.0, reduce(values, "max"))
+	require.Equal(t, 2.0, reduce(values, "mean"))
+	require.Equal(t, 4.0, reduce(values, "count"))
+}
+
+func TestParseLocation(t *testing.T) {
+	require.Equal(t, time.UTC, parseLocation("browser"))
+	_, offset := time.Date(2026, 1, 1, 0, 0, 0, 0, parseLocation("UTC-05:30")).Zone()
+	require.Equal(t, -(5*3600 + 30*60), offset)
+	require.Equal(t, "Europe/Stockholm", parseLocation("Europe/Stockholm").String())
+}
+
+func TestPNGCanvas(t *testing.T) {
+	c := newPNGCanvas(20, 10, 1)
+	half := color.NRGBA{R: 255, A: 128}
+
+	// the line goes back over itself, each pixel is blended once
+	c.polyline([]point{{x: 2, y: 5}, {x: 18, y: 5}, {x: 2, y: 5}}, 2, half)
+	require.Equal(t, color.RGBA{R: 128, A: 128}, c.img.RGBAAt(10, 5))
+	require.Equal(t, color.RGBA{}, c.img.RGBAAt(10, 1))
+
+	// shapes far outside of the image are clipped
+	c.arc(1e6, 1e6, 10, 0, math.Pi, 1, half)
+	c.polygon([]point{{x: -1e6, y: -1e6}, {x: 1e6, y: -1e6}, {x: 0, y: 1e6}}, color.NRGBA{B: 255, A: 255})
+	require.Equal(t, color.RGBA{B: 255, A: 255}, c.img.RGBAAt(10, 5))
+	require.True(t, c.mask.dirty.Empty())
+}
@@ -0,0 +1,167 @@
+package builtin
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// series is a numeric field of a frame, with the times of its values when the frame has a time
+// field. Null values are NaN.
+type series struct {
+	name   string
+	times  []time.Time
+	values []float64
+}
+
+// getSeries returns the numeric fields of the frames. Long frames, such as the results of SQL
+// queries, are converted to wide frames first.
+func getSeries(frames data.Frames) []series {
+	var result []series
+	for _, frame := range frames {
+		if frame == nil || len(frame.Fields) == 0 {
+			continue
+		}
+		if frame.TimeSeriesSchema().Type == data.TimeSeriesTypeLong {
+			if wide, err := data.LongToWide(frame, nil); err == nil {
+				frame = wide
+			}
+		}
+
+		var times []time.Time
+		for _, field := range frame.Fields {
+			if field.Type().Time() {
+				times = make([]time.Time, field.Len())
+				for i := range times {
+					if t, ok := field.ConcreteAt(i); ok {
+						times[i] = t.(time.Time)
+					}
+				}
+				break
+			}
+		}
+
+		numeric := 0
+		for _, field := range frame.Fields {
+			if field.Type().Numeric() {
+				numeric++
+			}
+		}
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() {
+				continue
+			}
+			s := series{name: seriesName(frame, field, numeric, len(frames)), times: times, values: make([]float64, field.Len())}
+			for i := range s.values {
+				v, err := field.NullableFloatAt(i)
+				if err != nil || v == nil {
+					s.values[i] = math.NaN()
+					continue
+				}
+				s.values[i] = *v
+			}
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func seriesName(frame *data.Frame, field *data.Field, numericFields, frames int) string {
+	if field.Config != nil {
+		if field.Config.DisplayNameFromDS != "" {
+			return field.Config.DisplayNameFromDS
+		}
+		if field.Config.DisplayName != "" {
+			return field.Config.DisplayName
+		}
+	}
+	if len(field.Labels) > 0 {
+		return "{" + field.Labels.String() + "}"
+	}
+	if frame.Name != "" && (numericFields == 1 || frames > 1) {
+		return frame.Name
+	}
+	return field.Name
+}
+
+// reduce calculates a single value of a series, like the reduceOptions of stat and gauge panels.
+// It returns NaN when the series has no values for the calculation.
+func reduce(values []float64, calc string) float64 {
+	var notNull []float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			notNull = append(notNull, v)
+		}
+	}
+
+	switch calc {
+	case "last":
+		if len(values) == 0 {
+			return math.NaN()
+		}
+		return values[len(values)-1]
+	case "first":
+		if len(values) == 0 {
+			return math.NaN()
+		}
+		return values[0]
+	case "count":
+		return float64(len(values))
+	}
+
+	if len(notNull) == 0 {
+		return math.NaN()
+	}
+	switch calc {
+	case "firstNotNull":
+		return notNull[0]
+	case "min":
+		return minOf(notNull)
+	case "max":
+		return maxOf(notNull)
+	case "sum", "mean":
+		sum := 0.0
+		for _, v := range notNull {
+			sum += v
+		}
+		if calc == "mean" {
+			return sum / float64(len(notNull))
+		}
+		return sum
+	case "median":
+		sorted := append([]float64{}, notNull...)
+		sort.Float64s(sorted)
+		if len(sorted)%2 == 1 {
+			return sorted[len(sorted)/2]
+		}
+		return (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	case "range":
+		return maxOf(notNull) - minOf(notNull)
+	case "diff":
+		return notNull[len(notNull)-1] - notNull[0]
+	}
+	// lastNotNull is the default calculation
+	return notNull[len(notNull)-1]
+}
+
+func minOf(values []float64) float64 {
+	m := math.Inf(1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			m = math.Min(m, v)
+		}
+	}
+	return m
+}
+
+func maxOf(values []float64) float64 {
+	m := math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			m = math.Max(m, v)
+		}
+	}
+	return m
+}
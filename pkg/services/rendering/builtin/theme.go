@@ -0,0 +1,118 @@
+package builtin
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+type theme struct {
+	background color.NRGBA
+	text       color.NRGBA
+	weakText   color.NRGBA
+	grid       color.NRGBA
+	border     color.NRGBA
+}
+
+var (
+	darkTheme = theme{
+		background: hexColor("#181b1f"),
+		text:       hexColor("#ccccdc"),
+		weakText:   withAlpha(hexColor("#ccccdc"), 166),
+		grid:       withAlpha(hexColor("#ccccdc"), 31),
+		border:     withAlpha(hexColor("#ccccdc"), 41),
+	}
+	lightTheme = theme{
+		background: hexColor("#ffffff"),
+		text:       hexColor("#24292e"),
+		weakText:   withAlpha(hexColor("#24292e"), 191),
+		grid:       withAlpha(hexColor("#24292e"), 31),
+		border:     withAlpha(hexColor("#24292e"), 31),
+	}
+)
+
+func getTheme(t models.Theme) theme {
+	if t == models.ThemeLight {
+		return lightTheme
+	}
+	return darkTheme
+}
+
+// seriesColors is the classic palette series are colored with, in order
+var seriesColors = []color.NRGBA{
+	hexColor("#73BF69"), hexColor("#F2CC0C"), hexColor("#8AB8FF"), hexColor("#FF780A"),
+	hexColor("#F2495C"), hexColor("#5794F2"), hexColor("#B877D9"), hexColor("#705DA0"),
+	hexColor("#37872D"), hexColor("#FADE2A"), hexColor("#447EBC"), hexColor("#C15C17"),
+	hexColor("#890F02"), hexColor("#0A437C"), hexColor("#6D1F62"), hexColor("#584477"),
+}
+
+// namedColors are the named colors of the color picker, without their shade
+var namedColors = map[string]color.NRGBA{
+	"red":         hexColor("#F2495C"),
+	"orange":      hexColor("#FF9830"),
+	"yellow":      hexColor("#FADE2A"),
+	"green":       hexColor("#73BF69"),
+	"blue":        hexColor("#5794F2"),
+	"purple":      hexColor("#B877D9"),
+	"transparent": {},
+}
+
+// parseColor parses colors of the dashboard JSON: named colors, hex colors and rgb(a) colors.
+// It returns false when the color is not recognized.
+func parseColor(s string, t theme) (color.NRGBA, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "text" {
+		return t.text, true
+	}
+	for _, shade := range []string{"super-light-", "light-", "semi-dark-", "dark-"} {
+		s = strings.TrimPrefix(s, shade)
+	}
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+	if strings.HasPrefix(s, "#") && (len(s) == 7 || len(s) == 4) {
+		if len(s) == 4 {
+			s = "#" + strings.Repeat(s[1:2], 2) + strings.Repeat(s[2:3], 2) + strings.Repeat(s[3:4], 2)
+		}
+		if _, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			return hexColor(s), true
+		}
+		return color.NRGBA{}, false
+	}
+	if strings.HasPrefix(s, "rgb") {
+		open, end := strings.Index(s, "("), strings.Index(s, ")")
+		if open < 0 || end < open {
+			return color.NRGBA{}, false
+		}
+		parts := strings.Split(s[open+1:end], ",")
+		if len(parts) < 3 {
+			return color.NRGBA{}, false
+		}
+		values := make([]float64, len(parts))
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return color.NRGBA{}, false
+			}
+			values[i] = v
+		}
+		c := color.NRGBA{R: uint8(values[0]), G: uint8(values[1]), B: uint8(values[2]), A: 255}
+		if len(values) > 3 {
+			c.A = uint8(values[3] * 255)
+		}
+		return c, true
+	}
+	return color.NRGBA{}, false
+}
+
+func hexColor(s string) color.NRGBA {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}
+}
+
+func withAlpha(c color.NRGBA, a uint8) color.NRGBA {
+	c.A = a
+	return c
+}
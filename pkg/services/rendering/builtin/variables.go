@@ -0,0 +1,204 @@
+package builtin
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var variableRegex = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?::([^\}]+))?\}`)
+
+const allValue = "$__all"
+
+// variable is a template variable with the values the render path selects, or the current
+// values of the dashboard. Variables are not refreshed, their options are the ones saved with
+// the dashboard.
+type variable struct {
+	values   []string
+	multi    bool
+	allValue string
+	options  []string
+}
+
+// getVariables returns the variables of a dashboard with their values
+func getVariables(model *simplejson.Json, selected url.Values) map[string]*variable {
+	variables := map[string]*variable{}
+	for i := range model.GetPath("templating", "list").MustArray() {
+		raw := model.GetPath("templating", "list").GetIndex(i)
+		name := raw.Get("name").MustString()
+		if name == "" {
+			continue
+		}
+
+		v := &variable{
+			multi:    raw.Get("multi").MustBool() || raw.Get("includeAll").MustBool(),
+			allValue: raw.Get("allValue").MustString(),
+		}
+		for j := range raw.Get("options").MustArray() {
+			if value := raw.Get("options").GetIndex(j).Get("value").MustString(); value != allValue {
+				v.options = append(v.options, value)
+			}
+		}
+
+		if values, ok := selected[name]; ok {
+			v.values = values
+		} else if values, err := raw.GetPath("current", "value").StringArray(); err == nil {
+			v.values = values
+		} else {
+			v.values = []string{raw.GetPath("current", "value").MustString()}
+		}
+		variables[name] = v
+	}
+	return variables
+}
+
+func (v *variable) isAll() bool {
+	return len(v.values) == 1 && (v.values[0] == allValue || v.values[0] == "All")
+}
+
+// interpolateQuery replaces the variables in the strings of a query with their values
+func interpolateQuery(query *simplejson.Json, variables map[string]*variable) {
+	if len(variables) == 0 {
+		return
+	}
+	datasourceType := query.GetPath("datasource", "type").MustString()
+	for key, value := range query.MustMap() {
+		if key == "datasource" || key == "refId" {
+			continue
+		}
+		query.Set(key, walkStrings(value, func(s string) string {
+			return interpolateString(s, variables, datasourceType)
+		}))
+	}
+}
+
+func interpolateString(s string, variables map[string]*variable, datasourceType string) string {
+	return variableRegex.ReplaceAllStringFunc(s, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+		name := firstNonEmpty(groups[1], groups[2], groups[4])
+		format := firstNonEmpty(groups[3], groups[5])
+		v, ok := variables[name]
+		if !ok {
+			return match
+		}
+		return v.format(format, datasourceType)
+	})
+}
+
+// format formats the values of a variable like the browser does, with the format of the
+// variable expression or the default format of the data source
+func (v *variable) format(format, datasourceType string) string {
+	if v.isAll() && v.allValue != "" {
+		return v.allValue
+	}
+	values := v.values
+	if v.isAll() {
+		values = v.options
+	}
+
+	switch format {
+	case "raw", "csv":
+		return strings.Join(values, ",")
+	case "pipe":
+		return strings.Join(values, "|")
+	case "regex":
+		return regexFormat(values)
+	case "json":
+		body, _ := json.Marshal(values)
+		return string(body)
+	case "singlequote":
+		return quoteEach(values, "'", `\'`)
+	case "doublequote":
+		return quoteEach(values, `"`, `\"`)
+	case "sqlstring":
+		return quoteEach(values, "'", "''")
+	}
+
+	switch datasourceType {
+	case "mysql", "postgres", "grafana-postgresql-datasource", "mssql":
+		if v.multi {
+			return quoteEach(values, "'", "''")
+		}
+		return strings.ReplaceAll(strings.Join(values, ","), "'", "''")
+	case "prometheus", "loki":
+		if v.multi {
+			return regexFormat(values)
+		}
+		return strings.Join(values, ",")
+	}
+	if len(values) > 1 {
+		return "{" + strings.Join(values, ",") + "}"
+	}
+	return strings.Join(values, ",")
+}
+
+func regexFormat(values []string) string {
+	escaped := make([]string, 0, len(values))
+	for _, value := range values {
+		escaped = append(escaped, regexp.QuoteMeta(value))
+	}
+	if len(escaped) == 1 {
+		return escaped[0]
+	}
+	return "(" + strings.Join(escaped, "|") + ")"
+}
+
+func quoteEach(values []string, quote string, escapedQuote string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, quote+strings.ReplaceAll(value, quote, escapedQuote)+quote)
+	}
+	return strings.Join(quoted, ",")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// walkStrings calls fn for the strings of a JSON value and replaces them with its result
+func walkStrings(value any, fn func(string) string) any {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		for key, item := range v {
+			v[key] = walkStrings(item, fn)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = walkStrings(item, fn)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// copyJSON returns a deep copy of a JSON value
+func copyJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for key, item := range v {
+			c[key] = copyJSON(item)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, item := range v {
+			c[i] = copyJSON(item)
+		}
+		return c
+	default:
+		return value
+	}
+}
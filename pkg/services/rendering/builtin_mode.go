@@ -0,0 +1,48 @@
+package rendering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+func (rs *RenderingService) renderViaBuiltin(ctx context.Context, _ string, opts Opts) (*RenderResult, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, getRequestTimeout(opts.TimeoutOpts))
+		defer cancel()
+	}
+
+	rt := RenderPNG
+	if opts.Encoding == string(RenderSVG) {
+		rt = RenderSVG
+	}
+	filePath, err := rs.getNewFilePath(rt)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec
+	f, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image file: %w", err)
+	}
+
+	err = rs.builtinRenderer.Render(ctx, opts, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if removeErr := os.Remove(filePath); removeErr != nil {
+			rs.log.Warn("Failed to remove image file", "path", filePath, "error", removeErr)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			rs.log.Info("Rendering timed out")
+			return nil, ErrTimeout
+		}
+		return nil, err
+	}
+
+	return &RenderResult{FilePath: filePath}, nil
+}
@@ -3,6 +3,7 @@ package rendering
 import (
 	"context"
 	"errors"
+	"io"
 	"time"
 
 	"github.com/grafana/grafana/pkg/models"
@@ -13,6 +14,7 @@ import (
 var ErrTimeout = errors.New("timeout error - you can set timeout in seconds with &timeout url parameter")
 var ErrConcurrentLimitReached = errors.New("rendering concurrent limit reached")
 var ErrRenderUnavailable = errors.New("rendering plugin not available")
+var ErrInvalidSize = errors.New("invalid image size")
 var ErrServerTimeout = errutil.NewBase(errutil.StatusUnknown, "rendering.serverTimeout", errutil.WithPublicMessage("error trying to connect to image-renderer service"))
 
 type RenderType string
@@ -20,6 +22,7 @@ type RenderType string
 const (
 	RenderCSV RenderType = "csv"
 	RenderPNG RenderType = "png"
+	RenderSVG RenderType = "svg"
 )
 
 type TimeoutOpts struct {
@@ -97,6 +100,14 @@ type renderFunc func(ctx context.Context, renderKey string, options Opts) (*Rend
 type renderCSVFunc func(ctx context.Context, renderKey string, options CSVOpts) (*RenderCSVResult, error)
 type sanitizeFunc func(ctx context.Context, req *SanitizeSVGRequest) (*SanitizeSVGResponse, error)
 
+// BuiltinRenderer draws panels from the results of their queries, without a browser. It is
+// used when neither the image renderer plugin nor a remote renderer is available.
+type BuiltinRenderer interface {
+	// Render writes the image of the path of the options in the encoding of the options,
+	// png or svg, querying the data as the user of the auth options.
+	Render(ctx context.Context, opts Opts, w io.Writer) error
+}
+
 type renderKeyProvider interface {
 	get(ctx context.Context, opts AuthOpts) (string, error)
 	afterRequest(ctx context.Context, opts AuthOpts, renderKey string)
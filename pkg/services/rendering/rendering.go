@@ -38,6 +38,7 @@ type RenderingService struct {
 	version           string
 	versionMutex      sync.RWMutex
 	capabilities      []Capability
+	builtinRenderer   BuiltinRenderer
 
 	perRequestRenderKeyProvider renderKeyProvider
 	Cfg                         *setting.Cfg
@@ -184,6 +185,16 @@ func (rs *RenderingService) Run(ctx context.Context) error {
 		return nil
 	}
 
+	if rs.builtinAvailable() {
+		rs.log = rs.log.New("renderer", "builtin")
+		rs.log.Info("No image renderer found/installed, falling back to the built-in renderer")
+
+		rs.renderAction = rs.renderViaBuiltin
+		<-ctx.Done()
+
+		return nil
+	}
+
 	rs.log.Debug("No image renderer found/installed. " +
 		"For image rendering support please install the grafana-image-renderer plugin. " +
 		"Read more at https://grafana.com/docs/grafana/latest/administration/image_rendering/")
@@ -200,10 +211,26 @@ func (rs *RenderingService) remoteAvailable() bool {
 	return rs.Cfg.RendererUrl != ""
 }
 
-func (rs *RenderingService) IsAvailable(ctx context.Context) bool {
+func (rs *RenderingService) builtinAvailable() bool {
+	return rs.builtinRenderer != nil
+}
+
+// externalAvailable returns true when rendering is done by the image renderer, which is the
+// only renderer supporting CSV rendering and SVG sanitization
+func (rs *RenderingService) externalAvailable(ctx context.Context) bool {
 	return rs.remoteAvailable() || rs.pluginAvailable(ctx)
 }
 
+func (rs *RenderingService) IsAvailable(ctx context.Context) bool {
+	return rs.externalAvailable(ctx) || rs.builtinAvailable()
+}
+
+// RegisterBuiltinRenderer sets the renderer used when no image renderer is available. It must
+// be called before the service runs.
+func (rs *RenderingService) RegisterBuiltinRenderer(renderer BuiltinRenderer) {
+	rs.builtinRenderer = renderer
+}
+
 func (rs *RenderingService) Version() string {
 	rs.versionMutex.RLock()
 	defer rs.versionMutex.RUnlock()
@@ -339,7 +366,7 @@ func (rs *RenderingService) renderCSV(ctx context.Context, opts CSVOpts, renderK
 		return nil, ErrConcurrentLimitReached
 	}
 
-	if !rs.IsAvailable(ctx) {
+	if !rs.externalAvailable(ctx) {
 		return nil, ErrRenderUnavailable
 	}
 
@@ -367,9 +394,12 @@ func (rs *RenderingService) getNewFilePath(rt RenderType) (string, error) {
 
 	ext := "png"
 	folder := rs.Cfg.ImagesDir
-	if rt == RenderCSV {
+	switch rt {
+	case RenderCSV:
 		ext = "csv"
 		folder = rs.Cfg.CSVsDir
+	case RenderSVG:
+		ext = "svg"
 	}
 
 	return filepath.Abs(filepath.Join(folder, fmt.Sprintf("%s.%s", rand, ext)))
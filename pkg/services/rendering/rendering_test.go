@@ -3,8 +3,10 @@ package rendering
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -120,6 +122,70 @@ func TestRenderUnavailableError(t *testing.T) {
 	assert.Nil(t, result)
 }
 
+type fakeBuiltinRenderer struct {
+	err error
+}
+
+func (r fakeBuiltinRenderer) Render(_ context.Context, opts Opts, w io.Writer) error {
+	if r.err != nil {
+		return r.err
+	}
+	_, err := w.Write([]byte(opts.Encoding))
+	return err
+}
+
+type fakeRenderKeyProvider struct{}
+
+func (fakeRenderKeyProvider) get(_ context.Context, _ AuthOpts) (string, error) { return "key", nil }
+
+func (fakeRenderKeyProvider) afterRequest(_ context.Context, _ AuthOpts, _ string) {}
+
+func TestRenderViaBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	rs := &RenderingService{
+		Cfg:                         &setting.Cfg{ImagesDir: dir, CSVsDir: dir},
+		log:                         log.New("test"),
+		RendererPluginManager:       unavailableRendererManager{},
+		perRequestRenderKeyProvider: fakeRenderKeyProvider{},
+	}
+	require.False(t, rs.IsAvailable(context.Background()))
+
+	rs.RegisterBuiltinRenderer(fakeBuiltinRenderer{})
+	rs.renderAction = rs.renderViaBuiltin
+	require.True(t, rs.IsAvailable(context.Background()))
+
+	t.Run("renders images with the built-in renderer", func(t *testing.T) {
+		result, err := rs.Render(context.Background(), Opts{ConcurrentLimit: 1, Encoding: "svg"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, ".svg", filepath.Ext(result.FilePath))
+		content, err := os.ReadFile(result.FilePath)
+		require.NoError(t, err)
+		require.Equal(t, "svg", string(content))
+
+		result, err = rs.Render(context.Background(), Opts{ConcurrentLimit: 1}, nil)
+		require.NoError(t, err)
+		require.Equal(t, ".png", filepath.Ext(result.FilePath))
+	})
+
+	t.Run("removes the image when rendering fails", func(t *testing.T) {
+		rs.RegisterBuiltinRenderer(fakeBuiltinRenderer{err: errors.New("query failed")})
+		defer rs.RegisterBuiltinRenderer(fakeBuiltinRenderer{})
+
+		before, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		_, err = rs.Render(context.Background(), Opts{ConcurrentLimit: 1}, nil)
+		require.EqualError(t, err, "query failed")
+		after, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, after, len(before))
+	})
+
+	t.Run("CSV rendering still requires the image renderer", func(t *testing.T) {
+		_, err := rs.RenderCSV(context.Background(), CSVOpts{ConcurrentLimit: 1}, nil)
+		require.Equal(t, ErrRenderUnavailable, err)
+	})
+}
+
 func TestRenderLimitImage(t *testing.T) {
 	path, err := filepath.Abs("../../../")
 	require.NoError(t, err)
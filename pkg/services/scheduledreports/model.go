@@ -0,0 +1,221 @@
+package scheduledreports
+
+import (
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrReportNotFound     = errutil.NotFound("scheduledreports.not-found", errutil.WithPublicMessage("Report not found"))
+	ErrInvalidReport      = errutil.BadRequest("scheduledreports.invalid")
+	ErrDashboardNotFound  = errutil.BadRequest("scheduledreports.dashboard-not-found", errutil.WithPublicMessage("Report dashboard not found"))
+	ErrDashboardForbidden = errutil.Forbidden("scheduledreports.dashboard-forbidden", errutil.WithPublicMessage("Access denied to the report dashboard"))
+	ErrReportForbidden    = errutil.Forbidden("scheduledreports.forbidden", errutil.WithPublicMessage("Only the report owner or an org admin can change or send the report"))
+	ErrWebhookNotAllowed  = errutil.BadRequest("scheduledreports.webhook-not-allowed", errutil.WithPublicMessage("Report webhook host is not allowed"))
+)
+
+type Format string
+
+const (
+	FormatPDF Format = "pdf"
+	FormatPNG Format = "png"
+	FormatCSV Format = "csv"
+)
+
+type RunStatus string
+
+const (
+	RunStatusRunning RunStatus = "running"
+	RunStatusSuccess RunStatus = "success"
+	RunStatusFailed  RunStatus = "failed"
+)
+
+type RunTrigger string
+
+const (
+	RunTriggerSchedule RunTrigger = "schedule"
+	RunTriggerManual   RunTrigger = "manual"
+)
+
+const (
+	DefaultTimeFrom = "now-24h"
+	DefaultTimeTo   = "now"
+)
+
+// Report is a stored report definition. Reports are rendered with the permissions
+// of the user that last saved them.
+type Report struct {
+	ID           int64               `xorm:"pk autoincr 'id'" json:"-"`
+	UID          string              `xorm:"uid" json:"uid"`
+	OrgID        int64               `xorm:"org_id" json:"orgId"`
+	Name         string              `xorm:"name" json:"name"`
+	DashboardUID string              `xorm:"dashboard_uid" json:"dashboardUid"`
+	Variables    map[string][]string `xorm:"variables" json:"variables"`
+	TimeFrom     string              `xorm:"time_from" json:"timeFrom"`
+	TimeTo       string              `xorm:"time_to" json:"timeTo"`
+	Schedule     string              `xorm:"schedule" json:"schedule"`
+	Timezone     string              `xorm:"timezone" json:"timezone"`
+	Formats      []Format            `xorm:"formats" json:"formats"`
+	Recipients   []string            `xorm:"recipients" json:"recipients"`
+	WebhookURL   string              `xorm:"webhook_url" json:"webhookUrl"`
+	Enabled      bool                `xorm:"enabled" json:"enabled"`
+	UserID       int64               `xorm:"user_id" json:"userId"`
+	// NextRun is the time of the next scheduled run in epoch milliseconds, 0 when the report is disabled.
+	NextRun int64     `xorm:"next_run" json:"nextRun"`
+	Created time.Time `xorm:"created" json:"created"`
+	Updated time.Time `xorm:"updated" json:"updated"`
+}
+
+func (r Report) TableName() string {
+	return "scheduled_report"
+}
+
+// ReportRun is a single, scheduled or manual, execution of a report.
+type ReportRun struct {
+	ID       int64      `xorm:"pk autoincr 'id'" json:"id"`
+	ReportID int64      `xorm:"report_id" json:"-"`
+	OrgID    int64      `xorm:"org_id" json:"-"`
+	Trigger  RunTrigger `xorm:"trigger_type" json:"trigger"`
+	Status   RunStatus  `xorm:"status" json:"status"`
+	// Error describes why the run failed, or the panels that could not be rendered.
+	Error    string     `xorm:"error" json:"error,omitempty"`
+	Started  time.Time  `xorm:"started" json:"started"`
+	Finished *time.Time `xorm:"finished" json:"finished,omitempty"`
+}
+
+func (r ReportRun) TableName() string {
+	return "scheduled_report_run"
+}
+
+// ReportSettings are the user editable fields of a report.
+type ReportSettings struct {
+	Name         string              `json:"name"`
+	DashboardUID string              `json:"dashboardUid"`
+	Variables    map[string][]string `json:"variables"`
+	TimeFrom     string              `json:"timeFrom"`
+	TimeTo       string              `json:"timeTo"`
+	// Schedule is a standard five field cron expression, or a descriptor such as @daily.
+	Schedule   string   `json:"schedule"`
+	Timezone   string   `json:"timezone"`
+	Formats    []Format `json:"formats"`
+	Recipients []string `json:"recipients"`
+	WebhookURL string   `json:"webhookUrl"`
+	Enabled    bool     `json:"enabled"`
+}
+
+// Validate checks the settings and fills in the default time range.
+func (s *ReportSettings) Validate() error {
+	if s.Name == "" {
+		return ErrInvalidReport.Errorf("report name is required")
+	}
+	if s.DashboardUID == "" {
+		return ErrInvalidReport.Errorf("report dashboard is required")
+	}
+
+	if s.TimeFrom == "" {
+		s.TimeFrom = DefaultTimeFrom
+	}
+	if s.TimeTo == "" {
+		s.TimeTo = DefaultTimeTo
+	}
+	tr := legacydata.NewDataTimeRange(s.TimeFrom, s.TimeTo)
+	if _, err := tr.ParseFrom(); err != nil {
+		return ErrInvalidReport.Errorf("invalid time range from %q: %w", s.TimeFrom, err)
+	}
+	if _, err := tr.ParseTo(); err != nil {
+		return ErrInvalidReport.Errorf("invalid time range to %q: %w", s.TimeTo, err)
+	}
+
+	if _, err := NextRun(s.Schedule, s.Timezone, time.Now()); err != nil {
+		return err
+	}
+
+	if len(s.Formats) == 0 {
+		return ErrInvalidReport.Errorf("at least one report format is required")
+	}
+	for _, f := range s.Formats {
+		if f != FormatPDF && f != FormatPNG && f != FormatCSV {
+			return ErrInvalidReport.Errorf("unsupported report format %q", f)
+		}
+	}
+
+	if len(s.Recipients) == 0 && s.WebhookURL == "" {
+		return ErrInvalidReport.Errorf("report needs recipients or a webhook URL")
+	}
+	for _, r := range s.Recipients {
+		if _, err := mail.ParseAddress(r); err != nil {
+			return ErrInvalidReport.Errorf("invalid recipient %q", r)
+		}
+	}
+	if s.WebhookURL != "" {
+		u, err := url.Parse(s.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidReport.Errorf("invalid webhook URL %q", s.WebhookURL)
+		}
+	}
+	return nil
+}
+
+// NextRun returns the first time after `after` that the cron schedule fires in the given time zone.
+func NextRun(schedule, timezone string, after time.Time) (time.Time, error) {
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return time.Time{}, ErrInvalidReport.Errorf("invalid time zone %q", timezone)
+		}
+	}
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return time.Time{}, ErrInvalidReport.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	return sched.Next(after.In(loc)), nil
+}
+
+type CreateReportCommand struct {
+	ReportSettings
+	OrgID  int64 `json:"-"`
+	UserID int64 `json:"-"`
+}
+
+type UpdateReportCommand struct {
+	ReportSettings
+	UID    string `json:"-"`
+	OrgID  int64  `json:"-"`
+	UserID int64  `json:"-"`
+}
+
+type GetReportQuery struct {
+	UID   string
+	OrgID int64
+}
+
+type ListReportsQuery struct {
+	OrgID int64
+	// DashboardUID limits the reports to a single dashboard when set.
+	DashboardUID string
+}
+
+type DeleteReportCommand struct {
+	UID   string
+	OrgID int64
+}
+
+type SendReportCommand struct {
+	UID   string
+	OrgID int64
+}
+
+type ListReportRunsQuery struct {
+	UID   string
+	OrgID int64
+	// Status limits the runs to the given status when set.
+	Status RunStatus
+	Limit  int
+}
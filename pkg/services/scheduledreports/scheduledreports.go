@@ -0,0 +1,18 @@
+package scheduledreports
+
+import (
+	"context"
+)
+
+// Service stores report definitions, renders the report dashboards on their cron
+// schedule and delivers them by email or webhook.
+type Service interface {
+	Create(ctx context.Context, cmd *CreateReportCommand) (*Report, error)
+	Update(ctx context.Context, cmd *UpdateReportCommand) (*Report, error)
+	Get(ctx context.Context, query *GetReportQuery) (*Report, error)
+	List(ctx context.Context, query *ListReportsQuery) ([]*Report, error)
+	Delete(ctx context.Context, cmd *DeleteReportCommand) error
+	// Send renders and delivers the report immediately and returns the finished run.
+	Send(ctx context.Context, cmd *SendReportCommand) (*ReportRun, error)
+	ListRuns(ctx context.Context, query *ListReportRunsQuery) ([]*ReportRun, error)
+}
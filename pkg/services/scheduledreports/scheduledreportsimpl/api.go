@@ -0,0 +1,212 @@
+package scheduledreportsimpl
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/scheduledreports"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/scheduled-reports", func(reports routing.RouteRegister) {
+		reports.Get("/", routing.Wrap(s.listHandler))
+		reports.Post("/", routing.Wrap(s.createHandler))
+		reports.Get("/:uid", routing.Wrap(s.getHandler))
+		reports.Put("/:uid", routing.Wrap(s.updateHandler))
+		reports.Delete("/:uid", routing.Wrap(s.deleteHandler))
+		reports.Post("/:uid/send", routing.Wrap(s.sendHandler))
+		reports.Get("/:uid/runs", routing.Wrap(s.listRunsHandler))
+	}, middleware.ReqEditorRole)
+}
+
+// GET /api/scheduled-reports
+func (s *Service) listHandler(c *contextmodel.ReqContext) response.Response {
+	reports, err := s.List(c.Req.Context(), &scheduledreports.ListReportsQuery{
+		OrgID:        c.SignedInUser.OrgID,
+		DashboardUID: c.Query("dashboardUid"),
+	})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get reports", err)
+	}
+
+	result := make([]*scheduledreports.Report, 0, len(reports))
+	for _, report := range reports {
+		canRead, err := s.canReadDashboard(c, report.DashboardUID)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to check dashboard permissions", err)
+		}
+		if canRead {
+			result = append(result, report)
+		}
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// POST /api/scheduled-reports
+func (s *Service) createHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := scheduledreports.CreateReportCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if rsp := s.requireDashboardRead(c, cmd.DashboardUID); rsp != nil {
+		return rsp
+	}
+
+	userID, err := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Reports can only be created by users", err)
+	}
+	cmd.OrgID = c.SignedInUser.OrgID
+	cmd.UserID = userID
+
+	report, err := s.Create(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to create report", err)
+	}
+	return response.JSON(http.StatusOK, report)
+}
+
+// GET /api/scheduled-reports/:uid
+func (s *Service) getHandler(c *contextmodel.ReqContext) response.Response {
+	report, rsp := s.getReport(c)
+	if rsp != nil {
+		return rsp
+	}
+	return response.JSON(http.StatusOK, report)
+}
+
+// PUT /api/scheduled-reports/:uid
+func (s *Service) updateHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := scheduledreports.UpdateReportCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if _, rsp := s.getOwnReport(c); rsp != nil {
+		return rsp
+	}
+	if rsp := s.requireDashboardRead(c, cmd.DashboardUID); rsp != nil {
+		return rsp
+	}
+
+	userID, err := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Reports can only be saved by users", err)
+	}
+	cmd.UID = web.Params(c.Req)[":uid"]
+	cmd.OrgID = c.SignedInUser.OrgID
+	cmd.UserID = userID
+
+	report, err := s.Update(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update report", err)
+	}
+	return response.JSON(http.StatusOK, report)
+}
+
+// DELETE /api/scheduled-reports/:uid
+func (s *Service) deleteHandler(c *contextmodel.ReqContext) response.Response {
+	report, rsp := s.getOwnReport(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	err := s.Delete(c.Req.Context(), &scheduledreports.DeleteReportCommand{UID: report.UID, OrgID: report.OrgID})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete report", err)
+	}
+	return response.Success("Report deleted")
+}
+
+// POST /api/scheduled-reports/:uid/send
+func (s *Service) sendHandler(c *contextmodel.ReqContext) response.Response {
+	report, rsp := s.getOwnReport(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	run, err := s.Send(c.Req.Context(), &scheduledreports.SendReportCommand{UID: report.UID, OrgID: report.OrgID})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to send report", err)
+	}
+	return response.JSON(http.StatusOK, run)
+}
+
+// GET /api/scheduled-reports/:uid/runs
+func (s *Service) listRunsHandler(c *contextmodel.ReqContext) response.Response {
+	report, rsp := s.getReport(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	runs, err := s.ListRuns(c.Req.Context(), &scheduledreports.ListReportRunsQuery{
+		UID:    report.UID,
+		OrgID:  report.OrgID,
+		Status: scheduledreports.RunStatus(c.Query("status")),
+		Limit:  c.QueryInt("limit"),
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get report runs", err)
+	}
+	return response.JSON(http.StatusOK, runs)
+}
+
+// getReport returns the report in the URL, if the user can read its dashboard.
+func (s *Service) getReport(c *contextmodel.ReqContext) (*scheduledreports.Report, response.Response) {
+	report, err := s.Get(c.Req.Context(), &scheduledreports.GetReportQuery{
+		UID:   web.Params(c.Req)[":uid"],
+		OrgID: c.SignedInUser.OrgID,
+	})
+	if err != nil {
+		return nil, response.ErrOrFallback(http.StatusInternalServerError, "Failed to get report", err)
+	}
+	if rsp := s.requireDashboardRead(c, report.DashboardUID); rsp != nil {
+		return nil, rsp
+	}
+	return report, nil
+}
+
+// getOwnReport returns the report in the URL, if the user can read its dashboard and owns
+// the report or is an org admin. Reports are rendered as their owner, so other users can
+// only read them.
+func (s *Service) getOwnReport(c *contextmodel.ReqContext) (*scheduledreports.Report, response.Response) {
+	report, rsp := s.getReport(c)
+	if rsp != nil {
+		return nil, rsp
+	}
+	if c.SignedInUser.HasRole(org.RoleAdmin) {
+		return report, nil
+	}
+	userID, err := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
+	if err != nil || userID != report.UserID {
+		return nil, response.Err(scheduledreports.ErrReportForbidden.Errorf("user is not the owner of report %s", report.UID))
+	}
+	return report, nil
+}
+
+func (s *Service) requireDashboardRead(c *contextmodel.ReqContext, dashboardUID string) response.Response {
+	if dashboardUID == "" {
+		// left to the report validation
+		return nil
+	}
+	canRead, err := s.canReadDashboard(c, dashboardUID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to check dashboard permissions", err)
+	}
+	if !canRead {
+		return response.Err(scheduledreports.ErrDashboardForbidden.Errorf("user cannot read dashboard %s", dashboardUID))
+	}
+	return nil
+}
+
+func (s *Service) canReadDashboard(c *contextmodel.ReqContext, dashboardUID string) (bool, error) {
+	evaluator := ac.EvalPermission(dashboards.ActionDashboardsRead, dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dashboardUID))
+	return s.accessControl.Evaluate(c.Req.Context(), c.SignedInUser, evaluator)
+}
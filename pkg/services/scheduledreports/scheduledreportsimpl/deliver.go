@@ -0,0 +1,113 @@
+package scheduledreportsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/scheduledreports"
+)
+
+const emailTemplate = "scheduled_report"
+
+type webhookFile struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	// Content is base64 encoded by encoding/json.
+	Content []byte `json:"content"`
+}
+
+type webhookPayload struct {
+	Report struct {
+		UID          string `json:"uid"`
+		Name         string `json:"name"`
+		DashboardUID string `json:"dashboardUid"`
+	} `json:"report"`
+	DashboardTitle string        `json:"dashboardTitle"`
+	DashboardURL   string        `json:"dashboardUrl"`
+	TimeRange      string        `json:"timeRange"`
+	Generated      time.Time     `json:"generated"`
+	PanelErrors    []string      `json:"panelErrors,omitempty"`
+	Files          []webhookFile `json:"files"`
+}
+
+// deliver sends the report to its recipients and webhook. Both are attempted even when
+// one of them fails.
+func (s *Service) deliver(ctx context.Context, report *scheduledreports.Report, content *reportContent) error {
+	var errs []error
+	if len(report.Recipients) > 0 {
+		if err := s.sendEmail(ctx, report, content); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send report email: %w", err))
+		}
+	}
+	if report.WebhookURL != "" {
+		if err := s.sendWebhook(ctx, report, content); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send report webhook: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) sendEmail(ctx context.Context, report *scheduledreports.Report, content *reportContent) error {
+	files := make([]*notifications.SendEmailAttachFile, 0, len(content.files))
+	for _, f := range content.files {
+		files = append(files, &notifications.SendEmailAttachFile{Name: f.name, Content: f.content})
+	}
+
+	return s.notificationService.SendEmailCommandHandlerSync(ctx, &notifications.SendEmailCommandSync{
+		SendEmailCommand: notifications.SendEmailCommand{
+			To:       report.Recipients,
+			Template: emailTemplate,
+			Data: map[string]any{
+				"ReportName":     report.Name,
+				"DashboardTitle": content.dashboard.Title,
+				"DashboardUrl":   dashboardURL(report, content),
+				"TimeRange":      content.timeRange,
+				"PanelErrors":    content.panelErrors,
+			},
+			AttachedFiles: files,
+		},
+	})
+}
+
+func (s *Service) sendWebhook(ctx context.Context, report *scheduledreports.Report, content *reportContent) error {
+	// the allowed hosts may have changed since the report was saved
+	if err := s.checkWebhook(report.WebhookURL); err != nil {
+		return err
+	}
+
+	payload := webhookPayload{
+		DashboardTitle: content.dashboard.Title,
+		DashboardURL:   dashboardURL(report, content),
+		TimeRange:      content.timeRange,
+		Generated:      s.now(),
+		PanelErrors:    content.panelErrors,
+		Files:          make([]webhookFile, 0, len(content.files)),
+	}
+	payload.Report.UID = report.UID
+	payload.Report.Name = report.Name
+	payload.Report.DashboardUID = report.DashboardUID
+	for _, f := range content.files {
+		payload.Files = append(payload.Files, webhookFile{Name: f.name, ContentType: f.contentType, Content: f.content})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return s.notificationService.SendWebhookSync(ctx, &notifications.SendWebhookSync{
+		Url:         report.WebhookURL,
+		Body:        string(body),
+		HttpMethod:  http.MethodPost,
+		ContentType: "application/json",
+	})
+}
+
+func dashboardURL(report *scheduledreports.Report, content *reportContent) string {
+	return fmt.Sprintf("%s?orgId=%d", dashboards.GetFullDashboardURL(content.dashboard.UID, content.dashboard.Slug), report.OrgID)
+}
@@ -0,0 +1,71 @@
+package scheduledreportsimpl
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/grafana/gofpdf"
+)
+
+const (
+	pdfMargin      = 10.0
+	pdfTitleHeight = 10.0
+	pdfTextHeight  = 6.0
+	pdfPanelGap    = 5.0
+)
+
+type panelImage struct {
+	panelID int64
+	title   string
+	png     []byte
+}
+
+// buildPDF lays out the rendered panels on landscape A4 pages, below a title and the
+// report time range. Panels are scaled to the page width and never split across pages.
+func buildPDF(title, subtitle string, images []panelImage) ([]byte, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	contentWidth := pageWidth - 2*pdfMargin
+	contentHeight := pageHeight - 2*pdfMargin
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(contentWidth, pdfTitleHeight, tr(title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(contentWidth, pdfTextHeight, tr(subtitle), "", 1, "L", false, 0, "")
+	pdf.Ln(pdfPanelGap)
+
+	for i, img := range images {
+		name := fmt.Sprintf("panel-%d-%d", i, img.panelID)
+		info := pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(img.png))
+		if pdf.Err() {
+			return nil, fmt.Errorf("failed to add panel %d to the PDF: %w", img.panelID, pdf.Error())
+		}
+
+		width := contentWidth
+		height := width * info.Height() / info.Width()
+		if maxHeight := contentHeight - pdfTextHeight; height > maxHeight {
+			width = width * maxHeight / height
+			height = maxHeight
+		}
+		if pdf.GetY()+pdfTextHeight+height > pageHeight-pdfMargin {
+			pdf.AddPage()
+		}
+
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(contentWidth, pdfTextHeight, tr(img.title), "", 1, "L", false, 0, "")
+		pdf.ImageOptions(name, pdfMargin, pdf.GetY(), width, height, true, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+		pdf.Ln(pdfPanelGap)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,339 @@
+package scheduledreportsimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/slugify"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/scheduledreports"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+)
+
+const (
+	// Dashboard grid units, in pixels, used to render panels at their dashboard size.
+	gridColumnWidth = 50
+	gridRowHeight   = 30
+	minPanelWidth   = 400
+	minPanelHeight  = 200
+
+	reportTimeFormat = "2006-01-02 15:04 MST"
+)
+
+type attachment struct {
+	name        string
+	contentType string
+	content     []byte
+}
+
+type panel struct {
+	id     int64
+	title  string
+	width  int
+	height int
+}
+
+// reportContent is the rendered report, ready to be delivered.
+type reportContent struct {
+	dashboard   *dashboards.Dashboard
+	timeRange   string
+	files       []attachment
+	panelErrors []string
+}
+
+// execute renders and delivers a report, and records the run in the report history.
+func (s *Service) execute(ctx context.Context, report *scheduledreports.Report, trigger scheduledreports.RunTrigger) (*scheduledreports.ReportRun, error) {
+	run := &scheduledreports.ReportRun{
+		ReportID: report.ID,
+		OrgID:    report.OrgID,
+		Trigger:  trigger,
+		Status:   scheduledreports.RunStatusRunning,
+		Started:  s.now(),
+	}
+	if err := s.store.InsertRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, s.runTimeout)
+	content, err := s.render(runCtx, report)
+	if err == nil {
+		err = s.deliver(runCtx, report, content)
+	}
+	cancel()
+
+	finished := s.now()
+	run.Finished = &finished
+	run.Status = scheduledreports.RunStatusSuccess
+	if err != nil {
+		run.Status = scheduledreports.RunStatusFailed
+		run.Error = err.Error()
+		s.log.Warn("Report run failed", "orgId", report.OrgID, "uid", report.UID, "trigger", trigger, "error", err)
+	} else if len(content.panelErrors) > 0 {
+		run.Error = strings.Join(content.panelErrors, "; ")
+	}
+
+	if err := s.store.UpdateRun(ctx, run); err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteOldRuns(ctx, report.ID, s.runsToKeep); err != nil {
+		s.log.Warn("Failed to delete old report runs", "orgId", report.OrgID, "uid", report.UID, "error", err)
+	}
+	return run, nil
+}
+
+// render renders every panel of the report dashboard as the report owner, and builds
+// the files for the report formats. Panels that fail to render are left out of the
+// report and listed in panelErrors, the report only fails when no panel could be rendered.
+func (s *Service) render(ctx context.Context, report *scheduledreports.Report) (*reportContent, error) {
+	dash, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{OrgID: report.OrgID, UID: report.DashboardUID})
+	if err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return nil, fmt.Errorf("dashboard %s not found", report.DashboardUID)
+		}
+		return nil, fmt.Errorf("failed to get dashboard: %w", err)
+	}
+
+	owner, err := s.userService.GetSignedInUser(ctx, &user.GetSignedInUserQuery{UserID: report.UserID, OrgID: report.OrgID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report owner: %w", err)
+	}
+	authOpts := rendering.AuthOpts{OrgID: report.OrgID, UserID: owner.UserID, OrgRole: owner.OrgRole}
+
+	loc := time.UTC
+	if report.Timezone != "" {
+		if loc, err = time.LoadLocation(report.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", report.Timezone, err)
+		}
+	}
+	tr := legacydata.NewDataTimeRange(report.TimeFrom, report.TimeTo)
+	tr.Now = s.now()
+	from, err := tr.ParseFrom(legacydata.WithLocation(loc))
+	if err != nil {
+		return nil, fmt.Errorf("invalid time range: %w", err)
+	}
+	to, err := tr.ParseTo(legacydata.WithLocation(loc))
+	if err != nil {
+		return nil, fmt.Errorf("invalid time range: %w", err)
+	}
+
+	session, err := s.renderService.CreateRenderingSession(ctx, authOpts, rendering.SessionOpts{
+		Expiry:                     s.runTimeout,
+		RefreshExpiryOnEachRequest: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rendering session: %w", err)
+	}
+	if session != nil {
+		defer session.Dispose(ctx)
+	}
+
+	content := &reportContent{
+		dashboard: dash,
+		timeRange: fmt.Sprintf("%s to %s", from.In(loc).Format(reportTimeFormat), to.In(loc).Format(reportTimeFormat)),
+	}
+	wantImages := hasFormat(report, scheduledreports.FormatPDF) || hasFormat(report, scheduledreports.FormatPNG)
+	wantCSV := hasFormat(report, scheduledreports.FormatCSV)
+
+	images := make([]panelImage, 0)
+	rendered := 0
+	for _, p := range dashboardPanels(dash.Data) {
+		panelPath := s.panelPath(dash, report, p, from, to)
+		failed := false
+
+		if wantImages {
+			img, err := s.renderPanelImage(ctx, authOpts, report, p, panelPath, session)
+			if err != nil {
+				content.panelErrors = append(content.panelErrors, fmt.Sprintf("%s: %s", panelName(p), err))
+				failed = true
+			} else {
+				images = append(images, *img)
+			}
+		}
+		if wantCSV && !failed {
+			csv, err := s.renderPanelCSV(ctx, authOpts, report, p, panelPath, session)
+			if err != nil {
+				content.panelErrors = append(content.panelErrors, fmt.Sprintf("%s: %s", panelName(p), err))
+				failed = true
+			} else {
+				content.files = append(content.files, *csv)
+			}
+		}
+		if !failed {
+			rendered++
+		}
+	}
+	if rendered == 0 {
+		if len(content.panelErrors) > 0 {
+			return nil, fmt.Errorf("no panel could be rendered: %s", strings.Join(content.panelErrors, "; "))
+		}
+		return nil, fmt.Errorf("dashboard %s has no panels", dash.UID)
+	}
+
+	if hasFormat(report, scheduledreports.FormatPNG) {
+		for _, img := range images {
+			content.files = append(content.files, attachment{
+				name:        fmt.Sprintf("%s-%d.png", fileSlug(img.title, "panel"), img.panelID),
+				contentType: "image/png",
+				content:     img.png,
+			})
+		}
+	}
+	if hasFormat(report, scheduledreports.FormatPDF) {
+		pdf, err := buildPDF(dash.Title, content.timeRange, images)
+		if err != nil {
+			return nil, err
+		}
+		content.files = append([]attachment{{
+			name:        fileSlug(report.Name, "report") + ".pdf",
+			contentType: "application/pdf",
+			content:     pdf,
+		}}, content.files...)
+	}
+	return content, nil
+}
+
+func (s *Service) renderPanelImage(ctx context.Context, authOpts rendering.AuthOpts, report *scheduledreports.Report, p panel, panelPath string, session rendering.Session) (*panelImage, error) {
+	result, err := s.renderService.Render(ctx, rendering.Opts{
+		TimeoutOpts: rendering.TimeoutOpts{Timeout: s.renderTimeout},
+		AuthOpts:    authOpts,
+		ErrorOpts: rendering.ErrorOpts{
+			ErrorConcurrentLimitReached: true,
+			ErrorRenderUnavailable:      true,
+		},
+		Width:             p.width,
+		Height:            p.height,
+		Path:              panelPath,
+		Timezone:          report.Timezone,
+		ConcurrentLimit:   s.cfg.RendererConcurrentRequestLimit,
+		DeviceScaleFactor: 1,
+		Theme:             models.ThemeLight,
+	}, session)
+	if err != nil {
+		return nil, err
+	}
+	png, err := readRenderedFile(result.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return &panelImage{panelID: p.id, title: panelName(p), png: png}, nil
+}
+
+func (s *Service) renderPanelCSV(ctx context.Context, authOpts rendering.AuthOpts, report *scheduledreports.Report, p panel, panelPath string, session rendering.Session) (*attachment, error) {
+	result, err := s.renderService.RenderCSV(ctx, rendering.CSVOpts{
+		TimeoutOpts:     rendering.TimeoutOpts{Timeout: s.renderTimeout},
+		AuthOpts:        authOpts,
+		Path:            panelPath,
+		Timezone:        report.Timezone,
+		ConcurrentLimit: s.cfg.RendererConcurrentRequestLimit,
+	}, session)
+	if err != nil {
+		return nil, err
+	}
+	csv, err := readRenderedFile(result.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment{
+		name:        fmt.Sprintf("%s-%d.csv", fileSlug(p.title, "panel"), p.id),
+		contentType: "text/csv",
+		content:     csv,
+	}, nil
+}
+
+// panelPath returns the solo panel URL for a panel, with the report time range resolved
+// so that all panels show the same range, and the report variables.
+func (s *Service) panelPath(dash *dashboards.Dashboard, report *scheduledreports.Report, p panel, from, to time.Time) string {
+	params := url.Values{}
+	params.Set("orgId", strconv.FormatInt(report.OrgID, 10))
+	params.Set("panelId", strconv.FormatInt(p.id, 10))
+	params.Set("from", strconv.FormatInt(from.UnixMilli(), 10))
+	params.Set("to", strconv.FormatInt(to.UnixMilli(), 10))
+	if report.Timezone != "" {
+		params.Set("tz", report.Timezone)
+	}
+	for name, values := range report.Variables {
+		for _, v := range values {
+			params.Add("var-"+name, v)
+		}
+	}
+	return path.Join("d-solo", dash.UID, dash.Slug) + "?" + params.Encode()
+}
+
+// dashboardPanels returns the panels of a dashboard, including the panels of collapsed rows.
+func dashboardPanels(data *simplejson.Json) []panel {
+	panels := make([]panel, 0)
+	for _, raw := range data.Get("panels").MustArray() {
+		p := simplejson.NewFromAny(raw)
+		if p.Get("type").MustString() == "row" {
+			for _, nested := range p.Get("panels").MustArray() {
+				if np, ok := toPanel(simplejson.NewFromAny(nested)); ok {
+					panels = append(panels, np)
+				}
+			}
+			continue
+		}
+		if np, ok := toPanel(p); ok {
+			panels = append(panels, np)
+		}
+	}
+	return panels
+}
+
+func toPanel(p *simplejson.Json) (panel, bool) {
+	id, err := p.Get("id").Int64()
+	if err != nil || p.Get("type").MustString() == "row" {
+		return panel{}, false
+	}
+	gridPos := p.Get("gridPos")
+	return panel{
+		id:     id,
+		title:  p.Get("title").MustString(),
+		width:  max(gridPos.Get("w").MustInt()*gridColumnWidth, minPanelWidth),
+		height: max(gridPos.Get("h").MustInt()*gridRowHeight, minPanelHeight),
+	}, true
+}
+
+func panelName(p panel) string {
+	if p.title != "" {
+		return p.title
+	}
+	return fmt.Sprintf("Panel %d", p.id)
+}
+
+func hasFormat(report *scheduledreports.Report, format scheduledreports.Format) bool {
+	for _, f := range report.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func fileSlug(name, fallback string) string {
+	if slug := slugify.Slugify(name); slug != "" {
+		return slug
+	}
+	return fallback
+}
+
+func readRenderedFile(filePath string) ([]byte, error) {
+	// We can ignore the gosec G304 warning on this one because `filePath` is the
+	// file created by the rendering service.
+	// nolint:gosec
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rendered file: %w", err)
+	}
+	return content, nil
+}
@@ -0,0 +1,270 @@
+package scheduledreportsimpl
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/scheduledreports"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	defaultRunsLimit = 50
+	maxRunsLimit     = 1000
+)
+
+// Service stores the report definitions and runs the reports that are due. Reports are
+// claimed in the database before they run, so each scheduled run happens once even when
+// several Grafana instances share the database.
+type Service struct {
+	store               store
+	cfg                 *setting.Cfg
+	log                 log.Logger
+	accessControl       accesscontrol.AccessControl
+	dashboardService    dashboards.DashboardService
+	userService         user.Service
+	renderService       rendering.Service
+	notificationService notifications.Service
+
+	enabled           bool
+	schedulerInterval time.Duration
+	runTimeout        time.Duration
+	renderTimeout     time.Duration
+	runsToKeep        int
+	// webhookAllowedHosts are the hosts, or host:port pairs, reports can post to.
+	webhookAllowedHosts map[string]bool
+	now                 func() time.Time
+}
+
+var _ scheduledreports.Service = (*Service)(nil)
+
+func ProvideService(cfg *setting.Cfg, db db.DB, routeRegister routing.RouteRegister, accessControl accesscontrol.AccessControl,
+	dashboardService dashboards.DashboardService, userService user.Service, renderService rendering.Service,
+	notificationService notifications.Service) *Service {
+	section := cfg.SectionWithEnvOverrides("scheduled_reports")
+	s := &Service{
+		store:               &sqlStore{db: db},
+		cfg:                 cfg,
+		log:                 log.New("scheduled-reports"),
+		accessControl:       accessControl,
+		dashboardService:    dashboardService,
+		userService:         userService,
+		renderService:       renderService,
+		notificationService: notificationService,
+		enabled:             section.Key("enabled").MustBool(true),
+		schedulerInterval:   section.Key("scheduler_interval").MustDuration(time.Minute),
+		runTimeout:          section.Key("run_timeout").MustDuration(5 * time.Minute),
+		renderTimeout:       section.Key("render_timeout").MustDuration(time.Minute),
+		runsToKeep:          section.Key("runs_to_keep").MustInt(100),
+		webhookAllowedHosts: map[string]bool{},
+		now:                 time.Now,
+	}
+	for _, host := range util.SplitString(section.Key("webhook_allowed_hosts").String()) {
+		s.webhookAllowedHosts[host] = true
+	}
+
+	if s.enabled {
+		s.registerAPIEndpoints(routeRegister)
+	}
+
+	return s
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.enabled
+}
+
+// Run starts the reports that are due every scheduler interval. A report that was due
+// several times while Grafana was stopped runs once.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runDue(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) runDue(ctx context.Context) {
+	now := s.now()
+	reports, err := s.store.ListDue(ctx, now)
+	if err != nil {
+		s.log.Error("Failed to get due reports", "error", err)
+		return
+	}
+
+	for _, report := range reports {
+		next, err := scheduledreports.NextRun(report.Schedule, report.Timezone, now)
+		if err != nil {
+			s.log.Error("Failed to schedule report", "orgId", report.OrgID, "uid", report.UID, "error", err)
+			continue
+		}
+		claimed, err := s.store.ClaimNextRun(ctx, report.ID, report.NextRun, next.UnixMilli())
+		if err != nil {
+			s.log.Error("Failed to claim report run", "orgId", report.OrgID, "uid", report.UID, "error", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if _, err := s.execute(ctx, report, scheduledreports.RunTriggerSchedule); err != nil {
+			s.log.Error("Failed to record report run", "orgId", report.OrgID, "uid", report.UID, "error", err)
+		}
+	}
+}
+
+func (s *Service) Create(ctx context.Context, cmd *scheduledreports.CreateReportCommand) (*scheduledreports.Report, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkWebhook(cmd.WebhookURL); err != nil {
+		return nil, err
+	}
+	if err := s.checkDashboard(ctx, cmd.OrgID, cmd.DashboardUID); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	report := &scheduledreports.Report{
+		UID:     util.GenerateShortUID(),
+		OrgID:   cmd.OrgID,
+		UserID:  cmd.UserID,
+		Created: now,
+	}
+	if err := s.apply(report, &cmd.ReportSettings, now); err != nil {
+		return nil, err
+	}
+	if err := s.store.Insert(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *Service) Update(ctx context.Context, cmd *scheduledreports.UpdateReportCommand) (*scheduledreports.Report, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkWebhook(cmd.WebhookURL); err != nil {
+		return nil, err
+	}
+	report, err := s.store.Get(ctx, &scheduledreports.GetReportQuery{UID: cmd.UID, OrgID: cmd.OrgID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDashboard(ctx, cmd.OrgID, cmd.DashboardUID); err != nil {
+		return nil, err
+	}
+
+	// The report is rendered as the user that saved it last.
+	report.UserID = cmd.UserID
+	if err := s.apply(report, &cmd.ReportSettings, s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.store.Update(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *Service) Get(ctx context.Context, query *scheduledreports.GetReportQuery) (*scheduledreports.Report, error) {
+	return s.store.Get(ctx, query)
+}
+
+func (s *Service) List(ctx context.Context, query *scheduledreports.ListReportsQuery) ([]*scheduledreports.Report, error) {
+	return s.store.List(ctx, query)
+}
+
+func (s *Service) Delete(ctx context.Context, cmd *scheduledreports.DeleteReportCommand) error {
+	return s.store.Delete(ctx, cmd)
+}
+
+func (s *Service) Send(ctx context.Context, cmd *scheduledreports.SendReportCommand) (*scheduledreports.ReportRun, error) {
+	report, err := s.store.Get(ctx, &scheduledreports.GetReportQuery{UID: cmd.UID, OrgID: cmd.OrgID})
+	if err != nil {
+		return nil, err
+	}
+	return s.execute(ctx, report, scheduledreports.RunTriggerManual)
+}
+
+func (s *Service) ListRuns(ctx context.Context, query *scheduledreports.ListReportRunsQuery) ([]*scheduledreports.ReportRun, error) {
+	report, err := s.store.Get(ctx, &scheduledreports.GetReportQuery{UID: query.UID, OrgID: query.OrgID})
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultRunsLimit
+	}
+	if limit > maxRunsLimit {
+		limit = maxRunsLimit
+	}
+	return s.store.ListRuns(ctx, report.ID, query.Status, limit)
+}
+
+// apply copies the settings to the report and schedules its next run.
+func (s *Service) apply(report *scheduledreports.Report, settings *scheduledreports.ReportSettings, now time.Time) error {
+	report.Name = settings.Name
+	report.DashboardUID = settings.DashboardUID
+	report.Variables = settings.Variables
+	report.TimeFrom = settings.TimeFrom
+	report.TimeTo = settings.TimeTo
+	report.Schedule = settings.Schedule
+	report.Timezone = settings.Timezone
+	report.Formats = settings.Formats
+	report.Recipients = settings.Recipients
+	report.WebhookURL = settings.WebhookURL
+	report.Enabled = settings.Enabled
+	report.Updated = now
+
+	report.NextRun = 0
+	if report.Enabled {
+		next, err := scheduledreports.NextRun(report.Schedule, report.Timezone, now)
+		if err != nil {
+			return err
+		}
+		report.NextRun = next.UnixMilli()
+	}
+	return nil
+}
+
+// checkWebhook rejects webhook URLs whose host is not in webhook_allowed_hosts, so that
+// reports can't be used to send requests to internal addresses.
+func (s *Service) checkWebhook(webhookURL string) error {
+	if webhookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return scheduledreports.ErrInvalidReport.Errorf("invalid webhook URL %q", webhookURL)
+	}
+	if !s.webhookAllowedHosts[u.Host] && !s.webhookAllowedHosts[u.Hostname()] {
+		return scheduledreports.ErrWebhookNotAllowed.Errorf("webhook host %q is not in webhook_allowed_hosts", u.Host)
+	}
+	return nil
+}
+
+func (s *Service) checkDashboard(ctx context.Context, orgID int64, uid string) error {
+	_, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{OrgID: orgID, UID: uid})
+	if errors.Is(err, dashboards.ErrDashboardNotFound) {
+		return scheduledreports.ErrDashboardNotFound.Errorf("dashboard %s not found", uid)
+	}
+	return err
+}
@@ -0,0 +1,342 @@
+package scheduledreportsimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/scheduledreports"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+// fakeRenderer renders blank panels to files, like the image renderer does.
+type fakeRenderer struct {
+	rendering.Service
+	dir        string
+	failPanels map[string]bool
+
+	mu    sync.Mutex
+	paths []string
+	auth  []rendering.AuthOpts
+}
+
+func (r *fakeRenderer) CreateRenderingSession(_ context.Context, _ rendering.AuthOpts, _ rendering.SessionOpts) (rendering.Session, error) {
+	return nil, nil
+}
+
+func (r *fakeRenderer) Render(_ context.Context, opts rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+	panelID, err := r.record(opts.Path, opts.AuthOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))); err != nil {
+		return nil, err
+	}
+	filePath := filepath.Join(r.dir, "panel-"+panelID+".png")
+	return &rendering.RenderResult{FilePath: filePath}, os.WriteFile(filePath, buf.Bytes(), 0600)
+}
+
+func (r *fakeRenderer) RenderCSV(_ context.Context, opts rendering.CSVOpts, _ rendering.Session) (*rendering.RenderCSVResult, error) {
+	panelID, err := r.record(opts.Path, opts.AuthOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath := filepath.Join(r.dir, "panel-"+panelID+".csv")
+	return &rendering.RenderCSVResult{FilePath: filePath}, os.WriteFile(filePath, []byte("time,value\n1,2\n"), 0600)
+}
+
+func (r *fakeRenderer) record(path string, auth rendering.AuthOpts) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths = append(r.paths, path)
+	r.auth = append(r.auth, auth)
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	panelID := u.Query().Get("panelId")
+	if r.failPanels[panelID] {
+		return "", errors.New("rendering timed out")
+	}
+	return panelID, nil
+}
+
+func TestIntegrationScheduledReports(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	testDB := db.InitTestDB(t)
+
+	dashJSON, err := simplejson.NewJson([]byte(`{
+		"title": "Production",
+		"panels": [
+			{"id": 1, "title": "CPU", "type": "timeseries", "gridPos": {"w": 12, "h": 8}},
+			{"id": 2, "title": "Memory", "type": "timeseries", "gridPos": {"w": 12, "h": 8}},
+			{"id": 10, "title": "Storage", "type": "row", "collapsed": true, "panels": [
+				{"id": 3, "title": "Disk", "type": "stat", "gridPos": {"w": 6, "h": 4}}
+			]}
+		]
+	}`))
+	require.NoError(t, err)
+	dash := &dashboards.Dashboard{UID: "prod", Slug: "production", Title: "Production", OrgID: 1, Data: dashJSON}
+
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).
+		Return(func(_ context.Context, q *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+			if q.UID != dash.UID {
+				return nil, dashboards.ErrDashboardNotFound
+			}
+			return dash, nil
+		})
+
+	renderer := &fakeRenderer{dir: t.TempDir(), failPanels: map[string]bool{}}
+	notificationService := notifications.MockNotificationService()
+	now := time.Date(2023, 10, 2, 8, 0, 0, 0, time.UTC)
+	cfg := setting.NewCfg()
+	s := &Service{
+		store:               &sqlStore{db: testDB},
+		cfg:                 cfg,
+		log:                 log.NewNopLogger(),
+		accessControl:       acimpl.ProvideAccessControl(cfg),
+		dashboardService:    dashboardService,
+		userService:         &usertest.FakeUserService{ExpectedSignedInUser: &user.SignedInUser{UserID: 7, OrgID: 1, OrgRole: org.RoleEditor}},
+		renderService:       renderer,
+		notificationService: notificationService,
+		enabled:             true,
+		runTimeout:          time.Minute,
+		renderTimeout:       time.Minute,
+		runsToKeep:          3,
+		webhookAllowedHosts: map[string]bool{"example.com": true},
+		now:                 func() time.Time { return now },
+	}
+
+	settings := scheduledreports.ReportSettings{
+		Name:         "Daily production",
+		DashboardUID: "prod",
+		Variables:    map[string][]string{"env": {"prod", "staging"}},
+		Schedule:     "0 9 * * *",
+		Timezone:     "Europe/Berlin",
+		Formats:      []scheduledreports.Format{scheduledreports.FormatPDF, scheduledreports.FormatCSV},
+		Recipients:   []string{"ops@example.com"},
+		WebhookURL:   "https://example.com/reports",
+		Enabled:      true,
+	}
+
+	t.Run("invalid reports are rejected", func(t *testing.T) {
+		for name, change := range map[string]func(*scheduledreports.ReportSettings){
+			"schedule":   func(s *scheduledreports.ReportSettings) { s.Schedule = "every day" },
+			"time zone":  func(s *scheduledreports.ReportSettings) { s.Timezone = "Mars/Olympus" },
+			"format":     func(s *scheduledreports.ReportSettings) { s.Formats = []scheduledreports.Format{"docx"} },
+			"time range": func(s *scheduledreports.ReportSettings) { s.TimeFrom = "yesterday-ish" },
+			"recipient":  func(s *scheduledreports.ReportSettings) { s.Recipients = []string{"not an email"} },
+			"delivery": func(s *scheduledreports.ReportSettings) {
+				s.Recipients = nil
+				s.WebhookURL = ""
+			},
+		} {
+			invalid := settings
+			change(&invalid)
+			_, err := s.Create(ctx, &scheduledreports.CreateReportCommand{ReportSettings: invalid, OrgID: 1, UserID: 7})
+			require.ErrorIs(t, err, scheduledreports.ErrInvalidReport, name)
+		}
+
+		internal := settings
+		internal.WebhookURL = "http://169.254.169.254/latest/meta-data"
+		_, err := s.Create(ctx, &scheduledreports.CreateReportCommand{ReportSettings: internal, OrgID: 1, UserID: 7})
+		require.ErrorIs(t, err, scheduledreports.ErrWebhookNotAllowed)
+
+		missing := settings
+		missing.DashboardUID = "missing"
+		_, err = s.Create(ctx, &scheduledreports.CreateReportCommand{ReportSettings: missing, OrgID: 1, UserID: 7})
+		require.ErrorIs(t, err, scheduledreports.ErrDashboardNotFound)
+	})
+
+	report, err := s.Create(ctx, &scheduledreports.CreateReportCommand{ReportSettings: settings, OrgID: 1, UserID: 7})
+	require.NoError(t, err)
+	require.Equal(t, scheduledreports.DefaultTimeFrom, report.TimeFrom)
+	// 09:00 in Berlin has passed today, so the next run is tomorrow at 07:00 UTC.
+	require.Equal(t, time.Date(2023, 10, 3, 7, 0, 0, 0, time.UTC).UnixMilli(), report.NextRun)
+
+	t.Run("sending renders all panels and delivers the report", func(t *testing.T) {
+		run, err := s.Send(ctx, &scheduledreports.SendReportCommand{UID: report.UID, OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, scheduledreports.RunStatusSuccess, run.Status)
+		require.Equal(t, scheduledreports.RunTriggerManual, run.Trigger)
+		require.Empty(t, run.Error)
+		require.NotNil(t, run.Finished)
+
+		// every panel is rendered as PNG for the PDF and as CSV, as the report owner
+		require.Len(t, renderer.paths, 6)
+		for _, auth := range renderer.auth {
+			require.Equal(t, rendering.AuthOpts{OrgID: 1, UserID: 7, OrgRole: org.RoleEditor}, auth)
+		}
+		u, err := url.Parse(renderer.paths[0])
+		require.NoError(t, err)
+		require.Equal(t, "d-solo/prod/production", u.Path)
+		require.Equal(t, []string{"prod", "staging"}, u.Query()["var-env"])
+		require.Equal(t, "1696147200000", u.Query().Get("from"))
+		require.Equal(t, "1696233600000", u.Query().Get("to"))
+		require.Equal(t, "Europe/Berlin", u.Query().Get("tz"))
+
+		email := notificationService.EmailSync
+		require.Equal(t, []string{"ops@example.com"}, email.To)
+		require.Equal(t, "scheduled_report", email.Template)
+		require.Equal(t, "Production", email.Data["DashboardTitle"])
+		names := make([]string, 0, len(email.AttachedFiles))
+		for _, f := range email.AttachedFiles {
+			names = append(names, f.Name)
+		}
+		require.Equal(t, []string{"daily-production.pdf", "cpu-1.csv", "memory-2.csv", "disk-3.csv"}, names)
+		require.True(t, bytes.HasPrefix(email.AttachedFiles[0].Content, []byte("%PDF")))
+
+		webhook := notificationService.Webhook
+		require.Equal(t, "https://example.com/reports", webhook.Url)
+		payload := webhookPayload{}
+		require.NoError(t, json.Unmarshal([]byte(webhook.Body), &payload))
+		require.Equal(t, report.UID, payload.Report.UID)
+		require.Len(t, payload.Files, 4)
+		require.Equal(t, []byte("time,value\n1,2\n"), payload.Files[1].Content)
+	})
+
+	t.Run("panels that fail to render are listed in the run", func(t *testing.T) {
+		renderer.failPanels["2"] = true
+		defer delete(renderer.failPanels, "2")
+
+		run, err := s.Send(ctx, &scheduledreports.SendReportCommand{UID: report.UID, OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, scheduledreports.RunStatusSuccess, run.Status)
+		require.Equal(t, "Memory: rendering timed out", run.Error)
+		require.Equal(t, []string{"Memory: rendering timed out"}, notificationService.EmailSync.Data["PanelErrors"])
+	})
+
+	t.Run("failed deliveries fail the run", func(t *testing.T) {
+		notificationService.ShouldError = errors.New("smtp not configured")
+		defer func() { notificationService.ShouldError = nil }()
+
+		run, err := s.Send(ctx, &scheduledreports.SendReportCommand{UID: report.UID, OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, scheduledreports.RunStatusFailed, run.Status)
+		require.Contains(t, run.Error, "failed to send report email: smtp not configured")
+		require.Contains(t, run.Error, "failed to send report webhook: smtp not configured")
+
+		failed, err := s.ListRuns(ctx, &scheduledreports.ListReportRunsQuery{UID: report.UID, OrgID: 1, Status: scheduledreports.RunStatusFailed})
+		require.NoError(t, err)
+		require.Len(t, failed, 1)
+		require.Equal(t, run.ID, failed[0].ID)
+	})
+
+	t.Run("only the owner or an org admin can change or send the report", func(t *testing.T) {
+		routeRegister := routing.NewRouteRegister()
+		s.registerAPIEndpoints(routeRegister)
+		server := webtest.NewServer(t, routeRegister)
+
+		call := func(t *testing.T, method, target, body string, userID int64, role org.RoleType) int {
+			t.Helper()
+			req := server.NewRequest(method, target, strings.NewReader(body))
+			webtest.RequestWithSignedInUser(req, &user.SignedInUser{UserID: userID, OrgID: 1, OrgRole: role, Permissions: map[int64]map[string][]string{
+				1: {dashboards.ActionDashboardsRead: {dashboards.ScopeDashboardsAll}},
+			}})
+			resp, err := server.SendJSON(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			return resp.StatusCode
+		}
+		body, err := json.Marshal(settings)
+		require.NoError(t, err)
+		target := "/api/scheduled-reports/" + report.UID
+
+		require.Equal(t, http.StatusOK, call(t, http.MethodGet, target, "", 8, org.RoleEditor))
+		require.Equal(t, http.StatusForbidden, call(t, http.MethodPut, target, string(body), 8, org.RoleEditor))
+		require.Equal(t, http.StatusForbidden, call(t, http.MethodPost, target+"/send", "", 8, org.RoleEditor))
+		require.Equal(t, http.StatusForbidden, call(t, http.MethodDelete, target, "", 8, org.RoleEditor))
+
+		require.Equal(t, http.StatusOK, call(t, http.MethodPost, target+"/send", "", 7, org.RoleEditor))
+		require.Equal(t, http.StatusOK, call(t, http.MethodPost, target+"/send", "", 9, org.RoleAdmin))
+	})
+
+	t.Run("webhooks to hosts that are no longer allowed are not sent", func(t *testing.T) {
+		s.webhookAllowedHosts = map[string]bool{}
+		defer func() { s.webhookAllowedHosts = map[string]bool{"example.com": true} }()
+
+		run, err := s.Send(ctx, &scheduledreports.SendReportCommand{UID: report.UID, OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, scheduledreports.RunStatusFailed, run.Status)
+		require.Contains(t, run.Error, "failed to send report webhook")
+	})
+
+	t.Run("due reports run once on schedule", func(t *testing.T) {
+		now = time.Date(2023, 10, 3, 7, 0, 30, 0, time.UTC)
+		other := &Service{store: s.store, log: s.log, now: s.now}
+
+		s.runDue(ctx)
+		// another instance sharing the database finds nothing left to run
+		other.runDue(ctx)
+
+		runs, err := s.ListRuns(ctx, &scheduledreports.ListReportRunsQuery{UID: report.UID, OrgID: 1})
+		require.NoError(t, err)
+		// only the latest runs are kept
+		require.Len(t, runs, 3)
+		require.Equal(t, scheduledreports.RunTriggerSchedule, runs[0].Trigger)
+		require.Equal(t, scheduledreports.RunStatusSuccess, runs[0].Status)
+
+		stored, err := s.Get(ctx, &scheduledreports.GetReportQuery{UID: report.UID, OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2023, 10, 4, 7, 0, 0, 0, time.UTC).UnixMilli(), stored.NextRun)
+	})
+
+	t.Run("disabled reports are not scheduled", func(t *testing.T) {
+		disabled := settings
+		disabled.Enabled = false
+		updated, err := s.Update(ctx, &scheduledreports.UpdateReportCommand{ReportSettings: disabled, UID: report.UID, OrgID: 1, UserID: 8})
+		require.NoError(t, err)
+		require.Zero(t, updated.NextRun)
+		require.Equal(t, int64(8), updated.UserID)
+
+		now = time.Date(2023, 10, 10, 7, 0, 0, 0, time.UTC)
+		due, err := s.store.ListDue(ctx, now)
+		require.NoError(t, err)
+		require.Empty(t, due)
+	})
+
+	t.Run("deleting a report deletes its runs", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, &scheduledreports.DeleteReportCommand{UID: report.UID, OrgID: 1}))
+
+		_, err := s.Get(ctx, &scheduledreports.GetReportQuery{UID: report.UID, OrgID: 1})
+		require.ErrorIs(t, err, scheduledreports.ErrReportNotFound)
+		runs, err := s.store.ListRuns(ctx, report.ID, "", 10)
+		require.NoError(t, err)
+		require.Empty(t, runs)
+	})
+}
@@ -0,0 +1,26 @@
+package scheduledreportsimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/scheduledreports"
+)
+
+type store interface {
+	Insert(ctx context.Context, report *scheduledreports.Report) error
+	Update(ctx context.Context, report *scheduledreports.Report) error
+	Get(ctx context.Context, query *scheduledreports.GetReportQuery) (*scheduledreports.Report, error)
+	List(ctx context.Context, query *scheduledreports.ListReportsQuery) ([]*scheduledreports.Report, error)
+	Delete(ctx context.Context, cmd *scheduledreports.DeleteReportCommand) error
+	// ListDue returns the enabled reports that should have run before now.
+	ListDue(ctx context.Context, now time.Time) ([]*scheduledreports.Report, error)
+	// ClaimNextRun moves the next run of a report from prev to next, and returns false
+	// when another instance already did, so a scheduled run is only executed once.
+	ClaimNextRun(ctx context.Context, id int64, prev, next int64) (bool, error)
+	InsertRun(ctx context.Context, run *scheduledreports.ReportRun) error
+	UpdateRun(ctx context.Context, run *scheduledreports.ReportRun) error
+	ListRuns(ctx context.Context, reportID int64, status scheduledreports.RunStatus, limit int) ([]*scheduledreports.ReportRun, error)
+	// DeleteOldRuns deletes all but the latest `keep` runs of a report.
+	DeleteOldRuns(ctx context.Context, reportID int64, keep int) error
+}
@@ -0,0 +1,144 @@
+package scheduledreportsimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/scheduledreports"
+)
+
+type sqlStore struct {
+	db db.DB
+}
+
+var _ store = (*sqlStore)(nil)
+
+func (s *sqlStore) Insert(ctx context.Context, report *scheduledreports.Report) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(report)
+		return err
+	})
+}
+
+func (s *sqlStore) Update(ctx context.Context, report *scheduledreports.Report) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("org_id = ? AND uid = ?", report.OrgID, report.UID).
+			Cols("name", "dashboard_uid", "variables", "time_from", "time_to", "schedule", "timezone", "formats",
+				"recipients", "webhook_url", "enabled", "user_id", "next_run", "updated").Update(report)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return scheduledreports.ErrReportNotFound.Errorf("report not found")
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) Get(ctx context.Context, query *scheduledreports.GetReportQuery) (*scheduledreports.Report, error) {
+	report := &scheduledreports.Report{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("org_id = ? AND uid = ?", query.OrgID, query.UID).Get(report)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return scheduledreports.ErrReportNotFound.Errorf("report not found")
+		}
+		return nil
+	})
+	return report, err
+}
+
+func (s *sqlStore) List(ctx context.Context, query *scheduledreports.ListReportsQuery) ([]*scheduledreports.Report, error) {
+	reports := make([]*scheduledreports.Report, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		sess.Where("org_id = ?", query.OrgID)
+		if query.DashboardUID != "" {
+			sess.And("dashboard_uid = ?", query.DashboardUID)
+		}
+		return sess.Asc("name").Find(&reports)
+	})
+	return reports, err
+}
+
+func (s *sqlStore) Delete(ctx context.Context, cmd *scheduledreports.DeleteReportCommand) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		report := &scheduledreports.Report{}
+		has, err := sess.Where("org_id = ? AND uid = ?", cmd.OrgID, cmd.UID).Get(report)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return scheduledreports.ErrReportNotFound.Errorf("report not found")
+		}
+		if _, err := sess.Exec("DELETE FROM scheduled_report_run WHERE report_id = ?", report.ID); err != nil {
+			return err
+		}
+		_, err = sess.Exec("DELETE FROM scheduled_report WHERE id = ?", report.ID)
+		return err
+	})
+}
+
+func (s *sqlStore) ListDue(ctx context.Context, now time.Time) ([]*scheduledreports.Report, error) {
+	reports := make([]*scheduledreports.Report, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("enabled = ? AND next_run > 0 AND next_run <= ?", true, now.UnixMilli()).
+			Asc("next_run").Find(&reports)
+	})
+	return reports, err
+}
+
+func (s *sqlStore) ClaimNextRun(ctx context.Context, id int64, prev, next int64) (bool, error) {
+	claimed := false
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("UPDATE scheduled_report SET next_run = ? WHERE id = ? AND next_run = ?", next, id, prev)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		claimed = affected > 0
+		return err
+	})
+	return claimed, err
+}
+
+func (s *sqlStore) InsertRun(ctx context.Context, run *scheduledreports.ReportRun) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(run)
+		return err
+	})
+}
+
+func (s *sqlStore) UpdateRun(ctx context.Context, run *scheduledreports.ReportRun) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.ID(run.ID).Cols("status", "error", "finished").Update(run)
+		return err
+	})
+}
+
+func (s *sqlStore) ListRuns(ctx context.Context, reportID int64, status scheduledreports.RunStatus, limit int) ([]*scheduledreports.ReportRun, error) {
+	runs := make([]*scheduledreports.ReportRun, 0)
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		sess.Where("report_id = ?", reportID)
+		if status != "" {
+			sess.And("status = ?", status)
+		}
+		return sess.Desc("started").Desc("id").Limit(limit).Find(&runs)
+	})
+	return runs, err
+}
+
+func (s *sqlStore) DeleteOldRuns(ctx context.Context, reportID int64, keep int) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		ids := make([]int64, 0)
+		err := sess.Table("scheduled_report_run").Where("report_id = ?", reportID).
+			Desc("started").Desc("id").Limit(1000000, keep).Cols("id").Find(&ids)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		_, err = sess.Table("scheduled_report_run").In("id", ids).Delete(&scheduledreports.ReportRun{})
+		return err
+	})
+}
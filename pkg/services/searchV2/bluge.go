@@ -441,26 +441,24 @@ func doSearchQuery(
 		hasConstraints = true
 	}
 
-	isMatchAllQuery := q.Query == "*" || q.Query == ""
-	if isMatchAllQuery {
+	// Queries that are not valid in the query language, such as `"api latency` or `(prod`,
+	// are searched as free text like before the query language existed.
+	parsed, parseErr := parseSearchQuery(q.Query)
+	if parseErr != nil {
+		logger.Debug("Searching invalid query as free text", "query", q.Query, "error", parseErr)
+	}
+
+	isMatchAllQuery := q.Query == "*" || (parseErr == nil && parsed == nil)
+	builder := &searchQueryBuilder{folders: newFolderLookup(ctx, reader)}
+	switch {
+	case isMatchAllQuery:
 		if !hasConstraints {
 			fullQuery.AddShould(bluge.NewMatchAllQuery())
 		}
-	} else {
-		bq := bluge.NewBooleanQuery()
-
-		bq.AddShould(NewSubstringQuery(formatForNameSortField(q.Query)).
-			SetField(documentFieldName_sort).
-			SetBoost(6))
-
-		if shouldUseNgram(q) {
-			bq.AddShould(bluge.NewMatchQuery(q.Query).
-				SetField(documentFieldName_ngram).
-				SetOperator(bluge.MatchQueryOperatorAnd). // all terms must match
-				SetAnalyzer(ngramQueryAnalyzer).SetBoost(1))
-		}
-
-		fullQuery.AddMust(bq)
+	case parseErr != nil || isFreeTextQuery(parsed):
+		fullQuery.AddMust(builder.textQuery(q.Query))
+	default:
+		fullQuery.AddMust(builder.toBlugeQuery(parsed))
 	}
 
 	limit := 50 // default view
@@ -674,10 +672,10 @@ func doSearchQuery(
 	return response
 }
 
-func shouldUseNgram(q DashboardQuery) bool {
+func shouldUseNgram(query string) bool {
 	var tokens []string
-	if len(q.Query) > ngramEdgeFilterMaxLength {
-		tokens = strings.Fields(q.Query)
+	if len(query) > ngramEdgeFilterMaxLength {
+		tokens = strings.Fields(query)
 		for _, k := range tokens {
 			// ngram will never match if at least one input token exceeds the max token length,
 			// as all tokens must match simultaneously with the `bluge.MatchQueryOperatorAnd` operator
@@ -693,6 +691,38 @@ func formatForNameSortField(name string) string {
 	return strings.Trim(strings.ToUpper(name), " ")
 }
 
+// newFolderLookup resolves folder names (or name wildcards) to folder UIDs using the index.
+func newFolderLookup(ctx context.Context, reader *bluge.Reader) folderLookup {
+	return func(nameOrUID string, wildcard bool) []string {
+		var nameQuery bluge.Query = bluge.NewTermQuery(formatForNameSortField(nameOrUID)).SetField(documentFieldName_sort)
+		if wildcard {
+			nameQuery = bluge.NewWildcardQuery(formatForNameSortField(nameOrUID)).SetField(documentFieldName_sort)
+		}
+		bq := bluge.NewBooleanQuery()
+		bq.AddMust(bluge.NewTermQuery(string(entityKindFolder)).SetField(documentFieldKind))
+		bq.AddMust(nameQuery)
+
+		documentMatchIterator, err := reader.Search(ctx, bluge.NewAllMatches(bq))
+		if err != nil {
+			return nil
+		}
+
+		var uids []string
+		match, err := documentMatchIterator.Next()
+		for err == nil && match != nil {
+			_ = match.VisitStoredFields(func(field string, value []byte) bool {
+				if field == documentFieldUID {
+					uids = append(uids, string(value))
+					return false
+				}
+				return true
+			})
+			match, err = documentMatchIterator.Next()
+		}
+		return uids
+	}
+}
+
 func getLocationLookupInfo(ctx context.Context, reader *bluge.Reader, uids map[string]bool) map[string]locationItem {
 	res := make(map[string]locationItem, len(uids))
 	bq := bluge.NewBooleanQuery()
@@ -104,22 +104,30 @@ type searchIndex struct {
 	tracer                  tracing.Tracer
 	features                featuremgmt.FeatureToggles
 	settings                setting.SearchSettings
+	snapshots               *indexSnapshotStore
+	snapshotSemaphore       chan struct{}
 }
 
 func newSearchIndex(dashLoader dashboardLoader, evStore eventStore, extender DocumentExtender, folderIDs folderUIDLookup, tracer tracing.Tracer, features featuremgmt.FeatureToggles, settings setting.SearchSettings) *searchIndex {
+	var snapshots *indexSnapshotStore
+	if settings.IndexSnapshotEnabled && settings.IndexSnapshotPath != "" {
+		snapshots = newIndexSnapshotStore(settings.IndexSnapshotPath)
+	}
 	return &searchIndex{
-		loader:          dashLoader,
-		eventStore:      evStore,
-		perOrgIndex:     map[int64]*orgIndex{},
-		initializedOrgs: map[int64]bool{},
-		logger:          log.New("searchIndex"),
-		buildSignals:    make(chan buildSignal),
-		extender:        extender,
-		folderIdLookup:  folderIDs,
-		syncCh:          make(chan chan struct{}),
-		tracer:          tracer,
-		features:        features,
-		settings:        settings,
+		loader:            dashLoader,
+		eventStore:        evStore,
+		perOrgIndex:       map[int64]*orgIndex{},
+		initializedOrgs:   map[int64]bool{},
+		logger:            log.New("searchIndex"),
+		buildSignals:      make(chan buildSignal),
+		extender:          extender,
+		folderIdLookup:    folderIDs,
+		syncCh:            make(chan chan struct{}),
+		tracer:            tracer,
+		features:          features,
+		settings:          settings,
+		snapshots:         snapshots,
+		snapshotSemaphore: make(chan struct{}, 1),
 	}
 }
 
@@ -204,11 +212,27 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 		lastEventID = lastEvent.Id
 	}
 
-	err = i.buildInitialIndexes(initialSetupCtx, orgIDs)
+	replayFromEventID, err := i.buildInitialIndexes(initialSetupCtx, orgIDs, lastEventID)
 	if err != nil {
 		initialSetupSpan.End()
 		return err
 	}
+	if replayFromEventID < lastEventID {
+		// Some indexes were loaded from snapshots, bring them up to date before serving requests.
+		lastEventID = i.applyIndexUpdates(initialSetupCtx, replayFromEventID)
+	}
+
+	// Snapshots are written periodically when enabled, a nil channel blocks forever otherwise.
+	var snapshotCh <-chan time.Time
+	if i.snapshots != nil {
+		snapshotTicker := time.NewTicker(i.settings.IndexSnapshotInterval)
+		defer snapshotTicker.Stop()
+		snapshotCh = snapshotTicker.C
+	}
+
+	// Indexes must not be snapshotted while being rebuilt asynchronously, since until
+	// the rebuild is finished they are not consistent with lastEventID.
+	asyncReIndexInProgress := false
 
 	// This semaphore channel allows limiting concurrent async re-indexing routines to 1.
 	asyncReIndexSemaphore := make(chan struct{}, 1)
@@ -257,6 +281,7 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 			// Full re-indexing will be later re-started in `case lastIndexedEventID := <-reIndexDoneCh`
 			// branch.
 			fullReIndexTimer.Stop()
+			asyncReIndexInProgress = true
 			go func() {
 				defer span.End()
 				// We need semaphore here since asynchronous re-indexing may be in progress already.
@@ -275,6 +300,7 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 			// come to an approach which does not require periodic re-indexing at all. One possible way
 			// is to use DB triggers, see https://github.com/grafana/grafana/pull/47712.
 			lastIndexedEventID := lastEventID
+			asyncReIndexInProgress = true
 			go func() {
 				defer span.End()
 				// Do full re-index asynchronously to avoid blocking index synchronization
@@ -301,23 +327,105 @@ func (i *searchIndex) run(ctx context.Context, orgIDs []int64, reIndexSignalCh c
 				partialUpdateTimer.Reset(0)
 			}
 			fullReIndexTimer.Reset(reIndexInterval)
+			asyncReIndexInProgress = false
+		case <-snapshotCh:
+			if !asyncReIndexInProgress {
+				checkpoint := lastEventID
+				go i.saveSnapshots(checkpoint)
+			}
 		case <-ctx.Done():
+			if i.snapshots != nil && !asyncReIndexInProgress {
+				i.saveSnapshots(lastEventID)
+			}
 			return ctx.Err()
 		}
 	}
 }
 
-func (i *searchIndex) buildInitialIndexes(ctx context.Context, orgIDs []int64) error {
+// buildInitialIndexes builds or loads the indexes for the given orgs. It returns the event ID
+// after which entity events must be replayed to bring indexes loaded from snapshots up to date.
+func (i *searchIndex) buildInitialIndexes(ctx context.Context, orgIDs []int64, lastEventID int64) (int64, error) {
 	started := time.Now()
 	i.logger.Info("Start building in-memory indexes")
+	replayFromEventID := lastEventID
 	for _, orgID := range orgIDs {
+		if checkpoint, ok := i.loadSnapshot(orgID, lastEventID); ok {
+			if checkpoint < replayFromEventID {
+				replayFromEventID = checkpoint
+			}
+			continue
+		}
 		err := i.buildInitialIndex(ctx, orgID)
 		if err != nil {
-			return fmt.Errorf("can't build initial dashboard search index for org %d: %w", orgID, err)
+			return 0, fmt.Errorf("can't build initial dashboard search index for org %d: %w", orgID, err)
 		}
 	}
 	i.logger.Info("Finish building in-memory indexes", "elapsed", time.Since(started))
-	return nil
+	return replayFromEventID, nil
+}
+
+// loadSnapshot loads the org index from a snapshot, and returns the ID of the last event
+// applied to it. Snapshots which can not be brought up to date are ignored.
+func (i *searchIndex) loadSnapshot(orgID int64, lastEventID int64) (int64, bool) {
+	if i.snapshots == nil {
+		return 0, false
+	}
+
+	started := time.Now()
+	index, meta, err := i.snapshots.load(orgID)
+	if err != nil {
+		if !errors.Is(err, errIndexSnapshotNotFound) {
+			i.logger.Warn("Can't load search index snapshot, rebuilding index", "orgId", orgID, "error", err)
+		}
+		return 0, false
+	}
+
+	if meta.LastEventID > lastEventID || time.Since(meta.Created) > indexSnapshotMaxAge {
+		i.logger.Info("Search index snapshot is outdated, rebuilding index", "orgId", orgID, "snapshotEventID", meta.LastEventID, "lastEventID", lastEventID, "created", meta.Created)
+		for _, w := range index.writers {
+			_ = w.Close()
+		}
+		return 0, false
+	}
+
+	i.mu.Lock()
+	i.perOrgIndex[orgID] = index
+	i.mu.Unlock()
+
+	i.initializationMutex.Lock()
+	i.initializedOrgs[orgID] = true
+	i.initializationMutex.Unlock()
+
+	i.logger.Info("Loaded search index snapshot", "orgId", orgID, "elapsed", time.Since(started), "numDocs", meta.DocCount, "snapshotEventID", meta.LastEventID)
+	return meta.LastEventID, true
+}
+
+// saveSnapshots writes a snapshot of every org index. Indexes must contain all the
+// changes up to lastEventID.
+func (i *searchIndex) saveSnapshots(lastEventID int64) {
+	select {
+	case i.snapshotSemaphore <- struct{}{}:
+		defer func() { <-i.snapshotSemaphore }()
+	default:
+		// Previous snapshot is still being written.
+		return
+	}
+
+	i.mu.RLock()
+	indexes := make(map[int64]*orgIndex, len(i.perOrgIndex))
+	for orgID, index := range i.perOrgIndex {
+		indexes[orgID] = index
+	}
+	i.mu.RUnlock()
+
+	for orgID, index := range indexes {
+		started := time.Now()
+		if err := i.snapshots.save(orgID, index, lastEventID); err != nil {
+			i.logger.Error("Can't save search index snapshot", "orgId", orgID, "error", err)
+			continue
+		}
+		i.logger.Debug("Saved search index snapshot", "orgId", orgID, "elapsed", time.Since(started), "lastEventID", lastEventID)
+	}
 }
 
 func (i *searchIndex) buildInitialIndex(ctx context.Context, orgID int64) error {
@@ -0,0 +1,305 @@
+package searchV2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/blugelabs/bluge"
+	"github.com/blugelabs/bluge/index"
+	segment "github.com/blugelabs/bluge_segment_api"
+)
+
+// indexSnapshotVersion must be incremented whenever the structure of indexed documents
+// changes, so that snapshots written by older versions are rebuilt instead of loaded.
+const indexSnapshotVersion = 1
+
+// indexSnapshotMaxAge is the maximum age of a snapshot which can still be brought up to date:
+// entity events older than a day are removed, see store.entityEventService.
+const indexSnapshotMaxAge = 23 * time.Hour
+
+const indexSnapshotMetaFile = "snapshot.json"
+
+var errIndexSnapshotNotFound = errors.New("index snapshot not found")
+
+type indexSnapshotMeta struct {
+	Version     int       `json:"version"`
+	OrgID       int64     `json:"orgId"`
+	LastEventID int64     `json:"lastEventId"`
+	DocCount    uint64    `json:"docCount"`
+	Created     time.Time `json:"created"`
+}
+
+// indexSnapshotStore persists org indexes to disk together with the ID of the last entity
+// event applied to them, so that on startup only the events after that checkpoint have to
+// be replayed instead of building the whole index from scratch.
+type indexSnapshotStore struct {
+	path string
+}
+
+func newIndexSnapshotStore(path string) *indexSnapshotStore {
+	return &indexSnapshotStore{path: path}
+}
+
+func (s *indexSnapshotStore) orgDir(orgID int64) string {
+	return filepath.Join(s.path, fmt.Sprintf("org-%d", orgID))
+}
+
+// save writes a snapshot of the org index. The snapshot is written to a temporary directory
+// first and then moved in place, the previous snapshot is moved aside and only removed once
+// the new one is in place, so a crash while saving never leaves a partial snapshot.
+func (s *indexSnapshotStore) save(orgID int64, idx *orgIndex, lastEventID int64) error {
+	reader, cancel, err := idx.readerForIndex(indexTypeDashboard)
+	if err != nil {
+		return fmt.Errorf("error getting reader: %w", err)
+	}
+	defer cancel()
+
+	count, err := reader.Count()
+	if err != nil {
+		return fmt.Errorf("error counting documents: %w", err)
+	}
+
+	dir := s.orgDir(orgID)
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+
+	indexDir := filepath.Join(tmpDir, "index")
+	if err := os.MkdirAll(indexDir, 0750); err != nil {
+		return err
+	}
+
+	cancelCh := make(chan struct{})
+	if err := reader.Backup(indexDir, cancelCh); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return fmt.Errorf("error writing index backup: %w", err)
+	}
+
+	meta, err := json.Marshal(indexSnapshotMeta{
+		Version:     indexSnapshotVersion,
+		OrgID:       orgID,
+		LastEventID: lastEventID,
+		DocCount:    count,
+		Created:     time.Now(),
+	})
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, indexSnapshotMetaFile), meta, 0600); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return err
+	}
+
+	oldDir := dir + ".old"
+	if _, err := os.Stat(dir); err == nil {
+		if err := os.RemoveAll(oldDir); err != nil {
+			return err
+		}
+		if err := os.Rename(dir, oldDir); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return err
+	}
+	return os.RemoveAll(oldDir)
+}
+
+// load reads the org index snapshot into memory. Any problem with the snapshot is returned
+// as an error and the caller is expected to fall back to building the index from scratch.
+func (s *indexSnapshotStore) load(orgID int64) (*orgIndex, *indexSnapshotMeta, error) {
+	dir := s.orgDir(orgID)
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		// A crash while saving may have left only the previous snapshot.
+		if _, err := os.Stat(dir + ".old"); err == nil {
+			dir += ".old"
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, indexSnapshotMetaFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, errIndexSnapshotNotFound
+		}
+		return nil, nil, err
+	}
+
+	meta := &indexSnapshotMeta{}
+	if err := json.Unmarshal(b, meta); err != nil {
+		return nil, nil, fmt.Errorf("error reading snapshot metadata: %w", err)
+	}
+	if meta.Version != indexSnapshotVersion {
+		return nil, nil, fmt.Errorf("snapshot version %d does not match %d", meta.Version, indexSnapshotVersion)
+	}
+	if meta.OrgID != orgID {
+		return nil, nil, fmt.Errorf("snapshot belongs to org %d", meta.OrgID)
+	}
+
+	dashboardDir, err := loadSnapshotDirectory(filepath.Join(dir, "index"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading snapshot: %w", err)
+	}
+
+	writer, err := bluge.OpenWriter(bluge.DefaultConfigWithDirectory(func() index.Directory {
+		return dashboardDir
+	}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening writer: %w", err)
+	}
+
+	idx := &orgIndex{
+		writers: map[indexType]*bluge.Writer{
+			indexTypeDashboard: writer,
+		},
+	}
+
+	reader, cancel, err := idx.readerForIndex(indexTypeDashboard)
+	if err != nil {
+		_ = writer.Close()
+		return nil, nil, fmt.Errorf("error opening reader: %w", err)
+	}
+	defer cancel()
+
+	count, err := reader.Count()
+	if err != nil || count != meta.DocCount {
+		_ = writer.Close()
+		return nil, nil, fmt.Errorf("snapshot is corrupt: expected %d documents, found %d (%v)", meta.DocCount, count, err)
+	}
+
+	return idx, meta, nil
+}
+
+// loadSnapshotDirectory copies the index files from disk into memory, so the loaded index
+// behaves the same as an index built with bluge.InMemoryOnlyConfig.
+func loadSnapshotDirectory(path string) (*memoryDirectory, error) {
+	src := index.NewFileSystemDirectory(path)
+	if err := src.Setup(true); err != nil {
+		return nil, err
+	}
+
+	dst := newMemoryDirectory()
+	for _, kind := range []string{index.ItemKindSegment, index.ItemKindSnapshot} {
+		ids, err := src.List(kind)
+		if err != nil {
+			return nil, err
+		}
+		if kind == index.ItemKindSnapshot && len(ids) == 0 {
+			return nil, errors.New("no index snapshot found")
+		}
+		for _, id := range ids {
+			data, closer, err := src.Load(kind, id)
+			if err != nil {
+				return nil, err
+			}
+			var buf bytes.Buffer
+			_, err = data.WriteTo(&buf)
+			if closer != nil {
+				_ = closer.Close()
+			}
+			if err != nil {
+				return nil, err
+			}
+			dst.items[kind][id] = buf.Bytes()
+		}
+	}
+	return dst, nil
+}
+
+// memoryDirectory is an in-memory index.Directory. Unlike index.InMemoryDirectory it also
+// keeps index snapshot items, which is required to open a writer on existing segments.
+type memoryDirectory struct {
+	mu    sync.RWMutex
+	items map[string]map[uint64][]byte
+}
+
+var _ index.Directory = (*memoryDirectory)(nil)
+
+func newMemoryDirectory() *memoryDirectory {
+	return &memoryDirectory{
+		items: map[string]map[uint64][]byte{
+			index.ItemKindSegment:  {},
+			index.ItemKindSnapshot: {},
+		},
+	}
+}
+
+func (d *memoryDirectory) Setup(_ bool) error {
+	return nil
+}
+
+func (d *memoryDirectory) List(kind string) ([]uint64, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	ids := make([]uint64, 0, len(d.items[kind]))
+	for id := range d.items[kind] {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+	return ids, nil
+}
+
+func (d *memoryDirectory) Load(kind string, id uint64) (*segment.Data, io.Closer, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	b, ok := d.items[kind][id]
+	if !ok {
+		return nil, nil, fmt.Errorf("item %d%s not found", id, kind)
+	}
+	return segment.NewDataBytes(b), nil, nil
+}
+
+func (d *memoryDirectory) Persist(kind string, id uint64, w index.WriterTo, closeCh chan struct{}) error {
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf, closeCh); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.items[kind]; !ok {
+		d.items[kind] = map[uint64][]byte{}
+	}
+	d.items[kind][id] = buf.Bytes()
+	return nil
+}
+
+func (d *memoryDirectory) Remove(kind string, id uint64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.items[kind], id)
+	return nil
+}
+
+func (d *memoryDirectory) Stats() (uint64, uint64) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var numItems, numBytes uint64
+	for _, items := range d.items {
+		for _, b := range items {
+			numItems++
+			numBytes += uint64(len(b))
+		}
+	}
+	return numItems, numBytes
+}
+
+func (d *memoryDirectory) Sync() error {
+	return nil
+}
+
+func (d *memoryDirectory) Lock() error {
+	return nil
+}
+
+func (d *memoryDirectory) Unlock() error {
+	return nil
+}
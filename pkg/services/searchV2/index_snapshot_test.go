@@ -0,0 +1,127 @@
+package searchV2
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func searchUIDs(t *testing.T, index *orgIndex, query string) []string {
+	t.Helper()
+	resp := doSearchQuery(context.Background(), testLogger, index, testAllowAllFilter,
+		DashboardQuery{Query: query, Sort: documentFieldName_sort}, &NoopQueryExtender{}, "")
+	require.NoError(t, resp.Error)
+	field, _ := resp.Frames[0].FieldByName("uid")
+	uids := make([]string, 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		uids = append(uids, field.At(i).(string))
+	}
+	return uids
+}
+
+func TestIndexSnapshotStore(t *testing.T) {
+	index := initTestOrgIndexFromDashes(t, dashboardsWithFolders)
+	snapshots := newIndexSnapshotStore(t.TempDir())
+
+	t.Run("load missing snapshot", func(t *testing.T) {
+		_, _, err := snapshots.load(2)
+		require.ErrorIs(t, err, errIndexSnapshotNotFound)
+	})
+
+	t.Run("save and load snapshot", func(t *testing.T) {
+		require.NoError(t, snapshots.save(testOrgID, index, 42))
+
+		loaded, meta, err := snapshots.load(testOrgID)
+		require.NoError(t, err)
+		require.Equal(t, int64(42), meta.LastEventID)
+		require.Equal(t, indexSnapshotVersion, meta.Version)
+		require.Equal(t, searchUIDs(t, index, "dash"), searchUIDs(t, loaded, "dash"))
+		require.Equal(t, searchUIDs(t, index, "Panel"), searchUIDs(t, loaded, "Panel"))
+
+		// The loaded index must accept updates like an index built from scratch.
+		searchIdx := initTestIndexFromDashes(t, dashboardsWithFolders)
+		require.NoError(t, searchIdx.removeFolder(context.Background(), loaded, "1"))
+		require.Equal(t, []string{"4"}, searchUIDs(t, loaded, "dash"))
+	})
+
+	t.Run("load previous snapshot after an interrupted save", func(t *testing.T) {
+		require.NoError(t, snapshots.save(testOrgID, index, 42))
+		require.NoError(t, snapshots.save(testOrgID, index, 43))
+		_, err := os.Stat(snapshots.orgDir(testOrgID) + ".old")
+		require.ErrorIs(t, err, os.ErrNotExist, "the previous snapshot is removed once the new one is in place")
+
+		// Simulate a crash after the previous snapshot was moved aside.
+		dir := snapshots.orgDir(testOrgID)
+		require.NoError(t, os.Rename(dir, dir+".old"))
+
+		_, meta, err := snapshots.load(testOrgID)
+		require.NoError(t, err)
+		require.Equal(t, int64(43), meta.LastEventID)
+
+		require.NoError(t, snapshots.save(testOrgID, index, 44))
+		_, meta, err = snapshots.load(testOrgID)
+		require.NoError(t, err)
+		require.Equal(t, int64(44), meta.LastEventID)
+	})
+
+	t.Run("version mismatch", func(t *testing.T) {
+		require.NoError(t, snapshots.save(testOrgID, index, 42))
+		metaPath := filepath.Join(snapshots.orgDir(testOrgID), indexSnapshotMetaFile)
+		require.NoError(t, os.WriteFile(metaPath, []byte(`{"version":0,"orgId":1}`), 0600))
+
+		_, _, err := snapshots.load(testOrgID)
+		require.ErrorContains(t, err, "snapshot version")
+	})
+
+	t.Run("corrupt snapshot", func(t *testing.T) {
+		require.NoError(t, snapshots.save(testOrgID, index, 42))
+		indexDir := filepath.Join(snapshots.orgDir(testOrgID), "index")
+		entries, err := os.ReadDir(indexDir)
+		require.NoError(t, err)
+		for _, e := range entries {
+			require.NoError(t, os.WriteFile(filepath.Join(indexDir, e.Name()), []byte("garbage"), 0600))
+		}
+
+		_, _, err = snapshots.load(testOrgID)
+		require.Error(t, err)
+	})
+}
+
+func TestSearchIndexLoadSnapshot(t *testing.T) {
+	settings := setting.SearchSettings{IndexSnapshotEnabled: true, IndexSnapshotPath: t.TempDir()}
+
+	newIndex := func() *searchIndex {
+		return newSearchIndex(&testDashboardLoader{dashboards: dashboardsWithFolders}, &store.MockEntityEventsService{}, &NoopDocumentExtender{},
+			func(ctx context.Context, folderId int64) (string, error) { return "x", nil }, tracing.InitializeTracerForTest(), featuremgmt.WithFeatures(), settings)
+	}
+
+	index := newIndex()
+	_, err := index.buildOrgIndex(context.Background(), testOrgID)
+	require.NoError(t, err)
+	index.saveSnapshots(10)
+
+	t.Run("loads snapshot", func(t *testing.T) {
+		loaded := newIndex()
+		replayFrom, err := loaded.buildInitialIndexes(context.Background(), []int64{testOrgID}, 15)
+		require.NoError(t, err)
+		require.Equal(t, int64(10), replayFrom)
+		orgIdx, ok := loaded.getOrgIndex(testOrgID)
+		require.True(t, ok)
+		require.Equal(t, []string{"1"}, searchUIDs(t, orgIdx, "My folder"))
+	})
+
+	t.Run("ignores snapshot ahead of the event store", func(t *testing.T) {
+		loaded := newIndex()
+		replayFrom, err := loaded.buildInitialIndexes(context.Background(), []int64{testOrgID}, 5)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), replayFrom)
+	})
+}
@@ -0,0 +1,471 @@
+package searchV2
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blugelabs/bluge"
+)
+
+// The dashboard search query language supports:
+//
+//	api latency                  free text, matched against the name
+//	tag:prod                     field match
+//	title:"api latency"          phrase
+//	ds_type:prom*                wildcard (`*` and `?`)
+//	-folder:Sandbox              negation (also `NOT folder:Sandbox`)
+//	tag:prod AND (kind:panel OR panel_type:timeseries)
+//
+// Terms next to each other are implicitly combined with AND. Only the fields below are
+// recognized, so `SLO: api` is free text.
+
+// queryFields maps the field names accepted in the query language to the index fields.
+var queryFields = map[string]string{
+	"tag":         documentFieldTag,
+	"tags":        documentFieldTag,
+	"kind":        documentFieldKind,
+	"uid":         documentFieldUID,
+	"title":       documentFieldName,
+	"name":        documentFieldName,
+	"folder":      documentFieldLocation,
+	"location":    documentFieldLocation,
+	"panel_type":  documentFieldPanelType,
+	"transformer": documentFieldTransformer,
+	"ds_uid":      documentFieldDSUID,
+	"ds_type":     documentFieldDSType,
+}
+
+// QueryParseError is returned when the search query can not be parsed.
+type QueryParseError struct {
+	Pos int // byte offset in the query
+	Msg string
+}
+
+func (e *QueryParseError) Error() string {
+	return fmt.Sprintf("invalid search query at position %d: %s", e.Pos, e.Msg)
+}
+
+type queryNode interface {
+	String() string
+}
+
+type queryTermNode struct {
+	field    string // query language field name, empty for free text
+	value    string
+	phrase   bool
+	wildcard bool
+}
+
+type queryNotNode struct {
+	child queryNode
+}
+
+type queryAndNode struct {
+	children []queryNode
+}
+
+type queryOrNode struct {
+	children []queryNode
+}
+
+func (n *queryTermNode) String() string {
+	v := n.value
+	if n.phrase {
+		v = fmt.Sprintf("%q", v)
+	}
+	if n.field != "" {
+		return n.field + ":" + v
+	}
+	return v
+}
+
+func (n *queryNotNode) String() string {
+	return "NOT " + n.child.String()
+}
+
+func (n *queryAndNode) String() string {
+	return joinQueryNodes(n.children, " AND ")
+}
+
+func (n *queryOrNode) String() string {
+	return joinQueryNodes(n.children, " OR ")
+}
+
+func joinQueryNodes(nodes []queryNode, sep string) string {
+	parts := make([]string, 0, len(nodes))
+	for _, c := range nodes {
+		parts = append(parts, c.String())
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+type queryTokenType int
+
+const (
+	queryTokenEOF queryTokenType = iota
+	queryTokenWord
+	queryTokenPhrase
+	queryTokenField
+	queryTokenLParen
+	queryTokenRParen
+	queryTokenMinus
+	queryTokenAnd
+	queryTokenOr
+	queryTokenNot
+)
+
+type queryToken struct {
+	typ queryTokenType
+	val string
+	pos int
+}
+
+func tokenizeSearchQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	i := 0
+	for i < len(input) {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, queryToken{typ: queryTokenLParen, pos: i})
+			i += size
+		case r == ')':
+			tokens = append(tokens, queryToken{typ: queryTokenRParen, pos: i})
+			i += size
+		case r == '-' && (i == 0 || isQueryTermBoundary(input[i-1])) && i+1 < len(input) && !isQueryTermBoundary(input[i+1]):
+			tokens = append(tokens, queryToken{typ: queryTokenMinus, pos: i})
+			i += size
+		case r == '"':
+			val, next, err := readQueryPhrase(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, queryToken{typ: queryTokenPhrase, val: val, pos: i})
+			i = next
+		default:
+			start := i
+			for i < len(input) {
+				r, size = utf8.DecodeRuneInString(input[i:])
+				if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' {
+					break
+				}
+				if r == ':' && isQueryField(input[start:i]) {
+					break
+				}
+				i += size
+			}
+			word := input[start:i]
+			if i < len(input) && input[i] == ':' {
+				tokens = append(tokens, queryToken{typ: queryTokenField, val: word, pos: start})
+				i++
+				continue
+			}
+			switch word {
+			case "AND":
+				tokens = append(tokens, queryToken{typ: queryTokenAnd, pos: start})
+			case "OR":
+				tokens = append(tokens, queryToken{typ: queryTokenOr, pos: start})
+			case "NOT":
+				tokens = append(tokens, queryToken{typ: queryTokenNot, pos: start})
+			default:
+				tokens = append(tokens, queryToken{typ: queryTokenWord, val: word, pos: start})
+			}
+		}
+	}
+	return append(tokens, queryToken{typ: queryTokenEOF, pos: len(input)}), nil
+}
+
+func isQueryTermBoundary(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '('
+}
+
+// isQueryField reports whether s is a field of the query language, i.e. `tag` in `tag:prod`.
+// Anything else followed by a colon (for example `12:30` or `SLO:`) is treated as free text.
+func isQueryField(s string) bool {
+	_, ok := queryFields[s]
+	return ok
+}
+
+func readQueryPhrase(input string, start int) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if i+1 < len(input) {
+				i++
+				sb.WriteByte(input[i])
+			}
+		case '"':
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(input[i])
+		}
+	}
+	return "", 0, &QueryParseError{Pos: start, Msg: "unterminated quoted phrase"}
+}
+
+type searchQueryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// parseSearchQuery parses the search query language into a query tree.
+// An empty query returns a nil node.
+func parseSearchQuery(input string) (queryNode, error) {
+	tokens, err := tokenizeSearchQuery(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &searchQueryParser{tokens: tokens}
+	if p.peek().typ == queryTokenEOF {
+		return nil, nil
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.typ != queryTokenEOF {
+		if t.typ == queryTokenRParen {
+			return nil, &QueryParseError{Pos: t.pos, Msg: "unexpected closing parenthesis"}
+		}
+		return nil, &QueryParseError{Pos: t.pos, Msg: "unexpected input"}
+	}
+	return node, nil
+}
+
+func (p *searchQueryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *searchQueryParser) next() queryToken {
+	t := p.tokens[p.pos]
+	if t.typ != queryTokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *searchQueryParser) parseOr() (queryNode, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []queryNode{first}
+	for p.peek().typ == queryTokenOr {
+		p.next()
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, n)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &queryOrNode{children: children}, nil
+}
+
+func (p *searchQueryParser) parseAnd() (queryNode, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	children := []queryNode{first}
+	for {
+		t := p.peek()
+		if t.typ == queryTokenAnd {
+			p.next()
+		} else if t.typ == queryTokenEOF || t.typ == queryTokenOr || t.typ == queryTokenRParen {
+			break
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, n)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &queryAndNode{children: children}, nil
+}
+
+func (p *searchQueryParser) parseUnary() (queryNode, error) {
+	t := p.peek()
+	if t.typ == queryTokenMinus || t.typ == queryTokenNot {
+		p.next()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNotNode{child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *searchQueryParser) parsePrimary() (queryNode, error) {
+	t := p.next()
+	switch t.typ {
+	case queryTokenLParen:
+		if p.peek().typ == queryTokenRParen {
+			return nil, &QueryParseError{Pos: t.pos, Msg: "empty parentheses"}
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.typ != queryTokenRParen {
+			return nil, &QueryParseError{Pos: t.pos, Msg: "missing closing parenthesis"}
+		}
+		return n, nil
+	case queryTokenField:
+		v := p.next()
+		switch v.typ {
+		case queryTokenWord:
+			return newQueryTermNode(t.val, v.val, false), nil
+		case queryTokenPhrase:
+			return newQueryTermNode(t.val, v.val, true), nil
+		default:
+			return nil, &QueryParseError{Pos: t.pos, Msg: fmt.Sprintf("missing value for field %q", t.val)}
+		}
+	case queryTokenWord:
+		return newQueryTermNode("", t.val, false), nil
+	case queryTokenPhrase:
+		return newQueryTermNode("", t.val, true), nil
+	case queryTokenEOF:
+		return nil, &QueryParseError{Pos: t.pos, Msg: "unexpected end of query"}
+	case queryTokenRParen:
+		return nil, &QueryParseError{Pos: t.pos, Msg: "unexpected closing parenthesis"}
+	default:
+		return nil, &QueryParseError{Pos: t.pos, Msg: "unexpected operator"}
+	}
+}
+
+func newQueryTermNode(field, value string, phrase bool) *queryTermNode {
+	return &queryTermNode{
+		field:    field,
+		value:    value,
+		phrase:   phrase,
+		wildcard: !phrase && strings.ContainsAny(value, "*?"),
+	}
+}
+
+// isFreeTextQuery reports whether the query only contains plain words, in which case
+// it is handled exactly like a query without any query language features.
+func isFreeTextQuery(node queryNode) bool {
+	switch n := node.(type) {
+	case *queryTermNode:
+		return n.field == "" && !n.phrase && !n.wildcard
+	case *queryAndNode:
+		for _, c := range n.children {
+			if !isFreeTextQuery(c) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// folderLookup returns the UIDs of the folders matching a name or UID.
+type folderLookup func(nameOrUID string, wildcard bool) []string
+
+type searchQueryBuilder struct {
+	folders folderLookup
+}
+
+// toBlugeQuery converts the parsed query into a bluge query. Free text terms that are
+// directly combined with AND are searched together, so `api latency tag:prod` behaves
+// like searching for "api latency" within the dashboards tagged "prod".
+func (b *searchQueryBuilder) toBlugeQuery(node queryNode) bluge.Query {
+	switch n := node.(type) {
+	case *queryAndNode:
+		bq := bluge.NewBooleanQuery()
+		var text []string
+		for _, c := range n.children {
+			if t, ok := c.(*queryTermNode); ok && t.field == "" && !t.phrase && !t.wildcard {
+				text = append(text, t.value)
+				continue
+			}
+			bq.AddMust(b.toBlugeQuery(c))
+		}
+		if len(text) > 0 {
+			bq.AddMust(b.textQuery(strings.Join(text, " ")))
+		}
+		return bq
+	case *queryOrNode:
+		bq := bluge.NewBooleanQuery()
+		for _, c := range n.children {
+			bq.AddShould(b.toBlugeQuery(c))
+		}
+		return bq
+	case *queryNotNode:
+		return bluge.NewBooleanQuery().
+			AddMust(bluge.NewMatchAllQuery()).
+			AddMustNot(b.toBlugeQuery(n.child))
+	case *queryTermNode:
+		return b.termQuery(n)
+	}
+	return bluge.NewMatchNoneQuery()
+}
+
+func (b *searchQueryBuilder) textQuery(text string) bluge.Query {
+	bq := bluge.NewBooleanQuery()
+
+	bq.AddShould(NewSubstringQuery(formatForNameSortField(text)).
+		SetField(documentFieldName_sort).
+		SetBoost(6))
+
+	if shouldUseNgram(text) {
+		bq.AddShould(bluge.NewMatchQuery(text).
+			SetField(documentFieldName_ngram).
+			SetOperator(bluge.MatchQueryOperatorAnd). // all terms must match
+			SetAnalyzer(ngramQueryAnalyzer).SetBoost(1))
+	}
+	return bq
+}
+
+func (b *searchQueryBuilder) termQuery(n *queryTermNode) bluge.Query {
+	field := queryFields[n.field]
+	switch field {
+	case "", documentFieldName:
+		switch {
+		case n.phrase:
+			return bluge.NewMatchPhraseQuery(n.value).SetField(documentFieldName)
+		case n.wildcard:
+			return bluge.NewWildcardQuery(formatForNameSortField(n.value)).SetField(documentFieldName_sort)
+		}
+		return b.textQuery(n.value)
+	case documentFieldLocation:
+		return b.folderQuery(n)
+	}
+	if n.wildcard {
+		return bluge.NewWildcardQuery(n.value).SetField(field)
+	}
+	return bluge.NewTermQuery(n.value).SetField(field)
+}
+
+// folderQuery matches everything located in a folder, either directly (dashboards) or
+// nested below a dashboard (panels). The folder can be referenced by its name or UID.
+func (b *searchQueryBuilder) folderQuery(n *queryTermNode) bluge.Query {
+	uids := []string{n.value}
+	if b.folders != nil {
+		uids = append(uids, b.folders(n.value, n.wildcard)...)
+	}
+
+	bq := bluge.NewBooleanQuery()
+	seen := make(map[string]bool, len(uids))
+	for _, uid := range uids {
+		if seen[uid] {
+			continue
+		}
+		seen[uid] = true
+		bq.AddShould(bluge.NewTermQuery(uid).SetField(documentFieldLocation))
+		bq.AddShould(bluge.NewPrefixQuery(uid + "/").SetField(documentFieldLocation))
+	}
+	return bq
+}
@@ -0,0 +1,170 @@
+package searchV2
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "", expected: "<nil>"},
+		{query: "boom", expected: "boom"},
+		{query: "api latency", expected: "(api AND latency)"},
+		{query: "tag:prod", expected: "tag:prod"},
+		{query: `title:"api latency"`, expected: `title:"api latency"`},
+		{query: "-folder:Sandbox", expected: "NOT folder:Sandbox"},
+		{query: "NOT tag:dev", expected: "NOT tag:dev"},
+		{query: "tag:a OR tag:b", expected: "(tag:a OR tag:b)"},
+		{query: "tag:a tag:b OR tag:c", expected: "((tag:a AND tag:b) OR tag:c)"},
+		{query: "tag:a AND (kind:panel OR panel_type:timeseries)", expected: "(tag:a AND (kind:panel OR panel_type:timeseries))"},
+		{
+			query:    `tag:prod AND panel_type:timeseries -folder:Sandbox ds_type:prometheus title:"api latency"`,
+			expected: `(tag:prod AND panel_type:timeseries AND NOT folder:Sandbox AND ds_type:prometheus AND title:"api latency")`,
+		},
+		{query: "my-dash 12:30", expected: "(my-dash AND 12:30)"},
+		{query: "cpu -", expected: "(cpu AND -)"},
+		{query: "SLO: api", expected: "(SLO: AND api)"},
+		{query: "Team A: prod", expected: "(Team AND A: AND prod)"},
+		{query: "unknown:x", expected: "unknown:x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := parseSearchQuery(tt.query)
+			require.NoError(t, err)
+			if node == nil {
+				require.Equal(t, tt.expected, "<nil>")
+				return
+			}
+			require.Equal(t, tt.expected, node.String())
+		})
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{query: `title:"api`, pos: 6, msg: "unterminated quoted phrase"},
+		{query: "tag:", pos: 0, msg: `missing value for field "tag"`},
+		{query: "(tag:a", pos: 0, msg: "missing closing parenthesis"},
+		{query: "tag:a)", pos: 5, msg: "unexpected closing parenthesis"},
+		{query: "tag:a OR", pos: 8, msg: "unexpected end of query"},
+		{query: "()", pos: 0, msg: "empty parentheses"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := parseSearchQuery(tt.query)
+			var parseErr *QueryParseError
+			require.True(t, errors.As(err, &parseErr), "expected parse error, got %v", err)
+			require.Equal(t, tt.pos, parseErr.Pos)
+			require.Equal(t, tt.msg, parseErr.Msg)
+		})
+	}
+}
+
+var dashboardsForQueryLanguage = []dashboard{
+	{
+		id:       1,
+		uid:      "sandbox",
+		isFolder: true,
+		summary:  &entity.EntitySummary{Name: "Sandbox"},
+	},
+	{
+		id:  2,
+		uid: "api",
+		summary: &entity.EntitySummary{
+			Name:   "API latency",
+			Labels: map[string]string{"prod": ""},
+			References: []*entity.EntityExternalReference{
+				{Family: entity.StandardKindDataSource, Type: "prometheus", Identifier: "prom1"},
+			},
+		},
+	},
+	{
+		id:       3,
+		uid:      "api-sandbox",
+		folderID: 1,
+		summary: &entity.EntitySummary{
+			Name:   "API latency (copy)",
+			Labels: map[string]string{"prod": ""},
+		},
+	},
+	{
+		id:      5,
+		uid:     "slo",
+		summary: &entity.EntitySummary{Name: "SLO: api availability"},
+	},
+	{
+		id:  4,
+		uid: "db",
+		summary: &entity.EntitySummary{
+			Name:   "Database overview",
+			Labels: map[string]string{"dev": ""},
+			References: []*entity.EntityExternalReference{
+				{Family: entity.StandardKindDataSource, Type: "mysql", Identifier: "mysql1"},
+			},
+		},
+	},
+}
+
+func TestDashboardIndex_QueryLanguage(t *testing.T) {
+	index := initTestOrgIndexFromDashes(t, dashboardsForQueryLanguage)
+
+	search := func(t *testing.T, query string) []string {
+		t.Helper()
+		resp := doSearchQuery(context.Background(), testLogger, index, testAllowAllFilter,
+			DashboardQuery{Query: query, Kind: []string{string(entityKindDashboard)}, Sort: documentFieldName_sort},
+			&NoopQueryExtender{}, "")
+		require.NoError(t, resp.Error)
+		field, _ := resp.Frames[0].FieldByName("uid")
+		uids := make([]string, 0, field.Len())
+		for i := 0; i < field.Len(); i++ {
+			uids = append(uids, field.At(i).(string))
+		}
+		return uids
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{query: "tag:prod", expected: []string{"api", "api-sandbox"}},
+		{query: "tag:prod -folder:Sandbox", expected: []string{"api"}},
+		{query: "tag:prod NOT folder:sandbox", expected: []string{"api"}},
+		{query: "folder:sand*", expected: []string{"api-sandbox"}},
+		{query: "ds_type:prom*", expected: []string{"api"}},
+		{query: "ds_uid:mysql1 OR ds_type:prometheus", expected: []string{"api", "db"}},
+		{query: `title:"api latency"`, expected: []string{"api", "api-sandbox"}},
+		{query: "title:data*", expected: []string{"db"}},
+		{query: "latency -tag:dev", expected: []string{"api", "api-sandbox"}},
+		{query: "tag:dev OR (tag:prod ds_type:prometheus)", expected: []string{"api", "db"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			require.Equal(t, tt.expected, search(t, tt.query))
+		})
+	}
+
+	t.Run("unknown fields are free text", func(t *testing.T) {
+		require.Equal(t, []string{"slo"}, search(t, "SLO: api"))
+	})
+
+	t.Run("invalid queries are searched as free text", func(t *testing.T) {
+		require.Equal(t, []string{"slo"}, search(t, "SLO: api)"))
+		require.Empty(t, search(t, "tag:prod AND"))
+		require.Empty(t, search(t, `title:"api`))
+	})
+}
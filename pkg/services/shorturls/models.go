@@ -7,21 +7,39 @@ import (
 )
 
 var (
-	ErrShortURLBadRequest   = errutil.BadRequest("shorturl.bad-request")
-	ErrShortURLNotFound     = errutil.NotFound("shorturl.not-found")
-	ErrShortURLAbsolutePath = errutil.ValidationFailed("shorturl.absolute-path", errutil.WithPublicMessage("Path should be relative"))
-	ErrShortURLInvalidPath  = errutil.ValidationFailed("shorturl.invalid-path", errutil.WithPublicMessage("Invalid short URL path"))
-	ErrShortURLInternal     = errutil.Internal("shorturl.internal")
+	ErrShortURLBadRequest    = errutil.BadRequest("shorturl.bad-request")
+	ErrShortURLNotFound      = errutil.NotFound("shorturl.not-found")
+	ErrShortURLAbsolutePath  = errutil.ValidationFailed("shorturl.absolute-path", errutil.WithPublicMessage("Path should be relative"))
+	ErrShortURLInvalidPath   = errutil.ValidationFailed("shorturl.invalid-path", errutil.WithPublicMessage("Invalid short URL path"))
+	ErrShortURLInvalidUID    = errutil.ValidationFailed("shorturl.invalid-uid", errutil.WithPublicMessage("Short URL slug can only contain letters, numbers, dashes and underscores, and be at most 40 characters long"))
+	ErrShortURLUIDTaken      = errutil.ValidationFailed("shorturl.uid-taken", errutil.WithPublicMessage("Short URL slug is already in use"))
+	ErrShortURLInvalidExpiry = errutil.ValidationFailed("shorturl.invalid-expiry", errutil.WithPublicMessage("Short URL expiry should be in the future"))
+	ErrShortURLForbidden     = errutil.Forbidden("shorturl.forbidden", errutil.WithPublicMessage("Only the creator of a short URL or an organization admin can delete it"))
+	ErrShortURLInternal      = errutil.Internal("shorturl.internal")
 )
 
 type ShortUrl struct {
-	Id         int64
-	OrgId      int64
-	Uid        string
-	Path       string
-	CreatedBy  int64
-	CreatedAt  int64
-	LastSeenAt int64
+	Id          int64
+	OrgId       int64
+	Uid         string
+	Path        string
+	CreatedBy   int64
+	CreatedAt   int64
+	LastSeenAt  int64
+	ExpiresAt   int64 // unix seconds, 0 if the short URL never expires
+	AccessCount int64
+}
+
+// IsExpired returns true if the short URL has an expiry and it has passed
+func (s ShortUrl) IsExpired(now time.Time) bool {
+	return s.ExpiresAt > 0 && now.Unix() >= s.ExpiresAt
+}
+
+type CreateShortURLCommand struct {
+	Path string
+	// UID is the custom slug of the short URL, a random one is generated when empty
+	UID       string
+	ExpiresAt int64
 }
 
 type DeleteShortUrlCommand struct {
@@ -29,3 +47,23 @@ type DeleteShortUrlCommand struct {
 
 	NumDeleted int64
 }
+
+type DeleteShortURLByUIDCommand struct {
+	OrgID int64
+	UID   string
+}
+
+type SearchShortURLsQuery struct {
+	OrgID int64
+	// CreatedBy only returns the short URLs created by this user when set
+	CreatedBy *int64
+	// Query matches the slug or the path of short URLs
+	Query string
+	Page  int
+	Limit int
+}
+
+type SearchShortURLsResult struct {
+	ShortURLs  []*ShortUrl
+	TotalCount int64
+}
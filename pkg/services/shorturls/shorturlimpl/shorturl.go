@@ -9,11 +9,17 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
 	"github.com/teris-io/shortid"
 )
 
 var getTime = time.Now
 
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
 type ShortURLService struct {
 	SQLStore store
 }
@@ -34,8 +40,8 @@ func (s ShortURLService) UpdateLastSeenAt(ctx context.Context, shortURL *shortur
 	return s.SQLStore.Update(ctx, shortURL)
 }
 
-func (s ShortURLService) CreateShortURL(ctx context.Context, user *user.SignedInUser, relPath string) (*shorturls.ShortUrl, error) {
-	relPath = strings.TrimSpace(relPath)
+func (s ShortURLService) CreateShortURL(ctx context.Context, user *user.SignedInUser, cmd *shorturls.CreateShortURLCommand) (*shorturls.ShortUrl, error) {
+	relPath := strings.TrimSpace(cmd.Path)
 
 	if path.IsAbs(relPath) {
 		return nil, shorturls.ErrShortURLAbsolutePath.Errorf("expected relative path: %s", relPath)
@@ -44,27 +50,68 @@ func (s ShortURLService) CreateShortURL(ctx context.Context, user *user.SignedIn
 		return nil, shorturls.ErrShortURLInvalidPath.Errorf("path cannot contain '../': %s", relPath)
 	}
 
-	uid, err := shortid.Generate()
-	if err != nil {
-		return nil, shorturls.ErrShortURLInternal.Errorf("failed to generate uid: %w", err)
+	now := getTime().Unix()
+	if cmd.ExpiresAt < 0 || (cmd.ExpiresAt > 0 && cmd.ExpiresAt <= now) {
+		return nil, shorturls.ErrShortURLInvalidExpiry.Errorf("expiry is not in the future: %d", cmd.ExpiresAt)
+	}
+
+	uid := strings.TrimSpace(cmd.UID)
+	if uid != "" {
+		if !util.IsValidShortUID(uid) || util.IsShortUIDTooLong(uid) {
+			return nil, shorturls.ErrShortURLInvalidUID.Errorf("invalid uid: %s", uid)
+		}
+		if _, err := s.SQLStore.Get(ctx, user, uid); err == nil {
+			return nil, shorturls.ErrShortURLUIDTaken.Errorf("uid already exists: %s", uid)
+		} else if !shorturls.ErrShortURLNotFound.Is(err) {
+			return nil, shorturls.ErrShortURLInternal.Errorf("failed to look up uid: %w", err)
+		}
+	} else {
+		generated, err := shortid.Generate()
+		if err != nil {
+			return nil, shorturls.ErrShortURLInternal.Errorf("failed to generate uid: %w", err)
+		}
+		uid = generated
 	}
 
-	now := time.Now().Unix()
 	shortURL := shorturls.ShortUrl{
 		OrgId:     user.OrgID,
 		Uid:       uid,
 		Path:      relPath,
 		CreatedBy: user.UserID,
 		CreatedAt: now,
+		ExpiresAt: cmd.ExpiresAt,
 	}
 
 	if err := s.SQLStore.Insert(ctx, &shortURL); err != nil {
+		// a short URL with the same slug can be created concurrently
+		if shorturls.ErrShortURLUIDTaken.Is(err) {
+			return nil, err
+		}
 		return nil, shorturls.ErrShortURLInternal.Errorf("failed to insert shorturl: %w", err)
 	}
 
 	return &shortURL, nil
 }
 
+func (s ShortURLService) SearchShortURLs(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	return s.SQLStore.Search(ctx, query)
+}
+
+func (s ShortURLService) DeleteShortURL(ctx context.Context, cmd *shorturls.DeleteShortURLByUIDCommand) error {
+	return s.SQLStore.DeleteByUID(ctx, cmd)
+}
+
+// DeleteStaleShortURLs deletes the short URLs that were never visited and are older than the
+// command's time, and the short URLs that expired
 func (s ShortURLService) DeleteStaleShortURLs(ctx context.Context, cmd *shorturls.DeleteShortUrlCommand) error {
 	return s.SQLStore.Delete(ctx, cmd)
 }
@@ -13,6 +13,7 @@ import (
 )
 
 func TestShortURLService(t *testing.T) {
+	otherUser := &user.SignedInUser{UserID: 2}
 	user := &user.SignedInUser{UserID: 1}
 	store := db.InitTestDB(t)
 
@@ -21,7 +22,7 @@ func TestShortURLService(t *testing.T) {
 
 		service := ShortURLService{SQLStore: &sqlStore{db: store}}
 
-		newShortURL, err := service.CreateShortURL(context.Background(), user, refPath)
+		newShortURL, err := service.CreateShortURL(context.Background(), user, &shorturls.CreateShortURLCommand{Path: refPath})
 		require.NoError(t, err)
 		require.NotNil(t, newShortURL)
 		require.NotEmpty(t, newShortURL.Uid)
@@ -48,10 +49,18 @@ func TestShortURLService(t *testing.T) {
 			updatedShortURL, err := service.GetShortURLByUID(context.Background(), user, existingShortURL.Uid)
 			require.NoError(t, err)
 			require.Equal(t, expectedTime.Unix(), updatedShortURL.LastSeenAt)
+			require.Equal(t, int64(1), updatedShortURL.AccessCount)
+
+			err = service.UpdateLastSeenAt(context.Background(), updatedShortURL)
+			require.NoError(t, err)
+
+			updatedShortURL, err = service.GetShortURLByUID(context.Background(), user, existingShortURL.Uid)
+			require.NoError(t, err)
+			require.Equal(t, int64(2), updatedShortURL.AccessCount)
 		})
 
 		t.Run("and stale short urls can be deleted", func(t *testing.T) {
-			staleShortURL, err := service.CreateShortURL(context.Background(), user, refPath)
+			staleShortURL, err := service.CreateShortURL(context.Background(), user, &shorturls.CreateShortURLCommand{Path: refPath})
 			require.NoError(t, err)
 			require.NotNil(t, staleShortURL)
 			require.NotEmpty(t, staleShortURL.Uid)
@@ -76,6 +85,92 @@ func TestShortURLService(t *testing.T) {
 		})
 	})
 
+	t.Run("User can create short URLs with a custom slug", func(t *testing.T) {
+		service := ShortURLService{SQLStore: &sqlStore{db: store}}
+
+		shortURL, err := service.CreateShortURL(context.Background(), user, &shorturls.CreateShortURLCommand{Path: "d/abc", UID: "my-dashboard"})
+		require.NoError(t, err)
+		require.Equal(t, "my-dashboard", shortURL.Uid)
+
+		t.Run("but not when the slug is taken", func(t *testing.T) {
+			_, err := service.CreateShortURL(context.Background(), user, &shorturls.CreateShortURLCommand{Path: "d/def", UID: "my-dashboard"})
+			require.True(t, shorturls.ErrShortURLUIDTaken.Is(err))
+		})
+
+		t.Run("but not when the slug was taken after it was looked up", func(t *testing.T) {
+			err := service.SQLStore.Insert(context.Background(), &shorturls.ShortUrl{OrgId: user.OrgID, Uid: "my-dashboard", Path: "d/def"})
+			require.True(t, shorturls.ErrShortURLUIDTaken.Is(err))
+		})
+
+		t.Run("but not when the slug is invalid", func(t *testing.T) {
+			_, err := service.CreateShortURL(context.Background(), user, &shorturls.CreateShortURLCommand{Path: "d/def", UID: "my/dashboard"})
+			require.True(t, shorturls.ErrShortURLInvalidUID.Is(err))
+		})
+	})
+
+	t.Run("Expired short URLs are deleted", func(t *testing.T) {
+		origGetTime := getTime
+		t.Cleanup(func() {
+			getTime = origGetTime
+		})
+		now := time.Now()
+		getTime = func() time.Time { return now }
+
+		service := ShortURLService{SQLStore: &sqlStore{db: store}}
+
+		_, err := service.CreateShortURL(context.Background(), user, &shorturls.CreateShortURLCommand{Path: "d/abc", ExpiresAt: now.Add(-time.Minute).Unix()})
+		require.True(t, shorturls.ErrShortURLInvalidExpiry.Is(err))
+
+		expiring, err := service.CreateShortURL(context.Background(), user, &shorturls.CreateShortURLCommand{Path: "d/abc", ExpiresAt: now.Add(time.Hour).Unix()})
+		require.NoError(t, err)
+		require.False(t, expiring.IsExpired(now))
+
+		// never visited short URLs with an expiry are kept until they expire
+		cmd := shorturls.DeleteShortUrlCommand{OlderThan: now.Add(time.Minute)}
+		require.NoError(t, service.DeleteStaleShortURLs(context.Background(), &cmd))
+		_, err = service.GetShortURLByUID(context.Background(), user, expiring.Uid)
+		require.NoError(t, err)
+
+		getTime = func() time.Time { return now.Add(2 * time.Hour) }
+		require.True(t, expiring.IsExpired(getTime()))
+		cmd = shorturls.DeleteShortUrlCommand{OlderThan: now.Add(-time.Hour)}
+		require.NoError(t, service.DeleteStaleShortURLs(context.Background(), &cmd))
+		require.Equal(t, int64(1), cmd.NumDeleted)
+		_, err = service.GetShortURLByUID(context.Background(), user, expiring.Uid)
+		require.True(t, shorturls.ErrShortURLNotFound.Is(err))
+	})
+
+	t.Run("Short URLs can be searched and deleted", func(t *testing.T) {
+		searchStore := db.InitTestDB(t)
+		service := ShortURLService{SQLStore: &sqlStore{db: searchStore}}
+
+		for _, uid := range []string{"team-a", "team-b"} {
+			_, err := service.CreateShortURL(context.Background(), user, &shorturls.CreateShortURLCommand{Path: "d/" + uid, UID: uid})
+			require.NoError(t, err)
+		}
+		_, err := service.CreateShortURL(context.Background(), otherUser, &shorturls.CreateShortURLCommand{Path: "d/other", UID: "other"})
+		require.NoError(t, err)
+
+		result, err := service.SearchShortURLs(context.Background(), &shorturls.SearchShortURLsQuery{CreatedBy: &user.UserID})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), result.TotalCount)
+
+		result, err = service.SearchShortURLs(context.Background(), &shorturls.SearchShortURLsQuery{Query: "team-b"})
+		require.NoError(t, err)
+		require.Len(t, result.ShortURLs, 1)
+		require.Equal(t, "team-b", result.ShortURLs[0].Uid)
+
+		result, err = service.SearchShortURLs(context.Background(), &shorturls.SearchShortURLsQuery{Limit: 2, Page: 2})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.ShortURLs, 1)
+
+		err = service.DeleteShortURL(context.Background(), &shorturls.DeleteShortURLByUIDCommand{UID: "other"})
+		require.NoError(t, err)
+		err = service.DeleteShortURL(context.Background(), &shorturls.DeleteShortURLByUIDCommand{UID: "other"})
+		require.True(t, shorturls.ErrShortURLNotFound.Is(err))
+	})
+
 	t.Run("User cannot look up nonexistent short URLs", func(t *testing.T) {
 		service := ShortURLService{SQLStore: &sqlStore{db: store}}
 
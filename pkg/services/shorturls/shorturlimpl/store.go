@@ -13,6 +13,8 @@ type store interface {
 	Update(ctx context.Context, shortURL *shorturls.ShortUrl) error
 	Insert(ctx context.Context, shortURL *shorturls.ShortUrl) error
 	Delete(ctx context.Context, cmd *shorturls.DeleteShortUrlCommand) error
+	DeleteByUID(ctx context.Context, cmd *shorturls.DeleteShortURLByUIDCommand) error
+	Search(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error)
 }
 
 type sqlStore struct {
@@ -39,13 +41,16 @@ func (s sqlStore) Get(ctx context.Context, user *user.SignedInUser, uid string)
 	return &shortURL, nil
 }
 
+// Update records a visit of the short URL
 func (s sqlStore) Update(ctx context.Context, shortURL *shorturls.ShortUrl) error {
 	shortURL.LastSeenAt = getTime().Unix()
 	return s.db.WithTransactionalDbSession(ctx, func(dbSession *db.Session) error {
-		_, err := dbSession.ID(shortURL.Id).Update(shortURL)
+		// the count is incremented in the database so that concurrent visits are all counted
+		_, err := dbSession.Exec("UPDATE short_url SET last_seen_at = ?, access_count = access_count + 1 WHERE id = ?", shortURL.LastSeenAt, shortURL.Id)
 		if err != nil {
 			return err
 		}
+		shortURL.AccessCount++
 		return nil
 	})
 }
@@ -53,15 +58,19 @@ func (s sqlStore) Update(ctx context.Context, shortURL *shorturls.ShortUrl) erro
 func (s sqlStore) Insert(ctx context.Context, shortURL *shorturls.ShortUrl) error {
 	return s.db.WithDbSession(ctx, func(session *db.Session) error {
 		_, err := session.Insert(shortURL)
+		if err != nil && s.db.GetDialect().IsUniqueConstraintViolation(err) {
+			return shorturls.ErrShortURLUIDTaken.Errorf("uid already exists: %s", shortURL.Uid)
+		}
 		return err
 	})
 }
 
 func (s sqlStore) Delete(ctx context.Context, cmd *shorturls.DeleteShortUrlCommand) error {
 	return s.db.WithTransactionalDbSession(ctx, func(session *db.Session) error {
-		var rawSql = "DELETE FROM short_url WHERE created_at <= ? AND (last_seen_at IS NULL OR last_seen_at = 0)"
+		// short URLs with an expiry are kept until they expire, even if they were never visited
+		var rawSql = "DELETE FROM short_url WHERE (expires_at = 0 AND created_at <= ? AND (last_seen_at IS NULL OR last_seen_at = 0)) OR (expires_at > 0 AND expires_at <= ?)"
 
-		if result, err := session.Exec(rawSql, cmd.OlderThan.Unix()); err != nil {
+		if result, err := session.Exec(rawSql, cmd.OlderThan.Unix(), getTime().Unix()); err != nil {
 			return err
 		} else if cmd.NumDeleted, err = result.RowsAffected(); err != nil {
 			return err
@@ -69,3 +78,50 @@ func (s sqlStore) Delete(ctx context.Context, cmd *shorturls.DeleteShortUrlComma
 		return nil
 	})
 }
+
+func (s sqlStore) DeleteByUID(ctx context.Context, cmd *shorturls.DeleteShortURLByUIDCommand) error {
+	return s.db.WithTransactionalDbSession(ctx, func(session *db.Session) error {
+		result, err := session.Exec("DELETE FROM short_url WHERE org_id = ? AND uid = ?", cmd.OrgID, cmd.UID)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return shorturls.ErrShortURLNotFound.Errorf("short URL not found")
+		}
+		return nil
+	})
+}
+
+func (s sqlStore) Search(ctx context.Context, query *shorturls.SearchShortURLsQuery) (*shorturls.SearchShortURLsResult, error) {
+	result := &shorturls.SearchShortURLsResult{ShortURLs: make([]*shorturls.ShortUrl, 0)}
+
+	where := "org_id = ?"
+	args := []any{query.OrgID}
+	if query.CreatedBy != nil {
+		where += " AND created_by = ?"
+		args = append(args, *query.CreatedBy)
+	}
+	if query.Query != "" {
+		like := s.db.GetDialect().LikeStr()
+		where += " AND (uid " + like + " ? OR path " + like + " ?)"
+		args = append(args, "%"+query.Query+"%", "%"+query.Query+"%")
+	}
+
+	err := s.db.WithDbSession(ctx, func(session *db.Session) error {
+		count, err := session.Where(where, args...).Count(&shorturls.ShortUrl{})
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		offset := query.Limit * (query.Page - 1)
+		return session.Where(where, args...).Desc("created_at", "id").Limit(query.Limit, offset).Find(&result.ShortURLs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -183,6 +183,28 @@ func addAnnotationMig(mg *Migrator) {
 	mg.AddMigration("Increase tags column to length 4096", NewRawSQLMigration("").
 		Postgres("ALTER TABLE annotation ALTER COLUMN tags TYPE VARCHAR(4096);").
 		Mysql("ALTER TABLE annotation MODIFY tags VARCHAR(4096);"))
+
+	//
+	// Structured fields of the annotation data
+	//
+	annotationFieldTable := Table{
+		Name: "annotation_field",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "annotation_id", Type: DB_BigInt, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "key", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "value", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "value_num", Type: DB_Double, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"annotation_id"}, Type: IndexType},
+			{Cols: []string{"org_id", "key", "value"}, Type: IndexType},
+		},
+	}
+
+	mg.AddMigration("create annotation_field table", NewAddTableMigration(annotationFieldTable))
+	addTableIndicesMigrations(mg, "v1", annotationFieldTable)
 }
 
 type AddMakeRegionSingleRowMigration struct {
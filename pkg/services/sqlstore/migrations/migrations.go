@@ -111,6 +111,16 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 	dashboardFolderMigrations.AddDashboardFolderMigrations(mg)
 
 	ssosettings.AddMigration(mg)
+
+	addDashboardUsageMigrations(mg)
+
+	addScheduledReportMigrations(mg)
+
+	addAnnotationWebhookMigrations(mg)
+
+	addQueryLibraryMigrations(mg)
+
+	addDatasourceHealthMigrations(mg)
 }
 
 func addStarMigrations(mg *Migrator) {
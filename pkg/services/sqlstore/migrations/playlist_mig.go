@@ -41,6 +41,43 @@ func addPlaylistMigrations(mg *Migrator) {
 	mg.AddMigration("Add playlist column updated_at", NewAddColumnMigration(playlistV2(), &Column{
 		Name: "updated_at", Type: DB_BigInt, Nullable: false, Default: "0",
 	}))
+
+	// Per-item overrides of the playlist interval, time range and template variables
+	playlistItem := Table{Name: "playlist_item"}
+	mg.AddMigration("Add playlist_item column duration", NewAddColumnMigration(playlistItem, &Column{
+		Name: "duration", Type: DB_NVarchar, Length: 255, Nullable: true,
+	}))
+	mg.AddMigration("Add playlist_item column time_from", NewAddColumnMigration(playlistItem, &Column{
+		Name: "time_from", Type: DB_NVarchar, Length: 255, Nullable: true,
+	}))
+	mg.AddMigration("Add playlist_item column time_to", NewAddColumnMigration(playlistItem, &Column{
+		Name: "time_to", Type: DB_NVarchar, Length: 255, Nullable: true,
+	}))
+	mg.AddMigration("Add playlist_item column variables", NewAddColumnMigration(playlistItem, &Column{
+		Name: "variables", Type: DB_Text, Nullable: true,
+	}))
+
+	// Display devices poll for the playlist they should show
+	displayDeviceV1 := Table{
+		Name: "playlist_display_device",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 80, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "playlist_uid", Type: DB_NVarchar, Length: 80, Nullable: false},
+			{Name: "timezone", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "schedule", Type: DB_Text, Nullable: true},
+			{Name: "last_seen_at", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "created_at", Type: DB_BigInt, Nullable: false},
+			{Name: "updated_at", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+		},
+	}
+	mg.AddMigration("create playlist_display_device table", NewAddTableMigration(displayDeviceV1))
+	mg.AddMigration("add unique index playlist_display_device.org_id_uid", NewAddIndexMigration(displayDeviceV1, displayDeviceV1.Indices[0]))
 }
 
 func addPlaylistUIDMigration(mg *Migrator) {
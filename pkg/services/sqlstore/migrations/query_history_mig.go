@@ -29,4 +29,33 @@ func addQueryHistoryMigrations(mg *Migrator) {
 	mg.AddMigration("alter table query_history alter column created_by type to bigint", NewRawSQLMigration("").
 		Mysql("ALTER TABLE query_history MODIFY created_by BIGINT;").
 		Postgres("ALTER TABLE query_history ALTER COLUMN created_by TYPE BIGINT;"))
+
+	mg.AddMigration("add column origin to query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "origin", Type: DB_NVarchar, Length: 20, Nullable: false, Default: "'explore'",
+	}))
+	mg.AddMigration("add column status to query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "status", Type: DB_NVarchar, Length: 20, Nullable: false, Default: "''",
+	}))
+	mg.AddMigration("add column error to query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "error", Type: DB_Text, Nullable: true,
+	}))
+	mg.AddMigration("add column duration_ms to query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "duration_ms", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column row_count to query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "row_count", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column dashboard_uid to query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: true,
+	}))
+	mg.AddMigration("add column panel_id to query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "panel_id", Type: DB_BigInt, Nullable: false, Default: "0",
+	}))
+	mg.AddMigration("add column alert_rule_uid to query_history", NewAddColumnMigration(queryHistoryV1, &Column{
+		Name: "alert_rule_uid", Type: DB_NVarchar, Length: 40, Nullable: true,
+	}))
+
+	mg.AddMigration("add index query_history.org_id-created_at", NewAddIndexMigration(queryHistoryV1, &Index{
+		Cols: []string{"org_id", "created_at"},
+	}))
 }
@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addQueryLibraryMigrations(mg *Migrator) {
+	queryLibraryV1 := Table{
+		Name: "query_library",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "title", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "description", Type: DB_Text, Nullable: false},
+			{Name: "tags", Type: DB_Text, Nullable: true},
+			{Name: "datasource_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "datasource_type", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "model", Type: DB_MediumText, Nullable: false},
+			{Name: "visibility", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "team_id", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "version", Type: DB_BigInt, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+			{Name: "updated_by", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "datasource_type"}},
+			{Cols: []string{"org_id", "title"}},
+		},
+	}
+
+	mg.AddMigration("create query_library table v1", NewAddTableMigration(queryLibraryV1))
+	addTableIndicesMigrations(mg, "v1", queryLibraryV1)
+
+	queryLibraryTagV1 := Table{
+		Name: "query_library_tag",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "query_id", Type: DB_BigInt, Nullable: false},
+			{Name: "tag", Type: DB_NVarchar, Length: 50, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"query_id", "tag"}, Type: UniqueIndex},
+			{Cols: []string{"tag"}},
+		},
+	}
+
+	mg.AddMigration("create query_library_tag table v1", NewAddTableMigration(queryLibraryTagV1))
+	addTableIndicesMigrations(mg, "v1", queryLibraryTagV1)
+
+	queryLibraryVersionV1 := Table{
+		Name: "query_library_version",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "query_id", Type: DB_BigInt, Nullable: false},
+			{Name: "version", Type: DB_BigInt, Nullable: false},
+			{Name: "title", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "description", Type: DB_Text, Nullable: false},
+			{Name: "tags", Type: DB_Text, Nullable: true},
+			{Name: "datasource_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "datasource_type", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "model", Type: DB_MediumText, Nullable: false},
+			{Name: "message", Type: DB_Text, Nullable: false},
+			{Name: "created_by", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"query_id", "version"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create query_library_version table v1", NewAddTableMigration(queryLibraryVersionV1))
+	addTableIndicesMigrations(mg, "v1", queryLibraryVersionV1)
+}
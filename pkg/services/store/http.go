@@ -55,6 +55,11 @@ func (s *standardStorageService) RegisterHTTPRoutes(storageRoute routing.RouteRe
 	storageRoute.Post("/createFolder", reqGrafanaAdmin, routing.Wrap(s.doCreateFolder))
 	storageRoute.Post("/deleteFolder", reqGrafanaAdmin, routing.Wrap(s.doDeleteFolder))
 	storageRoute.Get("/config", reqGrafanaAdmin, routing.Wrap(s.getConfig))
+
+	// Git sync
+	storageRoute.Get("/sync/*", reqGrafanaAdmin, routing.Wrap(s.getSyncStatus))
+	storageRoute.Post("/sync/*", reqGrafanaAdmin, routing.Wrap(s.doSync))
+	storageRoute.Post("/resolve/*", reqGrafanaAdmin, routing.Wrap(s.doResolveConflicts))
 }
 
 func (s *standardStorageService) doWrite(c *contextmodel.ReqContext) response.Response {
@@ -283,3 +288,49 @@ func (s *standardStorageService) getConfig(c *contextmodel.ReqContext) response.
 	}
 	return response.JSON(200, roots)
 }
+
+func (s *standardStorageService) getSyncStatus(c *contextmodel.ReqContext) response.Response {
+	scope, path := getPathAndScope(c)
+	root, err := s.getGitRoot(c.SignedInUser, scope+"/"+path)
+	if err != nil {
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	}
+	return response.JSON(http.StatusOK, root.SyncStatus())
+}
+
+func (s *standardStorageService) doSync(c *contextmodel.ReqContext) response.Response {
+	scope, path := getPathAndScope(c)
+	root, err := s.getGitRoot(c.SignedInUser, scope+"/"+path)
+	if err != nil {
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	}
+	return gitSyncResponse(root, root.sync(c.Req.Context()))
+}
+
+func (s *standardStorageService) doResolveConflicts(c *contextmodel.ReqContext) response.Response {
+	scope, path := getPathAndScope(c)
+	root, err := s.getGitRoot(c.SignedInUser, scope+"/"+path)
+	if err != nil {
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	}
+
+	cmd := &ResolveGitConflictsCmd{}
+	if err := web.Bind(c.Req, cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.Strategy != GitConflictResolution_Ours && cmd.Strategy != GitConflictResolution_Theirs {
+		return response.Error(http.StatusBadRequest, "strategy must be one of: ours, theirs", nil)
+	}
+	return gitSyncResponse(root, root.resolveConflicts(c.Req.Context(), cmd.Strategy))
+}
+
+// gitSyncResponse returns the sync status, conflicts are returned with the status code 409.
+func gitSyncResponse(root *rootStorageGit, err error) response.Response {
+	switch {
+	case errors.Is(err, ErrGitSyncConflict):
+		return response.JSON(http.StatusConflict, root.SyncStatus())
+	case err != nil:
+		return response.Error(http.StatusInternalServerError, "git sync failed: "+err.Error(), err)
+	}
+	return response.JSON(http.StatusOK, root.SyncStatus())
+}
@@ -581,6 +581,19 @@ func (s *standardStorageService) write(ctx context.Context, user *user.SignedInU
 	return root.Write(ctx, req)
 }
 
+// getGitRoot returns the git storage at the given path.
+func (s *standardStorageService) getGitRoot(user *user.SignedInUser, path string) (*rootStorageGit, error) {
+	root, _ := s.tree.getRoot(getOrgId(user), path)
+	if root == nil {
+		return nil, ErrStorageNotFound
+	}
+	gitRoot, ok := root.(*rootStorageGit)
+	if !ok || gitRoot.repo == nil {
+		return nil, fmt.Errorf("%s is not a git storage", path)
+	}
+	return gitRoot, nil
+}
+
 type workflowInfo struct {
 	Type        WriteValueWorkflow `json:"value"` // value matches selectable value
 	Label       string             `json:"label"`
@@ -605,11 +618,14 @@ func (s *standardStorageService) getWorkflowOptions(ctx context.Context, user *u
 	meta := root.Meta()
 	if meta.Config.Type == rootStorageTypeGit && meta.Config.Git != nil {
 		cfg := meta.Config.Git
-		options.Workflows = append(options.Workflows, workflowInfo{
-			Type:        WriteValueWorkflow_PR,
-			Label:       "Create pull request",
-			Description: "Create a new upstream pull request",
-		})
+		// pull requests are created through the github API
+		if gitRoot, ok := root.(*rootStorageGit); ok && gitRoot.github != nil {
+			options.Workflows = append(options.Workflows, workflowInfo{
+				Type:        WriteValueWorkflow_PR,
+				Label:       "Create pull request",
+				Description: "Create a new upstream pull request",
+			})
+		}
 		if !cfg.RequirePullRequest {
 			options.Workflows = append(options.Workflows, workflowInfo{
 				Type:        WriteValueWorkflow_Push,
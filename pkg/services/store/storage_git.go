@@ -2,19 +2,27 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing/object"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"gocloud.dev/blob"
 
 	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"github.com/grafana/grafana/pkg/infra/slugify"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -27,10 +35,15 @@ type rootStorageGit struct {
 	settings *StorageGitConfig
 	repo     *git.Repository
 	root     string // repostitory root
+	branch   string
 
 	github *githubHelper
 	meta   RootStorageMeta
 	store  filestorage.FileStorage
+
+	mu       sync.Mutex   // guards the work tree
+	statusMu sync.RWMutex // guards the status, which is read without waiting for a sync to finish
+	status   GitSyncStatus
 }
 
 func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache string) *rootStorageGit {
@@ -58,6 +71,13 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 		})
 	}
 
+	if isGitPath(cfg.Root) {
+		meta.Notice = append(meta.Notice, data.Notice{
+			Severity: data.NoticeSeverityError,
+			Text:     "Invalid root folder: " + cfg.Root,
+		})
+	}
+
 	if len(localWorkCache) < 2 {
 		meta.Notice = append(meta.Notice, data.Notice{
 			Severity: data.NoticeSeverityError,
@@ -91,14 +111,9 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 	}
 
 	if meta.Notice == nil {
-		repo, err := git.PlainOpen(localWorkCache)
-		if errors.Is(err, git.ErrRepositoryNotExists) {
-			repo, err = git.PlainClone(localWorkCache, false, &git.CloneOptions{
-				URL:      cfg.Remote,
-				Progress: os.Stdout,
-				//Depth:    1,
-				//SingleBranch: true,
-			})
+		repo, err := openGitRepository(localWorkCache, cfg)
+		if err == nil {
+			s.branch, err = gitBranchName(repo, cfg)
 		}
 
 		if err != nil {
@@ -123,9 +138,11 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 					Text:     "Failed to initialize storage",
 				})
 			} else {
+				// files uploaded through the storage API must never end up in the git directory
+				gitDir := filestorage.Delimiter + gitDirName
 				s.store = filestorage.NewCdkBlobStorage(
 					grafanaStorageLogger,
-					bucket, "", nil)
+					bucket, "", filestorage.NewPathFilter(nil, nil, []string{gitDir + filestorage.Delimiter}, []string{gitDir}))
 
 				meta.Ready = true // exists!
 				s.root = p
@@ -169,7 +186,9 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 		// Try pulling after init
 		if s.repo != nil && !scfg.Disabled {
 			err = s.Sync()
-			if err != nil {
+			if errors.Is(err, ErrGitSyncConflict) {
+				grafanaStorageLogger.Warn("Local changes conflict with the remote branch", "prefix", scfg.Prefix, "branch", s.branch)
+			} else if err != nil {
 				meta.Notice = append(meta.Notice, data.Notice{
 					Severity: data.NoticeSeverityError,
 					Text:     "unable to pull: " + err.Error(),
@@ -185,10 +204,9 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 					ticker := time.NewTicker(t)
 					go func() {
 						for range ticker.C {
-							grafanaStorageLogger.Info("Try git pull", "branch", s.settings.Remote)
-							err = s.Sync()
-							if err != nil {
-								grafanaStorageLogger.Info("Error pulling", "error", err)
+							grafanaStorageLogger.Debug("Try git sync", "remote", s.settings.Remote, "branch", s.branch)
+							if err := s.Sync(); err != nil {
+								grafanaStorageLogger.Info("Error syncing", "error", err)
 							}
 						}
 					}()
@@ -202,179 +220,248 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 }
 
 func (s *rootStorageGit) Meta() RootStorageMeta {
-	return s.meta
+	meta := s.meta
+	if conflicts := len(s.SyncStatus().Conflicts); conflicts > 0 {
+		meta.Notice = append(meta.Notice[:len(meta.Notice):len(meta.Notice)], data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("%d file(s) changed in grafana conflict with the remote branch", conflicts),
+		})
+	}
+	return meta
 }
 
 func (s *rootStorageGit) Store() filestorage.FileStorage {
 	return s.store
 }
 
-func (s *rootStorageGit) Pull() error {
-	w, err := s.repo.Worktree()
-	if err != nil {
-		return err
-	}
-
-	err = w.Pull(&git.PullOptions{
-		// Depth: 1,
-		//SingleBranch: true,
-	})
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func (s *rootStorageGit) Write(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
-	if s.github == nil {
-		return nil, fmt.Errorf("github client not initialized")
+	rel, err := gitFilePath(cmd.Path, cmd.Body)
+	if err != nil {
+		return nil, err
 	}
-	// Write to the correct subfolder
 	if s.settings.Root != "" {
-		cmd.Path = s.settings.Root + cmd.Path
+		rel = path.Join(s.settings.Root, rel)
 	}
 
 	if cmd.Workflow == WriteValueWorkflow_PR {
-		prcmd := makePRCommand{
-			baseBranch: s.settings.Branch,
-			headBranch: fmt.Sprintf("grafana_ui_%d", time.Now().UnixMilli()),
-			title:      cmd.Title,
-			body:       cmd.Message,
-		}
-		res := &WriteValueResponse{
-			Branch: prcmd.headBranch,
-		}
-
-		ref, _, err := s.github.createRef(ctx, prcmd.baseBranch, prcmd.headBranch)
-		if err != nil {
-			res.Code = 500
-			res.Message = "unable to create branch"
-			return res, nil
+		if s.github == nil {
+			return nil, fmt.Errorf("github client not initialized")
 		}
+		cmd.Path = rel
+		return s.writePR(ctx, cmd)
+	}
 
-		err = s.github.pushCommit(ctx, ref, cmd)
-		if err != nil {
-			res.Code = 500
-			res.Message = fmt.Sprintf("error creating commit: %s", err.Error())
-			return res, nil
-		}
+	msg := cmd.Message
+	if msg == "" {
+		msg = "changes from grafana ui"
+	}
 
-		if prcmd.title == "" {
-			prcmd.title = "Dashboard save: " + time.Now().String()
-		}
-		if prcmd.body == "" {
-			prcmd.body = "Dashboard save: " + time.Now().String()
-		}
+	s.mu.Lock()
+	hash, err := s.writeFile(rel, cmd.Body, cmd.User, msg)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	grafanaStorageLogger.Info("Made commit", "hash", hash)
 
-		pr, _, err := s.github.createPR(ctx, prcmd)
-		if err != nil {
-			res.Code = 500
-			res.Message = "error creating PR: " + err.Error()
-			return res, nil
-		}
+	res := &WriteValueResponse{
+		Code:    200,
+		Branch:  s.branch,
+		Hash:    hash.String(),
+		Message: "made commit",
+	}
 
-		res.Code = 200
-		res.URL = pr.GetHTMLURL()
+	// Push the commit right away
+	err = s.sync(ctx)
+	switch {
+	case errors.Is(err, ErrGitSyncConflict):
+		res.Code = http.StatusConflict
+		res.Message = "made commit, but " + err.Error()
+		res.Pending = true
+	case err != nil:
+		res.Code = http.StatusInternalServerError
+		res.Message = "made commit, but failed to push: " + err.Error()
 		res.Pending = true
-		res.Hash = *ref.Object.SHA
-		res.Branch = prcmd.headBranch
-		return res, nil
 	}
+	return res, nil
+}
 
-	// Push to remote branch (save)
-	if cmd.Workflow == WriteValueWorkflow_Push || true {
-		res := &WriteValueResponse{
-			Branch: s.settings.Branch,
-		}
-		ref, _, err := s.github.getRef(ctx, s.settings.Branch)
-		if err != nil {
-			res.Code = 500
-			res.Message = "unable to create branch"
-			return res, nil
-		}
-		err = s.github.pushCommit(ctx, ref, cmd)
-		if err != nil {
-			res.Code = 500
-			res.Message = "error creating commit"
-			return res, nil
-		}
-		ref, _, _ = s.github.getRef(ctx, s.settings.Branch)
-		if ref != nil {
-			res.Hash = *ref.Object.SHA
-			res.URL = ref.GetURL()
-		}
+// writeFile must be called with the lock held.
+func (s *rootStorageGit) writeFile(rel string, body []byte, usr *user.SignedInUser, msg string) (plumbing.Hash, error) {
+	root := s.repoRoot()
+	fpath := filepath.Join(root, filepath.FromSlash(rel))
+	if r, err := filepath.Rel(root, fpath); err != nil || r == "." || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return plumbing.ZeroHash, fmt.Errorf("%w: path %q is outside of the repository", ErrValidationFailed, rel)
+	}
+	if isGitPath(rel) {
+		return plumbing.ZeroHash, fmt.Errorf("%w: invalid path %q", ErrValidationFailed, rel)
+	}
+	if err := os.MkdirAll(filepath.Dir(fpath), 0750); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := os.WriteFile(fpath, body, 0644); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.commitWorktree(usr, msg)
+}
 
-		err = s.Pull()
-		if err != nil {
-			res.Message = "error pulling: " + err.Error()
-		}
+func (s *rootStorageGit) writePR(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	prcmd := makePRCommand{
+		baseBranch: s.branch,
+		headBranch: fmt.Sprintf("grafana_ui_%d", time.Now().UnixMilli()),
+		title:      cmd.Title,
+		body:       cmd.Message,
+	}
+	res := &WriteValueResponse{
+		Branch: prcmd.headBranch,
+	}
 
-		res.Code = 200
+	ref, _, err := s.github.createRef(ctx, prcmd.baseBranch, prcmd.headBranch)
+	if err != nil {
+		res.Code = 500
+		res.Message = "unable to create branch"
 		return res, nil
 	}
 
-	rel := cmd.Path
-	if s.meta.Config.Git.Root != "" {
-		rel = filepath.Join(s.meta.Config.Git.Root, cmd.Path)
+	err = s.github.pushCommit(ctx, ref, cmd)
+	if err != nil {
+		res.Code = 500
+		res.Message = fmt.Sprintf("error creating commit: %s", err.Error())
+		return res, nil
 	}
 
-	fpath := filepath.Join(s.root, rel)
-	err := os.WriteFile(fpath, cmd.Body, 0644)
+	if prcmd.title == "" {
+		prcmd.title = "Dashboard save: " + time.Now().String()
+	}
+	if prcmd.body == "" {
+		prcmd.body = "Dashboard save: " + time.Now().String()
+	}
+
+	pr, _, err := s.github.createPR(ctx, prcmd)
 	if err != nil {
-		return nil, err
+		res.Code = 500
+		res.Message = "error creating PR: " + err.Error()
+		return res, nil
 	}
 
+	res.Code = 200
+	res.URL = pr.GetHTMLURL()
+	res.Pending = true
+	res.Hash = *ref.Object.SHA
+	res.Branch = prcmd.headBranch
+	return res, nil
+}
+
+// Sync pulls the remote branch and pushes the changes made in grafana.
+func (s *rootStorageGit) Sync() error {
+	return s.sync(context.Background())
+}
+
+// repoRoot is the root of the git work tree, s.root may point to a subfolder.
+func (s *rootStorageGit) repoRoot() string {
 	w, err := s.repo.Worktree()
 	if err != nil {
-		return nil, err
+		return s.root
 	}
+	return w.Filesystem.Root()
+}
 
-	// The file we just wrote
-	_, err = w.Add(rel)
-	if err != nil {
-		return nil, err
+// openGitRepository opens the local copy of the remote, cloning it if needed.
+func openGitRepository(localWorkCache string, cfg *StorageGitConfig) (*git.Repository, error) {
+	repo, err := git.PlainOpen(localWorkCache)
+	if !errors.Is(err, git.ErrRepositoryNotExists) {
+		return repo, err
 	}
 
-	msg := cmd.Message
-	if msg == "" {
-		msg = "changes from grafana ui"
+	opts := &git.CloneOptions{
+		URL:      cfg.Remote,
+		Progress: io.Discard,
 	}
-	usr := cmd.User
-	if usr == nil {
-		usr = &user.SignedInUser{}
+	if cfg.Branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(cfg.Branch)
+	}
+	repo, err = git.PlainClone(localWorkCache, false, opts)
+	if !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return repo, err
 	}
 
-	hash, err := w.Commit(msg, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  firstRealString(usr.Name, usr.Login, usr.Email, "?"),
-			Email: firstRealString(usr.Email, usr.Login, usr.Name, "?"),
-			When:  time.Now(),
-		},
-	})
+	// Nothing to clone yet, the first sync pushes the changes made in grafana
+	_ = os.RemoveAll(filepath.Join(localWorkCache, ".git"))
+	repo, err = git.PlainInit(localWorkCache, false)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.Branch != "" {
+		head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(cfg.Branch))
+		if err := repo.Storer.SetReference(head); err != nil {
+			return nil, err
+		}
+	}
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{cfg.Remote},
+	})
+	return repo, err
+}
 
-	grafanaStorageLogger.Info("Made commit", "hash", hash)
-	// err = s.repo.Push(&git.PushOptions{
-	// 	InsecureSkipTLS: true,
-	// })
+// gitBranchName returns the configured branch, or the branch checked out when cloning.
+func gitBranchName(repo *git.Repository, cfg *StorageGitConfig) (string, error) {
+	if cfg.Branch != "" {
+		return cfg.Branch, nil
+	}
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() == plumbing.SymbolicReference && head.Target().IsBranch() {
+		return head.Target().Short(), nil
+	}
+	return "main", nil
+}
 
-	return &WriteValueResponse{
-		Hash:    hash.String(),
-		Message: "made commit",
-	}, nil
+// gitFilePath maps a dashboard save to a file in the repository. Folders are directories and dashboards
+// are JSON files named after the dashboard title when the path is a folder. Absolute paths and paths
+// with ".." segments are rejected.
+const gitDirName = ".git"
+
+// isGitPath reports whether any segment of the path names the git directory, which is never written through grafana.
+func isGitPath(p string) bool {
+	for _, segment := range strings.Split(path.Clean("/"+filepath.ToSlash(p)), "/") {
+		if strings.EqualFold(segment, gitDirName) {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *rootStorageGit) Sync() error {
-	grafanaStorageLogger.Info("GIT PULL", "remote", s.settings.Remote)
-	err := s.Pull()
-	if err != nil {
-		if err.Error() == "already up-to-date" {
-			return nil
+func gitFilePath(p string, body []byte) (string, error) {
+	rel := strings.TrimPrefix(p, "/")
+	if path.IsAbs(rel) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("%w: invalid path %q", ErrValidationFailed, p)
+	}
+	for _, segment := range strings.Split(rel, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: invalid path %q", ErrValidationFailed, p)
+		}
+	}
+	if isGitPath(rel) {
+		return "", fmt.Errorf("%w: invalid path %q", ErrValidationFailed, p)
+	}
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		dash := struct {
+			Title string `json:"title"`
+		}{}
+		_ = json.Unmarshal(body, &dash)
+		name := "dashboard"
+		if dash.Title != "" {
+			name = slugify.Slugify(dash.Title)
 		}
+		return rel + name + ".json", nil
+	}
+	if path.Ext(rel) == "" {
+		return rel + ".json", nil
 	}
-	return err
+	return rel, nil
 }
 
 func firstRealString(vals ...string) string {
@@ -0,0 +1,550 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// ErrGitSyncConflict is returned when changes made in grafana can not be applied on top of the remote branch
+// because the same files were changed upstream. The conflicting files are listed in the sync status.
+var ErrGitSyncConflict = errors.New("changes conflict with the remote branch")
+
+type GitConflictResolution = string
+
+var (
+	GitConflictResolution_Ours   GitConflictResolution = "ours"   // changes made in grafana win
+	GitConflictResolution_Theirs GitConflictResolution = "theirs" // remote changes win, changes made in grafana are dropped
+)
+
+type GitSyncConflict struct {
+	Path         string `json:"path"`
+	LocalCommit  string `json:"localCommit"`
+	RemoteCommit string `json:"remoteCommit"`
+}
+
+type GitSyncStatus struct {
+	Remote     string            `json:"remote"`
+	Branch     string            `json:"branch"`
+	Head       string            `json:"head,omitempty"`
+	RemoteHead string            `json:"remoteHead,omitempty"`
+	Ahead      int               `json:"ahead"` // local commits not pushed yet
+	LastSync   *time.Time        `json:"lastSync,omitempty"`
+	LastError  string            `json:"lastError,omitempty"`
+	Conflicts  []GitSyncConflict `json:"conflicts,omitempty"`
+}
+
+type ResolveGitConflictsCmd struct {
+	Strategy GitConflictResolution `json:"strategy"`
+}
+
+// SyncStatus returns the state of the local repository compared to the remote branch.
+func (s *rootStorageGit) SyncStatus() GitSyncStatus {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	status := s.status
+	status.Conflicts = append([]GitSyncConflict(nil), s.status.Conflicts...)
+	return status
+}
+
+// sync commits any uncommitted changes in the work tree, pulls the remote branch and pushes the local commits.
+// Local commits are replayed on top of the remote branch when both have changed; when they changed the same
+// files the repository is left as is and ErrGitSyncConflict is returned.
+func (s *rootStorageGit) sync(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recordSync(s.syncLocked(ctx, false))
+}
+
+// resolveConflicts syncs with the remote branch using the given resolution for the conflicting files.
+func (s *rootStorageGit) resolveConflicts(ctx context.Context, strategy GitConflictResolution) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strategy {
+	case GitConflictResolution_Ours:
+		return s.recordSync(s.syncLocked(ctx, true))
+	case GitConflictResolution_Theirs:
+		err := s.fetch(ctx)
+		if err == nil {
+			var remote *object.Commit
+			remote, err = s.remoteCommit()
+			if err == nil && remote == nil {
+				err = fmt.Errorf("branch %s does not exist on the remote", s.branch)
+			}
+			if err == nil {
+				err = s.resetTo(remote.Hash)
+			}
+		}
+		return s.recordSync(err)
+	}
+	return fmt.Errorf("unknown conflict resolution: %q", strategy)
+}
+
+// recordSync must be called with the lock held.
+func (s *rootStorageGit) recordSync(err error) error {
+	status := s.SyncStatus()
+	now := time.Now()
+	status.LastSync = &now
+	status.LastError = ""
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	if !errors.Is(err, ErrGitSyncConflict) {
+		status.Conflicts = nil
+	}
+	s.updateStatus(&status)
+
+	s.statusMu.Lock()
+	s.status = status
+	s.statusMu.Unlock()
+	return err
+}
+
+// updateStatus must be called with the lock held.
+func (s *rootStorageGit) updateStatus(status *GitSyncStatus) {
+	status.Remote = s.settings.Remote
+	status.Branch = s.branch
+	status.Head, status.RemoteHead, status.Ahead = "", "", 0
+
+	local, err := s.headCommit()
+	if err != nil || local == nil {
+		return
+	}
+	status.Head = local.Hash.String()
+
+	remote, err := s.remoteCommit()
+	if err != nil {
+		return
+	}
+	var stop plumbing.Hash
+	if remote != nil {
+		status.RemoteHead = remote.Hash.String()
+		if bases, err := local.MergeBase(remote); err == nil && len(bases) > 0 {
+			stop = bases[0].Hash
+		}
+	}
+	if commits, err := firstParentCommits(local, stop); err == nil {
+		status.Ahead = len(commits)
+	}
+}
+
+// syncLocked must be called with the lock held.
+func (s *rootStorageGit) syncLocked(ctx context.Context, force bool) error {
+	if _, err := s.commitWorktree(nil, "changes from grafana ui"); err != nil {
+		return err
+	}
+	if err := s.fetch(ctx); err != nil {
+		return err
+	}
+
+	local, err := s.headCommit()
+	if err != nil {
+		return err
+	}
+	remote, err := s.remoteCommit()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case local == nil && remote == nil:
+		return nil
+	case local == nil:
+		return s.resetTo(remote.Hash)
+	case remote == nil:
+		return s.push(ctx)
+	case local.Hash == remote.Hash:
+		return nil
+	}
+
+	if ok, err := remote.IsAncestor(local); err != nil {
+		return err
+	} else if ok {
+		return s.push(ctx)
+	}
+
+	if ok, err := local.IsAncestor(remote); err != nil {
+		return err
+	} else if ok {
+		return s.resetTo(remote.Hash)
+	}
+
+	if err := s.rebase(local, remote, force); err != nil {
+		return err
+	}
+	return s.push(ctx)
+}
+
+// rebase replays the local commits on top of the remote branch. Unless force is set, nothing is changed
+// when a file was changed on both sides. The branch is moved back to the local commits when replaying fails.
+func (s *rootStorageGit) rebase(local *object.Commit, remote *object.Commit, force bool) (err error) {
+	bases, err := local.MergeBase(remote)
+	if err != nil {
+		return err
+	}
+	if len(bases) == 0 {
+		return fmt.Errorf("local and remote branch %s have no common history", s.branch)
+	}
+	base := bases[0]
+
+	commits, err := firstParentCommits(local, base.Hash)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		conflicts, err := findConflicts(base, local, remote)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			s.statusMu.Lock()
+			s.status.Conflicts = conflicts
+			s.statusMu.Unlock()
+			return ErrGitSyncConflict
+		}
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		if resetErr := s.resetTo(local.Hash); resetErr != nil {
+			err = errors.Join(err, fmt.Errorf("unable to restore local commits: %w", resetErr))
+		}
+	}()
+	if err := s.resetTo(remote.Hash); err != nil {
+		return err
+	}
+
+	w, err := s.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	// oldest first
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		changes, err := commitChanges(c)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if err := applyChange(w, change); err != nil {
+				return err
+			}
+		}
+
+		status, err := w.Status()
+		if err != nil {
+			return err
+		}
+		if isCleanIgnoringAttrs(status) {
+			continue // the same change is already upstream
+		}
+		if _, err := w.Commit(c.Message, &git.CommitOptions{Author: &c.Author}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitWorktree commits all changes in the work tree, e.g. files uploaded or deleted through the storage API.
+// It must be called with the lock held.
+func (s *rootStorageGit) commitWorktree(usr *user.SignedInUser, msg string) (plumbing.Hash, error) {
+	w, err := s.repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if isCleanIgnoringAttrs(status) {
+		return plumbing.ZeroHash, nil
+	}
+
+	for path, fs := range status {
+		if strings.HasSuffix(path, gitIgnoredAttrsSuffix) {
+			continue
+		}
+		if fs.Worktree == git.Deleted {
+			_, err = w.Remove(path)
+		} else {
+			_, err = w.Add(path)
+		}
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	return w.Commit(msg, &git.CommitOptions{Author: gitSignature(usr)})
+}
+
+// The blob storage used for file uploads keeps metadata next to each file, these are never committed.
+const gitIgnoredAttrsSuffix = ".attrs"
+
+func isCleanIgnoringAttrs(status git.Status) bool {
+	for path, fs := range status {
+		if strings.HasSuffix(path, gitIgnoredAttrsSuffix) {
+			continue
+		}
+		if fs.Worktree != git.Unmodified || fs.Staging != git.Unmodified {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *rootStorageGit) fetch(ctx context.Context) error {
+	refSpec := config.RefSpec(fmt.Sprintf("+%s:%s", plumbing.NewBranchReferenceName(s.branch), s.remoteReferenceName()))
+	err := s.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{refSpec},
+	})
+	if errors.Is(err, git.NoErrAlreadyUpToDate) || errors.Is(err, transport.ErrEmptyRemoteRepository) || errors.Is(err, git.NoMatchingRefSpecError{}) {
+		return nil
+	}
+	return err
+}
+
+func (s *rootStorageGit) push(ctx context.Context) error {
+	branch := plumbing.NewBranchReferenceName(s.branch)
+	err := s.repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:%s", branch, branch))},
+	})
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Keep the remote tracking branch up to date, so the status does not wait for the next fetch
+	head, err := s.repo.Reference(branch, true)
+	if err != nil {
+		return err
+	}
+	return s.repo.Storer.SetReference(plumbing.NewHashReference(s.remoteReferenceName(), head.Hash()))
+}
+
+func (s *rootStorageGit) resetTo(hash plumbing.Hash) error {
+	w, err := s.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	// Also handles the first checkout of a repository cloned while the remote was empty
+	branch := plumbing.NewBranchReferenceName(s.branch)
+	if err := s.repo.Storer.SetReference(plumbing.NewHashReference(branch, hash)); err != nil {
+		return err
+	}
+	if err := s.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branch)); err != nil {
+		return err
+	}
+	return w.Reset(&git.ResetOptions{Commit: hash, Mode: git.HardReset})
+}
+
+func (s *rootStorageGit) remoteReferenceName() plumbing.ReferenceName {
+	return plumbing.NewRemoteReferenceName(git.DefaultRemoteName, s.branch)
+}
+
+// headCommit returns nil when nothing has been committed yet.
+func (s *rootStorageGit) headCommit() (*object.Commit, error) {
+	ref, err := s.repo.Reference(plumbing.NewBranchReferenceName(s.branch), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.CommitObject(ref.Hash())
+}
+
+// remoteCommit returns nil when the branch does not exist on the remote.
+func (s *rootStorageGit) remoteCommit() (*object.Commit, error) {
+	ref, err := s.repo.Reference(s.remoteReferenceName(), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.CommitObject(ref.Hash())
+}
+
+// firstParentCommits returns the commits from c back to stop (exclusive), newest first.
+func firstParentCommits(c *object.Commit, stop plumbing.Hash) ([]*object.Commit, error) {
+	commits := make([]*object.Commit, 0)
+	for c != nil && c.Hash != stop {
+		commits = append(commits, c)
+		if c.NumParents() == 0 {
+			break
+		}
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		c = parent
+	}
+	return commits, nil
+}
+
+// findConflicts lists the files changed both locally and on the remote since base, ending up with different contents.
+func findConflicts(base, local, remote *object.Commit) ([]GitSyncConflict, error) {
+	localChanges, err := changedFiles(base, local)
+	if err != nil {
+		return nil, err
+	}
+	remoteChanges, err := changedFiles(base, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]GitSyncConflict, 0)
+	for path, localHash := range localChanges {
+		remoteHash, ok := remoteChanges[path]
+		if ok && remoteHash != localHash {
+			conflicts = append(conflicts, GitSyncConflict{
+				Path:         path,
+				LocalCommit:  local.Hash.String(),
+				RemoteCommit: remote.Hash.String(),
+			})
+		}
+	}
+	return conflicts, nil
+}
+
+// changedFiles maps the paths changed between two commits to their new blob hash, or the zero hash when deleted.
+func changedFiles(from, to *object.Commit) (map[string]plumbing.Hash, error) {
+	fromTree, err := from.Tree()
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := to.Tree()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]plumbing.Hash, len(changes))
+	for _, change := range changes {
+		if change.From.Name != "" {
+			files[change.From.Name] = plumbing.ZeroHash
+		}
+		if change.To.Name != "" {
+			files[change.To.Name] = change.To.TreeEntry.Hash
+		}
+	}
+	return files, nil
+}
+
+type gitFileChange struct {
+	deleted  string
+	written  string
+	contents []byte
+}
+
+func commitChanges(c *object.Commit) ([]gitFileChange, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	parentTree := &object.Tree{}
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]gitFileChange, 0, len(changes))
+	for _, change := range changes {
+		fc := gitFileChange{}
+		if change.From.Name != "" && change.From.Name != change.To.Name {
+			fc.deleted = change.From.Name
+		}
+		if change.To.Name != "" {
+			file, err := tree.TreeEntryFile(&change.To.TreeEntry)
+			if err != nil {
+				return nil, err
+			}
+			contents, err := file.Contents()
+			if err != nil {
+				return nil, err
+			}
+			fc.written = change.To.Name
+			fc.contents = []byte(contents)
+		}
+		result = append(result, fc)
+	}
+	return result, nil
+}
+
+func applyChange(w *git.Worktree, change gitFileChange) error {
+	if isGitPath(change.deleted) || isGitPath(change.written) {
+		return fmt.Errorf("%w: invalid path in commit", ErrValidationFailed)
+	}
+	if change.deleted != "" {
+		if _, err := w.Remove(change.deleted); err != nil && !errors.Is(err, os.ErrNotExist) {
+			grafanaStorageLogger.Debug("Unable to remove file", "path", change.deleted, "error", err)
+		}
+	}
+	if change.written == "" {
+		return nil
+	}
+
+	if err := w.Filesystem.MkdirAll(filepath.Dir(change.written), 0750); err != nil {
+		return err
+	}
+	f, err := w.Filesystem.Create(change.written)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(change.contents)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Add(change.written)
+	return err
+}
+
+func gitSignature(usr *user.SignedInUser) *object.Signature {
+	if usr == nil {
+		usr = &user.SignedInUser{Name: "Grafana", Email: "grafana@localhost"}
+	}
+	return &object.Signature{
+		Name:  firstRealString(usr.Name, usr.Login, usr.Email, "?"),
+		Email: firstRealString(usr.Email, usr.Login, usr.Name, "?"),
+		When:  time.Now(),
+	}
+}
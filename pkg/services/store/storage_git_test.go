@@ -0,0 +1,297 @@
+package store
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+type testGitUpstream struct {
+	t      *testing.T
+	remote string
+	dir    string
+	repo   *git.Repository
+}
+
+// newTestGitUpstream creates a bare repository used as remote, and a clone to make upstream changes.
+func newTestGitUpstream(t *testing.T) *testGitUpstream {
+	t.Helper()
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	_, err := git.PlainInit(remote, true)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))))
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{remote}})
+	require.NoError(t, err)
+
+	u := &testGitUpstream{t: t, remote: remote, dir: dir, repo: repo}
+	u.commit("dashboards/initial.json", `{"title":"Initial"}`)
+	return u
+}
+
+func (u *testGitUpstream) commit(path string, body string) {
+	u.t.Helper()
+	fpath := filepath.Join(u.dir, path)
+	require.NoError(u.t, os.MkdirAll(filepath.Dir(fpath), 0750))
+	require.NoError(u.t, os.WriteFile(fpath, []byte(body), 0600))
+
+	w, err := u.repo.Worktree()
+	require.NoError(u.t, err)
+	_, err = w.Add(path)
+	require.NoError(u.t, err)
+	_, err = w.Commit("upstream change", &git.CommitOptions{
+		Author: &object.Signature{Name: "Upstream", Email: "upstream@example.com", When: time.Now()},
+	})
+	require.NoError(u.t, err)
+	require.NoError(u.t, u.repo.Push(&git.PushOptions{
+		RefSpecs: []gitconfig.RefSpec{"refs/heads/main:refs/heads/main"},
+	}))
+}
+
+func (u *testGitUpstream) pull() {
+	u.t.Helper()
+	w, err := u.repo.Worktree()
+	require.NoError(u.t, err)
+	err = w.Pull(&git.PullOptions{ReferenceName: plumbing.NewBranchReferenceName("main")})
+	if err != git.NoErrAlreadyUpToDate {
+		require.NoError(u.t, err)
+	}
+}
+
+func (u *testGitUpstream) read(path string) string {
+	u.t.Helper()
+	b, err := os.ReadFile(filepath.Join(u.dir, path))
+	require.NoError(u.t, err)
+	return string(b)
+}
+
+func (u *testGitUpstream) headAuthor() string {
+	u.t.Helper()
+	head, err := u.repo.Head()
+	require.NoError(u.t, err)
+	c, err := u.repo.CommitObject(head.Hash())
+	require.NoError(u.t, err)
+	return c.Author.Name
+}
+
+func newTestGitStorage(t *testing.T, remote string) *rootStorageGit {
+	t.Helper()
+	s := newGitStorage(RootStorageMeta{}, RootStorageConfig{
+		Prefix: "git",
+		Git:    &StorageGitConfig{Remote: remote, Branch: "main"},
+	}, filepath.Join(t.TempDir(), "cache"))
+	require.True(t, s.meta.Ready, "storage not ready: %v", s.meta.Notice)
+	return s
+}
+
+func TestGitStorageSync(t *testing.T) {
+	ctx := context.Background()
+	editor := &user.SignedInUser{Name: "Editor", Email: "editor@example.com"}
+
+	readLocal := func(t *testing.T, s *rootStorageGit, path string) string {
+		b, err := os.ReadFile(filepath.Join(s.root, path))
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	t.Run("pulls upstream changes", func(t *testing.T) {
+		upstream := newTestGitUpstream(t)
+		s := newTestGitStorage(t, upstream.remote)
+		require.Equal(t, `{"title":"Initial"}`, readLocal(t, s, "dashboards/initial.json"))
+
+		upstream.commit("dashboards/new.json", `{"title":"New"}`)
+		require.NoError(t, s.Sync())
+		require.Equal(t, `{"title":"New"}`, readLocal(t, s, "dashboards/new.json"))
+		require.Equal(t, 0, s.SyncStatus().Ahead)
+	})
+
+	t.Run("pushes dashboards saved in grafana", func(t *testing.T) {
+		upstream := newTestGitUpstream(t)
+		s := newTestGitStorage(t, upstream.remote)
+
+		res, err := s.Write(ctx, &WriteValueRequest{
+			Path: "/dashboards/team/",
+			Body: []byte(`{"title":"My dashboard"}`),
+			User: editor,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.Code, res.Message)
+
+		upstream.pull()
+		require.Equal(t, `{"title":"My dashboard"}`, upstream.read("dashboards/team/my-dashboard.json"))
+		require.Equal(t, "Editor", upstream.headAuthor())
+	})
+
+	t.Run("replays changes on top of the remote branch", func(t *testing.T) {
+		upstream := newTestGitUpstream(t)
+		s := newTestGitStorage(t, upstream.remote)
+
+		upstream.commit("dashboards/upstream.json", `{"title":"Upstream"}`)
+		res, err := s.Write(ctx, &WriteValueRequest{Path: "/dashboards/local.json", Body: []byte(`{"title":"Local"}`), User: editor})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.Code, res.Message)
+
+		upstream.pull()
+		require.Equal(t, `{"title":"Local"}`, upstream.read("dashboards/local.json"))
+		require.Equal(t, "Editor", upstream.headAuthor())
+		require.Equal(t, `{"title":"Upstream"}`, readLocal(t, s, "dashboards/upstream.json"))
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		setup := func(t *testing.T) (*testGitUpstream, *rootStorageGit) {
+			upstream := newTestGitUpstream(t)
+			s := newTestGitStorage(t, upstream.remote)
+
+			upstream.commit("dashboards/initial.json", `{"title":"Changed upstream"}`)
+			res, err := s.Write(ctx, &WriteValueRequest{Path: "/dashboards/initial.json", Body: []byte(`{"title":"Changed locally"}`), User: editor})
+			require.NoError(t, err)
+			require.Equal(t, http.StatusConflict, res.Code)
+
+			status := s.SyncStatus()
+			require.Len(t, status.Conflicts, 1)
+			require.Equal(t, "dashboards/initial.json", status.Conflicts[0].Path)
+			require.Equal(t, 1, status.Ahead)
+			require.Len(t, s.Meta().Notice, 1)
+
+			// Syncing again does not touch the local changes
+			require.ErrorIs(t, s.Sync(), ErrGitSyncConflict)
+			require.Equal(t, `{"title":"Changed locally"}`, readLocal(t, s, "dashboards/initial.json"))
+			return upstream, s
+		}
+
+		t.Run("resolve with remote changes", func(t *testing.T) {
+			_, s := setup(t)
+			require.NoError(t, s.resolveConflicts(ctx, GitConflictResolution_Theirs))
+			require.Equal(t, `{"title":"Changed upstream"}`, readLocal(t, s, "dashboards/initial.json"))
+			require.Empty(t, s.SyncStatus().Conflicts)
+			require.Equal(t, 0, s.SyncStatus().Ahead)
+		})
+
+		t.Run("resolve with local changes", func(t *testing.T) {
+			upstream, s := setup(t)
+			require.NoError(t, s.resolveConflicts(ctx, GitConflictResolution_Ours))
+			require.Empty(t, s.SyncStatus().Conflicts)
+			require.Empty(t, s.Meta().Notice)
+
+			upstream.pull()
+			require.Equal(t, `{"title":"Changed locally"}`, upstream.read("dashboards/initial.json"))
+		})
+	})
+
+	t.Run("reads the status while a sync is running", func(t *testing.T) {
+		upstream := newTestGitUpstream(t)
+		s := newTestGitStorage(t, upstream.remote)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		done := make(chan GitSyncStatus)
+		go func() {
+			_ = s.Meta()
+			done <- s.SyncStatus()
+		}()
+		select {
+		case status := <-done:
+			require.Equal(t, "main", status.Branch)
+		case <-time.After(5 * time.Second):
+			t.Fatal("reading the status waited for the sync")
+		}
+	})
+
+	t.Run("rejects paths outside of the repository", func(t *testing.T) {
+		upstream := newTestGitUpstream(t)
+		s := newTestGitStorage(t, upstream.remote)
+
+		_, err := s.Write(ctx, &WriteValueRequest{Path: "/../escape.json", Body: []byte(`{}`), User: editor})
+		require.ErrorIs(t, err, ErrValidationFailed)
+		_, err = os.Stat(filepath.Join(filepath.Dir(s.root), "escape.json"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("rejects paths in the git directory", func(t *testing.T) {
+		upstream := newTestGitUpstream(t)
+		s := newTestGitStorage(t, upstream.remote)
+
+		for _, p := range []string{"/.git/config", "/.GIT/refs/heads/main", "/dashboards/.Git/config"} {
+			_, err := s.Write(ctx, &WriteValueRequest{Path: p, Body: []byte(`{}`), User: editor})
+			require.ErrorIs(t, err, ErrValidationFailed, p)
+		}
+		_, err := s.writeFile(".git/config", []byte(`{}`), editor, "change")
+		require.ErrorIs(t, err, ErrValidationFailed)
+
+		// the file storage ignores writes to filtered paths
+		_ = s.Store().Upsert(ctx, &filestorage.UpsertFileCommand{Path: "/.git/config", Contents: []byte(`{}`)})
+		config, err := os.ReadFile(filepath.Join(s.repoRoot(), ".git", "config"))
+		require.NoError(t, err)
+		require.NotEqual(t, `{}`, string(config))
+	})
+
+	t.Run("keeps the local commits when replaying them fails", func(t *testing.T) {
+		upstream := newTestGitUpstream(t)
+		s := newTestGitStorage(t, upstream.remote)
+
+		// the local file can not be written on top of the remote branch, where its path is a folder
+		upstream.commit("dashboards/local.json/nested.json", `{"title":"Upstream"}`)
+		res, err := s.Write(ctx, &WriteValueRequest{Path: "/dashboards/local.json", Body: []byte(`{"title":"Local"}`), User: editor})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusInternalServerError, res.Code)
+		require.True(t, res.Pending)
+
+		head, err := s.headCommit()
+		require.NoError(t, err)
+		require.Equal(t, res.Hash, head.Hash.String())
+		require.Equal(t, `{"title":"Local"}`, readLocal(t, s, "dashboards/local.json"))
+		require.Equal(t, 1, s.SyncStatus().Ahead)
+	})
+
+	t.Run("clones an empty remote", func(t *testing.T) {
+		remote := filepath.Join(t.TempDir(), "remote.git")
+		_, err := git.PlainInit(remote, true)
+		require.NoError(t, err)
+
+		s := newTestGitStorage(t, remote)
+		res, err := s.Write(ctx, &WriteValueRequest{Path: "/first.json", Body: []byte(`{}`), User: editor})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.Code, res.Message)
+
+		require.Equal(t, res.Hash, s.SyncStatus().RemoteHead)
+	})
+}
+
+func TestGitFilePath(t *testing.T) {
+	for p, expected := range map[string]string{
+		"/a/b.json":   "a/b.json",
+		"/a/b":        "a/b.json",
+		"/a/..b.json": "a/..b.json",
+	} {
+		rel, err := gitFilePath(p, nil)
+		require.NoError(t, err)
+		require.Equal(t, expected, rel)
+	}
+
+	rel, err := gitFilePath("/a/", []byte(`{"title":"My dashboard"}`))
+	require.NoError(t, err)
+	require.Equal(t, "a/my-dashboard.json", rel)
+	rel, err = gitFilePath("/", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, "dashboard.json", rel)
+
+	for _, p := range []string{"/../b.json", "/a/../../b.json", "//etc/passwd", "/a/..", "/.git/config", "/.GIT/HEAD", "/a/.git/b.json"} {
+		_, err := gitFilePath(p, nil)
+		require.ErrorIs(t, err, ErrValidationFailed, p)
+	}
+}
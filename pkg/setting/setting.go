@@ -207,6 +207,10 @@ type Cfg struct {
 	RendererAuthToken              string
 	RendererConcurrentRequestLimit int
 	RendererRenderKeyLifeTime      time.Duration
+	RendererBuiltinEnabled         bool
+	RendererBuiltinMaxWidth        int
+	RendererBuiltinMaxHeight       int
+	RendererBuiltinMaxScale        float64
 
 	// Security
 	DisableInitAdminCreation          bool
@@ -385,6 +389,8 @@ type Cfg struct {
 	AlertingAnnotationCleanupSetting   AnnotationCleanupSettings
 	DashboardAnnotationCleanupSettings AnnotationCleanupSettings
 	APIAnnotationCleanupSettings       AnnotationCleanupSettings
+	AnnotationArchiveEnabled           bool
+	AnnotationArchivePath              string
 
 	// GrafanaJavascriptAgent config
 	GrafanaJavascriptAgent GrafanaJavascriptAgent
@@ -751,6 +757,10 @@ func (cfg *Cfg) readAnnotationSettings() error {
 	cfg.DashboardAnnotationCleanupSettings = newAnnotationCleanupSettings(dashboardAnnotation, "max_age")
 	cfg.APIAnnotationCleanupSettings = newAnnotationCleanupSettings(apiIAnnotation, "max_age")
 
+	archiveSection := cfg.Raw.Section("annotations.archive")
+	cfg.AnnotationArchiveEnabled = archiveSection.Key("enabled").MustBool(false)
+	cfg.AnnotationArchivePath = makeAbsolute(archiveSection.Key("path").MustString("annotations-archive"), cfg.DataPath)
+
 	return nil
 }
 
@@ -1204,6 +1214,9 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 
 	cfg.Storage = readStorageSettings(iniFile)
 	cfg.Search = readSearchSettings(iniFile)
+	if cfg.Search.IndexSnapshotPath == "" {
+		cfg.Search.IndexSnapshotPath = filepath.Join(cfg.DataPath, "search-index")
+	}
 
 	cfg.SecureSocksDSProxy, err = readSecureSocksDSProxySettings(iniFile)
 	if err != nil {
@@ -1287,6 +1300,8 @@ type RemoteCacheOptions struct {
 	ConnStr    string
 	Prefix     string
 	Encryption bool
+	// MemoryMaxSize is the total size of the items kept by the memory cache, in bytes. A default is used when 0.
+	MemoryMaxSize int
 }
 
 func (cfg *Cfg) readSAMLConfig() {
@@ -1770,6 +1785,10 @@ func (cfg *Cfg) readRenderingSettings(iniFile *ini.File) error {
 
 	cfg.RendererConcurrentRequestLimit = renderSec.Key("concurrent_render_request_limit").MustInt(30)
 	cfg.RendererRenderKeyLifeTime = renderSec.Key("render_key_lifetime").MustDuration(5 * time.Minute)
+	cfg.RendererBuiltinEnabled = renderSec.Key("builtin_renderer_enabled").MustBool(false)
+	cfg.RendererBuiltinMaxWidth = renderSec.Key("builtin_renderer_max_width").MustInt(4000)
+	cfg.RendererBuiltinMaxHeight = renderSec.Key("builtin_renderer_max_height").MustInt(4000)
+	cfg.RendererBuiltinMaxScale = renderSec.Key("builtin_renderer_max_scale").MustFloat64(4)
 	cfg.ImagesDir = filepath.Join(cfg.DataPath, "png")
 	cfg.CSVsDir = filepath.Join(cfg.DataPath, "csv")
 
@@ -10,6 +10,10 @@ type SearchSettings struct {
 	FullReindexInterval       time.Duration
 	IndexUpdateInterval       time.Duration
 	DashboardLoadingBatchSize int
+
+	IndexSnapshotEnabled  bool
+	IndexSnapshotPath     string
+	IndexSnapshotInterval time.Duration
 }
 
 func readSearchSettings(iniFile *ini.File) SearchSettings {
@@ -19,5 +23,8 @@ func readSearchSettings(iniFile *ini.File) SearchSettings {
 	s.DashboardLoadingBatchSize = searchSection.Key("dashboard_loading_batch_size").MustInt(200)
 	s.FullReindexInterval = searchSection.Key("full_reindex_interval").MustDuration(5 * time.Minute)
 	s.IndexUpdateInterval = searchSection.Key("index_update_interval").MustDuration(10 * time.Second)
+	s.IndexSnapshotEnabled = searchSection.Key("index_snapshot_enabled").MustBool(false)
+	s.IndexSnapshotPath = searchSection.Key("index_snapshot_path").MustString("")
+	s.IndexSnapshotInterval = searchSection.Key("index_snapshot_interval").MustDuration(5 * time.Minute)
 	return s
 }
@@ -219,6 +219,92 @@ func TestIntegrationUpdateCorrelation(t *testing.T) {
 		require.NoError(t, res.Body.Close())
 	})
 
+	t.Run("updating the type of a correlation without a matching target should result in a 400", func(t *testing.T) {
+		correlation := ctx.createCorrelation(correlations.CreateCorrelationCommand{
+			SourceUID: writableDs,
+			TargetUID: &writableDs,
+			OrgId:     writableDsOrgId,
+		})
+
+		res := ctx.Patch(PatchParams{
+			url:  fmt.Sprintf("/api/datasources/uid/%s/correlations/%s", correlation.SourceUID, correlation.UID),
+			user: adminUser,
+			body: `{
+					"config": {
+						"type": "dashboard"
+					}
+				}`,
+		})
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		var response errorResponseBody
+		err = json.Unmarshal(responseBody, &response)
+		require.NoError(t, err)
+
+		require.Equal(t, "Invalid correlation config", response.Message)
+		require.NoError(t, res.Body.Close())
+
+		res = ctx.Patch(PatchParams{
+			url:  fmt.Sprintf("/api/datasources/uid/%s/correlations/%s", correlation.SourceUID, correlation.UID),
+			user: adminUser,
+			body: `{
+					"config": {
+						"type": "dashboard",
+						"target": { "dashboardUID": "service-overview", "variables": { "service": "${service}" } }
+					}
+				}`,
+		})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, err = io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		var updated correlations.UpdateCorrelationResponseBody
+		err = json.Unmarshal(responseBody, &updated)
+		require.NoError(t, err)
+
+		// only query correlations have a target data source
+		require.Nil(t, updated.Result.TargetUID)
+		require.NoError(t, res.Body.Close())
+	})
+
+	t.Run("updating the type of a correlation without a target data source to query should result in a 400", func(t *testing.T) {
+		correlation := ctx.createCorrelation(correlations.CreateCorrelationCommand{
+			SourceUID: writableDs,
+			OrgId:     writableDsOrgId,
+			Config: correlations.CorrelationConfig{
+				Field:  "traceId",
+				Type:   correlations.ConfigTypeExternal,
+				Target: map[string]any{"url": "https://example.com/traces/${traceId}"},
+			},
+		})
+
+		res := ctx.Patch(PatchParams{
+			url:  fmt.Sprintf("/api/datasources/uid/%s/correlations/%s", correlation.SourceUID, correlation.UID),
+			user: adminUser,
+			body: `{
+					"config": {
+						"type": "query",
+						"target": { "expr": "foo" }
+					}
+				}`,
+		})
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		var response errorResponseBody
+		err = json.Unmarshal(responseBody, &response)
+		require.NoError(t, err)
+
+		require.Equal(t, "Invalid correlation config", response.Message)
+		require.NoError(t, res.Body.Close())
+	})
+
 	t.Run("updating a correlation pointing to a read-only data source should work", func(t *testing.T) {
 		correlation := ctx.createCorrelation(correlations.CreateCorrelationCommand{
 			SourceUID: writableDs,
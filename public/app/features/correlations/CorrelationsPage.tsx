@@ -28,10 +28,10 @@ import { AddCorrelationForm } from './Forms/AddCorrelationForm';
 import { EditCorrelationForm } from './Forms/EditCorrelationForm';
 import { EmptyCorrelationsCTA } from './components/EmptyCorrelationsCTA';
 import type { RemoveCorrelationParams } from './types';
-import { CorrelationData, useCorrelations } from './useCorrelations';
+import { CorrelationData, isQueryCorrelation, useCorrelations } from './useCorrelations';
 
 const sortDatasource: SortByFn<CorrelationData> = (a, b, column) =>
-  a.values[column].name.localeCompare(b.values[column].name);
+  (a.values[column]?.name ?? '').localeCompare(b.values[column]?.name ?? '');
 
 const isCorrelationsReadOnly = (correlation: CorrelationData) => correlation.provisioned;
 
@@ -131,7 +131,7 @@ export default function CorrelationsPage() {
       {
         id: 'target',
         header: t('correlations.list.target', 'Target'),
-        cell: DataSourceCell,
+        cell: TargetCell,
         sortType: sortDatasource,
       },
       { id: 'label', header: t('correlations.list.label', 'Label'), sortType: 'alphanumeric' },
@@ -248,9 +248,10 @@ function ExpendedRow({ correlation: { source, target, ...correlation }, readOnly
 
   return (
     <EditCorrelationForm
-      correlation={{ ...correlation, sourceUID: source.uid, targetUID: target.uid }}
+      correlation={{ ...correlation, sourceUID: source.uid, targetUID: target?.uid }}
       onUpdated={onUpdated}
-      readOnly={readOnly}
+      // the form only edits the targets of query correlations
+      readOnly={readOnly || !isQueryCorrelation(correlation)}
     />
   );
 }
@@ -268,9 +269,7 @@ const getDatasourceCellStyles = (theme: GrafanaTheme2) => ({
 });
 
 const DataSourceCell = memo(
-  function DataSourceCell({
-    cell: { value },
-  }: CellProps<CorrelationData, CorrelationData['source'] | CorrelationData['target']>) {
+  function DataSourceCell({ cell: { value } }: CellProps<CorrelationData, CorrelationData['source']>) {
     const styles = useStyles2(getDatasourceCellStyles);
 
     return (
@@ -285,6 +284,19 @@ const DataSourceCell = memo(
   }
 );
 
+function TargetCell(props: CellProps<CorrelationData, CorrelationData['target']>) {
+  if (props.cell.value) {
+    return <DataSourceCell {...(props as CellProps<CorrelationData, CorrelationData['source']>)} />;
+  }
+
+  switch (props.row.original.config?.type) {
+    case 'dashboard':
+      return <span>{t('correlations.list.target-dashboard', 'Dashboard')}</span>;
+    default:
+      return <span>{t('correlations.list.target-external', 'External link')}</span>;
+  }
+}
+
 const noWrap = css`
   white-space: nowrap;
 `;
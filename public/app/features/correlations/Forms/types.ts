@@ -61,6 +61,40 @@ export function getSupportedTransTypeDetails(
           helpText: 'Defines the name of the variable if the capture group is not named.',
         },
       };
+    case SupportedTransformationType.JSONPath:
+      return {
+        label: 'JSONPath',
+        value: SupportedTransformationType.JSONPath,
+        description:
+          'Field containing JSON will be parsed with a JSONPath expression, such as $.user.id. The first value found is added to the variable named by map value, or the field name.',
+        expressionDetails: {
+          show: true,
+          required: true,
+          helpText: 'Supports child members, array indexes, wildcards and filters such as [?(@.kind=="server")].',
+        },
+        mapValueDetails: {
+          show: true,
+          required: false,
+          helpText: 'Defines the name of the variable. By default it is the name of the field.',
+        },
+      };
+    case SupportedTransformationType.Label:
+      return {
+        label: 'Label',
+        value: SupportedTransformationType.Label,
+        description:
+          'The value of a label of the field, or of the field containing labels such as the labels of Loki logs, is added to a variable.',
+        expressionDetails: {
+          show: true,
+          required: true,
+          helpText: 'Name of the label.',
+        },
+        mapValueDetails: {
+          show: true,
+          required: false,
+          helpText: 'Defines the name of the variable. By default it is the name of the label.',
+        },
+      };
     default:
       return {
         label: transType,
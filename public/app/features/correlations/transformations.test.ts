@@ -0,0 +1,59 @@
+import { SupportedTransformationType } from '@grafana/data';
+
+import { evaluateJSONPath, getTransformationVars } from './transformations';
+
+describe('correlation transformations', () => {
+  describe('evaluateJSONPath', () => {
+    const value = {
+      user: { id: 'u1', 'first-name': 'Jane' },
+      items: [
+        { name: 'a', kind: 'client' },
+        { name: 'b', kind: 'server', port: 80 },
+      ],
+    };
+
+    it.each([
+      ['$.user.id', 'u1'],
+      ["$['user']['first-name']", 'Jane'],
+      ['$.items[1].name', 'b'],
+      ['$.items[-1].name', 'b'],
+      ['$.items[*].name', 'a'],
+      ['$.items.*.name', 'a'],
+      ['$.items[?(@.kind=="server")].name', 'b'],
+      ["$.items[?(@.kind != 'server')].name", 'a'],
+      ['$.items[?(@.port)].name', 'b'],
+      ['$.items[?(@.port==80)].name', 'b'],
+      ['$.user', { id: 'u1', 'first-name': 'Jane' }],
+    ])('evaluates %s', (expression, expected) => {
+      expect(evaluateJSONPath(expression, value)).toEqual(expected);
+    });
+
+    it.each(['$.missing', '$.items[5]', '$..name', '$.items[0:1]', '$.user[id'])(
+      'does not return a value for %s',
+      (expression) => {
+        expect(evaluateJSONPath(expression, value)).toBeUndefined();
+      }
+    );
+  });
+
+  describe('getTransformationVars', () => {
+    it('extracts a JSONPath value from a field containing JSON', () => {
+      const transformation = { type: SupportedTransformationType.JSONPath, expression: '$.trace.id' };
+      expect(getTransformationVars(transformation, '{"trace":{"id":"abc"}}', 'msg')).toEqual({ msg: { value: 'abc' } });
+      const mapped = getTransformationVars({ ...transformation, mapValue: 'traceId' }, { trace: { id: 'abc' } }, 'msg');
+      expect(mapped).toEqual({ traceId: { value: 'abc' } });
+      expect(getTransformationVars(transformation, 'not json', 'msg')).toEqual({});
+    });
+
+    it('extracts the value of a label of the field value or of the field', () => {
+      const transformation = { type: SupportedTransformationType.Label, expression: 'env' };
+      expect(getTransformationVars(transformation, { env: 'prod' }, 'labels')).toEqual({ env: { value: 'prod' } });
+      expect(getTransformationVars(transformation, 'a log line', 'line', { env: 'dev' })).toEqual({
+        env: { value: 'dev' },
+      });
+      const mapped = getTransformationVars({ ...transformation, mapValue: 'environment' }, '{"env":"prod"}', 'labels');
+      expect(mapped).toEqual({ environment: { value: 'prod' } });
+      expect(getTransformationVars(transformation, 'a log line', 'line')).toEqual({});
+    });
+  });
+});
@@ -1,18 +1,19 @@
 import logfmt from 'logfmt';
 
-import { ScopedVars, DataLinkTransformationConfig, SupportedTransformationType } from '@grafana/data';
+import { ScopedVars, DataLinkTransformationConfig, SupportedTransformationType, Labels } from '@grafana/data';
 import { safeStringifyValue } from 'app/core/utils/explore';
 
 export const getTransformationVars = (
   transformation: DataLinkTransformationConfig,
-  fieldValue: string,
-  fieldName: string
+  fieldValue: unknown,
+  fieldName: string,
+  fieldLabels?: Labels
 ): ScopedVars => {
   let transformationScopedVars: ScopedVars = {};
-  let transformVal: { [key: string]: string | boolean | null | undefined } = {};
+  let transformVal: { [key: string]: unknown } = {};
+  const stringFieldVal = typeof fieldValue === 'string' ? fieldValue : safeStringifyValue(fieldValue);
   if (transformation.type === SupportedTransformationType.Regex && transformation.expression) {
     const regexp = new RegExp(transformation.expression, 'gi');
-    const stringFieldVal = typeof fieldValue === 'string' ? fieldValue : safeStringifyValue(fieldValue);
 
     const matches = stringFieldVal.matchAll(regexp);
     for (const match of matches) {
@@ -23,7 +24,19 @@ export const getTransformationVars = (
       }
     }
   } else if (transformation.type === SupportedTransformationType.Logfmt) {
-    transformVal = logfmt.parse(fieldValue);
+    transformVal = logfmt.parse(stringFieldVal);
+  } else if (transformation.type === SupportedTransformationType.JSONPath && transformation.expression) {
+    const value = evaluateJSONPath(transformation.expression, parseJSONValue(fieldValue));
+    if (value !== undefined) {
+      transformVal[transformation.mapValue || fieldName] = value;
+    }
+  } else if (transformation.type === SupportedTransformationType.Label && transformation.expression) {
+    // the labels are the value of the field, like the labels field of Loki logs, or the labels of the field
+    const labels = parseJSONValue(fieldValue);
+    const value = isObject(labels) ? labels[transformation.expression] : fieldLabels?.[transformation.expression];
+    if (value !== undefined && value !== null) {
+      transformVal[transformation.mapValue || transformation.expression] = value;
+    }
   }
 
   Object.keys(transformVal).forEach((key) => {
@@ -34,3 +47,144 @@ export const getTransformationVars = (
 
   return transformationScopedVars;
 };
+
+const isObject = (value: unknown): value is Record<string, unknown> => typeof value === 'object' && value !== null;
+
+const parseJSONValue = (value: unknown): unknown => {
+  if (typeof value !== 'string') {
+    return value;
+  }
+  try {
+    return JSON.parse(value);
+  } catch (e) {
+    return undefined;
+  }
+};
+
+type JSONPathStep =
+  | { type: 'member'; name: string }
+  | { type: 'index'; index: number }
+  | { type: 'wildcard' }
+  | { type: 'filter'; path: string[]; operator?: '==' | '!='; value?: unknown };
+
+const memberRegex = /^[\w$-]+/;
+const filterRegex = /^@((?:\.[\w$-]+)+)\s*(?:(==|!=)\s*(.+))?$/;
+
+/**
+ * Returns the first value of a JSONPath expression, such as $.user.id, $.items[0].name or
+ * $.spans[?(@.kind=="server")].id. Child members, array indexes, wildcards and filters comparing a member with
+ * == or != are supported, other expressions don't return a value.
+ */
+export const evaluateJSONPath = (expression: string, value: unknown): unknown => {
+  const steps = parseJSONPath(expression);
+  if (!steps) {
+    return undefined;
+  }
+
+  let nodes: unknown[] = [value];
+  for (const step of steps) {
+    nodes = nodes.flatMap((node) => applyJSONPathStep(step, node));
+  }
+  return nodes[0];
+};
+
+const applyJSONPathStep = (step: JSONPathStep, node: unknown): unknown[] => {
+  if (!isObject(node)) {
+    return [];
+  }
+  switch (step.type) {
+    case 'member':
+      return node[step.name] === undefined ? [] : [node[step.name]];
+    case 'index': {
+      if (!Array.isArray(node)) {
+        return [];
+      }
+      const item = node[step.index < 0 ? node.length + step.index : step.index];
+      return item === undefined ? [] : [item];
+    }
+    case 'wildcard':
+      return Object.values(node);
+    case 'filter':
+      return Object.values(node).filter((item) => {
+        const value = step.path.reduce<unknown>((v, name) => (isObject(v) ? v[name] : undefined), item);
+        switch (step.operator) {
+          case '==':
+            return value === step.value;
+          case '!=':
+            return value !== undefined && value !== step.value;
+          default:
+            return value !== undefined;
+        }
+      });
+  }
+};
+
+const parseJSONPath = (expression: string): JSONPathStep[] | undefined => {
+  const steps: JSONPathStep[] = [];
+  let rest = expression.trim().replace(/^\$/, '');
+  while (rest.length > 0) {
+    if (rest.startsWith('.*')) {
+      steps.push({ type: 'wildcard' });
+      rest = rest.slice(2);
+    } else if (rest.startsWith('.')) {
+      const name = rest.slice(1).match(memberRegex)?.[0];
+      if (!name) {
+        return undefined;
+      }
+      steps.push({ type: 'member', name });
+      rest = rest.slice(1 + name.length);
+    } else if (rest.startsWith('[?(')) {
+      const end = rest.indexOf(')]');
+      const filter = end < 0 ? undefined : parseJSONPathFilter(rest.slice(3, end).trim());
+      if (!filter) {
+        return undefined;
+      }
+      steps.push(filter);
+      rest = rest.slice(end + 2);
+    } else if (rest.startsWith('[')) {
+      const end = rest.indexOf(']');
+      const step = end < 0 ? undefined : parseJSONPathSubscript(rest.slice(1, end).trim());
+      if (!step) {
+        return undefined;
+      }
+      steps.push(step);
+      rest = rest.slice(end + 1);
+    } else {
+      return undefined;
+    }
+  }
+  return steps;
+};
+
+const parseJSONPathSubscript = (subscript: string): JSONPathStep | undefined => {
+  if (subscript === '*') {
+    return { type: 'wildcard' };
+  }
+  if (/^-?\d+$/.test(subscript)) {
+    return { type: 'index', index: parseInt(subscript, 10) };
+  }
+  const quoted = subscript.match(/^'(.*)'$|^"(.*)"$/);
+  if (quoted) {
+    return { type: 'member', name: quoted[1] ?? quoted[2] };
+  }
+  return undefined;
+};
+
+const parseJSONPathFilter = (filter: string): JSONPathStep | undefined => {
+  const match = filter.match(filterRegex);
+  if (!match) {
+    return undefined;
+  }
+  const path = match[1].slice(1).split('.');
+  const operator = match[2] === '==' || match[2] === '!=' ? match[2] : undefined;
+  if (!operator) {
+    return { type: 'filter', path };
+  }
+  try {
+    // single quoted strings are compared like double quoted ones
+    const literal = match[3].trim().replace(/^'(.*)'$/, (_, s: string) => JSON.stringify(s));
+    return { type: 'filter', path, operator, value: JSON.parse(literal) };
+  } catch (e) {
+    return undefined;
+  }
+};
@@ -26,7 +26,11 @@ export interface RemoveCorrelationResponse {
   message: string;
 }
 
-type CorrelationConfigType = 'query';
+/**
+ * query correlations run a query of the target data source, external correlations open a URL and dashboard
+ * correlations open a dashboard
+ */
+export type CorrelationConfigType = 'query' | 'external' | 'dashboard';
 
 export interface CorrelationConfig {
   field: string;
@@ -35,10 +39,22 @@ export interface CorrelationConfig {
   transformations?: DataLinkTransformationConfig[];
 }
 
+/** Target of external correlations, the variables can be used in the path and the query of the URL */
+export interface ExternalCorrelationTarget {
+  url: string;
+}
+
+/** Target of dashboard correlations, the variables set the values of the variables of the dashboard */
+export interface DashboardCorrelationTarget {
+  dashboardUID: string;
+  variables?: Record<string, string>;
+}
+
 export interface Correlation {
   uid: string;
   sourceUID: string;
-  targetUID: string;
+  // only query correlations have a target data source
+  targetUID?: string;
   label?: string;
   description?: string;
   provisioned: boolean;
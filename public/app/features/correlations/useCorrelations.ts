@@ -25,7 +25,8 @@ export interface CorrelationsResponse {
 
 export interface CorrelationData extends Omit<Correlation, 'sourceUID' | 'targetUID'> {
   source: DataSourceInstanceSettings;
-  target: DataSourceInstanceSettings;
+  // only query correlations have a target data source
+  target?: DataSourceInstanceSettings;
 }
 
 export interface CorrelationsData {
@@ -35,20 +36,28 @@ export interface CorrelationsData {
   totalCount: number;
 }
 
+export const isQueryCorrelation = (correlation: Pick<Correlation, 'config'>) =>
+  !correlation.config?.type || correlation.config.type === 'query';
+
 const toEnrichedCorrelationData = ({
   sourceUID,
   targetUID,
   ...correlation
 }: Correlation): CorrelationData | undefined => {
   const sourceDatasource = getDataSourceSrv().getInstanceSettings(sourceUID);
-  const targetDatasource = getDataSourceSrv().getInstanceSettings(targetUID);
+  if (!sourceDatasource || sourceDatasource.uid === undefined) {
+    return undefined;
+  }
 
-  if (
-    sourceDatasource &&
-    sourceDatasource?.uid !== undefined &&
-    targetDatasource &&
-    targetDatasource.uid !== undefined
-  ) {
+  if (!isQueryCorrelation(correlation)) {
+    return {
+      ...correlation,
+      source: sourceDatasource,
+    };
+  }
+
+  const targetDatasource = getDataSourceSrv().getInstanceSettings(targetUID);
+  if (targetDatasource && targetDatasource.uid !== undefined) {
     return {
       ...correlation,
       source: sourceDatasource,
@@ -1,4 +1,11 @@
-import { DataFrame, DataSourceInstanceSettings, FieldType, toDataFrame } from '@grafana/data';
+import {
+  DataFrame,
+  DataLinkConfigOrigin,
+  DataSourceInstanceSettings,
+  FieldType,
+  SupportedTransformationType,
+  toDataFrame,
+} from '@grafana/data';
 
 import { CorrelationData } from './useCorrelations';
 import { attachCorrelationsToDataFrames } from './utils';
@@ -66,6 +73,52 @@ describe('correlations utils', () => {
     // Prometheus value (linked to Elastic)
     expect(testDataFrames[2].fields[0].config.links).toHaveLength(1);
   });
+
+  it('attaches external and dashboard correlations as links to their URL', () => {
+    const { testDataFrames, refIdMap, loki } = setup();
+    const transformations = [{ type: SupportedTransformationType.Label, expression: 'service' }];
+    const correlations: CorrelationData[] = [
+      {
+        uid: 'loki-to-incidents',
+        label: 'Incidents',
+        source: loki,
+        config: {
+          type: 'external',
+          field: 'line',
+          target: { url: 'https://incidents.example.com/services/${service}' },
+          transformations,
+        },
+        provisioned: false,
+      },
+      {
+        uid: 'loki-to-dashboard',
+        source: loki,
+        config: {
+          type: 'dashboard',
+          field: 'line',
+          target: { dashboardUID: 'service-overview', variables: { service: '${service}', 'my env': 'prod' } },
+        },
+        provisioned: false,
+      },
+    ];
+    attachCorrelationsToDataFrames(testDataFrames, correlations, refIdMap);
+
+    expect(testDataFrames[0].fields[0].config.links).toEqual([
+      {
+        title: 'Incidents',
+        url: 'https://incidents.example.com/services/${service}',
+        targetBlank: true,
+        origin: DataLinkConfigOrigin.Correlations,
+        transformations,
+      },
+      {
+        title: 'service-overview',
+        url: '/d/service-overview?var-service=${service}&var-my%20env=prod',
+        origin: DataLinkConfigOrigin.Correlations,
+        transformations: undefined,
+      },
+    ]);
+  });
 });
 
 function setup() {
@@ -131,5 +184,5 @@ function setup() {
     },
   ];
 
-  return { testDataFrames, correlations, refIdMap, prometheus, elastic };
+  return { testDataFrames, correlations, refIdMap, loki, prometheus, elastic };
 }
@@ -1,12 +1,17 @@
 import { lastValueFrom } from 'rxjs';
 
-import { DataFrame, DataLinkConfigOrigin } from '@grafana/data';
+import { DataFrame, DataLink, DataLinkConfigOrigin } from '@grafana/data';
 import { getBackendSrv, getDataSourceSrv } from '@grafana/runtime';
 import { ExploreItemState } from 'app/types';
 
 import { formatValueName } from '../explore/PrometheusListView/ItemLabels';
 
-import { CreateCorrelationParams, CreateCorrelationResponse } from './types';
+import {
+  CreateCorrelationParams,
+  CreateCorrelationResponse,
+  DashboardCorrelationTarget,
+  ExternalCorrelationTarget,
+} from './types';
 import {
   CorrelationData,
   CorrelationsData,
@@ -54,22 +59,74 @@ const decorateDataFrameWithInternalDataLinks = (dataFrame: DataFrame, correlatio
     field.config.links = field.config.links?.filter((link) => link.origin !== DataLinkConfigOrigin.Correlations) || [];
     correlations.map((correlation) => {
       if (correlation.config?.field === field.name) {
-        field.config.links!.push({
-          internal: {
-            query: correlation.config?.target,
-            datasourceUid: correlation.target.uid,
-            datasourceName: correlation.target.name,
-            transformations: correlation.config?.transformations,
-          },
-          url: '',
-          title: correlation.label || correlation.target.name,
-          origin: DataLinkConfigOrigin.Correlations,
-        });
+        const link = getCorrelationDataLink(correlation);
+        if (link) {
+          field.config.links!.push(link);
+        }
       }
     });
   });
 };
 
+/**
+ * Returns the data link of a correlation: query correlations are internal links, external and dashboard
+ * correlations link to their URL with the variables extracted by their transformations
+ */
+const getCorrelationDataLink = (correlation: CorrelationData): DataLink | undefined => {
+  switch (correlation.config?.type) {
+    case 'external': {
+      const target = correlation.config.target as Partial<ExternalCorrelationTarget>;
+      if (!target.url) {
+        return undefined;
+      }
+      return {
+        url: target.url,
+        title: correlation.label || '',
+        targetBlank: true,
+        origin: DataLinkConfigOrigin.Correlations,
+        transformations: correlation.config.transformations,
+      };
+    }
+    case 'dashboard': {
+      const target = correlation.config.target as Partial<DashboardCorrelationTarget>;
+      if (!target.dashboardUID) {
+        return undefined;
+      }
+      return {
+        url: getDashboardCorrelationUrl(target.dashboardUID, target.variables),
+        title: correlation.label || target.dashboardUID,
+        origin: DataLinkConfigOrigin.Correlations,
+        transformations: correlation.config.transformations,
+      };
+    }
+    default:
+      if (!correlation.target) {
+        return undefined;
+      }
+      return {
+        internal: {
+          query: correlation.config?.target,
+          datasourceUid: correlation.target.uid,
+          datasourceName: correlation.target.name,
+          transformations: correlation.config?.transformations,
+        },
+        url: '',
+        title: correlation.label || correlation.target.name,
+        origin: DataLinkConfigOrigin.Correlations,
+      };
+  }
+};
+
+/**
+ * Returns the URL of a dashboard with the values of its variables, which are interpolated with the variables
+ * of the correlation when the link is created
+ */
+export const getDashboardCorrelationUrl = (dashboardUID: string, variables: Record<string, string> = {}) => {
+  const params = Object.entries(variables).map(([name, value]) => `var-${encodeURIComponent(name)}=${value}`);
+  const url = `/d/${encodeURIComponent(dashboardUID)}`;
+  return params.length > 0 ? `${url}?${params.join('&')}` : url;
+};
+
 export const getCorrelationsBySourceUIDs = async (sourceUIDs: string[]): Promise<CorrelationsData> => {
   return lastValueFrom(
     getBackendSrv().fetch<CorrelationsResponse>({
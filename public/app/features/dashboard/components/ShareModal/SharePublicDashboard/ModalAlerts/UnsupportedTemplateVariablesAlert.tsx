@@ -8,10 +8,10 @@ const selectors = e2eSelectors.pages.ShareDashboardModal.PublicDashboard;
 export const UnsupportedTemplateVariablesAlert = () => (
   <Alert
     severity="warning"
-    title="Template variables are not supported"
+    title="Some template variables are not supported"
     data-testid={selectors.TemplateVariablesWarningAlert}
     bottomSpacing={0}
   >
-    This public dashboard may not work since it uses template variables
+    This public dashboard may not work since it uses template variables other than custom, constant, interval and query variables
   </Alert>
 );
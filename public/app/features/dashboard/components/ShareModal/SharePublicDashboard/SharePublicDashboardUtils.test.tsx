@@ -47,6 +47,12 @@ describe('dashboardHasTemplateVariables', () => {
     let variables: TypedVariableModel[] = ['a'];
     expect(dashboardHasTemplateVariables(variables)).toBe(true);
   });
+
+  it('false when all variables are supported', () => {
+    //@ts-ignore
+    let variables: TypedVariableModel[] = [{ type: 'custom' }, { type: 'query' }];
+    expect(dashboardHasTemplateVariables(variables)).toBe(false);
+  });
 });
 
 describe('generatePublicDashboardUrl', () => {
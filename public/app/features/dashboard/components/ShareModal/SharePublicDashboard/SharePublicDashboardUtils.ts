@@ -41,9 +41,12 @@ export interface SessionUser {
   totalDashboards: number;
 }
 
+// Template variables that public dashboards resolve on the server
+const supportedTemplateVariableTypes = ['custom', 'constant', 'interval', 'query'];
+
 // Instance methods
 export const dashboardHasTemplateVariables = (variables: TypedVariableModel[]): boolean => {
-  return variables.length > 0;
+  return variables.some((variable) => !supportedTemplateVariableTypes.includes(variable?.type));
 };
 
 export const publicDashboardPersisted = (publicDashboard?: PublicDashboard): boolean => {
@@ -3,7 +3,7 @@ import React, { useId, useState, useMemo, useEffect } from 'react';
 import Highlighter from 'react-highlight-words';
 import { useForm } from 'react-hook-form';
 
-import { DataLinkTransformationConfig, ScopedVars } from '@grafana/data';
+import { DataLinkTransformationConfig, ScopedVars, SupportedTransformationType } from '@grafana/data';
 import { Button, Field, Icon, Input, InputControl, Label, Modal, Select, Tooltip, Stack } from '@grafana/ui';
 
 import {
@@ -92,10 +92,13 @@ export const CorrelationTransformationAddModal = ({
       let isExpressionValid = false;
       if (expression !== undefined) {
         isExpressionValid = true;
-        try {
-          new RegExp(expression);
-        } catch (e) {
-          isExpressionValid = false;
+        // the expressions of the other transformations are checked when the correlation is saved
+        if (formValues.type === SupportedTransformationType.Regex) {
+          try {
+            new RegExp(expression);
+          } catch (e) {
+            isExpressionValid = false;
+          }
         }
       } else {
         isExpressionValid = !formFieldsVis.expressionDetails.show;
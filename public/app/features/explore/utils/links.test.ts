@@ -562,6 +562,107 @@ describe('explore links utils', () => {
       );
     });
 
+    it('returns internal links with jsonpath and label transformations', () => {
+      const transformationLink: DataLink = {
+        title: '',
+        url: '',
+        internal: {
+          query: { query: 'http_requests{user=${userId} service=${service}}' },
+          datasourceUid: 'uid_1',
+          datasourceName: 'test_ds',
+          transformations: [
+            {
+              type: SupportedTransformationType.JSONPath,
+              expression: '$.spans[?(@.kind=="server")].user.id',
+              mapValue: 'userId',
+            },
+            { type: SupportedTransformationType.Label, expression: 'service', field: 'labels' },
+          ],
+        },
+      };
+
+      const { field, range, dataFrame } = setup(
+        transformationLink,
+        true,
+        {
+          name: 'msg',
+          type: FieldType.string,
+          values: [
+            '{"spans":[{"kind":"client","user":{"id":"u0"}},{"kind":"server","user":{"id":"u1"}}]}',
+            '{"spans":[]}',
+          ],
+          config: {
+            links: [transformationLink],
+          },
+        },
+        [
+          {
+            name: 'labels',
+            type: FieldType.other,
+            values: [{ service: 'api' }, { service: 'web' }],
+            config: {},
+          },
+        ]
+      );
+
+      const links = [
+        getFieldLinksForExplore({ field, rowIndex: 0, range, dataFrame }),
+        getFieldLinksForExplore({ field, rowIndex: 1, range, dataFrame }),
+      ];
+      expect(links[0]).toHaveLength(1);
+      expect(links[0][0].href).toBe(
+        `/explore?left=${encodeURIComponent(
+          '{"range":{"from":"now-1h","to":"now"},"datasource":"uid_1","queries":[{"query":"http_requests{user=u1 service=api}"}]}'
+        )}`
+      );
+      // the JSONPath expression has no value
+      expect(links[1]).toHaveLength(0);
+    });
+
+    it('returns external correlation links with the variables of their transformations', () => {
+      const correlationLink: DataLink = {
+        title: 'Incidents',
+        url: 'https://incidents.example.com/services/${service}',
+        origin: DataLinkConfigOrigin.Correlations,
+        transformations: [{ type: SupportedTransformationType.Label, expression: 'service' }],
+      };
+
+      const { field, range, dataFrame } = setup(correlationLink, true, {
+        name: 'msg',
+        type: FieldType.string,
+        values: ['{"service":"api gateway"}', '{}'],
+        config: {
+          links: [correlationLink],
+        },
+      });
+      setLinkSrv({
+        getDataLinkUIModel(link: DataLink, replaceVariables: InterpolateFunction | undefined, origin) {
+          return {
+            href: replaceVariables!(link.url, undefined, 'uriencode'),
+            title: link.title,
+            target: '_blank',
+            origin: origin,
+          };
+        },
+        getAnchorInfo(link) {
+          return { ...link };
+        },
+        getLinkUrl(link) {
+          return link.url;
+        },
+      });
+
+      const links = [
+        getFieldLinksForExplore({ field, rowIndex: 0, range, dataFrame }),
+        getFieldLinksForExplore({ field, rowIndex: 1, range, dataFrame }),
+      ];
+      expect(links[0]).toHaveLength(1);
+      expect(links[0][0].href).toBe('https://incidents.example.com/services/api%20gateway');
+      expect(links[0][0].title).toBe('Incidents');
+      // correlations are only shown when all their variables have values
+      expect(links[1]).toHaveLength(0);
+    });
+
     it('returns internal links for non-existing fields accessed with __data.fields', () => {
       const { field, range, dataFrame } = setup({
         title: '',
@@ -41,8 +41,8 @@ const DATA_LINK_FILTERS: DataLinkFilter[] = [dataLinkHasRequiredPermissionsFilte
  * This extension of the LinkModel was done to support correlations, which need the variables' names
  * and values split out for display purposes
  *
- * Correlations are internal links only so the variables property will always be defined (but possibly empty)
- * for internal links and undefined for non-internal links
+ * The variables property is always defined (but possibly empty) for internal links and undefined for
+ * non-internal links
  */
 export interface ExploreFieldLinkModel extends LinkModel<Field> {
   variables?: VariableInterpolation[];
@@ -61,7 +61,8 @@ export const exploreDataLinkPostProcessorFactory = (
     const { field, dataLinkScopedVars: vars, frame: dataFrame, link, linkModel } = options;
     const { valueRowIndex: rowIndex } = options.config;
 
-    if (!link.internal || rowIndex === undefined) {
+    // correlations that are not internal links have transformations extracting their variables
+    if ((!link.internal && !link.transformations) || rowIndex === undefined) {
       return linkModel;
     }
 
@@ -155,9 +156,38 @@ export const getFieldLinksForExplore = (options: {
     });
 
     const fieldLinks = links.map((link) => {
+      let linkSpecificVars: ScopedVars = {};
+      const transformations = link.internal?.transformations ?? link.transformations;
+      if (transformations) {
+        transformations.forEach((transformation) => {
+          const transformField = transformation.field
+            ? dataFrame?.fields.find((field) => field.name === transformation.field)
+            : field;
+
+          linkSpecificVars = {
+            ...linkSpecificVars,
+            ...getTransformationVars(
+              transformation,
+              transformField?.values[rowIndex],
+              field.name,
+              transformField?.labels
+            ),
+          };
+        });
+      }
+
       if (!link.internal) {
-        const replace: InterpolateFunction = (value, vars) =>
-          getTemplateSrv().replace(value, { ...vars, ...scopedVars });
+        const allVars = { ...scopedVars, ...linkSpecificVars };
+        // like internal links, correlations are only shown when all their variables have values
+        if (
+          link.origin === DataLinkConfigOrigin.Correlations &&
+          !getVariableUsageInfo(link, allVars).allVariablesDefined
+        ) {
+          return undefined;
+        }
+
+        const replace: InterpolateFunction = (value, vars, format) =>
+          getTemplateSrv().replace(value, { ...vars, ...allVars }, format);
 
         const linkModel = getLinkSrv().getDataLinkUIModel(link, replace, field);
         if (!linkModel.title) {
@@ -165,25 +195,7 @@ export const getFieldLinksForExplore = (options: {
         }
         return linkModel;
       } else {
-        let internalLinkSpecificVars: ScopedVars = {};
-        if (link.internal?.transformations) {
-          link.internal?.transformations.forEach((transformation) => {
-            let fieldValue;
-            if (transformation.field) {
-              const transformField = dataFrame?.fields.find((field) => field.name === transformation.field);
-              fieldValue = transformField?.values[rowIndex];
-            } else {
-              fieldValue = field.values[rowIndex];
-            }
-
-            internalLinkSpecificVars = {
-              ...internalLinkSpecificVars,
-              ...getTransformationVars(transformation, fieldValue, field.name),
-            };
-          });
-        }
-
-        const allVars = { ...scopedVars, ...internalLinkSpecificVars };
+        const allVars = { ...scopedVars, ...linkSpecificVars };
         const variableData = getVariableUsageInfo(link, allVars);
         let variables: VariableInterpolation[] = [];
 
@@ -1,11 +1,11 @@
 import { Location } from 'history';
 import { pickBy } from 'lodash';
 
-import { locationUtil, urlUtil, rangeUtil } from '@grafana/data';
+import { locationUtil, urlUtil, rangeUtil, UrlQueryMap } from '@grafana/data';
 import { locationService } from '@grafana/runtime';
 
 import { getPlaylistAPI, loadDashboards } from './api';
-import { PlaylistAPI } from './types';
+import { PlaylistAPI, PlaylistItem } from './types';
 
 export const queryParamsToPreserve: { [key: string]: boolean } = {
   kiosk: true,
@@ -13,9 +13,15 @@ export const queryParamsToPreserve: { [key: string]: boolean } = {
   orgId: true,
 };
 
+interface PlaylistEntry {
+  url: string;
+  interval: number;
+  params: UrlQueryMap;
+}
+
 export class PlaylistSrv {
   private nextTimeoutId: ReturnType<typeof setTimeout> | undefined;
-  private urls: string[] = []; // the URLs we need to load
+  private entries: PlaylistEntry[] = []; // the URLs we need to load
   private index = 0;
   private declare interval: number;
   private declare startUrl: string;
@@ -34,7 +40,7 @@ export class PlaylistSrv {
   next() {
     clearTimeout(this.nextTimeoutId);
 
-    const playedAllDashboards = this.index > this.urls.length - 1;
+    const playedAllDashboards = this.index > this.entries.length - 1;
     if (playedAllDashboards) {
       this.numberOfLoops++;
 
@@ -47,16 +53,16 @@ export class PlaylistSrv {
       this.index = 0;
     }
 
-    const url = this.urls[this.index];
+    const entry = this.entries[this.index];
     const queryParams = locationService.getSearchObject();
     const filteredParams = pickBy(queryParams, (value: unknown, key: string) => queryParamsToPreserve[key]);
-    const nextDashboardUrl = locationUtil.stripBaseFromUrl(url);
+    const nextDashboardUrl = locationUtil.stripBaseFromUrl(entry.url);
 
     this.index++;
     this.validPlaylistUrl = nextDashboardUrl;
-    this.nextTimeoutId = setTimeout(() => this.next(), this.interval);
+    this.nextTimeoutId = setTimeout(() => this.next(), entry.interval);
 
-    locationService.push(nextDashboardUrl + '?' + urlUtil.toUrlParams(filteredParams));
+    locationService.push(nextDashboardUrl + '?' + urlUtil.toUrlParams({ ...entry.params, ...filteredParams }));
   }
 
   prev() {
@@ -81,7 +87,7 @@ export class PlaylistSrv {
     // setup location tracking
     this.locationListenerUnsub = locationService.getHistory().listen(this.locationUpdated);
 
-    const urls: string[] = [];
+    const entries: PlaylistEntry[] = [];
     let playlist = await this.api.getPlaylist(playlistUid);
     if (!playlist.items?.length) {
       // alert
@@ -92,17 +98,19 @@ export class PlaylistSrv {
     const items = await loadDashboards(playlist.items);
     for (const item of items) {
       if (item.dashboards) {
+        const interval = item.duration ? rangeUtil.intervalToMs(item.duration) : this.interval;
+        const params = itemParams(item);
         for (const dash of item.dashboards) {
-          urls.push(dash.url);
+          entries.push({ url: dash.url, interval, params });
         }
       }
     }
 
-    if (!urls.length) {
+    if (!entries.length) {
       // alert... not found, etc
       return;
     }
-    this.urls = urls;
+    this.entries = entries;
     this.isPlaying = true;
     this.next();
     return;
@@ -130,4 +138,19 @@ export class PlaylistSrv {
   }
 }
 
+// itemParams returns the time range and variable overrides of an item as dashboard URL parameters
+function itemParams(item: PlaylistItem): UrlQueryMap {
+  const params: UrlQueryMap = {};
+  if (item.timeFrom) {
+    params.from = item.timeFrom;
+  }
+  if (item.timeTo) {
+    params.to = item.timeTo;
+  }
+  for (const [name, values] of Object.entries(item.variables ?? {})) {
+    params[`var-${name}`] = values;
+  }
+  return params;
+}
+
 export const playlistSrv = new PlaylistSrv();
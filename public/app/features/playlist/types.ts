@@ -56,6 +56,22 @@ export interface PlaylistItem {
    */
   value: string;
 
+  /**
+   * Duration overrides the interval of the playlist for this item.
+   */
+  duration?: string;
+
+  /**
+   * TimeFrom and TimeTo override the time range of the dashboards of this item.
+   */
+  timeFrom?: string;
+  timeTo?: string;
+
+  /**
+   * Variables override template variable values of the dashboards of this item.
+   */
+  variables?: Record<string, string[]>;
+
   /**
    * Loaded at runtime by the frontend.
    *